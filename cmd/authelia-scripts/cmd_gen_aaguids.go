@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// GenAAGUIDs refreshes the names of the authenticator models embedded in Authelia with the descriptions of the FIDO
+// Metadata Service. The models which are not listed by the service, such as most passkey providers, are kept.
+func GenAAGUIDs(cobraCmd *cobra.Command, args []string) {
+	models := map[string]string{}
+
+	data, err := ioutil.ReadFile(aaguidsFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = json.Unmarshal(data, &models); err != nil {
+		log.Fatalf("Unable to parse %s: %s", aaguidsFile, err)
+	}
+
+	entries, err := loadFIDOMetadataEntries()
+	if err != nil {
+		log.Fatalf("Unable to load the FIDO Metadata Service blob: %s", err)
+	}
+
+	count := 0
+
+	for _, entry := range entries {
+		if entry.AAGUID == "" || entry.MetadataStatement.Description == "" {
+			continue
+		}
+
+		models[strings.ToLower(entry.AAGUID)] = entry.MetadataStatement.Description
+		count++
+	}
+
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	// The keys of the maps are sorted by the encoder which keeps the diffs of the file readable.
+	if err = encoder.Encode(models); err != nil {
+		log.Fatal(err)
+	}
+
+	if err = ioutil.WriteFile(aaguidsFile, buffer.Bytes(), 0644); err != nil { //nolint:gosec
+		log.Fatal(err)
+	}
+
+	log.Infof("Updated %d authenticator models from the FIDO Metadata Service, %d models in total", count, len(models))
+}
+
+type fidoMetadataEntry struct {
+	AAGUID            string `json:"aaguid"`
+	MetadataStatement struct {
+		Description string `json:"description"`
+	} `json:"metadataStatement"`
+}
+
+// loadFIDOMetadataEntries downloads the blob of the FIDO Metadata Service and returns its entries. The signature of the
+// blob is not verified as the descriptions are only used to name the authenticators and the generated file is reviewed.
+func loadFIDOMetadataEntries() ([]fidoMetadataEntry, error) {
+	resp, err := http.Get(fidoMetadataServiceURL) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	blob, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(strings.TrimSpace(string(blob)), ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("the blob is not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the payload of the blob: %w", err)
+	}
+
+	var metadata struct {
+		Entries []fidoMetadataEntry `json:"entries"`
+	}
+
+	if err = json.Unmarshal(payload, &metadata); err != nil {
+		return nil, fmt.Errorf("unable to parse the payload of the blob: %w", err)
+	}
+
+	return metadata.Entries, nil
+}
@@ -18,3 +18,6 @@ const masterTag = "master"
 const stringFalse = "false"
 const stringTrue = "true"
 const webDirectory = "web"
+
+const aaguidsFile = "internal/webauthn/aaguids.json"
+const fidoMetadataServiceURL = "https://mds3.fidoalliance.org/"
@@ -46,6 +46,11 @@ var Commands = []AutheliaCommandDefinition{
 		Short: "Clean build artifacts",
 		Func:  Clean,
 	},
+	{
+		Name:  "gen-aaguids",
+		Short: "Refresh the names of the authenticator models from the FIDO Metadata Service",
+		Func:  GenAAGUIDs,
+	},
 	{
 		Name:        "docker",
 		Short:       "Commands related to building and publishing docker image",
@@ -11,6 +11,7 @@ import (
 	"github.com/authelia/authelia/internal/authorization"
 	"github.com/authelia/authelia/internal/commands"
 	"github.com/authelia/authelia/internal/configuration"
+	"github.com/authelia/authelia/internal/geoip"
 	"github.com/authelia/authelia/internal/logging"
 	"github.com/authelia/authelia/internal/middlewares"
 	"github.com/authelia/authelia/internal/notification"
@@ -72,19 +73,23 @@ func startServer() {
 		logger.Info("===> Authelia is running in development mode. <===")
 	}
 
-	var storageProvider storage.Provider
-
-	switch {
-	case config.Storage.PostgreSQL != nil:
-		storageProvider = storage.NewPostgreSQLProvider(*config.Storage.PostgreSQL)
-	case config.Storage.MySQL != nil:
-		storageProvider = storage.NewMySQLProvider(*config.Storage.MySQL)
-	case config.Storage.Local != nil:
-		storageProvider = storage.NewSQLiteProvider(config.Storage.Local.Path)
-	default:
+	storageProvider := storage.NewSQLProvider(config.Storage)
+	if storageProvider == nil {
 		logger.Fatalf("Unrecognized storage backend")
 	}
 
+	storageProvider.StartAuthenticationLogsPruning()
+	storageProvider.StartWebAuthnCredentialsPurge()
+	storageProvider.StartMaintenance()
+
+	clock := utils.RealClock{}
+
+	var provider storage.Provider = storageProvider
+
+	if cacheTTL, _ := utils.ParseDurationString(config.Storage.Cache.TTL); cacheTTL > 0 {
+		provider = storage.NewCachedProvider(storageProvider, cacheTTL, clock)
+	}
+
 	var userProvider authentication.UserProvider
 
 	switch {
@@ -114,24 +119,32 @@ func startServer() {
 		}
 	}
 
-	clock := utils.RealClock{}
 	authorizer := authorization.NewAuthorizer(config.AccessControl)
 	sessionProvider := session.NewProvider(config.Session, autheliaCertPool)
-	regulator := regulation.NewRegulator(config.Regulation, storageProvider, clock)
+	regulator := regulation.NewRegulator(config.Regulation, provider, clock)
 	oidcProvider, err := oidc.NewOpenIDConnectProvider(config.IdentityProviders.OIDC)
 
 	if err != nil {
 		logger.Fatalf("Error initializing OpenID Connect Provider: %+v", err)
 	}
 
+	var geoIP *geoip.Database
+
+	if config.GeoIP != nil {
+		if geoIP, err = geoip.NewDatabase(config.GeoIP.Database); err != nil {
+			logger.Fatalf("Unable to load the GeoIP database %s: %s", config.GeoIP.Database, err)
+		}
+	}
+
 	providers := middlewares.Providers{
 		Authorizer:      authorizer,
 		UserProvider:    userProvider,
 		Regulator:       regulator,
 		OpenIDConnect:   oidcProvider,
-		StorageProvider: storageProvider,
+		StorageProvider: provider,
 		Notifier:        notifier,
 		SessionProvider: sessionProvider,
+		GeoIP:           geoIP,
 	}
 
 	server.StartServer(*config, providers)
@@ -158,7 +171,7 @@ func main() {
 
 	rootCmd.AddCommand(versionCmd, commands.HashPasswordCmd,
 		commands.ValidateConfigCmd, commands.CertificatesCmd,
-		commands.RSACmd)
+		commands.RSACmd, commands.StorageCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		logger.Fatal(err)
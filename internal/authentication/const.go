@@ -23,10 +23,20 @@ const (
 	U2F = "u2f"
 	// Push Method using Duo application to receive push notifications.
 	Push = "mobile_push"
+	// Email Method using one-time codes sent to the email address of the user.
+	Email = "email"
+	// PushApproval Method using approval requests pushed to a webhook like ntfy, Gotify or a custom mobile application.
+	PushApproval = "push_approval"
+	// PrivacyIDEA Method using the one-time passwords and push tokens managed by a privacyIDEA server.
+	PrivacyIDEA = "privacyidea"
+	// HOTP Method using the counter-based one-time passwords of legacy hardware tokens.
+	HOTP = "hotp"
+	// YubiKeyOTP Method using the Yubico OTPs typed by YubiKeys, validated by YubiCloud or a self-hosted server.
+	YubiKeyOTP = "yubikey_otp"
 )
 
 // PossibleMethods is the set of all possible 2FA methods.
-var PossibleMethods = []string{TOTP, U2F, Push}
+var PossibleMethods = []string{TOTP, U2F, Push, Email, PushApproval, PrivacyIDEA, HOTP, YubiKeyOTP}
 
 // CryptAlgo the crypt representation of an algorithm used in the prefix of the hash.
 type CryptAlgo string
@@ -28,6 +28,8 @@ type UserDetailsModel struct {
 	DisplayName    string   `yaml:"displayname" valid:"required"`
 	Email          string   `yaml:"email"`
 	Groups         []string `yaml:"groups"`
+	// The additional attributes of the user.
+	Extra map[string]string `yaml:"extra,omitempty"`
 }
 
 // DatabaseModel is the model of users file database.
@@ -157,11 +159,22 @@ func (p *FileUserProvider) CheckUserPassword(username string, password string) (
 // GetDetails retrieve the groups a user belongs to.
 func (p *FileUserProvider) GetDetails(username string) (*UserDetails, error) {
 	if details, ok := p.database.Users[username]; ok {
+		var attributes map[string][]string
+
+		if len(details.Extra) != 0 {
+			attributes = make(map[string][]string, len(details.Extra))
+
+			for name, value := range details.Extra {
+				attributes[name] = []string{value}
+			}
+		}
+
 		return &UserDetails{
 			Username:    username,
 			DisplayName: details.DisplayName,
 			Groups:      details.Groups,
 			Emails:      []string{details.Email},
+			Attributes:  attributes,
 		}, nil
 	}
 
@@ -130,6 +130,18 @@ func TestShouldRetrieveUserDetails(t *testing.T) {
 		assert.Equal(t, details.Username, "john")
 		assert.Equal(t, details.Emails, []string{"john.doe@authelia.com"})
 		assert.Equal(t, details.Groups, []string{"admins", "dev"})
+		assert.Nil(t, details.Attributes)
+	})
+}
+
+func TestShouldRetrieveUserExtraAttributes(t *testing.T) {
+	WithDatabase(UserDatabaseContent, func(path string) {
+		config := DefaultFileAuthenticationBackendConfiguration
+		config.Path = path
+		provider := NewFileUserProvider(&config)
+		details, err := provider.GetDetails("bob")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string][]string{"department": {"Music"}}, details.Attributes)
 	})
 }
 
@@ -297,6 +309,8 @@ users:
     email: bob.dylan@authelia.com
     groups:
       - dev
+    extra:
+      department: Music
 
   james:
     displayname: "James Dean"
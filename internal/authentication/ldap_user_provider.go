@@ -140,6 +140,7 @@ type ldapUserProfile struct {
 	Emails      []string
 	DisplayName string
 	Username    string
+	Attributes  map[string][]string
 }
 
 func (p *LDAPUserProvider) resolveUsersFilter(userFilter string, inputUsername string) string {
@@ -160,6 +161,7 @@ func (p *LDAPUserProvider) getUserProfile(conn LDAPConnection, inputUsername str
 		p.configuration.DisplayNameAttribute,
 		p.configuration.MailAttribute,
 		p.configuration.UsernameAttribute}
+	attributes = append(attributes, p.configuration.ExtraAttributes...)
 
 	// Search for the given username.
 	searchRequest := ldap.NewSearchRequest(
@@ -201,6 +203,18 @@ func (p *LDAPUserProvider) getUserProfile(conn LDAPConnection, inputUsername str
 
 			userProfile.Username = attr.Values[0]
 		}
+
+		for _, extra := range p.configuration.ExtraAttributes {
+			if attr.Name != extra {
+				continue
+			}
+
+			if userProfile.Attributes == nil {
+				userProfile.Attributes = make(map[string][]string)
+			}
+
+			userProfile.Attributes[extra] = attr.Values
+		}
 	}
 
 	if userProfile.DN == "" {
@@ -272,6 +286,7 @@ func (p *LDAPUserProvider) GetDetails(inputUsername string) (*UserDetails, error
 		DisplayName: profile.DisplayName,
 		Emails:      profile.Emails,
 		Groups:      groups,
+		Attributes:  profile.Attributes,
 	}, nil
 }
 
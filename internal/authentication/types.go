@@ -6,4 +6,6 @@ type UserDetails struct {
 	DisplayName string
 	Emails      []string
 	Groups      []string
+	// The additional attributes of the user, such as the extra attributes of LDAP.
+	Attributes map[string][]string
 }
@@ -2,6 +2,7 @@ package authorization
 
 import (
 	"net"
+	"time"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/utils"
@@ -20,7 +21,7 @@ func NewAccessControlRules(config schema.AccessControlConfiguration) (rules []*A
 
 // NewAccessControlRule parses a schema ACL and generates an internal ACL.
 func NewAccessControlRule(pos int, rule schema.ACLRule, networksMap map[string][]*net.IPNet, networksCacheMap map[string]*net.IPNet) *AccessControlRule {
-	return &AccessControlRule{
+	acr := &AccessControlRule{
 		Position:  pos,
 		Domains:   schemaDomainsToACL(rule.Domains),
 		Resources: schemaResourcesToACL(rule.Resources),
@@ -28,7 +29,14 @@ func NewAccessControlRule(pos int, rule schema.ACLRule, networksMap map[string][
 		Networks:  schemaNetworksToACL(rule.Networks, networksMap, networksCacheMap),
 		Subjects:  schemaSubjectsToACL(rule.Subjects),
 		Policy:    PolicyToLevel(rule.Policy),
+
+		SecondFactorMethods: rule.SecondFactorMethods,
 	}
+
+	// Ignore the error as it will be handled by validator.
+	acr.MaxAuthenticationAge, _ = utils.ParseDurationString(rule.MaxAuthenticationAge)
+
+	return acr
 }
 
 // AccessControlRule controls and represents an ACL internally.
@@ -40,6 +48,12 @@ type AccessControlRule struct {
 	Networks  []*net.IPNet
 	Subjects  []AccessControlSubjects
 	Policy    Level
+
+	// MaxAuthenticationAge is the maximum time elapsed since the factor required by the policy was performed.
+	MaxAuthenticationAge time.Duration
+
+	// SecondFactorMethods are the second factor methods one of which must have been performed, any when empty.
+	SecondFactorMethods []string
 }
 
 // IsMatch returns true if all elements of an AccessControlRule match the object and subject.
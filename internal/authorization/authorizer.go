@@ -1,6 +1,9 @@
 package authorization
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/sirupsen/logrus"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
@@ -11,20 +14,49 @@ import (
 type Authorizer struct {
 	defaultPolicy Level
 	rules         []*AccessControlRule
+	shadow        *Authorizer
+	opa           *opaClient
 }
 
 // NewAuthorizer create an instance of authorizer with a given access control configuration.
 func NewAuthorizer(configuration schema.AccessControlConfiguration) *Authorizer {
+	var shadow *Authorizer
+
+	if configuration.Shadow != nil {
+		shadow = newShadowAuthorizer(configuration)
+	}
+
 	if logging.Logger().IsLevelEnabled(logrus.TraceLevel) {
 		return &Authorizer{
 			defaultPolicy: PolicyToLevel(configuration.DefaultPolicy),
 			rules:         NewAccessControlRules(configuration),
+			shadow:        shadow,
+			opa:           newOPAClient(configuration.OPA),
 		}
 	}
 
 	return &Authorizer{
 		defaultPolicy: PolicyToLevel(configuration.DefaultPolicy),
 		rules:         NewAccessControlRules(configuration),
+		shadow:        shadow,
+		opa:           newOPAClient(configuration.OPA),
+	}
+}
+
+// newShadowAuthorizer create the authorizer of the shadow rules, which use the default policy and the network groups of
+// the live rules unless they have their own default policy.
+func newShadowAuthorizer(configuration schema.AccessControlConfiguration) *Authorizer {
+	defaultPolicy := configuration.Shadow.DefaultPolicy
+	if defaultPolicy == "" {
+		defaultPolicy = configuration.DefaultPolicy
+	}
+
+	return &Authorizer{
+		defaultPolicy: PolicyToLevel(defaultPolicy),
+		rules: NewAccessControlRules(schema.AccessControlConfiguration{
+			Networks: configuration.Networks,
+			Rules:    configuration.Shadow.Rules,
+		}),
 	}
 }
 
@@ -45,6 +77,14 @@ func (p *Authorizer) IsSecondFactorEnabled() bool {
 
 // GetRequiredLevel retrieve the required level of authorization to access the object.
 func (p Authorizer) GetRequiredLevel(subject Subject, object Object) Level {
+	level, _ := p.GetRequiredLevelAndRule(subject, object)
+
+	return level
+}
+
+// GetRequiredLevelAndRule retrieve the required level of authorization to access the object along with the position of
+// the rule which matched, or 0 if the default policy applies.
+func (p Authorizer) GetRequiredLevelAndRule(subject Subject, object Object) (level Level, position int) {
 	logger := logging.Logger()
 
 	logger.Debugf("Check authorization of subject %s and object %s (method %s).",
@@ -54,7 +94,9 @@ func (p Authorizer) GetRequiredLevel(subject Subject, object Object) Level {
 		if rule.IsMatch(subject, object) {
 			logger.Tracef(traceFmtACLHitMiss, "HIT", rule.Position, subject.String(), object.String(), object.Method)
 
-			return rule.Policy
+			p.compareShadow(subject, object, rule.Policy, rule.Position)
+
+			return rule.Policy, rule.Position
 		}
 
 		logger.Tracef(traceFmtACLHitMiss, "MISS", rule.Position, subject.String(), object.String(), object.Method)
@@ -63,5 +105,64 @@ func (p Authorizer) GetRequiredLevel(subject Subject, object Object) Level {
 	logger.Debugf("No matching rule for subject %s and url %s... Applying default policy.",
 		subject.String(), object.String())
 
-	return p.defaultPolicy
+	p.compareShadow(subject, object, p.defaultPolicy, 0)
+
+	return p.defaultPolicy, 0
+}
+
+// GetMaxAuthenticationAge retrieve the maximum age of the authentication required by the rule at the given position, or
+// 0 if it has none.
+func (p Authorizer) GetMaxAuthenticationAge(position int) time.Duration {
+	if position < 1 || position > len(p.rules) {
+		return 0
+	}
+
+	return p.rules[position-1].MaxAuthenticationAge
+}
+
+// GetSecondFactorMethods retrieve the second factor methods required by the rule at the given position, or nil if it
+// accepts any of them.
+func (p Authorizer) GetSecondFactorMethods(position int) []string {
+	if position < 1 || position > len(p.rules) {
+		return nil
+	}
+
+	return p.rules[position-1].SecondFactorMethods
+}
+
+// IsAllowedByPolicyEngine queries the Open Policy Agent, when it's configured, about the access of the subject to the
+// object the ACLs authorized at the given level. The access is refused when the query fails.
+func (p Authorizer) IsAllowedByPolicyEngine(subject Subject, object Object, level Level, position int) (bool, error) {
+	if p.opa == nil {
+		return true, nil
+	}
+
+	allowed, err := p.opa.allow(subject, object, level, position)
+	if err != nil {
+		return false, fmt.Errorf("Unable to query the Open Policy Agent: %s", err)
+	}
+
+	return allowed, nil
+}
+
+// compareShadow evaluates the shadow rules and logs when they require another level than the live rules, the decision
+// being left unchanged.
+func (p Authorizer) compareShadow(subject Subject, object Object, level Level, position int) {
+	if p.shadow == nil {
+		return
+	}
+
+	shadowLevel, shadowPosition := p.shadow.defaultPolicy, 0
+
+	for _, rule := range p.shadow.rules {
+		if rule.IsMatch(subject, object) {
+			shadowLevel, shadowPosition = rule.Policy, rule.Position
+			break
+		}
+	}
+
+	if shadowLevel != level {
+		logging.Logger().Infof(logFmtACLShadowMismatch, LevelToPolicy(shadowLevel), shadowPosition,
+			LevelToPolicy(level), position, subject.String(), object.String(), object.Method)
+	}
 }
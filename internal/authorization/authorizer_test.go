@@ -4,7 +4,10 @@ import (
 	"net"
 	"net/url"
 	"testing"
+	"time"
 
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
@@ -137,6 +140,115 @@ func (s *AuthorizerSuite) TestShouldCheckMultiDomainRule() {
 	tester.CheckAuthorizations(s.T(), UserWithGroups, "https://public.example.co/", "GET", Denied)
 }
 
+func (s *AuthorizerSuite) TestShouldReturnPositionOfMatchingRule() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("deny").
+		WithRule(schema.ACLRule{
+			Domains: []string{"public.example.com"},
+			Policy:  "bypass",
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"*.example.com"},
+			Policy:  "two_factor",
+		}).
+		Build()
+
+	for _, tc := range []struct {
+		url      string
+		level    Level
+		position int
+	}{
+		{"https://public.example.com/", Bypass, 1},
+		{"https://private.example.com/", TwoFactor, 2},
+		{"https://example.org/", Denied, 0},
+	} {
+		url, _ := url.ParseRequestURI(tc.url)
+
+		level, position := tester.GetRequiredLevelAndRule(UserWithGroups, NewObject(url, "GET"))
+
+		s.Assert().Equal(tc.level, level, tc.url)
+		s.Assert().Equal(tc.position, position, tc.url)
+	}
+}
+
+func (s *AuthorizerSuite) TestShouldLogShadowRulesMismatchWithoutAffectingDecision() {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	tester := NewAuthorizerTester(schema.AccessControlConfiguration{
+		DefaultPolicy: "deny",
+		Rules: []schema.ACLRule{
+			{
+				Domains: []string{"*.example.com"},
+				Policy:  "one_factor",
+			},
+		},
+		Shadow: &schema.AccessControlShadowConfiguration{
+			Rules: []schema.ACLRule{
+				{
+					Domains: []string{"secure.example.com"},
+					Policy:  "two_factor",
+				},
+				{
+					Domains: []string{"*.example.com"},
+					Policy:  "one_factor",
+				},
+			},
+		},
+	})
+
+	tester.CheckAuthorizations(s.T(), UserWithGroups, "https://public.example.com/", "GET", OneFactor)
+
+	for _, entry := range hook.AllEntries() {
+		s.Assert().NotContains(entry.Message, "Shadow ACL")
+	}
+
+	tester.CheckAuthorizations(s.T(), UserWithGroups, "https://secure.example.com/", "GET", OneFactor)
+	s.Require().NotNil(hook.LastEntry())
+	s.Assert().Equal(logrus.InfoLevel, hook.LastEntry().Level)
+	s.Assert().Contains(hook.LastEntry().Message, "Shadow ACL requires policy two_factor (Position 1) instead of policy one_factor (Position 1)")
+}
+
+func (s *AuthorizerSuite) TestShouldReturnMaxAuthenticationAgeOfRule() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("deny").
+		WithRule(schema.ACLRule{
+			Domains: []string{"public.example.com"},
+			Policy:  "bypass",
+		}).
+		WithRule(schema.ACLRule{
+			Domains:              []string{"admin.example.com"},
+			Policy:               "two_factor",
+			MaxAuthenticationAge: "5m",
+		}).
+		Build()
+
+	s.Assert().Equal(time.Duration(0), tester.GetMaxAuthenticationAge(0))
+	s.Assert().Equal(time.Duration(0), tester.GetMaxAuthenticationAge(1))
+	s.Assert().Equal(5*time.Minute, tester.GetMaxAuthenticationAge(2))
+	s.Assert().Equal(time.Duration(0), tester.GetMaxAuthenticationAge(3))
+}
+
+func (s *AuthorizerSuite) TestShouldReturnSecondFactorMethodsOfRule() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("deny").
+		WithRule(schema.ACLRule{
+			Domains: []string{"public.example.com"},
+			Policy:  "bypass",
+		}).
+		WithRule(schema.ACLRule{
+			Domains:             []string{"admin.example.com"},
+			Policy:              "two_factor",
+			SecondFactorMethods: []string{"webauthn"},
+		}).
+		Build()
+
+	s.Assert().Nil(tester.GetSecondFactorMethods(0))
+	s.Assert().Nil(tester.GetSecondFactorMethods(1))
+	s.Assert().Equal([]string{"webauthn"}, tester.GetSecondFactorMethods(2))
+	s.Assert().Nil(tester.GetSecondFactorMethods(3))
+}
+
 func (s *AuthorizerSuite) TestShouldCheckDynamicDomainRules() {
 	tester := NewAuthorizerBuilder().
 		WithDefaultPolicy("deny").
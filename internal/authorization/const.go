@@ -18,3 +18,5 @@ const userPrefix = "user:"
 const groupPrefix = "group:"
 
 const traceFmtACLHitMiss = "ACL %s Position %d for subject %s and object %s (Method %s)"
+
+const logFmtACLShadowMismatch = "Shadow ACL requires policy %s (Position %d) instead of policy %s (Position %d) for subject %s and object %s (Method %s)"
@@ -0,0 +1,127 @@
+package authorization
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// opaClient queries the data API of an Open Policy Agent with the subject and the object of the requests authorized by
+// the ACLs, the policy being able to refuse them.
+type opaClient struct {
+	url    string
+	client *http.Client
+}
+
+type opaRequest struct {
+	Input opaInput `json:"input"`
+}
+
+type opaInput struct {
+	Subject       opaSubject `json:"subject"`
+	Object        opaObject  `json:"object"`
+	RequiredLevel string     `json:"required_level"`
+	Rule          int        `json:"rule"`
+}
+
+type opaSubject struct {
+	Username string   `json:"username"`
+	Groups   []string `json:"groups"`
+	IP       string   `json:"ip"`
+}
+
+type opaObject struct {
+	Scheme string `json:"scheme"`
+	Domain string `json:"domain"`
+	Path   string `json:"path"`
+	Method string `json:"method"`
+}
+
+type opaResponse struct {
+	Result *json.RawMessage `json:"result"`
+}
+
+// newOPAClient returns the client of the Open Policy Agent of the configuration, or nil if it's not configured.
+func newOPAClient(configuration *schema.AccessControlOPAConfiguration) *opaClient {
+	if configuration == nil {
+		return nil
+	}
+
+	// Skip Error Check since validator checks it.
+	timeout, _ := utils.ParseDurationString(configuration.Timeout)
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+
+	return &opaClient{
+		url:    configuration.URL,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// allow returns the decision of the policy for the subject and the object. The policy must evaluate to true, or to an
+// object whose allow attribute is true, for the request to be allowed; an undefined decision refuses it.
+func (c *opaClient) allow(subject Subject, object Object, level Level, position int) (bool, error) {
+	body, err := json.Marshal(opaRequest{
+		Input: opaInput{
+			Subject: opaSubject{
+				Username: subject.Username,
+				Groups:   subject.Groups,
+				IP:       subject.IP.String(),
+			},
+			Object: opaObject{
+				Scheme: object.Scheme,
+				Domain: object.Domain,
+				Path:   object.Path,
+				Method: object.Method,
+			},
+			RequiredLevel: LevelToPolicy(level),
+			Rule:          position,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.client.Post(c.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("Open Policy Agent replied with status code %d", resp.StatusCode)
+	}
+
+	var response opaResponse
+
+	if err = json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return false, fmt.Errorf("Unable to decode the response of the Open Policy Agent: %s", err)
+	}
+
+	if response.Result == nil {
+		return false, nil
+	}
+
+	var allowed bool
+
+	if err = json.Unmarshal(*response.Result, &allowed); err == nil {
+		return allowed, nil
+	}
+
+	var result struct {
+		Allow bool `json:"allow"`
+	}
+
+	if err = json.Unmarshal(*response.Result, &result); err != nil {
+		return false, fmt.Errorf("Unable to decode the result of the Open Policy Agent: %s", err)
+	}
+
+	return result.Allow, nil
+}
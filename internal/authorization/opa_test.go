@@ -0,0 +1,101 @@
+package authorization
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldQueryOPAWithSubjectAndObject(t *testing.T) {
+	var input opaInput
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request opaRequest
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+		input = request.Input
+
+		_, _ = w.Write([]byte(`{"result": {"allow": true}}`))
+	}))
+	defer server.Close()
+
+	authorizer := NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "two_factor",
+		OPA:           &schema.AccessControlOPAConfiguration{URL: server.URL, Timeout: "1s"},
+	})
+
+	allowed, err := authorizer.IsAllowedByPolicyEngine(
+		Subject{Username: "john", Groups: []string{"dev"}, IP: net.ParseIP("192.168.1.1")},
+		Object{Scheme: "https", Domain: "app.example.com", Path: "/admin", Method: "POST"},
+		TwoFactor, 0)
+
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, opaInput{
+		Subject:       opaSubject{Username: "john", Groups: []string{"dev"}, IP: "192.168.1.1"},
+		Object:        opaObject{Scheme: "https", Domain: "app.example.com", Path: "/admin", Method: "POST"},
+		RequiredLevel: "two_factor",
+		Rule:          0,
+	}, input)
+}
+
+func TestShouldDecideWithOPAResult(t *testing.T) {
+	testCases := []struct {
+		name       string
+		statusCode int
+		body       string
+		expAllowed bool
+		expErr     bool
+	}{
+		{"BooleanTrue", 200, `{"result": true}`, true, false},
+		{"BooleanFalse", 200, `{"result": false}`, false, false},
+		{"ObjectFalse", 200, `{"result": {"allow": false}}`, false, false},
+		{"Undefined", 200, `{}`, false, false},
+		{"InvalidResult", 200, `{"result": "yes"}`, false, true},
+		{"ServerError", 500, `{}`, false, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.statusCode)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			authorizer := NewAuthorizer(schema.AccessControlConfiguration{
+				DefaultPolicy: "one_factor",
+				OPA:           &schema.AccessControlOPAConfiguration{URL: server.URL},
+			})
+
+			allowed, err := authorizer.IsAllowedByPolicyEngine(Subject{Username: "john", IP: net.ParseIP("127.0.0.1")},
+				Object{Scheme: "https", Domain: "app.example.com", Path: "/", Method: "GET"}, OneFactor, 0)
+
+			assert.Equal(t, tc.expAllowed, allowed)
+
+			if tc.expErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestShouldAllowWithoutOPA(t *testing.T) {
+	authorizer := NewAuthorizer(schema.AccessControlConfiguration{DefaultPolicy: "one_factor"})
+
+	allowed, err := authorizer.IsAllowedByPolicyEngine(Subject{}, Object{}, OneFactor, 0)
+
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
@@ -25,6 +25,20 @@ func PolicyToLevel(policy string) Level {
 	return Denied
 }
 
+// LevelToPolicy converts an authorization level to its string policy.
+func LevelToPolicy(level Level) string {
+	switch level {
+	case Bypass:
+		return "bypass"
+	case OneFactor:
+		return "one_factor"
+	case TwoFactor:
+		return "two_factor"
+	}
+
+	return "deny"
+}
+
 func schemaSubjectToACLSubject(subjectRule string) (subject AccessControlSubject) {
 	if strings.HasPrefix(subjectRule, userPrefix) {
 		user := strings.Trim(subjectRule[len(userPrefix):], " ")
@@ -195,3 +195,9 @@ func TestShouldReturnCorrectValidationLevel(t *testing.T) {
 	assert.False(t, IsAuthLevelSufficient(authentication.OneFactor, TwoFactor))
 	assert.True(t, IsAuthLevelSufficient(authentication.TwoFactor, TwoFactor))
 }
+
+func TestShouldConvertLevelToPolicy(t *testing.T) {
+	for _, policy := range []string{"bypass", "one_factor", "two_factor", "deny"} {
+		assert.Equal(t, policy, LevelToPolicy(PolicyToLevel(policy)))
+	}
+}
@@ -0,0 +1,1297 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/notification"
+	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/storage"
+	"github.com/authelia/authelia/internal/templates"
+	"github.com/authelia/authelia/internal/utils"
+	"github.com/authelia/authelia/internal/yubico"
+)
+
+var (
+	storageConfigPath          string
+	storageFilePath            string
+	storageUsername            string
+	storagePassphrasePath      string
+	storageBanReason           string
+	storageBanDuration         string
+	storageAPIKeyName          string
+	storageAPIKeyGroups        []string
+	storageWebAuthnID          string
+	storageWebAuthnDescription string
+	storageWebAuthnDeleted     bool
+	storageTOTPSecret          string
+	storageTOTPAlgorithm       string
+	storageTOTPDigits          int
+	storageTOTPPeriod          int
+	storageHOTPCounter         uint64
+	storageYubiKeyOTP          string
+	storageYubiKeyPublicID     string
+	storageResetTOTP           bool
+	storageResetHOTP           bool
+	storageResetYubiKey        bool
+	storageResetWebAuthn       bool
+	storageResetDuo            bool
+	storageResetNotify         bool
+	storageMigrateTarget       int
+	storageDestroyData         bool
+	storageDryRun              bool
+	storageListType            string
+	storageListLimit           int
+	storageListCursor          string
+)
+
+func init() {
+	StorageCmd.PersistentFlags().StringVar(&storageConfigPath, "config", "", "Configuration file of the storage backend")
+
+	if err := StorageCmd.MarkPersistentFlagRequired("config"); err != nil {
+		log.Fatal(err)
+	}
+
+	StorageExportCmd.Flags().StringVar(&storageFilePath, "file", "", "File to write the export to, standard output if not set")
+	StorageImportCmd.Flags().StringVar(&storageFilePath, "file", "", "File to read the export from")
+
+	if err := StorageImportCmd.MarkFlagRequired("file"); err != nil {
+		log.Fatal(err)
+	}
+
+	StorageUserExportCmd.Flags().StringVar(&storageUsername, "user", "", "Username of the user to export the data of")
+	StorageUserExportCmd.Flags().StringVar(&storageFilePath, "file", "", "File to write the export to, standard output if not set")
+
+	if err := StorageUserExportCmd.MarkFlagRequired("user"); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, cmd := range []*cobra.Command{StorageBackupCmd, StorageRestoreCmd} {
+		cmd.Flags().StringVar(&storageFilePath, "file", "", "File of the encrypted backup")
+		cmd.Flags().StringVar(&storagePassphrasePath, "passphrase-file", "", "File containing the passphrase the backup is encrypted with")
+
+		for _, flag := range []string{"file", "passphrase-file"} {
+			if err := cmd.MarkFlagRequired(flag); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	StorageMigrateUpCmd.Flags().BoolVar(&storageDryRun, "dry-run", false, "Print the statements of the pending migrations without running them")
+
+	StorageMigrateDownCmd.Flags().IntVar(&storageMigrateTarget, "target", 0, "Schema version to downgrade the storage backend to")
+	StorageMigrateDownCmd.Flags().BoolVar(&storageDestroyData, "destroy-data", false, "Downgrade the schema even though the tables and the columns it drops hold data")
+
+	if err := StorageMigrateDownCmd.MarkFlagRequired("target"); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, cmd := range []*cobra.Command{StorageAuditListCmd, StorageLogsListCmd} {
+		cmd.Flags().StringVar(&storageUsername, "user", "", "Username of the user to list the entries of, every user if not set")
+		cmd.Flags().StringVar(&storageListType, "type", "", "Type of the entries to list, every type if not set")
+		cmd.Flags().IntVar(&storageListLimit, "limit", 100, "Maximum number of entries of the page")
+		cmd.Flags().StringVar(&storageListCursor, "cursor", "", "Cursor of the page as printed with the previous page, the first page if not set")
+	}
+
+	StorageBanAddCmd.Flags().StringVar(&storageBanReason, "reason", "", "Reason of the ban")
+	StorageBanAddCmd.Flags().StringVar(&storageBanDuration, "duration", "", "Duration of the ban, permanent if not set")
+	StorageBanListCmd.Flags().StringVar(&storageUsername, "user", "", "Username of the user to list the bans of, every user if not set")
+
+	for _, cmd := range []*cobra.Command{StorageBanAddCmd, StorageBanRevokeCmd} {
+		cmd.Flags().StringVar(&storageUsername, "user", "", "Username of the user")
+
+		if err := cmd.MarkFlagRequired("user"); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	StorageAPIKeyAddCmd.Flags().StringSliceVar(&storageAPIKeyGroups, "groups", nil, "Groups of the requests authenticated with the key")
+
+	for _, cmd := range []*cobra.Command{StorageAPIKeyAddCmd, StorageAPIKeyRevokeCmd} {
+		cmd.Flags().StringVar(&storageAPIKeyName, "name", "", "Name of the key, used as the username of the requests")
+
+		if err := cmd.MarkFlagRequired("name"); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	StorageUserWebAuthnImportCmd.Flags().StringVar(&storageFilePath, "file", "", "File containing the credentials to import")
+
+	if err := StorageUserWebAuthnImportCmd.MarkFlagRequired("file"); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, cmd := range []*cobra.Command{StorageUserWebAuthnListCmd, StorageUserWebAuthnDeleteCmd, StorageUserWebAuthnRestoreCmd,
+		StorageUserWebAuthnRenameCmd, StorageUserWebAuthnImportCmd} {
+		cmd.Flags().StringVar(&storageUsername, "user", "", "Username of the user")
+
+		if err := cmd.MarkFlagRequired("user"); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, cmd := range []*cobra.Command{StorageUserWebAuthnDeleteCmd, StorageUserWebAuthnRestoreCmd, StorageUserWebAuthnRenameCmd} {
+		cmd.Flags().StringVar(&storageWebAuthnID, "id", "", "ID of the passkey as printed by the list command")
+
+		if err := cmd.MarkFlagRequired("id"); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	StorageUserWebAuthnListCmd.Flags().BoolVar(&storageWebAuthnDeleted, "deleted", false, "List the deleted passkeys which can still be restored instead")
+
+	StorageUserWebAuthnRenameCmd.Flags().StringVar(&storageWebAuthnDescription, "description", "", "New description of the passkey")
+
+	if err := StorageUserWebAuthnRenameCmd.MarkFlagRequired("description"); err != nil {
+		log.Fatal(err)
+	}
+
+	StorageUserTOTPSetCmd.Flags().StringVar(&storageUsername, "user", "", "Username of the user")
+	StorageUserTOTPSetCmd.Flags().StringVar(&storageTOTPSecret, "secret", "", "Base32 encoded secret of the token")
+	StorageUserTOTPSetCmd.Flags().StringVar(&storageTOTPAlgorithm, "algorithm", models.TOTPAlgorithmSHA1, "Algorithm of the token, one of SHA1, SHA256 or SHA512")
+	StorageUserTOTPSetCmd.Flags().IntVar(&storageTOTPDigits, "digits", 6, "Number of digits of the one-time passwords of the token, 6 or 8")
+	StorageUserTOTPSetCmd.Flags().IntVar(&storageTOTPPeriod, "period", 0, "Period in seconds of the one-time passwords of the token, the configured period if not set")
+
+	for _, flag := range []string{"user", "secret"} {
+		if err := StorageUserTOTPSetCmd.MarkFlagRequired(flag); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	StorageUserHOTPSetCmd.Flags().StringVar(&storageTOTPSecret, "secret", "", "Base32 encoded secret of the token")
+	StorageUserHOTPSetCmd.Flags().Uint64Var(&storageHOTPCounter, "counter", 0, "Counter of the next one-time password of the token")
+	StorageUserHOTPSetCmd.Flags().IntVar(&storageTOTPDigits, "digits", 6, "Number of digits of the one-time passwords of the token, 6 or 8")
+
+	if err := StorageUserHOTPSetCmd.MarkFlagRequired("secret"); err != nil {
+		log.Fatal(err)
+	}
+
+	StorageUserYubiKeyAddCmd.Flags().StringVar(&storageYubiKeyOTP, "otp", "", "One-time password typed by the YubiKey, its public ID is registered")
+
+	if err := StorageUserYubiKeyAddCmd.MarkFlagRequired("otp"); err != nil {
+		log.Fatal(err)
+	}
+
+	StorageUserYubiKeyDeleteCmd.Flags().StringVar(&storageYubiKeyPublicID, "public-id", "", "Public ID of the YubiKey as printed by the list command")
+
+	if err := StorageUserYubiKeyDeleteCmd.MarkFlagRequired("public-id"); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, cmd := range []*cobra.Command{StorageUserHOTPSetCmd, StorageUserHOTPDeleteCmd, StorageUserYubiKeyAddCmd,
+		StorageUserYubiKeyListCmd, StorageUserYubiKeyDeleteCmd} {
+		cmd.Flags().StringVar(&storageUsername, "user", "", "Username of the user")
+
+		if err := cmd.MarkFlagRequired("user"); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	StorageUser2FAResetCmd.Flags().StringVar(&storageUsername, "user", "", "Username of the user")
+	StorageUser2FAResetCmd.Flags().BoolVar(&storageResetTOTP, "totp", false, "Delete the TOTP token of the user")
+	StorageUser2FAResetCmd.Flags().BoolVar(&storageResetHOTP, "hotp", false, "Delete the HOTP token of the user")
+	StorageUser2FAResetCmd.Flags().BoolVar(&storageResetYubiKey, "yubikey", false, "Delete the YubiKeys generating Yubico OTPs of the user")
+	StorageUser2FAResetCmd.Flags().BoolVar(&storageResetWebAuthn, "webauthn", false, "Delete the passkeys and the security keys of the user")
+	StorageUser2FAResetCmd.Flags().BoolVar(&storageResetDuo, "duo", false, "Forget Duo as the preferred second factor method of the user")
+	StorageUser2FAResetCmd.Flags().BoolVar(&storageResetNotify, "notify", false, "Email the user about the reset with the configured notifier")
+
+	if err := StorageUser2FAResetCmd.MarkFlagRequired("user"); err != nil {
+		log.Fatal(err)
+	}
+
+	StorageUserTOTPCmd.AddCommand(StorageUserTOTPSetCmd)
+	StorageUserHOTPCmd.AddCommand(StorageUserHOTPSetCmd, StorageUserHOTPDeleteCmd)
+	StorageUserYubiKeyCmd.AddCommand(StorageUserYubiKeyAddCmd, StorageUserYubiKeyListCmd, StorageUserYubiKeyDeleteCmd)
+	StorageUser2FACmd.AddCommand(StorageUser2FAResetCmd)
+	StorageUserWebAuthnCmd.AddCommand(StorageUserWebAuthnListCmd, StorageUserWebAuthnDeleteCmd, StorageUserWebAuthnRestoreCmd,
+		StorageUserWebAuthnRenameCmd, StorageUserWebAuthnImportCmd)
+	StorageUserCmd.AddCommand(StorageUserExportCmd, StorageUserWebAuthnCmd, StorageUserTOTPCmd, StorageUserHOTPCmd,
+		StorageUserYubiKeyCmd, StorageUser2FACmd)
+	StorageBanCmd.AddCommand(StorageBanAddCmd, StorageBanRevokeCmd, StorageBanListCmd)
+	StorageAPIKeyCmd.AddCommand(StorageAPIKeyAddCmd, StorageAPIKeyRevokeCmd, StorageAPIKeyListCmd)
+	StorageAuditCmd.AddCommand(StorageAuditVerifyCmd, StorageAuditListCmd)
+	StorageLogsCmd.AddCommand(StorageLogsListCmd)
+	StorageMigrateCmd.AddCommand(StorageMigrateUpCmd, StorageMigrateDownCmd)
+	StorageCmd.AddCommand(StorageExportCmd, StorageImportCmd, StorageUserCmd, StoragePruneCmd, StorageMaintenanceCmd,
+		StorageBackupCmd, StorageRestoreCmd, StorageBanCmd, StorageAPIKeyCmd, StorageAuditCmd, StorageLogsCmd, StorageMigrateCmd)
+}
+
+// readStorageConfiguration reads the configuration given with the config flag.
+func readStorageConfiguration() *schema.Configuration {
+	config, errs := configuration.Read(storageConfigPath)
+	if len(errs) != 0 {
+		for _, err := range errs {
+			log.Println(err)
+		}
+
+		log.Fatalf("Unable to read the configuration file %s", storageConfigPath)
+	}
+
+	return config
+}
+
+// getStorageProvider reads the configuration and constructs the SQL provider of the configured storage backend.
+func getStorageProvider() *storage.SQLProvider {
+	return newStorageProvider(readStorageConfiguration())
+}
+
+func newStorageProvider(config *schema.Configuration) *storage.SQLProvider {
+	provider := storage.NewSQLProvider(config.Storage)
+	if provider == nil {
+		log.Fatalf("Unrecognized storage backend")
+	}
+
+	return provider
+}
+
+func exportStorage(cmd *cobra.Command, args []string) {
+	export, err := getStorageProvider().Export()
+	if err != nil {
+		log.Fatalf("Unable to export the storage data: %v", err)
+	}
+
+	writeStorageExport(export)
+}
+
+func exportStorageUser(cmd *cobra.Command, args []string) {
+	export, err := getStorageProvider().ExportUser(storageUsername)
+	if err != nil {
+		log.Fatalf("Unable to export the data of user %s: %v", storageUsername, err)
+	}
+
+	writeStorageExport(export)
+}
+
+// writeStorageExport writes an export as JSON to the file given with the file flag, or to standard output if the flag
+// isn't set.
+func writeStorageExport(export interface{}) {
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		log.Fatalf("Unable to encode the storage data: %v", err)
+	}
+
+	if storageFilePath == "" {
+		fmt.Println(string(data))
+		return
+	}
+
+	if err = ioutil.WriteFile(storageFilePath, data, 0600); err != nil {
+		log.Fatalf("Unable to write %s: %v", storageFilePath, err)
+	}
+
+	log.Printf("Storage data exported to %s", storageFilePath)
+}
+
+func importStorage(cmd *cobra.Command, args []string) {
+	data, err := ioutil.ReadFile(storageFilePath)
+	if err != nil {
+		log.Fatalf("Unable to read %s: %v", storageFilePath, err)
+	}
+
+	export := &storage.Export{}
+
+	if err = json.Unmarshal(data, export); err != nil {
+		log.Fatalf("Unable to decode %s: %v", storageFilePath, err)
+	}
+
+	warnStorageAuditEventsNotImported(export)
+
+	if err = getStorageProvider().Import(export); err != nil {
+		log.Fatalf("Unable to import the storage data: %v", err)
+	}
+
+	log.Printf("Storage data imported from %s", storageFilePath)
+}
+
+// warnStorageAuditEventsNotImported tells the operator the audit events of an export are not imported, the export
+// remains the archive of the audit log of the source database.
+func warnStorageAuditEventsNotImported(export *storage.Export) {
+	if len(export.AuditEvents) != 0 {
+		log.Printf("The %d audit events of %s are not imported, the audit log can only be appended to by Authelia", len(export.AuditEvents), storageFilePath)
+	}
+}
+
+// readStoragePassphrase reads the passphrase of a backup from the file given with the passphrase-file flag, ignoring
+// the trailing newline.
+func readStoragePassphrase() string {
+	data, err := ioutil.ReadFile(storagePassphrasePath)
+	if err != nil {
+		log.Fatalf("Unable to read %s: %v", storagePassphrasePath, err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n")
+}
+
+func backupStorage(cmd *cobra.Command, args []string) {
+	passphrase := readStoragePassphrase()
+
+	export, err := getStorageProvider().Export()
+	if err != nil {
+		log.Fatalf("Unable to export the storage data: %v", err)
+	}
+
+	data, err := storage.EncryptBackup(export, passphrase)
+	if err != nil {
+		log.Fatalf("Unable to encrypt the backup: %v", err)
+	}
+
+	if err = ioutil.WriteFile(storageFilePath, data, 0600); err != nil {
+		log.Fatalf("Unable to write %s: %v", storageFilePath, err)
+	}
+
+	log.Printf("Storage backup written to %s", storageFilePath)
+}
+
+func restoreStorage(cmd *cobra.Command, args []string) {
+	passphrase := readStoragePassphrase()
+
+	data, err := ioutil.ReadFile(storageFilePath)
+	if err != nil {
+		log.Fatalf("Unable to read %s: %v", storageFilePath, err)
+	}
+
+	export, err := storage.DecryptBackup(data, passphrase)
+	if err != nil {
+		log.Fatalf("Unable to decrypt %s: %v", storageFilePath, err)
+	}
+
+	warnStorageAuditEventsNotImported(export)
+
+	if err = getStorageProvider().Import(export); err != nil {
+		log.Fatalf("Unable to restore the storage backup: %v", err)
+	}
+
+	log.Printf("Storage backup restored from %s", storageFilePath)
+}
+
+func addStorageBan(cmd *cobra.Command, args []string) {
+	ban := models.Ban{
+		Username: storageUsername,
+		Reason:   storageBanReason,
+		Time:     time.Now(),
+	}
+
+	if storageBanDuration != "" {
+		duration, err := utils.ParseDurationString(storageBanDuration)
+		if err != nil || duration <= 0 {
+			log.Fatalf("Invalid ban duration %s", storageBanDuration)
+		}
+
+		expires := ban.Time.Add(duration)
+		ban.Expires = &expires
+	}
+
+	if err := getStorageProvider().SaveBan(ban); err != nil {
+		log.Fatalf("Unable to ban user %s: %v", storageUsername, err)
+	}
+
+	if ban.Expires == nil {
+		log.Printf("User %s is banned permanently", storageUsername)
+	} else {
+		log.Printf("User %s is banned until %s", storageUsername, ban.Expires)
+	}
+}
+
+func revokeStorageBan(cmd *cobra.Command, args []string) {
+	revoked, err := getStorageProvider().RevokeBan(storageUsername)
+	if err != nil {
+		log.Fatalf("Unable to revoke the bans of user %s: %v", storageUsername, err)
+	}
+
+	log.Printf("Revoked %d bans of user %s", revoked, storageUsername)
+}
+
+func listStorageBans(cmd *cobra.Command, args []string) {
+	bans, err := getStorageProvider().LoadActiveBans(storageUsername)
+	if err != nil {
+		log.Fatalf("Unable to load the bans: %v", err)
+	}
+
+	writeStorageExport(bans)
+}
+
+func addStorageAPIKey(cmd *cobra.Command, args []string) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		log.Fatalf("Unable to generate the API key: %v", err)
+	}
+
+	key := models.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	err := getStorageProvider().SaveAPIKey(models.APIKey{
+		Name:   storageAPIKeyName,
+		Hash:   utils.HashSHA256FromString(key),
+		Groups: storageAPIKeyGroups,
+		Time:   time.Now(),
+	})
+	if err != nil {
+		log.Fatalf("Unable to save the API key %s: %v", storageAPIKeyName, err)
+	}
+
+	log.Printf("API key %s created, it can't be displayed again", storageAPIKeyName)
+	fmt.Println(key)
+}
+
+func revokeStorageAPIKey(cmd *cobra.Command, args []string) {
+	revoked, err := getStorageProvider().RevokeAPIKey(storageAPIKeyName)
+	if err != nil {
+		log.Fatalf("Unable to revoke the API key %s: %v", storageAPIKeyName, err)
+	}
+
+	log.Printf("Revoked %d API keys named %s", revoked, storageAPIKeyName)
+}
+
+func listStorageAPIKeys(cmd *cobra.Command, args []string) {
+	keys, err := getStorageProvider().LoadAPIKeys()
+	if err != nil {
+		log.Fatalf("Unable to load the API keys: %v", err)
+	}
+
+	writeStorageExport(keys)
+}
+
+func verifyStorageAuditEvents(cmd *cobra.Command, args []string) {
+	verification, err := getStorageProvider().VerifyAuditEvents()
+	if err != nil {
+		log.Fatalf("Unable to verify the audit events: %v", err)
+	}
+
+	writeStorageExport(verification)
+}
+
+func listStorageAuditEvents(cmd *cobra.Command, args []string) {
+	events, next, err := getStorageProvider().LoadAuditEvents(storage.AuditEventsFilter{
+		Username: storageUsername,
+		Type:     storageListType,
+		Limit:    storageListLimit,
+		Cursor:   storageListCursor,
+	})
+	if err != nil {
+		log.Fatalf("Unable to load the audit events: %v", err)
+	}
+
+	writeStorageExport(storagePage{Entries: events, Next: next})
+}
+
+func listStorageAuthenticationLogs(cmd *cobra.Command, args []string) {
+	attempts, next, err := getStorageProvider().LoadAuthenticationLogs(storage.AuthenticationLogsFilter{
+		Username: storageUsername,
+		Type:     storageListType,
+		Limit:    storageListLimit,
+		Cursor:   storageListCursor,
+	})
+	if err != nil {
+		log.Fatalf("Unable to load the authentication logs: %v", err)
+	}
+
+	writeStorageExport(storagePage{Entries: attempts, Next: next})
+}
+
+// storagePage is a page of a listing printed by the list commands, Next is the cursor of the next page.
+type storagePage struct {
+	Entries interface{} `json:"entries"`
+	Next    string      `json:"next,omitempty"`
+}
+
+func listStorageWebAuthnCredentials(cmd *cobra.Command, args []string) {
+	provider := getStorageProvider()
+
+	load := provider.LoadWebAuthnCredentials
+	if storageWebAuthnDeleted {
+		load = provider.LoadDeletedWebAuthnCredentials
+	}
+
+	credentials, err := load(storageUsername)
+	if err != nil {
+		log.Fatalf("Unable to load the passkeys of user %s: %v", storageUsername, err)
+	}
+
+	writeStorageExport(credentials)
+}
+
+// decodeStorageWebAuthnID decodes the ID of a passkey given with the id flag, which is base64 encoded like in the
+// output of the list command.
+func decodeStorageWebAuthnID() []byte {
+	id, err := base64.StdEncoding.DecodeString(storageWebAuthnID)
+	if err != nil || len(id) == 0 {
+		log.Fatalf("Invalid passkey ID %s", storageWebAuthnID)
+	}
+
+	return id
+}
+
+func deleteStorageWebAuthnCredential(cmd *cobra.Command, args []string) {
+	deleted, err := getStorageProvider().DeleteWebAuthnCredential(storageUsername, decodeStorageWebAuthnID())
+	if err != nil {
+		log.Fatalf("Unable to delete the passkey %s of user %s: %v", storageWebAuthnID, storageUsername, err)
+	}
+
+	if deleted == 0 {
+		log.Fatalf("The passkey %s is not registered by user %s", storageWebAuthnID, storageUsername)
+	}
+
+	log.Printf("Deleted the passkey %s of user %s", storageWebAuthnID, storageUsername)
+}
+
+func restoreStorageWebAuthnCredential(cmd *cobra.Command, args []string) {
+	provider := getStorageProvider()
+
+	restored, err := provider.RestoreWebAuthnCredential(storageUsername, decodeStorageWebAuthnID())
+	if err != nil {
+		log.Fatalf("Unable to restore the passkey %s of user %s: %v", storageWebAuthnID, storageUsername, err)
+	}
+
+	if restored == 0 {
+		log.Fatalf("The passkey %s was not deleted by user %s or was already purged", storageWebAuthnID, storageUsername)
+	}
+
+	err = provider.AppendAuditEvent(models.AuditEvent{
+		Username: storageUsername,
+		Type:     models.AuditEventPasskeyRestored,
+		Details:  storageWebAuthnID,
+		Time:     time.Now(),
+	})
+	if err != nil {
+		log.Printf("Unable to record the audit event of user %s: %v", storageUsername, err)
+	}
+
+	log.Printf("Restored the passkey %s of user %s", storageWebAuthnID, storageUsername)
+}
+
+func renameStorageWebAuthnCredential(cmd *cobra.Command, args []string) {
+	updated, err := getStorageProvider().UpdateWebAuthnCredentialDescription(storageUsername, decodeStorageWebAuthnID(), storageWebAuthnDescription)
+	if err != nil {
+		log.Fatalf("Unable to rename the passkey %s of user %s: %v", storageWebAuthnID, storageUsername, err)
+	}
+
+	if updated == 0 {
+		log.Fatalf("The passkey %s is not registered by user %s", storageWebAuthnID, storageUsername)
+	}
+
+	log.Printf("Renamed the passkey %s of user %s to %s", storageWebAuthnID, storageUsername, storageWebAuthnDescription)
+}
+
+func setStorageTOTPConfiguration(cmd *cobra.Command, args []string) {
+	secret := strings.TrimRight(strings.ToUpper(strings.ReplaceAll(storageTOTPSecret, " ", "")), "=")
+
+	if _, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret); err != nil {
+		log.Fatalf("The secret is not base32 encoded: %v", err)
+	}
+
+	switch storageTOTPAlgorithm {
+	case models.TOTPAlgorithmSHA1, models.TOTPAlgorithmSHA256, models.TOTPAlgorithmSHA512:
+	default:
+		log.Fatalf("The algorithm must be one of SHA1, SHA256 or SHA512 but it is %s", storageTOTPAlgorithm)
+	}
+
+	if storageTOTPDigits != 6 && storageTOTPDigits != 8 {
+		log.Fatalf("The digits must be 6 or 8 but they are %d", storageTOTPDigits)
+	}
+
+	if storageTOTPPeriod < 0 {
+		log.Fatalf("The period must be 0 or more but it is %d", storageTOTPPeriod)
+	}
+
+	provider := getStorageProvider()
+
+	event := models.TOTPHistoryEventReplaced
+
+	switch _, err := provider.LoadTOTPConfiguration(storageUsername); err {
+	case nil:
+	case storage.ErrNoTOTPSecret:
+		event = models.TOTPHistoryEventCreated
+	default:
+		log.Fatalf("Unable to load the TOTP configuration of user %s: %v", storageUsername, err)
+	}
+
+	err := provider.SaveTOTPConfiguration(models.TOTPConfiguration{
+		Username:  storageUsername,
+		Secret:    secret,
+		Algorithm: storageTOTPAlgorithm,
+		Digits:    storageTOTPDigits,
+		Period:    storageTOTPPeriod,
+	})
+	if err != nil {
+		log.Fatalf("Unable to save the TOTP configuration of user %s: %v", storageUsername, err)
+	}
+
+	if err = provider.AppendTOTPHistory(models.TOTPHistoryEntry{Username: storageUsername, Event: event, Time: time.Now()}); err != nil {
+		log.Printf("Unable to record the TOTP history of user %s: %v", storageUsername, err)
+	}
+
+	log.Printf("Registered the TOTP token of user %s with algorithm %s and %d digits", storageUsername, storageTOTPAlgorithm, storageTOTPDigits)
+}
+
+func setStorageHOTPDevice(cmd *cobra.Command, args []string) {
+	secret := strings.TrimRight(strings.ToUpper(strings.ReplaceAll(storageTOTPSecret, " ", "")), "=")
+
+	if _, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret); err != nil {
+		log.Fatalf("The secret is not base32 encoded: %v", err)
+	}
+
+	if storageTOTPDigits != 6 && storageTOTPDigits != 8 {
+		log.Fatalf("The digits must be 6 or 8 but they are %d", storageTOTPDigits)
+	}
+
+	err := getStorageProvider().SaveHOTPDevice(models.HOTPDevice{
+		Username: storageUsername,
+		Secret:   secret,
+		Counter:  storageHOTPCounter,
+		Digits:   storageTOTPDigits,
+	})
+	if err != nil {
+		log.Fatalf("Unable to save the HOTP token of user %s: %v", storageUsername, err)
+	}
+
+	log.Printf("Registered the HOTP token of user %s with counter %d and %d digits", storageUsername, storageHOTPCounter, storageTOTPDigits)
+}
+
+func deleteStorageHOTPDevice(cmd *cobra.Command, args []string) {
+	if err := getStorageProvider().DeleteHOTPDevice(storageUsername); err != nil {
+		log.Fatalf("Unable to delete the HOTP token of user %s: %v", storageUsername, err)
+	}
+
+	log.Printf("Deleted the HOTP token of user %s", storageUsername)
+}
+
+func addStorageYubiKeyOTPDevice(cmd *cobra.Command, args []string) {
+	publicID, err := yubico.PublicID(strings.TrimSpace(storageYubiKeyOTP))
+	if err != nil {
+		log.Fatalf("The one-time password is not a Yubico OTP: %v", err)
+	}
+
+	provider := getStorageProvider()
+
+	devices, err := provider.LoadYubiKeyOTPDevices(storageUsername)
+	if err != nil {
+		log.Fatalf("Unable to load the YubiKeys of user %s: %v", storageUsername, err)
+	}
+
+	for _, device := range devices {
+		if device.PublicID == publicID {
+			log.Fatalf("The YubiKey %s is already registered by user %s", publicID, storageUsername)
+		}
+	}
+
+	err = provider.SaveYubiKeyOTPDevice(models.YubiKeyOTPDevice{Username: storageUsername, PublicID: publicID, Time: time.Now()})
+	if err != nil {
+		log.Fatalf("Unable to save the YubiKey %s of user %s: %v", publicID, storageUsername, err)
+	}
+
+	log.Printf("Registered the YubiKey %s of user %s", publicID, storageUsername)
+}
+
+func listStorageYubiKeyOTPDevices(cmd *cobra.Command, args []string) {
+	devices, err := getStorageProvider().LoadYubiKeyOTPDevices(storageUsername)
+	if err != nil {
+		log.Fatalf("Unable to load the YubiKeys of user %s: %v", storageUsername, err)
+	}
+
+	writeStorageExport(devices)
+}
+
+func deleteStorageYubiKeyOTPDevice(cmd *cobra.Command, args []string) {
+	deleted, err := getStorageProvider().DeleteYubiKeyOTPDevice(storageUsername, storageYubiKeyPublicID)
+	if err != nil {
+		log.Fatalf("Unable to delete the YubiKey %s of user %s: %v", storageYubiKeyPublicID, storageUsername, err)
+	}
+
+	if deleted == 0 {
+		log.Fatalf("The YubiKey %s is not registered by user %s", storageYubiKeyPublicID, storageUsername)
+	}
+
+	log.Printf("Deleted the YubiKey %s of user %s", storageYubiKeyPublicID, storageUsername)
+}
+
+func importStorageWebAuthnCredentials(cmd *cobra.Command, args []string) {
+	data, err := ioutil.ReadFile(storageFilePath)
+	if err != nil {
+		log.Fatalf("Unable to read %s: %v", storageFilePath, err)
+	}
+
+	config := readStorageConfiguration()
+
+	credentials, err := storage.ParseWebAuthnCredentialImport(data, storageUsername, webAuthnRPIDs(config), time.Now())
+	if err != nil {
+		log.Fatalf("Unable to import the passkeys of %s: %v", storageFilePath, err)
+	}
+
+	provider := newStorageProvider(config)
+
+	for _, credential := range credentials {
+		if err = provider.SaveWebAuthnCredential(credential); err != nil {
+			log.Fatalf("Unable to save the passkey %s of user %s: %v", base64.StdEncoding.EncodeToString(credential.ID), storageUsername, err)
+		}
+	}
+
+	log.Printf("Imported %d passkeys of user %s", len(credentials), storageUsername)
+}
+
+// webAuthnRPIDs returns the relying party IDs the passkeys can be registered for, the configured one or the cookie
+// domains of the sessions.
+func webAuthnRPIDs(config *schema.Configuration) (rpIDs []string) {
+	if config.WebAuthn != nil && config.WebAuthn.RPID != "" {
+		return []string{config.WebAuthn.RPID}
+	}
+
+	for _, domain := range session.Domains(config.Session) {
+		rpIDs = append(rpIDs, strings.TrimPrefix(domain.Domain, "."))
+	}
+
+	return rpIDs
+}
+
+// resetStorageSecondFactor deletes the second factor methods of a user selected with the flags, for example when the
+// user lost their phone and can't use their recovery codes.
+func resetStorageSecondFactor(cmd *cobra.Command, args []string) {
+	if !storageResetTOTP && !storageResetHOTP && !storageResetYubiKey && !storageResetWebAuthn && !storageResetDuo {
+		log.Fatal("At least one of the totp, hotp, yubikey, webauthn or duo flags must be set")
+	}
+
+	config := readStorageConfiguration()
+	provider := newStorageProvider(config)
+
+	var methods []string
+
+	if storageResetTOTP {
+		if err := provider.DeleteTOTPSecret(storageUsername); err != nil {
+			log.Fatalf("Unable to delete the TOTP token of user %s: %v", storageUsername, err)
+		}
+
+		if err := provider.AppendTOTPHistory(models.TOTPHistoryEntry{Username: storageUsername, Event: models.TOTPHistoryEventDeleted, Time: time.Now()}); err != nil {
+			log.Printf("Unable to record the TOTP history of user %s: %v", storageUsername, err)
+		}
+
+		methods = append(methods, "totp")
+	}
+
+	if storageResetHOTP {
+		if err := provider.DeleteHOTPDevice(storageUsername); err != nil {
+			log.Fatalf("Unable to delete the HOTP token of user %s: %v", storageUsername, err)
+		}
+
+		methods = append(methods, "hotp")
+	}
+
+	if storageResetYubiKey {
+		deleted, err := provider.DeleteYubiKeyOTPDevices(storageUsername)
+		if err != nil {
+			log.Fatalf("Unable to delete the YubiKeys of user %s: %v", storageUsername, err)
+		}
+
+		log.Printf("Deleted %d YubiKeys of user %s", deleted, storageUsername)
+
+		methods = append(methods, "yubikey")
+	}
+
+	if storageResetWebAuthn {
+		deleted, err := provider.DeleteWebAuthnCredentials(storageUsername)
+		if err != nil {
+			log.Fatalf("Unable to delete the passkeys of user %s: %v", storageUsername, err)
+		}
+
+		if err = provider.DeleteU2FDeviceHandle(storageUsername); err != nil {
+			log.Fatalf("Unable to delete the security key of user %s: %v", storageUsername, err)
+		}
+
+		log.Printf("Deleted %d passkeys of user %s", deleted, storageUsername)
+
+		methods = append(methods, "webauthn")
+	}
+
+	// The Duo devices are enrolled in Duo, only the preference of the user is stored by Authelia.
+	if storageResetDuo {
+		preferred, err := provider.LoadPreferred2FAMethod(storageUsername)
+		if err != nil {
+			log.Fatalf("Unable to load the preferred second factor method of user %s: %v", storageUsername, err)
+		}
+
+		if preferred == authentication.Push {
+			if err = provider.SavePreferred2FAMethod(storageUsername, ""); err != nil {
+				log.Fatalf("Unable to reset the preferred second factor method of user %s: %v", storageUsername, err)
+			}
+		}
+
+		methods = append(methods, "duo")
+	}
+
+	err := provider.AppendAuditEvent(models.AuditEvent{
+		Username: storageUsername,
+		Type:     models.AuditEventSecondFactorReset,
+		Details:  strings.Join(methods, ","),
+		Time:     time.Now(),
+	})
+	if err != nil {
+		log.Printf("Unable to record the audit event of user %s: %v", storageUsername, err)
+	}
+
+	log.Printf("Reset the second factor methods %s of user %s", strings.Join(methods, ", "), storageUsername)
+
+	if storageResetNotify {
+		notifySecondFactorReset(config, methods)
+	}
+}
+
+// notifySecondFactorReset emails the user about the reset of their second factor methods, using the email address
+// returned by the authentication backend.
+func notifySecondFactorReset(config *schema.Configuration, methods []string) {
+	certPool, errs, _ := utils.NewX509CertPool(config.CertificatesDirectory)
+	if len(errs) != 0 {
+		log.Fatalf("Unable to load the certificates to notify user %s: %v", storageUsername, errs[0])
+	}
+
+	var userProvider authentication.UserProvider
+
+	switch {
+	case config.AuthenticationBackend.File != nil:
+		userProvider = authentication.NewFileUserProvider(config.AuthenticationBackend.File)
+	case config.AuthenticationBackend.LDAP != nil:
+		userProvider = authentication.NewLDAPUserProvider(*config.AuthenticationBackend.LDAP, certPool)
+	default:
+		log.Fatalf("Unrecognized authentication backend")
+	}
+
+	var notifier notification.Notifier
+
+	switch {
+	case config.Notifier.SMTP != nil:
+		notifier = notification.NewSMTPNotifier(*config.Notifier.SMTP, certPool)
+	case config.Notifier.FileSystem != nil:
+		notifier = notification.NewFileNotifier(*config.Notifier.FileSystem)
+	default:
+		log.Fatalf("Unrecognized notifier")
+	}
+
+	details, err := userProvider.GetDetails(storageUsername)
+	if err != nil {
+		log.Fatalf("Unable to retrieve the details of user %s: %v", storageUsername, err)
+	}
+
+	if len(details.Emails) == 0 {
+		log.Fatalf("User %s has no email address to notify of the reset", storageUsername)
+	}
+
+	body := new(bytes.Buffer)
+
+	err = templates.SecondFactorResetEmailTemplate.Execute(body, map[string]interface{}{
+		"methods": strings.Join(methods, ", "),
+		"time":    time.Now().UTC().Format(time.RFC1123),
+	})
+	if err != nil {
+		log.Fatalf("Unable to render the notification of the reset: %v", err)
+	}
+
+	if err = notifier.Send(details.Emails[0], "Your second factor methods were reset", body.String(), ""); err != nil {
+		log.Fatalf("Unable to notify user %s of the reset: %v", storageUsername, err)
+	}
+
+	log.Printf("Notified user %s of the reset at %s", storageUsername, details.Emails[0])
+}
+
+func pruneStorage(cmd *cobra.Command, args []string) {
+	provider := getStorageProvider()
+
+	purged, err := provider.PurgeWebAuthnCredentials()
+	if err != nil {
+		log.Fatalf("Unable to purge the deleted passkeys: %v", err)
+	}
+
+	log.Printf("Purged %d deleted passkeys", purged)
+
+	deleted, err := provider.PruneAuthenticationLogs()
+
+	switch err {
+	case nil:
+		log.Printf("Pruned %d authentication logs", deleted)
+	case storage.ErrNoAuthenticationLogsRetention:
+		log.Println("The authentication logs are kept as no retention is configured for them")
+	default:
+		log.Fatalf("Unable to prune the authentication logs: %v", err)
+	}
+}
+
+func maintainStorage(cmd *cobra.Command, args []string) {
+	if err := getStorageProvider().Maintenance(); err != nil {
+		log.Fatalf("Unable to run the storage maintenance: %v", err)
+	}
+
+	log.Println("Storage maintenance completed")
+}
+
+// migrateStorageUp upgrades the schema to the current version, which every provider does when it's created, or prints
+// the statements of the pending migrations without running them when the dry-run flag is given.
+func migrateStorageUp(cmd *cobra.Command, args []string) {
+	config := readStorageConfiguration()
+	config.Storage.DisableSchemaUpgrade = storageDryRun
+
+	provider := newStorageProvider(config)
+
+	if !storageDryRun {
+		log.Printf("Storage schema is up to date")
+		return
+	}
+
+	upgrades, err := provider.PlanSchemaUpgrade()
+	if err != nil {
+		log.Fatalf("Unable to plan the upgrade of the storage schema: %v", err)
+	}
+
+	if len(upgrades) == 0 {
+		log.Printf("Storage schema is up to date, there are no pending migrations")
+		return
+	}
+
+	for _, upgrade := range upgrades {
+		fmt.Printf("-- Migration to v%d\n", upgrade.Version)
+
+		for _, statement := range upgrade.Statements {
+			fmt.Printf("%s;\n", statement)
+		}
+	}
+}
+
+func migrateStorageDown(cmd *cobra.Command, args []string) {
+	provider := getStorageProvider()
+	target := storage.SchemaVersion(storageMigrateTarget)
+
+	downgrades, err := provider.PlanSchemaDowngrade(target)
+	if err != nil {
+		log.Fatalf("Unable to plan the downgrade of the storage schema: %v", err)
+	}
+
+	printStorageSchemaDowngrades(downgrades)
+
+	if !storageDestroyData && storageSchemaDowngradesDestroyData(downgrades) {
+		log.Fatalf("The storage schema was not downgraded as it destroys the data listed above, run the command again with --destroy-data to downgrade it")
+	}
+
+	if _, err = provider.DowngradeSchema(target); err != nil {
+		log.Fatalf("Unable to downgrade the storage schema: %v", err)
+	}
+
+	log.Printf("Storage schema downgraded to v%d", target)
+}
+
+// storageSchemaDowngradesDestroyData returns true if at least one of the downgrades drops a table or a column holding
+// data.
+func storageSchemaDowngradesDestroyData(downgrades []storage.SchemaDowngrade) bool {
+	for _, downgrade := range downgrades {
+		if downgrade.DestroysData() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// printStorageSchemaDowngrades prints the tables and the columns dropped by each migration reverted by a downgrade of
+// the schema, along with the number of rows holding data in each of them.
+func printStorageSchemaDowngrades(downgrades []storage.SchemaDowngrade) {
+	for _, downgrade := range downgrades {
+		fmt.Printf("Reverting the migration to v%d:\n", downgrade.Version)
+
+		for _, table := range downgrade.Tables {
+			fmt.Printf("  drops table %s and its %d rows\n", table.Table, table.Rows)
+		}
+
+		for _, column := range downgrade.Columns {
+			fmt.Printf("  drops column %s of table %s holding data in %d rows\n", column.Column, column.Table, column.Rows)
+		}
+	}
+}
+
+// StorageCmd storage management command.
+var StorageCmd = &cobra.Command{
+	Use:   "storage",
+	Short: "Commands related to the storage backend",
+}
+
+// StorageExportCmd storage export command.
+var StorageExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export all of the data of the storage backend to a portable JSON file",
+	Run:   exportStorage,
+}
+
+// StorageImportCmd storage import command.
+var StorageImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import the data of a JSON file created by the export command into the storage backend",
+	Run:   importStorage,
+}
+
+// StorageUserCmd storage user management command.
+var StorageUserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Commands related to the data of a single user in the storage backend",
+}
+
+// StorageUserExportCmd storage user export command.
+var StorageUserExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export all of the data of a user in the storage backend to a JSON file",
+	Run:   exportStorageUser,
+}
+
+// StorageUserWebAuthnCmd storage user WebAuthn management command.
+var StorageUserWebAuthnCmd = &cobra.Command{
+	Use:   "webauthn",
+	Short: "Commands related to the passkeys registered by a user",
+}
+
+// StorageUserTOTPCmd storage user TOTP management command.
+var StorageUserTOTPCmd = &cobra.Command{
+	Use:   "totp",
+	Short: "Commands related to the TOTP token registered by a user",
+}
+
+// StorageUserTOTPSetCmd storage user TOTP set command.
+var StorageUserTOTPSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Register the secret of a TOTP token for a user, for example a hardware token provisioned by an administrator",
+	Run:   setStorageTOTPConfiguration,
+}
+
+// StorageUserHOTPCmd storage user HOTP management command.
+var StorageUserHOTPCmd = &cobra.Command{
+	Use:   "hotp",
+	Short: "Commands related to the HOTP token registered for a user",
+}
+
+// StorageUserHOTPSetCmd storage user HOTP set command.
+var StorageUserHOTPSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Register the secret and the counter of a counter-based hardware token for a user, replacing the previous one",
+	Run:   setStorageHOTPDevice,
+}
+
+// StorageUserHOTPDeleteCmd storage user HOTP delete command.
+var StorageUserHOTPDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete the HOTP token of a user",
+	Run:   deleteStorageHOTPDevice,
+}
+
+// StorageUserYubiKeyCmd storage user YubiKey OTP management command.
+var StorageUserYubiKeyCmd = &cobra.Command{
+	Use:   "yubikey",
+	Short: "Commands related to the YubiKeys generating Yubico OTPs registered for a user",
+}
+
+// StorageUserYubiKeyAddCmd storage user YubiKey OTP add command.
+var StorageUserYubiKeyAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Register the YubiKey which typed a one-time password for a user",
+	Run:   addStorageYubiKeyOTPDevice,
+}
+
+// StorageUserYubiKeyListCmd storage user YubiKey OTP list command.
+var StorageUserYubiKeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the YubiKeys registered for a user as JSON",
+	Run:   listStorageYubiKeyOTPDevices,
+}
+
+// StorageUserYubiKeyDeleteCmd storage user YubiKey OTP delete command.
+var StorageUserYubiKeyDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a YubiKey of a user, for example when the user lost it",
+	Run:   deleteStorageYubiKeyOTPDevice,
+}
+
+// StorageUser2FACmd storage user second factor management command.
+var StorageUser2FACmd = &cobra.Command{
+	Use:   "2fa",
+	Short: "Commands related to all of the second factor methods of a user",
+}
+
+// StorageUser2FAResetCmd storage user second factor reset command.
+var StorageUser2FAResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Delete the selected second factor methods of a user so they can register them again, for example when they lost their phone",
+	Run:   resetStorageSecondFactor,
+}
+
+// StorageUserWebAuthnListCmd storage user WebAuthn list command.
+var StorageUserWebAuthnListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the passkeys registered by a user as JSON",
+	Run:   listStorageWebAuthnCredentials,
+}
+
+// StorageUserWebAuthnDeleteCmd storage user WebAuthn delete command.
+var StorageUserWebAuthnDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a passkey of a user, for example when the user lost their security key",
+	Run:   deleteStorageWebAuthnCredential,
+}
+
+// StorageUserWebAuthnRestoreCmd storage user WebAuthn restore command.
+var StorageUserWebAuthnRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a passkey deleted by a user which was not purged yet",
+	Run:   restoreStorageWebAuthnCredential,
+}
+
+// StorageUserWebAuthnRenameCmd storage user WebAuthn rename command.
+var StorageUserWebAuthnRenameCmd = &cobra.Command{
+	Use:   "rename",
+	Short: "Change the description of a passkey of a user",
+	Run:   renameStorageWebAuthnCredential,
+}
+
+// StorageUserWebAuthnImportCmd storage user WebAuthn import command.
+var StorageUserWebAuthnImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import the passkeys a user registered with another identity provider from a JSON file",
+	Run:   importStorageWebAuthnCredentials,
+}
+
+// StoragePruneCmd storage prune command.
+var StoragePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete the authentication logs which are not retained and purge the passkeys deleted before the grace period",
+	Run:   pruneStorage,
+}
+
+// StorageMaintenanceCmd storage maintenance command.
+var StorageMaintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Reclaim the space of deleted rows and refresh the statistics of the tables of the storage backend",
+	Run:   maintainStorage,
+}
+
+// StorageBackupCmd storage backup command.
+var StorageBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Write an encrypted backup of all of the data of the storage backend to a file",
+	Run:   backupStorage,
+}
+
+// StorageRestoreCmd storage restore command.
+var StorageRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore an encrypted backup created by the backup command into the storage backend",
+	Run:   restoreStorage,
+}
+
+// StorageMigrateCmd storage migrate command.
+var StorageMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Commands related to the migrations of the schema of the storage backend",
+}
+
+// StorageMigrateUpCmd storage migrate up command.
+var StorageMigrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Upgrade the schema of the storage backend to the current version, or print the pending migrations",
+	Run:   migrateStorageUp,
+}
+
+// StorageMigrateDownCmd storage migrate down command.
+var StorageMigrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Downgrade the schema of the storage backend to a previous version, listing the data destroyed by each migration",
+	Run:   migrateStorageDown,
+}
+
+// StorageBanCmd storage ban management command.
+var StorageBanCmd = &cobra.Command{
+	Use:   "ban",
+	Short: "Commands related to the bans of the users persisted in the storage backend",
+}
+
+// StorageBanAddCmd storage ban add command.
+var StorageBanAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Ban a user for a duration or permanently",
+	Run:   addStorageBan,
+}
+
+// StorageBanRevokeCmd storage ban revoke command.
+var StorageBanRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Lift the active bans of a user",
+	Run:   revokeStorageBan,
+}
+
+// StorageBanListCmd storage ban list command.
+var StorageBanListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the active bans as JSON",
+	Run:   listStorageBans,
+}
+
+// StorageAPIKeyCmd storage API key management command.
+var StorageAPIKeyCmd = &cobra.Command{
+	Use:   "api-key",
+	Short: "Commands related to the API keys persisted in the storage backend",
+}
+
+// StorageAPIKeyAddCmd storage API key add command.
+var StorageAPIKeyAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Create an API key and print it",
+	Run:   addStorageAPIKey,
+}
+
+// StorageAPIKeyRevokeCmd storage API key revoke command.
+var StorageAPIKeyRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revoke the API keys with a name",
+	Run:   revokeStorageAPIKey,
+}
+
+// StorageAPIKeyListCmd storage API key list command.
+var StorageAPIKeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the API keys which are not revoked as JSON",
+	Run:   listStorageAPIKeys,
+}
+
+// StorageAuditCmd storage audit management command.
+var StorageAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Commands related to the audit events persisted in the storage backend",
+}
+
+// StorageAuditVerifyCmd storage audit verify command.
+var StorageAuditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the hash chain of the audit events and print the hash of the last one as JSON",
+	Run:   verifyStorageAuditEvents,
+}
+
+// StorageAuditListCmd storage audit list command.
+var StorageAuditListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List a page of the audit events as JSON, the most recent first",
+	Run:   listStorageAuditEvents,
+}
+
+// StorageLogsCmd storage authentication logs management command.
+var StorageLogsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Commands related to the authentication logs persisted in the storage backend",
+}
+
+// StorageLogsListCmd storage logs list command.
+var StorageLogsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List a page of the authentication logs as JSON, the most recent first",
+	Run:   listStorageAuthenticationLogs,
+}
@@ -83,7 +83,7 @@ func TestShouldErrorSecretNotExist(t *testing.T) {
 
 	_, errors := Read("./test_resources/config.yml")
 
-	require.Len(t, errors, 12)
+	require.Len(t, errors, 13)
 
 	if runtime.GOOS == windows {
 		assert.EqualError(t, errors[0], "error loading secret file (jwt_secret): open /path/not/existjwt: The system cannot find the path specified.")
@@ -106,9 +106,10 @@ func TestShouldErrorSecretNotExist(t *testing.T) {
 	}
 
 	assert.EqualError(t, errors[8], "Provide a JWT secret using \"jwt_secret\" key")
-	assert.EqualError(t, errors[9], "Please provide a password to connect to the LDAP server")
-	assert.EqualError(t, errors[10], "The session secret must be set when using the redis sentinel session provider")
-	assert.EqualError(t, errors[11], "the SQL username and password must be provided")
+	assert.EqualError(t, errors[9], "Duo API integration_key and secret_key must be provided when client_id is not set")
+	assert.EqualError(t, errors[10], "Please provide a password to connect to the LDAP server")
+	assert.EqualError(t, errors[11], "The session secret must be set when using the redis sentinel session provider")
+	assert.EqualError(t, errors[12], "the SQL username and password must be provided")
 }
 
 func TestShouldErrorPermissionsOnLocalFS(t *testing.T) {
@@ -216,6 +217,7 @@ func TestShouldParseAltConfigFile(t *testing.T) {
 	require.NoError(t, os.Setenv("AUTHELIA_AUTHENTICATION_BACKEND_LDAP_PASSWORD_FILE", dir+"authentication"))
 	require.NoError(t, os.Setenv("AUTHELIA_JWT_SECRET_FILE", dir+"jwt"))
 	require.NoError(t, os.Setenv("AUTHELIA_SESSION_SECRET_FILE", dir+"session"))
+	require.NoError(t, os.Setenv("AUTHELIA_DUO_API_SECRET_KEY_FILE", dir+"duo"))
 
 	config, errors := Read("./test_resources/config_alt.yml")
 	require.Len(t, errors, 0)
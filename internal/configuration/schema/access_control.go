@@ -2,9 +2,25 @@ package schema
 
 // AccessControlConfiguration represents the configuration related to ACLs.
 type AccessControlConfiguration struct {
-	DefaultPolicy string       `mapstructure:"default_policy"`
-	Networks      []ACLNetwork `mapstructure:"networks"`
-	Rules         []ACLRule    `mapstructure:"rules"`
+	DefaultPolicy string                            `mapstructure:"default_policy"`
+	Networks      []ACLNetwork                      `mapstructure:"networks"`
+	Rules         []ACLRule                         `mapstructure:"rules"`
+	Shadow        *AccessControlShadowConfiguration `mapstructure:"shadow"`
+	OPA           *AccessControlOPAConfiguration    `mapstructure:"opa"`
+}
+
+// AccessControlOPAConfiguration represents the configuration of the Open Policy Agent queried after the ACLs, which can
+// refuse the requests they authorize.
+type AccessControlOPAConfiguration struct {
+	URL     string `mapstructure:"url"`
+	Timeout string `mapstructure:"timeout"`
+}
+
+// AccessControlShadowConfiguration represents a set of ACLs evaluated along with the live ones without affecting the
+// decisions, only logging when they differ.
+type AccessControlShadowConfiguration struct {
+	DefaultPolicy string    `mapstructure:"default_policy"`
+	Rules         []ACLRule `mapstructure:"rules"`
 }
 
 // ACLNetwork represents one ACL network group entry; "weak" coerces a single value into slice.
@@ -15,12 +31,19 @@ type ACLNetwork struct {
 
 // ACLRule represents one ACL rule entry; "weak" coerces a single value into slice.
 type ACLRule struct {
-	Domains   []string   `mapstructure:"domain,weak"`
-	Policy    string     `mapstructure:"policy"`
-	Subjects  [][]string `mapstructure:"subject,weak"`
-	Networks  []string   `mapstructure:"networks"`
-	Resources []string   `mapstructure:"resources"`
-	Methods   []string   `mapstructure:"methods"`
+	Domains              []string   `mapstructure:"domain,weak"`
+	Policy               string     `mapstructure:"policy"`
+	Subjects             [][]string `mapstructure:"subject,weak"`
+	Networks             []string   `mapstructure:"networks"`
+	Resources            []string   `mapstructure:"resources"`
+	Methods              []string   `mapstructure:"methods"`
+	MaxAuthenticationAge string     `mapstructure:"max_authentication_age"`
+	SecondFactorMethods  []string   `mapstructure:"second_factor_methods,weak"`
+}
+
+// DefaultAccessControlOPAConfiguration represents the default configuration of the Open Policy Agent.
+var DefaultAccessControlOPAConfiguration = AccessControlOPAConfiguration{
+	Timeout: "2s",
 }
 
 // DefaultACLNetwork represents the default configuration related to access control network group configuration.
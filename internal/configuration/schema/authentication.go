@@ -13,6 +13,7 @@ type LDAPAuthenticationBackendConfiguration struct {
 	UsernameAttribute    string     `mapstructure:"username_attribute"`
 	MailAttribute        string     `mapstructure:"mail_attribute"`
 	DisplayNameAttribute string     `mapstructure:"display_name_attribute"`
+	ExtraAttributes      []string   `mapstructure:"extra_attributes"`
 	User                 string     `mapstructure:"user"`
 	Password             string     `mapstructure:"password"`
 	StartTLS             bool       `mapstructure:"start_tls"`
@@ -35,12 +36,48 @@ type PasswordConfiguration struct {
 	Parallelism int    `mapstructure:"parallelism"`
 }
 
+// TrustedHeaderAuthenticationConfiguration represents the configuration of the authentication of the requests by a
+// header containing the username, set by a trusted reverse proxy.
+type TrustedHeaderAuthenticationConfiguration struct {
+	Header          string   `mapstructure:"header"`
+	TrustedNetworks []string `mapstructure:"trusted_networks"`
+	SecretHeader    string   `mapstructure:"secret_header"`
+	Secret          string   `mapstructure:"secret"`
+}
+
+// ClientCertificateAuthenticationConfiguration represents the configuration of the authentication of the requests by
+// a client certificate, verified by a reverse proxy and forwarded in a header.
+type ClientCertificateAuthenticationConfiguration struct {
+	Header               string   `mapstructure:"header"`
+	CertificateAuthority string   `mapstructure:"certificate_authority"`
+	TrustedNetworks      []string `mapstructure:"trusted_networks"`
+	UsernameAttribute    string   `mapstructure:"username_attribute"`
+	UsernamePattern      string   `mapstructure:"username_pattern"`
+	FirstFactor          bool     `mapstructure:"first_factor"`
+}
+
 // AuthenticationBackendConfiguration represents the configuration related to the authentication backend.
 type AuthenticationBackendConfiguration struct {
-	DisableResetPassword bool                                    `mapstructure:"disable_reset_password"`
-	RefreshInterval      string                                  `mapstructure:"refresh_interval"`
-	LDAP                 *LDAPAuthenticationBackendConfiguration `mapstructure:"ldap"`
-	File                 *FileAuthenticationBackendConfiguration `mapstructure:"file"`
+	DisableResetPassword bool                                          `mapstructure:"disable_reset_password"`
+	RefreshInterval      string                                        `mapstructure:"refresh_interval"`
+	RefreshAsync         bool                                          `mapstructure:"refresh_async"`
+	LDAP                 *LDAPAuthenticationBackendConfiguration       `mapstructure:"ldap"`
+	File                 *FileAuthenticationBackendConfiguration       `mapstructure:"file"`
+	TrustedHeader        *TrustedHeaderAuthenticationConfiguration     `mapstructure:"trusted_header"`
+	ClientCertificate    *ClientCertificateAuthenticationConfiguration `mapstructure:"client_certificate"`
+}
+
+// DefaultTrustedHeaderAuthenticationConfiguration represents the default configuration of the trusted header
+// authentication.
+var DefaultTrustedHeaderAuthenticationConfiguration = TrustedHeaderAuthenticationConfiguration{
+	SecretHeader: "X-Proxy-Secret",
+}
+
+// DefaultClientCertificateAuthenticationConfiguration represents the default configuration of the client certificate
+// authentication.
+var DefaultClientCertificateAuthenticationConfiguration = ClientCertificateAuthenticationConfiguration{
+	Header:            "X-Forwarded-Tls-Client-Cert",
+	UsernameAttribute: "common_name",
 }
 
 // DefaultPasswordConfiguration represents the default configuration related to Argon2id hashing.
@@ -14,14 +14,25 @@ type Configuration struct {
 	JWTSecret             string `mapstructure:"jwt_secret"`
 	DefaultRedirectionURL string `mapstructure:"default_redirection_url"`
 
-	IdentityProviders     IdentityProvidersConfiguration     `mapstructure:"identity_providers"`
-	AuthenticationBackend AuthenticationBackendConfiguration `mapstructure:"authentication_backend"`
-	Session               SessionConfiguration               `mapstructure:"session"`
-	TOTP                  *TOTPConfiguration                 `mapstructure:"totp"`
-	DuoAPI                *DuoAPIConfiguration               `mapstructure:"duo_api"`
-	AccessControl         AccessControlConfiguration         `mapstructure:"access_control"`
-	Regulation            *RegulationConfiguration           `mapstructure:"regulation"`
-	Storage               StorageConfiguration               `mapstructure:"storage"`
-	Notifier              *NotifierConfiguration             `mapstructure:"notifier"`
-	Server                ServerConfiguration                `mapstructure:"server"`
+	IdentityProviders      IdentityProvidersConfiguration       `mapstructure:"identity_providers"`
+	AuthenticationBackend  AuthenticationBackendConfiguration   `mapstructure:"authentication_backend"`
+	Session                SessionConfiguration                 `mapstructure:"session"`
+	TOTP                   *TOTPConfiguration                   `mapstructure:"totp"`
+	WebAuthn               *WebAuthnConfiguration               `mapstructure:"webauthn"`
+	DuoAPI                 *DuoAPIConfiguration                 `mapstructure:"duo_api"`
+	EmailOneTimeCode       *EmailOneTimeCodeConfiguration       `mapstructure:"email_one_time_code"`
+	PushApproval           *PushApprovalConfiguration           `mapstructure:"push_approval"`
+	PrivacyIDEA            *PrivacyIDEAConfiguration            `mapstructure:"privacyidea"`
+	HOTP                   *HOTPConfiguration                   `mapstructure:"hotp"`
+	YubiKeyOTP             *YubiKeyOTPConfiguration             `mapstructure:"yubikey_otp"`
+	TrustedDevices         *TrustedDevicesConfiguration         `mapstructure:"trusted_devices"`
+	SecondFactorEnrollment *SecondFactorEnrollmentConfiguration `mapstructure:"second_factor_enrollment"`
+	SecondFactorPolicies   []SecondFactorPolicyConfiguration    `mapstructure:"second_factor_policies"`
+	SecondFactorFallback   *SecondFactorFallbackConfiguration   `mapstructure:"second_factor_fallback"`
+	AccessControl          AccessControlConfiguration           `mapstructure:"access_control"`
+	Regulation             *RegulationConfiguration             `mapstructure:"regulation"`
+	GeoIP                  *GeoIPConfiguration                  `mapstructure:"geoip"`
+	Storage                StorageConfiguration                 `mapstructure:"storage"`
+	Notifier               *NotifierConfiguration               `mapstructure:"notifier"`
+	Server                 ServerConfiguration                  `mapstructure:"server"`
 }
@@ -23,3 +23,6 @@ const LDAPImplementationCustom = "custom"
 
 // LDAPImplementationActiveDirectory is the string for the Active Directory LDAP implementation.
 const LDAPImplementationActiveDirectory = "activedirectory"
+
+// DuoDefaultIntegrationName is the name of the Duo integration configured at the root of the duo_api section.
+const DuoDefaultIntegrationName = "default"
@@ -5,4 +5,29 @@ type DuoAPIConfiguration struct {
 	Hostname       string `mapstructure:"hostname"`
 	IntegrationKey string `mapstructure:"integration_key"`
 	SecretKey      string `mapstructure:"secret_key"`
+
+	// The credentials of the Web SDK application, the Universal Prompt is used instead of the Auth API when set.
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+
+	// The integrations used in addition to the one above, for the users of some groups or when Duo is failing.
+	Integrations []DuoIntegrationConfiguration `mapstructure:"integrations"`
+}
+
+// DuoIntegrationConfiguration represents an additional Duo integration. The users of the groups are routed to the
+// integrations listing one of them instead of the default one, the integrations without groups are used when the
+// default one is failing.
+type DuoIntegrationConfiguration struct {
+	Name           string   `mapstructure:"name"`
+	Hostname       string   `mapstructure:"hostname"`
+	IntegrationKey string   `mapstructure:"integration_key"`
+	SecretKey      string   `mapstructure:"secret_key"`
+	ClientID       string   `mapstructure:"client_id"`
+	ClientSecret   string   `mapstructure:"client_secret"`
+	Groups         []string `mapstructure:"groups"`
+}
+
+// IsUniversalPrompt returns true when the users are redirected to the Duo Universal Prompt.
+func (c DuoAPIConfiguration) IsUniversalPrompt() bool {
+	return c.ClientID != ""
 }
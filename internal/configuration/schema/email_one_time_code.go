@@ -0,0 +1,13 @@
+package schema
+
+// EmailOneTimeCodeConfiguration represents the configuration of the one-time codes sent by email as a second factor.
+type EmailOneTimeCodeConfiguration struct {
+	Length   int    `mapstructure:"length"`
+	Lifespan string `mapstructure:"lifespan"`
+}
+
+// DefaultEmailOneTimeCodeConfiguration represents the default configuration of the one-time codes sent by email.
+var DefaultEmailOneTimeCodeConfiguration = EmailOneTimeCodeConfiguration{
+	Length:   6,
+	Lifespan: "5m",
+}
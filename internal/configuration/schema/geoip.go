@@ -0,0 +1,6 @@
+package schema
+
+// GeoIPConfiguration represents the configuration of the GeoIP database locating the clients in their country.
+type GeoIPConfiguration struct {
+	Database string `mapstructure:"database"`
+}
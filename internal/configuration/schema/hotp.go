@@ -0,0 +1,13 @@
+package schema
+
+// HOTPConfiguration represents the configuration of the counter-based one-time passwords of the hardware tokens.
+type HOTPConfiguration struct {
+	LookAhead    int `mapstructure:"look_ahead"`
+	ResyncWindow int `mapstructure:"resync_window"`
+}
+
+// DefaultHOTPConfiguration represents the default configuration of the counter-based one-time passwords.
+var DefaultHOTPConfiguration = HOTPConfiguration{
+	LookAhead:    10,
+	ResyncWindow: 100,
+}
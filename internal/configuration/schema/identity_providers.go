@@ -11,6 +11,8 @@ type OpenIDConnectConfiguration struct {
 	HMACSecret       string `mapstructure:"hmac_secret"`
 	IssuerPrivateKey string `mapstructure:"issuer_private_key"`
 
+	EnableBearerAuthorization bool `mapstructure:"enable_bearer_authorization"`
+
 	Clients []OpenIDConnectClientConfiguration `mapstructure:"clients"`
 }
 
@@ -22,6 +24,7 @@ type OpenIDConnectClientConfiguration struct {
 	RedirectURIs  []string `mapstructure:"redirect_uris"`
 	Policy        string   `mapstructure:"authorization_policy"`
 	Scopes        []string `mapstructure:"scopes"`
+	Audience      []string `mapstructure:"audience"`
 	GrantTypes    []string `mapstructure:"grant_types"`
 	ResponseTypes []string `mapstructure:"response_types"`
 }
@@ -0,0 +1,14 @@
+package schema
+
+// PrivacyIDEAConfiguration represents the configuration related to the privacyIDEA server.
+type PrivacyIDEAConfiguration struct {
+	URL         string `mapstructure:"url"`
+	Realm       string `mapstructure:"realm"`
+	AdminToken  string `mapstructure:"admin_token"`
+	PushTimeout string `mapstructure:"push_timeout"`
+}
+
+// DefaultPrivacyIDEAConfiguration represents the default configuration related to the privacyIDEA server.
+var DefaultPrivacyIDEAConfiguration = PrivacyIDEAConfiguration{
+	PushTimeout: "60s",
+}
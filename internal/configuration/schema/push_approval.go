@@ -0,0 +1,13 @@
+package schema
+
+// PushApprovalConfiguration represents the configuration of the approval requests pushed to a webhook.
+type PushApprovalConfiguration struct {
+	URL     string `mapstructure:"url"`
+	Secret  string `mapstructure:"secret"`
+	Timeout string `mapstructure:"timeout"`
+}
+
+// DefaultPushApprovalConfiguration represents the default configuration of the approval requests pushed to a webhook.
+var DefaultPushApprovalConfiguration = PushApprovalConfiguration{
+	Timeout: "60s",
+}
@@ -0,0 +1,13 @@
+package schema
+
+// SecondFactorEnrollmentConfiguration represents the configuration of the policy requiring the users to enroll a second
+// factor.
+type SecondFactorEnrollmentConfiguration struct {
+	Groups      []string `mapstructure:"groups"`
+	GracePeriod string   `mapstructure:"grace_period"`
+}
+
+// DefaultSecondFactorEnrollmentConfiguration represents the default configuration of the second factor enrollment.
+var DefaultSecondFactorEnrollmentConfiguration = SecondFactorEnrollmentConfiguration{
+	GracePeriod: "7d",
+}
@@ -0,0 +1,12 @@
+package schema
+
+// SecondFactorFallbackConfiguration represents the configuration of the order in which the portal offers the second
+// factor methods of the users.
+type SecondFactorFallbackConfiguration struct {
+	Methods []string `mapstructure:"methods"`
+}
+
+// DefaultSecondFactorFallbackConfiguration represents the default configuration of the second factor fallback.
+var DefaultSecondFactorFallbackConfiguration = SecondFactorFallbackConfiguration{
+	Methods: []string{"u2f", "totp", "hotp", "yubikey_otp", "mobile_push", "push_approval", "privacyidea", "email"},
+}
@@ -0,0 +1,8 @@
+package schema
+
+// SecondFactorPolicyConfiguration represents the configuration of the second factor methods the users of groups are
+// allowed to use.
+type SecondFactorPolicyConfiguration struct {
+	Groups  []string `mapstructure:"groups"`
+	Methods []string `mapstructure:"methods"`
+}
@@ -5,10 +5,151 @@ type ServerConfiguration struct {
 	Path            string `mapstructure:"path"`
 	ReadBufferSize  int    `mapstructure:"read_buffer_size"`
 	WriteBufferSize int    `mapstructure:"write_buffer_size"`
+
+	VerifyHeaders       ServerVerifyHeadersConfiguration       `mapstructure:"verify_headers"`
+	VerifyObjectHeaders ServerVerifyObjectHeadersConfiguration `mapstructure:"verify_object_headers"`
+	VerifyBasicAuth     ServerVerifyBasicAuthConfiguration     `mapstructure:"verify_basic_auth"`
+	VerifyRedirect      ServerVerifyRedirectConfiguration      `mapstructure:"verify_redirect"`
+	VerifyRateLimit     ServerVerifyRateLimitConfiguration     `mapstructure:"verify_rate_limit"`
+	VerifyBypass        []ServerVerifyBypassConfiguration      `mapstructure:"verify_bypass"`
+	VerifyTemplates     ServerVerifyTemplatesConfiguration     `mapstructure:"verify_templates"`
+	VerifyCacheDuration string                                 `mapstructure:"verify_cache_duration"`
+
+	VerifyImpersonation ServerVerifyImpersonationConfiguration `mapstructure:"verify_impersonation"`
+	VerifyStrategies    []ServerVerifyStrategyConfiguration    `mapstructure:"verify_strategies"`
+
+	VerifyWebSocketCacheDuration string `mapstructure:"verify_websocket_cache_duration"`
+	VerifyMaxAuthenticationAge   string `mapstructure:"verify_max_authentication_age"`
+
+	// VerifyGRPC enables the gRPC mode of the verify endpoint for the requests with a gRPC content type.
+	VerifyGRPC bool `mapstructure:"verify_grpc"`
+}
+
+// ServerVerifyHeadersConfiguration represents the configuration of the headers set in the responses of the verify
+// endpoint.
+type ServerVerifyHeadersConfiguration struct {
+	User    string                            `mapstructure:"user"`
+	Groups  string                            `mapstructure:"groups"`
+	Name    string                            `mapstructure:"name"`
+	Email   string                            `mapstructure:"email"`
+	Exclude []string                          `mapstructure:"exclude"`
+	Static  []ServerStaticHeaderConfiguration `mapstructure:"static"`
+
+	// The optional headers describing the authorization decision, not set when their name is empty.
+	Rule    string `mapstructure:"rule"`
+	Level   string `mapstructure:"level"`
+	Methods string `mapstructure:"methods"`
+
+	Attributes []ServerVerifyAttributeHeaderConfiguration `mapstructure:"attributes"`
+
+	Signature ServerVerifySignatureConfiguration `mapstructure:"signature"`
+}
+
+// ServerVerifySignatureConfiguration represents the configuration of the header signing the forwarded identity with a
+// secret shared with the backends, disabled when the secret is empty.
+type ServerVerifySignatureConfiguration struct {
+	Secret string `mapstructure:"secret"`
+	Header string `mapstructure:"header"`
+}
+
+// ServerVerifyAttributeHeaderConfiguration represents a header set to the values of an attribute of the user.
+type ServerVerifyAttributeHeaderConfiguration struct {
+	Attribute string `mapstructure:"attribute"`
+	Header    string `mapstructure:"header"`
+}
+
+// ServerVerifyObjectHeadersConfiguration represents the configuration of the request headers the verify endpoint reads
+// the target URL and method from, in addition to the X-Original-URL and X-Forwarded-* headers.
+type ServerVerifyObjectHeadersConfiguration struct {
+	URL    string `mapstructure:"url"`
+	Method string `mapstructure:"method"`
+}
+
+// ServerVerifyBasicAuthConfiguration represents the configuration of the Basic challenge sent by the verify endpoint
+// when the credentials of a request are missing or wrong.
+type ServerVerifyBasicAuthConfiguration struct {
+	Realm             string `mapstructure:"realm"`
+	Charset           string `mapstructure:"charset"`
+	ProxyAuthenticate bool   `mapstructure:"proxy_authenticate"`
+}
+
+// ServerVerifyRedirectConfiguration represents the configuration of the redirections of the verify endpoint to the
+// portal.
+type ServerVerifyRedirectConfiguration struct {
+	StatusCode      int  `mapstructure:"status_code"`
+	XHRUnauthorized bool `mapstructure:"xhr_unauthorized"`
+}
+
+// ServerVerifyRateLimitConfiguration represents the configuration of the rate limit of the requests of each remote IP to
+// the verify endpoint, the remote IP being forwarded by the trusted proxies.
+type ServerVerifyRateLimitConfiguration struct {
+	Requests       int      `mapstructure:"requests"`
+	Burst          int      `mapstructure:"burst"`
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+}
+
+// ServerVerifyBypassConfiguration represents a matcher of the requests the verify endpoint allows without looking up
+// the session, such as the health checks. A request matches when it targets one of the domains and matches every other
+// criterion which is set.
+type ServerVerifyBypassConfiguration struct {
+	Domains    []string `mapstructure:"domains"`
+	Paths      []string `mapstructure:"paths"`
+	UserAgents []string `mapstructure:"user_agents"`
+	Networks   []string `mapstructure:"networks"`
+}
+
+// ServerVerifyStrategyConfiguration represents an authentication strategy of the verify endpoint and whether the next
+// strategies are tried when it fails to authenticate a request.
+type ServerVerifyStrategyConfiguration struct {
+	Name        string `mapstructure:"name"`
+	Fallthrough bool   `mapstructure:"fallthrough"`
+}
+
+// ServerVerifyImpersonationConfiguration represents the configuration of the impersonation of the users by the
+// members of the administration groups authenticated with the session cookie through a request header of the verify
+// endpoint, disabled when the header is empty. The impersonated users must be members of the target groups when they are
+// set.
+type ServerVerifyImpersonationConfiguration struct {
+	Header       string   `mapstructure:"header"`
+	Groups       []string `mapstructure:"groups"`
+	TargetGroups []string `mapstructure:"target_groups"`
+}
+
+// ServerVerifyTemplatesConfiguration represents the paths of the templates of the bodies of the 401 and 403 responses
+// of the verify endpoint, in HTML and JSON.
+type ServerVerifyTemplatesConfiguration struct {
+	UnauthorizedHTML string `mapstructure:"unauthorized_html"`
+	UnauthorizedJSON string `mapstructure:"unauthorized_json"`
+	ForbiddenHTML    string `mapstructure:"forbidden_html"`
+	ForbiddenJSON    string `mapstructure:"forbidden_json"`
+}
+
+// ServerStaticHeaderConfiguration represents a header with a static value.
+type ServerStaticHeaderConfiguration struct {
+	Name  string `mapstructure:"name"`
+	Value string `mapstructure:"value"`
 }
 
 // DefaultServerConfiguration represents the default values of the ServerConfiguration.
 var DefaultServerConfiguration = ServerConfiguration{
 	ReadBufferSize:  4096,
 	WriteBufferSize: 4096,
+	VerifyHeaders: ServerVerifyHeadersConfiguration{
+		User:   "Remote-User",
+		Groups: "Remote-Groups",
+		Name:   "Remote-Name",
+		Email:  "Remote-Email",
+		Signature: ServerVerifySignatureConfiguration{
+			Header: "Remote-Signature",
+		},
+	},
+	VerifyBasicAuth: ServerVerifyBasicAuthConfiguration{
+		Realm: "Authentication required",
+	},
+	VerifyRedirect: ServerVerifyRedirectConfiguration{
+		StatusCode: 302,
+	},
+	VerifyCacheDuration:          "0",
+	VerifyWebSocketCacheDuration: "0",
+	VerifyMaxAuthenticationAge:   "0",
 }
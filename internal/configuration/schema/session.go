@@ -28,16 +28,24 @@ type RedisSessionConfiguration struct {
 	HighAvailability         *RedisHighAvailabilityConfiguration `mapstructure:"high_availability"`
 }
 
+// SessionDomainConfiguration represents a root domain the session cookie is set for and the URL of the portal
+// protecting it.
+type SessionDomainConfiguration struct {
+	Domain      string `mapstructure:"domain"`
+	AutheliaURL string `mapstructure:"authelia_url"`
+}
+
 // SessionConfiguration represents the configuration related to user sessions.
 type SessionConfiguration struct {
-	Name               string                     `mapstructure:"name"`
-	Domain             string                     `mapstructure:"domain"`
-	SameSite           string                     `mapstructure:"same_site"`
-	Secret             string                     `mapstructure:"secret"`
-	Expiration         string                     `mapstructure:"expiration"`
-	Inactivity         string                     `mapstructure:"inactivity"`
-	RememberMeDuration string                     `mapstructure:"remember_me_duration"`
-	Redis              *RedisSessionConfiguration `mapstructure:"redis"`
+	Name               string                       `mapstructure:"name"`
+	Domain             string                       `mapstructure:"domain"`
+	Domains            []SessionDomainConfiguration `mapstructure:"domains"`
+	SameSite           string                       `mapstructure:"same_site"`
+	Secret             string                       `mapstructure:"secret"`
+	Expiration         string                       `mapstructure:"expiration"`
+	Inactivity         string                       `mapstructure:"inactivity"`
+	RememberMeDuration string                       `mapstructure:"remember_me_duration"`
+	Redis              *RedisSessionConfiguration   `mapstructure:"redis"`
 }
 
 // DefaultSessionConfiguration is the default session configuration.
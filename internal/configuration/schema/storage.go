@@ -2,16 +2,30 @@ package schema
 
 // LocalStorageConfiguration represents the configuration when using local storage.
 type LocalStorageConfiguration struct {
-	Path string `mapstructure:"path"`
+	Path        string `mapstructure:"path"`
+	JournalMode string `mapstructure:"journal_mode"`
+	BusyTimeout string `mapstructure:"busy_timeout"`
+	Synchronous string `mapstructure:"synchronous"`
+}
+
+// SQLReplicaConfiguration represents the configuration of a SQL read replica.
+type SQLReplicaConfiguration struct {
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port"`
 }
 
 // SQLStorageConfiguration represents the configuration of the SQL database.
 type SQLStorageConfiguration struct {
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
-	Database string `mapstructure:"database"`
-	Username string `mapstructure:"username"`
-	Password string `mapstructure:"password"`
+	Host            string                    `mapstructure:"host"`
+	Port            int                       `mapstructure:"port"`
+	Database        string                    `mapstructure:"database"`
+	Username        string                    `mapstructure:"username"`
+	Password        string                    `mapstructure:"password"`
+	ReadReplicas    []SQLReplicaConfiguration `mapstructure:"read_replicas"`
+	MaxOpenConns    int                       `mapstructure:"max_open_conns"`
+	MaxIdleConns    int                       `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime string                    `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime string                    `mapstructure:"conn_max_idle_time"`
 }
 
 // MySQLStorageConfiguration represents the configuration of a MySQL database.
@@ -25,9 +39,80 @@ type PostgreSQLStorageConfiguration struct {
 	SSLMode                 string `mapstructure:"sslmode"`
 }
 
+// CockroachDBStorageConfiguration represents the configuration of a CockroachDB database.
+type CockroachDBStorageConfiguration struct {
+	SQLStorageConfiguration `mapstructure:",squash"`
+	SSLMode                 string `mapstructure:"sslmode"`
+}
+
+// StorageRetryConfiguration represents the configuration of the retries of storage operations which failed with a
+// transient error.
+type StorageRetryConfiguration struct {
+	MaxAttempts int `mapstructure:"max_attempts"`
+	Backoff     int `mapstructure:"backoff"`
+	MaxBackoff  int `mapstructure:"max_backoff"`
+}
+
+// StorageAuthenticationLogsConfiguration represents the configuration of the retention of the authentication logs.
+type StorageAuthenticationLogsConfiguration struct {
+	MaxAge        string `mapstructure:"max_age"`
+	MaxCount      int    `mapstructure:"max_count"`
+	PruneInterval string `mapstructure:"prune_interval"`
+}
+
+// StorageWebAuthnCredentialsConfiguration represents the configuration of the purge of the deleted WebAuthn credentials.
+type StorageWebAuthnCredentialsConfiguration struct {
+	GracePeriod   string `mapstructure:"grace_period"`
+	PurgeInterval string `mapstructure:"purge_interval"`
+}
+
+// StorageCacheConfiguration represents the configuration of the cache of the reads made on every authentication.
+type StorageCacheConfiguration struct {
+	TTL string `mapstructure:"ttl"`
+}
+
+// StorageMaintenanceConfiguration represents the configuration of the scheduled maintenance of the storage backend.
+type StorageMaintenanceConfiguration struct {
+	Interval string `mapstructure:"interval"`
+}
+
 // StorageConfiguration represents the configuration of the storage backend.
 type StorageConfiguration struct {
-	Local      *LocalStorageConfiguration      `mapstructure:"local"`
-	MySQL      *MySQLStorageConfiguration      `mapstructure:"mysql"`
-	PostgreSQL *PostgreSQLStorageConfiguration `mapstructure:"postgres"`
+	Local       *LocalStorageConfiguration       `mapstructure:"local"`
+	MySQL       *MySQLStorageConfiguration       `mapstructure:"mysql"`
+	PostgreSQL  *PostgreSQLStorageConfiguration  `mapstructure:"postgres"`
+	CockroachDB *CockroachDBStorageConfiguration `mapstructure:"cockroachdb"`
+
+	TablePrefix string                    `mapstructure:"table_prefix"`
+	Retry       StorageRetryConfiguration `mapstructure:"retry"`
+
+	AuthenticationLogs  StorageAuthenticationLogsConfiguration  `mapstructure:"authentication_logs"`
+	WebAuthnCredentials StorageWebAuthnCredentialsConfiguration `mapstructure:"webauthn_credentials"`
+	Cache               StorageCacheConfiguration               `mapstructure:"cache"`
+	Maintenance         StorageMaintenanceConfiguration         `mapstructure:"maintenance"`
+
+	// DisableSchemaUpgrade prevents the providers from upgrading the schema when they are created, for the commands
+	// which only inspect it. It can't be configured.
+	DisableSchemaUpgrade bool `mapstructure:"-"`
+}
+
+// DefaultLocalStorageConfiguration represents the default local storage configuration.
+var DefaultLocalStorageConfiguration = LocalStorageConfiguration{
+	BusyTimeout: "5s",
+}
+
+// DefaultStorageConfiguration represents the default storage configuration.
+var DefaultStorageConfiguration = StorageConfiguration{
+	Retry: StorageRetryConfiguration{
+		MaxAttempts: 3,
+		Backoff:     100,
+		MaxBackoff:  1000,
+	},
+	AuthenticationLogs: StorageAuthenticationLogsConfiguration{
+		PruneInterval: "1h",
+	},
+	WebAuthnCredentials: StorageWebAuthnCredentialsConfiguration{
+		GracePeriod:   "30d",
+		PurgeInterval: "1h",
+	},
 }
@@ -0,0 +1,11 @@
+package schema
+
+// TrustedDevicesConfiguration represents the configuration of the devices the users trust to skip the second factor.
+type TrustedDevicesConfiguration struct {
+	Duration string `mapstructure:"duration"`
+}
+
+// DefaultTrustedDevicesConfiguration represents the default configuration of the trusted devices.
+var DefaultTrustedDevicesConfiguration = TrustedDevicesConfiguration{
+	Duration: "30d",
+}
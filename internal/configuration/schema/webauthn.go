@@ -0,0 +1,57 @@
+package schema
+
+// WebAuthnConfiguration represents the configuration related to WebAuthn options.
+type WebAuthnConfiguration struct {
+	EnablePasskeys                  bool     `mapstructure:"enable_passkeys"`
+	PasskeyMode                     string   `mapstructure:"passkey_mode"`
+	DisplayName                     string   `mapstructure:"display_name"`
+	Timeout                         string   `mapstructure:"timeout"`
+	RegistrationTimeout             string   `mapstructure:"registration_timeout"`
+	AuthenticationTimeout           string   `mapstructure:"authentication_timeout"`
+	RPID                            string   `mapstructure:"rp_id"`
+	Origins                         []string `mapstructure:"origins"`
+	ResidentKey                     string   `mapstructure:"resident_key"`
+	Attachment                      string   `mapstructure:"attachment"`
+	MaxPasskeys                     int      `mapstructure:"max_passkeys"`
+	SyncedPasskeys                  string   `mapstructure:"synced_passkeys"`
+	AttestationCertificateAuthority string   `mapstructure:"attestation_certificate_authority"`
+	AllowedAAGUIDs                  []string `mapstructure:"allowed_aaguids"`
+	DeniedAAGUIDs                   []string `mapstructure:"denied_aaguids"`
+	EnterpriseAttestationRPIDs      []string `mapstructure:"enterprise_attestation_rp_ids"`
+	EnterpriseAttestationAAGUIDs    []string `mapstructure:"enterprise_attestation_aaguids"`
+}
+
+// DefaultWebAuthnConfiguration represents default configuration parameters for WebAuthn.
+var DefaultWebAuthnConfiguration = WebAuthnConfiguration{
+	PasskeyMode:    "passwordless",
+	DisplayName:    "Authelia",
+	Timeout:        "60s",
+	ResidentKey:    "required",
+	Attachment:     "any",
+	MaxPasskeys:    10,
+	SyncedPasskeys: "allow",
+}
+
+// WebAuthnAAGUIDPresets are the named groups of authenticator models which can be used in place of their AAGUIDs in
+// the allowed and denied AAGUIDs.
+var WebAuthnAAGUIDPresets = map[string][]string{
+	"yubikey-5-series": {
+		"cb69481e-8ff7-4039-93ec-0a2729a154a8",
+		"ee882879-721c-4913-9775-3dfcce97072a",
+		"fa2b99dc-9e39-4257-8f92-4a30d23c4118",
+		"2fc0579f-8113-47ea-b116-bb5a8db9202a",
+		"73bb0cd4-e502-49b8-9c6f-b59445bf720b",
+		"c5ef55ff-ad9a-4b9f-b580-adebafe026d0",
+	},
+	"windows-hello": {
+		"08987058-cadc-4b81-b6e1-30de50dcbe96",
+		"9ddd1817-af5a-4672-a2b9-3e3dd95000a9",
+		"6028b017-b1d4-4c02-b4b3-afcdafc96bb2",
+	},
+	"icloud-keychain": {
+		"fbfc3007-154e-4ecc-8c0b-6e020557d7bd",
+	},
+	"google-password-manager": {
+		"ea9b8d66-4d01-1d21-3ce4-b6b48cb575d4",
+	},
+}
@@ -0,0 +1,16 @@
+package schema
+
+// YubiKeyOTPConfiguration represents the configuration related to the validation of the Yubico OTPs by YubiCloud or
+// a self-hosted validation server.
+type YubiKeyOTPConfiguration struct {
+	ClientID  string   `mapstructure:"client_id"`
+	SecretKey string   `mapstructure:"secret_key"`
+	APIURLs   []string `mapstructure:"api_urls"`
+	Timeout   string   `mapstructure:"timeout"`
+}
+
+// DefaultYubiKeyOTPConfiguration represents the default configuration related to the validation of the Yubico OTPs.
+var DefaultYubiKeyOTPConfiguration = YubiKeyOTPConfiguration{
+	APIURLs: []string{"https://api.yubico.com/wsapi/2.0/verify"},
+	Timeout: "5s",
+}
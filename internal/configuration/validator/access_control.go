@@ -3,6 +3,7 @@ package validator
 import (
 	"fmt"
 	"net"
+	"net/url"
 	"regexp"
 	"strings"
 
@@ -64,6 +65,57 @@ func ValidateAccessControl(configuration schema.AccessControlConfiguration, vali
 			}
 		}
 	}
+
+	if configuration.Shadow != nil {
+		validateShadowAccessControl(configuration, validator)
+	}
+
+	if configuration.OPA != nil {
+		validateOPAAccessControl(configuration.OPA, validator)
+	}
+}
+
+// validateOPAAccessControl validates the URL of the Open Policy Agent data API and the timeout of its queries.
+func validateOPAAccessControl(configuration *schema.AccessControlOPAConfiguration, validator *schema.StructValidator) {
+	if configuration.URL == "" {
+		validator.Push(fmt.Errorf("Access control opa url must be provided"))
+	} else if u, err := url.Parse(configuration.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		validator.Push(fmt.Errorf("Access control opa url %s must be an absolute http or https URL", configuration.URL))
+	}
+
+	if configuration.Timeout == "" {
+		configuration.Timeout = schema.DefaultAccessControlOPAConfiguration.Timeout
+	} else if _, err := utils.ParseDurationString(configuration.Timeout); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing access control opa timeout string: %s", err))
+	}
+}
+
+// validateShadowAccessControl validates the shadow ACLs like the live ones, the default policy and the network groups
+// of the live ACLs being used when the shadow ACLs don't have their own.
+func validateShadowAccessControl(configuration schema.AccessControlConfiguration, validator *schema.StructValidator) {
+	shadow := schema.AccessControlConfiguration{
+		DefaultPolicy: configuration.Shadow.DefaultPolicy,
+		Networks:      configuration.Networks,
+		Rules:         configuration.Shadow.Rules,
+	}
+
+	if shadow.DefaultPolicy == "" {
+		shadow.DefaultPolicy = configuration.DefaultPolicy
+	} else if !IsPolicyValid(shadow.DefaultPolicy) {
+		validator.Push(fmt.Errorf("Shadow access control 'default_policy' must either be 'deny', 'two_factor', 'one_factor' or 'bypass'"))
+	}
+
+	shadowValidator := schema.NewStructValidator()
+
+	ValidateRules(shadow, shadowValidator)
+
+	for _, err := range shadowValidator.Errors() {
+		validator.Push(fmt.Errorf("Shadow access control: %s", err))
+	}
+
+	for _, err := range shadowValidator.Warnings() {
+		validator.PushWarning(fmt.Errorf("Shadow access control: %s", err))
+	}
 }
 
 // ValidateRules validates an ACL Rule configuration.
@@ -99,6 +151,10 @@ func ValidateRules(configuration schema.AccessControlConfiguration, validator *s
 
 		validateMethods(rulePosition, rule, validator)
 
+		validateMaxAuthenticationAge(rulePosition, rule, validator)
+
+		validateSecondFactorMethods(rulePosition, rule, validator)
+
 		if rule.Policy == bypassPolicy && len(rule.Subjects) != 0 {
 			validator.Push(fmt.Errorf(errAccessControlInvalidPolicyWithSubjects, rulePosition, rule.Domains, rule.Subjects))
 		}
@@ -140,3 +196,33 @@ func validateMethods(rulePosition int, rule schema.ACLRule, validator *schema.St
 		}
 	}
 }
+
+func validateMaxAuthenticationAge(rulePosition int, rule schema.ACLRule, validator *schema.StructValidator) {
+	if rule.MaxAuthenticationAge == "" {
+		return
+	}
+
+	if _, err := utils.ParseDurationString(rule.MaxAuthenticationAge); err != nil {
+		validator.Push(fmt.Errorf("Max authentication age %s for rule #%d domain: %s is invalid: %s", rule.MaxAuthenticationAge, rulePosition, rule.Domains, err))
+	}
+
+	if rule.Policy != oneFactorPolicy && rule.Policy != twoFactorPolicy {
+		validator.Push(fmt.Errorf("Max authentication age for rule #%d domain: %s is invalid, the policy must either be 'one_factor' or 'two_factor'", rulePosition, rule.Domains))
+	}
+}
+
+func validateSecondFactorMethods(rulePosition int, rule schema.ACLRule, validator *schema.StructValidator) {
+	if len(rule.SecondFactorMethods) == 0 {
+		return
+	}
+
+	for _, method := range rule.SecondFactorMethods {
+		if !utils.IsStringInSlice(method, validSecondFactorMethods) {
+			validator.Push(fmt.Errorf("Second factor method %s for rule #%d domain: %s is invalid, must be one of the following methods: %s", method, rulePosition, rule.Domains, strings.Join(validSecondFactorMethods, ", ")))
+		}
+	}
+
+	if rule.Policy != twoFactorPolicy {
+		validator.Push(fmt.Errorf("Second factor methods for rule #%d domain: %s are invalid, the policy must be 'two_factor'", rulePosition, rule.Domains))
+	}
+}
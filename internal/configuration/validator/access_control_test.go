@@ -21,6 +21,8 @@ func (suite *AccessControl) SetupTest() {
 	suite.configuration.DefaultPolicy = denyPolicy
 	suite.configuration.Networks = schema.DefaultACLNetwork
 	suite.configuration.Rules = schema.DefaultACLRule
+	suite.configuration.Shadow = nil
+	suite.configuration.OPA = nil
 }
 
 func (suite *AccessControl) TestShouldValidateCompleteConfiguration() {
@@ -182,6 +184,101 @@ func (suite *AccessControl) TestShouldRaiseErrorInvalidSubject() {
 	suite.Assert().EqualError(suite.validator.Errors()[1], fmt.Sprintf(errAccessControlInvalidPolicyWithSubjects, 1, domains, subjects))
 }
 
+func (suite *AccessControl) TestShouldRaiseErrorsInvalidShadowRules() {
+	suite.configuration.Shadow = &schema.AccessControlShadowConfiguration{
+		DefaultPolicy: testInvalidPolicy,
+		Rules: []schema.ACLRule{
+			{
+				Domains:  []string{"public.example.com"},
+				Policy:   "one_factor",
+				Networks: []string{"vpn"},
+			},
+		},
+	}
+
+	ValidateAccessControl(suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 2)
+
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Shadow access control 'default_policy' must either be 'deny', 'two_factor', 'one_factor' or 'bypass'")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "Shadow access control: Network [vpn] for rule #1 domain: [public.example.com] is not a valid network or network group")
+}
+
+func (suite *AccessControl) TestShouldSetDefaultOPATimeout() {
+	suite.configuration.OPA = &schema.AccessControlOPAConfiguration{
+		URL: "http://opa:8181/v1/data/authelia/allow",
+	}
+
+	ValidateAccessControl(suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Assert().False(suite.validator.HasErrors())
+
+	suite.Assert().Equal("2s", suite.configuration.OPA.Timeout)
+}
+
+func (suite *AccessControl) TestShouldRaiseErrorsInvalidOPA() {
+	suite.configuration.OPA = &schema.AccessControlOPAConfiguration{
+		URL:     "opa:8181/v1/data/authelia/allow",
+		Timeout: "2 seconds",
+	}
+
+	ValidateAccessControl(suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 2)
+
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Access control opa url opa:8181/v1/data/authelia/allow must be an absolute http or https URL")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "Error occurred parsing access control opa timeout string: Could not convert the input string of 2 seconds into a duration")
+}
+
+func (suite *AccessControl) TestShouldRaiseErrorInvalidMaxAuthenticationAge() {
+	suite.configuration.Rules = []schema.ACLRule{
+		{
+			Domains:              []string{"admin.example.com"},
+			Policy:               "two_factor",
+			MaxAuthenticationAge: "5 minutes",
+		},
+		{
+			Domains:              []string{"public.example.com"},
+			Policy:               "bypass",
+			MaxAuthenticationAge: "5m",
+		},
+	}
+
+	ValidateRules(suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 2)
+
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Max authentication age 5 minutes for rule #1 domain: [admin.example.com] is invalid: Could not convert the input string of 5 minutes into a duration")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "Max authentication age for rule #2 domain: [public.example.com] is invalid, the policy must either be 'one_factor' or 'two_factor'")
+}
+
+func (suite *AccessControl) TestShouldRaiseErrorInvalidSecondFactorMethods() {
+	suite.configuration.Rules = []schema.ACLRule{
+		{
+			Domains:             []string{"admin.example.com"},
+			Policy:              "two_factor",
+			SecondFactorMethods: []string{"webauthn", "sms"},
+		},
+		{
+			Domains:             []string{"public.example.com"},
+			Policy:              "one_factor",
+			SecondFactorMethods: []string{"totp"},
+		},
+	}
+
+	ValidateRules(suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 2)
+
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Second factor method sms for rule #1 domain: [admin.example.com] is invalid, must be one of the following methods: totp, u2f, passkey, webauthn, duo, email, push_approval, privacyidea, hotp, yubikey_otp, recovery_code")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "Second factor methods for rule #2 domain: [public.example.com] are invalid, the policy must be 'two_factor'")
+}
+
 func TestAccessControl(t *testing.T) {
 	suite.Run(t, new(AccessControl))
 }
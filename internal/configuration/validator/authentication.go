@@ -1,9 +1,12 @@
 package validator
 
 import (
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/url"
+	"regexp"
 	"strings"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
@@ -26,6 +29,14 @@ func ValidateAuthenticationBackend(configuration *schema.AuthenticationBackendCo
 		validateLDAPAuthenticationBackend(configuration.LDAP, validator)
 	}
 
+	if configuration.TrustedHeader != nil {
+		validateTrustedHeaderAuthentication(configuration.TrustedHeader, validator)
+	}
+
+	if configuration.ClientCertificate != nil {
+		validateClientCertificateAuthentication(configuration.ClientCertificate, validator)
+	}
+
 	if configuration.RefreshInterval == "" {
 		configuration.RefreshInterval = schema.RefreshIntervalDefault
 	} else {
@@ -36,6 +47,65 @@ func ValidateAuthenticationBackend(configuration *schema.AuthenticationBackendCo
 	}
 }
 
+func validateTrustedHeaderAuthentication(configuration *schema.TrustedHeaderAuthenticationConfiguration, validator *schema.StructValidator) {
+	if configuration.Header == "" {
+		validator.Push(errors.New("Please provide the `header` containing the username in `authentication_backend.trusted_header`"))
+	}
+
+	for _, network := range configuration.TrustedNetworks {
+		if !IsNetworkValid(network) {
+			validator.Push(fmt.Errorf("Trusted network %s in `authentication_backend.trusted_header` must be a valid IP or CIDR", network))
+		}
+	}
+
+	if len(configuration.TrustedNetworks) == 0 && configuration.Secret == "" {
+		validator.Push(errors.New("Please provide `trusted_networks` or a `secret` in `authentication_backend.trusted_header` so the header is only trusted from the reverse proxy"))
+	}
+
+	if configuration.SecretHeader == "" {
+		configuration.SecretHeader = schema.DefaultTrustedHeaderAuthenticationConfiguration.SecretHeader
+	}
+}
+
+func validateClientCertificateAuthentication(configuration *schema.ClientCertificateAuthenticationConfiguration, validator *schema.StructValidator) {
+	if configuration.Header == "" {
+		configuration.Header = schema.DefaultClientCertificateAuthenticationConfiguration.Header
+	}
+
+	if configuration.CertificateAuthority == "" {
+		validator.Push(errors.New("Please provide the `certificate_authority` verifying the client certificates in `authentication_backend.client_certificate`"))
+	} else if content, err := ioutil.ReadFile(configuration.CertificateAuthority); err != nil {
+		validator.Push(fmt.Errorf("Unable to read the `certificate_authority` in `authentication_backend.client_certificate`: %s", err))
+	} else if !x509.NewCertPool().AppendCertsFromPEM(content) {
+		validator.Push(fmt.Errorf("The `certificate_authority` %s in `authentication_backend.client_certificate` does not contain any PEM certificate", configuration.CertificateAuthority))
+	}
+
+	if len(configuration.TrustedNetworks) == 0 {
+		validator.Push(errors.New("Please provide the `trusted_networks` of the reverse proxies in `authentication_backend.client_certificate`"))
+	}
+
+	for _, network := range configuration.TrustedNetworks {
+		if !IsNetworkValid(network) {
+			validator.Push(fmt.Errorf("Trusted network %s in `authentication_backend.client_certificate` must be a valid IP or CIDR", network))
+		}
+	}
+
+	switch configuration.UsernameAttribute {
+	case "":
+		configuration.UsernameAttribute = schema.DefaultClientCertificateAuthenticationConfiguration.UsernameAttribute
+	case "common_name", "email", "dns", "uri":
+		break
+	default:
+		validator.Push(fmt.Errorf("The `username_attribute` %s in `authentication_backend.client_certificate` must be one of 'common_name', 'email', 'dns' or 'uri'", configuration.UsernameAttribute))
+	}
+
+	if configuration.UsernamePattern != "" {
+		if _, err := regexp.Compile(configuration.UsernamePattern); err != nil {
+			validator.Push(fmt.Errorf("The `username_pattern` in `authentication_backend.client_certificate` is not a valid regular expression: %s", err))
+		}
+	}
+}
+
 //nolint:gocyclo // TODO: Consider refactoring/simplifying, time permitting.
 func validateFileAuthenticationBackend(configuration *schema.FileAuthenticationBackendConfiguration, validator *schema.StructValidator) {
 	if configuration.Path == "" {
@@ -1,6 +1,9 @@
 package validator
 
 import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -35,6 +38,73 @@ func TestShouldRaiseErrorWhenNoBackendProvided(t *testing.T) {
 	assert.EqualError(t, validator.Errors()[0], "Please provide `ldap` or `file` object in `authentication_backend`")
 }
 
+func TestShouldValidateTrustedHeaderAuthentication(t *testing.T) {
+	validator := schema.NewStructValidator()
+	backendConfig := schema.AuthenticationBackendConfiguration{
+		File:          &schema.FileAuthenticationBackendConfiguration{Path: "/a/path"},
+		TrustedHeader: &schema.TrustedHeaderAuthenticationConfiguration{TrustedNetworks: []string{"10.0.0.0/8", "abc"}},
+	}
+
+	ValidateAuthenticationBackend(&backendConfig, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Please provide the `header` containing the username in `authentication_backend.trusted_header`")
+	assert.EqualError(t, validator.Errors()[1], "Trusted network abc in `authentication_backend.trusted_header` must be a valid IP or CIDR")
+
+	validator.Clear()
+
+	backendConfig.TrustedHeader = &schema.TrustedHeaderAuthenticationConfiguration{Header: "Remote-User"}
+
+	ValidateAuthenticationBackend(&backendConfig, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Please provide `trusted_networks` or a `secret` in `authentication_backend.trusted_header` so the header is only trusted from the reverse proxy")
+
+	validator.Clear()
+
+	backendConfig.TrustedHeader.Secret = "secret"
+
+	ValidateAuthenticationBackend(&backendConfig, validator)
+
+	assert.False(t, validator.HasErrors())
+	assert.Equal(t, "X-Proxy-Secret", backendConfig.TrustedHeader.SecretHeader)
+}
+
+func TestShouldValidateClientCertificateAuthentication(t *testing.T) {
+	validator := schema.NewStructValidator()
+	backendConfig := schema.AuthenticationBackendConfiguration{
+		File: &schema.FileAuthenticationBackendConfiguration{Path: "/a/path"},
+		ClientCertificate: &schema.ClientCertificateAuthenticationConfiguration{
+			TrustedNetworks:   []string{"abc"},
+			UsernameAttribute: "serial",
+			UsernamePattern:   "(",
+		},
+	}
+
+	ValidateAuthenticationBackend(&backendConfig, validator)
+
+	require.Len(t, validator.Errors(), 4)
+	assert.EqualError(t, validator.Errors()[0], "Please provide the `certificate_authority` verifying the client certificates in `authentication_backend.client_certificate`")
+	assert.EqualError(t, validator.Errors()[1], "Trusted network abc in `authentication_backend.client_certificate` must be a valid IP or CIDR")
+	assert.EqualError(t, validator.Errors()[2], "The `username_attribute` serial in `authentication_backend.client_certificate` must be one of 'common_name', 'email', 'dns' or 'uri'")
+	assert.EqualError(t, validator.Errors()[3], "The `username_pattern` in `authentication_backend.client_certificate` is not a valid regular expression: error parsing regexp: missing closing ): `(`")
+	assert.Equal(t, "X-Forwarded-Tls-Client-Cert", backendConfig.ClientCertificate.Header)
+
+	validator.Clear()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, ioutil.WriteFile(path, []byte("not a certificate"), 0600))
+
+	backendConfig.ClientCertificate = &schema.ClientCertificateAuthenticationConfiguration{CertificateAuthority: path}
+
+	ValidateAuthenticationBackend(&backendConfig, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], fmt.Sprintf("The `certificate_authority` %s in `authentication_backend.client_certificate` does not contain any PEM certificate", path))
+	assert.EqualError(t, validator.Errors()[1], "Please provide the `trusted_networks` of the reverse proxies in `authentication_backend.client_certificate`")
+	assert.Equal(t, "common_name", backendConfig.ClientCertificate.UsernameAttribute)
+}
+
 type FileBasedAuthenticationBackend struct {
 	suite.Suite
 	configuration schema.AuthenticationBackendConfiguration
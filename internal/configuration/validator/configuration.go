@@ -64,6 +64,50 @@ func ValidateConfiguration(configuration *schema.Configuration, validator *schem
 
 	ValidateTOTP(configuration.TOTP, validator)
 
+	if configuration.WebAuthn == nil {
+		configuration.WebAuthn = &schema.DefaultWebAuthnConfiguration
+	}
+
+	ValidateWebAuthn(configuration.WebAuthn, validator)
+
+	if configuration.DuoAPI != nil {
+		ValidateDuoAPI(configuration.DuoAPI, validator)
+	}
+
+	if configuration.EmailOneTimeCode != nil {
+		ValidateEmailOneTimeCode(configuration.EmailOneTimeCode, validator)
+	}
+
+	if configuration.PushApproval != nil {
+		ValidatePushApproval(configuration.PushApproval, validator)
+	}
+
+	if configuration.PrivacyIDEA != nil {
+		ValidatePrivacyIDEA(configuration.PrivacyIDEA, validator)
+	}
+
+	if configuration.HOTP != nil {
+		ValidateHOTP(configuration.HOTP, validator)
+	}
+
+	if configuration.YubiKeyOTP != nil {
+		ValidateYubiKeyOTP(configuration.YubiKeyOTP, validator)
+	}
+
+	if configuration.TrustedDevices != nil {
+		ValidateTrustedDevices(configuration.TrustedDevices, validator)
+	}
+
+	if configuration.SecondFactorEnrollment != nil {
+		ValidateSecondFactorEnrollment(configuration.SecondFactorEnrollment, validator)
+	}
+
+	ValidateSecondFactorPolicies(configuration.SecondFactorPolicies, validator)
+
+	if configuration.SecondFactorFallback != nil {
+		ValidateSecondFactorFallback(configuration.SecondFactorFallback, validator)
+	}
+
 	ValidateAuthenticationBackend(&configuration.AuthenticationBackend, validator)
 
 	if configuration.AccessControl.DefaultPolicy == "" {
@@ -82,9 +126,13 @@ func ValidateConfiguration(configuration *schema.Configuration, validator *schem
 
 	ValidateRegulation(configuration.Regulation, validator)
 
+	if configuration.GeoIP != nil {
+		ValidateGeoIP(configuration.GeoIP, validator)
+	}
+
 	ValidateServer(&configuration.Server, validator)
 
-	ValidateStorage(configuration.Storage, validator)
+	ValidateStorage(&configuration.Storage, validator)
 
 	if configuration.Notifier == nil {
 		validator.Push(fmt.Errorf("A notifier configuration must be provided"))
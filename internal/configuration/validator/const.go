@@ -9,6 +9,7 @@ const (
 
 	errOAuthOIDCServerClientRedirectURIFmt               = "OIDC Server Client redirect URI %s has an invalid scheme %s, should be http or https"
 	errOAuthOIDCServerClientRedirectURICantBeParsedFmt   = "OIDC Client with ID '%s' has an invalid redirect URI '%s' could not be parsed: %v"
+	errOAuthOIDCServerClientAudienceFmt                  = "OIDC Client with ID '%s' has an invalid audience '%s', it must be an absolute http or https URL"
 	errIdentityProvidersOIDCServerClientInvalidPolicyFmt = "OIDC Client with ID '%s' has an invalid policy '%s', should be either 'one_factor' or 'two_factor'"
 	errIdentityProvidersOIDCServerClientInvalidSecFmt    = "OIDC Client with ID '%s' has an empty secret"
 
@@ -45,17 +46,44 @@ const (
 
 var validRequestMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "TRACE", "CONNECT", "OPTIONS"}
 
+var validSecondFactorMethods = []string{"totp", "u2f", "passkey", "webauthn", "duo", "email", "push_approval", "privacyidea", "hotp", "yubikey_otp", "recovery_code"}
+
+var validPreferredMethods = []string{"totp", "u2f", "mobile_push", "email", "push_approval", "privacyidea", "hotp", "yubikey_otp"}
+
+var validSQLiteJournalModes = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+
+var validWebAuthnResidentKeys = []string{"discouraged", "preferred", "required"}
+
+var validWebAuthnAttachments = []string{"any", "platform", "cross-platform"}
+
+var validWebAuthnSyncedPasskeys = []string{"allow", "warn", "reject"}
+
+var validWebAuthnPasskeyModes = []string{"passwordless", "first_factor"}
+
+var validSQLiteSynchronousModes = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+
+var validVerifyHeaders = []string{"user", "groups", "name", "email"}
+
+var validVerifyStrategies = []string{"trusted_header", "client_certificate", "api_key", "bearer_token", "basic_auth", "session_cookie"}
+
 // SecretNames contains a map of secret names.
 var SecretNames = map[string]string{
 	"JWTSecret":                     "jwt_secret",
 	"SessionSecret":                 "session.secret",
 	"DUOSecretKey":                  "duo_api.secret_key",
+	"DUOClientSecret":               "duo_api.client_secret",
+	"PushApprovalSecret":            "push_approval.secret",
+	"PrivacyIDEAAdminToken":         "privacyidea.admin_token",
+	"YubiKeyOTPSecretKey":           "yubikey_otp.secret_key",
 	"RedisPassword":                 "session.redis.password",
 	"RedisSentinelPassword":         "session.redis.high_availability.sentinel_password",
 	"LDAPPassword":                  "authentication_backend.ldap.password",
+	"TrustedHeaderSecret":           "authentication_backend.trusted_header.secret",
+	"VerifySignatureSecret":         "server.verify_headers.signature.secret",
 	"SMTPPassword":                  "notifier.smtp.password",
 	"MySQLPassword":                 "storage.mysql.password",
 	"PostgreSQLPassword":            "storage.postgres.password",
+	"CockroachDBPassword":           "storage.cockroachdb.password",
 	"OpenIDConnectHMACSecret":       "identity_providers.oidc.hmac_secret",
 	"OpenIDConnectIssuerPrivateKey": "identity_providers.oidc.issuer_private_key",
 }
@@ -79,20 +107,82 @@ var validKeys = []string{
 	"server.read_buffer_size",
 	"server.write_buffer_size",
 	"server.path",
+	"server.verify_headers.user",
+	"server.verify_headers.groups",
+	"server.verify_headers.name",
+	"server.verify_headers.email",
+	"server.verify_headers.exclude",
+	"server.verify_headers.static",
+	"server.verify_headers.rule",
+	"server.verify_headers.level",
+	"server.verify_headers.methods",
+	"server.verify_headers.attributes",
+	"server.verify_headers.signature.header",
+	"server.verify_object_headers.url",
+	"server.verify_object_headers.method",
+	"server.verify_basic_auth.realm",
+	"server.verify_basic_auth.charset",
+	"server.verify_basic_auth.proxy_authenticate",
+	"server.verify_redirect.status_code",
+	"server.verify_redirect.xhr_unauthorized",
+	"server.verify_rate_limit.requests",
+	"server.verify_rate_limit.burst",
+	"server.verify_rate_limit.trusted_proxies",
+	"server.verify_bypass",
+	"server.verify_strategies",
+	"server.verify_impersonation.header",
+	"server.verify_impersonation.groups",
+	"server.verify_impersonation.target_groups",
+	"server.verify_templates.unauthorized_html",
+	"server.verify_templates.unauthorized_json",
+	"server.verify_templates.forbidden_html",
+	"server.verify_templates.forbidden_json",
+	"server.verify_cache_duration",
+	"server.verify_websocket_cache_duration",
+	"server.verify_max_authentication_age",
+	"server.verify_grpc",
 
 	// TOTP Keys.
 	"totp.issuer",
+	"totp.algorithm",
+	"totp.digits",
 	"totp.period",
 	"totp.skew",
+	"totp.skew_before",
+	"totp.skew_after",
+
+	// WebAuthn Keys.
+	"webauthn.enable_passkeys",
+	"webauthn.passkey_mode",
+	"webauthn.display_name",
+	"webauthn.timeout",
+	"webauthn.registration_timeout",
+	"webauthn.authentication_timeout",
+	"webauthn.rp_id",
+	"webauthn.origins",
+	"webauthn.resident_key",
+	"webauthn.attachment",
+	"webauthn.max_passkeys",
+	"webauthn.synced_passkeys",
+	"webauthn.attestation_certificate_authority",
+	"webauthn.allowed_aaguids",
+	"webauthn.denied_aaguids",
+	"webauthn.enterprise_attestation_rp_ids",
+	"webauthn.enterprise_attestation_aaguids",
 
 	// Access Control Keys.
 	"access_control.rules",
 	"access_control.default_policy",
 	"access_control.networks",
+	"access_control.shadow.default_policy",
+	"access_control.shadow.rules",
+	"access_control.opa.url",
+	"access_control.opa.timeout",
 
 	// Session Keys.
 	"session.name",
 	"session.domain",
+	"session.domains",
 	"session.same_site",
 	"session.expiration",
 	"session.inactivity",
@@ -118,22 +208,60 @@ var validKeys = []string{
 	"session.redis.timeouts.read",
 	"session.redis.timeouts.write",
 
+	// Storage Keys.
+	"storage.table_prefix",
+	"storage.retry.max_attempts",
+	"storage.retry.backoff",
+	"storage.retry.max_backoff",
+	"storage.authentication_logs.max_age",
+	"storage.authentication_logs.max_count",
+	"storage.authentication_logs.prune_interval",
+	"storage.webauthn_credentials.grace_period",
+	"storage.webauthn_credentials.purge_interval",
+	"storage.cache.ttl",
+	"storage.maintenance.interval",
+
 	// Local Storage Keys.
 	"storage.local.path",
+	"storage.local.journal_mode",
+	"storage.local.busy_timeout",
+	"storage.local.synchronous",
 
 	// MySQL Storage Keys.
 	"storage.mysql.host",
 	"storage.mysql.port",
 	"storage.mysql.database",
 	"storage.mysql.username",
+	"storage.mysql.read_replicas",
+	"storage.mysql.max_open_conns",
+	"storage.mysql.max_idle_conns",
+	"storage.mysql.conn_max_lifetime",
+	"storage.mysql.conn_max_idle_time",
 
 	// PostgreSQL Storage Keys.
 	"storage.postgres.host",
 	"storage.postgres.port",
 	"storage.postgres.database",
 	"storage.postgres.username",
+	"storage.postgres.read_replicas",
+	"storage.postgres.max_open_conns",
+	"storage.postgres.max_idle_conns",
+	"storage.postgres.conn_max_lifetime",
+	"storage.postgres.conn_max_idle_time",
 	"storage.postgres.sslmode",
 
+	// CockroachDB Storage Keys.
+	"storage.cockroachdb.host",
+	"storage.cockroachdb.port",
+	"storage.cockroachdb.database",
+	"storage.cockroachdb.username",
+	"storage.cockroachdb.read_replicas",
+	"storage.cockroachdb.max_open_conns",
+	"storage.cockroachdb.max_idle_conns",
+	"storage.cockroachdb.conn_max_lifetime",
+	"storage.cockroachdb.conn_max_idle_time",
+	"storage.cockroachdb.sslmode",
+
 	// FileSystem Notifier Keys.
 	"notifier.filesystem.filename",
 	"notifier.disable_startup_check",
@@ -157,13 +285,63 @@ var validKeys = []string{
 	"regulation.find_time",
 	"regulation.ban_time",
 
+	// GeoIP Keys.
+	"geoip.database",
+
 	// DUO API Keys.
 	"duo_api.hostname",
 	"duo_api.integration_key",
+	"duo_api.client_id",
+	"duo_api.integrations",
+
+	// Email One-Time Code Keys.
+	"email_one_time_code.length",
+	"email_one_time_code.lifespan",
+
+	// Push Approval Keys.
+	"push_approval.url",
+	"push_approval.timeout",
+
+	// PrivacyIDEA Keys.
+	"privacyidea.url",
+	"privacyidea.realm",
+	"privacyidea.push_timeout",
+
+	// HOTP Keys.
+	"hotp.look_ahead",
+	"hotp.resync_window",
+
+	// YubiKey OTP Keys.
+	"yubikey_otp.client_id",
+	"yubikey_otp.api_urls",
+	"yubikey_otp.timeout",
+
+	// Trusted Devices Keys.
+	"trusted_devices.duration",
+
+	// Second Factor Enrollment Keys.
+	"second_factor_enrollment.groups",
+	"second_factor_enrollment.grace_period",
+	"second_factor_policies",
+	"second_factor_fallback.methods",
 
 	// Authentication Backend Keys.
 	"authentication_backend.disable_reset_password",
 	"authentication_backend.refresh_interval",
+	"authentication_backend.refresh_async",
+
+	// Trusted Header Authentication Keys.
+	"authentication_backend.trusted_header.header",
+	"authentication_backend.trusted_header.trusted_networks",
+	"authentication_backend.trusted_header.secret_header",
+
+	// Client Certificate Authentication Keys.
+	"authentication_backend.client_certificate.header",
+	"authentication_backend.client_certificate.certificate_authority",
+	"authentication_backend.client_certificate.trusted_networks",
+	"authentication_backend.client_certificate.username_attribute",
+	"authentication_backend.client_certificate.username_pattern",
+	"authentication_backend.client_certificate.first_factor",
 
 	// LDAP Authentication Backend Keys.
 	"authentication_backend.ldap.implementation",
@@ -177,6 +355,7 @@ var validKeys = []string{
 	"authentication_backend.ldap.group_name_attribute",
 	"authentication_backend.ldap.mail_attribute",
 	"authentication_backend.ldap.display_name_attribute",
+	"authentication_backend.ldap.extra_attributes",
 	"authentication_backend.ldap.user",
 	"authentication_backend.ldap.start_tls",
 	"authentication_backend.ldap.tls.minimum_version",
@@ -194,6 +373,7 @@ var validKeys = []string{
 
 	// Identity Provider Keys.
 	"identity_providers.oidc.clients",
+	"identity_providers.oidc.enable_bearer_authorization",
 }
 
 var replacedKeys = map[string]string{
@@ -0,0 +1,68 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ValidateDuoAPI validates the configuration related to Duo, either the Auth API credentials used to send the push
+// notifications or the Web SDK credentials used to redirect the users to the Universal Prompt.
+func ValidateDuoAPI(configuration *schema.DuoAPIConfiguration, validator *schema.StructValidator) {
+	if configuration.Hostname == "" {
+		validator.Push(fmt.Errorf("Duo API hostname must be provided"))
+	}
+
+	validateDuoCredentials(schema.DuoIntegrationConfiguration{
+		Name:           schema.DuoDefaultIntegrationName,
+		IntegrationKey: configuration.IntegrationKey,
+		SecretKey:      configuration.SecretKey,
+		ClientID:       configuration.ClientID,
+		ClientSecret:   configuration.ClientSecret,
+	}, configuration.IsUniversalPrompt(), "Duo API", validator)
+
+	validateDuoIntegrations(configuration, validator)
+}
+
+func validateDuoIntegrations(configuration *schema.DuoAPIConfiguration, validator *schema.StructValidator) {
+	names := []string{schema.DuoDefaultIntegrationName}
+
+	for i, integration := range configuration.Integrations {
+		if integration.Name == "" {
+			validator.Push(fmt.Errorf("Duo API integration %d must have a name", i+1))
+			continue
+		}
+
+		if utils.IsStringInSlice(integration.Name, names) {
+			validator.Push(fmt.Errorf("Duo API integration %s must have a unique name other than %s", integration.Name, schema.DuoDefaultIntegrationName))
+		}
+
+		names = append(names, integration.Name)
+
+		if integration.Hostname == "" {
+			validator.Push(fmt.Errorf("Duo API integration %s must have a hostname", integration.Name))
+		}
+
+		validateDuoCredentials(integration, configuration.IsUniversalPrompt(),
+			fmt.Sprintf("Duo API integration %s", integration.Name), validator)
+	}
+}
+
+// validateDuoCredentials checks that the integration has the credentials of the Web SDK when the users are redirected
+// to the Universal Prompt, or the credentials of the Auth API otherwise.
+func validateDuoCredentials(integration schema.DuoIntegrationConfiguration, universalPrompt bool, prefix string, validator *schema.StructValidator) {
+	if !universalPrompt {
+		if integration.IntegrationKey == "" || integration.SecretKey == "" {
+			validator.Push(fmt.Errorf("%s integration_key and secret_key must be provided when client_id is not set", prefix))
+		}
+
+		return
+	}
+
+	if integration.ClientID == "" || integration.ClientSecret == "" {
+		validator.Push(fmt.Errorf("%s client_id and client_secret must both be provided to use the Universal Prompt", prefix))
+	} else if len(integration.ClientSecret) < 40 {
+		validator.Push(fmt.Errorf("%s client_secret must be at least 40 characters long", prefix))
+	}
+}
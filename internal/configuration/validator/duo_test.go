@@ -0,0 +1,141 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+const testDuoClientSecret = "abcdefghijklmnopqrstuvwxyz0123456789abcd"
+
+func TestShouldValidateDuoAuthAPIConfiguration(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.DuoAPIConfiguration{
+		Hostname:       "api-123456789.example.com",
+		IntegrationKey: "ABCDEF",
+		SecretKey:      "secret",
+	}
+
+	ValidateDuoAPI(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.False(t, config.IsUniversalPrompt())
+}
+
+func TestShouldValidateDuoUniversalPromptConfiguration(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.DuoAPIConfiguration{
+		Hostname:     "api-123456789.example.com",
+		ClientID:     "DIWJ8X6AEYOR5OMC6TQ1",
+		ClientSecret: testDuoClientSecret,
+	}
+
+	ValidateDuoAPI(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.True(t, config.IsUniversalPrompt())
+}
+
+func TestShouldRaiseErrorsWhenDuoCredentialsAreMissing(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.DuoAPIConfiguration{}
+
+	ValidateDuoAPI(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Duo API hostname must be provided")
+	assert.EqualError(t, validator.Errors()[1], "Duo API integration_key and secret_key must be provided when client_id is not set")
+}
+
+func TestShouldRaiseErrorWhenDuoClientSecretIsMissing(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.DuoAPIConfiguration{
+		Hostname: "api-123456789.example.com",
+		ClientID: "DIWJ8X6AEYOR5OMC6TQ1",
+	}
+
+	ValidateDuoAPI(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Duo API client_id and client_secret must both be provided to use the Universal Prompt")
+}
+
+func TestShouldRaiseErrorWhenDuoClientSecretIsTooShort(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.DuoAPIConfiguration{
+		Hostname:     "api-123456789.example.com",
+		ClientID:     "DIWJ8X6AEYOR5OMC6TQ1",
+		ClientSecret: "short",
+	}
+
+	ValidateDuoAPI(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Duo API client_secret must be at least 40 characters long")
+}
+
+func TestShouldValidateDuoIntegrations(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.DuoAPIConfiguration{
+		Hostname:       "api-123456789.example.com",
+		IntegrationKey: "ABCDEF",
+		SecretKey:      "secret",
+		Integrations: []schema.DuoIntegrationConfiguration{
+			{
+				Name:           "emea",
+				Hostname:       "api-987654321.example.com",
+				IntegrationKey: "GHIJKL",
+				SecretKey:      "secret",
+				Groups:         []string{"emea"},
+			},
+			{
+				Name:           "backup",
+				Hostname:       "api-111111111.example.com",
+				IntegrationKey: "MNOPQR",
+				SecretKey:      "secret",
+			},
+		},
+	}
+
+	ValidateDuoAPI(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+}
+
+func TestShouldRaiseErrorsWhenDuoIntegrationsAreInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.DuoAPIConfiguration{
+		Hostname:     "api-123456789.example.com",
+		ClientID:     "DIWJ8X6AEYOR5OMC6TQ1",
+		ClientSecret: testDuoClientSecret,
+		Integrations: []schema.DuoIntegrationConfiguration{
+			{
+				Hostname:     "api-987654321.example.com",
+				ClientID:     "DIWJ8X6AEYOR5OMC6TQ2",
+				ClientSecret: testDuoClientSecret,
+			},
+			{
+				Name:     "default",
+				Hostname: "api-987654321.example.com",
+				ClientID: "DIWJ8X6AEYOR5OMC6TQ3",
+			},
+			{
+				Name:           "emea",
+				IntegrationKey: "GHIJKL",
+				SecretKey:      "secret",
+			},
+		},
+	}
+
+	ValidateDuoAPI(&config, validator)
+
+	require.Len(t, validator.Errors(), 5)
+	assert.EqualError(t, validator.Errors()[0], "Duo API integration 1 must have a name")
+	assert.EqualError(t, validator.Errors()[1], "Duo API integration default must have a unique name other than default")
+	assert.EqualError(t, validator.Errors()[2], "Duo API integration default client_id and client_secret must both be provided to use the Universal Prompt")
+	assert.EqualError(t, validator.Errors()[3], "Duo API integration emea must have a hostname")
+	assert.EqualError(t, validator.Errors()[4], "Duo API integration emea client_id and client_secret must both be provided to use the Universal Prompt")
+}
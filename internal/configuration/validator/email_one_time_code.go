@@ -0,0 +1,23 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ValidateEmailOneTimeCode validates and update the configuration of the one-time codes sent by email.
+func ValidateEmailOneTimeCode(configuration *schema.EmailOneTimeCodeConfiguration, validator *schema.StructValidator) {
+	if configuration.Length == 0 {
+		configuration.Length = schema.DefaultEmailOneTimeCodeConfiguration.Length
+	} else if configuration.Length < 6 || configuration.Length > 10 {
+		validator.Push(fmt.Errorf("Email one-time code length must be between 6 and 10 but it is configured as %d", configuration.Length))
+	}
+
+	if configuration.Lifespan == "" {
+		configuration.Lifespan = schema.DefaultEmailOneTimeCodeConfiguration.Lifespan
+	} else if _, err := utils.ParseDurationString(configuration.Lifespan); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing email one-time code lifespan string: %s", err))
+	}
+}
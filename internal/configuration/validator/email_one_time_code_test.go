@@ -0,0 +1,49 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldSetDefaultEmailOneTimeCodeValues(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.EmailOneTimeCodeConfiguration{}
+
+	ValidateEmailOneTimeCode(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, 6, config.Length)
+	assert.Equal(t, "5m", config.Lifespan)
+}
+
+func TestShouldRaiseErrorWhenEmailOneTimeCodeLengthIsOutOfRange(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.EmailOneTimeCodeConfiguration{Length: 4}
+
+	ValidateEmailOneTimeCode(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Email one-time code length must be between 6 and 10 but it is configured as 4")
+
+	validator = schema.NewStructValidator()
+	config = schema.EmailOneTimeCodeConfiguration{Length: 12}
+
+	ValidateEmailOneTimeCode(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Email one-time code length must be between 6 and 10 but it is configured as 12")
+}
+
+func TestShouldRaiseErrorWhenEmailOneTimeCodeLifespanIsInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.EmailOneTimeCodeConfiguration{Lifespan: "abc"}
+
+	ValidateEmailOneTimeCode(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Error occurred parsing email one-time code lifespan string: Could not convert the input string of abc into a duration")
+}
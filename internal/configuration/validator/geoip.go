@@ -0,0 +1,14 @@
+package validator
+
+import (
+	"errors"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// ValidateGeoIP validates the configuration of the GeoIP database.
+func ValidateGeoIP(configuration *schema.GeoIPConfiguration, validator *schema.StructValidator) {
+	if configuration.Database == "" {
+		validator.Push(errors.New("GeoIP database must be provided"))
+	}
+}
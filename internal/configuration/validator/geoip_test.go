@@ -0,0 +1,27 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldRaiseErrorWhenGeoIPDatabaseIsMissing(t *testing.T) {
+	validator := schema.NewStructValidator()
+
+	ValidateGeoIP(&schema.GeoIPConfiguration{}, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "GeoIP database must be provided")
+}
+
+func TestShouldValidateGeoIPConfiguration(t *testing.T) {
+	validator := schema.NewStructValidator()
+
+	ValidateGeoIP(&schema.GeoIPConfiguration{Database: "/config/geoip.csv"}, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+}
@@ -0,0 +1,24 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// ValidateHOTP validates and update the configuration of the counter-based one-time passwords.
+func ValidateHOTP(configuration *schema.HOTPConfiguration, validator *schema.StructValidator) {
+	if configuration.LookAhead == 0 {
+		configuration.LookAhead = schema.DefaultHOTPConfiguration.LookAhead
+	} else if configuration.LookAhead < 0 {
+		validator.Push(fmt.Errorf("HOTP look_ahead must be greater than 0"))
+	}
+
+	if configuration.ResyncWindow == 0 {
+		configuration.ResyncWindow = schema.DefaultHOTPConfiguration.ResyncWindow
+	}
+
+	if configuration.ResyncWindow < configuration.LookAhead {
+		validator.Push(fmt.Errorf("HOTP resync_window must be greater than or equal to look_ahead (%d)", configuration.LookAhead))
+	}
+}
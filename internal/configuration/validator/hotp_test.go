@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldSetDefaultHOTPValues(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.HOTPConfiguration{}
+
+	ValidateHOTP(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, 10, config.LookAhead)
+	assert.Equal(t, 100, config.ResyncWindow)
+}
+
+func TestShouldRaiseErrorsWhenHOTPWindowsAreInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.HOTPConfiguration{
+		LookAhead:    -1,
+		ResyncWindow: -5,
+	}
+
+	ValidateHOTP(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "HOTP look_ahead must be greater than 0")
+	assert.EqualError(t, validator.Errors()[1], "HOTP resync_window must be greater than or equal to look_ahead (-1)")
+}
+
+func TestShouldRaiseErrorWhenHOTPResyncWindowIsSmallerThanLookAhead(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.HOTPConfiguration{
+		LookAhead:    20,
+		ResyncWindow: 10,
+	}
+
+	ValidateHOTP(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "HOTP resync_window must be greater than or equal to look_ahead (20)")
+}
@@ -71,6 +71,7 @@ func validateOIDCClients(configuration *schema.OpenIDConnectConfiguration, valid
 		}
 
 		validateOIDCClientRedirectURIs(client, validator)
+		validateOIDCClientAudience(client, validator)
 	}
 
 	if invalidID {
@@ -96,3 +97,15 @@ func validateOIDCClientRedirectURIs(client schema.OpenIDConnectClientConfigurati
 		}
 	}
 }
+
+// validateOIDCClientAudience validates the audience of the client, which are the URLs of the resources its access tokens
+// can be granted for.
+func validateOIDCClientAudience(client schema.OpenIDConnectClientConfiguration, validator *schema.StructValidator) {
+	for _, audience := range client.Audience {
+		parsedURI, err := url.Parse(audience)
+
+		if err != nil || (parsedURI.Scheme != "https" && parsedURI.Scheme != "http") || parsedURI.Host == "" {
+			validator.Push(fmt.Errorf(errOAuthOIDCServerClientAudienceFmt, client.ID, audience))
+		}
+	}
+}
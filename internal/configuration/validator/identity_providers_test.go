@@ -101,6 +101,38 @@ func TestShouldRaiseErrorWhenOIDCServerClientBadValues(t *testing.T) {
 	assert.EqualError(t, validator.Errors()[6], "OIDC Server has clients with duplicate ID's")
 }
 
+func TestShouldRaiseErrorWhenOIDCServerClientAudienceInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := &schema.IdentityProvidersConfiguration{
+		OIDC: &schema.OpenIDConnectConfiguration{
+			HMACSecret:       "rLABDrx87et5KvRHVUgTm3pezWWd8LMN",
+			IssuerPrivateKey: "key-material",
+			Clients: []schema.OpenIDConnectClientConfiguration{
+				{
+					ID:     "a-client",
+					Secret: "a-secret",
+					Policy: oneFactorPolicy,
+					RedirectURIs: []string{
+						"https://google.com",
+					},
+					Audience: []string{
+						"https://api.example.com/deploy",
+						"api.example.com",
+						"ftp://api.example.com",
+					},
+				},
+			},
+		},
+	}
+
+	ValidateIdentityProviders(config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+
+	assert.EqualError(t, validator.Errors()[0], fmt.Sprintf(errOAuthOIDCServerClientAudienceFmt, "a-client", "api.example.com"))
+	assert.EqualError(t, validator.Errors()[1], fmt.Sprintf(errOAuthOIDCServerClientAudienceFmt, "a-client", "ftp://api.example.com"))
+}
+
 func TestShouldNotRaiseErrorWhenOIDCServerConfiguredCorrectly(t *testing.T) {
 	validator := schema.NewStructValidator()
 	config := &schema.IdentityProvidersConfiguration{
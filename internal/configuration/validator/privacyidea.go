@@ -0,0 +1,28 @@
+package validator
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ValidatePrivacyIDEA validates and update the configuration related to the privacyIDEA server.
+func ValidatePrivacyIDEA(configuration *schema.PrivacyIDEAConfiguration, validator *schema.StructValidator) {
+	if configuration.URL == "" {
+		validator.Push(fmt.Errorf("PrivacyIDEA url must be provided"))
+	} else if u, err := url.Parse(configuration.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		validator.Push(fmt.Errorf("PrivacyIDEA url %s must be an absolute http or https URL", configuration.URL))
+	}
+
+	if configuration.AdminToken == "" {
+		validator.Push(fmt.Errorf("PrivacyIDEA admin_token must be provided to look up the tokens of the users"))
+	}
+
+	if configuration.PushTimeout == "" {
+		configuration.PushTimeout = schema.DefaultPrivacyIDEAConfiguration.PushTimeout
+	} else if _, err := utils.ParseDurationString(configuration.PushTimeout); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing privacyIDEA push_timeout string: %s", err))
+	}
+}
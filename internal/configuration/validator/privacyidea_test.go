@@ -0,0 +1,50 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldSetDefaultPrivacyIDEAValues(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.PrivacyIDEAConfiguration{
+		URL:        "https://privacyidea.example.com",
+		AdminToken: "abc",
+	}
+
+	ValidatePrivacyIDEA(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "60s", config.PushTimeout)
+	assert.Equal(t, "", config.Realm)
+}
+
+func TestShouldRaiseErrorsWhenPrivacyIDEAIsMissingURLAndAdminToken(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.PrivacyIDEAConfiguration{}
+
+	ValidatePrivacyIDEA(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "PrivacyIDEA url must be provided")
+	assert.EqualError(t, validator.Errors()[1], "PrivacyIDEA admin_token must be provided to look up the tokens of the users")
+}
+
+func TestShouldRaiseErrorsWhenPrivacyIDEAIsInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.PrivacyIDEAConfiguration{
+		URL:         "privacyidea.example.com",
+		AdminToken:  "abc",
+		PushTimeout: "abc",
+	}
+
+	ValidatePrivacyIDEA(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "PrivacyIDEA url privacyidea.example.com must be an absolute http or https URL")
+	assert.EqualError(t, validator.Errors()[1], "Error occurred parsing privacyIDEA push_timeout string: Could not convert the input string of abc into a duration")
+}
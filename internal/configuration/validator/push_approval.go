@@ -0,0 +1,28 @@
+package validator
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ValidatePushApproval validates and update the configuration of the approval requests pushed to a webhook.
+func ValidatePushApproval(configuration *schema.PushApprovalConfiguration, validator *schema.StructValidator) {
+	if configuration.URL == "" {
+		validator.Push(fmt.Errorf("Push approval url must be provided"))
+	} else if u, err := url.Parse(configuration.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		validator.Push(fmt.Errorf("Push approval url %s must be an absolute http or https URL", configuration.URL))
+	}
+
+	if configuration.Secret == "" {
+		validator.Push(fmt.Errorf("Push approval secret must be provided"))
+	}
+
+	if configuration.Timeout == "" {
+		configuration.Timeout = schema.DefaultPushApprovalConfiguration.Timeout
+	} else if _, err := utils.ParseDurationString(configuration.Timeout); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing push approval timeout string: %s", err))
+	}
+}
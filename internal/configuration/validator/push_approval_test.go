@@ -0,0 +1,49 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldSetDefaultPushApprovalValues(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.PushApprovalConfiguration{
+		URL:    "https://ntfy.example.com/authelia",
+		Secret: "abc",
+	}
+
+	ValidatePushApproval(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "60s", config.Timeout)
+}
+
+func TestShouldRaiseErrorsWhenPushApprovalIsMissingURLAndSecret(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.PushApprovalConfiguration{}
+
+	ValidatePushApproval(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Push approval url must be provided")
+	assert.EqualError(t, validator.Errors()[1], "Push approval secret must be provided")
+}
+
+func TestShouldRaiseErrorsWhenPushApprovalIsInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.PushApprovalConfiguration{
+		URL:     "ntfy.example.com/authelia",
+		Secret:  "abc",
+		Timeout: "abc",
+	}
+
+	ValidatePushApproval(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Push approval url ntfy.example.com/authelia must be an absolute http or https URL")
+	assert.EqualError(t, validator.Errors()[1], "Error occurred parsing push approval timeout string: Could not convert the input string of abc into a duration")
+}
@@ -0,0 +1,31 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ValidateSecondFactorEnrollment validates and update the configuration of the second factor enrollment.
+func ValidateSecondFactorEnrollment(configuration *schema.SecondFactorEnrollmentConfiguration, validator *schema.StructValidator) {
+	for i, group := range configuration.Groups {
+		if group == "" {
+			validator.Push(fmt.Errorf("Second factor enrollment group %d must not be empty", i+1))
+		}
+	}
+
+	if configuration.GracePeriod == "" {
+		configuration.GracePeriod = schema.DefaultSecondFactorEnrollmentConfiguration.GracePeriod
+		return
+	}
+
+	duration, err := utils.ParseDurationString(configuration.GracePeriod)
+
+	switch {
+	case err != nil:
+		validator.Push(fmt.Errorf("Error occurred parsing second factor enrollment grace period string: %s", err))
+	case duration < 0:
+		validator.Push(fmt.Errorf("Second factor enrollment grace period must not be negative but it is configured as %s", configuration.GracePeriod))
+	}
+}
@@ -0,0 +1,51 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldSetDefaultSecondFactorEnrollmentValues(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.SecondFactorEnrollmentConfiguration{}
+
+	ValidateSecondFactorEnrollment(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "7d", config.GracePeriod)
+	assert.Len(t, config.Groups, 0)
+}
+
+func TestShouldAllowSecondFactorEnrollmentWithoutGracePeriod(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.SecondFactorEnrollmentConfiguration{Groups: []string{"admins"}, GracePeriod: "0"}
+
+	ValidateSecondFactorEnrollment(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "0", config.GracePeriod)
+}
+
+func TestShouldRaiseErrorWhenSecondFactorEnrollmentGracePeriodIsInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.SecondFactorEnrollmentConfiguration{GracePeriod: "abc"}
+
+	ValidateSecondFactorEnrollment(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Error occurred parsing second factor enrollment grace period string: Could not convert the input string of abc into a duration")
+}
+
+func TestShouldRaiseErrorWhenSecondFactorEnrollmentGroupIsEmpty(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.SecondFactorEnrollmentConfiguration{Groups: []string{"admins", ""}}
+
+	ValidateSecondFactorEnrollment(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Second factor enrollment group 2 must not be empty")
+}
@@ -0,0 +1,26 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ValidateSecondFactorFallback validates and update the configuration of the second factor fallback.
+func ValidateSecondFactorFallback(configuration *schema.SecondFactorFallbackConfiguration, validator *schema.StructValidator) {
+	if len(configuration.Methods) == 0 {
+		configuration.Methods = schema.DefaultSecondFactorFallbackConfiguration.Methods
+		return
+	}
+
+	for i, method := range configuration.Methods {
+		switch {
+		case !utils.IsStringInSlice(method, validPreferredMethods):
+			validator.Push(fmt.Errorf("Second factor fallback method %s is invalid, must be one of the following methods: %s", method, strings.Join(validPreferredMethods, ", ")))
+		case utils.IsStringInSlice(method, configuration.Methods[:i]):
+			validator.Push(fmt.Errorf("Second factor fallback method %s is configured more than once", method))
+		}
+	}
+}
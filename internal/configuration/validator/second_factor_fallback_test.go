@@ -0,0 +1,41 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldSetDefaultSecondFactorFallbackValues(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.SecondFactorFallbackConfiguration{}
+
+	ValidateSecondFactorFallback(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, schema.DefaultSecondFactorFallbackConfiguration.Methods, config.Methods)
+}
+
+func TestShouldKeepConfiguredSecondFactorFallbackOrder(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.SecondFactorFallbackConfiguration{Methods: []string{"totp", "u2f"}}
+
+	ValidateSecondFactorFallback(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, []string{"totp", "u2f"}, config.Methods)
+}
+
+func TestShouldRaiseErrorWhenSecondFactorFallbackMethodIsInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.SecondFactorFallbackConfiguration{Methods: []string{"u2f", "webauthn", "u2f"}}
+
+	ValidateSecondFactorFallback(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Second factor fallback method webauthn is invalid, must be one of the following methods: totp, u2f, mobile_push, email, push_approval, privacyidea, hotp, yubikey_otp")
+	assert.EqualError(t, validator.Errors()[1], "Second factor fallback method u2f is configured more than once")
+}
@@ -0,0 +1,36 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ValidateSecondFactorPolicies validates the configuration of the second factor policies.
+func ValidateSecondFactorPolicies(configuration []schema.SecondFactorPolicyConfiguration, validator *schema.StructValidator) {
+	for i, policy := range configuration {
+		position := i + 1
+
+		if len(policy.Groups) == 0 {
+			validator.Push(fmt.Errorf("Second factor policy #%d must have at least one group", position))
+		}
+
+		for _, group := range policy.Groups {
+			if group == "" {
+				validator.Push(fmt.Errorf("Second factor policy #%d has an empty group", position))
+			}
+		}
+
+		if len(policy.Methods) == 0 {
+			validator.Push(fmt.Errorf("Second factor policy #%d must have at least one method", position))
+		}
+
+		for _, method := range policy.Methods {
+			if !utils.IsStringInSlice(method, validSecondFactorMethods) {
+				validator.Push(fmt.Errorf("Second factor method %s of policy #%d is invalid, must be one of the following methods: %s", method, position, strings.Join(validSecondFactorMethods, ", ")))
+			}
+		}
+	}
+}
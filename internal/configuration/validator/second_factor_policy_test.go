@@ -0,0 +1,47 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldValidateSecondFactorPolicies(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := []schema.SecondFactorPolicyConfiguration{
+		{Groups: []string{"admins"}, Methods: []string{"webauthn"}},
+		{Groups: []string{"dev", "users"}, Methods: []string{"totp", "webauthn", "push_approval"}},
+	}
+
+	ValidateSecondFactorPolicies(config, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+}
+
+func TestShouldRaiseErrorWhenSecondFactorPolicyHasNoGroupsOrMethods(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := []schema.SecondFactorPolicyConfiguration{{}}
+
+	ValidateSecondFactorPolicies(config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Second factor policy #1 must have at least one group")
+	assert.EqualError(t, validator.Errors()[1], "Second factor policy #1 must have at least one method")
+}
+
+func TestShouldRaiseErrorWhenSecondFactorPolicyIsInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := []schema.SecondFactorPolicyConfiguration{
+		{Groups: []string{"admins"}, Methods: []string{"webauthn"}},
+		{Groups: []string{""}, Methods: []string{"sms"}},
+	}
+
+	ValidateSecondFactorPolicies(config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Second factor policy #2 has an empty group")
+	assert.EqualError(t, validator.Errors()[1], "Second factor method sms of policy #2 is invalid, must be one of the following methods: totp, u2f, passkey, webauthn, duo, email, push_approval, privacyidea, hotp, yubikey_otp, recovery_code")
+}
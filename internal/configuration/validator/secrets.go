@@ -29,9 +29,23 @@ func isSecretKey(value string) (isSecretKey bool) {
 func ValidateSecrets(configuration *schema.Configuration, validator *schema.StructValidator, viper *viper.Viper) {
 	configuration.JWTSecret = getSecretValue(SecretNames["JWTSecret"], validator, viper)
 	configuration.Session.Secret = getSecretValue(SecretNames["SessionSecret"], validator, viper)
+	configuration.Server.VerifyHeaders.Signature.Secret = getSecretValue(SecretNames["VerifySignatureSecret"], validator, viper)
 
 	if configuration.DuoAPI != nil {
 		configuration.DuoAPI.SecretKey = getSecretValue(SecretNames["DUOSecretKey"], validator, viper)
+		configuration.DuoAPI.ClientSecret = getSecretValue(SecretNames["DUOClientSecret"], validator, viper)
+	}
+
+	if configuration.PushApproval != nil {
+		configuration.PushApproval.Secret = getSecretValue(SecretNames["PushApprovalSecret"], validator, viper)
+	}
+
+	if configuration.PrivacyIDEA != nil {
+		configuration.PrivacyIDEA.AdminToken = getSecretValue(SecretNames["PrivacyIDEAAdminToken"], validator, viper)
+	}
+
+	if configuration.YubiKeyOTP != nil {
+		configuration.YubiKeyOTP.SecretKey = getSecretValue(SecretNames["YubiKeyOTPSecretKey"], validator, viper)
 	}
 
 	if configuration.Session.Redis != nil {
@@ -47,6 +61,10 @@ func ValidateSecrets(configuration *schema.Configuration, validator *schema.Stru
 		configuration.AuthenticationBackend.LDAP.Password = getSecretValue(SecretNames["LDAPPassword"], validator, viper)
 	}
 
+	if configuration.AuthenticationBackend.TrustedHeader != nil {
+		configuration.AuthenticationBackend.TrustedHeader.Secret = getSecretValue(SecretNames["TrustedHeaderSecret"], validator, viper)
+	}
+
 	if configuration.Notifier != nil && configuration.Notifier.SMTP != nil {
 		configuration.Notifier.SMTP.Password = getSecretValue(SecretNames["SMTPPassword"], validator, viper)
 	}
@@ -59,6 +77,10 @@ func ValidateSecrets(configuration *schema.Configuration, validator *schema.Stru
 		configuration.Storage.PostgreSQL.Password = getSecretValue(SecretNames["PostgreSQLPassword"], validator, viper)
 	}
 
+	if configuration.Storage.CockroachDB != nil {
+		configuration.Storage.CockroachDB.Password = getSecretValue(SecretNames["CockroachDBPassword"], validator, viper)
+	}
+
 	if configuration.IdentityProviders.OIDC != nil {
 		configuration.IdentityProviders.OIDC.HMACSecret = getSecretValue(SecretNames["OpenIDConnectHMACSecret"], validator, viper)
 		configuration.IdentityProviders.OIDC.IssuerPrivateKey = getSecretValue(SecretNames["OpenIDConnectIssuerPrivateKey"], validator, viper)
@@ -2,8 +2,11 @@ package validator
 
 import (
 	"fmt"
+	htmltemplate "html/template"
+	"io/ioutil"
 	"path"
 	"strings"
+	texttemplate "text/template"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/utils"
@@ -35,4 +38,222 @@ func ValidateServer(configuration *schema.ServerConfiguration, validator *schema
 	} else if configuration.WriteBufferSize < 0 {
 		validator.Push(fmt.Errorf("server write buffer size must be above 0"))
 	}
+
+	validateServerVerifyHeaders(&configuration.VerifyHeaders, validator)
+	validateServerVerifyBasicAuth(&configuration.VerifyBasicAuth, validator)
+	validateServerVerifyRedirect(&configuration.VerifyRedirect, validator)
+	validateServerVerifyRateLimit(&configuration.VerifyRateLimit, validator)
+	validateServerVerifyBypass(configuration.VerifyBypass, validator)
+	validateServerVerifyTemplates(configuration.VerifyTemplates, validator)
+	validateServerVerifyImpersonation(configuration.VerifyImpersonation, validator)
+	validateServerVerifyStrategies(configuration.VerifyStrategies, validator)
+
+	if configuration.VerifyGRPC {
+		validateServerVerifyGRPC(configuration.VerifyHeaders, validator)
+	}
+
+	if configuration.VerifyCacheDuration == "" {
+		configuration.VerifyCacheDuration = schema.DefaultServerConfiguration.VerifyCacheDuration
+	} else if _, err := utils.ParseDurationString(configuration.VerifyCacheDuration); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing server verify_cache_duration string: %s", err))
+	}
+
+	if configuration.VerifyWebSocketCacheDuration == "" {
+		configuration.VerifyWebSocketCacheDuration = schema.DefaultServerConfiguration.VerifyWebSocketCacheDuration
+	} else if _, err := utils.ParseDurationString(configuration.VerifyWebSocketCacheDuration); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing server verify_websocket_cache_duration string: %s", err))
+	}
+
+	if configuration.VerifyMaxAuthenticationAge == "" {
+		configuration.VerifyMaxAuthenticationAge = schema.DefaultServerConfiguration.VerifyMaxAuthenticationAge
+	} else if _, err := utils.ParseDurationString(configuration.VerifyMaxAuthenticationAge); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing server verify_max_authentication_age string: %s", err))
+	}
+}
+
+func validateServerVerifyHeaders(configuration *schema.ServerVerifyHeadersConfiguration, validator *schema.StructValidator) {
+	defaults := schema.DefaultServerConfiguration.VerifyHeaders
+
+	for _, header := range []struct {
+		value    *string
+		fallback string
+	}{
+		{&configuration.User, defaults.User},
+		{&configuration.Groups, defaults.Groups},
+		{&configuration.Name, defaults.Name},
+		{&configuration.Email, defaults.Email},
+	} {
+		if *header.value == "" {
+			*header.value = header.fallback
+		}
+	}
+
+	for _, exclude := range configuration.Exclude {
+		if !utils.IsStringInSlice(exclude, validVerifyHeaders) {
+			validator.Push(fmt.Errorf("server verify header %s can't be excluded, it must be one of %s", exclude, strings.Join(validVerifyHeaders, ", ")))
+		}
+	}
+
+	for i, header := range configuration.Static {
+		if header.Name == "" {
+			validator.Push(fmt.Errorf("server verify static header %d must have a name", i+1))
+		}
+	}
+
+	for i, header := range configuration.Attributes {
+		if header.Attribute == "" || header.Header == "" {
+			validator.Push(fmt.Errorf("server verify attribute header %d must have an attribute and a header", i+1))
+		}
+	}
+
+	if configuration.Signature.Header == "" {
+		configuration.Signature.Header = defaults.Signature.Header
+	}
+}
+
+// validateServerVerifyGRPC checks none of the headers set by the verify endpoint uses a prefix reserved by gRPC, they
+// would otherwise be mistaken for the metadata of the gRPC protocol itself.
+func validateServerVerifyGRPC(configuration schema.ServerVerifyHeadersConfiguration, validator *schema.StructValidator) {
+	headers := []string{configuration.User, configuration.Groups, configuration.Name, configuration.Email,
+		configuration.Rule, configuration.Level, configuration.Methods, configuration.Signature.Header}
+
+	for _, header := range configuration.Static {
+		headers = append(headers, header.Name)
+	}
+
+	for _, header := range configuration.Attributes {
+		headers = append(headers, header.Header)
+	}
+
+	for _, header := range headers {
+		if lower := strings.ToLower(header); strings.HasPrefix(lower, "grpc-") || strings.HasPrefix(lower, ":") {
+			validator.Push(fmt.Errorf("server verify header %s can't be used with gRPC since its prefix is reserved", header))
+		}
+	}
+}
+
+func validateServerVerifyStrategies(configuration []schema.ServerVerifyStrategyConfiguration, validator *schema.StructValidator) {
+	names := make([]string, 0, len(configuration))
+
+	for _, strategy := range configuration {
+		switch {
+		case !utils.IsStringInSlice(strategy.Name, validVerifyStrategies):
+			validator.Push(fmt.Errorf("server verify strategy %s is invalid, it must be one of %s", strategy.Name, strings.Join(validVerifyStrategies, ", ")))
+		case utils.IsStringInSlice(strategy.Name, names):
+			validator.Push(fmt.Errorf("server verify strategy %s is configured more than once", strategy.Name))
+		default:
+			names = append(names, strategy.Name)
+		}
+	}
+}
+
+func validateServerVerifyImpersonation(configuration schema.ServerVerifyImpersonationConfiguration, validator *schema.StructValidator) {
+	if configuration.Header != "" && len(configuration.Groups) == 0 {
+		validator.Push(fmt.Errorf("server verify impersonation must have at least one group allowed to impersonate when the header is set"))
+	}
+
+	for _, group := range configuration.TargetGroups {
+		if utils.IsStringInSlice(group, configuration.Groups) {
+			validator.Push(fmt.Errorf("server verify impersonation target group %s must not be a group allowed to impersonate", group))
+		}
+	}
+}
+
+func validateServerVerifyBasicAuth(configuration *schema.ServerVerifyBasicAuthConfiguration, validator *schema.StructValidator) {
+	if configuration.Realm == "" {
+		configuration.Realm = schema.DefaultServerConfiguration.VerifyBasicAuth.Realm
+	} else if strings.ContainsAny(configuration.Realm, "\"\\") {
+		validator.Push(fmt.Errorf("server verify basic auth realm must not contain quotes or backslashes"))
+	}
+
+	// The charset parameter of the Basic scheme only allows UTF-8, see RFC 7617.
+	if configuration.Charset != "" && !strings.EqualFold(configuration.Charset, "UTF-8") {
+		validator.Push(fmt.Errorf("server verify basic auth charset must be UTF-8 when it's set but it is %s", configuration.Charset))
+	}
+}
+
+func validateServerVerifyRateLimit(configuration *schema.ServerVerifyRateLimitConfiguration, validator *schema.StructValidator) {
+	switch {
+	case configuration.Requests < 0:
+		validator.Push(fmt.Errorf("server verify rate limit requests must be 0 or above"))
+	case configuration.Burst < 0:
+		validator.Push(fmt.Errorf("server verify rate limit burst must be 0 or above"))
+	case configuration.Burst == 0:
+		configuration.Burst = configuration.Requests
+	}
+
+	for _, network := range configuration.TrustedProxies {
+		if !IsNetworkValid(network) {
+			validator.Push(fmt.Errorf("server verify rate limit trusted proxy %s must be a valid IP or CIDR", network))
+		}
+	}
+}
+
+func validateServerVerifyBypass(configuration []schema.ServerVerifyBypassConfiguration, validator *schema.StructValidator) {
+	for i, bypass := range configuration {
+		if len(bypass.Domains) == 0 {
+			validator.Push(fmt.Errorf("server verify bypass %d must have at least one domain", i+1))
+		}
+
+		// The user agents are set by the clients, so they can't restrict a matcher on their own.
+		if len(bypass.Paths) == 0 && len(bypass.Networks) == 0 {
+			validator.Push(fmt.Errorf("server verify bypass %d must have at least one of paths or networks", i+1))
+		}
+
+		for _, p := range bypass.Paths {
+			if !strings.HasPrefix(p, "/") {
+				validator.Push(fmt.Errorf("server verify bypass %d path %s must start with a forward slash", i+1, p))
+			}
+		}
+
+		for _, network := range bypass.Networks {
+			if !IsNetworkValid(network) {
+				validator.Push(fmt.Errorf("server verify bypass %d network %s must be a valid IP or CIDR", i+1, network))
+			}
+		}
+	}
+}
+
+// validateServerVerifyTemplates checks the templates can be read and parsed, the JSON templates being able to use the
+// json function of the verify endpoint.
+func validateServerVerifyTemplates(configuration schema.ServerVerifyTemplatesConfiguration, validator *schema.StructValidator) {
+	for _, t := range []struct {
+		name, path string
+		html       bool
+	}{
+		{"unauthorized_html", configuration.UnauthorizedHTML, true},
+		{"unauthorized_json", configuration.UnauthorizedJSON, false},
+		{"forbidden_html", configuration.ForbiddenHTML, true},
+		{"forbidden_json", configuration.ForbiddenJSON, false},
+	} {
+		if t.path == "" {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(t.path)
+		if err != nil {
+			validator.Push(fmt.Errorf("server verify template %s can't be read: %s", t.name, err))
+			continue
+		}
+
+		if t.html {
+			_, err = htmltemplate.New(t.name).Parse(string(content))
+		} else {
+			_, err = texttemplate.New(t.name).Funcs(texttemplate.FuncMap{"json": func(interface{}) string { return "" }}).Parse(string(content))
+		}
+
+		if err != nil {
+			validator.Push(fmt.Errorf("server verify template %s can't be parsed: %s", t.name, err))
+		}
+	}
+}
+
+func validateServerVerifyRedirect(configuration *schema.ServerVerifyRedirectConfiguration, validator *schema.StructValidator) {
+	switch configuration.StatusCode {
+	case 0:
+		configuration.StatusCode = schema.DefaultServerConfiguration.VerifyRedirect.StatusCode
+	case 301, 302, 303, 307, 308:
+	default:
+		validator.Push(fmt.Errorf("server verify redirect status code must be one of 301, 302, 303, 307 or 308 but it is %d", configuration.StatusCode))
+	}
 }
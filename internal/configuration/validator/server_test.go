@@ -1,6 +1,8 @@
 package validator
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -61,3 +63,266 @@ func TestShouldRaiseOnForwardSlashInPath(t *testing.T) {
 	assert.Len(t, validator.Errors(), 1)
 	assert.Error(t, validator.Errors()[0], "server path must not contain any forward slashes")
 }
+
+func TestShouldValidateVerifyHeaders(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		VerifyHeaders: schema.ServerVerifyHeadersConfiguration{
+			User:    "X-Forwarded-User",
+			Exclude: []string{"name", "phone"},
+			Static:  []schema.ServerStaticHeaderConfiguration{{Name: "X-Auth", Value: "authelia"}, {Value: "abc"}},
+			Attributes: []schema.ServerVerifyAttributeHeaderConfiguration{
+				{Attribute: "department", Header: "Remote-Department"},
+				{Attribute: "employeeNumber"},
+			},
+		},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 3)
+	assert.EqualError(t, validator.Errors()[0], "server verify header phone can't be excluded, it must be one of user, groups, name, email")
+	assert.EqualError(t, validator.Errors()[1], "server verify static header 2 must have a name")
+	assert.EqualError(t, validator.Errors()[2], "server verify attribute header 2 must have an attribute and a header")
+
+	assert.Equal(t, "X-Forwarded-User", config.VerifyHeaders.User)
+	assert.Equal(t, "Remote-Groups", config.VerifyHeaders.Groups)
+	assert.Equal(t, "Remote-Name", config.VerifyHeaders.Name)
+	assert.Equal(t, "Remote-Email", config.VerifyHeaders.Email)
+	assert.Equal(t, "Remote-Signature", config.VerifyHeaders.Signature.Header)
+}
+
+func TestShouldValidateVerifyCacheDuration(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "0", config.VerifyCacheDuration)
+
+	config.VerifyCacheDuration = "5 seconds"
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Error occurred parsing server verify_cache_duration string: Could not convert the input string of 5 seconds into a duration")
+}
+
+func TestShouldValidateVerifyWebSocketCacheDuration(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "0", config.VerifyWebSocketCacheDuration)
+
+	config.VerifyWebSocketCacheDuration = "1 hour"
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Error occurred parsing server verify_websocket_cache_duration string: Could not convert the input string of 1 hour into a duration")
+}
+
+func TestShouldValidateVerifyMaxAuthenticationAge(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "0", config.VerifyMaxAuthenticationAge)
+
+	config.VerifyMaxAuthenticationAge = "1 day"
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Error occurred parsing server verify_max_authentication_age string: Could not convert the input string of 1 day into a duration")
+}
+
+func TestShouldValidateVerifyBasicAuth(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "Authentication required", config.VerifyBasicAuth.Realm)
+
+	config.VerifyBasicAuth = schema.ServerVerifyBasicAuthConfiguration{
+		Realm:   "Git \"repositories\"",
+		Charset: "ISO-8859-1",
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "server verify basic auth realm must not contain quotes or backslashes")
+	assert.EqualError(t, validator.Errors()[1], "server verify basic auth charset must be UTF-8 when it's set but it is ISO-8859-1")
+}
+
+func TestShouldValidateVerifyGRPC(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		VerifyGRPC: true,
+		VerifyHeaders: schema.ServerVerifyHeadersConfiguration{
+			User:   "Grpc-User",
+			Static: []schema.ServerStaticHeaderConfiguration{{Name: ":authority", Value: "authelia"}},
+		},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "server verify header Grpc-User can't be used with gRPC since its prefix is reserved")
+	assert.EqualError(t, validator.Errors()[1], "server verify header :authority can't be used with gRPC since its prefix is reserved")
+
+	validator = schema.NewStructValidator()
+	config.VerifyGRPC = false
+
+	ValidateServer(&config, validator)
+	assert.Len(t, validator.Errors(), 0)
+}
+
+func TestShouldValidateVerifyStrategies(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		VerifyStrategies: []schema.ServerVerifyStrategyConfiguration{
+			{Name: "basic_auth", Fallthrough: true},
+			{Name: "cookie"},
+			{Name: "session_cookie"},
+			{Name: "basic_auth"},
+		},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "server verify strategy cookie is invalid, it must be one of trusted_header, client_certificate, api_key, bearer_token, basic_auth, session_cookie")
+	assert.EqualError(t, validator.Errors()[1], "server verify strategy basic_auth is configured more than once")
+}
+
+func TestShouldValidateVerifyImpersonation(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		VerifyImpersonation: schema.ServerVerifyImpersonationConfiguration{Header: "Remote-Impersonate"},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "server verify impersonation must have at least one group allowed to impersonate when the header is set")
+
+	validator = schema.NewStructValidator()
+	config.VerifyImpersonation.Groups = []string{"admins"}
+	config.VerifyImpersonation.TargetGroups = []string{"dev"}
+
+	ValidateServer(&config, validator)
+	assert.Len(t, validator.Errors(), 0)
+
+	validator = schema.NewStructValidator()
+	config.VerifyImpersonation.TargetGroups = []string{"dev", "admins"}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "server verify impersonation target group admins must not be a group allowed to impersonate")
+}
+
+func TestShouldValidateVerifyRateLimit(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		VerifyRateLimit: schema.ServerVerifyRateLimitConfiguration{Requests: 50},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, 50, config.VerifyRateLimit.Burst)
+
+	config.VerifyRateLimit = schema.ServerVerifyRateLimitConfiguration{Requests: 50, Burst: -1}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "server verify rate limit burst must be 0 or above")
+
+	validator = schema.NewStructValidator()
+	config.VerifyRateLimit = schema.ServerVerifyRateLimitConfiguration{Requests: 50, TrustedProxies: []string{"10.0.0.1", "172.16.0.0/12", "proxy"}}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "server verify rate limit trusted proxy proxy must be a valid IP or CIDR")
+}
+
+func TestShouldValidateVerifyBypass(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		VerifyBypass: []schema.ServerVerifyBypassConfiguration{
+			{Domains: []string{"*.example.com"}, Paths: []string{"/healthz"}, UserAgents: []string{"kube-probe/"}, Networks: []string{"10.0.0.0/8"}},
+			{Domains: []string{"status.example.com"}, Paths: []string{"/status"}, UserAgents: []string{"Uptime-Kuma/"}},
+		},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 0)
+
+	config.VerifyBypass = []schema.ServerVerifyBypassConfiguration{
+		{},
+		{Domains: []string{"example.com"}, Paths: []string{"healthz"}, Networks: []string{"10.0.0.0/33"}},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 4)
+	assert.EqualError(t, validator.Errors()[0], "server verify bypass 1 must have at least one domain")
+	assert.EqualError(t, validator.Errors()[1], "server verify bypass 1 must have at least one of paths or networks")
+	assert.EqualError(t, validator.Errors()[2], "server verify bypass 2 path healthz must start with a forward slash")
+	assert.EqualError(t, validator.Errors()[3], "server verify bypass 2 network 10.0.0.0/33 must be a valid IP or CIDR")
+}
+
+func TestShouldRaiseErrorWhenVerifyBypassOnlyMatchesUserAgents(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		VerifyBypass: []schema.ServerVerifyBypassConfiguration{
+			{Domains: []string{"status.example.com"}, UserAgents: []string{"Uptime-Kuma/"}},
+		},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "server verify bypass 1 must have at least one of paths or networks")
+}
+
+func TestShouldValidateVerifyRedirect(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, 302, config.VerifyRedirect.StatusCode)
+
+	config.VerifyRedirect.StatusCode = 200
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "server verify redirect status code must be one of 301, 302, 303, 307 or 308 but it is 200")
+}
+
+func TestShouldValidateVerifyTemplates(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.json")
+	require.NoError(t, ioutil.WriteFile(valid, []byte(`{"error": {{ json .Status }}}`), 0600))
+
+	invalid := filepath.Join(dir, "invalid.html")
+	require.NoError(t, ioutil.WriteFile(invalid, []byte(`<p>{{ .Status </p>`), 0600))
+
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		VerifyTemplates: schema.ServerVerifyTemplatesConfiguration{
+			UnauthorizedJSON: valid,
+			ForbiddenJSON:    valid,
+		},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 0)
+
+	config.VerifyTemplates = schema.ServerVerifyTemplatesConfiguration{
+		UnauthorizedHTML: invalid,
+		ForbiddenHTML:    filepath.Join(dir, "missing.html"),
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 2)
+	assert.Contains(t, validator.Errors()[0].Error(), "server verify template unauthorized_html can't be parsed: ")
+	assert.Contains(t, validator.Errors()[1].Error(), "server verify template forbidden_html can't be read: ")
+}
@@ -3,6 +3,7 @@ package validator
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
@@ -49,6 +50,10 @@ func validateSession(configuration *schema.SessionConfiguration, validator *sche
 		validator.Push(fmt.Errorf("Error occurred parsing session remember_me_duration string: %s", err))
 	}
 
+	if configuration.Domain == "" && len(configuration.Domains) != 0 {
+		configuration.Domain = configuration.Domains[0].Domain
+	}
+
 	if configuration.Domain == "" {
 		validator.Push(errors.New("Set domain of the session object"))
 	}
@@ -57,6 +62,8 @@ func validateSession(configuration *schema.SessionConfiguration, validator *sche
 		validator.Push(errors.New("The domain of the session must be the root domain you're protecting instead of a wildcard domain"))
 	}
 
+	validateSessionDomains(configuration, validator)
+
 	if configuration.SameSite == "" {
 		configuration.SameSite = schema.DefaultSessionConfiguration.SameSite
 	} else if configuration.SameSite != "none" && configuration.SameSite != "lax" && configuration.SameSite != "strict" {
@@ -64,6 +71,32 @@ func validateSession(configuration *schema.SessionConfiguration, validator *sche
 	}
 }
 
+func validateSessionDomains(configuration *schema.SessionConfiguration, validator *schema.StructValidator) {
+	for i, domain := range configuration.Domains {
+		switch {
+		case domain.Domain == "":
+			validator.Push(fmt.Errorf("Session domain %d must have a domain", i+1))
+			continue
+		case strings.Contains(domain.Domain, "*"):
+			validator.Push(fmt.Errorf("Session domain %s must be the root domain you're protecting instead of a wildcard domain", domain.Domain))
+		}
+
+		if domain.AutheliaURL == "" {
+			continue
+		}
+
+		autheliaURL, err := url.Parse(domain.AutheliaURL)
+		if err != nil {
+			validator.Push(fmt.Errorf("Session domain %s has an authelia_url which could not be parsed: %s", domain.Domain, err))
+			continue
+		}
+
+		if autheliaURL.Scheme != "https" || !strings.HasSuffix(autheliaURL.Hostname(), domain.Domain) {
+			validator.Push(fmt.Errorf("Session domain %s has an authelia_url %s which must be a https URL under this domain", domain.Domain, domain.AutheliaURL))
+		}
+	}
+}
+
 func validateRedis(configuration *schema.SessionConfiguration, validator *schema.StructValidator) {
 	if configuration.Redis.Host == "" {
 		validator.Push(fmt.Errorf(errFmtSessionRedisHostRequired, "redis"))
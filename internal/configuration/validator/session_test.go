@@ -392,6 +392,42 @@ func TestShouldRaiseErrorWhenDomainIsWildcard(t *testing.T) {
 	assert.EqualError(t, validator.Errors()[0], "The domain of the session must be the root domain you're protecting instead of a wildcard domain")
 }
 
+func TestShouldUseFirstSessionDomainWhenDomainNotSet(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Domain = ""
+	config.Domains = []schema.SessionDomainConfiguration{
+		{Domain: "example.org", AutheliaURL: "https://auth.example.org"},
+		{Domain: "example.net"},
+	}
+
+	ValidateSession(&config, validator)
+
+	assert.False(t, validator.HasWarnings())
+	assert.False(t, validator.HasErrors())
+	assert.Equal(t, "example.org", config.Domain)
+}
+
+func TestShouldRaiseErrorsWhenSessionDomainsIncorrectlyConfigured(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Domains = []schema.SessionDomainConfiguration{
+		{AutheliaURL: "https://auth.example.org"},
+		{Domain: "*.example.org"},
+		{Domain: "example.net", AutheliaURL: "http://auth.example.net"},
+		{Domain: "example.io", AutheliaURL: "https://auth.example.org"},
+	}
+
+	ValidateSession(&config, validator)
+
+	assert.False(t, validator.HasWarnings())
+	require.Len(t, validator.Errors(), 4)
+	assert.EqualError(t, validator.Errors()[0], "Session domain 1 must have a domain")
+	assert.EqualError(t, validator.Errors()[1], "Session domain *.example.org must be the root domain you're protecting instead of a wildcard domain")
+	assert.EqualError(t, validator.Errors()[2], "Session domain example.net has an authelia_url http://auth.example.net which must be a https URL under this domain")
+	assert.EqualError(t, validator.Errors()[3], "Session domain example.io has an authelia_url https://auth.example.org which must be a https URL under this domain")
+}
+
 func TestShouldRaiseErrorWhenSameSiteSetIncorrectly(t *testing.T) {
 	validator := schema.NewStructValidator()
 	config := newDefaultSessionConfig()
@@ -2,26 +2,124 @@ package validator
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
 )
 
+var storageTablePrefixRegexp = regexp.MustCompile(`^[a-z0-9_]*$`)
+
 // ValidateStorage validates storage configuration.
-func ValidateStorage(configuration schema.StorageConfiguration, validator *schema.StructValidator) {
-	if configuration.Local == nil && configuration.MySQL == nil && configuration.PostgreSQL == nil {
-		validator.Push(errors.New("A storage configuration must be provided. It could be 'local', 'mysql' or 'postgres'"))
+func ValidateStorage(configuration *schema.StorageConfiguration, validator *schema.StructValidator) {
+	if configuration.Local == nil && configuration.MySQL == nil && configuration.PostgreSQL == nil && configuration.CockroachDB == nil {
+		validator.Push(errors.New("A storage configuration must be provided. It could be 'local', 'mysql', 'postgres' or 'cockroachdb'"))
+	}
+
+	if !storageTablePrefixRegexp.MatchString(configuration.TablePrefix) {
+		validator.Push(errors.New("the storage table prefix must only contain lowercase letters, numbers and underscores"))
+	}
+
+	validateStorageRetryConfiguration(&configuration.Retry, validator)
+	validateStorageAuthenticationLogsConfiguration(&configuration.AuthenticationLogs, validator)
+	validateStorageWebAuthnCredentialsConfiguration(&configuration.WebAuthnCredentials, validator)
+
+	if configuration.Cache.TTL != "" {
+		if _, err := utils.ParseDurationString(configuration.Cache.TTL); err != nil {
+			validator.Push(fmt.Errorf("Error occurred parsing storage cache ttl string: %s", err))
+		}
 	}
 
+	validateStorageMaintenanceConfiguration(configuration, validator)
+
 	switch {
 	case configuration.MySQL != nil:
 		validateSQLConfiguration(&configuration.MySQL.SQLStorageConfiguration, validator)
 	case configuration.PostgreSQL != nil:
 		validatePostgreSQLConfiguration(configuration.PostgreSQL, validator)
+	case configuration.CockroachDB != nil:
+		validateCockroachDBConfiguration(configuration.CockroachDB, validator)
 	case configuration.Local != nil:
 		validateLocalStorageConfiguration(configuration.Local, validator)
 	}
 }
 
+func validateStorageRetryConfiguration(configuration *schema.StorageRetryConfiguration, validator *schema.StructValidator) {
+	if configuration.MaxAttempts == 0 {
+		configuration.MaxAttempts = schema.DefaultStorageConfiguration.Retry.MaxAttempts
+	} else if configuration.MaxAttempts < 0 {
+		validator.Push(errors.New("the storage retry max_attempts must be 1 or more"))
+	}
+
+	if configuration.Backoff == 0 {
+		configuration.Backoff = schema.DefaultStorageConfiguration.Retry.Backoff
+	}
+
+	if configuration.MaxBackoff == 0 {
+		configuration.MaxBackoff = schema.DefaultStorageConfiguration.Retry.MaxBackoff
+	}
+
+	if configuration.Backoff < 0 || configuration.MaxBackoff < 0 {
+		validator.Push(errors.New("the storage retry backoff and max_backoff must not be negative"))
+	} else if configuration.Backoff > configuration.MaxBackoff {
+		validator.Push(errors.New("the storage retry backoff cannot be greater than max_backoff"))
+	}
+}
+
+func validateStorageAuthenticationLogsConfiguration(configuration *schema.StorageAuthenticationLogsConfiguration, validator *schema.StructValidator) {
+	if configuration.MaxAge != "" {
+		if _, err := utils.ParseDurationString(configuration.MaxAge); err != nil {
+			validator.Push(fmt.Errorf("Error occurred parsing authentication logs max_age string: %s", err))
+		}
+	}
+
+	if configuration.MaxCount < 0 {
+		validator.Push(errors.New("the authentication logs max_count must not be negative"))
+	}
+
+	if configuration.PruneInterval == "" {
+		configuration.PruneInterval = schema.DefaultStorageConfiguration.AuthenticationLogs.PruneInterval
+	} else if pruneInterval, err := utils.ParseDurationString(configuration.PruneInterval); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing authentication logs prune_interval string: %s", err))
+	} else if pruneInterval <= 0 {
+		validator.Push(errors.New("the authentication logs prune_interval must be greater than 0"))
+	}
+}
+
+func validateStorageWebAuthnCredentialsConfiguration(configuration *schema.StorageWebAuthnCredentialsConfiguration, validator *schema.StructValidator) {
+	if configuration.GracePeriod == "" {
+		configuration.GracePeriod = schema.DefaultStorageConfiguration.WebAuthnCredentials.GracePeriod
+	} else if _, err := utils.ParseDurationString(configuration.GracePeriod); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing WebAuthn credentials grace_period string: %s", err))
+	}
+
+	if configuration.PurgeInterval == "" {
+		configuration.PurgeInterval = schema.DefaultStorageConfiguration.WebAuthnCredentials.PurgeInterval
+	} else if purgeInterval, err := utils.ParseDurationString(configuration.PurgeInterval); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing WebAuthn credentials purge_interval string: %s", err))
+	} else if purgeInterval <= 0 {
+		validator.Push(errors.New("the WebAuthn credentials purge_interval must be greater than 0"))
+	}
+}
+
+func validateStorageMaintenanceConfiguration(configuration *schema.StorageConfiguration, validator *schema.StructValidator) {
+	if configuration.Maintenance.Interval == "" {
+		return
+	}
+
+	if interval, err := utils.ParseDurationString(configuration.Maintenance.Interval); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing storage maintenance interval string: %s", err))
+	} else if interval <= 0 {
+		validator.Push(errors.New("the storage maintenance interval must be greater than 0"))
+	}
+
+	if configuration.CockroachDB != nil {
+		validator.Push(errors.New("the storage maintenance can't be scheduled with cockroachdb which performs it automatically"))
+	}
+}
+
 func validateSQLConfiguration(configuration *schema.SQLStorageConfiguration, validator *schema.StructValidator) {
 	if configuration.Password == "" || configuration.Username == "" {
 		validator.Push(errors.New("the SQL username and password must be provided"))
@@ -30,23 +128,79 @@ func validateSQLConfiguration(configuration *schema.SQLStorageConfiguration, val
 	if configuration.Database == "" {
 		validator.Push(errors.New("the SQL database must be provided"))
 	}
+
+	for i, replica := range configuration.ReadReplicas {
+		if replica.Host == "" {
+			validator.Push(errors.New("the SQL read replicas require a host set but you have not set the host for one or more read replicas"))
+			break
+		}
+
+		if replica.Port == 0 {
+			configuration.ReadReplicas[i].Port = configuration.Port
+		}
+	}
+
+	if configuration.ConnMaxLifetime != "" {
+		if _, err := utils.ParseDurationString(configuration.ConnMaxLifetime); err != nil {
+			validator.Push(fmt.Errorf("Error occurred parsing SQL conn_max_lifetime string: %s", err))
+		}
+	}
+
+	if configuration.ConnMaxIdleTime != "" {
+		if _, err := utils.ParseDurationString(configuration.ConnMaxIdleTime); err != nil {
+			validator.Push(fmt.Errorf("Error occurred parsing SQL conn_max_idle_time string: %s", err))
+		}
+	}
 }
 
 func validatePostgreSQLConfiguration(configuration *schema.PostgreSQLStorageConfiguration, validator *schema.StructValidator) {
 	validateSQLConfiguration(&configuration.SQLStorageConfiguration, validator)
 
-	if configuration.SSLMode == "" {
-		configuration.SSLMode = testModeDisabled
+	configuration.SSLMode = validateSQLSSLMode(configuration.SSLMode, validator)
+}
+
+func validateCockroachDBConfiguration(configuration *schema.CockroachDBStorageConfiguration, validator *schema.StructValidator) {
+	validateSQLConfiguration(&configuration.SQLStorageConfiguration, validator)
+
+	configuration.SSLMode = validateSQLSSLMode(configuration.SSLMode, validator)
+}
+
+func validateSQLSSLMode(mode string, validator *schema.StructValidator) string {
+	if mode == "" {
+		return testModeDisabled
 	}
 
-	if !(configuration.SSLMode == testModeDisabled || configuration.SSLMode == "require" ||
-		configuration.SSLMode == "verify-ca" || configuration.SSLMode == "verify-full") {
+	if !(mode == testModeDisabled || mode == "require" || mode == "verify-ca" || mode == "verify-full") {
 		validator.Push(errors.New("SSL mode must be 'disable', 'require', 'verify-ca', or 'verify-full'"))
 	}
+
+	return mode
 }
 
 func validateLocalStorageConfiguration(configuration *schema.LocalStorageConfiguration, validator *schema.StructValidator) {
 	if configuration.Path == "" {
 		validator.Push(errors.New("A file path must be provided with key 'path'"))
 	}
+
+	if configuration.JournalMode != "" {
+		configuration.JournalMode = strings.ToUpper(configuration.JournalMode)
+
+		if !utils.IsStringInSlice(configuration.JournalMode, validSQLiteJournalModes) {
+			validator.Push(fmt.Errorf("the SQLite journal_mode must be one of %s", strings.Join(validSQLiteJournalModes, ", ")))
+		}
+	}
+
+	if configuration.BusyTimeout == "" {
+		configuration.BusyTimeout = schema.DefaultLocalStorageConfiguration.BusyTimeout
+	} else if _, err := utils.ParseDurationString(configuration.BusyTimeout); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing SQLite busy_timeout string: %s", err))
+	}
+
+	if configuration.Synchronous != "" {
+		configuration.Synchronous = strings.ToUpper(configuration.Synchronous)
+
+		if !utils.IsStringInSlice(configuration.Synchronous, validSQLiteSynchronousModes) {
+			validator.Push(fmt.Errorf("the SQLite synchronous must be one of %s", strings.Join(validSQLiteSynchronousModes, ", ")))
+		}
+	}
 }
@@ -16,6 +16,7 @@ type StorageSuite struct {
 
 func (suite *StorageSuite) SetupTest() {
 	suite.validator = schema.NewStructValidator()
+	suite.configuration = schema.StorageConfiguration{}
 	suite.configuration.Local = &schema.LocalStorageConfiguration{
 		Path: "/this/is/a/path",
 	}
@@ -24,17 +25,17 @@ func (suite *StorageSuite) SetupTest() {
 func (suite *StorageSuite) TestShouldValidateOneStorageIsConfigured() {
 	suite.configuration.Local = nil
 
-	ValidateStorage(suite.configuration, suite.validator)
+	ValidateStorage(&suite.configuration, suite.validator)
 
 	suite.Assert().False(suite.validator.HasWarnings())
 	suite.Require().Len(suite.validator.Errors(), 1)
-	suite.Assert().EqualError(suite.validator.Errors()[0], "A storage configuration must be provided. It could be 'local', 'mysql' or 'postgres'")
+	suite.Assert().EqualError(suite.validator.Errors()[0], "A storage configuration must be provided. It could be 'local', 'mysql', 'postgres' or 'cockroachdb'")
 }
 
 func (suite *StorageSuite) TestShouldValidateLocalPathIsProvided() {
 	suite.configuration.Local.Path = ""
 
-	ValidateStorage(suite.configuration, suite.validator)
+	ValidateStorage(&suite.configuration, suite.validator)
 
 	suite.Assert().False(suite.validator.HasWarnings())
 	suite.Require().Len(suite.validator.Errors(), 1)
@@ -44,15 +45,181 @@ func (suite *StorageSuite) TestShouldValidateLocalPathIsProvided() {
 	suite.validator.Clear()
 	suite.configuration.Local.Path = "/myapth"
 
-	ValidateStorage(suite.configuration, suite.validator)
+	ValidateStorage(&suite.configuration, suite.validator)
 
 	suite.Assert().False(suite.validator.HasWarnings())
 	suite.Assert().False(suite.validator.HasErrors())
 }
 
+func (suite *StorageSuite) TestShouldValidateLocalPragmas() {
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Assert().False(suite.validator.HasErrors())
+	suite.Assert().Equal("5s", suite.configuration.Local.BusyTimeout)
+	suite.Assert().Equal("", suite.configuration.Local.JournalMode)
+	suite.Assert().Equal("", suite.configuration.Local.Synchronous)
+
+	suite.configuration.Local.JournalMode = "wal"
+	suite.configuration.Local.Synchronous = "normal"
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Assert().False(suite.validator.HasErrors())
+	suite.Assert().Equal("WAL", suite.configuration.Local.JournalMode)
+	suite.Assert().Equal("NORMAL", suite.configuration.Local.Synchronous)
+
+	suite.configuration.Local.JournalMode = "journal"
+	suite.configuration.Local.BusyTimeout = "abc"
+	suite.configuration.Local.Synchronous = "always"
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 3)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "the SQLite journal_mode must be one of DELETE, TRUNCATE, PERSIST, MEMORY, WAL, OFF")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "Error occurred parsing SQLite busy_timeout string: Could not convert the input string of abc into a duration")
+	suite.Assert().EqualError(suite.validator.Errors()[2], "the SQLite synchronous must be one of OFF, NORMAL, FULL, EXTRA")
+}
+
+func (suite *StorageSuite) TestShouldValidateTablePrefix() {
+	suite.configuration.TablePrefix = "authelia_"
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Assert().False(suite.validator.HasErrors())
+
+	suite.configuration.TablePrefix = "Authelia-"
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 1)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "the storage table prefix must only contain lowercase letters, numbers and underscores")
+}
+
+func (suite *StorageSuite) TestShouldSetDefaultRetryConfiguration() {
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Assert().False(suite.validator.HasErrors())
+
+	suite.Assert().Equal(schema.DefaultStorageConfiguration.Retry, suite.configuration.Retry)
+}
+
+func (suite *StorageSuite) TestShouldValidateRetryConfiguration() {
+	suite.configuration.Retry = schema.StorageRetryConfiguration{
+		MaxAttempts: -1,
+		Backoff:     -1,
+	}
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 2)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "the storage retry max_attempts must be 1 or more")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "the storage retry backoff and max_backoff must not be negative")
+
+	suite.validator.Clear()
+
+	suite.configuration.Retry = schema.StorageRetryConfiguration{
+		MaxAttempts: 5,
+		Backoff:     2000,
+	}
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 1)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "the storage retry backoff cannot be greater than max_backoff")
+}
+
+func (suite *StorageSuite) TestShouldSetDefaultAuthenticationLogsPruneInterval() {
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Assert().False(suite.validator.HasErrors())
+
+	suite.Assert().Equal("1h", suite.configuration.AuthenticationLogs.PruneInterval)
+}
+
+func (suite *StorageSuite) TestShouldValidateAuthenticationLogsConfiguration() {
+	suite.configuration.AuthenticationLogs = schema.StorageAuthenticationLogsConfiguration{
+		MaxAge:        "abc",
+		MaxCount:      -1,
+		PruneInterval: "0",
+	}
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 3)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Error occurred parsing authentication logs max_age string: Could not convert the input string of abc into a duration")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "the authentication logs max_count must not be negative")
+	suite.Assert().EqualError(suite.validator.Errors()[2], "the authentication logs prune_interval must be greater than 0")
+}
+
+func (suite *StorageSuite) TestShouldSetDefaultWebAuthnCredentialsConfiguration() {
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Assert().False(suite.validator.HasErrors())
+
+	suite.Assert().Equal("30d", suite.configuration.WebAuthnCredentials.GracePeriod)
+	suite.Assert().Equal("1h", suite.configuration.WebAuthnCredentials.PurgeInterval)
+}
+
+func (suite *StorageSuite) TestShouldValidateWebAuthnCredentialsConfiguration() {
+	suite.configuration.WebAuthnCredentials = schema.StorageWebAuthnCredentialsConfiguration{
+		GracePeriod:   "abc",
+		PurgeInterval: "0",
+	}
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 2)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Error occurred parsing WebAuthn credentials grace_period string: Could not convert the input string of abc into a duration")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "the WebAuthn credentials purge_interval must be greater than 0")
+}
+
+func (suite *StorageSuite) TestShouldValidateMaintenanceInterval() {
+	suite.configuration.Maintenance.Interval = "1w"
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Assert().False(suite.validator.HasErrors())
+
+	suite.configuration.Maintenance.Interval = "abc"
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Require().Len(suite.validator.Errors(), 1)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Error occurred parsing storage maintenance interval string: Could not convert the input string of abc into a duration")
+
+	suite.validator.Clear()
+	suite.configuration.Maintenance.Interval = "1d"
+	suite.configuration.Local = nil
+	suite.configuration.CockroachDB = &schema.CockroachDBStorageConfiguration{
+		SQLStorageConfiguration: schema.SQLStorageConfiguration{
+			Username: "myuser",
+			Password: "pass",
+			Database: "database",
+		},
+	}
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Require().Len(suite.validator.Errors(), 1)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "the storage maintenance can't be scheduled with cockroachdb which performs it automatically")
+}
+
 func (suite *StorageSuite) TestShouldValidateSQLUsernamePasswordAndDatabaseAreProvided() {
 	suite.configuration.MySQL = &schema.MySQLStorageConfiguration{}
-	ValidateStorage(suite.configuration, suite.validator)
+	ValidateStorage(&suite.configuration, suite.validator)
 
 	suite.Require().Len(suite.validator.Errors(), 2)
 	suite.Assert().EqualError(suite.validator.Errors()[0], "the SQL username and password must be provided")
@@ -66,12 +233,72 @@ func (suite *StorageSuite) TestShouldValidateSQLUsernamePasswordAndDatabaseArePr
 			Database: "database",
 		},
 	}
-	ValidateStorage(suite.configuration, suite.validator)
+	ValidateStorage(&suite.configuration, suite.validator)
 
 	suite.Assert().False(suite.validator.HasWarnings())
 	suite.Assert().False(suite.validator.HasErrors())
 }
 
+func (suite *StorageSuite) TestShouldValidateSQLReadReplicas() {
+	suite.configuration.MySQL = &schema.MySQLStorageConfiguration{
+		SQLStorageConfiguration: schema.SQLStorageConfiguration{
+			Port:     3307,
+			Username: "myuser",
+			Password: "pass",
+			Database: "database",
+			ReadReplicas: []schema.SQLReplicaConfiguration{
+				{Host: "replica1"},
+				{Host: "replica2", Port: 3308},
+			},
+		},
+	}
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Assert().False(suite.validator.HasErrors())
+
+	suite.Assert().Equal(3307, suite.configuration.MySQL.ReadReplicas[0].Port)
+	suite.Assert().Equal(3308, suite.configuration.MySQL.ReadReplicas[1].Port)
+
+	suite.configuration.MySQL.ReadReplicas = append(suite.configuration.MySQL.ReadReplicas, schema.SQLReplicaConfiguration{})
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 1)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "the SQL read replicas require a host set but you have not set the host for one or more read replicas")
+}
+
+func (suite *StorageSuite) TestShouldValidateSQLConnectionPoolDurations() {
+	suite.configuration.PostgreSQL = &schema.PostgreSQLStorageConfiguration{
+		SQLStorageConfiguration: schema.SQLStorageConfiguration{
+			Username:        "myuser",
+			Password:        "pass",
+			Database:        "database",
+			MaxOpenConns:    20,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: "1h",
+			ConnMaxIdleTime: "5m",
+		},
+	}
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Assert().False(suite.validator.HasErrors())
+
+	suite.configuration.PostgreSQL.ConnMaxLifetime = "1 hour"
+	suite.configuration.PostgreSQL.ConnMaxIdleTime = "abc"
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 2)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Error occurred parsing SQL conn_max_lifetime string: Could not convert the input string of 1 hour into a duration")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "Error occurred parsing SQL conn_max_idle_time string: Could not convert the input string of abc into a duration")
+}
+
 func (suite *StorageSuite) TestShouldValidatePostgresSSLModeIsDisableByDefault() {
 	suite.configuration.PostgreSQL = &schema.PostgreSQLStorageConfiguration{
 		SQLStorageConfiguration: schema.SQLStorageConfiguration{
@@ -81,7 +308,7 @@ func (suite *StorageSuite) TestShouldValidatePostgresSSLModeIsDisableByDefault()
 		},
 	}
 
-	ValidateStorage(suite.configuration, suite.validator)
+	ValidateStorage(&suite.configuration, suite.validator)
 
 	suite.Assert().False(suite.validator.HasWarnings())
 	suite.Assert().False(suite.validator.HasErrors())
@@ -99,7 +326,41 @@ func (suite *StorageSuite) TestShouldValidatePostgresSSLModeMustBeValid() {
 		SSLMode: "unknown",
 	}
 
-	ValidateStorage(suite.configuration, suite.validator)
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 1)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "SSL mode must be 'disable', 'require', 'verify-ca', or 'verify-full'")
+}
+
+func (suite *StorageSuite) TestShouldValidateCockroachDBSSLModeIsDisableByDefault() {
+	suite.configuration.CockroachDB = &schema.CockroachDBStorageConfiguration{
+		SQLStorageConfiguration: schema.SQLStorageConfiguration{
+			Username: "myuser",
+			Password: "pass",
+			Database: "database",
+		},
+	}
+
+	ValidateStorage(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Assert().False(suite.validator.HasErrors())
+
+	suite.Assert().Equal("disable", suite.configuration.CockroachDB.SSLMode)
+}
+
+func (suite *StorageSuite) TestShouldValidateCockroachDBSSLModeMustBeValid() {
+	suite.configuration.CockroachDB = &schema.CockroachDBStorageConfiguration{
+		SQLStorageConfiguration: schema.SQLStorageConfiguration{
+			Username: "myuser",
+			Password: "pass",
+			Database: "database",
+		},
+		SSLMode: "unknown",
+	}
+
+	ValidateStorage(&suite.configuration, suite.validator)
 
 	suite.Assert().False(suite.validator.HasWarnings())
 	suite.Require().Len(suite.validator.Errors(), 1)
@@ -12,6 +12,22 @@ func ValidateTOTP(configuration *schema.TOTPConfiguration, validator *schema.Str
 		configuration.Issuer = schema.DefaultTOTPConfiguration.Issuer
 	}
 
+	switch configuration.Algorithm {
+	case "":
+		configuration.Algorithm = schema.DefaultTOTPConfiguration.Algorithm
+	case "SHA1", "SHA256", "SHA512":
+	default:
+		validator.Push(fmt.Errorf("TOTP Algorithm must be one of SHA1, SHA256 or SHA512 but it is configured as '%s'", configuration.Algorithm))
+	}
+
+	switch configuration.Digits {
+	case 0:
+		configuration.Digits = schema.DefaultTOTPConfiguration.Digits
+	case 6, 8:
+	default:
+		validator.Push(fmt.Errorf("TOTP Digits must be 6 or 8 but it is configured as %d", configuration.Digits))
+	}
+
 	if configuration.Period == 0 {
 		configuration.Period = schema.DefaultTOTPConfiguration.Period
 	} else if configuration.Period < 0 {
@@ -23,4 +39,17 @@ func ValidateTOTP(configuration *schema.TOTPConfiguration, validator *schema.Str
 	} else if *configuration.Skew < 0 {
 		validator.Push(fmt.Errorf("TOTP Skew must be 0 or more"))
 	}
+
+	// The skew before and after the current one-time password default to the skew on both sides.
+	if configuration.SkewBefore == nil {
+		configuration.SkewBefore = configuration.Skew
+	} else if *configuration.SkewBefore < 0 {
+		validator.Push(fmt.Errorf("TOTP SkewBefore must be 0 or more"))
+	}
+
+	if configuration.SkewAfter == nil {
+		configuration.SkewAfter = configuration.Skew
+	} else if *configuration.SkewAfter < 0 {
+		validator.Push(fmt.Errorf("TOTP SkewAfter must be 0 or more"))
+	}
 }
@@ -17,7 +17,11 @@ func TestShouldSetDefaultTOTPValues(t *testing.T) {
 
 	require.Len(t, validator.Errors(), 0)
 	assert.Equal(t, "Authelia", config.Issuer)
+	assert.Equal(t, "SHA1", config.Algorithm)
+	assert.Equal(t, 6, config.Digits)
 	assert.Equal(t, *schema.DefaultTOTPConfiguration.Skew, *config.Skew)
+	assert.Equal(t, *schema.DefaultTOTPConfiguration.Skew, *config.SkewBefore)
+	assert.Equal(t, *schema.DefaultTOTPConfiguration.Skew, *config.SkewAfter)
 	assert.Equal(t, schema.DefaultTOTPConfiguration.Period, config.Period)
 }
 
@@ -34,3 +38,59 @@ func TestShouldRaiseErrorWhenInvalidTOTPMinimumValues(t *testing.T) {
 	assert.EqualError(t, validator.Errors()[0], "TOTP Period must be 1 or more")
 	assert.EqualError(t, validator.Errors()[1], "TOTP Skew must be 0 or more")
 }
+
+func TestShouldDefaultTOTPSkewBeforeAndAfterToSkew(t *testing.T) {
+	skew, skewAfter := 2, 0
+
+	validator := schema.NewStructValidator()
+	config := schema.TOTPConfiguration{
+		Skew:      &skew,
+		SkewAfter: &skewAfter,
+	}
+	ValidateTOTP(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, 2, *config.SkewBefore)
+	assert.Equal(t, 0, *config.SkewAfter)
+}
+
+func TestShouldRaiseErrorWhenInvalidTOTPSkewBeforeOrAfter(t *testing.T) {
+	badSkew := -1
+
+	validator := schema.NewStructValidator()
+	config := schema.TOTPConfiguration{
+		SkewBefore: &badSkew,
+		SkewAfter:  &badSkew,
+	}
+	ValidateTOTP(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "TOTP SkewBefore must be 0 or more")
+	assert.EqualError(t, validator.Errors()[1], "TOTP SkewAfter must be 0 or more")
+}
+
+func TestShouldRaiseErrorWhenInvalidTOTPAlgorithmOrDigits(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.TOTPConfiguration{
+		Algorithm: "MD5",
+		Digits:    7,
+	}
+	ValidateTOTP(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "TOTP Algorithm must be one of SHA1, SHA256 or SHA512 but it is configured as 'MD5'")
+	assert.EqualError(t, validator.Errors()[1], "TOTP Digits must be 6 or 8 but it is configured as 7")
+}
+
+func TestShouldAcceptTOTPAlgorithmAndDigitsOfHardwareTokens(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.TOTPConfiguration{
+		Algorithm: "SHA256",
+		Digits:    8,
+	}
+	ValidateTOTP(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "SHA256", config.Algorithm)
+	assert.Equal(t, 8, config.Digits)
+}
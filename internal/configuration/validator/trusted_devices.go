@@ -0,0 +1,25 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ValidateTrustedDevices validates and update the configuration of the trusted devices.
+func ValidateTrustedDevices(configuration *schema.TrustedDevicesConfiguration, validator *schema.StructValidator) {
+	if configuration.Duration == "" {
+		configuration.Duration = schema.DefaultTrustedDevicesConfiguration.Duration
+		return
+	}
+
+	duration, err := utils.ParseDurationString(configuration.Duration)
+
+	switch {
+	case err != nil:
+		validator.Push(fmt.Errorf("Error occurred parsing trusted devices duration string: %s", err))
+	case duration <= 0:
+		validator.Push(fmt.Errorf("Trusted devices duration must be greater than 0 but it is configured as %s", configuration.Duration))
+	}
+}
@@ -0,0 +1,40 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldSetDefaultTrustedDevicesValues(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.TrustedDevicesConfiguration{}
+
+	ValidateTrustedDevices(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "30d", config.Duration)
+}
+
+func TestShouldRaiseErrorWhenTrustedDevicesDurationIsInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.TrustedDevicesConfiguration{Duration: "abc"}
+
+	ValidateTrustedDevices(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Error occurred parsing trusted devices duration string: Could not convert the input string of abc into a duration")
+}
+
+func TestShouldRaiseErrorWhenTrustedDevicesDurationIsZero(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.TrustedDevicesConfiguration{Duration: "0"}
+
+	ValidateTrustedDevices(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Trusted devices duration must be greater than 0 but it is configured as 0")
+}
@@ -0,0 +1,140 @@
+package validator
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+var webAuthnAAGUIDRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ValidateWebAuthn validates and update WebAuthn configuration.
+func ValidateWebAuthn(configuration *schema.WebAuthnConfiguration, validator *schema.StructValidator) {
+	if configuration.PasskeyMode == "" {
+		configuration.PasskeyMode = schema.DefaultWebAuthnConfiguration.PasskeyMode
+	} else if !utils.IsStringInSlice(configuration.PasskeyMode, validWebAuthnPasskeyModes) {
+		validator.Push(fmt.Errorf("WebAuthn passkey_mode must be one of %s", strings.Join(validWebAuthnPasskeyModes, ", ")))
+	}
+
+	if configuration.DisplayName == "" {
+		configuration.DisplayName = schema.DefaultWebAuthnConfiguration.DisplayName
+	}
+
+	if configuration.Timeout == "" {
+		configuration.Timeout = schema.DefaultWebAuthnConfiguration.Timeout
+	}
+
+	// The timeouts of the ceremonies default to the timeout shared by them.
+	if configuration.RegistrationTimeout == "" {
+		configuration.RegistrationTimeout = configuration.Timeout
+	}
+
+	if configuration.AuthenticationTimeout == "" {
+		configuration.AuthenticationTimeout = configuration.Timeout
+	}
+
+	validateWebAuthnTimeout("timeout", configuration.Timeout, validator)
+	validateWebAuthnTimeout("registration_timeout", configuration.RegistrationTimeout, validator)
+	validateWebAuthnTimeout("authentication_timeout", configuration.AuthenticationTimeout, validator)
+
+	if strings.ContainsAny(configuration.RPID, ":/") {
+		validator.Push(fmt.Errorf("WebAuthn rp_id must be a domain without scheme, port or path"))
+	}
+
+	for _, origin := range configuration.Origins {
+		u, err := url.Parse(origin)
+
+		switch {
+		case err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.Path != "" || u.RawQuery != "":
+			validator.Push(fmt.Errorf("WebAuthn origins entry %s must be an origin like https://login.example.com", origin))
+		case configuration.RPID != "" && u.Hostname() != configuration.RPID && !strings.HasSuffix(u.Hostname(), "."+configuration.RPID):
+			validator.Push(fmt.Errorf("WebAuthn origins entry %s is not within the rp_id %s", origin, configuration.RPID))
+		}
+	}
+
+	if configuration.ResidentKey == "" {
+		configuration.ResidentKey = schema.DefaultWebAuthnConfiguration.ResidentKey
+	} else if !utils.IsStringInSlice(configuration.ResidentKey, validWebAuthnResidentKeys) {
+		validator.Push(fmt.Errorf("WebAuthn resident_key must be one of %s", strings.Join(validWebAuthnResidentKeys, ", ")))
+	}
+
+	if configuration.Attachment == "" {
+		configuration.Attachment = schema.DefaultWebAuthnConfiguration.Attachment
+	} else if !utils.IsStringInSlice(configuration.Attachment, validWebAuthnAttachments) {
+		validator.Push(fmt.Errorf("WebAuthn attachment must be one of %s", strings.Join(validWebAuthnAttachments, ", ")))
+	}
+
+	if configuration.MaxPasskeys == 0 {
+		configuration.MaxPasskeys = schema.DefaultWebAuthnConfiguration.MaxPasskeys
+	} else if configuration.MaxPasskeys < 0 {
+		validator.Push(fmt.Errorf("WebAuthn max_passkeys must be more than 0"))
+	}
+
+	if configuration.SyncedPasskeys == "" {
+		configuration.SyncedPasskeys = schema.DefaultWebAuthnConfiguration.SyncedPasskeys
+	} else if !utils.IsStringInSlice(configuration.SyncedPasskeys, validWebAuthnSyncedPasskeys) {
+		validator.Push(fmt.Errorf("WebAuthn synced_passkeys must be one of %s", strings.Join(validWebAuthnSyncedPasskeys, ", ")))
+	}
+
+	if configuration.AttestationCertificateAuthority != "" {
+		if content, err := ioutil.ReadFile(configuration.AttestationCertificateAuthority); err != nil {
+			validator.Push(fmt.Errorf("Unable to read the WebAuthn attestation_certificate_authority: %s", err))
+		} else if !x509.NewCertPool().AppendCertsFromPEM(content) {
+			validator.Push(fmt.Errorf("The WebAuthn attestation_certificate_authority %s does not contain any PEM certificate", configuration.AttestationCertificateAuthority))
+		}
+	}
+
+	validateWebAuthnAAGUIDs("allowed_aaguids", configuration.AllowedAAGUIDs, validator)
+	validateWebAuthnAAGUIDs("denied_aaguids", configuration.DeniedAAGUIDs, validator)
+	validateWebAuthnEnterpriseAttestation(configuration, validator)
+}
+
+func validateWebAuthnEnterpriseAttestation(configuration *schema.WebAuthnConfiguration, validator *schema.StructValidator) {
+	if len(configuration.EnterpriseAttestationRPIDs) == 0 && len(configuration.EnterpriseAttestationAAGUIDs) == 0 {
+		return
+	}
+
+	if len(configuration.EnterpriseAttestationRPIDs) == 0 || len(configuration.EnterpriseAttestationAAGUIDs) == 0 {
+		validator.Push(fmt.Errorf("WebAuthn enterprise_attestation_rp_ids and enterprise_attestation_aaguids must be configured together"))
+	}
+
+	// The enterprise attestations identify the authenticators so they must be issued by a trusted certificate authority.
+	if configuration.AttestationCertificateAuthority == "" {
+		validator.Push(fmt.Errorf("WebAuthn enterprise attestation requires an attestation_certificate_authority"))
+	}
+
+	for _, rpID := range configuration.EnterpriseAttestationRPIDs {
+		if rpID == "" || strings.ContainsAny(rpID, ":/") {
+			validator.Push(fmt.Errorf("WebAuthn enterprise_attestation_rp_ids entry %s must be a domain without scheme, port or path", rpID))
+		}
+	}
+
+	validateWebAuthnAAGUIDs("enterprise_attestation_aaguids", configuration.EnterpriseAttestationAAGUIDs, validator)
+}
+
+func validateWebAuthnAAGUIDs(name string, aaguids []string, validator *schema.StructValidator) {
+	for _, aaguid := range aaguids {
+		if _, ok := schema.WebAuthnAAGUIDPresets[aaguid]; ok {
+			continue
+		}
+
+		if !webAuthnAAGUIDRegexp.MatchString(aaguid) {
+			validator.Push(fmt.Errorf("WebAuthn %s entry %s is neither an AAGUID formatted as an UUID nor one of the presets", name, aaguid))
+		}
+	}
+}
+
+func validateWebAuthnTimeout(name, value string, validator *schema.StructValidator) {
+	timeout, err := utils.ParseDurationString(value)
+	if err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing webauthn %s string: %s", name, err))
+	} else if timeout <= 0 {
+		validator.Push(fmt.Errorf("WebAuthn %s must be more than 0", name))
+	}
+}
@@ -0,0 +1,210 @@
+package validator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldSetDefaultWebAuthnValues(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.WebAuthnConfiguration{}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.False(t, config.EnablePasskeys)
+	assert.Equal(t, "Authelia", config.DisplayName)
+	assert.Equal(t, "60s", config.Timeout)
+	assert.Equal(t, "required", config.ResidentKey)
+	assert.Equal(t, "any", config.Attachment)
+	assert.Equal(t, 10, config.MaxPasskeys)
+	assert.Equal(t, "allow", config.SyncedPasskeys)
+	assert.Equal(t, "passwordless", config.PasskeyMode)
+}
+
+func TestShouldRaiseErrorWhenInvalidWebAuthnMaxPasskeys(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.WebAuthnConfiguration{MaxPasskeys: -1}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "WebAuthn max_passkeys must be more than 0")
+}
+
+func TestShouldRaiseErrorWhenInvalidWebAuthnAttestation(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.WebAuthnConfiguration{
+		AttestationCertificateAuthority: "/path/does/not/exist",
+		AllowedAAGUIDs:                  []string{"cb69481e-8ff7-4039-93ec-0a2729a154a8", "yubikey-5-series", "yubikey"},
+		DeniedAAGUIDs:                   []string{"icloud-keychain", "fbfc3007"},
+	}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 3)
+	assert.EqualError(t, validator.Errors()[0], "Unable to read the WebAuthn attestation_certificate_authority: open /path/does/not/exist: no such file or directory")
+	assert.EqualError(t, validator.Errors()[1], "WebAuthn allowed_aaguids entry yubikey is neither an AAGUID formatted as an UUID nor one of the presets")
+	assert.EqualError(t, validator.Errors()[2], "WebAuthn denied_aaguids entry fbfc3007 is neither an AAGUID formatted as an UUID nor one of the presets")
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, ioutil.WriteFile(path, []byte("not a certificate"), 0600))
+
+	validator = schema.NewStructValidator()
+	config = schema.WebAuthnConfiguration{AttestationCertificateAuthority: path}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], fmt.Sprintf("The WebAuthn attestation_certificate_authority %s does not contain any PEM certificate", path))
+}
+
+func TestShouldRaiseErrorWhenInvalidWebAuthnResidentKey(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.WebAuthnConfiguration{ResidentKey: "always"}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "WebAuthn resident_key must be one of discouraged, preferred, required")
+
+	validator = schema.NewStructValidator()
+	config = schema.WebAuthnConfiguration{ResidentKey: "preferred"}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "preferred", config.ResidentKey)
+}
+
+func TestShouldRaiseErrorWhenInvalidWebAuthnAttachment(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.WebAuthnConfiguration{Attachment: "roaming"}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "WebAuthn attachment must be one of any, platform, cross-platform")
+
+	validator = schema.NewStructValidator()
+	config = schema.WebAuthnConfiguration{Attachment: "platform"}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "platform", config.Attachment)
+}
+
+func TestShouldRaiseErrorWhenInvalidWebAuthnSyncedPasskeys(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.WebAuthnConfiguration{SyncedPasskeys: "deny"}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "WebAuthn synced_passkeys must be one of allow, warn, reject")
+}
+
+func TestShouldRaiseErrorWhenInvalidWebAuthnPasskeyMode(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.WebAuthnConfiguration{PasskeyMode: "second_factor"}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "WebAuthn passkey_mode must be one of passwordless, first_factor")
+}
+
+func TestShouldRaiseErrorWhenInvalidWebAuthnRPIDOrOrigins(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.WebAuthnConfiguration{
+		RPID:    "example.com",
+		Origins: []string{"https://auth.example.com", "https://auth.example.com:8443", "https://example.org", "auth.example.com", "https://auth.example.com/login"},
+	}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 3)
+	assert.EqualError(t, validator.Errors()[0], "WebAuthn origins entry https://example.org is not within the rp_id example.com")
+	assert.EqualError(t, validator.Errors()[1], "WebAuthn origins entry auth.example.com must be an origin like https://login.example.com")
+	assert.EqualError(t, validator.Errors()[2], "WebAuthn origins entry https://auth.example.com/login must be an origin like https://login.example.com")
+
+	validator = schema.NewStructValidator()
+	config = schema.WebAuthnConfiguration{RPID: "https://example.com"}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "WebAuthn rp_id must be a domain without scheme, port or path")
+}
+
+func TestShouldRaiseErrorWhenInvalidWebAuthnTimeout(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.WebAuthnConfiguration{Timeout: "abc", RegistrationTimeout: "2m", AuthenticationTimeout: "30s"}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Error occurred parsing webauthn timeout string: Could not convert the input string of abc into a duration")
+
+	validator = schema.NewStructValidator()
+	config = schema.WebAuthnConfiguration{Timeout: "0", RegistrationTimeout: "2m", AuthenticationTimeout: "30s"}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "WebAuthn timeout must be more than 0")
+}
+
+func TestShouldSetDefaultWebAuthnCeremonyTimeouts(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.WebAuthnConfiguration{Timeout: "90s", RegistrationTimeout: "3m"}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "3m", config.RegistrationTimeout)
+	assert.Equal(t, "90s", config.AuthenticationTimeout)
+}
+
+func TestShouldRaiseErrorWhenInvalidWebAuthnCeremonyTimeouts(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.WebAuthnConfiguration{RegistrationTimeout: "abc", AuthenticationTimeout: "0"}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Error occurred parsing webauthn registration_timeout string: Could not convert the input string of abc into a duration")
+	assert.EqualError(t, validator.Errors()[1], "WebAuthn authentication_timeout must be more than 0")
+}
+
+func TestShouldRaiseErrorWhenInvalidWebAuthnEnterpriseAttestation(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.WebAuthnConfiguration{
+		EnterpriseAttestationRPIDs:   []string{"example.com", "https://example.org"},
+		EnterpriseAttestationAAGUIDs: []string{"yubikey-5-series", "abc"},
+	}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 3)
+	assert.EqualError(t, validator.Errors()[0], "WebAuthn enterprise attestation requires an attestation_certificate_authority")
+	assert.EqualError(t, validator.Errors()[1], "WebAuthn enterprise_attestation_rp_ids entry https://example.org must be a domain without scheme, port or path")
+	assert.EqualError(t, validator.Errors()[2], "WebAuthn enterprise_attestation_aaguids entry abc is neither an AAGUID formatted as an UUID nor one of the presets")
+
+	validator = schema.NewStructValidator()
+	config = schema.WebAuthnConfiguration{EnterpriseAttestationRPIDs: []string{"example.com"}}
+
+	ValidateWebAuthn(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "WebAuthn enterprise_attestation_rp_ids and enterprise_attestation_aaguids must be configured together")
+	assert.EqualError(t, validator.Errors()[1], "WebAuthn enterprise attestation requires an attestation_certificate_authority")
+}
@@ -0,0 +1,39 @@
+package validator
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ValidateYubiKeyOTP validates and update the configuration related to the validation of the Yubico OTPs.
+func ValidateYubiKeyOTP(configuration *schema.YubiKeyOTPConfiguration, validator *schema.StructValidator) {
+	if configuration.ClientID == "" {
+		validator.Push(fmt.Errorf("YubiKey OTP client_id must be provided"))
+	}
+
+	if configuration.SecretKey == "" {
+		validator.Push(fmt.Errorf("YubiKey OTP secret_key must be provided to verify the signature of the responses"))
+	} else if _, err := base64.StdEncoding.DecodeString(configuration.SecretKey); err != nil {
+		validator.Push(fmt.Errorf("YubiKey OTP secret_key must be base64 encoded: %s", err))
+	}
+
+	if len(configuration.APIURLs) == 0 {
+		configuration.APIURLs = schema.DefaultYubiKeyOTPConfiguration.APIURLs
+	}
+
+	for _, apiURL := range configuration.APIURLs {
+		if u, err := url.Parse(apiURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			validator.Push(fmt.Errorf("YubiKey OTP api_urls entry %s must be an absolute http or https URL", apiURL))
+		}
+	}
+
+	if configuration.Timeout == "" {
+		configuration.Timeout = schema.DefaultYubiKeyOTPConfiguration.Timeout
+	} else if _, err := utils.ParseDurationString(configuration.Timeout); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing YubiKey OTP timeout string: %s", err))
+	}
+}
@@ -0,0 +1,52 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldSetDefaultYubiKeyOTPValues(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.YubiKeyOTPConfiguration{
+		ClientID:  "12345",
+		SecretKey: "c2VjcmV0",
+	}
+
+	ValidateYubiKeyOTP(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, []string{"https://api.yubico.com/wsapi/2.0/verify"}, config.APIURLs)
+	assert.Equal(t, "5s", config.Timeout)
+}
+
+func TestShouldRaiseErrorsWhenYubiKeyOTPIsMissingClientIDAndSecretKey(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.YubiKeyOTPConfiguration{}
+
+	ValidateYubiKeyOTP(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "YubiKey OTP client_id must be provided")
+	assert.EqualError(t, validator.Errors()[1], "YubiKey OTP secret_key must be provided to verify the signature of the responses")
+}
+
+func TestShouldRaiseErrorsWhenYubiKeyOTPIsInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.YubiKeyOTPConfiguration{
+		ClientID:  "12345",
+		SecretKey: "not base64!",
+		APIURLs:   []string{"https://yubikey-val.example.com/wsapi/2.0/verify", "yubikey-val.example.com"},
+		Timeout:   "abc",
+	}
+
+	ValidateYubiKeyOTP(&config, validator)
+
+	require.Len(t, validator.Errors(), 3)
+	assert.EqualError(t, validator.Errors()[0], "YubiKey OTP secret_key must be base64 encoded: illegal base64 data at input byte 3")
+	assert.EqualError(t, validator.Errors()[1], "YubiKey OTP api_urls entry yubikey-val.example.com must be an absolute http or https URL")
+	assert.EqualError(t, validator.Errors()[2], "Error occurred parsing YubiKey OTP timeout string: Could not convert the input string of abc into a duration")
+}
@@ -0,0 +1,19 @@
+package duo
+
+import (
+	"time"
+)
+
+const (
+	healthCheckPath = "/oauth/v1/health_check"
+	authorizePath   = "/oauth/v1/authorize"
+	tokenPath       = "/oauth/v1/token"
+
+	clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	assertionLifespan = 5 * time.Minute
+	universalTimeout  = 10 * time.Second
+)
+
+// AuthResultAllow is the status of the result of the authentication when the user is allowed by Duo.
+const AuthResultAllow = "allow"
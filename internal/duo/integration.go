@@ -0,0 +1,90 @@
+package duo
+
+import (
+	duoapi "github.com/duosecurity/duo_api_golang"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// NewIntegrations creates the clients of the default integration followed by the ones of the additional integrations,
+// with the Universal Prompt clients when it is configured and the Auth API clients otherwise.
+func NewIntegrations(configuration schema.DuoAPIConfiguration, insecure bool) Integrations {
+	configurations := append([]schema.DuoIntegrationConfiguration{{
+		Name:           schema.DuoDefaultIntegrationName,
+		Hostname:       configuration.Hostname,
+		IntegrationKey: configuration.IntegrationKey,
+		SecretKey:      configuration.SecretKey,
+		ClientID:       configuration.ClientID,
+		ClientSecret:   configuration.ClientSecret,
+	}}, configuration.Integrations...)
+
+	integrations := make(Integrations, 0, len(configurations))
+
+	for _, c := range configurations {
+		integration := Integration{Name: c.Name, Groups: c.Groups}
+
+		if configuration.IsUniversalPrompt() {
+			integration.UniversalAPI = NewUniversalAPI(schema.DuoAPIConfiguration{
+				Hostname:     c.Hostname,
+				ClientID:     c.ClientID,
+				ClientSecret: c.ClientSecret,
+			}, insecure)
+		} else {
+			if insecure {
+				integration.API = NewDuoAPI(duoapi.NewDuoApi(c.IntegrationKey, c.SecretKey, c.Hostname, "", duoapi.SetInsecure()))
+			} else {
+				integration.API = NewDuoAPI(duoapi.NewDuoApi(c.IntegrationKey, c.SecretKey, c.Hostname, ""))
+			}
+		}
+
+		integrations = append(integrations, integration)
+	}
+
+	return integrations
+}
+
+// Select returns the integrations the user is routed to, in the order they are tried. The users of the groups of some
+// integrations are routed to these integrations only, as they are likely unknown to the others. The other users are
+// routed to the default integration followed by the integrations without groups.
+func (i Integrations) Select(groups []string) Integrations {
+	selected := Integrations{}
+
+	for _, integration := range i {
+		for _, group := range groups {
+			if utils.IsStringInSlice(group, integration.Groups) {
+				selected = append(selected, integration)
+				break
+			}
+		}
+	}
+
+	if len(selected) != 0 {
+		return selected
+	}
+
+	for _, integration := range i {
+		if len(integration.Groups) == 0 {
+			selected = append(selected, integration)
+		}
+	}
+
+	return selected
+}
+
+// Get returns the integration with the name.
+func (i Integrations) Get(name string) (Integration, bool) {
+	for _, integration := range i {
+		if integration.Name == name {
+			return integration, true
+		}
+	}
+
+	return Integration{}, false
+}
+
+// IsServerError returns true when Duo failed to process the request because of an error on its side, in which case the
+// request is sent to the next integration.
+func (r Response) IsServerError() bool {
+	return r.Stat == "FAIL" && r.Code >= 50000
+}
@@ -0,0 +1,78 @@
+package duo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+var testIntegrations = Integrations{
+	{Name: "default"},
+	{Name: "emea", Groups: []string{"emea"}},
+	{Name: "backup"},
+	{Name: "emea-backup", Groups: []string{"emea", "apac"}},
+}
+
+func names(integrations Integrations) []string {
+	result := make([]string, 0, len(integrations))
+	for _, integration := range integrations {
+		result = append(result, integration.Name)
+	}
+
+	return result
+}
+
+func TestShouldRouteUsersOfGroupsToTheirIntegrations(t *testing.T) {
+	assert.Equal(t, []string{"emea", "emea-backup"}, names(testIntegrations.Select([]string{"dev", "emea"})))
+	assert.Equal(t, []string{"emea-backup"}, names(testIntegrations.Select([]string{"apac"})))
+}
+
+func TestShouldRouteOtherUsersToTheDefaultIntegrationAndFailover(t *testing.T) {
+	assert.Equal(t, []string{"default", "backup"}, names(testIntegrations.Select([]string{"dev"})))
+	assert.Equal(t, []string{"default", "backup"}, names(testIntegrations.Select(nil)))
+}
+
+func TestShouldGetIntegrationByName(t *testing.T) {
+	integration, ok := testIntegrations.Get("backup")
+	require.True(t, ok)
+	assert.Equal(t, "backup", integration.Name)
+
+	_, ok = testIntegrations.Get("unknown")
+	assert.False(t, ok)
+}
+
+func TestShouldCreateIntegrationsOfTheConfiguredMode(t *testing.T) {
+	integrations := NewIntegrations(schema.DuoAPIConfiguration{
+		Hostname:       "api-123456789.example.com",
+		IntegrationKey: "ABCDEF",
+		SecretKey:      "secret",
+		Integrations: []schema.DuoIntegrationConfiguration{
+			{Name: "emea", Hostname: "api-987654321.example.com", IntegrationKey: "GHIJKL", SecretKey: "secret", Groups: []string{"emea"}},
+		},
+	}, false)
+
+	require.Len(t, integrations, 2)
+	assert.Equal(t, "default", integrations[0].Name)
+	assert.NotNil(t, integrations[0].API)
+	assert.Nil(t, integrations[0].UniversalAPI)
+	assert.Equal(t, []string{"emea"}, integrations[1].Groups)
+
+	integrations = NewIntegrations(schema.DuoAPIConfiguration{
+		Hostname:     "api-123456789.example.com",
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+	}, false)
+
+	require.Len(t, integrations, 1)
+	assert.Nil(t, integrations[0].API)
+	assert.NotNil(t, integrations[0].UniversalAPI)
+}
+
+func TestShouldDetectServerErrors(t *testing.T) {
+	assert.True(t, Response{Stat: "FAIL", Code: 50001}.IsServerError())
+	assert.False(t, Response{Stat: "FAIL", Code: 40002}.IsServerError())
+	assert.False(t, Response{Stat: "OK"}.IsServerError())
+}
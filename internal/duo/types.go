@@ -1,8 +1,12 @@
 package duo
 
 import (
+	"fmt"
+	"net/http"
 	"net/url"
+	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	duoapi "github.com/duosecurity/duo_api_golang"
 
 	"github.com/authelia/authelia/internal/middlewares"
@@ -18,6 +22,19 @@ type APIImpl struct {
 	*duoapi.DuoApi
 }
 
+// Integration is one of the Duo integrations the users are routed to, either API or UniversalAPI is set depending on
+// whether the users are redirected to the Universal Prompt.
+type Integration struct {
+	Name   string
+	Groups []string
+
+	API          API
+	UniversalAPI UniversalAPI
+}
+
+// Integrations the Duo integrations by order of preference, the default one first.
+type Integrations []Integration
+
 // Response response coming from Duo API.
 type Response struct {
 	Response struct {
@@ -30,3 +47,54 @@ type Response struct {
 	MessageDetail string `json:"message_detail"`
 	Stat          string `json:"stat"`
 }
+
+// UniversalAPI interface wrapping the OIDC endpoints of the Duo Universal Prompt for testing purpose.
+type UniversalAPI interface {
+	HealthCheck() error
+	AuthURL(username, state, nonce, redirectURI string) (string, error)
+	ExchangeCode(code, redirectURI, username, nonce string) (*IDTokenClaims, error)
+}
+
+// UniversalAPIImpl implementation of the UniversalAPI interface.
+type UniversalAPIImpl struct {
+	clientID     string
+	clientSecret []byte
+	baseURL      string
+	client       *http.Client
+}
+
+// IDTokenClaims the claims of the ID token returned once the user went through the Universal Prompt.
+type IDTokenClaims struct {
+	jwt.StandardClaims
+
+	Nonce             string `json:"nonce"`
+	PreferredUsername string `json:"preferred_username"`
+	AuthResult        struct {
+		Result        string `json:"result"`
+		Status        string `json:"status"`
+		StatusMessage string `json:"status_msg"`
+	} `json:"auth_result"`
+}
+
+// Valid only checks the expiration of the token, the time it was issued at is ignored to tolerate a clock skew with
+// Duo.
+func (c IDTokenClaims) Valid() error {
+	if !c.VerifyExpiresAt(time.Now().Unix(), true) {
+		return fmt.Errorf("token is expired")
+	}
+
+	return nil
+}
+
+type healthCheckResponse struct {
+	Stat          string `json:"stat"`
+	Code          int    `json:"code"`
+	Message       string `json:"message"`
+	MessageDetail string `json:"message_detail"`
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
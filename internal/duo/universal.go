@@ -0,0 +1,177 @@
+package duo
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// NewUniversalAPI create a client of the OIDC endpoints of the Duo Universal Prompt. The TLS certificate of Duo is not
+// verified when insecure is true, which is only meant to be used against a mock in development.
+func NewUniversalAPI(configuration schema.DuoAPIConfiguration, insecure bool) *UniversalAPIImpl {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // Only used in development.
+	}
+
+	return &UniversalAPIImpl{
+		clientID:     configuration.ClientID,
+		clientSecret: []byte(configuration.ClientSecret),
+		baseURL:      "https://" + configuration.Hostname,
+		client:       &http.Client{Timeout: universalTimeout, Transport: transport},
+	}
+}
+
+// HealthCheck checks that Duo is available and accepts the credentials before redirecting the user to it.
+func (u *UniversalAPIImpl) HealthCheck() error {
+	endpoint := u.baseURL + healthCheckPath
+
+	assertion, err := u.clientAssertion(endpoint)
+	if err != nil {
+		return err
+	}
+
+	values := url.Values{}
+	values.Set("client_id", u.clientID)
+	values.Set("client_assertion", assertion)
+
+	var response healthCheckResponse
+	if err = u.post(endpoint, values, &response); err != nil {
+		return err
+	}
+
+	if response.Stat != "OK" {
+		return fmt.Errorf("health check failed: %s (%s), error code %d", response.Message, response.MessageDetail, response.Code)
+	}
+
+	return nil
+}
+
+// AuthURL returns the URL of the Universal Prompt the user is redirected to. Duo redirects the user back to the
+// redirect URI with the state and a code to exchange once the user is authenticated.
+func (u *UniversalAPIImpl) AuthURL(username, state, nonce, redirectURI string) (string, error) {
+	now := time.Now()
+
+	request, err := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{
+		"response_type":          "code",
+		"scope":                  "openid",
+		"exp":                    now.Add(assertionLifespan).Unix(),
+		"client_id":              u.clientID,
+		"redirect_uri":           redirectURI,
+		"state":                  state,
+		"duo_uname":              username,
+		"iss":                    u.clientID,
+		"aud":                    u.baseURL,
+		"nonce":                  nonce,
+		"use_duo_code_attribute": true,
+	}).SignedString(u.clientSecret)
+	if err != nil {
+		return "", err
+	}
+
+	values := url.Values{}
+	values.Set("response_type", "code")
+	values.Set("client_id", u.clientID)
+	values.Set("request", request)
+
+	return fmt.Sprintf("%s%s?%s", u.baseURL, authorizePath, values.Encode()), nil
+}
+
+// ExchangeCode exchanges the code Duo redirected the user with for the ID token holding the result of the
+// authentication. The token must be signed with the client secret, be issued for the user and carry the nonce of the
+// authorization request.
+func (u *UniversalAPIImpl) ExchangeCode(code, redirectURI, username, nonce string) (*IDTokenClaims, error) {
+	endpoint := u.baseURL + tokenPath
+
+	assertion, err := u.clientAssertion(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	values.Set("grant_type", "authorization_code")
+	values.Set("code", code)
+	values.Set("redirect_uri", redirectURI)
+	values.Set("client_assertion_type", clientAssertionType)
+	values.Set("client_assertion", assertion)
+
+	var response tokenResponse
+	if err = u.post(endpoint, values, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Error != "" {
+		return nil, fmt.Errorf("token exchange failed: %s (%s)", response.Error, response.ErrorDescription)
+	}
+
+	claims := &IDTokenClaims{}
+
+	_, err = jwt.ParseWithClaims(response.IDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS512 {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Header["alg"])
+		}
+
+		return u.clientSecret, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %s", err)
+	}
+
+	switch {
+	case !claims.VerifyIssuer(endpoint, true):
+		return nil, fmt.Errorf("invalid ID token: unexpected issuer %s", claims.Issuer)
+	case !claims.VerifyAudience(u.clientID, true):
+		return nil, fmt.Errorf("invalid ID token: unexpected audience %s", claims.Audience)
+	case claims.Nonce != nonce:
+		return nil, fmt.Errorf("invalid ID token: the nonce does not match the one of the request")
+	case !strings.EqualFold(claims.PreferredUsername, username):
+		return nil, fmt.Errorf("invalid ID token: issued for user %s", claims.PreferredUsername)
+	}
+
+	return claims, nil
+}
+
+// clientAssertion returns the token authenticating the client to the endpoint.
+func (u *UniversalAPIImpl) clientAssertion(endpoint string) (string, error) {
+	jti := make([]byte, 18)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.StandardClaims{
+		Issuer:    u.clientID,
+		Subject:   u.clientID,
+		Audience:  endpoint,
+		ExpiresAt: now.Add(assertionLifespan).Unix(),
+		IssuedAt:  now.Unix(),
+		Id:        hex.EncodeToString(jti),
+	}).SignedString(u.clientSecret)
+}
+
+func (u *UniversalAPIImpl) post(endpoint string, values url.Values, response interface{}) error {
+	resp, err := u.client.PostForm(endpoint, values)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if err = json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("unable to decode the response with status code %d: %s", resp.StatusCode, err)
+	}
+
+	return nil
+}
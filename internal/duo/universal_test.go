@@ -0,0 +1,200 @@
+package duo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+const (
+	testClientID     = "DIWJ8X6AEYOR5OMC6TQ1"
+	testClientSecret = "abcdefghijklmnopqrstuvwxyz0123456789abcd"
+	testRedirectURI  = "https://login.example.com/api/secondfactor/duo/callback"
+)
+
+func newTestUniversalAPI(t *testing.T, handler http.HandlerFunc) *UniversalAPIImpl {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	api := NewUniversalAPI(schema.DuoAPIConfiguration{
+		Hostname:     "api-123456789.example.com",
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+	}, false)
+	api.baseURL = server.URL
+
+	return api
+}
+
+// assertClientAssertion checks the request is authenticated with a token signed with the client secret for the
+// endpoint.
+func assertClientAssertion(t *testing.T, api *UniversalAPIImpl, r *http.Request) {
+	claims := jwt.StandardClaims{}
+	_, err := jwt.ParseWithClaims(r.PostForm.Get("client_assertion"), &claims, func(token *jwt.Token) (interface{}, error) {
+		return api.clientSecret, nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, testClientID, claims.Issuer)
+	assert.Equal(t, testClientID, claims.Subject)
+	assert.Equal(t, api.baseURL+r.URL.Path, claims.Audience)
+	assert.NotEmpty(t, claims.Id)
+}
+
+// idToken returns an ID token as issued by the token endpoint.
+func idToken(t *testing.T, api *UniversalAPIImpl, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString(api.clientSecret)
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestShouldPassHealthCheck(t *testing.T) {
+	var api *UniversalAPIImpl
+
+	api = newTestUniversalAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "/oauth/v1/health_check", r.URL.Path)
+		assert.Equal(t, testClientID, r.PostForm.Get("client_id"))
+		assertClientAssertion(t, api, r)
+
+		_, _ = w.Write([]byte(`{"stat":"OK","response":{"timestamp":1634302800}}`))
+	})
+
+	assert.NoError(t, api.HealthCheck())
+}
+
+func TestShouldFailHealthCheck(t *testing.T) {
+	api := newTestUniversalAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"stat":"FAIL","code":40002,"message":"Invalid request parameters","message_detail":"client_assertion"}`))
+	})
+
+	assert.EqualError(t, api.HealthCheck(), "health check failed: Invalid request parameters (client_assertion), error code 40002")
+}
+
+func TestShouldBuildAuthURL(t *testing.T) {
+	api := newTestUniversalAPI(t, nil)
+
+	authURL, err := api.AuthURL("john", "state", "nonce", testRedirectURI)
+	require.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/oauth/v1/authorize", u.Path)
+	assert.Equal(t, "code", u.Query().Get("response_type"))
+	assert.Equal(t, testClientID, u.Query().Get("client_id"))
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(u.Query().Get("request"), claims, func(token *jwt.Token) (interface{}, error) {
+		return api.clientSecret, nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "john", claims["duo_uname"])
+	assert.Equal(t, "state", claims["state"])
+	assert.Equal(t, "nonce", claims["nonce"])
+	assert.Equal(t, testRedirectURI, claims["redirect_uri"])
+	assert.Equal(t, api.baseURL, claims["aud"])
+	assert.Equal(t, "openid", claims["scope"])
+}
+
+func TestShouldExchangeCode(t *testing.T) {
+	var api *UniversalAPIImpl
+
+	api = newTestUniversalAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "/oauth/v1/token", r.URL.Path)
+		assert.Equal(t, "authorization_code", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "code", r.PostForm.Get("code"))
+		assert.Equal(t, testRedirectURI, r.PostForm.Get("redirect_uri"))
+		assert.Equal(t, clientAssertionType, r.PostForm.Get("client_assertion_type"))
+		assertClientAssertion(t, api, r)
+
+		_ = json.NewEncoder(w).Encode(tokenResponse{IDToken: idToken(t, api, jwt.MapClaims{
+			"iss":                api.baseURL + "/oauth/v1/token",
+			"aud":                testClientID,
+			"exp":                time.Now().Add(time.Minute).Unix(),
+			"iat":                time.Now().Add(time.Minute).Unix(),
+			"nonce":              "nonce",
+			"preferred_username": "john",
+			"auth_result":        map[string]string{"result": "allow", "status": "allow", "status_msg": "Login Successful"},
+		})})
+	})
+
+	claims, err := api.ExchangeCode("code", testRedirectURI, "john", "nonce")
+	require.NoError(t, err)
+
+	assert.Equal(t, AuthResultAllow, claims.AuthResult.Status)
+}
+
+func TestShouldFailToExchangeCodeWithIDTokenOfAnotherRequest(t *testing.T) {
+	var api *UniversalAPIImpl
+
+	api = newTestUniversalAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(tokenResponse{IDToken: idToken(t, api, jwt.MapClaims{
+			"iss":                api.baseURL + "/oauth/v1/token",
+			"aud":                testClientID,
+			"exp":                time.Now().Add(time.Minute).Unix(),
+			"nonce":              "other",
+			"preferred_username": "john",
+		})})
+	})
+
+	_, err := api.ExchangeCode("code", testRedirectURI, "john", "nonce")
+	assert.EqualError(t, err, "invalid ID token: the nonce does not match the one of the request")
+}
+
+func TestShouldFailToExchangeCodeWithIDTokenOfAnotherUser(t *testing.T) {
+	var api *UniversalAPIImpl
+
+	api = newTestUniversalAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(tokenResponse{IDToken: idToken(t, api, jwt.MapClaims{
+			"iss":                api.baseURL + "/oauth/v1/token",
+			"aud":                testClientID,
+			"exp":                time.Now().Add(time.Minute).Unix(),
+			"nonce":              "nonce",
+			"preferred_username": "harry",
+		})})
+	})
+
+	_, err := api.ExchangeCode("code", testRedirectURI, "john", "nonce")
+	assert.EqualError(t, err, "invalid ID token: issued for user harry")
+}
+
+func TestShouldFailToExchangeCodeWithExpiredIDToken(t *testing.T) {
+	var api *UniversalAPIImpl
+
+	api = newTestUniversalAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(tokenResponse{IDToken: idToken(t, api, jwt.MapClaims{
+			"iss":                api.baseURL + "/oauth/v1/token",
+			"aud":                testClientID,
+			"exp":                time.Now().Add(-time.Minute).Unix(),
+			"nonce":              "nonce",
+			"preferred_username": "john",
+		})})
+	})
+
+	_, err := api.ExchangeCode("code", testRedirectURI, "john", "nonce")
+	assert.EqualError(t, err, "invalid ID token: token is expired")
+}
+
+func TestShouldFailToExchangeInvalidCode(t *testing.T) {
+	api := newTestUniversalAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"The provided authorization grant is invalid"}`))
+	})
+
+	_, err := api.ExchangeCode("code", testRedirectURI, "john", "nonce")
+	assert.EqualError(t, err, "token exchange failed: invalid_grant (The provided authorization grant is invalid)")
+}
@@ -0,0 +1,95 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Database is a GeoIP database locating the IP addresses in their country.
+type Database struct {
+	ranges []countryRange
+}
+
+// countryRange is a range of IP addresses located in a country. The addresses are in their 16 bytes form so the IPv4
+// and IPv6 ranges can be compared.
+type countryRange struct {
+	first, last net.IP
+	country     string
+}
+
+// NewDatabase loads a GeoIP database from a CSV file.
+func NewDatabase(path string) (*Database, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return ParseDatabase(file)
+}
+
+// ParseDatabase parses a GeoIP database in the CSV format of the IP to Country Lite database of DB-IP, where each line
+// is the first and the last IP addresses of a range followed by the ISO 3166-1 alpha-2 code of its country.
+func ParseDatabase(reader io.Reader) (*Database, error) {
+	r := csv.NewReader(reader)
+	r.FieldsPerRecord = 3
+	r.ReuseRecord = true
+
+	database := &Database{}
+
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the GeoIP database: %w", err)
+		}
+
+		first, last := net.ParseIP(strings.TrimSpace(record[0])), net.ParseIP(strings.TrimSpace(record[1]))
+		if first == nil || last == nil || bytes.Compare(first.To16(), last.To16()) > 0 {
+			return nil, fmt.Errorf("invalid range of IP addresses %s-%s at line %d of the GeoIP database", record[0], record[1], line)
+		}
+
+		database.ranges = append(database.ranges, countryRange{
+			first:   first.To16(),
+			last:    last.To16(),
+			country: strings.ToUpper(strings.TrimSpace(record[2])),
+		})
+	}
+
+	sort.Slice(database.ranges, func(i, j int) bool {
+		return bytes.Compare(database.ranges[i].first, database.ranges[j].first) < 0
+	})
+
+	return database, nil
+}
+
+// Country returns the ISO 3166-1 alpha-2 code of the country of the IP address, empty if the database is nil or doesn't
+// locate the address.
+func (d *Database) Country(ip net.IP) string {
+	if d == nil || ip == nil {
+		return ""
+	}
+
+	ip = ip.To16()
+
+	// The range containing the address is the last one starting before or at the address, if it doesn't end before it.
+	i := sort.Search(len(d.ranges), func(i int) bool {
+		return bytes.Compare(d.ranges[i].first, ip) > 0
+	})
+
+	if i == 0 || bytes.Compare(d.ranges[i-1].last, ip) < 0 {
+		return ""
+	}
+
+	return d.ranges[i-1].country
+}
@@ -0,0 +1,56 @@
+package geoip
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDatabase = `1.0.0.0,1.0.0.255,AU
+1.0.4.0,1.0.7.255,au
+2.16.0.0,2.16.255.255,FR
+2001:db8::,2001:db8::ffff,DE
+`
+
+func TestShouldLocateIPAddressesInTheirCountry(t *testing.T) {
+	database, err := ParseDatabase(strings.NewReader(testDatabase))
+	require.NoError(t, err)
+
+	assert.Equal(t, "AU", database.Country(net.ParseIP("1.0.0.0")))
+	assert.Equal(t, "AU", database.Country(net.ParseIP("1.0.0.255")))
+	assert.Equal(t, "AU", database.Country(net.ParseIP("1.0.5.10")))
+	assert.Equal(t, "FR", database.Country(net.ParseIP("2.16.10.1")))
+	assert.Equal(t, "DE", database.Country(net.ParseIP("2001:db8::1")))
+}
+
+func TestShouldNotLocateIPAddressesOutsideOfTheRanges(t *testing.T) {
+	database, err := ParseDatabase(strings.NewReader(testDatabase))
+	require.NoError(t, err)
+
+	assert.Equal(t, "", database.Country(net.ParseIP("0.255.255.255")))
+	assert.Equal(t, "", database.Country(net.ParseIP("1.0.1.0")))
+	assert.Equal(t, "", database.Country(net.ParseIP("192.168.1.1")))
+	assert.Equal(t, "", database.Country(net.ParseIP("2001:db8::1:0")))
+	assert.Equal(t, "", database.Country(nil))
+}
+
+func TestShouldNotLocateIPAddressesWithoutDatabase(t *testing.T) {
+	var database *Database
+
+	assert.Equal(t, "", database.Country(net.ParseIP("1.0.0.1")))
+}
+
+func TestShouldRaiseErrorWhenGeoIPDatabaseIsInvalid(t *testing.T) {
+	_, err := ParseDatabase(strings.NewReader("1.0.0.0,1.0.0.255,AU\n1.0.4.0,1.0.0.0,AU\n"))
+	assert.EqualError(t, err, "invalid range of IP addresses 1.0.4.0-1.0.0.0 at line 2 of the GeoIP database")
+
+	_, err = ParseDatabase(strings.NewReader("1.0.0.0,AU\n"))
+	assert.EqualError(t, err, "unable to read the GeoIP database: record on line 1: wrong number of fields")
+
+	_, err = NewDatabase(filepath.Join(t.TempDir(), "geoip.csv"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// isAPIKeyAuthorization returns true if the header value is a bearer API key.
+func isAPIKeyAuthorization(auth []byte) bool {
+	return strings.HasPrefix(string(auth), bearerPrefix+models.APIKeyPrefix)
+}
+
+// verifyAPIKey verifies the API key against the hashes of the keys persisted in the storage. The requests
+// authenticated with a key are identified by the name of the key prefixed with apikey: so they can't be mistaken for a
+// user with the same name, with the groups of the key.
+func verifyAPIKey(header string, auth []byte, ctx *middlewares.AutheliaCtx) (username string, groups []string, authLevel authentication.Level, err error) {
+	key, err := ctx.Providers.StorageProvider.LoadAPIKey(utils.HashSHA256FromString(string(auth[len(bearerPrefix):])))
+	if err != nil {
+		if err == storage.ErrNoAPIKey {
+			return "", nil, authentication.NotAuthenticated, fmt.Errorf("The API key of the %s header is not valid", header)
+		}
+
+		return "", nil, authentication.NotAuthenticated, fmt.Errorf("Unable to load the API key of the %s header: %s", header, err)
+	}
+
+	return apiKeySubjectPrefix + key.Name, key.Groups, authentication.OneFactor, nil
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+func TestShouldDetectAPIKeyAuthorization(t *testing.T) {
+	assert.True(t, isAPIKeyAuthorization([]byte("Bearer "+models.APIKeyPrefix+"abc")))
+	assert.False(t, isAPIKeyAuthorization([]byte("Bearer abc")))
+	assert.False(t, isAPIKeyAuthorization([]byte("Basic "+models.APIKeyPrefix+"abc")))
+}
+
+func TestShouldVerifyAPIKey(t *testing.T) {
+	key := models.APIKeyPrefix + "abc"
+
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().
+		LoadAPIKey(gomock.Eq(utils.HashSHA256FromString(key))).
+		Return(&models.APIKey{Name: "john", Groups: []string{"ci"}}, nil)
+
+	username, groups, level, err := verifyAPIKey("Authorization", []byte("Bearer "+key), mock.Ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "apikey:john", username)
+	assert.Equal(t, []string{"ci"}, groups)
+	assert.Equal(t, authentication.OneFactor, level)
+}
+
+func TestShouldNotVerifyUnknownAPIKey(t *testing.T) {
+	key := models.APIKeyPrefix + "abc"
+
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().
+		LoadAPIKey(gomock.Eq(utils.HashSHA256FromString(key))).
+		Return(nil, storage.ErrNoAPIKey)
+
+	username, groups, level, err := verifyAPIKey("Authorization", []byte("Bearer "+key), mock.Ctx)
+
+	assert.EqualError(t, err, "The API key of the Authorization header is not valid")
+	assert.Equal(t, "", username)
+	assert.Nil(t, groups)
+	assert.Equal(t, authentication.NotAuthenticated, level)
+}
+
+func TestShouldNotVerifyAPIKeyWhenStorageFails(t *testing.T) {
+	key := models.APIKeyPrefix + "abc"
+
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().
+		LoadAPIKey(gomock.Eq(utils.HashSHA256FromString(key))).
+		Return(nil, errors.New("failed"))
+
+	_, _, level, err := verifyAPIKey("Proxy-Authorization", []byte("Bearer "+key), mock.Ctx)
+
+	assert.EqualError(t, err, "Unable to load the API key of the Proxy-Authorization header: failed")
+	assert.Equal(t, authentication.NotAuthenticated, level)
+}
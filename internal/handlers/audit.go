@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+)
+
+// appendAuditEvent records an audit event caused by the client of the request. A failure is logged but doesn't fail
+// the request since the action the event relates to has already been carried out.
+func appendAuditEvent(ctx *middlewares.AutheliaCtx, username, eventType, details string) {
+	err := ctx.Providers.StorageProvider.AppendAuditEvent(models.AuditEvent{
+		Username: username,
+		Type:     eventType,
+		RemoteIP: ctx.RemoteIP().String(),
+		Details:  details,
+		Time:     ctx.Clock.Now(),
+	})
+
+	if err != nil {
+		ctx.Logger.Errorf("Unable to record the %s audit event of user %s: %v", eventType, username, err)
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/openid"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/authorization"
+	"github.com/authelia/authelia/internal/middlewares"
+)
+
+// isBearerAuthorization returns true if the bearer authorization of the requests with OAuth2 access tokens is enabled
+// and the header value is a bearer token.
+func isBearerAuthorization(ctx *middlewares.AutheliaCtx, auth []byte) bool {
+	return ctx.Configuration.IdentityProviders.OIDC != nil &&
+		ctx.Configuration.IdentityProviders.OIDC.EnableBearerAuthorization &&
+		strings.HasPrefix(string(auth), bearerPrefix)
+}
+
+// verifyBearerToken introspects the OAuth2 access token issued by Authelia and retrieves the details of its subject.
+// The token must be granted for an audience covering the target URL, so a token issued for a resource can't be replayed
+// against another one. The tokens of the client credentials grant have no subject, the client is then identified by
+// its ID in the namespace of the OAuth2 clients, so it can't be mistaken for a user with the same name, and its granted
+// scopes are used as groups. The authentication level is the authorization policy of the client.
+func verifyBearerToken(header string, auth []byte, targetURL *url.URL, ctx *middlewares.AutheliaCtx) (username, name string, groups, emails []string, authLevel authentication.Level, err error) {
+	oidcSession, err := newDefaultOIDCSession(ctx)
+	if err != nil {
+		return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("Unable to create the session of the token: %s", err)
+	}
+
+	_, requester, err := ctx.Providers.OpenIDConnect.Fosite.IntrospectToken(ctx, string(auth[len(bearerPrefix):]), fosite.AccessToken, oidcSession)
+	if err != nil {
+		return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("Unable to introspect the token of the %s header: %s", header, err)
+	}
+
+	clientID := requester.GetClient().GetID()
+
+	resource := url.URL{Scheme: targetURL.Scheme, Host: targetURL.Host, Path: targetURL.Path}
+
+	if err = fosite.DefaultAudienceMatchingStrategy(requester.GetGrantedAudience(), []string{resource.String()}); err != nil {
+		return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("The token of client %s is not granted for an audience covering %s", clientID, resource.String())
+	}
+
+	authLevel = authentication.OneFactor
+	if ctx.Providers.OpenIDConnect.Store.GetClientPolicy(clientID) == authorization.TwoFactor {
+		authLevel = authentication.TwoFactor
+	}
+
+	if tokenSession, ok := requester.GetSession().(*openid.DefaultSession); ok && tokenSession.Claims != nil && tokenSession.Claims.Subject != "" {
+		username = tokenSession.Claims.Subject
+	}
+
+	if username == "" {
+		return oauth2ClientSubjectPrefix + clientID, "", requester.GetGrantedScopes(), nil, authLevel, nil
+	}
+
+	details, err := ctx.Providers.UserProvider.GetDetails(username)
+	if err != nil {
+		return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("Unable to retrieve details of user %s: %s", username, err)
+	}
+
+	return username, details.DisplayName, details.Groups, details.Emails, authLevel, nil
+}
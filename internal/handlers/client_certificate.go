@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
+)
+
+// clientCertificateAuthentication authenticates the requests with the client certificate verified by a trusted reverse
+// proxy and forwarded in a header, the username being taken from the subject or the alternative names.
+type clientCertificateAuthentication struct {
+	header            string
+	roots             *x509.CertPool
+	networks          []*net.IPNet
+	usernameAttribute string
+	usernamePattern   *regexp.Regexp
+}
+
+// newClientCertificateAuthentication returns the client certificate authentication of the configuration, or nil if
+// it's not configured.
+func newClientCertificateAuthentication(configuration *schema.ClientCertificateAuthenticationConfiguration) *clientCertificateAuthentication {
+	if configuration == nil {
+		return nil
+	}
+
+	clientCertificate := &clientCertificateAuthentication{
+		header:            configuration.Header,
+		roots:             x509.NewCertPool(),
+		networks:          parseTrustedNetworks(configuration.TrustedNetworks),
+		usernameAttribute: configuration.UsernameAttribute,
+	}
+
+	// The certificate authority and the pattern are checked by the configuration validator.
+	if content, err := ioutil.ReadFile(configuration.CertificateAuthority); err == nil {
+		clientCertificate.roots.AppendCertsFromPEM(content)
+	}
+
+	if configuration.UsernamePattern != "" {
+		clientCertificate.usernamePattern, _ = regexp.Compile(configuration.UsernamePattern)
+	}
+
+	return clientCertificate
+}
+
+// parseCertificate parses the value of the header, either an URL encoded PEM certificate or a base64 encoded DER
+// certificate. Only the first certificate of the chain is considered. Only the PEM certificates are URL decoded, as the
+// plus signs of the base64 encoding would otherwise be decoded as spaces.
+func (a *clientCertificateAuthentication) parseCertificate(value []byte) (*x509.Certificate, error) {
+	decoded := string(value)
+
+	if strings.HasPrefix(decoded, clientCertificateEscapedPEMPrefix) {
+		var err error
+
+		if decoded, err = url.PathUnescape(decoded); err != nil {
+			return nil, fmt.Errorf("Unable to decode the client certificate: %s", err)
+		}
+	}
+
+	if block, _ := pem.Decode([]byte(decoded)); block != nil {
+		return x509.ParseCertificate(block.Bytes)
+	}
+
+	der, err := base64.StdEncoding.DecodeString(strings.SplitN(decoded, ",", 2)[0])
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decode the client certificate: %s", err)
+	}
+
+	return x509.ParseCertificate(der)
+}
+
+// username returns the username of the certificate according to the configured attribute and pattern.
+func (a *clientCertificateAuthentication) username(certificate *x509.Certificate) (string, error) {
+	var candidates []string
+
+	switch a.usernameAttribute {
+	case "email":
+		candidates = certificate.EmailAddresses
+	case "dns":
+		candidates = certificate.DNSNames
+	case "uri":
+		for _, uri := range certificate.URIs {
+			candidates = append(candidates, uri.String())
+		}
+	default:
+		candidates = []string{certificate.Subject.CommonName}
+	}
+
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+
+		if a.usernamePattern == nil {
+			return candidate, nil
+		}
+
+		if match := a.usernamePattern.FindStringSubmatch(candidate); match != nil {
+			if len(match) > 1 {
+				return match[1], nil
+			}
+
+			return match[0], nil
+		}
+	}
+
+	return "", fmt.Errorf("No %s of the client certificate %s matches a username", a.usernameAttribute, certificate.Subject)
+}
+
+// authenticate verifies the certificate of the request against the certificate authority and returns its username.
+func (a *clientCertificateAuthentication) authenticate(ctx *middlewares.AutheliaCtx) (string, error) {
+	// The X-Forwarded-For header can be set by anyone so the network is checked against the address of the TCP peer.
+	if !isIPTrusted(ctx.RequestCtx.RemoteIP(), a.networks) {
+		return "", fmt.Errorf("The %s header was not set by a trusted reverse proxy", a.header)
+	}
+
+	value := ctx.Request.Header.Peek(a.header)
+	if len(value) == 0 {
+		return "", errors.New("No client certificate was provided")
+	}
+
+	certificate, err := a.parseCertificate(value)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = certificate.Verify(x509.VerifyOptions{
+		Roots:       a.roots,
+		CurrentTime: ctx.Clock.Now(),
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return "", fmt.Errorf("The client certificate %s is not valid: %s", certificate.Subject, err)
+	}
+
+	return a.username(certificate)
+}
+
+// verifyClientCertificate verifies the client certificate of the request and retrieves the details of the user. The
+// user is authenticated with one factor, unless the session of the same user is authenticated with two factors.
+func verifyClientCertificate(ctx *middlewares.AutheliaCtx, clientCertificate *clientCertificateAuthentication, userSession *session.UserSession) (username, name string, groups, emails []string, authLevel authentication.Level, err error) {
+	username, err = clientCertificate.authenticate(ctx)
+	if err != nil {
+		return "", "", nil, nil, authentication.NotAuthenticated, err
+	}
+
+	name, groups, emails, authLevel, err = getUpstreamUserDetails(ctx, username, userSession)
+
+	return username, name, groups, emails, authLevel, err
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+)
+
+func generateTestCertificate(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return certificate, key
+}
+
+// generateTestClientCertificate returns the path of a certificate authority and a client certificate it signed.
+func generateTestClientCertificate(t *testing.T, commonName string) (string, *x509.Certificate) {
+	ca, caKey := generateTestCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Authelia Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, nil)
+
+	client, _ := generateTestCertificate(t, &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		Subject:        pkix.Name{CommonName: commonName},
+		EmailAddresses: []string{commonName + "@example.com"},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0600))
+
+	return path, client
+}
+
+func TestShouldParseClientCertificateHeader(t *testing.T) {
+	_, certificate := generateTestClientCertificate(t, testUsername)
+	clientCertificate := &clientCertificateAuthentication{}
+
+	values := []string{
+		// The PEM certificate URL encoded like the $ssl_client_escaped_cert variable of NGINX.
+		strings.ReplaceAll(url.QueryEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}))), "+", "%20"),
+		base64.StdEncoding.EncodeToString(certificate.Raw),
+		base64.StdEncoding.EncodeToString(certificate.Raw) + ",abc",
+	}
+
+	for _, value := range values {
+		parsed, err := clientCertificate.parseCertificate([]byte(value))
+		require.NoError(t, err)
+		assert.Equal(t, certificate.Raw, parsed.Raw)
+	}
+
+	_, err := clientCertificate.parseCertificate([]byte("not a certificate"))
+	assert.Error(t, err)
+}
+
+func TestShouldGetUsernameOfClientCertificate(t *testing.T) {
+	_, certificate := generateTestClientCertificate(t, testUsername)
+
+	clientCertificate := newClientCertificateAuthentication(&schema.ClientCertificateAuthenticationConfiguration{
+		UsernameAttribute: "common_name",
+	})
+
+	username, err := clientCertificate.username(certificate)
+	require.NoError(t, err)
+	assert.Equal(t, testUsername, username)
+
+	clientCertificate = newClientCertificateAuthentication(&schema.ClientCertificateAuthenticationConfiguration{
+		UsernameAttribute: "email",
+		UsernamePattern:   "^(.+)@example\\.com$",
+	})
+
+	username, err = clientCertificate.username(certificate)
+	require.NoError(t, err)
+	assert.Equal(t, testUsername, username)
+
+	clientCertificate = newClientCertificateAuthentication(&schema.ClientCertificateAuthenticationConfiguration{
+		UsernameAttribute: "dns",
+	})
+
+	_, err = clientCertificate.username(certificate)
+	assert.EqualError(t, err, "No dns of the client certificate CN=john matches a username")
+}
+
+func TestShouldVerifyAuthorizationsUsingClientCertificate(t *testing.T) {
+	caPath, certificate := generateTestClientCertificate(t, testUsername)
+	_, untrustedCertificate := generateTestClientCertificate(t, testUsername)
+
+	cfg := verifyGetCfg
+	cfg.ClientCertificate = &schema.ClientCertificateAuthenticationConfiguration{
+		Header:               "X-Forwarded-Tls-Client-Cert",
+		CertificateAuthority: caPath,
+		TrustedNetworks:      []string{"10.0.0.0/8"},
+		UsernameAttribute:    "common_name",
+	}
+
+	testCases := []struct {
+		name          string
+		remoteIP      string
+		certificate   *x509.Certificate
+		expStatusCode int
+	}{
+		{"TrustedCertificate", "10.1.1.1", certificate, 200},
+		{"UntrustedCertificate", "10.1.1.1", untrustedCertificate, 401},
+		{"UntrustedNetwork", "192.168.1.1", certificate, 401},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mock := mocks.NewMockAutheliaCtx(t)
+			defer mock.Close()
+
+			mock.Clock.Set(time.Now())
+
+			if tc.expStatusCode == 200 {
+				mock.UserProviderMock.EXPECT().
+					GetDetails(gomock.Eq(testUsername)).
+					Return(&authentication.UserDetails{
+						Emails: []string{"john@example.com"},
+						Groups: []string{"dev"},
+					}, nil)
+			}
+
+			// The X-Forwarded-For header claims a trusted network for every request, only the TCP peer is trusted.
+			mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP(tc.remoteIP)})
+			mock.Ctx.Request.Header.Set("X-Forwarded-For", "10.1.1.1")
+			mock.Ctx.Request.Header.Set("X-Original-URL", "https://one-factor.example.com")
+			mock.Ctx.Request.Header.Set("X-Forwarded-Tls-Client-Cert", base64.StdEncoding.EncodeToString(tc.certificate.Raw))
+
+			VerifyGet(cfg, 0, 0)(mock.Ctx)
+
+			assert.Equal(t, tc.expStatusCode, mock.Ctx.Response.StatusCode())
+
+			if tc.expStatusCode == 200 {
+				assert.Equal(t, []byte(testUsername), mock.Ctx.Response.Header.Peek("Remote-User"))
+			}
+		})
+	}
+}
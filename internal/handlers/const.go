@@ -3,6 +3,12 @@ package handlers
 // TOTPRegistrationAction is the string representation of the action for which the token has been produced.
 const TOTPRegistrationAction = "RegisterTOTPDevice"
 
+// The proofs of identity given by the users registering a TOTP secret.
+const (
+	totpRegistrationProofIdentityVerification = "identity_verification"
+	totpRegistrationProofTOTP                 = "totp"
+)
+
 // U2FRegistrationAction is the string representation of the action for which the token has been produced.
 const U2FRegistrationAction = "RegisterU2FDevice"
 
@@ -11,6 +17,17 @@ const ResetPasswordAction = "ResetPassword"
 
 const authPrefix = "Basic "
 
+const bearerPrefix = "Bearer "
+
+// oauth2ClientSubjectPrefix prefixes the ID of the OAuth2 client authenticated by an access token without subject.
+const oauth2ClientSubjectPrefix = "oauth2:client:"
+
+// apiKeySubjectPrefix prefixes the name of the API key authenticating a request.
+const apiKeySubjectPrefix = "apikey:"
+
+// clientCertificateEscapedPEMPrefix is the beginning of an URL encoded PEM client certificate forwarded in a header.
+const clientCertificateEscapedPEMPrefix = "-----BEGIN%20"
+
 // ProxyAuthorizationHeader is the basic-auth HTTP header Authelia utilises.
 const ProxyAuthorizationHeader = "Proxy-Authorization"
 
@@ -24,6 +41,61 @@ const remoteUserHeader = "Remote-User"
 const remoteNameHeader = "Remote-Name"
 const remoteEmailHeader = "Remote-Email"
 const remoteGroupsHeader = "Remote-Groups"
+const remoteSignatureHeader = "Remote-Signature"
+
+const wwwAuthenticateHeader = "WWW-Authenticate"
+const proxyAuthenticateHeader = "Proxy-Authenticate"
+
+const xRequestedWithHeader = "X-Requested-With"
+const xmlHTTPRequest = "XMLHttpRequest"
+
+const webSocketUpgrade = "websocket"
+
+const grpcContentType = "application/grpc"
+
+// The authentication strategies of the verify endpoint.
+const (
+	verifyStrategyTrustedHeader     = "trusted_header"
+	verifyStrategyClientCertificate = "client_certificate"
+	verifyStrategyAPIKey            = "api_key"
+	verifyStrategyBearerToken       = "bearer_token"
+	verifyStrategyBasicAuth         = "basic_auth"
+	verifyStrategySessionCookie     = "session_cookie"
+)
+
+// defaultVerifyStrategies is the order of the authentication strategies of the verify endpoint when none is configured.
+var defaultVerifyStrategies = []string{
+	verifyStrategyTrustedHeader,
+	verifyStrategyClientCertificate,
+	verifyStrategyAPIKey,
+	verifyStrategyBearerToken,
+	verifyStrategyBasicAuth,
+	verifyStrategySessionCookie,
+}
+
+// The authentication methods reported by the verify endpoint.
+const (
+	authnMethodPassword      = "password"
+	authnMethodCertificate   = "certificate"
+	authnMethodTOTP          = "totp"
+	authnMethodU2F           = "u2f"
+	authnMethodDuo           = "duo"
+	authnMethodAPIKey        = "api_key"
+	authnMethodToken         = "token"
+	authnMethodTrustedHeader = "trusted_header"
+	authnMethodPasskey       = "passkey"
+	authnMethodRecoveryCode  = "recovery_code"
+	authnMethodEmail         = "email"
+	authnMethodPushApproval  = "push_approval"
+	authnMethodPrivacyIDEA   = "privacyidea"
+	authnMethodHOTP          = "hotp"
+	authnMethodYubiKeyOTP    = "yubikey_otp"
+	authnMethodTrustedDevice = "trusted_device"
+)
+
+// secondFactorMethodWebAuthn is the second factor method of the access control rules satisfied by any WebAuthn
+// credential, whether it's a security key or a passkey.
+const secondFactorMethodWebAuthn = "webauthn"
 
 const (
 	// Forbidden means the user is forbidden the access to a resource.
@@ -39,9 +111,56 @@ const authenticationFailedMessage = "Authentication failed. Check your credentia
 const userBannedMessage = "Please retry in a few minutes."
 const unableToRegisterOneTimePasswordMessage = "Unable to set up one-time passwords." //nolint:gosec
 const unableToRegisterSecurityKeyMessage = "Unable to register your security key."
+const unableToRegisterPasskeyMessage = "Unable to register your passkey."
+const passkeyNotAllowedMessage = "Your authenticator is not allowed to register passkeys."
+const passkeyAlreadyRegisteredMessage = "This authenticator is already registered."
+const syncedPasskeyNotAllowedMessage = "Passkeys synced between devices are not allowed."
+const maxPasskeysReachedMessage = "You have reached the maximum number of passkeys, delete one of them to register another one."
 const unableToResetPasswordMessage = "Unable to reset your password."
 const mfaValidationFailedMessage = "Authentication failed, please retry later."
 
+// webAuthnCredentialDescriptionMaxLength is the maximum length of the descriptions of the passkeys, the size of the
+// description column of the storage.
+const webAuthnCredentialDescriptionMaxLength = 64
+
+// unnamedPasskeyDescription is the description of the passkeys registered without one in the notifications.
+const unnamedPasskeyDescription = "Unnamed passkey"
+
+// Policies applied to the passkeys synced between the devices of the users.
+const (
+	syncedPasskeysWarn   = "warn"
+	syncedPasskeysReject = "reject"
+)
+
+// The recovery codes generated at once for a user, and the number of characters of each code. The characters are
+// chosen among recoveryCodeCharacters which excludes the ones easily mistaken for others, giving close to 50 bits of
+// entropy per code, and the codes are displayed split in two halves.
+const (
+	recoveryCodesCount     = 10
+	recoveryCodeLength     = 10
+	recoveryCodeCharacters = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// The cookie holding the token of a trusted device, the random bytes of the tokens and of the IDs of the trusted devices,
+// and the maximum length of their descriptions which is the size of the description column of the storage.
+const (
+	trustedDeviceCookieName           = "authelia_trusted_device"
+	trustedDeviceTokenLength          = 32
+	trustedDeviceIDLength             = 16
+	trustedDeviceDescriptionMaxLength = 255
+)
+
+// The path of the callback Duo redirects the users to from the Universal Prompt, and the random bytes of the state and
+// nonce of the redirection which Duo requires to be between 16 and 1024 characters long once hex encoded.
+const (
+	duoCallbackPath = "/api/secondfactor/duo/callback"
+	duoStateLength  = 20
+)
+
+// passkeyModeFirstFactor is the passkey mode in which the passkeys only replace the username and the users enter their
+// password afterwards.
+const passkeyModeFirstFactor = "first_factor"
+
 const ldapPasswordComplexityCode = "0000052D."
 
 var ldapPasswordComplexityCodes = []string{"0000052D"}
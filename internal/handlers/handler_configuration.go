@@ -7,9 +7,11 @@ import (
 
 // ConfigurationBody the content returned by the configuration endpoint.
 type ConfigurationBody struct {
-	AvailableMethods    MethodList `json:"available_methods"`
-	SecondFactorEnabled bool       `json:"second_factor_enabled"` // whether second factor is enabled or not.
-	TOTPPeriod          int        `json:"totp_period"`
+	AvailableMethods      MethodList `json:"available_methods"`
+	SecondFactorEnabled   bool       `json:"second_factor_enabled"` // whether second factor is enabled or not.
+	TOTPPeriod            int        `json:"totp_period"`
+	TrustedDevicesEnabled bool       `json:"trusted_devices_enabled"` // whether users can trust their devices or not.
+	DuoUniversalPrompt    bool       `json:"duo_universal_prompt"`    // whether users are redirected to Duo or not.
 }
 
 // ConfigurationGet get the configuration accessible to authenticated users.
@@ -20,9 +22,33 @@ func ConfigurationGet(ctx *middlewares.AutheliaCtx) {
 
 	if ctx.Configuration.DuoAPI != nil {
 		body.AvailableMethods = append(body.AvailableMethods, authentication.Push)
+		body.DuoUniversalPrompt = ctx.Configuration.DuoAPI.IsUniversalPrompt()
 	}
 
+	if ctx.Configuration.EmailOneTimeCode != nil {
+		body.AvailableMethods = append(body.AvailableMethods, authentication.Email)
+	}
+
+	if ctx.Configuration.PushApproval != nil {
+		body.AvailableMethods = append(body.AvailableMethods, authentication.PushApproval)
+	}
+
+	if ctx.Configuration.PrivacyIDEA != nil {
+		body.AvailableMethods = append(body.AvailableMethods, authentication.PrivacyIDEA)
+	}
+
+	if ctx.Configuration.HOTP != nil {
+		body.AvailableMethods = append(body.AvailableMethods, authentication.HOTP)
+	}
+
+	if ctx.Configuration.YubiKeyOTP != nil {
+		body.AvailableMethods = append(body.AvailableMethods, authentication.YubiKeyOTP)
+	}
+
+	body.AvailableMethods = filterAllowedMethods(ctx, body.AvailableMethods)
+
 	body.SecondFactorEnabled = ctx.Providers.Authorizer.IsSecondFactorEnabled()
+	body.TrustedDevicesEnabled = ctx.Configuration.TrustedDevices != nil
 	ctx.Logger.Tracef("Second factor enabled: %v", body.SecondFactorEnabled)
 
 	ctx.Logger.Tracef("Available methods are %s", body.AvailableMethods)
@@ -32,3 +58,18 @@ func ConfigurationGet(ctx *middlewares.AutheliaCtx) {
 		ctx.Logger.Errorf("Unable to set configuration response in body: %s", err)
 	}
 }
+
+// filterAllowedMethods removes the methods the second factor policy of the user of the session does not allow.
+func filterAllowedMethods(ctx *middlewares.AutheliaCtx, methods MethodList) MethodList {
+	userSession := ctx.GetSession()
+
+	allowed := MethodList{}
+
+	for _, method := range methods {
+		if isSecondFactorMethodAllowed(ctx.Configuration.SecondFactorPolicies, userSession.Groups, preferredMethodAuthnMethod(method)) {
+			allowed = append(allowed, method)
+		}
+	}
+
+	return allowed
+}
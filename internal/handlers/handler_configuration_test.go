@@ -60,6 +60,59 @@ func (s *SecondFactorAvailableMethodsFixture) TestShouldServeDefaultMethodsAndMo
 	s.mock.Assert200OK(s.T(), expectedBody)
 }
 
+func (s *SecondFactorAvailableMethodsFixture) TestShouldServeDuoUniversalPrompt() {
+	s.mock.Ctx.Configuration = schema.Configuration{
+		DuoAPI: &schema.DuoAPIConfiguration{ClientID: "DIWJ8X6AEYOR5OMC6TQ1"},
+		TOTP: &schema.TOTPConfiguration{
+			Period: schema.DefaultTOTPConfiguration.Period,
+		},
+	}
+	expectedBody := ConfigurationBody{
+		AvailableMethods:    []string{"totp", "u2f", "mobile_push"},
+		SecondFactorEnabled: false,
+		TOTPPeriod:          schema.DefaultTOTPConfiguration.Period,
+		DuoUniversalPrompt:  true,
+	}
+
+	ConfigurationGet(s.mock.Ctx)
+	s.mock.Assert200OK(s.T(), expectedBody)
+}
+
+func (s *SecondFactorAvailableMethodsFixture) TestShouldServeDefaultMethodsAndEmail() {
+	s.mock.Ctx.Configuration = schema.Configuration{
+		EmailOneTimeCode: &schema.DefaultEmailOneTimeCodeConfiguration,
+		TOTP: &schema.TOTPConfiguration{
+			Period: schema.DefaultTOTPConfiguration.Period,
+		},
+	}
+	expectedBody := ConfigurationBody{
+		AvailableMethods:    []string{"totp", "u2f", "email"},
+		SecondFactorEnabled: false,
+		TOTPPeriod:          schema.DefaultTOTPConfiguration.Period,
+	}
+
+	ConfigurationGet(s.mock.Ctx)
+	s.mock.Assert200OK(s.T(), expectedBody)
+}
+
+func (s *SecondFactorAvailableMethodsFixture) TestShouldServeTrustedDevicesEnabled() {
+	s.mock.Ctx.Configuration = schema.Configuration{
+		TrustedDevices: &schema.DefaultTrustedDevicesConfiguration,
+		TOTP: &schema.TOTPConfiguration{
+			Period: schema.DefaultTOTPConfiguration.Period,
+		},
+	}
+	expectedBody := ConfigurationBody{
+		AvailableMethods:      []string{"totp", "u2f"},
+		SecondFactorEnabled:   false,
+		TOTPPeriod:            schema.DefaultTOTPConfiguration.Period,
+		TrustedDevicesEnabled: true,
+	}
+
+	ConfigurationGet(s.mock.Ctx)
+	s.mock.Assert200OK(s.T(), expectedBody)
+}
+
 func (s *SecondFactorAvailableMethodsFixture) TestShouldCheckSecondFactorIsDisabledWhenNoRuleIsSetToTwoFactor() {
 	s.mock.Ctx.Configuration = schema.Configuration{
 		TOTP: &schema.TOTPConfiguration{
@@ -9,6 +9,7 @@ import (
 
 	"github.com/authelia/authelia/internal/authentication"
 	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
 	"github.com/authelia/authelia/internal/regulation"
 	"github.com/authelia/authelia/internal/session"
 )
@@ -82,7 +83,13 @@ func FirstFactorPost(msInitialDelay time.Duration, delayEnabled bool) middleware
 
 		if err != nil {
 			if err == regulation.ErrUserIsBanned {
+				if bannedUntil.IsZero() {
+					handleAuthenticationUnauthorized(ctx, fmt.Errorf("User %s is banned permanently", bodyJSON.Username), userBannedMessage)
+					return
+				}
+
 				handleAuthenticationUnauthorized(ctx, fmt.Errorf("User %s is banned until %s", bodyJSON.Username, bannedUntil), userBannedMessage)
+
 				return
 			}
 
@@ -96,7 +103,7 @@ func FirstFactorPost(msInitialDelay time.Duration, delayEnabled bool) middleware
 		if err != nil {
 			ctx.Logger.Debugf("Mark authentication attempt made by user %s", bodyJSON.Username)
 
-			if err := ctx.Providers.Regulator.Mark(bodyJSON.Username, false); err != nil {
+			if err := ctx.Providers.Regulator.Mark(bodyJSON.Username, false, ctx.RemoteIP(), models.AuthenticationTypeFirstFactor); err != nil {
 				ctx.Logger.Errorf("Unable to mark authentication: %s", err.Error())
 			}
 
@@ -108,7 +115,7 @@ func FirstFactorPost(msInitialDelay time.Duration, delayEnabled bool) middleware
 		if !userPasswordOk {
 			ctx.Logger.Debugf("Mark authentication attempt made by user %s", bodyJSON.Username)
 
-			if err := ctx.Providers.Regulator.Mark(bodyJSON.Username, false); err != nil {
+			if err := ctx.Providers.Regulator.Mark(bodyJSON.Username, false, ctx.RemoteIP(), models.AuthenticationTypeFirstFactor); err != nil {
 				ctx.Logger.Errorf("Unable to mark authentication: %s", err.Error())
 			}
 
@@ -118,7 +125,7 @@ func FirstFactorPost(msInitialDelay time.Duration, delayEnabled bool) middleware
 		}
 
 		ctx.Logger.Debugf("Mark authentication attempt made by user %s", bodyJSON.Username)
-		err = ctx.Providers.Regulator.Mark(bodyJSON.Username, true)
+		err = ctx.Providers.Regulator.Mark(bodyJSON.Username, true, ctx.RemoteIP(), models.AuthenticationTypeFirstFactor)
 
 		if err != nil {
 			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to mark authentication: %s", err.Error()), authenticationFailedMessage)
@@ -173,7 +180,10 @@ func FirstFactorPost(msInitialDelay time.Duration, delayEnabled bool) middleware
 		userSession.DisplayName = userDetails.DisplayName
 		userSession.Groups = userDetails.Groups
 		userSession.Emails = userDetails.Emails
+		userSession.Attributes = userDetails.Attributes
 		userSession.AuthenticationLevel = authentication.OneFactor
+		userSession.FirstFactorAuthnTimestamp = ctx.Clock.Now().Unix()
+		userSession.AuthenticationMethods = []string{authnMethodPassword}
 		userSession.LastActivity = time.Now().Unix()
 		userSession.KeepMeLoggedIn = keepMeLoggedIn
 		refresh, refreshInterval := getProfileRefreshSettings(ctx.Configuration.AuthenticationBackend)
@@ -182,6 +192,9 @@ func FirstFactorPost(msInitialDelay time.Duration, delayEnabled bool) middleware
 			userSession.RefreshTTL = ctx.Clock.Now().Add(refreshInterval)
 		}
 
+		skipSecondFactorOnTrustedDevice(ctx, &userSession)
+		requireSecondFactorEnrollment(ctx, &userSession)
+
 		err = ctx.SaveSession(userSession)
 
 		if err != nil {
@@ -191,9 +204,12 @@ func FirstFactorPost(msInitialDelay time.Duration, delayEnabled bool) middleware
 
 		successful = true
 
-		if userSession.OIDCWorkflowSession != nil {
+		switch {
+		case userSession.OIDCWorkflowSession != nil:
 			HandleOIDCWorkflowResponse(ctx)
-		} else {
+		case userSession.AuthenticationLevel == authentication.TwoFactor:
+			Handle2FAResponse(ctx, bodyJSON.TargetURL)
+		default:
 			Handle1FAResponse(ctx, bodyJSON.TargetURL, bodyJSON.RequestMethod, userSession.Username, userSession.Groups)
 		}
 	}
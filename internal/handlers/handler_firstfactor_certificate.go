@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/session"
+)
+
+// FirstFactorCertificatePost is the handler performing the first factor with the client certificate of the request.
+func FirstFactorCertificatePost(configuration *schema.ClientCertificateAuthenticationConfiguration) middlewares.RequestHandler {
+	clientCertificate := newClientCertificateAuthentication(configuration)
+
+	return func(ctx *middlewares.AutheliaCtx) {
+		bodyJSON := firstFactorCertificateRequestBody{}
+
+		if len(ctx.PostBody()) != 0 {
+			if err := ctx.ParseBody(&bodyJSON); err != nil {
+				handleAuthenticationUnauthorized(ctx, err, authenticationFailedMessage)
+				return
+			}
+		}
+
+		username, err := clientCertificate.authenticate(ctx)
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to authenticate with the client certificate: %s", err), authenticationFailedMessage)
+			return
+		}
+
+		if _, err = ctx.Providers.Regulator.Regulate(username); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regulate authentication of user %s: %s", username, err), userBannedMessage)
+			return
+		}
+
+		ctx.Logger.Debugf("Mark authentication attempt made by user %s", username)
+
+		if err = ctx.Providers.Regulator.Mark(username, true, ctx.RemoteIP(), models.AuthenticationTypeFirstFactor); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to mark authentication: %s", err), authenticationFailedMessage)
+			return
+		}
+
+		userDetails, err := ctx.Providers.UserProvider.GetDetails(username)
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Error while retrieving details from user %s: %s", username, err), authenticationFailedMessage)
+			return
+		}
+
+		userSession := ctx.GetSession()
+		newSession := session.NewDefaultUserSession()
+		newSession.OIDCWorkflowSession = userSession.OIDCWorkflowSession
+
+		// Reset all values from previous session except OIDC workflow before regenerating the cookie.
+		if err = ctx.SaveSession(newSession); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to reset the session for user %s: %s", username, err), authenticationFailedMessage)
+			return
+		}
+
+		if err = ctx.Providers.SessionProvider.RegenerateSession(ctx.RequestCtx); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regenerate session for user %s: %s", username, err), authenticationFailedMessage)
+			return
+		}
+
+		userSession.Username = userDetails.Username
+		userSession.DisplayName = userDetails.DisplayName
+		userSession.Groups = userDetails.Groups
+		userSession.Emails = userDetails.Emails
+		userSession.Attributes = userDetails.Attributes
+		userSession.AuthenticationLevel = authentication.OneFactor
+		userSession.FirstFactorAuthnTimestamp = ctx.Clock.Now().Unix()
+		userSession.AuthenticationMethods = []string{authnMethodCertificate}
+		userSession.LastActivity = time.Now().Unix()
+
+		if refresh, refreshInterval := getProfileRefreshSettings(ctx.Configuration.AuthenticationBackend); refresh {
+			userSession.RefreshTTL = ctx.Clock.Now().Add(refreshInterval)
+		}
+
+		skipSecondFactorOnTrustedDevice(ctx, &userSession)
+		requireSecondFactorEnrollment(ctx, &userSession)
+
+		if err = ctx.SaveSession(userSession); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to save session of user %s", username), authenticationFailedMessage)
+			return
+		}
+
+		switch {
+		case userSession.OIDCWorkflowSession != nil:
+			HandleOIDCWorkflowResponse(ctx)
+		case userSession.AuthenticationLevel == authentication.TwoFactor:
+			Handle2FAResponse(ctx, bodyJSON.TargetURL)
+		default:
+			Handle1FAResponse(ctx, bodyJSON.TargetURL, bodyJSON.RequestMethod, userSession.Username, userSession.Groups)
+		}
+	}
+}
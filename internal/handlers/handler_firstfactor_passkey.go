@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/storage"
+	"github.com/authelia/authelia/internal/webauthn"
+)
+
+// FirstFactorPasskeyOptionsPost is the handler starting the assertion of a passkey, which lets the authenticator offer
+// any of the passkeys it holds for the relying party without the user entering their username.
+func FirstFactorPasskeyOptionsPost(ctx *middlewares.AutheliaCtx) {
+	firstFactorPasskeyOptions(ctx, false)
+}
+
+// FirstFactorPasskeyConditionalOptionsPost is the handler starting the conditional assertion of a passkey, which lets
+// the browser offer the passkeys in the autofill of the username field.
+func FirstFactorPasskeyConditionalOptionsPost(ctx *middlewares.AutheliaCtx) {
+	firstFactorPasskeyOptions(ctx, true)
+}
+
+func firstFactorPasskeyOptions(ctx *middlewares.AutheliaCtx, conditional bool) {
+	rp, err := newWebAuthnRelyingParty(ctx, nil)
+	if err != nil {
+		ctx.Error(err, authenticationFailedMessage)
+		return
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to generate new WebAuthn challenge for assertion: %s", err), authenticationFailedMessage)
+		return
+	}
+
+	userSession := ctx.GetSession()
+
+	if conditional {
+		userSession.WebAuthnConditionalChallenge = challenge
+	} else {
+		userSession.WebAuthnChallenge = challenge
+	}
+
+	if err = ctx.SaveSession(userSession); err != nil {
+		ctx.Error(fmt.Errorf("Unable to save WebAuthn challenge in session: %s", err), authenticationFailedMessage)
+		return
+	}
+
+	if err = ctx.SetJSONBody(rp.NewAssertionOptions(challenge)); err != nil {
+		ctx.Logger.Errorf("Unable to set the WebAuthn assertion options in body: %s", err)
+	}
+}
+
+// FirstFactorPasskeyPost is the handler signing the user in with the assertion of a passkey. The authenticators verify
+// the user before asserting a passkey so the session is established at the two-factor authentication level, unless the
+// passkeys are only a first factor in which case the user completes the sign in with their password.
+func FirstFactorPasskeyPost(ctx *middlewares.AutheliaCtx) {
+	bodyJSON := firstFactorPasskeyRequestBody{}
+
+	if err := ctx.ParseBody(&bodyJSON); err != nil {
+		handleAuthenticationUnauthorized(ctx, err, authenticationFailedMessage)
+		return
+	}
+
+	userSession := ctx.GetSession()
+
+	challenge := userSession.WebAuthnChallenge
+	if bodyJSON.Conditional {
+		challenge = userSession.WebAuthnConditionalChallenge
+	}
+
+	if challenge == nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("WebAuthn assertion has not been initiated yet (no challenge)"), authenticationFailedMessage)
+		return
+	}
+
+	rp, err := newWebAuthnRelyingParty(ctx, nil)
+	if err != nil {
+		handleAuthenticationUnauthorized(ctx, err, authenticationFailedMessage)
+		return
+	}
+
+	credential, err := ctx.Providers.StorageProvider.LoadWebAuthnCredential(bodyJSON.Credential.ID)
+	if err != nil {
+		if err == storage.ErrNoWebAuthnCredential {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("The passkey is not registered"), authenticationFailedMessage)
+			return
+		}
+
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to load the passkey: %s", err), authenticationFailedMessage)
+
+		return
+	}
+
+	username := credential.Username
+
+	if len(bodyJSON.Credential.Response.UserHandle) == 0 || !bytes.Equal(bodyJSON.Credential.Response.UserHandle, credential.UserHandle) {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("The user handle of the passkey of user %s does not match", username), authenticationFailedMessage)
+		return
+	}
+
+	if _, err = ctx.Providers.Regulator.Regulate(username); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regulate authentication of user %s: %s", username, err), userBannedMessage)
+		return
+	}
+
+	asserted, err := rp.VerifyAssertion(challenge, bodyJSON.Credential, webauthn.Credential{
+		ID:        credential.ID,
+		PublicKey: credential.PublicKey,
+		SignCount: credential.SignCount,
+	})
+	if err == nil {
+		err = verifySyncedPasskeyPolicy(ctx, ctx.Configuration.WebAuthn, username, asserted)
+	}
+
+	ctx.Logger.Debugf("Mark authentication attempt made by user %s", username)
+
+	if markErr := ctx.Providers.Regulator.Mark(username, err == nil, ctx.RemoteIP(), models.AuthenticationTypePasskey); markErr != nil {
+		ctx.Logger.Errorf("Unable to mark authentication: %s", markErr)
+	}
+
+	if err != nil {
+		// The credential is flagged so the user can see it in the list of their passkeys.
+		if errors.Is(err, webauthn.ErrCloneDetected) {
+			if cloneErr := ctx.Providers.StorageProvider.SetWebAuthnCredentialCloneWarning(credential.ID); cloneErr != nil {
+				ctx.Logger.Errorf("Unable to flag the passkey of user %s as cloned: %s", username, cloneErr)
+			}
+		}
+
+		message := authenticationFailedMessage
+		if err == errSyncedPasskeyRejected {
+			message = syncedPasskeyNotAllowedMessage
+		}
+
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to verify the passkey of user %s: %s", username, err), message)
+
+		return
+	}
+
+	now := ctx.Clock.Now()
+
+	credential.SignCount = asserted.SignCount
+	credential.LastUsed = &now
+	credential.LastUsedIP = ctx.RemoteIP().String()
+	credential.LastUsedCountry = ctx.Providers.GeoIP.Country(ctx.RemoteIP())
+	credential.BackupEligible = asserted.BackupEligible
+	credential.BackupState = asserted.BackupState
+
+	if err = ctx.Providers.StorageProvider.UpdateWebAuthnCredentialUsage(*credential); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to update the signature counter of the passkey of user %s: %s", username, err), authenticationFailedMessage)
+		return
+	}
+
+	userDetails, err := ctx.Providers.UserProvider.GetDetails(username)
+	if err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Error while retrieving details from user %s: %s", username, err), authenticationFailedMessage)
+		return
+	}
+
+	if !isSecondFactorMethodAllowed(ctx.Configuration.SecondFactorPolicies, userDetails.Groups, authnMethodPasskey) {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("The second factor policy of user %s does not allow the %s method", username, authnMethodPasskey), authenticationFailedMessage)
+		return
+	}
+
+	newSession := session.NewDefaultUserSession()
+	newSession.OIDCWorkflowSession = userSession.OIDCWorkflowSession
+
+	// Reset all values from previous session except OIDC workflow before regenerating the cookie.
+	if err = ctx.SaveSession(newSession); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to reset the session for user %s: %s", username, err), authenticationFailedMessage)
+		return
+	}
+
+	if err = ctx.Providers.SessionProvider.RegenerateSession(ctx.RequestCtx); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regenerate session for user %s: %s", username, err), authenticationFailedMessage)
+		return
+	}
+
+	newSession.Username = userDetails.Username
+	newSession.DisplayName = userDetails.DisplayName
+	newSession.Groups = userDetails.Groups
+	newSession.Emails = userDetails.Emails
+	newSession.Attributes = userDetails.Attributes
+	newSession.FirstFactorAuthnTimestamp = now.Unix()
+	newSession.AuthenticationMethods = []string{authnMethodPasskey}
+	newSession.LastActivity = time.Now().Unix()
+
+	firstFactor := isWebAuthnPasskeyFirstFactor(ctx.Configuration.WebAuthn)
+
+	if firstFactor {
+		newSession.AuthenticationLevel = authentication.OneFactor
+	} else {
+		newSession.AuthenticationLevel = authentication.TwoFactor
+		newSession.SecondFactorAuthnTimestamp = now.Unix()
+	}
+
+	if refresh, refreshInterval := getProfileRefreshSettings(ctx.Configuration.AuthenticationBackend); refresh {
+		newSession.RefreshTTL = ctx.Clock.Now().Add(refreshInterval)
+	}
+
+	if err = ctx.SaveSession(newSession); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to save session of user %s", username), authenticationFailedMessage)
+		return
+	}
+
+	switch {
+	case newSession.OIDCWorkflowSession != nil:
+		HandleOIDCWorkflowResponse(ctx)
+	case firstFactor:
+		Handle1FAResponse(ctx, bodyJSON.TargetURL, bodyJSON.RequestMethod, newSession.Username, newSession.Groups)
+	default:
+		Handle2FAResponse(ctx, bodyJSON.TargetURL)
+	}
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+	"github.com/authelia/authelia/internal/webauthn"
+)
+
+type FirstFactorPasskeySuite struct {
+	suite.Suite
+
+	mock *mocks.MockAutheliaCtx
+}
+
+func (s *FirstFactorPasskeySuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.mock.Ctx.Configuration.Session.Domain = "example.com"
+	s.mock.Ctx.Request.Header.Set("X-Forwarded-Proto", "https")
+	s.mock.Ctx.Request.Header.Set("X-Forwarded-Host", "login.example.com")
+}
+
+func (s *FirstFactorPasskeySuite) TearDownTest() {
+	s.mock.Close()
+}
+
+func (s *FirstFactorPasskeySuite) setCredentialBody(id, userHandle []byte) {
+	body := firstFactorPasskeyRequestBody{}
+	body.Credential.ID = id
+	body.Credential.Type = "public-key"
+	body.Credential.Response.UserHandle = userHandle
+
+	bodyBytes, err := json.Marshal(body)
+	s.Require().NoError(err)
+	s.mock.Ctx.Request.SetBody(bodyBytes)
+}
+
+func (s *FirstFactorPasskeySuite) setChallenge() {
+	userSession := s.mock.Ctx.GetSession()
+	userSession.WebAuthnChallenge = []byte("challenge")
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+}
+
+func (s *FirstFactorPasskeySuite) TestShouldReturnAssertionOptions() {
+	FirstFactorPasskeyOptionsPost(s.mock.Ctx)
+
+	options := webauthn.CredentialRequestOptions{}
+	s.mock.GetResponseData(s.T(), &options)
+
+	assert.Equal(s.T(), 200, s.mock.Ctx.Response.StatusCode())
+	assert.Equal(s.T(), "example.com", options.RPID)
+	assert.Equal(s.T(), "required", options.UserVerification)
+	assert.Equal(s.T(), int64(60000), options.Timeout)
+	assert.Equal(s.T(), []byte(options.Challenge), s.mock.Ctx.GetSession().WebAuthnChallenge)
+}
+
+func (s *FirstFactorPasskeySuite) TestShouldReturnConditionalAssertionOptions() {
+	s.setChallenge()
+
+	FirstFactorPasskeyConditionalOptionsPost(s.mock.Ctx)
+
+	options := webauthn.CredentialRequestOptions{}
+	s.mock.GetResponseData(s.T(), &options)
+
+	assert.Equal(s.T(), 200, s.mock.Ctx.Response.StatusCode())
+	assert.Equal(s.T(), []byte(options.Challenge), s.mock.Ctx.GetSession().WebAuthnConditionalChallenge)
+	assert.Equal(s.T(), []byte("challenge"), s.mock.Ctx.GetSession().WebAuthnChallenge)
+}
+
+func (s *FirstFactorPasskeySuite) TestShouldReturnAssertionOptionsWithConfiguredRPID() {
+	s.mock.Ctx.Configuration.WebAuthn = &schema.WebAuthnConfiguration{Timeout: "60s", RPID: "login.example.com"}
+
+	FirstFactorPasskeyOptionsPost(s.mock.Ctx)
+
+	options := webauthn.CredentialRequestOptions{}
+	s.mock.GetResponseData(s.T(), &options)
+
+	assert.Equal(s.T(), "login.example.com", options.RPID)
+}
+
+func (s *FirstFactorPasskeySuite) TestShouldOnlyPreferUserVerificationWhenPasskeysAreFirstFactor() {
+	s.mock.Ctx.Configuration.WebAuthn = &schema.WebAuthnConfiguration{Timeout: "60s", PasskeyMode: "first_factor"}
+
+	FirstFactorPasskeyOptionsPost(s.mock.Ctx)
+
+	options := webauthn.CredentialRequestOptions{}
+	s.mock.GetResponseData(s.T(), &options)
+
+	assert.Equal(s.T(), "preferred", options.UserVerification)
+}
+
+func (s *FirstFactorPasskeySuite) TestShouldNotReturnAssertionOptionsForHostOutsideOfRPID() {
+	s.mock.Ctx.Configuration.WebAuthn = &schema.WebAuthnConfiguration{Timeout: "60s", RPID: "auth.example.com"}
+
+	FirstFactorPasskeyOptionsPost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "The host login.example.com is not within the WebAuthn relying party ID auth.example.com", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), "Authentication failed. Check your credentials.")
+}
+
+func (s *FirstFactorPasskeySuite) TestShouldNotReturnAssertionOptionsForUnprotectedHost() {
+	s.mock.Ctx.Request.Header.Set("X-Forwarded-Host", "login.example.org")
+
+	FirstFactorPasskeyOptionsPost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "The host login.example.org is not protected by any of the session domains", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), "Authentication failed. Check your credentials.")
+	assert.Nil(s.T(), s.mock.Ctx.GetSession().WebAuthnChallenge)
+}
+
+func (s *FirstFactorPasskeySuite) TestShouldFailIfAssertionWasNotInitiated() {
+	s.setCredentialBody([]byte("id"), []byte("handle"))
+
+	FirstFactorPasskeyPost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "WebAuthn assertion has not been initiated yet (no challenge)", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), "Authentication failed. Check your credentials.")
+}
+
+func (s *FirstFactorPasskeySuite) TestShouldFailIfConditionalAssertionWasNotInitiated() {
+	s.setChallenge()
+
+	body := firstFactorPasskeyRequestBody{Conditional: true}
+	body.Credential.ID = []byte("id")
+	body.Credential.Type = "public-key"
+
+	bodyBytes, err := json.Marshal(body)
+	s.Require().NoError(err)
+	s.mock.Ctx.Request.SetBody(bodyBytes)
+
+	FirstFactorPasskeyPost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "WebAuthn assertion has not been initiated yet (no challenge)", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), "Authentication failed. Check your credentials.")
+}
+
+func (s *FirstFactorPasskeySuite) TestShouldFailIfPasskeyIsNotRegistered() {
+	s.setChallenge()
+	s.setCredentialBody([]byte("id"), []byte("handle"))
+
+	s.mock.StorageProviderMock.EXPECT().
+		LoadWebAuthnCredential(gomock.Eq([]byte("id"))).
+		Return(nil, storage.ErrNoWebAuthnCredential)
+
+	FirstFactorPasskeyPost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "The passkey is not registered", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), "Authentication failed. Check your credentials.")
+}
+
+func (s *FirstFactorPasskeySuite) TestShouldFailIfUserHandleDoesNotMatch() {
+	s.setChallenge()
+	s.setCredentialBody([]byte("id"), []byte("other"))
+
+	s.mock.StorageProviderMock.EXPECT().
+		LoadWebAuthnCredential(gomock.Eq([]byte("id"))).
+		Return(&models.WebAuthnCredential{Username: testUsername, ID: []byte("id"), UserHandle: []byte("handle")}, nil)
+
+	FirstFactorPasskeyPost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "The user handle of the passkey of user john does not match", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), "Authentication failed. Check your credentials.")
+}
+
+func (s *FirstFactorPasskeySuite) TestShouldMarkFailedAssertion() {
+	s.setChallenge()
+	s.setCredentialBody([]byte("id"), []byte("handle"))
+
+	s.mock.StorageProviderMock.EXPECT().
+		LoadWebAuthnCredential(gomock.Eq([]byte("id"))).
+		Return(&models.WebAuthnCredential{Username: testUsername, ID: []byte("id"), UserHandle: []byte("handle")}, nil)
+
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   testUsername,
+			Successful: false,
+			Time:       s.mock.Clock.Now(),
+			RemoteIP:   "0.0.0.0",
+			Type:       models.AuthenticationTypePasskey,
+		}))
+
+	FirstFactorPasskeyPost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Unable to verify the passkey of user john: unable to parse the client data: unexpected end of JSON input", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), "Authentication failed. Check your credentials.")
+}
+
+func TestRunFirstFactorPasskeySuite(t *testing.T) {
+	suite.Run(t, new(FirstFactorPasskeySuite))
+}
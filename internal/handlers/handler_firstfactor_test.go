@@ -15,6 +15,7 @@ import (
 	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/mocks"
 	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/utils"
 )
 
 type FirstFactorSuite struct {
@@ -62,6 +63,8 @@ func (s *FirstFactorSuite) TestShouldFailIfUserProviderCheckPasswordFail() {
 			Username:   "test",
 			Successful: false,
 			Time:       s.mock.Clock.Now(),
+			RemoteIP:   "0.0.0.0",
+			Type:       models.AuthenticationTypeFirstFactor,
 		}))
 
 	s.mock.Ctx.Request.SetBodyString(`{
@@ -87,6 +90,8 @@ func (s *FirstFactorSuite) TestShouldCheckAuthenticationIsMarkedWhenInvalidCrede
 			Username:   "test",
 			Successful: false,
 			Time:       s.mock.Clock.Now(),
+			RemoteIP:   "0.0.0.0",
+			Type:       models.AuthenticationTypeFirstFactor,
 		}))
 
 	s.mock.Ctx.Request.SetBodyString(`{
@@ -407,6 +412,37 @@ func (s *FirstFactorRedirectionSuite) TestShouldReply200WhenUnsafeTargetURLProvi
 	s.mock.Assert200OK(s.T(), nil)
 }
 
+// When:
+//   1/ two_factor is enabled (default policy)
+//   2/ the user signs in on a device they trusted
+// Then:
+//   the user should be redirected to the default url with the second factor skipped.
+func (s *FirstFactorRedirectionSuite) TestShouldRedirectToDefaultURLWhenDeviceIsTrusted() {
+	s.mock.Ctx.Configuration.TrustedDevices = &schema.DefaultTrustedDevicesConfiguration
+	s.mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "two_factor",
+	})
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		LoadTrustedDevice(gomock.Eq(utils.HashSHA256FromString("token"))).
+		Return(&models.TrustedDevice{Username: "test", ID: "abc"}, nil)
+
+	s.mock.Ctx.Request.Header.SetCookie(trustedDeviceCookieName, "token")
+	s.mock.Ctx.Request.SetBodyString(`{
+		"username": "test",
+		"password": "hello",
+		"requestMethod": "GET",
+		"keepMeLoggedIn": false
+	}`)
+
+	FirstFactorPost(0, false)(s.mock.Ctx)
+
+	// Respond with 200.
+	s.mock.Assert200OK(s.T(), redirectResponse{Redirect: "https://default.local"})
+	assert.Equal(s.T(), authentication.TwoFactor, s.mock.Ctx.GetSession().AuthenticationLevel)
+}
+
 func TestFirstFactorSuite(t *testing.T) {
 	suite.Run(t, new(FirstFactorSuite))
 	suite.Run(t, new(FirstFactorRedirectionSuite))
@@ -1,10 +1,31 @@
 package handlers
 
 import (
+	"fmt"
+
+	"github.com/valyala/fasthttp"
+
 	"github.com/authelia/authelia/internal/middlewares"
 )
 
-// HealthGet can be used by health checks.
+// HealthGet can be used by health checks. It replies with a service unavailable status when the storage backend is
+// unhealthy.
 func HealthGet(ctx *middlewares.AutheliaCtx) {
-	ctx.ReplyOK()
+	status, err := ctx.Providers.StorageProvider.HealthCheck()
+	if err != nil {
+		ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+		ctx.Error(fmt.Errorf("Storage health check failed: %w", err), "Storage backend is unhealthy.")
+
+		return
+	}
+
+	err = ctx.SetJSONBody(healthResponseBody{
+		Storage: storageHealth{
+			SchemaVersion: int(status.SchemaVersion),
+			Latency:       status.Latency.String(),
+		},
+	})
+	if err != nil {
+		ctx.Logger.Errorf("Unable to set health response in body: %s", err)
+	}
 }
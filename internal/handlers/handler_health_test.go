@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/storage"
+)
+
+func TestHealthGetShouldReplyWithStorageHealth(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().
+		HealthCheck().
+		Return(storage.HealthStatus{SchemaVersion: 5, Latency: 2 * time.Millisecond}, nil)
+
+	HealthGet(mock.Ctx)
+
+	assert.Equal(t, fasthttp.StatusOK, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, `{"status":"OK","data":{"storage":{"schema_version":5,"latency":"2ms"}}}`, string(mock.Ctx.Response.Body()))
+}
+
+func TestHealthGetShouldReplyUnavailableWhenStorageIsUnhealthy(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().
+		HealthCheck().
+		Return(storage.HealthStatus{}, errors.New("connection refused"))
+
+	HealthGet(mock.Ctx)
+
+	assert.Equal(t, fasthttp.StatusServiceUnavailable, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, `{"status":"KO","message":"Storage backend is unhealthy."}`, string(mock.Ctx.Response.Body()))
+	assert.Equal(t, "Storage health check failed: connection refused", mock.Hook.LastEntry().Message)
+}
@@ -5,7 +5,6 @@ import (
 	"net/url"
 
 	"github.com/authelia/authelia/internal/middlewares"
-	"github.com/authelia/authelia/internal/utils"
 )
 
 type logoutBody struct {
@@ -37,7 +36,7 @@ func LogoutPost(ctx *middlewares.AutheliaCtx) {
 
 	redirectionURL, err := url.Parse(body.TargetURL)
 	if err == nil {
-		responseBody.SafeTargetURL = utils.IsRedirectionSafe(*redirectionURL, ctx.Configuration.Session.Domain)
+		responseBody.SafeTargetURL = isRedirectionSafe(ctx, *redirectionURL)
 	}
 
 	if body.TargetURL != "" {
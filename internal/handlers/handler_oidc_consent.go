@@ -3,8 +3,10 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
 )
 
 func oidcConsent(ctx *middlewares.AutheliaCtx) {
@@ -105,6 +107,9 @@ func oidcConsentPOST(ctx *middlewares.AutheliaCtx) {
 			ctx.Error(fmt.Errorf("Unable to write session: %v", err), "Operation failed")
 			return
 		}
+
+		appendAuditEvent(ctx, userSession.Username, models.AuditEventConsentGranted,
+			fmt.Sprintf("client_id=%s scopes=%s", client.ID, strings.Join(userSession.OIDCWorkflowSession.GrantedScopes, " ")))
 	} else if body.AcceptOrReject == reject {
 		redirectionURL = fmt.Sprintf("%s?error=access_denied&error_description=%s",
 			userSession.OIDCWorkflowSession.TargetURI, "User has rejected the scopes")
@@ -25,13 +25,18 @@ func oidcToken(ctx *middlewares.AutheliaCtx, rw http.ResponseWriter, req *http.R
 		return
 	}
 
-	// If this is a client_credentials grant, grant all scopes the client is allowed to perform.
+	// If this is a client_credentials grant, grant all scopes the client is allowed to perform, and the requested
+	// audience which was already matched against the audience of the client.
 	if accessRequest.GetGrantTypes().ExactOne("client_credentials") {
 		for _, scope := range accessRequest.GetRequestedScopes() {
 			if fosite.HierarchicScopeStrategy(accessRequest.GetClient().GetScopes(), scope) {
 				accessRequest.GrantScope(scope)
 			}
 		}
+
+		for _, audience := range accessRequest.GetRequestedAudience() {
+			accessRequest.GrantAudience(audience)
+		}
 	}
 
 	response, err := ctx.Providers.OpenIDConnect.Fosite.NewAccessResponse(ctx, accessRequest)
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// RecoveryCodesGet is the handler returning the number of recovery codes the user has not used yet.
+func RecoveryCodesGet(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	remaining, err := ctx.Providers.StorageProvider.CountRecoveryCodes(userSession.Username)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to count the recovery codes of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	if err = ctx.SetJSONBody(recoveryCodesCountResponse{Remaining: remaining}); err != nil {
+		ctx.Logger.Errorf("Unable to set the count of the recovery codes in body: %s", err)
+	}
+}
+
+// RecoveryCodesPost is the handler generating new recovery codes for the user, which replace the ones generated
+// previously. Only the hashes of the codes are persisted so they are displayed to the user once.
+func RecoveryCodesPost(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]models.RecoveryCode, 0, recoveryCodesCount)
+
+	for i := 0; i < recoveryCodesCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to generate the recovery codes of user %s: %s", userSession.Username, err), operationFailedMessage)
+			return
+		}
+
+		codes = append(codes, code)
+		hashes = append(hashes, models.RecoveryCode{
+			Username: userSession.Username,
+			Hash:     hashRecoveryCode(code),
+			Time:     ctx.Clock.Now(),
+		})
+	}
+
+	if err := ctx.Providers.StorageProvider.SaveRecoveryCodes(userSession.Username, hashes); err != nil {
+		ctx.Error(fmt.Errorf("Unable to save the recovery codes of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	appendAuditEvent(ctx, userSession.Username, models.AuditEventRecoveryCodesGenerated, "")
+	notifyRecoveryCodesChange(ctx, userSession, "Recovery codes were generated",
+		"New recovery codes were generated for your account, the previous ones can't be used anymore", len(codes))
+
+	if err := ctx.SetJSONBody(recoveryCodesResponse{Codes: codes}); err != nil {
+		ctx.Logger.Errorf("Unable to set the recovery codes in body: %s", err)
+	}
+}
+
+// SecondFactorRecoveryCodePost is the handler validating a recovery code used by the user as a second factor when they
+// lost access to their other second factors. The code can't be used again.
+func SecondFactorRecoveryCodePost(ctx *middlewares.AutheliaCtx) {
+	requestBody := signRecoveryCodeRequestBody{}
+
+	if err := ctx.ParseBody(&requestBody); err != nil {
+		handleAuthenticationUnauthorized(ctx, err, mfaValidationFailedMessage)
+		return
+	}
+
+	userSession := ctx.GetSession()
+
+	if isSecondFactorMethodDenied(ctx, userSession, authnMethodRecoveryCode) {
+		return
+	}
+
+	if _, err := ctx.Providers.Regulator.Regulate(userSession.Username); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regulate authentication of user %s: %s", userSession.Username, err), userBannedMessage)
+		return
+	}
+
+	consumed, err := ctx.Providers.StorageProvider.ConsumeRecoveryCode(userSession.Username, hashRecoveryCode(requestBody.Code))
+
+	ctx.Logger.Debugf("Mark authentication attempt made by user %s", userSession.Username)
+
+	if markErr := ctx.Providers.Regulator.Mark(userSession.Username, err == nil && consumed, ctx.RemoteIP(), models.AuthenticationTypeRecoveryCode); markErr != nil {
+		ctx.Logger.Errorf("Unable to mark authentication: %s", markErr)
+	}
+
+	if err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to consume the recovery code of user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+		return
+	}
+
+	if !consumed {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Wrong or already used recovery code for user %s", userSession.Username), mfaValidationFailedMessage)
+		return
+	}
+
+	if err = ctx.Providers.SessionProvider.RegenerateSession(ctx.RequestCtx); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regenerate session for user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+		return
+	}
+
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.SecondFactorAuthnTimestamp = ctx.Clock.Now().Unix()
+	userSession.AuthenticationMethods = append(userSession.AuthenticationMethods, authnMethodRecoveryCode)
+
+	if err = ctx.SaveSession(userSession); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to update the authentication level with the recovery code: %s", err), mfaValidationFailedMessage)
+		return
+	}
+
+	remaining, err := ctx.Providers.StorageProvider.CountRecoveryCodes(userSession.Username)
+	if err != nil {
+		ctx.Logger.Errorf("Unable to count the recovery codes of user %s: %s", userSession.Username, err)
+	}
+
+	appendAuditEvent(ctx, userSession.Username, models.AuditEventRecoveryCodeUsed, fmt.Sprintf("remaining=%d", remaining))
+	notifyRecoveryCodesChange(ctx, userSession, "A recovery code was used", "A recovery code was used to sign in to your account", remaining)
+
+	if userSession.OIDCWorkflowSession != nil {
+		HandleOIDCWorkflowResponse(ctx)
+	} else {
+		Handle2FAResponse(ctx, requestBody.TargetURL)
+	}
+}
+
+// generateRecoveryCode returns a random recovery code, split in two halves to be easier to copy.
+func generateRecoveryCode() (string, error) {
+	code := make([]byte, recoveryCodeLength)
+	max := big.NewInt(int64(len(recoveryCodeCharacters)))
+
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+
+		code[i] = recoveryCodeCharacters[n.Int64()]
+	}
+
+	return string(code[:recoveryCodeLength/2]) + "-" + string(code[recoveryCodeLength/2:]), nil
+}
+
+// hashRecoveryCode returns the hash of a recovery code as persisted in the storage. The codes are normalized first so
+// the users can type them without the separator, with spaces or in upper case.
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+
+	return utils.HashSHA256FromString(normalized)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+)
+
+type HandlerRecoveryCodesSuite struct {
+	suite.Suite
+
+	mock *mocks.MockAutheliaCtx
+}
+
+func (s *HandlerRecoveryCodesSuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.mock.Ctx.Clock = &s.mock.Clock
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.AuthenticationMethods = []string{authnMethodPassword}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+}
+
+func (s *HandlerRecoveryCodesSuite) TearDownTest() {
+	s.mock.Close()
+}
+
+func (s *HandlerRecoveryCodesSuite) expectMark(successful bool) {
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   testUsername,
+			Successful: successful,
+			Time:       s.mock.Clock.Now(),
+			RemoteIP:   "0.0.0.0",
+			Type:       models.AuthenticationTypeRecoveryCode,
+		}))
+}
+
+func (s *HandlerRecoveryCodesSuite) TestShouldGenerateRecoveryCodes() {
+	var saved []models.RecoveryCode
+
+	s.mock.StorageProviderMock.EXPECT().
+		SaveRecoveryCodes(gomock.Eq(testUsername), gomock.Any()).
+		DoAndReturn(func(username string, codes []models.RecoveryCode) error {
+			saved = codes
+			return nil
+		})
+
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuditEvent(gomock.Eq(models.AuditEvent{
+			Username: testUsername,
+			Type:     models.AuditEventRecoveryCodesGenerated,
+			RemoteIP: "0.0.0.0",
+			Time:     s.mock.Clock.Now(),
+		}))
+
+	RecoveryCodesPost(s.mock.Ctx)
+
+	response := recoveryCodesResponse{}
+	s.mock.GetResponseData(s.T(), &response)
+
+	s.Require().Len(response.Codes, recoveryCodesCount)
+	s.Require().Len(saved, recoveryCodesCount)
+
+	for i, code := range response.Codes {
+		s.Regexp("^[a-z2-9]{5}-[a-z2-9]{5}$", code)
+		s.Equal(models.RecoveryCode{Username: testUsername, Hash: hashRecoveryCode(code), Time: s.mock.Clock.Now()}, saved[i])
+	}
+}
+
+func (s *HandlerRecoveryCodesSuite) TestShouldCountRemainingRecoveryCodes() {
+	s.mock.StorageProviderMock.EXPECT().
+		CountRecoveryCodes(gomock.Eq(testUsername)).
+		Return(7, nil)
+
+	RecoveryCodesGet(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), recoveryCodesCountResponse{Remaining: 7})
+}
+
+func (s *HandlerRecoveryCodesSuite) TestShouldUpgradeToTwoFactorWithRecoveryCode() {
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Emails = []string{"john@example.com"}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+
+	s.mock.StorageProviderMock.EXPECT().
+		ConsumeRecoveryCode(gomock.Eq(testUsername), gomock.Eq(hashRecoveryCode("abcde-fghjk"))).
+		Return(true, nil)
+
+	s.expectMark(true)
+
+	s.mock.StorageProviderMock.EXPECT().
+		CountRecoveryCodes(gomock.Eq(testUsername)).
+		Return(9, nil)
+
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuditEvent(gomock.Eq(models.AuditEvent{
+			Username: testUsername,
+			Type:     models.AuditEventRecoveryCodeUsed,
+			RemoteIP: "0.0.0.0",
+			Details:  "remaining=9",
+			Time:     s.mock.Clock.Now(),
+		}))
+
+	s.mock.NotifierMock.EXPECT().
+		Send(gomock.Eq("john@example.com"), gomock.Eq("A recovery code was used"), gomock.Any(), gomock.Eq("")).
+		DoAndReturn(func(recipient, subject, body, htmlBody string) error {
+			assert.Contains(s.T(), body, "A recovery code was used to sign in to your account.")
+			assert.Contains(s.T(), body, "Remaining recovery codes: 9")
+
+			return nil
+		})
+
+	s.mock.Ctx.Configuration.DefaultRedirectionURL = testRedirectionURL
+	s.mock.Ctx.Request.SetBodyString(`{"code":"ABCDE FGHJK"}`)
+
+	SecondFactorRecoveryCodePost(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), redirectResponse{Redirect: testRedirectionURL})
+
+	userSession = s.mock.Ctx.GetSession()
+	assert.Equal(s.T(), authentication.TwoFactor, userSession.AuthenticationLevel)
+	assert.Equal(s.T(), []string{authnMethodPassword, authnMethodRecoveryCode}, userSession.AuthenticationMethods)
+}
+
+func (s *HandlerRecoveryCodesSuite) TestShouldFailWithUsedRecoveryCode() {
+	s.mock.StorageProviderMock.EXPECT().
+		ConsumeRecoveryCode(gomock.Eq(testUsername), gomock.Eq(hashRecoveryCode("abcde-fghjk"))).
+		Return(false, nil)
+
+	s.expectMark(false)
+
+	s.mock.Ctx.Request.SetBodyString(`{"code":"abcde-fghjk"}`)
+
+	SecondFactorRecoveryCodePost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Wrong or already used recovery code for user john", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+	assert.Equal(s.T(), authentication.OneFactor, s.mock.Ctx.GetSession().AuthenticationLevel)
+}
+
+func (s *HandlerRecoveryCodesSuite) TestShouldFailWhenRecoveryCodeCannotBeConsumed() {
+	s.mock.StorageProviderMock.EXPECT().
+		ConsumeRecoveryCode(gomock.Eq(testUsername), gomock.Any()).
+		Return(false, fmt.Errorf("database is locked"))
+
+	s.expectMark(false)
+
+	s.mock.Ctx.Request.SetBodyString(`{"code":"abcde-fghjk"}`)
+
+	SecondFactorRecoveryCodePost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Unable to consume the recovery code of user john: database is locked", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func TestRunHandlerRecoveryCodesSuite(t *testing.T) {
+	suite.Run(t, new(HandlerRecoveryCodesSuite))
+}
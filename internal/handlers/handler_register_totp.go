@@ -6,7 +6,9 @@ import (
 	"github.com/pquerna/otp/totp"
 
 	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
 	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/storage"
 )
 
 // identityRetrieverFromSession retriever computing the identity from the cookie session.
@@ -37,11 +39,62 @@ var SecondFactorTOTPIdentityStart = middlewares.IdentityVerificationStart(middle
 })
 
 func secondFactorTOTPIdentityFinish(ctx *middlewares.AutheliaCtx, username string) {
+	registerTOTP(ctx, username, totpRegistrationProofIdentityVerification)
+}
+
+// SecondFactorTOTPRegisterPost registers a new TOTP secret for a user who proves they hold the current one with a valid
+// one-time password, which spares them the identity verification. The attempts are regulated like the sign in.
+func SecondFactorTOTPRegisterPost(totpVerifier TOTPVerifier) middlewares.RequestHandler {
+	return func(ctx *middlewares.AutheliaCtx) {
+		requestBody := registerTOTPRequestBody{}
+
+		if err := ctx.ParseBody(&requestBody); err != nil {
+			handleAuthenticationUnauthorized(ctx, err, mfaValidationFailedMessage)
+			return
+		}
+
+		userSession := ctx.GetSession()
+
+		if _, err := ctx.Providers.Regulator.Regulate(userSession.Username); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regulate authentication of user %s: %s", userSession.Username, err), userBannedMessage)
+			return
+		}
+
+		configuration, err := ctx.Providers.StorageProvider.LoadTOTPConfiguration(userSession.Username)
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to load TOTP secret of user %s to replace it: %s", userSession.Username, err), mfaValidationFailedMessage)
+			return
+		}
+
+		valid, err := totpVerifier.Verify(requestBody.Token, *configuration)
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Error occurred during OTP validation for user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+			return
+		}
+
+		if err = ctx.Providers.Regulator.Mark(userSession.Username, valid, ctx.RemoteIP(), models.AuthenticationTypeTOTP); err != nil {
+			ctx.Logger.Errorf("Unable to mark authentication: %s", err)
+		}
+
+		if !valid {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Wrong passcode to replace the TOTP secret of user %s", userSession.Username), mfaValidationFailedMessage)
+			return
+		}
+
+		registerTOTP(ctx, userSession.Username, totpRegistrationProofTOTP)
+	}
+}
+
+// registerTOTP generates and saves a new TOTP secret for the user and replies with it. The proof of identity the user
+// gave is recorded with the event.
+func registerTOTP(ctx *middlewares.AutheliaCtx, username, proof string) {
 	key, err := totp.Generate(totp.GenerateOpts{
 		Issuer:      ctx.Configuration.TOTP.Issuer,
 		AccountName: username,
 		SecretSize:  32,
 		Period:      uint(ctx.Configuration.TOTP.Period),
+		Digits:      totpDigits(ctx.Configuration.TOTP.Digits),
+		Algorithm:   totpAlgorithm(ctx.Configuration.TOTP.Algorithm),
 	})
 
 	if err != nil {
@@ -49,12 +102,43 @@ func secondFactorTOTPIdentityFinish(ctx *middlewares.AutheliaCtx, username strin
 		return
 	}
 
-	err = ctx.Providers.StorageProvider.SaveTOTPSecret(username, key.Secret())
+	event := models.TOTPHistoryEventReplaced
+
+	_, err = ctx.Providers.StorageProvider.LoadTOTPConfiguration(username)
+
+	switch err {
+	case nil:
+	case storage.ErrNoTOTPSecret:
+		event = models.TOTPHistoryEventCreated
+	default:
+		ctx.Error(fmt.Errorf("Unable to load TOTP secret from DB: %s", err), unableToRegisterOneTimePasswordMessage)
+		return
+	}
+
+	err = ctx.Providers.StorageProvider.SaveTOTPConfiguration(models.TOTPConfiguration{
+		Username:  username,
+		Secret:    key.Secret(),
+		Algorithm: ctx.Configuration.TOTP.Algorithm,
+		Digits:    ctx.Configuration.TOTP.Digits,
+		Period:    ctx.Configuration.TOTP.Period,
+	})
 	if err != nil {
 		ctx.Error(fmt.Errorf("Unable to save TOTP secret in DB: %s", err), unableToRegisterOneTimePasswordMessage)
 		return
 	}
 
+	err = ctx.Providers.StorageProvider.AppendTOTPHistory(models.TOTPHistoryEntry{
+		Username: username,
+		Event:    event,
+		RemoteIP: ctx.RemoteIP().String(),
+		Time:     ctx.Clock.Now(),
+	})
+	if err != nil {
+		ctx.Logger.Errorf("Unable to record the TOTP history of user %s: %v", username, err)
+	}
+
+	appendAuditEvent(ctx, username, models.AuditEventTOTPRegistered, fmt.Sprintf("event=%s proof=%s", event, proof))
+
 	response := TOTPKeyResponse{
 		OTPAuthURL:   key.URL(),
 		Base32Secret: key.Secret(),
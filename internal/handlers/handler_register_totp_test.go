@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+)
+
+type HandlerRegisterTOTPSuite struct {
+	suite.Suite
+
+	mock     *mocks.MockAutheliaCtx
+	verifier *MockTOTPVerifier
+}
+
+func (s *HandlerRegisterTOTPSuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.mock.Ctx.Clock = &s.mock.Clock
+	s.mock.Ctx.Configuration.TOTP = &schema.TOTPConfiguration{Issuer: "Authelia", Period: 30}
+	s.verifier = NewMockTOTPVerifier(s.mock.Ctrl)
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+}
+
+func (s *HandlerRegisterTOTPSuite) TearDownTest() {
+	s.mock.Close()
+}
+
+func (s *HandlerRegisterTOTPSuite) expectMark(successful bool) {
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   testUsername,
+			Successful: successful,
+			Time:       s.mock.Clock.Now(),
+			RemoteIP:   "0.0.0.0",
+			Type:       models.AuthenticationTypeTOTP,
+		}))
+}
+
+func (s *HandlerRegisterTOTPSuite) TestShouldReplaceSecretWithValidPasscode() {
+	current := &models.TOTPConfiguration{Username: testUsername, Secret: "secret"}
+
+	s.mock.StorageProviderMock.EXPECT().
+		LoadTOTPConfiguration(gomock.Eq(testUsername)).
+		Return(current, nil).
+		Times(2)
+
+	s.verifier.EXPECT().
+		Verify(gomock.Eq("123456"), gomock.Eq(*current)).
+		Return(true, nil)
+
+	s.expectMark(true)
+
+	s.mock.StorageProviderMock.EXPECT().
+		SaveTOTPConfiguration(gomock.Any()).
+		DoAndReturn(func(configuration models.TOTPConfiguration) error {
+			assert.Equal(s.T(), testUsername, configuration.Username)
+			assert.NotEqual(s.T(), "secret", configuration.Secret)
+
+			return nil
+		})
+
+	s.mock.StorageProviderMock.EXPECT().
+		AppendTOTPHistory(gomock.Eq(models.TOTPHistoryEntry{
+			Username: testUsername,
+			Event:    models.TOTPHistoryEventReplaced,
+			RemoteIP: "0.0.0.0",
+			Time:     s.mock.Clock.Now(),
+		}))
+
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuditEvent(gomock.Eq(models.AuditEvent{
+			Username: testUsername,
+			Type:     models.AuditEventTOTPRegistered,
+			RemoteIP: "0.0.0.0",
+			Details:  "event=replaced proof=totp",
+			Time:     s.mock.Clock.Now(),
+		}))
+
+	s.mock.Ctx.Request.SetBodyString(`{"token":"123456"}`)
+
+	SecondFactorTOTPRegisterPost(s.verifier)(s.mock.Ctx)
+
+	assert.Equal(s.T(), 200, s.mock.Ctx.Response.StatusCode())
+	assert.Contains(s.T(), string(s.mock.Ctx.Response.Body()), "otpauth://totp/Authelia:john")
+}
+
+func (s *HandlerRegisterTOTPSuite) TestShouldNotReplaceSecretWithWrongPasscode() {
+	current := &models.TOTPConfiguration{Username: testUsername, Secret: "secret"}
+
+	s.mock.StorageProviderMock.EXPECT().
+		LoadTOTPConfiguration(gomock.Eq(testUsername)).
+		Return(current, nil)
+
+	s.verifier.EXPECT().
+		Verify(gomock.Eq("123456"), gomock.Eq(*current)).
+		Return(false, nil)
+
+	s.expectMark(false)
+
+	s.mock.Ctx.Request.SetBodyString(`{"token":"123456"}`)
+
+	SecondFactorTOTPRegisterPost(s.verifier)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Wrong passcode to replace the TOTP secret of user john", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func (s *HandlerRegisterTOTPSuite) TestShouldRequireIdentityVerificationWithoutSecret() {
+	s.mock.StorageProviderMock.EXPECT().
+		LoadTOTPConfiguration(gomock.Eq(testUsername)).
+		Return(nil, storage.ErrNoTOTPSecret)
+
+	s.mock.Ctx.Request.SetBodyString(`{"token":"123456"}`)
+
+	SecondFactorTOTPRegisterPost(s.verifier)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Unable to load TOTP secret of user john to replace it: No TOTP secret registered", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func TestRunHandlerRegisterTOTPSuite(t *testing.T) {
+	suite.Run(t, new(HandlerRegisterTOTPSuite))
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/tstranex/u2f"
 
 	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
 )
 
 // SecondFactorU2FRegister handler validating the client has successfully validated the challenge
@@ -52,5 +53,7 @@ func SecondFactorU2FRegister(ctx *middlewares.AutheliaCtx) {
 		return
 	}
 
+	appendAuditEvent(ctx, userSession.Username, models.AuditEventU2FRegistered, "")
+
 	ctx.ReplyOK()
 }
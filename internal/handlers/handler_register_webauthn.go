@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+	"github.com/authelia/authelia/internal/webauthn"
+)
+
+// WebAuthnRegistrationOptionsPost is the handler starting the registration of a passkey by a user authenticated with
+// two factors. The passkeys of a user share the same user handle.
+func WebAuthnRegistrationOptionsPost(configuration *schema.WebAuthnConfiguration) middlewares.RequestHandler {
+	attestation := newWebAuthnAttestationPolicy(configuration)
+	maxPasskeys := webAuthnMaxPasskeys(configuration)
+
+	return func(ctx *middlewares.AutheliaCtx) {
+		rp, err := newWebAuthnRelyingParty(ctx, attestation)
+		if err != nil {
+			ctx.Error(err, unableToRegisterPasskeyMessage)
+			return
+		}
+
+		userSession := ctx.GetSession()
+
+		credentials, err := ctx.Providers.StorageProvider.LoadWebAuthnCredentials(userSession.Username)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to load the passkeys of user %s: %s", userSession.Username, err), unableToRegisterPasskeyMessage)
+			return
+		}
+
+		if len(credentials) >= maxPasskeys {
+			ctx.Error(fmt.Errorf("User %s has reached the maximum of %d passkeys", userSession.Username, maxPasskeys), maxPasskeysReachedMessage)
+			return
+		}
+
+		exclude := make([][]byte, 0, len(credentials))
+
+		for _, credential := range credentials {
+			exclude = append(exclude, credential.ID)
+		}
+
+		var userHandle []byte
+
+		if len(credentials) != 0 {
+			userHandle = credentials[0].UserHandle
+		} else if userHandle, err = webauthn.NewUserHandle(); err != nil {
+			ctx.Error(fmt.Errorf("Unable to generate the user handle of user %s: %s", userSession.Username, err), unableToRegisterPasskeyMessage)
+			return
+		}
+
+		challenge, err := webauthn.NewChallenge()
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to generate new WebAuthn challenge for registration: %s", err), unableToRegisterPasskeyMessage)
+			return
+		}
+
+		userSession.WebAuthnChallenge = challenge
+		userSession.WebAuthnUserHandle = userHandle
+
+		if err = ctx.SaveSession(userSession); err != nil {
+			ctx.Error(fmt.Errorf("Unable to save WebAuthn challenge in session: %s", err), unableToRegisterPasskeyMessage)
+			return
+		}
+
+		displayName := userSession.DisplayName
+		if displayName == "" {
+			displayName = userSession.Username
+		}
+
+		user := webauthn.User{ID: userHandle, Name: userSession.Username, DisplayName: displayName}
+
+		if err = ctx.SetJSONBody(rp.NewRegistrationOptions(challenge, user, exclude)); err != nil {
+			ctx.Logger.Errorf("Unable to set the WebAuthn registration options in body: %s", err)
+		}
+	}
+}
+
+// WebAuthnRegistrationPost is the handler verifying the passkey created by the authenticator of the user and
+// completing its registration.
+func WebAuthnRegistrationPost(configuration *schema.WebAuthnConfiguration) middlewares.RequestHandler {
+	attestation := newWebAuthnAttestationPolicy(configuration)
+	maxPasskeys := webAuthnMaxPasskeys(configuration)
+
+	return func(ctx *middlewares.AutheliaCtx) {
+		var bodyJSON webAuthnRegistrationRequestBody
+
+		if err := ctx.ParseBody(&bodyJSON); err != nil {
+			ctx.Error(fmt.Errorf("Unable to parse response body: %s", err), unableToRegisterPasskeyMessage)
+			return
+		}
+
+		if utf8.RuneCountInString(bodyJSON.Description) > webAuthnCredentialDescriptionMaxLength {
+			ctx.Error(fmt.Errorf("The description of the passkey is longer than %d characters", webAuthnCredentialDescriptionMaxLength), unableToRegisterPasskeyMessage)
+			return
+		}
+
+		userSession := ctx.GetSession()
+
+		if userSession.WebAuthnChallenge == nil || userSession.WebAuthnUserHandle == nil {
+			ctx.Error(fmt.Errorf("WebAuthn registration has not been initiated yet"), unableToRegisterPasskeyMessage)
+			return
+		}
+
+		challenge, userHandle := userSession.WebAuthnChallenge, userSession.WebAuthnUserHandle
+
+		// Ensure the challenge is cleared if anything goes wrong.
+		defer func() {
+			userSession.WebAuthnChallenge = nil
+			userSession.WebAuthnUserHandle = nil
+
+			if err := ctx.SaveSession(userSession); err != nil {
+				ctx.Logger.Errorf("Unable to clear WebAuthn challenge in session for user %s: %s", userSession.Username, err)
+			}
+		}()
+
+		rp, err := newWebAuthnRelyingParty(ctx, attestation)
+		if err != nil {
+			ctx.Error(err, unableToRegisterPasskeyMessage)
+			return
+		}
+
+		credential, err := rp.VerifyRegistration(challenge, bodyJSON.Credential)
+		if err != nil {
+			if errors.Is(err, webauthn.ErrAttestationRejected) {
+				ctx.Error(fmt.Errorf("Rejected the passkey of user %s: %s", userSession.Username, err), passkeyNotAllowedMessage)
+				return
+			}
+
+			ctx.Error(fmt.Errorf("Unable to verify WebAuthn registration: %s", err), unableToRegisterPasskeyMessage)
+
+			return
+		}
+
+		if err = verifySyncedPasskeyPolicy(ctx, configuration, userSession.Username, credential); err != nil {
+			ctx.Error(fmt.Errorf("Rejected the passkey of user %s: %s", userSession.Username, err), syncedPasskeyNotAllowedMessage)
+			return
+		}
+
+		if err = verifyWebAuthnCredentialNotRegistered(ctx, credential.ID); err != nil {
+			replyWebAuthnRegistrationError(ctx, userSession.Username, err)
+			return
+		}
+
+		// The limit is checked again as other passkeys may have been registered since the options were issued.
+		credentials, err := ctx.Providers.StorageProvider.LoadWebAuthnCredentials(userSession.Username)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to load the passkeys of user %s: %s", userSession.Username, err), unableToRegisterPasskeyMessage)
+			return
+		}
+
+		if len(credentials) >= maxPasskeys {
+			ctx.Error(fmt.Errorf("User %s has reached the maximum of %d passkeys", userSession.Username, maxPasskeys), maxPasskeysReachedMessage)
+			return
+		}
+
+		ctx.Logger.Debugf("Register passkey for user %s", userSession.Username)
+
+		err = ctx.Providers.StorageProvider.SaveWebAuthnCredential(models.WebAuthnCredential{
+			Username:       userSession.Username,
+			ID:             credential.ID,
+			UserHandle:     userHandle,
+			PublicKey:      credential.PublicKey,
+			SignCount:      credential.SignCount,
+			Time:           ctx.Clock.Now(),
+			Description:    bodyJSON.Description,
+			BackupEligible: credential.BackupEligible,
+			BackupState:    credential.BackupState,
+			AAGUID:         credential.AAGUID,
+		})
+		if err != nil {
+			replyWebAuthnRegistrationError(ctx, userSession.Username, err)
+			return
+		}
+
+		details := ""
+
+		if credential.EnterpriseSerialNumber != "" {
+			ctx.Logger.Infof("User %s registered a passkey with the authenticator %s attested by an enterprise attestation", userSession.Username, credential.EnterpriseSerialNumber)
+
+			details = fmt.Sprintf("enterprise_serial_number=%s", credential.EnterpriseSerialNumber)
+		}
+
+		appendAuditEvent(ctx, userSession.Username, models.AuditEventPasskeyRegistered, details)
+		notifyPasskeyChange(ctx, userSession, "A passkey was registered", "A passkey was registered to your account", bodyJSON.Description)
+
+		ctx.ReplyOK()
+	}
+}
+
+// verifyWebAuthnCredentialNotRegistered returns storage.ErrWebAuthnCredentialRegistered if the credential is already
+// registered. The IDs of the credentials identify the users signing in with a passkey so they can't be registered twice,
+// the registered credentials are excluded from the registration options but the browsers may not enforce it.
+func verifyWebAuthnCredentialNotRegistered(ctx *middlewares.AutheliaCtx, id []byte) error {
+	_, err := ctx.Providers.StorageProvider.LoadWebAuthnCredential(id)
+
+	switch err {
+	case nil:
+		return storage.ErrWebAuthnCredentialRegistered
+	case storage.ErrNoWebAuthnCredential:
+		return nil
+	default:
+		return err
+	}
+}
+
+// replyWebAuthnRegistrationError replies with the message of the error preventing the registration of a passkey. The
+// storage also rejects the credentials registered concurrently by another request.
+func replyWebAuthnRegistrationError(ctx *middlewares.AutheliaCtx, username string, err error) {
+	if err == storage.ErrWebAuthnCredentialRegistered {
+		ctx.Error(fmt.Errorf("Unable to register passkey for user %s: the credential is already registered", username), passkeyAlreadyRegisteredMessage)
+		return
+	}
+
+	ctx.Error(fmt.Errorf("Unable to register passkey for user %s: %s", username, err), unableToRegisterPasskeyMessage)
+}
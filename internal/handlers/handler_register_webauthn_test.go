@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+	"github.com/authelia/authelia/internal/webauthn"
+)
+
+type RegisterWebAuthnSuite struct {
+	suite.Suite
+
+	mock *mocks.MockAutheliaCtx
+}
+
+func (s *RegisterWebAuthnSuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.mock.Ctx.Configuration.Session.Domain = "example.com"
+	s.mock.Ctx.Request.Header.Set("X-Forwarded-Proto", "https")
+	s.mock.Ctx.Request.Header.Set("X-Forwarded-Host", "login.example.com")
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+}
+
+func (s *RegisterWebAuthnSuite) TearDownTest() {
+	s.mock.Close()
+}
+
+func (s *RegisterWebAuthnSuite) TestShouldIssueRegistrationOptions() {
+	s.mock.StorageProviderMock.EXPECT().
+		LoadWebAuthnCredentials(gomock.Eq(testUsername)).
+		Return([]models.WebAuthnCredential{{Username: testUsername, ID: []byte("abc"), UserHandle: []byte("handle")}}, nil)
+
+	WebAuthnRegistrationOptionsPost(&schema.WebAuthnConfiguration{MaxPasskeys: 2})(s.mock.Ctx)
+
+	assert.Equal(s.T(), 200, s.mock.Ctx.Response.StatusCode())
+	assert.Equal(s.T(), []byte("handle"), s.mock.Ctx.GetSession().WebAuthnUserHandle)
+	assert.NotEmpty(s.T(), s.mock.Ctx.GetSession().WebAuthnChallenge)
+
+	options := webauthn.CredentialCreationOptions{}
+	s.mock.GetResponseData(s.T(), &options)
+
+	assert.Equal(s.T(), []webauthn.CredentialDescriptor{{Type: "public-key", ID: []byte("abc")}}, options.ExcludeCredentials)
+}
+
+func (s *RegisterWebAuthnSuite) TestShouldNotIssueRegistrationOptionsWhenMaxPasskeysIsReached() {
+	s.mock.StorageProviderMock.EXPECT().
+		LoadWebAuthnCredentials(gomock.Eq(testUsername)).
+		Return([]models.WebAuthnCredential{
+			{Username: testUsername, ID: []byte("abc")},
+			{Username: testUsername, ID: []byte("def")},
+		}, nil)
+
+	WebAuthnRegistrationOptionsPost(&schema.WebAuthnConfiguration{MaxPasskeys: 2})(s.mock.Ctx)
+
+	assert.Equal(s.T(), "User john has reached the maximum of 2 passkeys", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), maxPasskeysReachedMessage)
+	assert.Empty(s.T(), s.mock.Ctx.GetSession().WebAuthnChallenge)
+}
+
+func (s *RegisterWebAuthnSuite) TestShouldApplySyncedPasskeyPolicy() {
+	synced := &webauthn.Credential{BackupEligible: true}
+
+	assert.NoError(s.T(), verifySyncedPasskeyPolicy(s.mock.Ctx, &schema.WebAuthnConfiguration{SyncedPasskeys: "allow"}, testUsername, synced))
+	assert.NoError(s.T(), verifySyncedPasskeyPolicy(s.mock.Ctx, &schema.WebAuthnConfiguration{SyncedPasskeys: "reject"}, testUsername, &webauthn.Credential{}))
+
+	assert.NoError(s.T(), verifySyncedPasskeyPolicy(s.mock.Ctx, &schema.WebAuthnConfiguration{SyncedPasskeys: "warn"}, testUsername, synced))
+	assert.Equal(s.T(), "The passkey of user john can be synced between devices", s.mock.Hook.LastEntry().Message)
+
+	assert.Equal(s.T(), errSyncedPasskeyRejected, verifySyncedPasskeyPolicy(s.mock.Ctx, &schema.WebAuthnConfiguration{SyncedPasskeys: "reject"}, testUsername, synced))
+}
+
+func TestShouldExpandWebAuthnAAGUIDPresets(t *testing.T) {
+	policy := newWebAuthnAttestationPolicy(&schema.WebAuthnConfiguration{
+		AllowedAAGUIDs: []string{"cb69481e-8ff7-4039-93ec-0a2729a154a8"},
+		DeniedAAGUIDs:  []string{"icloud-keychain", "google-password-manager"},
+	})
+
+	assert.Equal(t, []string{"cb69481e-8ff7-4039-93ec-0a2729a154a8"}, policy.AAGUIDs)
+	assert.Equal(t, []string{"fbfc3007-154e-4ecc-8c0b-6e020557d7bd", "ea9b8d66-4d01-1d21-3ce4-b6b48cb575d4"}, policy.DeniedAAGUIDs)
+	assert.Nil(t, policy.Roots)
+
+	assert.Nil(t, newWebAuthnAttestationPolicy(&schema.WebAuthnConfiguration{}))
+
+	policy = newWebAuthnAttestationPolicy(&schema.WebAuthnConfiguration{
+		EnterpriseAttestationRPIDs:   []string{"example.com"},
+		EnterpriseAttestationAAGUIDs: []string{"icloud-keychain"},
+	})
+
+	assert.Equal(t, []string{"example.com"}, policy.EnterpriseRPIDs)
+	assert.Equal(t, []string{"fbfc3007-154e-4ecc-8c0b-6e020557d7bd"}, policy.EnterpriseAAGUIDs)
+}
+
+func (s *RegisterWebAuthnSuite) TestShouldRejectCredentialAlreadyRegistered() {
+	gomock.InOrder(
+		s.mock.StorageProviderMock.EXPECT().
+			LoadWebAuthnCredential(gomock.Eq([]byte("abc"))).
+			Return(&models.WebAuthnCredential{Username: "harry", ID: []byte("abc")}, nil),
+		s.mock.StorageProviderMock.EXPECT().
+			LoadWebAuthnCredential(gomock.Eq([]byte("def"))).
+			Return(nil, storage.ErrNoWebAuthnCredential),
+		s.mock.StorageProviderMock.EXPECT().
+			LoadWebAuthnCredential(gomock.Eq([]byte("ghi"))).
+			Return(nil, errors.New("failed")),
+	)
+
+	err := verifyWebAuthnCredentialNotRegistered(s.mock.Ctx, []byte("abc"))
+	assert.Equal(s.T(), storage.ErrWebAuthnCredentialRegistered, err)
+
+	replyWebAuthnRegistrationError(s.mock.Ctx, testUsername, err)
+
+	assert.Equal(s.T(), "Unable to register passkey for user john: the credential is already registered", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), passkeyAlreadyRegisteredMessage)
+
+	assert.NoError(s.T(), verifyWebAuthnCredentialNotRegistered(s.mock.Ctx, []byte("def")))
+	assert.EqualError(s.T(), verifyWebAuthnCredentialNotRegistered(s.mock.Ctx, []byte("ghi")), "failed")
+}
+
+func TestRunRegisterWebAuthnSuite(t *testing.T) {
+	suite.Run(t, new(RegisterWebAuthnSuite))
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
 	"github.com/authelia/authelia/internal/utils"
 )
 
@@ -44,6 +45,8 @@ func ResetPasswordPost(ctx *middlewares.AutheliaCtx) {
 
 	ctx.Logger.Debugf("Password of user %s has been reset", *userSession.PasswordResetUsername)
 
+	appendAuditEvent(ctx, *userSession.PasswordResetUsername, models.AuditEventPasswordReset, "")
+
 	// Reset the request.
 	userSession.PasswordResetUsername = nil
 	err = ctx.SaveSession(userSession)
@@ -9,8 +9,9 @@ import (
 	"github.com/authelia/authelia/internal/middlewares"
 )
 
-// SecondFactorDuoPost handler for sending a push notification via duo api.
-func SecondFactorDuoPost(duoAPI duo.API) middlewares.RequestHandler {
+// SecondFactorDuoPost handler for sending a push notification via duo api. The notification is sent through the
+// integrations the user is routed to, the next one being tried when Duo fails on its side.
+func SecondFactorDuoPost(integrations duo.Integrations) middlewares.RequestHandler {
 	return func(ctx *middlewares.AutheliaCtx) {
 		var requestBody signDuoRequestBody
 		err := ctx.ParseBody(&requestBody)
@@ -21,6 +22,11 @@ func SecondFactorDuoPost(duoAPI duo.API) middlewares.RequestHandler {
 		}
 
 		userSession := ctx.GetSession()
+
+		if isSecondFactorMethodDenied(ctx, userSession, authnMethodDuo) {
+			return
+		}
+
 		remoteIP := ctx.RemoteIP().String()
 
 		ctx.Logger.Debugf("Starting Duo Push Auth Attempt for %s from IP %s", userSession.Username, remoteIP)
@@ -36,7 +42,23 @@ func SecondFactorDuoPost(duoAPI duo.API) middlewares.RequestHandler {
 			values.Set("pushinfo", fmt.Sprintf("target%%20url=%s", requestBody.TargetURL))
 		}
 
-		duoResponse, err := duoAPI.Call(values, ctx)
+		var duoResponse *duo.Response
+
+		for _, integration := range integrations.Select(userSession.Groups) {
+			duoResponse, err = integration.API.Call(values, ctx)
+			if err == nil && !duoResponse.IsServerError() {
+				ctx.Logger.Debugf("Duo Push Auth Attempt for %s handled by integration %s", userSession.Username, integration.Name)
+				break
+			}
+
+			if err != nil {
+				ctx.Logger.Warnf("Duo integration %s errored for %s: %s", integration.Name, userSession.Username, err)
+			} else {
+				ctx.Logger.Warnf("Duo integration %s failed for %s: %s (%s), error code %d", integration.Name,
+					userSession.Username, duoResponse.Message, duoResponse.MessageDetail, duoResponse.Code)
+			}
+		}
+
 		if err != nil {
 			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Duo API errored: %s", err), mfaValidationFailedMessage)
 			return
@@ -66,6 +88,8 @@ func SecondFactorDuoPost(duoAPI duo.API) middlewares.RequestHandler {
 		}
 
 		userSession.AuthenticationLevel = authentication.TwoFactor
+		userSession.SecondFactorAuthnTimestamp = ctx.Clock.Now().Unix()
+		userSession.AuthenticationMethods = append(userSession.AuthenticationMethods, authnMethodDuo)
 		err = ctx.SaveSession(userSession)
 
 		if err != nil {
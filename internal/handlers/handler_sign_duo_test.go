@@ -51,7 +51,7 @@ func (s *SecondFactorDuoPostSuite) TestShouldCallDuoAPIAndAllowAccess() {
 
 	s.mock.Ctx.Request.SetBodyString("{\"targetURL\": \"https://target.example.com\"}")
 
-	SecondFactorDuoPost(duoMock)(s.mock.Ctx)
+	SecondFactorDuoPost(duo.Integrations{{Name: "default", API: duoMock}})(s.mock.Ctx)
 
 	assert.Equal(s.T(), s.mock.Ctx.Response.StatusCode(), 200)
 }
@@ -73,7 +73,7 @@ func (s *SecondFactorDuoPostSuite) TestShouldCallDuoAPIAndDenyAccess() {
 
 	s.mock.Ctx.Request.SetBodyString("{\"targetURL\": \"https://target.example.com\"}")
 
-	SecondFactorDuoPost(duoMock)(s.mock.Ctx)
+	SecondFactorDuoPost(duo.Integrations{{Name: "default", API: duoMock}})(s.mock.Ctx)
 
 	assert.Equal(s.T(), s.mock.Ctx.Response.StatusCode(), 401)
 }
@@ -92,7 +92,7 @@ func (s *SecondFactorDuoPostSuite) TestShouldCallDuoAPIAndFail() {
 
 	s.mock.Ctx.Request.SetBodyString("{\"targetURL\": \"https://target.example.com\"}")
 
-	SecondFactorDuoPost(duoMock)(s.mock.Ctx)
+	SecondFactorDuoPost(duo.Integrations{{Name: "default", API: duoMock}})(s.mock.Ctx)
 
 	s.mock.Assert401KO(s.T(), "Authentication failed, please retry later.")
 }
@@ -111,7 +111,7 @@ func (s *SecondFactorDuoPostSuite) TestShouldRedirectUserToDefaultURL() {
 	s.Require().NoError(err)
 	s.mock.Ctx.Request.SetBody(bodyBytes)
 
-	SecondFactorDuoPost(duoMock)(s.mock.Ctx)
+	SecondFactorDuoPost(duo.Integrations{{Name: "default", API: duoMock}})(s.mock.Ctx)
 	s.mock.Assert200OK(s.T(), redirectResponse{
 		Redirect: testRedirectionURL,
 	})
@@ -129,7 +129,7 @@ func (s *SecondFactorDuoPostSuite) TestShouldNotReturnRedirectURL() {
 	s.Require().NoError(err)
 	s.mock.Ctx.Request.SetBody(bodyBytes)
 
-	SecondFactorDuoPost(duoMock)(s.mock.Ctx)
+	SecondFactorDuoPost(duo.Integrations{{Name: "default", API: duoMock}})(s.mock.Ctx)
 	s.mock.Assert200OK(s.T(), nil)
 }
 
@@ -147,7 +147,7 @@ func (s *SecondFactorDuoPostSuite) TestShouldRedirectUserToSafeTargetURL() {
 	s.Require().NoError(err)
 	s.mock.Ctx.Request.SetBody(bodyBytes)
 
-	SecondFactorDuoPost(duoMock)(s.mock.Ctx)
+	SecondFactorDuoPost(duo.Integrations{{Name: "default", API: duoMock}})(s.mock.Ctx)
 	s.mock.Assert200OK(s.T(), redirectResponse{
 		Redirect: "https://mydomain.local",
 	})
@@ -167,7 +167,7 @@ func (s *SecondFactorDuoPostSuite) TestShouldNotRedirectToUnsafeURL() {
 	s.Require().NoError(err)
 	s.mock.Ctx.Request.SetBody(bodyBytes)
 
-	SecondFactorDuoPost(duoMock)(s.mock.Ctx)
+	SecondFactorDuoPost(duo.Integrations{{Name: "default", API: duoMock}})(s.mock.Ctx)
 	s.mock.Assert200OK(s.T(), nil)
 }
 
@@ -188,7 +188,7 @@ func (s *SecondFactorDuoPostSuite) TestShouldRegenerateSessionForPreventingSessi
 	r := regexp.MustCompile("^authelia_session=(.*); path=")
 	res := r.FindAllStringSubmatch(string(s.mock.Ctx.Response.Header.PeekCookie("authelia_session")), -1)
 
-	SecondFactorDuoPost(duoMock)(s.mock.Ctx)
+	SecondFactorDuoPost(duo.Integrations{{Name: "default", API: duoMock}})(s.mock.Ctx)
 	s.mock.Assert200OK(s.T(), nil)
 
 	s.Assert().NotEqual(
@@ -196,6 +196,70 @@ func (s *SecondFactorDuoPostSuite) TestShouldRegenerateSessionForPreventingSessi
 		string(s.mock.Ctx.Request.Header.Cookie("authelia_session")))
 }
 
+func (s *SecondFactorDuoPostSuite) TestShouldFailoverToNextIntegrationOnServerError() {
+	failingMock := mocks.NewMockAPI(s.mock.Ctrl)
+	backupMock := mocks.NewMockAPI(s.mock.Ctrl)
+
+	failure := duo.Response{Stat: "FAIL", Code: 50001, Message: "Internal server error"}
+	response := duo.Response{}
+	response.Response.Result = testResultAllow
+
+	gomock.InOrder(
+		failingMock.EXPECT().Call(gomock.Any(), s.mock.Ctx).Return(&failure, nil),
+		backupMock.EXPECT().Call(gomock.Any(), s.mock.Ctx).Return(&response, nil),
+	)
+
+	s.mock.Ctx.Request.SetBodyString("{}")
+
+	SecondFactorDuoPost(duo.Integrations{
+		{Name: "default", API: failingMock},
+		{Name: "backup", API: backupMock},
+	})(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), nil)
+}
+
+func (s *SecondFactorDuoPostSuite) TestShouldFailWhenAllIntegrationsAreFailing() {
+	failingMock := mocks.NewMockAPI(s.mock.Ctrl)
+	backupMock := mocks.NewMockAPI(s.mock.Ctrl)
+
+	failingMock.EXPECT().Call(gomock.Any(), s.mock.Ctx).Return(nil, fmt.Errorf("Connnection error"))
+	backupMock.EXPECT().Call(gomock.Any(), s.mock.Ctx).Return(nil, fmt.Errorf("Connnection refused"))
+
+	s.mock.Ctx.Request.SetBodyString("{}")
+
+	SecondFactorDuoPost(duo.Integrations{
+		{Name: "default", API: failingMock},
+		{Name: "backup", API: backupMock},
+	})(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Duo API errored: Connnection refused", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), "Authentication failed, please retry later.")
+}
+
+func (s *SecondFactorDuoPostSuite) TestShouldRouteUserToIntegrationOfGroup() {
+	defaultMock := mocks.NewMockAPI(s.mock.Ctrl)
+	emeaMock := mocks.NewMockAPI(s.mock.Ctrl)
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Groups = []string{"dev", "emea"}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+
+	response := duo.Response{}
+	response.Response.Result = testResultAllow
+
+	emeaMock.EXPECT().Call(gomock.Any(), s.mock.Ctx).Return(&response, nil)
+
+	s.mock.Ctx.Request.SetBodyString("{}")
+
+	SecondFactorDuoPost(duo.Integrations{
+		{Name: "default", API: defaultMock},
+		{Name: "emea", Groups: []string{"emea"}, API: emeaMock},
+	})(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), nil)
+}
+
 func TestRunSecondFactorDuoPostSuite(t *testing.T) {
 	s := new(SecondFactorDuoPostSuite)
 	suite.Run(t, s)
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/url"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/authorization"
+	"github.com/authelia/authelia/internal/duo"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
+)
+
+// SecondFactorDuoUniversalPost is the handler starting the Duo Universal Prompt flow. It returns the URL of Duo the user
+// is redirected to, Duo then redirects the user back to SecondFactorDuoUniversalCallbackGet. The user is redirected to
+// the first available integration among the ones the user is routed to.
+func SecondFactorDuoUniversalPost(integrations duo.Integrations) middlewares.RequestHandler {
+	return func(ctx *middlewares.AutheliaCtx) {
+		var requestBody signDuoRequestBody
+
+		if err := ctx.ParseBody(&requestBody); err != nil {
+			handleAuthenticationUnauthorized(ctx, err, mfaValidationFailedMessage)
+			return
+		}
+
+		userSession := ctx.GetSession()
+
+		if isSecondFactorMethodDenied(ctx, userSession, authnMethodDuo) {
+			return
+		}
+
+		integration, ok := selectAvailableDuoIntegration(ctx, integrations, userSession.Username, userSession.Groups)
+		if !ok {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("No Duo integration is available for user %s", userSession.Username), mfaValidationFailedMessage)
+			return
+		}
+
+		redirectURI, err := duoCallbackURI(ctx)
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, err, mfaValidationFailedMessage)
+			return
+		}
+
+		state, err := randomHexString(duoStateLength)
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to generate the Duo state: %s", err), mfaValidationFailedMessage)
+			return
+		}
+
+		nonce, err := randomHexString(duoStateLength)
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to generate the Duo nonce: %s", err), mfaValidationFailedMessage)
+			return
+		}
+
+		authURL, err := integration.UniversalAPI.AuthURL(userSession.Username, state, nonce, redirectURI)
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to create the Duo authorization request of user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+			return
+		}
+
+		userSession.DuoState = state
+		userSession.DuoNonce = nonce
+		userSession.DuoIntegration = integration.Name
+		userSession.DuoTargetURL = requestBody.TargetURL
+
+		if err = ctx.SaveSession(userSession); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to save the Duo state in session: %s", err), mfaValidationFailedMessage)
+			return
+		}
+
+		ctx.Logger.Debugf("Redirecting user %s to the Duo Universal Prompt of integration %s", userSession.Username, integration.Name)
+
+		if err = ctx.SetJSONBody(duoUniversalResponse{URL: authURL}); err != nil {
+			ctx.Logger.Errorf("Unable to set the Duo authorization URL in body: %s", err)
+		}
+	}
+}
+
+// SecondFactorDuoUniversalCallbackGet is the handler Duo redirects the user to once the user went through the Universal
+// Prompt. The state must match the one of the request started by the user, it can only be used once.
+func SecondFactorDuoUniversalCallbackGet(integrations duo.Integrations) middlewares.RequestHandler {
+	return func(ctx *middlewares.AutheliaCtx) {
+		userSession := ctx.GetSession()
+
+		state := ctx.QueryArgs().Peek("state")
+		code := string(ctx.QueryArgs().Peek("duo_code"))
+		expectedState, nonce, targetURL := userSession.DuoState, userSession.DuoNonce, userSession.DuoTargetURL
+		integrationName := userSession.DuoIntegration
+
+		userSession.DuoState = ""
+		userSession.DuoNonce = ""
+		userSession.DuoIntegration = ""
+		userSession.DuoTargetURL = ""
+
+		if err := ctx.SaveSession(userSession); err != nil {
+			redirectDuoFailure(ctx, targetURL, fmt.Errorf("Unable to clear the Duo state in session: %s", err))
+			return
+		}
+
+		if expectedState == "" || subtle.ConstantTimeCompare(state, []byte(expectedState)) != 1 {
+			redirectDuoFailure(ctx, targetURL, fmt.Errorf("Duo redirected user %s with an unexpected state", userSession.Username))
+			return
+		}
+
+		if code == "" {
+			redirectDuoFailure(ctx, targetURL, fmt.Errorf("Duo redirected user %s without a code", userSession.Username))
+			return
+		}
+
+		integration, ok := integrations.Get(integrationName)
+		if !ok {
+			redirectDuoFailure(ctx, targetURL, fmt.Errorf("Duo redirected user %s from unknown integration %s", userSession.Username, integrationName))
+			return
+		}
+
+		redirectURI, err := duoCallbackURI(ctx)
+		if err != nil {
+			redirectDuoFailure(ctx, targetURL, err)
+			return
+		}
+
+		claims, err := integration.UniversalAPI.ExchangeCode(code, redirectURI, userSession.Username, nonce)
+		if err != nil {
+			redirectDuoFailure(ctx, targetURL, fmt.Errorf("Unable to exchange the Duo code of user %s: %s", userSession.Username, err))
+			return
+		}
+
+		if claims.AuthResult.Status != duo.AuthResultAllow {
+			redirectDuoFailure(ctx, targetURL, fmt.Errorf("Duo denied the authentication of user %s: %s", userSession.Username, claims.AuthResult.StatusMessage))
+			return
+		}
+
+		if err = ctx.Providers.SessionProvider.RegenerateSession(ctx.RequestCtx); err != nil {
+			redirectDuoFailure(ctx, targetURL, fmt.Errorf("Unable to regenerate session for user %s: %s", userSession.Username, err))
+			return
+		}
+
+		userSession.AuthenticationLevel = authentication.TwoFactor
+		userSession.SecondFactorAuthnTimestamp = ctx.Clock.Now().Unix()
+		userSession.AuthenticationMethods = append(userSession.AuthenticationMethods, authnMethodDuo)
+
+		if err = ctx.SaveSession(userSession); err != nil {
+			redirectDuoFailure(ctx, targetURL, fmt.Errorf("Unable to update authentication level with Duo: %s", err))
+			return
+		}
+
+		ctx.Redirect(duoRedirectionURL(ctx, userSession, targetURL), fasthttp.StatusFound)
+	}
+}
+
+// selectAvailableDuoIntegration returns the first integration the user is routed to which passes the health check.
+func selectAvailableDuoIntegration(ctx *middlewares.AutheliaCtx, integrations duo.Integrations, username string, groups []string) (duo.Integration, bool) {
+	for _, integration := range integrations.Select(groups) {
+		if err := integration.UniversalAPI.HealthCheck(); err != nil {
+			ctx.Logger.Warnf("Duo integration %s is unavailable for user %s: %s", integration.Name, username, err)
+			continue
+		}
+
+		return integration, true
+	}
+
+	return duo.Integration{}, false
+}
+
+// duoCallbackURI returns the URI of the callback Duo redirects the user to.
+func duoCallbackURI(ctx *middlewares.AutheliaCtx) (string, error) {
+	uri, err := ctx.ForwardedProtoHost()
+	if err != nil {
+		return "", fmt.Errorf("Unable to get forward facing URI: %s", err)
+	}
+
+	return fmt.Sprintf("%s%s%s", uri, ctx.Configuration.Server.Path, duoCallbackPath), nil
+}
+
+// duoPortalURL returns the URL of the portal, keeping the target URL so that the user is redirected to it later.
+func duoPortalURL(ctx *middlewares.AutheliaCtx, targetURL string) string {
+	uri, _ := ctx.ForwardedProtoHost()
+	portalURL := fmt.Sprintf("%s%s/", uri, ctx.Configuration.Server.Path)
+
+	if targetURL != "" {
+		portalURL += "?rd=" + url.QueryEscape(targetURL)
+	}
+
+	return portalURL
+}
+
+// duoRedirectionURL returns the URL the user is redirected to once authenticated with Duo, the same as the one
+// returned by the other second factor methods to the portal.
+func duoRedirectionURL(ctx *middlewares.AutheliaCtx, userSession session.UserSession, targetURL string) string {
+	uri, _ := ctx.ForwardedProtoHost()
+
+	if userSession.OIDCWorkflowSession != nil {
+		switch {
+		case !authorization.IsAuthLevelSufficient(userSession.AuthenticationLevel, userSession.OIDCWorkflowSession.RequiredAuthorizationLevel):
+			return duoPortalURL(ctx, "")
+		case isConsentMissing(userSession.OIDCWorkflowSession,
+			userSession.OIDCWorkflowSession.RequestedScopes, userSession.OIDCWorkflowSession.RequestedAudience):
+			return fmt.Sprintf("%s/consent", uri)
+		default:
+			return userSession.OIDCWorkflowSession.AuthURI
+		}
+	}
+
+	if targetURL != "" {
+		if u, err := url.ParseRequestURI(targetURL); err == nil && isRedirectionSafe(ctx, *u) {
+			return targetURL
+		}
+	}
+
+	if ctx.Configuration.DefaultRedirectionURL != "" {
+		return ctx.Configuration.DefaultRedirectionURL
+	}
+
+	return duoPortalURL(ctx, "")
+}
+
+// redirectDuoFailure logs the error and redirects the user back to the portal to try again.
+func redirectDuoFailure(ctx *middlewares.AutheliaCtx, targetURL string, err error) {
+	ctx.Logger.Error(err)
+	ctx.Redirect(duoPortalURL(ctx, targetURL), fasthttp.StatusFound)
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/duo"
+	"github.com/authelia/authelia/internal/mocks"
+)
+
+const testDuoCallbackURI = "https://login.example.com/api/secondfactor/duo/callback"
+
+type HandlerSignDuoUniversalSuite struct {
+	suite.Suite
+
+	mock   *mocks.MockAutheliaCtx
+	api    *mocks.MockUniversalAPI
+	backup *mocks.MockUniversalAPI
+
+	integrations duo.Integrations
+}
+
+func (s *HandlerSignDuoUniversalSuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.mock.Ctx.Clock = &s.mock.Clock
+	s.mock.Ctx.Request.Header.Set("X-Forwarded-Proto", "https")
+	s.mock.Ctx.Request.Header.Set("X-Forwarded-Host", "login.example.com")
+	s.api = mocks.NewMockUniversalAPI(s.mock.Ctrl)
+	s.backup = mocks.NewMockUniversalAPI(s.mock.Ctrl)
+	s.integrations = duo.Integrations{
+		{Name: "default", UniversalAPI: s.api},
+		{Name: "backup", UniversalAPI: s.backup},
+	}
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.AuthenticationMethods = []string{authnMethodPassword}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+}
+
+func (s *HandlerSignDuoUniversalSuite) TearDownTest() {
+	s.mock.Close()
+}
+
+// setState saves the state of a redirection to the Universal Prompt in session.
+func (s *HandlerSignDuoUniversalSuite) setState(targetURL string) {
+	userSession := s.mock.Ctx.GetSession()
+	userSession.DuoState = "state"
+	userSession.DuoNonce = "nonce"
+	userSession.DuoIntegration = "default"
+	userSession.DuoTargetURL = targetURL
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+}
+
+func (s *HandlerSignDuoUniversalSuite) allow() *duo.IDTokenClaims {
+	claims := &duo.IDTokenClaims{}
+	claims.AuthResult.Status = duo.AuthResultAllow
+
+	return claims
+}
+
+func (s *HandlerSignDuoUniversalSuite) TestShouldReturnAuthURLAndSaveState() {
+	s.api.EXPECT().HealthCheck().Return(nil)
+	s.api.EXPECT().
+		AuthURL(gomock.Eq(testUsername), gomock.Any(), gomock.Any(), gomock.Eq(testDuoCallbackURI)).
+		Return("https://api-123456789.duosecurity.com/oauth/v1/authorize?request=abc", nil)
+
+	s.mock.Ctx.Request.SetBodyString(`{"targetURL":"https://mydomain.local"}`)
+
+	SecondFactorDuoUniversalPost(s.integrations)(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), duoUniversalResponse{URL: "https://api-123456789.duosecurity.com/oauth/v1/authorize?request=abc"})
+
+	userSession := s.mock.Ctx.GetSession()
+	assert.Len(s.T(), userSession.DuoState, 40)
+	assert.Len(s.T(), userSession.DuoNonce, 40)
+	assert.NotEqual(s.T(), userSession.DuoState, userSession.DuoNonce)
+	assert.Equal(s.T(), "default", userSession.DuoIntegration)
+	assert.Equal(s.T(), "https://mydomain.local", userSession.DuoTargetURL)
+}
+
+func (s *HandlerSignDuoUniversalSuite) TestShouldFailoverToNextAvailableIntegration() {
+	s.api.EXPECT().HealthCheck().Return(fmt.Errorf("unable to decode the response with status code 503: EOF"))
+	s.backup.EXPECT().HealthCheck().Return(nil)
+	s.backup.EXPECT().
+		AuthURL(gomock.Eq(testUsername), gomock.Any(), gomock.Any(), gomock.Eq(testDuoCallbackURI)).
+		Return("https://api-987654321.duosecurity.com/oauth/v1/authorize?request=abc", nil)
+
+	s.mock.Ctx.Request.SetBodyString(`{}`)
+
+	SecondFactorDuoUniversalPost(s.integrations)(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), duoUniversalResponse{URL: "https://api-987654321.duosecurity.com/oauth/v1/authorize?request=abc"})
+	assert.Equal(s.T(), "backup", s.mock.Ctx.GetSession().DuoIntegration)
+}
+
+func (s *HandlerSignDuoUniversalSuite) TestShouldFailWhenDuoIsUnavailable() {
+	s.api.EXPECT().HealthCheck().Return(fmt.Errorf("health check failed: Invalid request parameters (client_assertion), error code 40002"))
+	s.backup.EXPECT().HealthCheck().Return(fmt.Errorf("unable to decode the response with status code 503: EOF"))
+
+	s.mock.Ctx.Request.SetBodyString(`{}`)
+
+	SecondFactorDuoUniversalPost(s.integrations)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "No Duo integration is available for user john", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+	assert.Equal(s.T(), "", s.mock.Ctx.GetSession().DuoState)
+}
+
+func (s *HandlerSignDuoUniversalSuite) TestShouldUpgradeToTwoFactorAndRedirectToTargetURL() {
+	s.setState("https://mydomain.local")
+	s.api.EXPECT().
+		ExchangeCode(gomock.Eq("code"), gomock.Eq(testDuoCallbackURI), gomock.Eq(testUsername), gomock.Eq("nonce")).
+		Return(s.allow(), nil)
+
+	s.mock.Ctx.Request.SetRequestURI("/api/secondfactor/duo/callback?state=state&duo_code=code")
+
+	SecondFactorDuoUniversalCallbackGet(s.integrations)(s.mock.Ctx)
+
+	assert.Equal(s.T(), 302, s.mock.Ctx.Response.StatusCode())
+	assert.Equal(s.T(), "https://mydomain.local/", string(s.mock.Ctx.Response.Header.Peek("Location")))
+
+	userSession := s.mock.Ctx.GetSession()
+	assert.Equal(s.T(), authentication.TwoFactor, userSession.AuthenticationLevel)
+	assert.Equal(s.T(), []string{authnMethodPassword, authnMethodDuo}, userSession.AuthenticationMethods)
+	assert.Equal(s.T(), "", userSession.DuoState)
+	assert.Equal(s.T(), "", userSession.DuoNonce)
+}
+
+func (s *HandlerSignDuoUniversalSuite) TestShouldRedirectToDefaultRedirectionURLWithUnsafeTargetURL() {
+	s.setState("http://mydomain.local")
+	s.api.EXPECT().
+		ExchangeCode(gomock.Eq("code"), gomock.Eq(testDuoCallbackURI), gomock.Eq(testUsername), gomock.Eq("nonce")).
+		Return(s.allow(), nil)
+
+	s.mock.Ctx.Configuration.DefaultRedirectionURL = testRedirectionURL
+	s.mock.Ctx.Request.SetRequestURI("/api/secondfactor/duo/callback?state=state&duo_code=code")
+
+	SecondFactorDuoUniversalCallbackGet(s.integrations)(s.mock.Ctx)
+
+	assert.Equal(s.T(), 302, s.mock.Ctx.Response.StatusCode())
+	assert.Equal(s.T(), testRedirectionURL+"/", string(s.mock.Ctx.Response.Header.Peek("Location")))
+}
+
+func (s *HandlerSignDuoUniversalSuite) TestShouldRedirectToPortalWithUnexpectedState() {
+	s.setState("https://mydomain.local")
+
+	s.mock.Ctx.Request.SetRequestURI("/api/secondfactor/duo/callback?state=other&duo_code=code")
+
+	SecondFactorDuoUniversalCallbackGet(s.integrations)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Duo redirected user john with an unexpected state", s.mock.Hook.LastEntry().Message)
+	assert.Equal(s.T(), 302, s.mock.Ctx.Response.StatusCode())
+	assert.Equal(s.T(), "https://login.example.com/?rd=https%3A%2F%2Fmydomain.local", string(s.mock.Ctx.Response.Header.Peek("Location")))
+
+	userSession := s.mock.Ctx.GetSession()
+	assert.Equal(s.T(), authentication.OneFactor, userSession.AuthenticationLevel)
+	assert.Equal(s.T(), "", userSession.DuoState)
+}
+
+func (s *HandlerSignDuoUniversalSuite) TestShouldRedirectToPortalWithoutPendingRequest() {
+	s.mock.Ctx.Request.SetRequestURI("/api/secondfactor/duo/callback?state=&duo_code=code")
+
+	SecondFactorDuoUniversalCallbackGet(s.integrations)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Duo redirected user john with an unexpected state", s.mock.Hook.LastEntry().Message)
+	assert.Equal(s.T(), "https://login.example.com/", string(s.mock.Ctx.Response.Header.Peek("Location")))
+	assert.Equal(s.T(), authentication.OneFactor, s.mock.Ctx.GetSession().AuthenticationLevel)
+}
+
+func (s *HandlerSignDuoUniversalSuite) TestShouldRedirectToPortalWhenDuoDeniesAuthentication() {
+	s.setState("")
+
+	claims := &duo.IDTokenClaims{}
+	claims.AuthResult.Status = "deny"
+	claims.AuthResult.StatusMessage = "Login denied"
+
+	s.api.EXPECT().
+		ExchangeCode(gomock.Eq("code"), gomock.Eq(testDuoCallbackURI), gomock.Eq(testUsername), gomock.Eq("nonce")).
+		Return(claims, nil)
+
+	s.mock.Ctx.Request.SetRequestURI("/api/secondfactor/duo/callback?state=state&duo_code=code")
+
+	SecondFactorDuoUniversalCallbackGet(s.integrations)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Duo denied the authentication of user john: Login denied", s.mock.Hook.LastEntry().Message)
+	assert.Equal(s.T(), "https://login.example.com/", string(s.mock.Ctx.Response.Header.Peek("Location")))
+	assert.Equal(s.T(), authentication.OneFactor, s.mock.Ctx.GetSession().AuthenticationLevel)
+}
+
+func (s *HandlerSignDuoUniversalSuite) TestShouldRedirectToPortalWhenCodeExchangeFails() {
+	s.setState("")
+	s.api.EXPECT().
+		ExchangeCode(gomock.Eq("code"), gomock.Eq(testDuoCallbackURI), gomock.Eq(testUsername), gomock.Eq("nonce")).
+		Return(nil, fmt.Errorf("invalid ID token: the nonce does not match the one of the request"))
+
+	s.mock.Ctx.Request.SetRequestURI("/api/secondfactor/duo/callback?state=state&duo_code=code")
+
+	SecondFactorDuoUniversalCallbackGet(s.integrations)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Unable to exchange the Duo code of user john: invalid ID token: the nonce does not match the one of the request", s.mock.Hook.LastEntry().Message)
+	assert.Equal(s.T(), "https://login.example.com/", string(s.mock.Ctx.Response.Header.Peek("Location")))
+}
+
+func (s *HandlerSignDuoUniversalSuite) TestShouldExchangeCodeWithIntegrationTheUserWasRedirectedTo() {
+	s.setState("")
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.DuoIntegration = "backup"
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+
+	s.backup.EXPECT().
+		ExchangeCode(gomock.Eq("code"), gomock.Eq(testDuoCallbackURI), gomock.Eq(testUsername), gomock.Eq("nonce")).
+		Return(s.allow(), nil)
+
+	s.mock.Ctx.Request.SetRequestURI("/api/secondfactor/duo/callback?state=state&duo_code=code")
+
+	SecondFactorDuoUniversalCallbackGet(s.integrations)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "https://login.example.com/", string(s.mock.Ctx.Response.Header.Peek("Location")))
+	assert.Equal(s.T(), authentication.TwoFactor, s.mock.Ctx.GetSession().AuthenticationLevel)
+}
+
+func TestRunHandlerSignDuoUniversalSuite(t *testing.T) {
+	suite.Run(t, new(HandlerSignDuoUniversalSuite))
+}
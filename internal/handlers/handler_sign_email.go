@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/templates"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// SecondFactorEmailStartPost is the handler sending a one-time code to the email address of the user. The hash of the
+// code is kept in the session of the user until it is used or it expires, sending a new code replaces it.
+func SecondFactorEmailStartPost(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	if len(userSession.Emails) == 0 {
+		ctx.Error(fmt.Errorf("User %s has no email address to send the one-time code to", userSession.Username), operationFailedMessage)
+		return
+	}
+
+	lifespan, err := utils.ParseDurationString(ctx.Configuration.EmailOneTimeCode.Lifespan)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to parse the lifespan of the email one-time codes: %s", err), operationFailedMessage)
+		return
+	}
+
+	code, err := generateEmailOneTimeCode(ctx.Configuration.EmailOneTimeCode.Length)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to generate the email one-time code of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	body := new(bytes.Buffer)
+
+	err = templates.EmailOneTimeCodeTemplate.Execute(body, map[string]interface{}{
+		"code":     code,
+		"lifespan": lifespan.String(),
+		"ip":       ctx.RemoteIP().String(),
+		"time":     ctx.Clock.Now().UTC().Format(time.RFC1123),
+	})
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to render the email one-time code of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	userSession.EmailOneTimeCodeHash = utils.HashSHA256FromString(code)
+	userSession.EmailOneTimeCodeExpiration = ctx.Clock.Now().Add(lifespan).Unix()
+
+	if err = ctx.SaveSession(userSession); err != nil {
+		ctx.Error(fmt.Errorf("Unable to save the email one-time code in the session: %s", err), operationFailedMessage)
+		return
+	}
+
+	ctx.Logger.Debugf("Sending an email one-time code to user %s", userSession.Username)
+
+	if err = ctx.Providers.Notifier.Send(userSession.Emails[0], "Your one-time code", body.String(), ""); err != nil {
+		ctx.Error(fmt.Errorf("Unable to send the email one-time code to user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	ctx.ReplyOK()
+}
+
+// SecondFactorEmailPost is the handler validating the one-time code sent to the email address of the user. The code
+// can only be used once.
+func SecondFactorEmailPost(ctx *middlewares.AutheliaCtx) {
+	requestBody := signEmailRequestBody{}
+
+	if err := ctx.ParseBody(&requestBody); err != nil {
+		handleAuthenticationUnauthorized(ctx, err, mfaValidationFailedMessage)
+		return
+	}
+
+	userSession := ctx.GetSession()
+
+	if isSecondFactorMethodDenied(ctx, userSession, authnMethodEmail) {
+		return
+	}
+
+	if _, err := ctx.Providers.Regulator.Regulate(userSession.Username); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regulate authentication of user %s: %s", userSession.Username, err), userBannedMessage)
+		return
+	}
+
+	if userSession.EmailOneTimeCodeHash == "" {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("No email one-time code was sent to user %s", userSession.Username), mfaValidationFailedMessage)
+		return
+	}
+
+	if ctx.Clock.Now().Unix() > userSession.EmailOneTimeCodeExpiration {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("The email one-time code of user %s has expired", userSession.Username), mfaValidationFailedMessage)
+		return
+	}
+
+	hash := utils.HashSHA256FromString(strings.TrimSpace(requestBody.Code))
+	valid := subtle.ConstantTimeCompare([]byte(hash), []byte(userSession.EmailOneTimeCodeHash)) == 1
+
+	ctx.Logger.Debugf("Mark authentication attempt made by user %s", userSession.Username)
+
+	if err := ctx.Providers.Regulator.Mark(userSession.Username, valid, ctx.RemoteIP(), models.AuthenticationTypeEmail); err != nil {
+		ctx.Logger.Errorf("Unable to mark authentication: %s", err)
+	}
+
+	if !valid {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Wrong email one-time code for user %s", userSession.Username), mfaValidationFailedMessage)
+		return
+	}
+
+	if err := ctx.Providers.SessionProvider.RegenerateSession(ctx.RequestCtx); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regenerate session for user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+		return
+	}
+
+	userSession.EmailOneTimeCodeHash = ""
+	userSession.EmailOneTimeCodeExpiration = 0
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.SecondFactorAuthnTimestamp = ctx.Clock.Now().Unix()
+	userSession.AuthenticationMethods = append(userSession.AuthenticationMethods, authnMethodEmail)
+
+	if err := ctx.SaveSession(userSession); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to update the authentication level with the email one-time code: %s", err), mfaValidationFailedMessage)
+		return
+	}
+
+	if userSession.OIDCWorkflowSession != nil {
+		HandleOIDCWorkflowResponse(ctx)
+	} else {
+		Handle2FAResponse(ctx, requestBody.TargetURL)
+	}
+}
+
+// generateEmailOneTimeCode returns a random numeric one-time code of the given length.
+func generateEmailOneTimeCode(length int) (string, error) {
+	code := make([]byte, length)
+	max := big.NewInt(10)
+
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+
+		code[i] = byte('0' + n.Int64())
+	}
+
+	return string(code), nil
+}
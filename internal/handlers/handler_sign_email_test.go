@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+)
+
+type HandlerSignEmailSuite struct {
+	suite.Suite
+
+	mock *mocks.MockAutheliaCtx
+}
+
+func (s *HandlerSignEmailSuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.mock.Ctx.Clock = &s.mock.Clock
+	s.mock.Ctx.Configuration.EmailOneTimeCode = &schema.EmailOneTimeCodeConfiguration{
+		Length:   8,
+		Lifespan: "5m",
+	}
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.Emails = []string{"john@example.com"}
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.AuthenticationMethods = []string{authnMethodPassword}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+}
+
+func (s *HandlerSignEmailSuite) TearDownTest() {
+	s.mock.Close()
+}
+
+func (s *HandlerSignEmailSuite) expectMark(successful bool) {
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   testUsername,
+			Successful: successful,
+			Time:       s.mock.Clock.Now(),
+			RemoteIP:   "0.0.0.0",
+			Type:       models.AuthenticationTypeEmail,
+		}))
+}
+
+// sendCode sends a one-time code to the user and returns the code found in the email.
+func (s *HandlerSignEmailSuite) sendCode() string {
+	var code string
+
+	s.mock.NotifierMock.EXPECT().
+		Send(gomock.Eq("john@example.com"), gomock.Eq("Your one-time code"), gomock.Any(), gomock.Eq("")).
+		DoAndReturn(func(recipient, subject, body, htmlBody string) error {
+			matches := regexp.MustCompile(`Your one-time code is ([0-9]+), it expires in 5m0s`).FindStringSubmatch(body)
+			s.Require().Len(matches, 2)
+
+			code = matches[1]
+
+			return nil
+		})
+
+	SecondFactorEmailStartPost(s.mock.Ctx)
+
+	assert.Equal(s.T(), 200, s.mock.Ctx.Response.StatusCode())
+	s.Require().Len(code, 8)
+
+	s.mock.Ctx.Response.Reset()
+
+	return code
+}
+
+func (s *HandlerSignEmailSuite) TestShouldUpgradeToTwoFactor() {
+	code := s.sendCode()
+
+	s.expectMark(true)
+
+	s.mock.Ctx.Configuration.DefaultRedirectionURL = testRedirectionURL
+	s.mock.Ctx.Request.SetBodyString(`{"code":"` + code + `"}`)
+
+	SecondFactorEmailPost(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), redirectResponse{Redirect: testRedirectionURL})
+
+	userSession := s.mock.Ctx.GetSession()
+	assert.Equal(s.T(), authentication.TwoFactor, userSession.AuthenticationLevel)
+	assert.Equal(s.T(), []string{authnMethodPassword, authnMethodEmail}, userSession.AuthenticationMethods)
+	assert.Equal(s.T(), "", userSession.EmailOneTimeCodeHash)
+}
+
+func (s *HandlerSignEmailSuite) TestShouldFailWithWrongCode() {
+	s.sendCode()
+
+	s.expectMark(false)
+
+	s.mock.Ctx.Request.SetBodyString(`{"code":"abc"}`)
+
+	SecondFactorEmailPost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Wrong email one-time code for user john", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+	assert.Equal(s.T(), authentication.OneFactor, s.mock.Ctx.GetSession().AuthenticationLevel)
+}
+
+func (s *HandlerSignEmailSuite) TestShouldFailWithExpiredCode() {
+	code := s.sendCode()
+
+	s.mock.Clock.Set(s.mock.Clock.Now().Add(6 * time.Minute))
+	s.mock.Ctx.Request.SetBodyString(`{"code":"` + code + `"}`)
+
+	SecondFactorEmailPost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "The email one-time code of user john has expired", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func (s *HandlerSignEmailSuite) TestShouldFailWhenNoCodeWasSent() {
+	s.mock.Ctx.Request.SetBodyString(`{"code":"12345678"}`)
+
+	SecondFactorEmailPost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "No email one-time code was sent to user john", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func (s *HandlerSignEmailSuite) TestShouldFailToSendCodeWithoutEmailAddress() {
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Emails = nil
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+
+	SecondFactorEmailStartPost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "User john has no email address to send the one-time code to", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), operationFailedMessage)
+}
+
+func TestRunHandlerSignEmailSuite(t *testing.T) {
+	suite.Run(t, new(HandlerSignEmailSuite))
+}
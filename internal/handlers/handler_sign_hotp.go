@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+)
+
+// SecondFactorHOTPPost is the handler validating the counter-based one-time password of the HOTP device of the user.
+// When the next token is provided, the device is resynchronized with the two consecutive one-time passwords.
+func SecondFactorHOTPPost(hotpVerifier HOTPVerifier) middlewares.RequestHandler {
+	return func(ctx *middlewares.AutheliaCtx) {
+		requestBody := signHOTPRequestBody{}
+
+		if err := ctx.ParseBody(&requestBody); err != nil {
+			handleAuthenticationUnauthorized(ctx, err, mfaValidationFailedMessage)
+			return
+		}
+
+		userSession := ctx.GetSession()
+
+		if isSecondFactorMethodDenied(ctx, userSession, authnMethodHOTP) {
+			return
+		}
+
+		if _, err := ctx.Providers.Regulator.Regulate(userSession.Username); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regulate authentication of user %s: %s", userSession.Username, err), userBannedMessage)
+			return
+		}
+
+		device, err := ctx.Providers.StorageProvider.LoadHOTPDevice(userSession.Username)
+		if err != nil {
+			if err == storage.ErrNoHOTPDevice {
+				handleAuthenticationUnauthorized(ctx, fmt.Errorf("No HOTP device is registered for user %s", userSession.Username), mfaValidationFailedMessage)
+				return
+			}
+
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to load the HOTP device of user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+
+			return
+		}
+
+		resync := requestBody.NextToken != ""
+
+		var (
+			next  uint64
+			valid bool
+		)
+
+		if resync {
+			next, valid, err = hotpVerifier.Resync(requestBody.Token, requestBody.NextToken, *device)
+		} else {
+			next, valid, err = hotpVerifier.Verify(requestBody.Token, *device)
+		}
+
+		if err != nil {
+			markHOTPAttempt(ctx, userSession.Username, false)
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Error occurred during HOTP validation for user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+
+			return
+		}
+
+		if valid {
+			// The counter is only moved forward if no other request used it in the meantime so that every one-time
+			// password can only be used once.
+			if valid, err = ctx.Providers.StorageProvider.UpdateHOTPCounter(userSession.Username, device.Counter, next); err != nil {
+				handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to update the HOTP counter of user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+				return
+			}
+		}
+
+		markHOTPAttempt(ctx, userSession.Username, valid)
+
+		if !valid {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Wrong passcode during HOTP validation for user %s", userSession.Username), mfaValidationFailedMessage)
+			return
+		}
+
+		if resync {
+			appendAuditEvent(ctx, userSession.Username, models.AuditEventHOTPResynchronized, fmt.Sprintf("counter=%d", next))
+		}
+
+		if err = ctx.Providers.SessionProvider.RegenerateSession(ctx.RequestCtx); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regenerate session for user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+			return
+		}
+
+		userSession.AuthenticationLevel = authentication.TwoFactor
+		userSession.SecondFactorAuthnTimestamp = ctx.Clock.Now().Unix()
+		userSession.AuthenticationMethods = append(userSession.AuthenticationMethods, authnMethodHOTP)
+
+		if err = ctx.SaveSession(userSession); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to update the authentication level with HOTP: %s", err), mfaValidationFailedMessage)
+			return
+		}
+
+		if userSession.OIDCWorkflowSession != nil {
+			HandleOIDCWorkflowResponse(ctx)
+		} else {
+			Handle2FAResponse(ctx, requestBody.TargetURL)
+		}
+	}
+}
+
+func markHOTPAttempt(ctx *middlewares.AutheliaCtx, username string, successful bool) {
+	ctx.Logger.Debugf("Mark authentication attempt made by user %s", username)
+
+	if err := ctx.Providers.Regulator.Mark(username, successful, ctx.RemoteIP(), models.AuthenticationTypeHOTP); err != nil {
+		ctx.Logger.Errorf("Unable to mark authentication: %s", err)
+	}
+}
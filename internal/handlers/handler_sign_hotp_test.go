@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pquerna/otp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+)
+
+type HandlerSignHOTPSuite struct {
+	suite.Suite
+
+	mock     *mocks.MockAutheliaCtx
+	verifier *HOTPVerifierImpl
+}
+
+func (s *HandlerSignHOTPSuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.mock.Ctx.Clock = &s.mock.Clock
+	s.verifier = &HOTPVerifierImpl{LookAhead: 2, ResyncWindow: 50}
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.AuthenticationMethods = []string{authnMethodPassword}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+}
+
+func (s *HandlerSignHOTPSuite) TearDownTest() {
+	s.mock.Close()
+}
+
+func (s *HandlerSignHOTPSuite) expectDevice() {
+	s.mock.StorageProviderMock.EXPECT().
+		LoadHOTPDevice(gomock.Eq(testUsername)).
+		Return(&models.HOTPDevice{Username: testUsername, Secret: testHOTPSecret, Counter: 5}, nil)
+}
+
+func (s *HandlerSignHOTPSuite) expectMark(successful bool) {
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   testUsername,
+			Successful: successful,
+			Time:       s.mock.Clock.Now(),
+			RemoteIP:   "0.0.0.0",
+			Type:       models.AuthenticationTypeHOTP,
+		}))
+}
+
+func (s *HandlerSignHOTPSuite) TestShouldUpgradeToTwoFactorAndMoveCounterForward() {
+	s.expectDevice()
+	s.mock.StorageProviderMock.EXPECT().
+		UpdateHOTPCounter(gomock.Eq(testUsername), gomock.Eq(uint64(5)), gomock.Eq(uint64(7))).
+		Return(true, nil)
+	s.expectMark(true)
+
+	s.mock.Ctx.Configuration.DefaultRedirectionURL = testRedirectionURL
+	s.mock.Ctx.Request.SetBodyString(fmt.Sprintf(`{"token":"%s"}`, hotpCode(s.T(), 6, otp.DigitsSix)))
+
+	SecondFactorHOTPPost(s.verifier)(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), redirectResponse{Redirect: testRedirectionURL})
+
+	userSession := s.mock.Ctx.GetSession()
+	assert.Equal(s.T(), authentication.TwoFactor, userSession.AuthenticationLevel)
+	assert.Equal(s.T(), []string{authnMethodPassword, authnMethodHOTP}, userSession.AuthenticationMethods)
+}
+
+func (s *HandlerSignHOTPSuite) TestShouldFailWithTokenBeyondLookAhead() {
+	s.expectDevice()
+	s.expectMark(false)
+
+	s.mock.Ctx.Request.SetBodyString(fmt.Sprintf(`{"token":"%s"}`, hotpCode(s.T(), 20, otp.DigitsSix)))
+
+	SecondFactorHOTPPost(s.verifier)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Wrong passcode during HOTP validation for user john", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func (s *HandlerSignHOTPSuite) TestShouldFailWhenCounterWasMovedConcurrently() {
+	s.expectDevice()
+	s.mock.StorageProviderMock.EXPECT().
+		UpdateHOTPCounter(gomock.Eq(testUsername), gomock.Eq(uint64(5)), gomock.Eq(uint64(6))).
+		Return(false, nil)
+	s.expectMark(false)
+
+	s.mock.Ctx.Request.SetBodyString(fmt.Sprintf(`{"token":"%s"}`, hotpCode(s.T(), 5, otp.DigitsSix)))
+
+	SecondFactorHOTPPost(s.verifier)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Wrong passcode during HOTP validation for user john", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func (s *HandlerSignHOTPSuite) TestShouldResyncWithConsecutiveTokens() {
+	s.expectDevice()
+	s.mock.StorageProviderMock.EXPECT().
+		UpdateHOTPCounter(gomock.Eq(testUsername), gomock.Eq(uint64(5)), gomock.Eq(uint64(32))).
+		Return(true, nil)
+	s.expectMark(true)
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuditEvent(gomock.Eq(models.AuditEvent{
+			Username: testUsername,
+			Type:     models.AuditEventHOTPResynchronized,
+			RemoteIP: "0.0.0.0",
+			Details:  "counter=32",
+			Time:     s.mock.Clock.Now(),
+		})).
+		Return(nil)
+
+	s.mock.Ctx.Configuration.DefaultRedirectionURL = testRedirectionURL
+	s.mock.Ctx.Request.SetBodyString(fmt.Sprintf(`{"token":"%s","nextToken":"%s"}`,
+		hotpCode(s.T(), 30, otp.DigitsSix), hotpCode(s.T(), 31, otp.DigitsSix)))
+
+	SecondFactorHOTPPost(s.verifier)(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), redirectResponse{Redirect: testRedirectionURL})
+	assert.Equal(s.T(), authentication.TwoFactor, s.mock.Ctx.GetSession().AuthenticationLevel)
+}
+
+func (s *HandlerSignHOTPSuite) TestShouldFailWhenNoDeviceIsRegistered() {
+	s.mock.StorageProviderMock.EXPECT().
+		LoadHOTPDevice(gomock.Eq(testUsername)).
+		Return(nil, storage.ErrNoHOTPDevice)
+
+	s.mock.Ctx.Request.SetBodyString(`{"token":"123456"}`)
+
+	SecondFactorHOTPPost(s.verifier)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "No HOTP device is registered for user john", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func TestRunHandlerSignHOTPSuite(t *testing.T) {
+	suite.Run(t, new(HandlerSignHOTPSuite))
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/session"
+)
+
+// isPasswordRequired returns true if the user signed in with a passkey used as a first factor and has yet to enter their
+// password to reach the two-factor authentication level.
+func isPasswordRequired(userSession session.UserSession) bool {
+	return userSession.AuthenticationLevel == authentication.OneFactor &&
+		len(userSession.AuthenticationMethods) == 1 && userSession.AuthenticationMethods[0] == authnMethodPasskey
+}
+
+// SecondFactorPasswordPost validates the password of the user who signed in with a passkey used as a first factor.
+func SecondFactorPasswordPost(ctx *middlewares.AutheliaCtx) {
+	requestBody := signPasswordRequestBody{}
+
+	if err := ctx.ParseBody(&requestBody); err != nil {
+		handleAuthenticationUnauthorized(ctx, err, mfaValidationFailedMessage)
+		return
+	}
+
+	userSession := ctx.GetSession()
+
+	if !isPasswordRequired(userSession) {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("User %s did not sign in with a passkey as first factor", userSession.Username), mfaValidationFailedMessage)
+		return
+	}
+
+	if _, err := ctx.Providers.Regulator.Regulate(userSession.Username); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regulate authentication of user %s: %s", userSession.Username, err), userBannedMessage)
+		return
+	}
+
+	valid, err := ctx.Providers.UserProvider.CheckUserPassword(userSession.Username, requestBody.Password)
+
+	ctx.Logger.Debugf("Mark authentication attempt made by user %s", userSession.Username)
+
+	if markErr := ctx.Providers.Regulator.Mark(userSession.Username, err == nil && valid, ctx.RemoteIP(), models.AuthenticationTypeFirstFactor); markErr != nil {
+		ctx.Logger.Errorf("Unable to mark authentication: %s", markErr)
+	}
+
+	if err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Error while checking password for user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+		return
+	}
+
+	if !valid {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Credentials are wrong for user %s", userSession.Username), mfaValidationFailedMessage)
+		return
+	}
+
+	if err = ctx.Providers.SessionProvider.RegenerateSession(ctx.RequestCtx); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regenerate session for user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+		return
+	}
+
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.SecondFactorAuthnTimestamp = ctx.Clock.Now().Unix()
+	userSession.AuthenticationMethods = append(userSession.AuthenticationMethods, authnMethodPassword)
+
+	if err = ctx.SaveSession(userSession); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to update the authentication level with the password: %s", err), mfaValidationFailedMessage)
+		return
+	}
+
+	if userSession.OIDCWorkflowSession != nil {
+		HandleOIDCWorkflowResponse(ctx)
+	} else {
+		Handle2FAResponse(ctx, requestBody.TargetURL)
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+)
+
+type HandlerSignPasswordSuite struct {
+	suite.Suite
+
+	mock *mocks.MockAutheliaCtx
+}
+
+func (s *HandlerSignPasswordSuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.mock.Ctx.Clock = &s.mock.Clock
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.AuthenticationMethods = []string{authnMethodPasskey}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+}
+
+func (s *HandlerSignPasswordSuite) TearDownTest() {
+	s.mock.Close()
+}
+
+func (s *HandlerSignPasswordSuite) expectMark(successful bool) {
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   testUsername,
+			Successful: successful,
+			Time:       s.mock.Clock.Now(),
+			RemoteIP:   "0.0.0.0",
+			Type:       models.AuthenticationTypeFirstFactor,
+		}))
+}
+
+func (s *HandlerSignPasswordSuite) TestShouldUpgradeToTwoFactor() {
+	s.mock.UserProviderMock.EXPECT().
+		CheckUserPassword(gomock.Eq(testUsername), gomock.Eq("password")).
+		Return(true, nil)
+
+	s.expectMark(true)
+
+	s.mock.Ctx.Configuration.DefaultRedirectionURL = testRedirectionURL
+	s.mock.Ctx.Request.SetBodyString(`{"password":"password"}`)
+
+	SecondFactorPasswordPost(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), redirectResponse{Redirect: testRedirectionURL})
+
+	userSession := s.mock.Ctx.GetSession()
+	assert.Equal(s.T(), authentication.TwoFactor, userSession.AuthenticationLevel)
+	assert.Equal(s.T(), []string{authnMethodPasskey, authnMethodPassword}, userSession.AuthenticationMethods)
+	assert.Equal(s.T(), s.mock.Clock.Now().Unix(), userSession.SecondFactorAuthnTimestamp)
+}
+
+func (s *HandlerSignPasswordSuite) TestShouldFailWithWrongPassword() {
+	s.mock.UserProviderMock.EXPECT().
+		CheckUserPassword(gomock.Eq(testUsername), gomock.Eq("wrong")).
+		Return(false, nil)
+
+	s.expectMark(false)
+
+	s.mock.Ctx.Request.SetBodyString(`{"password":"wrong"}`)
+
+	SecondFactorPasswordPost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Credentials are wrong for user john", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+	assert.Equal(s.T(), authentication.OneFactor, s.mock.Ctx.GetSession().AuthenticationLevel)
+}
+
+func (s *HandlerSignPasswordSuite) TestShouldFailIfUserDidNotSignInWithPasskey() {
+	userSession := s.mock.Ctx.GetSession()
+	userSession.AuthenticationMethods = []string{authnMethodPassword}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+
+	s.mock.Ctx.Request.SetBodyString(`{"password":"password"}`)
+
+	SecondFactorPasswordPost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "User john did not sign in with a passkey as first factor", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func TestRunHandlerSignPasswordSuite(t *testing.T) {
+	suite.Run(t, new(HandlerSignPasswordSuite))
+}
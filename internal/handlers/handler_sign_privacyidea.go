@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/privacyidea"
+	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// privacyIDEAPollInterval is the interval between the checks of the push challenges.
+const privacyIDEAPollInterval = time.Second
+
+// PrivacyIDEATokensGet is the handler returning whether the user enrolled tokens in privacyIDEA and their types, so the
+// portal only offers the ways to sign in the user can use.
+func PrivacyIDEATokensGet(api privacyidea.API) middlewares.RequestHandler {
+	return func(ctx *middlewares.AutheliaCtx) {
+		userSession := ctx.GetSession()
+
+		tokens, err := api.Tokens(userSession.Username)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to list the privacyIDEA tokens of user %s: %s", userSession.Username, err), operationFailedMessage)
+			return
+		}
+
+		response := privacyIDEATokensResponse{Types: []string{}}
+
+		for _, token := range tokens {
+			if !token.Usable() {
+				continue
+			}
+
+			response.Enrolled = true
+
+			if !utils.IsStringInSlice(token.TokenType, response.Types) {
+				response.Types = append(response.Types, token.TokenType)
+			}
+		}
+
+		sort.Strings(response.Types)
+
+		if err = ctx.SetJSONBody(response); err != nil {
+			ctx.Logger.Errorf("Unable to set the privacyIDEA tokens in body: %s", err)
+		}
+	}
+}
+
+// SecondFactorPrivacyIDEAPost is the handler validating the one-time password of one of the privacyIDEA tokens of the
+// user.
+func SecondFactorPrivacyIDEAPost(api privacyidea.API) middlewares.RequestHandler {
+	return func(ctx *middlewares.AutheliaCtx) {
+		requestBody := signPrivacyIDEARequestBody{}
+
+		if err := ctx.ParseBody(&requestBody); err != nil {
+			handleAuthenticationUnauthorized(ctx, err, mfaValidationFailedMessage)
+			return
+		}
+
+		userSession := ctx.GetSession()
+
+		if isSecondFactorMethodDenied(ctx, userSession, authnMethodPrivacyIDEA) {
+			return
+		}
+
+		if _, err := ctx.Providers.Regulator.Regulate(userSession.Username); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regulate authentication of user %s: %s", userSession.Username, err), userBannedMessage)
+			return
+		}
+
+		if requestBody.Token == "" {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("No privacyIDEA one-time password was provided by user %s", userSession.Username), mfaValidationFailedMessage)
+			return
+		}
+
+		response, err := api.Check(userSession.Username, requestBody.Token, "")
+		valid := err == nil && response.Result.Value
+
+		markPrivacyIDEAAttempt(ctx, userSession.Username, valid)
+
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to check the privacyIDEA one-time password of user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+			return
+		}
+
+		if !valid {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Wrong privacyIDEA one-time password for user %s: %s", userSession.Username, response.Detail.Message), mfaValidationFailedMessage)
+			return
+		}
+
+		completePrivacyIDEASignIn(ctx, userSession, requestBody.TargetURL)
+	}
+}
+
+// SecondFactorPrivacyIDEAPushPost is the handler triggering the push challenges of the privacyIDEA tokens of the user
+// and waiting for the user to accept one of them, like the Duo push notifications.
+func SecondFactorPrivacyIDEAPushPost(api privacyidea.API) middlewares.RequestHandler {
+	return func(ctx *middlewares.AutheliaCtx) {
+		requestBody := signPrivacyIDEARequestBody{}
+
+		if err := ctx.ParseBody(&requestBody); err != nil {
+			handleAuthenticationUnauthorized(ctx, err, mfaValidationFailedMessage)
+			return
+		}
+
+		userSession := ctx.GetSession()
+
+		if isSecondFactorMethodDenied(ctx, userSession, authnMethodPrivacyIDEA) {
+			return
+		}
+
+		if _, err := ctx.Providers.Regulator.Regulate(userSession.Username); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regulate authentication of user %s: %s", userSession.Username, err), userBannedMessage)
+			return
+		}
+
+		timeout, err := utils.ParseDurationString(ctx.Configuration.PrivacyIDEA.PushTimeout)
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to parse the privacyIDEA push timeout: %s", err), mfaValidationFailedMessage)
+			return
+		}
+
+		// An empty pass triggers the challenges of the tokens of the user.
+		challenge, err := api.Check(userSession.Username, "", "")
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to trigger the privacyIDEA push challenge of user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+			return
+		}
+
+		if challenge.Detail.TransactionID == "" {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("No privacyIDEA push challenge was triggered for user %s: %s", userSession.Username, challenge.Detail.Message), mfaValidationFailedMessage)
+			return
+		}
+
+		ctx.Logger.Debugf("Waiting for user %s to accept the privacyIDEA push challenge %s", userSession.Username, challenge.Detail.TransactionID)
+
+		deadline := ctx.Clock.Now().Add(timeout)
+		accepted := false
+
+		for !accepted && ctx.Clock.Now().Before(deadline) {
+			if accepted, err = api.PollTransaction(challenge.Detail.TransactionID); err != nil {
+				handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to poll the privacyIDEA push challenge of user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+				return
+			}
+
+			if !accepted {
+				<-ctx.Clock.After(privacyIDEAPollInterval)
+			}
+		}
+
+		valid := false
+
+		if accepted {
+			// The transaction must be checked once accepted for privacyIDEA to consider the authentication complete.
+			response, err := api.Check(userSession.Username, "", challenge.Detail.TransactionID)
+			if err != nil {
+				ctx.Logger.Errorf("Unable to complete the privacyIDEA push challenge of user %s: %s", userSession.Username, err)
+			}
+
+			valid = err == nil && response.Result.Value
+		}
+
+		markPrivacyIDEAAttempt(ctx, userSession.Username, valid)
+
+		if !valid {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("The privacyIDEA push challenge of user %s was not accepted", userSession.Username), mfaValidationFailedMessage)
+			return
+		}
+
+		completePrivacyIDEASignIn(ctx, userSession, requestBody.TargetURL)
+	}
+}
+
+func markPrivacyIDEAAttempt(ctx *middlewares.AutheliaCtx, username string, successful bool) {
+	ctx.Logger.Debugf("Mark authentication attempt made by user %s", username)
+
+	if err := ctx.Providers.Regulator.Mark(username, successful, ctx.RemoteIP(), models.AuthenticationTypePrivacyIDEA); err != nil {
+		ctx.Logger.Errorf("Unable to mark authentication: %s", err)
+	}
+}
+
+// completePrivacyIDEASignIn upgrades the session of the user to two factors once privacyIDEA validated the token.
+func completePrivacyIDEASignIn(ctx *middlewares.AutheliaCtx, userSession session.UserSession, targetURL string) {
+	if err := ctx.Providers.SessionProvider.RegenerateSession(ctx.RequestCtx); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regenerate session for user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+		return
+	}
+
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.SecondFactorAuthnTimestamp = ctx.Clock.Now().Unix()
+	userSession.AuthenticationMethods = append(userSession.AuthenticationMethods, authnMethodPrivacyIDEA)
+
+	if err := ctx.SaveSession(userSession); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to update the authentication level with privacyIDEA: %s", err), mfaValidationFailedMessage)
+		return
+	}
+
+	if userSession.OIDCWorkflowSession != nil {
+		HandleOIDCWorkflowResponse(ctx)
+	} else {
+		Handle2FAResponse(ctx, targetURL)
+	}
+}
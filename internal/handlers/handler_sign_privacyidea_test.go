@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/privacyidea"
+)
+
+type HandlerSignPrivacyIDEASuite struct {
+	suite.Suite
+
+	mock *mocks.MockAutheliaCtx
+	api  *mocks.MockPrivacyIDEAAPI
+}
+
+func (s *HandlerSignPrivacyIDEASuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.mock.Ctx.Clock = &s.mock.Clock
+	s.mock.Ctx.Configuration.PrivacyIDEA = &schema.PrivacyIDEAConfiguration{PushTimeout: "60s"}
+	s.api = mocks.NewMockPrivacyIDEAAPI(s.mock.Ctrl)
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.AuthenticationMethods = []string{authnMethodPassword}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+}
+
+func (s *HandlerSignPrivacyIDEASuite) TearDownTest() {
+	s.mock.Close()
+}
+
+func (s *HandlerSignPrivacyIDEASuite) expectMark(successful bool) {
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   testUsername,
+			Successful: successful,
+			Time:       s.mock.Clock.Now(),
+			RemoteIP:   "0.0.0.0",
+			Type:       models.AuthenticationTypePrivacyIDEA,
+		}))
+}
+
+func privacyIDEACheckResponse(value bool, transactionID string) *privacyidea.CheckResponse {
+	response := &privacyidea.CheckResponse{}
+	response.Result.Status = true
+	response.Result.Value = value
+	response.Detail.TransactionID = transactionID
+
+	return response
+}
+
+func (s *HandlerSignPrivacyIDEASuite) TestShouldReturnTypesOfUsableTokens() {
+	s.api.EXPECT().
+		Tokens(gomock.Eq(testUsername)).
+		Return([]privacyidea.Token{
+			{Serial: "TOTP0001", TokenType: "totp", Active: true},
+			{Serial: "PIPU0001", TokenType: "push", Active: true},
+			{Serial: "HOTP0001", TokenType: "hotp", Active: true, Revoked: true},
+			{Serial: "TOTP0002", TokenType: "totp", Active: true},
+		}, nil)
+
+	PrivacyIDEATokensGet(s.api)(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), privacyIDEATokensResponse{Enrolled: true, Types: []string{"push", "totp"}})
+}
+
+func (s *HandlerSignPrivacyIDEASuite) TestShouldReturnUserIsNotEnrolled() {
+	s.api.EXPECT().
+		Tokens(gomock.Eq(testUsername)).
+		Return([]privacyidea.Token{{Serial: "TOTP0001", TokenType: "totp", Locked: true}}, nil)
+
+	PrivacyIDEATokensGet(s.api)(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), privacyIDEATokensResponse{Enrolled: false, Types: []string{}})
+}
+
+func (s *HandlerSignPrivacyIDEASuite) TestShouldUpgradeToTwoFactorWithOneTimePassword() {
+	s.api.EXPECT().
+		Check(gomock.Eq(testUsername), gomock.Eq("123456"), gomock.Eq("")).
+		Return(privacyIDEACheckResponse(true, ""), nil)
+
+	s.expectMark(true)
+
+	s.mock.Ctx.Configuration.DefaultRedirectionURL = testRedirectionURL
+	s.mock.Ctx.Request.SetBodyString(`{"token":"123456"}`)
+
+	SecondFactorPrivacyIDEAPost(s.api)(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), redirectResponse{Redirect: testRedirectionURL})
+
+	userSession := s.mock.Ctx.GetSession()
+	assert.Equal(s.T(), authentication.TwoFactor, userSession.AuthenticationLevel)
+	assert.Equal(s.T(), []string{authnMethodPassword, authnMethodPrivacyIDEA}, userSession.AuthenticationMethods)
+}
+
+func (s *HandlerSignPrivacyIDEASuite) TestShouldFailWithWrongOneTimePassword() {
+	response := privacyIDEACheckResponse(false, "")
+	response.Detail.Message = "wrong otp value"
+
+	s.api.EXPECT().
+		Check(gomock.Eq(testUsername), gomock.Eq("123456"), gomock.Eq("")).
+		Return(response, nil)
+
+	s.expectMark(false)
+
+	s.mock.Ctx.Request.SetBodyString(`{"token":"123456"}`)
+
+	SecondFactorPrivacyIDEAPost(s.api)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Wrong privacyIDEA one-time password for user john: wrong otp value", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func (s *HandlerSignPrivacyIDEASuite) TestShouldFailWhenPrivacyIDEAErrors() {
+	s.api.EXPECT().
+		Check(gomock.Eq(testUsername), gomock.Eq("123456"), gomock.Eq("")).
+		Return(nil, fmt.Errorf("privacyIDEA error 904: The user can not be found"))
+
+	s.expectMark(false)
+
+	s.mock.Ctx.Request.SetBodyString(`{"token":"123456"}`)
+
+	SecondFactorPrivacyIDEAPost(s.api)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Unable to check the privacyIDEA one-time password of user john: privacyIDEA error 904: The user can not be found", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func (s *HandlerSignPrivacyIDEASuite) TestShouldUpgradeToTwoFactorWithAcceptedPush() {
+	gomock.InOrder(
+		s.api.EXPECT().
+			Check(gomock.Eq(testUsername), gomock.Eq(""), gomock.Eq("")).
+			Return(privacyIDEACheckResponse(false, "12345"), nil),
+		s.api.EXPECT().
+			PollTransaction(gomock.Eq("12345")).
+			Return(true, nil),
+		s.api.EXPECT().
+			Check(gomock.Eq(testUsername), gomock.Eq(""), gomock.Eq("12345")).
+			Return(privacyIDEACheckResponse(true, ""), nil),
+	)
+
+	s.expectMark(true)
+
+	s.mock.Ctx.Configuration.DefaultRedirectionURL = testRedirectionURL
+	s.mock.Ctx.Request.SetBodyString(`{}`)
+
+	SecondFactorPrivacyIDEAPushPost(s.api)(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), redirectResponse{Redirect: testRedirectionURL})
+	assert.Equal(s.T(), authentication.TwoFactor, s.mock.Ctx.GetSession().AuthenticationLevel)
+}
+
+func (s *HandlerSignPrivacyIDEASuite) TestShouldFailWhenPushIsNotAcceptedInTime() {
+	s.mock.Ctx.Configuration.PrivacyIDEA.PushTimeout = "0"
+
+	s.api.EXPECT().
+		Check(gomock.Eq(testUsername), gomock.Eq(""), gomock.Eq("")).
+		Return(privacyIDEACheckResponse(false, "12345"), nil)
+
+	s.expectMark(false)
+
+	s.mock.Ctx.Request.SetBodyString(`{}`)
+
+	SecondFactorPrivacyIDEAPushPost(s.api)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "The privacyIDEA push challenge of user john was not accepted", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func (s *HandlerSignPrivacyIDEASuite) TestShouldFailWhenNoPushChallengeIsTriggered() {
+	response := privacyIDEACheckResponse(false, "")
+	response.Detail.Message = "The user has no tokens assigned"
+
+	s.api.EXPECT().
+		Check(gomock.Eq(testUsername), gomock.Eq(""), gomock.Eq("")).
+		Return(response, nil)
+
+	s.mock.Ctx.Request.SetBodyString(`{}`)
+
+	SecondFactorPrivacyIDEAPushPost(s.api)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "No privacyIDEA push challenge was triggered for user john: The user has no tokens assigned", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func TestRunHandlerSignPrivacyIDEASuite(t *testing.T) {
+	suite.Run(t, new(HandlerSignPrivacyIDEASuite))
+}
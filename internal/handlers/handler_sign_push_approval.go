@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/pushapproval"
+)
+
+// SecondFactorPushApprovalStartPost is the handler pushing an approval request to the device of the user. The
+// identifier of the request is kept in the session of the user until it is answered or it expires.
+func SecondFactorPushApprovalStartPost(provider pushapproval.Provider) middlewares.RequestHandler {
+	return func(ctx *middlewares.AutheliaCtx) {
+		requestBody := signPushApprovalRequestBody{}
+
+		if err := ctx.ParseBody(&requestBody); err != nil {
+			handleAuthenticationUnauthorized(ctx, err, mfaValidationFailedMessage)
+			return
+		}
+
+		userSession := ctx.GetSession()
+
+		if _, err := ctx.Providers.Regulator.Regulate(userSession.Username); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regulate authentication of user %s: %s", userSession.Username, err), userBannedMessage)
+			return
+		}
+
+		ctx.Logger.Debugf("Pushing an approval request to user %s from IP %s", userSession.Username, ctx.RemoteIP().String())
+
+		id, err := provider.Send(userSession.Username, ctx.RemoteIP().String(), requestBody.TargetURL)
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to push the approval request of user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+			return
+		}
+
+		userSession.PushApprovalRequestID = id
+
+		if err = ctx.SaveSession(userSession); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to save the approval request in the session: %s", err), mfaValidationFailedMessage)
+			return
+		}
+
+		ctx.ReplyOK()
+	}
+}
+
+// SecondFactorPushApprovalPost is the handler polled by the portal until the approval request pushed to the user is
+// answered. The session is upgraded to two factors once the request is approved.
+func SecondFactorPushApprovalPost(provider pushapproval.Provider) middlewares.RequestHandler {
+	return func(ctx *middlewares.AutheliaCtx) {
+		requestBody := signPushApprovalRequestBody{}
+
+		if err := ctx.ParseBody(&requestBody); err != nil {
+			handleAuthenticationUnauthorized(ctx, err, mfaValidationFailedMessage)
+			return
+		}
+
+		userSession := ctx.GetSession()
+
+		if isSecondFactorMethodDenied(ctx, userSession, authnMethodPushApproval) {
+			return
+		}
+
+		if userSession.PushApprovalRequestID == "" {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("No approval request was pushed to user %s", userSession.Username), mfaValidationFailedMessage)
+			return
+		}
+
+		status := provider.Status(userSession.PushApprovalRequestID, userSession.Username)
+
+		if status == pushapproval.StatusPending {
+			if err := ctx.SetJSONBody(pushApprovalStatusResponse{Status: status}); err != nil {
+				ctx.Logger.Errorf("Unable to set the status of the approval request in body: %s", err)
+			}
+
+			return
+		}
+
+		userSession.PushApprovalRequestID = ""
+
+		ctx.Logger.Debugf("Mark authentication attempt made by user %s", userSession.Username)
+
+		if err := ctx.Providers.Regulator.Mark(userSession.Username, status == pushapproval.StatusApproved, ctx.RemoteIP(), models.AuthenticationTypePushApproval); err != nil {
+			ctx.Logger.Errorf("Unable to mark authentication: %s", err)
+		}
+
+		if status != pushapproval.StatusApproved {
+			if err := ctx.SaveSession(userSession); err != nil {
+				ctx.Logger.Errorf("Unable to remove the approval request from the session: %s", err)
+			}
+
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("The approval request of user %s is %s", userSession.Username, status), mfaValidationFailedMessage)
+
+			return
+		}
+
+		if err := ctx.Providers.SessionProvider.RegenerateSession(ctx.RequestCtx); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regenerate session for user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+			return
+		}
+
+		userSession.AuthenticationLevel = authentication.TwoFactor
+		userSession.SecondFactorAuthnTimestamp = ctx.Clock.Now().Unix()
+		userSession.AuthenticationMethods = append(userSession.AuthenticationMethods, authnMethodPushApproval)
+
+		if err := ctx.SaveSession(userSession); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to update the authentication level with the push approval: %s", err), mfaValidationFailedMessage)
+			return
+		}
+
+		if userSession.OIDCWorkflowSession != nil {
+			HandleOIDCWorkflowResponse(ctx)
+		} else {
+			Handle2FAResponse(ctx, requestBody.TargetURL)
+		}
+	}
+}
+
+// PushApprovalCallbackPost is the handler receiving the answer of the user to an approval request. The callback is
+// not made by the browser of the user so it is authenticated by the signature of its body with the shared secret.
+func PushApprovalCallbackPost(provider pushapproval.Provider) middlewares.RequestHandler {
+	return func(ctx *middlewares.AutheliaCtx) {
+		body := ctx.PostBody()
+
+		if !provider.VerifySignature(body, string(ctx.Request.Header.Peek(pushapproval.SignatureHeader))) {
+			ctx.Logger.Errorf("Invalid signature of the push approval callback from IP %s", ctx.RemoteIP().String())
+			ctx.ReplyUnauthorized()
+
+			return
+		}
+
+		callback := pushapproval.Callback{}
+
+		if err := json.Unmarshal(body, &callback); err != nil {
+			ctx.Error(fmt.Errorf("Unable to parse the push approval callback: %s", err), operationFailedMessage)
+			return
+		}
+
+		if err := provider.Respond(callback.ID, callback.Approved); err != nil {
+			ctx.Error(fmt.Errorf("Unable to answer the approval request %s: %s", callback.ID, err), operationFailedMessage)
+			return
+		}
+
+		ctx.ReplyOK()
+	}
+}
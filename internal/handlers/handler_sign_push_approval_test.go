@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/pushapproval"
+)
+
+type HandlerSignPushApprovalSuite struct {
+	suite.Suite
+
+	mock     *mocks.MockAutheliaCtx
+	server   *httptest.Server
+	provider *pushapproval.WebhookProvider
+	payload  pushapproval.Payload
+}
+
+func (s *HandlerSignPushApprovalSuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.mock.Ctx.Clock = &s.mock.Clock
+
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		s.Require().NoError(json.Unmarshal(body, &s.payload))
+	}))
+	s.provider = pushapproval.NewWebhookProvider(schema.PushApprovalConfiguration{
+		URL:     s.server.URL,
+		Secret:  "secret",
+		Timeout: "60s",
+	}, &s.mock.Clock)
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.AuthenticationMethods = []string{authnMethodPassword}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+}
+
+func (s *HandlerSignPushApprovalSuite) TearDownTest() {
+	s.server.Close()
+	s.mock.Close()
+}
+
+func (s *HandlerSignPushApprovalSuite) expectMark(successful bool) {
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   testUsername,
+			Successful: successful,
+			Time:       s.mock.Clock.Now(),
+			RemoteIP:   "0.0.0.0",
+			Type:       models.AuthenticationTypePushApproval,
+		}))
+}
+
+func (s *HandlerSignPushApprovalSuite) push() {
+	s.mock.Ctx.Request.SetBodyString(`{"targetURL":"https://app.example.com"}`)
+
+	SecondFactorPushApprovalStartPost(s.provider)(s.mock.Ctx)
+
+	s.Require().Equal(200, s.mock.Ctx.Response.StatusCode())
+	s.Require().Equal(s.payload.ID, s.mock.Ctx.GetSession().PushApprovalRequestID)
+	s.Equal(testUsername, s.payload.Username)
+	s.Equal("https://app.example.com", s.payload.TargetURL)
+
+	s.mock.Ctx.Response.Reset()
+}
+
+func (s *HandlerSignPushApprovalSuite) callback(body, signature string) {
+	s.mock.Ctx.Request.SetBodyString(body)
+	s.mock.Ctx.Request.Header.Set(pushapproval.SignatureHeader, signature)
+
+	PushApprovalCallbackPost(s.provider)(s.mock.Ctx)
+}
+
+func signPushApprovalCallback(body string) string {
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(body))
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *HandlerSignPushApprovalSuite) TestShouldUpgradeToTwoFactorOnceApproved() {
+	s.push()
+
+	s.mock.Ctx.Request.SetBodyString(`{}`)
+	SecondFactorPushApprovalPost(s.provider)(s.mock.Ctx)
+	s.mock.Assert200OK(s.T(), pushApprovalStatusResponse{Status: pushapproval.StatusPending})
+	s.mock.Ctx.Response.Reset()
+
+	body := `{"id":"` + s.payload.ID + `","approved":true}`
+	s.callback(body, signPushApprovalCallback(body))
+	s.Require().Equal(200, s.mock.Ctx.Response.StatusCode())
+	s.mock.Ctx.Response.Reset()
+
+	s.expectMark(true)
+
+	s.mock.Ctx.Configuration.DefaultRedirectionURL = testRedirectionURL
+	s.mock.Ctx.Request.SetBodyString(`{}`)
+	SecondFactorPushApprovalPost(s.provider)(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), redirectResponse{Redirect: testRedirectionURL})
+
+	userSession := s.mock.Ctx.GetSession()
+	assert.Equal(s.T(), authentication.TwoFactor, userSession.AuthenticationLevel)
+	assert.Equal(s.T(), []string{authnMethodPassword, authnMethodPushApproval}, userSession.AuthenticationMethods)
+	assert.Equal(s.T(), "", userSession.PushApprovalRequestID)
+}
+
+func (s *HandlerSignPushApprovalSuite) TestShouldFailWhenDenied() {
+	s.push()
+
+	body := `{"id":"` + s.payload.ID + `","approved":false}`
+	s.callback(body, signPushApprovalCallback(body))
+	s.mock.Ctx.Response.Reset()
+
+	s.expectMark(false)
+
+	s.mock.Ctx.Request.SetBodyString(`{}`)
+	SecondFactorPushApprovalPost(s.provider)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "The approval request of user john is denied", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+	assert.Equal(s.T(), authentication.OneFactor, s.mock.Ctx.GetSession().AuthenticationLevel)
+	assert.Equal(s.T(), "", s.mock.Ctx.GetSession().PushApprovalRequestID)
+}
+
+func (s *HandlerSignPushApprovalSuite) TestShouldRejectCallbackWithInvalidSignature() {
+	s.push()
+
+	s.callback(`{"id":"`+s.payload.ID+`","approved":true}`, signPushApprovalCallback(`{"id":"abc","approved":true}`))
+
+	assert.Equal(s.T(), 401, s.mock.Ctx.Response.StatusCode())
+	assert.Equal(s.T(), "Invalid signature of the push approval callback from IP 0.0.0.0", s.mock.Hook.LastEntry().Message)
+	assert.Equal(s.T(), pushapproval.StatusPending, s.provider.Status(s.payload.ID, testUsername))
+}
+
+func (s *HandlerSignPushApprovalSuite) TestShouldFailWhenNoRequestWasPushed() {
+	s.mock.Ctx.Request.SetBodyString(`{}`)
+	SecondFactorPushApprovalPost(s.provider)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "No approval request was pushed to user john", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func TestRunHandlerSignPushApprovalSuite(t *testing.T) {
+	suite.Run(t, new(HandlerSignPushApprovalSuite))
+}
@@ -20,13 +20,17 @@ func SecondFactorTOTPPost(totpVerifier TOTPVerifier) middlewares.RequestHandler
 
 		userSession := ctx.GetSession()
 
-		secret, err := ctx.Providers.StorageProvider.LoadTOTPSecret(userSession.Username)
+		if isSecondFactorMethodDenied(ctx, userSession, authnMethodTOTP) {
+			return
+		}
+
+		configuration, err := ctx.Providers.StorageProvider.LoadTOTPConfiguration(userSession.Username)
 		if err != nil {
 			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to load TOTP secret: %s", err), mfaValidationFailedMessage)
 			return
 		}
 
-		isValid, err := totpVerifier.Verify(requestBody.Token, secret)
+		isValid, err := totpVerifier.Verify(requestBody.Token, *configuration)
 		if err != nil {
 			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Error occurred during OTP validation for user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
 			return
@@ -37,6 +41,16 @@ func SecondFactorTOTPPost(totpVerifier TOTPVerifier) middlewares.RequestHandler
 			return
 		}
 
+		now := ctx.Clock.Now()
+
+		configuration.LastUsed = &now
+		configuration.LastUsedIP = ctx.RemoteIP().String()
+		configuration.LastUsedCountry = ctx.Providers.GeoIP.Country(ctx.RemoteIP())
+
+		if err = ctx.Providers.StorageProvider.UpdateTOTPConfigurationSignIn(*configuration); err != nil {
+			ctx.Logger.Errorf("Unable to record the sign in of user %s with TOTP: %s", userSession.Username, err)
+		}
+
 		err = ctx.Providers.SessionProvider.RegenerateSession(ctx.RequestCtx)
 
 		if err != nil {
@@ -45,6 +59,8 @@ func SecondFactorTOTPPost(totpVerifier TOTPVerifier) middlewares.RequestHandler
 		}
 
 		userSession.AuthenticationLevel = authentication.TwoFactor
+		userSession.SecondFactorAuthnTimestamp = ctx.Clock.Now().Unix()
+		userSession.AuthenticationMethods = append(userSession.AuthenticationMethods, authnMethodTOTP)
 		err = ctx.SaveSession(userSession)
 
 		if err != nil {
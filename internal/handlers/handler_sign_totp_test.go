@@ -3,14 +3,18 @@ package handlers
 import (
 	"encoding/json"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/tstranex/u2f"
 
+	"github.com/authelia/authelia/internal/geoip"
 	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
 	"github.com/authelia/authelia/internal/session"
 )
 
@@ -38,13 +42,17 @@ func (s *HandlerSignTOTPSuite) TestShouldRedirectUserToDefaultURL() {
 	verifier := NewMockTOTPVerifier(s.mock.Ctrl)
 
 	s.mock.StorageProviderMock.EXPECT().
-		LoadTOTPSecret(gomock.Any()).
-		Return("secret", nil)
+		LoadTOTPConfiguration(gomock.Any()).
+		Return(&models.TOTPConfiguration{Username: testUsername, Secret: "secret"}, nil)
 
 	verifier.EXPECT().
-		Verify(gomock.Eq("abc"), gomock.Eq("secret")).
+		Verify(gomock.Eq("abc"), gomock.Eq(models.TOTPConfiguration{Username: testUsername, Secret: "secret"})).
 		Return(true, nil)
 
+	s.mock.StorageProviderMock.EXPECT().
+		UpdateTOTPConfigurationSignIn(gomock.Any()).
+		Return(nil)
+
 	s.mock.Ctx.Configuration.DefaultRedirectionURL = testRedirectionURL
 
 	bodyBytes, err := json.Marshal(signTOTPRequestBody{
@@ -63,13 +71,17 @@ func (s *HandlerSignTOTPSuite) TestShouldNotReturnRedirectURL() {
 	verifier := NewMockTOTPVerifier(s.mock.Ctrl)
 
 	s.mock.StorageProviderMock.EXPECT().
-		LoadTOTPSecret(gomock.Any()).
-		Return("secret", nil)
+		LoadTOTPConfiguration(gomock.Any()).
+		Return(&models.TOTPConfiguration{Username: testUsername, Secret: "secret"}, nil)
 
 	verifier.EXPECT().
-		Verify(gomock.Eq("abc"), gomock.Eq("secret")).
+		Verify(gomock.Eq("abc"), gomock.Eq(models.TOTPConfiguration{Username: testUsername, Secret: "secret"})).
 		Return(true, nil)
 
+	s.mock.StorageProviderMock.EXPECT().
+		UpdateTOTPConfigurationSignIn(gomock.Any()).
+		Return(nil)
+
 	bodyBytes, err := json.Marshal(signTOTPRequestBody{
 		Token: "abc",
 	})
@@ -84,13 +96,17 @@ func (s *HandlerSignTOTPSuite) TestShouldRedirectUserToSafeTargetURL() {
 	verifier := NewMockTOTPVerifier(s.mock.Ctrl)
 
 	s.mock.StorageProviderMock.EXPECT().
-		LoadTOTPSecret(gomock.Any()).
-		Return("secret", nil)
+		LoadTOTPConfiguration(gomock.Any()).
+		Return(&models.TOTPConfiguration{Username: testUsername, Secret: "secret"}, nil)
 
 	verifier.EXPECT().
-		Verify(gomock.Eq("abc"), gomock.Eq("secret")).
+		Verify(gomock.Eq("abc"), gomock.Eq(models.TOTPConfiguration{Username: testUsername, Secret: "secret"})).
 		Return(true, nil)
 
+	s.mock.StorageProviderMock.EXPECT().
+		UpdateTOTPConfigurationSignIn(gomock.Any()).
+		Return(nil)
+
 	bodyBytes, err := json.Marshal(signTOTPRequestBody{
 		Token:     "abc",
 		TargetURL: "https://mydomain.local",
@@ -108,13 +124,17 @@ func (s *HandlerSignTOTPSuite) TestShouldNotRedirectToUnsafeURL() {
 	verifier := NewMockTOTPVerifier(s.mock.Ctrl)
 
 	s.mock.StorageProviderMock.EXPECT().
-		LoadTOTPSecret(gomock.Any()).
-		Return("secret", nil)
+		LoadTOTPConfiguration(gomock.Any()).
+		Return(&models.TOTPConfiguration{Username: testUsername, Secret: "secret"}, nil)
 
 	verifier.EXPECT().
-		Verify(gomock.Eq("abc"), gomock.Eq("secret")).
+		Verify(gomock.Eq("abc"), gomock.Eq(models.TOTPConfiguration{Username: testUsername, Secret: "secret"})).
 		Return(true, nil)
 
+	s.mock.StorageProviderMock.EXPECT().
+		UpdateTOTPConfigurationSignIn(gomock.Any()).
+		Return(nil)
+
 	bodyBytes, err := json.Marshal(signTOTPRequestBody{
 		Token:     "abc",
 		TargetURL: "http://mydomain.local",
@@ -130,13 +150,17 @@ func (s *HandlerSignTOTPSuite) TestShouldRegenerateSessionForPreventingSessionFi
 	verifier := NewMockTOTPVerifier(s.mock.Ctrl)
 
 	s.mock.StorageProviderMock.EXPECT().
-		LoadTOTPSecret(gomock.Any()).
-		Return("secret", nil)
+		LoadTOTPConfiguration(gomock.Any()).
+		Return(&models.TOTPConfiguration{Username: testUsername, Secret: "secret"}, nil)
 
 	verifier.EXPECT().
-		Verify(gomock.Eq("abc"), gomock.Eq("secret")).
+		Verify(gomock.Eq("abc"), gomock.Eq(models.TOTPConfiguration{Username: testUsername, Secret: "secret"})).
 		Return(true, nil)
 
+	s.mock.StorageProviderMock.EXPECT().
+		UpdateTOTPConfigurationSignIn(gomock.Any()).
+		Return(nil)
+
 	bodyBytes, err := json.Marshal(signTOTPRequestBody{
 		Token: "abc",
 	})
@@ -154,6 +178,47 @@ func (s *HandlerSignTOTPSuite) TestShouldRegenerateSessionForPreventingSessionFi
 		string(s.mock.Ctx.Request.Header.Cookie("authelia_session")))
 }
 
+func (s *HandlerSignTOTPSuite) TestShouldRecordTheSignIn() {
+	verifier := NewMockTOTPVerifier(s.mock.Ctrl)
+
+	database, err := geoip.ParseDatabase(strings.NewReader("1.0.0.0,1.0.0.255,AU\n"))
+	s.Require().NoError(err)
+
+	s.mock.Ctx.Providers.GeoIP = database
+	s.mock.Ctx.Clock = &s.mock.Clock
+	s.mock.Clock.Set(time.Unix(1620000000, 0))
+	s.mock.Ctx.Request.Header.Set("X-Forwarded-For", "1.0.0.1")
+
+	now := time.Unix(1620000000, 0)
+
+	s.mock.StorageProviderMock.EXPECT().
+		LoadTOTPConfiguration(gomock.Any()).
+		Return(&models.TOTPConfiguration{Username: testUsername, Secret: "secret"}, nil)
+
+	verifier.EXPECT().
+		Verify(gomock.Eq("abc"), gomock.Eq(models.TOTPConfiguration{Username: testUsername, Secret: "secret"})).
+		Return(true, nil)
+
+	s.mock.StorageProviderMock.EXPECT().
+		UpdateTOTPConfigurationSignIn(gomock.Eq(models.TOTPConfiguration{
+			Username:        testUsername,
+			Secret:          "secret",
+			LastUsed:        &now,
+			LastUsedIP:      "1.0.0.1",
+			LastUsedCountry: "AU",
+		})).
+		Return(nil)
+
+	bodyBytes, err := json.Marshal(signTOTPRequestBody{
+		Token: "abc",
+	})
+	s.Require().NoError(err)
+	s.mock.Ctx.Request.SetBody(bodyBytes)
+
+	SecondFactorTOTPPost(verifier)(s.mock.Ctx)
+	s.mock.Assert200OK(s.T(), nil)
+}
+
 func TestRunHandlerSignTOTPSuite(t *testing.T) {
 	suite.Run(t, new(HandlerSignTOTPSuite))
 }
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/yubico"
+)
+
+// SecondFactorYubiKeyOTPPost is the handler validating the Yubico OTP typed by one of the YubiKeys of the user. The
+// public ID prefixing the one-time password tells which YubiKey generated it, the validation server checks the rest.
+func SecondFactorYubiKeyOTPPost(api yubico.API) middlewares.RequestHandler {
+	return func(ctx *middlewares.AutheliaCtx) {
+		requestBody := signYubiKeyOTPRequestBody{}
+
+		if err := ctx.ParseBody(&requestBody); err != nil {
+			handleAuthenticationUnauthorized(ctx, err, mfaValidationFailedMessage)
+			return
+		}
+
+		userSession := ctx.GetSession()
+
+		if isSecondFactorMethodDenied(ctx, userSession, authnMethodYubiKeyOTP) {
+			return
+		}
+
+		if _, err := ctx.Providers.Regulator.Regulate(userSession.Username); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regulate authentication of user %s: %s", userSession.Username, err), userBannedMessage)
+			return
+		}
+
+		publicID, err := yubico.PublicID(requestBody.Token)
+		if err != nil {
+			markYubiKeyOTPAttempt(ctx, userSession.Username, false)
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Invalid YubiKey OTP provided by user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+
+			return
+		}
+
+		devices, err := ctx.Providers.StorageProvider.LoadYubiKeyOTPDevices(userSession.Username)
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to load the YubiKeys of user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+			return
+		}
+
+		if !isYubiKeyOTPDeviceRegistered(devices, publicID) {
+			markYubiKeyOTPAttempt(ctx, userSession.Username, false)
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("The YubiKey %s is not registered by user %s", publicID, userSession.Username), mfaValidationFailedMessage)
+
+			return
+		}
+
+		status, err := api.Verify(requestBody.Token)
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to validate the YubiKey OTP of user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+			return
+		}
+
+		markYubiKeyOTPAttempt(ctx, userSession.Username, status == yubico.StatusOK)
+
+		if status != yubico.StatusOK {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Wrong YubiKey OTP for user %s: %s", userSession.Username, status), mfaValidationFailedMessage)
+			return
+		}
+
+		if err = ctx.Providers.SessionProvider.RegenerateSession(ctx.RequestCtx); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regenerate session for user %s: %s", userSession.Username, err), mfaValidationFailedMessage)
+			return
+		}
+
+		userSession.AuthenticationLevel = authentication.TwoFactor
+		userSession.SecondFactorAuthnTimestamp = ctx.Clock.Now().Unix()
+		userSession.AuthenticationMethods = append(userSession.AuthenticationMethods, authnMethodYubiKeyOTP)
+
+		if err = ctx.SaveSession(userSession); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to update the authentication level with YubiKey OTP: %s", err), mfaValidationFailedMessage)
+			return
+		}
+
+		if userSession.OIDCWorkflowSession != nil {
+			HandleOIDCWorkflowResponse(ctx)
+		} else {
+			Handle2FAResponse(ctx, requestBody.TargetURL)
+		}
+	}
+}
+
+func isYubiKeyOTPDeviceRegistered(devices []models.YubiKeyOTPDevice, publicID string) bool {
+	for _, device := range devices {
+		if device.PublicID == publicID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func markYubiKeyOTPAttempt(ctx *middlewares.AutheliaCtx, username string, successful bool) {
+	ctx.Logger.Debugf("Mark authentication attempt made by user %s", username)
+
+	if err := ctx.Providers.Regulator.Mark(username, successful, ctx.RemoteIP(), models.AuthenticationTypeYubiKeyOTP); err != nil {
+		ctx.Logger.Errorf("Unable to mark authentication: %s", err)
+	}
+}
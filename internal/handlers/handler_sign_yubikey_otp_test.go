@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/yubico"
+)
+
+const testYubiKeyOTP = "ccccccbcgujhingjrdejhgfnuetrgigvejhhgbkugded"
+
+type HandlerSignYubiKeyOTPSuite struct {
+	suite.Suite
+
+	mock *mocks.MockAutheliaCtx
+	api  *mocks.MockYubicoAPI
+}
+
+func (s *HandlerSignYubiKeyOTPSuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.mock.Ctx.Clock = &s.mock.Clock
+	s.api = mocks.NewMockYubicoAPI(s.mock.Ctrl)
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.AuthenticationMethods = []string{authnMethodPassword}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+}
+
+func (s *HandlerSignYubiKeyOTPSuite) TearDownTest() {
+	s.mock.Close()
+}
+
+func (s *HandlerSignYubiKeyOTPSuite) expectDevices(publicIDs ...string) {
+	devices := make([]models.YubiKeyOTPDevice, 0, len(publicIDs))
+	for _, publicID := range publicIDs {
+		devices = append(devices, models.YubiKeyOTPDevice{Username: testUsername, PublicID: publicID})
+	}
+
+	s.mock.StorageProviderMock.EXPECT().
+		LoadYubiKeyOTPDevices(gomock.Eq(testUsername)).
+		Return(devices, nil)
+}
+
+func (s *HandlerSignYubiKeyOTPSuite) expectMark(successful bool) {
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   testUsername,
+			Successful: successful,
+			Time:       s.mock.Clock.Now(),
+			RemoteIP:   "0.0.0.0",
+			Type:       models.AuthenticationTypeYubiKeyOTP,
+		}))
+}
+
+func (s *HandlerSignYubiKeyOTPSuite) TestShouldUpgradeToTwoFactorWithValidOneTimePassword() {
+	s.expectDevices("cccccccccccc", "ccccccbcgujh")
+	s.api.EXPECT().
+		Verify(gomock.Eq(testYubiKeyOTP)).
+		Return(yubico.StatusOK, nil)
+	s.expectMark(true)
+
+	s.mock.Ctx.Configuration.DefaultRedirectionURL = testRedirectionURL
+	s.mock.Ctx.Request.SetBodyString(fmt.Sprintf(`{"token":"%s"}`, testYubiKeyOTP))
+
+	SecondFactorYubiKeyOTPPost(s.api)(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), redirectResponse{Redirect: testRedirectionURL})
+
+	userSession := s.mock.Ctx.GetSession()
+	assert.Equal(s.T(), authentication.TwoFactor, userSession.AuthenticationLevel)
+	assert.Equal(s.T(), []string{authnMethodPassword, authnMethodYubiKeyOTP}, userSession.AuthenticationMethods)
+}
+
+func (s *HandlerSignYubiKeyOTPSuite) TestShouldFailWithYubiKeyOfAnotherUser() {
+	s.expectDevices("cccccccccccc")
+	s.expectMark(false)
+
+	s.mock.Ctx.Request.SetBodyString(fmt.Sprintf(`{"token":"%s"}`, testYubiKeyOTP))
+
+	SecondFactorYubiKeyOTPPost(s.api)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "The YubiKey ccccccbcgujh is not registered by user john", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func (s *HandlerSignYubiKeyOTPSuite) TestShouldFailWithReplayedOneTimePassword() {
+	s.expectDevices("ccccccbcgujh")
+	s.api.EXPECT().
+		Verify(gomock.Eq(testYubiKeyOTP)).
+		Return(yubico.StatusReplayedOTP, nil)
+	s.expectMark(false)
+
+	s.mock.Ctx.Request.SetBodyString(fmt.Sprintf(`{"token":"%s"}`, testYubiKeyOTP))
+
+	SecondFactorYubiKeyOTPPost(s.api)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Wrong YubiKey OTP for user john: REPLAYED_OTP", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func (s *HandlerSignYubiKeyOTPSuite) TestShouldFailWhenValidationServersAreUnavailable() {
+	s.expectDevices("ccccccbcgujh")
+	s.api.EXPECT().
+		Verify(gomock.Eq(testYubiKeyOTP)).
+		Return("", fmt.Errorf("unexpected status code 503"))
+
+	s.mock.Ctx.Request.SetBodyString(fmt.Sprintf(`{"token":"%s"}`, testYubiKeyOTP))
+
+	SecondFactorYubiKeyOTPPost(s.api)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Unable to validate the YubiKey OTP of user john: unexpected status code 503", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func (s *HandlerSignYubiKeyOTPSuite) TestShouldFailWithMalformedOneTimePassword() {
+	s.expectMark(false)
+
+	s.mock.Ctx.Request.SetBodyString(`{"token":"123456"}`)
+
+	SecondFactorYubiKeyOTPPost(s.api)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Invalid YubiKey OTP provided by user john: the one-time password must be between 33 and 48 characters long", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), mfaValidationFailedMessage)
+}
+
+func TestRunHandlerSignYubiKeyOTPSuite(t *testing.T) {
+	suite.Run(t, new(HandlerSignYubiKeyOTPSuite))
+}
@@ -11,6 +11,7 @@ func StateGet(ctx *middlewares.AutheliaCtx) {
 		Username:              userSession.Username,
 		AuthenticationLevel:   userSession.AuthenticationLevel,
 		DefaultRedirectionURL: ctx.Configuration.DefaultRedirectionURL,
+		PasswordRequired:      isPasswordRequired(userSession),
 	}
 
 	err := ctx.SetJSONBody(stateResponse)
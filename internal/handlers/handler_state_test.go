@@ -86,6 +86,21 @@ func (s *StateGetSuite) TestShouldReturnAuthenticationLevelFromSession() {
 	assert.Equal(s.T(), expectedBody, actualBody)
 }
 
+func (s *StateGetSuite) TestShouldRequirePasswordAfterPasskeyFirstFactor() {
+	userSession := s.mock.Ctx.GetSession()
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.AuthenticationMethods = []string{authnMethodPasskey}
+	err := s.mock.Ctx.SaveSession(userSession)
+	require.NoError(s.T(), err)
+
+	StateGet(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), StateResponse{
+		AuthenticationLevel: authentication.OneFactor,
+		PasswordRequired:    true,
+	})
+}
+
 func TestRunStateGetSuite(t *testing.T) {
 	s := new(StateGetSuite)
 	suite.Run(t, s)
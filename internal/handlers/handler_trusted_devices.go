@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/storage"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// TrustedDevicePost is the handler trusting the device of the user, on which the second factor is skipped until the
+// trust expires. The device is only trusted right after a second factor so a trusted device can't extend its own trust.
+func TrustedDevicePost(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	if utils.IsStringInSlice(authnMethodTrustedDevice, userSession.AuthenticationMethods) {
+		ctx.Error(fmt.Errorf("User %s skipped the second factor on a trusted device", userSession.Username), operationFailedMessage)
+		return
+	}
+
+	duration, err := utils.ParseDurationString(ctx.Configuration.TrustedDevices.Duration)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to parse the duration of the trusted devices: %s", err), operationFailedMessage)
+		return
+	}
+
+	token, err := randomHexString(trustedDeviceTokenLength)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to generate the token of the trusted device of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	id, err := randomHexString(trustedDeviceIDLength)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to generate the ID of the trusted device of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	description := string(ctx.UserAgent())
+	if len(description) > trustedDeviceDescriptionMaxLength {
+		description = description[:trustedDeviceDescriptionMaxLength]
+	}
+
+	device := models.TrustedDevice{
+		Username:    userSession.Username,
+		ID:          id,
+		TokenHash:   utils.HashSHA256FromString(token),
+		Description: description,
+		RemoteIP:    ctx.RemoteIP().String(),
+		Time:        ctx.Clock.Now(),
+		Expires:     ctx.Clock.Now().Add(duration),
+	}
+
+	if err = ctx.Providers.StorageProvider.SaveTrustedDevice(device); err != nil {
+		ctx.Error(fmt.Errorf("Unable to save the trusted device of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetKey(trustedDeviceCookieName)
+	cookie.SetValue(token)
+	cookie.SetPath("/")
+	cookie.SetExpire(device.Expires)
+	cookie.SetHTTPOnly(true)
+	cookie.SetSecure(true)
+	cookie.SetSameSite(fasthttp.CookieSameSiteStrictMode)
+
+	ctx.Response.Header.SetCookie(cookie)
+
+	appendAuditEvent(ctx, userSession.Username, models.AuditEventDeviceTrusted, fmt.Sprintf("id=%s", id))
+
+	ctx.ReplyOK()
+}
+
+// TrustedDevicesGet is the handler listing the devices trusted by the user which have not expired, the oldest first.
+func TrustedDevicesGet(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	devices, err := ctx.Providers.StorageProvider.LoadTrustedDevices(userSession.Username)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to load the trusted devices of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	current := ""
+	if token := ctx.Request.Header.Cookie(trustedDeviceCookieName); len(token) != 0 {
+		current = utils.HashSHA256FromString(string(token))
+	}
+
+	response := make([]trustedDeviceResponse, 0, len(devices))
+
+	for _, device := range devices {
+		response = append(response, trustedDeviceResponse{
+			ID:          device.ID,
+			Description: device.Description,
+			RemoteIP:    device.RemoteIP,
+			CreatedAt:   device.Time,
+			ExpiresAt:   device.Expires,
+			Current:     device.TokenHash == current,
+		})
+	}
+
+	if err = ctx.SetJSONBody(response); err != nil {
+		ctx.Logger.Errorf("Unable to set the trusted devices in body: %s", err)
+	}
+}
+
+// TrustedDeviceRevokePost is the handler revoking a device trusted by the user, the second factor being required again
+// on this device.
+func TrustedDeviceRevokePost(ctx *middlewares.AutheliaCtx) {
+	var bodyJSON trustedDeviceRevokeRequestBody
+
+	if err := ctx.ParseBody(&bodyJSON); err != nil {
+		ctx.Error(err, operationFailedMessage)
+		return
+	}
+
+	if bodyJSON.ID == "" {
+		ctx.Error(fmt.Errorf("The ID of the trusted device is required"), operationFailedMessage)
+		return
+	}
+
+	userSession := ctx.GetSession()
+
+	count, err := ctx.Providers.StorageProvider.DeleteTrustedDevice(userSession.Username, bodyJSON.ID)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to revoke the trusted device of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	if count == 0 {
+		ctx.Error(fmt.Errorf("The device to revoke is not trusted by user %s", userSession.Username), operationFailedMessage)
+		return
+	}
+
+	appendAuditEvent(ctx, userSession.Username, models.AuditEventTrustedDeviceRevoked, fmt.Sprintf("id=%s", bodyJSON.ID))
+
+	ctx.ReplyOK()
+}
+
+// skipSecondFactorOnTrustedDevice upgrades the session of a user who just completed the first factor to two factor when
+// the request comes from a device the user trusted and the trust has not expired. The caller is responsible for saving
+// the session.
+func skipSecondFactorOnTrustedDevice(ctx *middlewares.AutheliaCtx, userSession *session.UserSession) {
+	if ctx.Configuration.TrustedDevices == nil {
+		return
+	}
+
+	token := ctx.Request.Header.Cookie(trustedDeviceCookieName)
+	if len(token) == 0 {
+		return
+	}
+
+	device, err := ctx.Providers.StorageProvider.LoadTrustedDevice(utils.HashSHA256FromString(string(token)))
+
+	switch {
+	case err == storage.ErrNoTrustedDevice:
+		ctx.Logger.Debugf("The device of user %s is not trusted anymore", userSession.Username)
+		return
+	case err != nil:
+		ctx.Logger.Errorf("Unable to load the trusted device of user %s: %s", userSession.Username, err)
+		return
+	case device.Username != userSession.Username:
+		ctx.Logger.Debugf("The device is trusted by user %s and not by user %s", device.Username, userSession.Username)
+		return
+	}
+
+	ctx.Logger.Debugf("Second factor of user %s skipped on trusted device %s", userSession.Username, device.ID)
+
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.SecondFactorAuthnTimestamp = ctx.Clock.Now().Unix()
+	userSession.AuthenticationMethods = append(userSession.AuthenticationMethods, authnMethodTrustedDevice)
+}
+
+// randomHexString returns n random bytes encoded in hexadecimal.
+func randomHexString(n int) (string, error) {
+	random := make([]byte, n)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(random), nil
+}
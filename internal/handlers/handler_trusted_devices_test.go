@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+type HandlerTrustedDevicesSuite struct {
+	suite.Suite
+
+	mock *mocks.MockAutheliaCtx
+}
+
+func (s *HandlerTrustedDevicesSuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.mock.Ctx.Clock = &s.mock.Clock
+	s.mock.Ctx.Configuration.TrustedDevices = &schema.TrustedDevicesConfiguration{Duration: "30d"}
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.AuthenticationMethods = []string{authnMethodPassword, authnMethodTOTP}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+}
+
+func (s *HandlerTrustedDevicesSuite) TearDownTest() {
+	s.mock.Close()
+}
+
+func (s *HandlerTrustedDevicesSuite) TestShouldTrustDevice() {
+	var saved models.TrustedDevice
+
+	s.mock.StorageProviderMock.EXPECT().
+		SaveTrustedDevice(gomock.Any()).
+		DoAndReturn(func(device models.TrustedDevice) error {
+			saved = device
+			return nil
+		})
+
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuditEvent(gomock.Any()).
+		DoAndReturn(func(event models.AuditEvent) error {
+			s.Assert().Equal(models.AuditEventDeviceTrusted, event.Type)
+			s.Assert().Equal(fmt.Sprintf("id=%s", saved.ID), event.Details)
+
+			return nil
+		})
+
+	s.mock.Ctx.Request.Header.SetUserAgent("Firefox")
+
+	TrustedDevicePost(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), nil)
+
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetKey(trustedDeviceCookieName)
+	s.Require().True(s.mock.Ctx.Response.Header.Cookie(cookie))
+
+	assert.Equal(s.T(), testUsername, saved.Username)
+	assert.Len(s.T(), saved.ID, 2*trustedDeviceIDLength)
+	assert.Equal(s.T(), utils.HashSHA256FromString(string(cookie.Value())), saved.TokenHash)
+	assert.Equal(s.T(), "Firefox", saved.Description)
+	assert.Equal(s.T(), s.mock.Clock.Now(), saved.Time)
+	assert.Equal(s.T(), s.mock.Clock.Now().Add(30*24*time.Hour), saved.Expires)
+	assert.True(s.T(), cookie.HTTPOnly())
+	assert.True(s.T(), cookie.Secure())
+}
+
+func (s *HandlerTrustedDevicesSuite) TestShouldNotTrustDeviceIfSecondFactorWasSkipped() {
+	userSession := s.mock.Ctx.GetSession()
+	userSession.AuthenticationMethods = []string{authnMethodPassword, authnMethodTrustedDevice}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+
+	TrustedDevicePost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "User john skipped the second factor on a trusted device", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), operationFailedMessage)
+}
+
+func (s *HandlerTrustedDevicesSuite) TestShouldListTrustedDevices() {
+	now := s.mock.Clock.Now()
+
+	s.mock.StorageProviderMock.EXPECT().
+		LoadTrustedDevices(gomock.Eq(testUsername)).
+		Return([]models.TrustedDevice{
+			{Username: testUsername, ID: "abc", TokenHash: utils.HashSHA256FromString("token"), Description: "Firefox", Time: now, Expires: now.Add(time.Hour)},
+			{Username: testUsername, ID: "def", TokenHash: utils.HashSHA256FromString("other"), Description: "Chrome", RemoteIP: "10.0.0.1", Time: now, Expires: now.Add(time.Hour)},
+		}, nil)
+
+	s.mock.Ctx.Request.Header.SetCookie(trustedDeviceCookieName, "token")
+
+	TrustedDevicesGet(s.mock.Ctx)
+
+	var response []trustedDeviceResponse
+
+	s.mock.GetResponseData(s.T(), &response)
+	s.Require().Len(response, 2)
+	assert.Equal(s.T(), "abc", response[0].ID)
+	assert.True(s.T(), response[0].Current)
+	assert.Equal(s.T(), "def", response[1].ID)
+	assert.Equal(s.T(), "10.0.0.1", response[1].RemoteIP)
+	assert.False(s.T(), response[1].Current)
+}
+
+func (s *HandlerTrustedDevicesSuite) TestShouldRevokeTrustedDevice() {
+	s.mock.StorageProviderMock.EXPECT().
+		DeleteTrustedDevice(gomock.Eq(testUsername), gomock.Eq("abc")).
+		Return(int64(1), nil)
+
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuditEvent(gomock.Eq(models.AuditEvent{
+			Username: testUsername,
+			Type:     models.AuditEventTrustedDeviceRevoked,
+			RemoteIP: "0.0.0.0",
+			Details:  "id=abc",
+			Time:     s.mock.Clock.Now(),
+		}))
+
+	s.mock.Ctx.Request.SetBodyString(`{"id":"abc"}`)
+
+	TrustedDeviceRevokePost(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), nil)
+}
+
+func (s *HandlerTrustedDevicesSuite) TestShouldFailToRevokeDeviceNotTrustedByUser() {
+	s.mock.StorageProviderMock.EXPECT().
+		DeleteTrustedDevice(gomock.Eq(testUsername), gomock.Eq("abc")).
+		Return(int64(0), nil)
+
+	s.mock.Ctx.Request.SetBodyString(`{"id":"abc"}`)
+
+	TrustedDeviceRevokePost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "The device to revoke is not trusted by user john", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), operationFailedMessage)
+}
+
+func (s *HandlerTrustedDevicesSuite) TestShouldSkipSecondFactorOnTrustedDevice() {
+	s.mock.StorageProviderMock.EXPECT().
+		LoadTrustedDevice(gomock.Eq(utils.HashSHA256FromString("token"))).
+		Return(&models.TrustedDevice{Username: testUsername, ID: "abc"}, nil)
+
+	s.mock.Ctx.Request.Header.SetCookie(trustedDeviceCookieName, "token")
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.AuthenticationMethods = []string{authnMethodPassword}
+
+	skipSecondFactorOnTrustedDevice(s.mock.Ctx, &userSession)
+
+	assert.Equal(s.T(), authentication.TwoFactor, userSession.AuthenticationLevel)
+	assert.Equal(s.T(), []string{authnMethodPassword, authnMethodTrustedDevice}, userSession.AuthenticationMethods)
+	assert.Equal(s.T(), s.mock.Clock.Now().Unix(), userSession.SecondFactorAuthnTimestamp)
+}
+
+func (s *HandlerTrustedDevicesSuite) TestShouldNotSkipSecondFactorOnDeviceTrustedByAnotherUser() {
+	s.mock.StorageProviderMock.EXPECT().
+		LoadTrustedDevice(gomock.Eq(utils.HashSHA256FromString("token"))).
+		Return(&models.TrustedDevice{Username: "harry", ID: "abc"}, nil)
+
+	s.mock.Ctx.Request.Header.SetCookie(trustedDeviceCookieName, "token")
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.AuthenticationLevel = authentication.OneFactor
+
+	skipSecondFactorOnTrustedDevice(s.mock.Ctx, &userSession)
+
+	assert.Equal(s.T(), authentication.OneFactor, userSession.AuthenticationLevel)
+}
+
+func (s *HandlerTrustedDevicesSuite) TestShouldNotSkipSecondFactorOnExpiredDevice() {
+	s.mock.StorageProviderMock.EXPECT().
+		LoadTrustedDevice(gomock.Eq(utils.HashSHA256FromString("token"))).
+		Return(nil, storage.ErrNoTrustedDevice)
+
+	s.mock.Ctx.Request.Header.SetCookie(trustedDeviceCookieName, "token")
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.AuthenticationLevel = authentication.OneFactor
+
+	skipSecondFactorOnTrustedDevice(s.mock.Ctx, &userSession)
+
+	assert.Equal(s.T(), authentication.OneFactor, userSession.AuthenticationLevel)
+}
+
+func (s *HandlerTrustedDevicesSuite) TestShouldNotSkipSecondFactorWhenTrustedDevicesAreDisabled() {
+	s.mock.Ctx.Configuration.TrustedDevices = nil
+	s.mock.Ctx.Request.Header.SetCookie(trustedDeviceCookieName, "token")
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.AuthenticationLevel = authentication.OneFactor
+
+	skipSecondFactorOnTrustedDevice(s.mock.Ctx, &userSession)
+
+	assert.Equal(s.T(), authentication.OneFactor, userSession.AuthenticationLevel)
+}
+
+func TestRunHandlerTrustedDevicesSuite(t *testing.T) {
+	suite.Run(t, new(HandlerTrustedDevicesSuite))
+}
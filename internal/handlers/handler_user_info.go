@@ -8,7 +8,9 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
 	"github.com/authelia/authelia/internal/storage"
 	"github.com/authelia/authelia/internal/utils"
 )
@@ -59,7 +61,7 @@ func loadInfo(username string, storageProvider storage.Provider, userInfo *UserI
 	go func() {
 		defer wg.Done()
 
-		_, err := storageProvider.LoadTOTPSecret(username)
+		configuration, err := storageProvider.LoadTOTPConfiguration(username)
 		if err != nil {
 			if err == storage.ErrNoTOTPSecret {
 				return
@@ -72,6 +74,12 @@ func loadInfo(username string, storageProvider storage.Provider, userInfo *UserI
 		}
 
 		userInfo.HasTOTP = true
+
+		if configuration != nil {
+			userInfo.TOTPLastUsedAt = configuration.LastUsed
+			userInfo.TOTPLastUsedIP = configuration.LastUsedIP
+			userInfo.TOTPLastUsedCountry = configuration.LastUsedCountry
+		}
 	}()
 
 	wg.Wait()
@@ -93,15 +101,69 @@ func UserInfoGet(ctx *middlewares.AutheliaCtx) {
 
 	userInfo.DisplayName = userSession.DisplayName
 
-	err := ctx.SetJSONBody(userInfo)
+	domain, err := preferenceDomain(ctx, string(ctx.QueryArgs().Peek("domain")))
+	if err != nil {
+		ctx.Error(err, operationFailedMessage)
+		return
+	}
+
+	if domain != "" {
+		method, err := ctx.Providers.StorageProvider.LoadDomainPreferred2FAMethod(userSession.Username, domain)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to load the preferred 2FA method of user %s for domain %s: %s", userSession.Username, domain, err), operationFailedMessage)
+			return
+		}
+
+		if method != "" {
+			userInfo.Method = method
+		}
+	}
+
+	if !isSecondFactorMethodAllowed(ctx.Configuration.SecondFactorPolicies, userSession.Groups, preferredMethodAuthnMethod(userInfo.Method)) {
+		userInfo.Method = firstAllowedMethod(ctx.Configuration, userSession.Groups, userInfo.Method)
+	}
+
+	fallbackMethods, err := secondFactorFallbackMethods(ctx, userSession, userInfo)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to load the second factor methods of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	if len(fallbackMethods) != 0 {
+		userInfo.Method = fallbackMethods[0]
+		userInfo.FallbackMethods = fallbackMethods
+	}
+
+	if userSession.SecondFactorEnrollmentDeadline != 0 && userSession.AuthenticationLevel == authentication.OneFactor {
+		enrolled, err := isSecondFactorEnrolled(ctx.Providers.StorageProvider, userSession.Username)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to check whether user %s enrolled a second factor: %s", userSession.Username, err), operationFailedMessage)
+			return
+		}
+
+		if enrolled {
+			userSession.SecondFactorEnrollmentDeadline = 0
+
+			if err = ctx.SaveSession(userSession); err != nil {
+				ctx.Error(fmt.Errorf("Unable to save session of user %s: %s", userSession.Username, err), operationFailedMessage)
+				return
+			}
+		} else {
+			userInfo.EnrollmentRequired = true
+			userInfo.EnrollmentDeadline = userSession.SecondFactorEnrollmentDeadline
+		}
+	}
+
+	err = ctx.SetJSONBody(userInfo)
 	if err != nil {
 		ctx.Logger.Errorf("Unable to set user info response in body: %s", err)
 	}
 }
 
-// MethodBody the selected 2FA method.
+// MethodBody the selected 2FA method, for every domain or only for the given one.
 type MethodBody struct {
 	Method string `json:"method" valid:"required"`
+	Domain string `json:"domain"`
 }
 
 // MethodPreferencePost update the user preferences regarding 2FA method.
@@ -119,7 +181,37 @@ func MethodPreferencePost(ctx *middlewares.AutheliaCtx) {
 		return
 	}
 
+	if !isMethodEnabled(ctx.Configuration, bodyJSON.Method) {
+		ctx.Error(fmt.Errorf("Method '%s' is not enabled in the configuration", bodyJSON.Method), operationFailedMessage)
+		return
+	}
+
 	userSession := ctx.GetSession()
+
+	if !isSecondFactorMethodAllowed(ctx.Configuration.SecondFactorPolicies, userSession.Groups, preferredMethodAuthnMethod(bodyJSON.Method)) {
+		ctx.Error(fmt.Errorf("Method '%s' is not allowed by the second factor policy of user %s", bodyJSON.Method, userSession.Username), operationFailedMessage)
+		return
+	}
+
+	domain, err := preferenceDomain(ctx, bodyJSON.Domain)
+	if err != nil {
+		ctx.Error(err, operationFailedMessage)
+		return
+	}
+
+	if domain != "" {
+		ctx.Logger.Debugf("Save new preferred 2FA method of user %s for domain %s to %s", userSession.Username, domain, bodyJSON.Method)
+
+		if err = ctx.Providers.StorageProvider.SaveDomainPreferred2FAMethod(userSession.Username, domain, bodyJSON.Method); err != nil {
+			ctx.Error(fmt.Errorf("Unable to save new preferred 2FA method for domain %s: %s", domain, err), operationFailedMessage)
+			return
+		}
+
+		ctx.ReplyOK()
+
+		return
+	}
+
 	ctx.Logger.Debugf("Save new preferred 2FA method of user %s to %s", userSession.Username, bodyJSON.Method)
 	err = ctx.Providers.StorageProvider.SavePreferred2FAMethod(userSession.Username, bodyJSON.Method)
 
@@ -130,3 +222,52 @@ func MethodPreferencePost(ctx *middlewares.AutheliaCtx) {
 
 	ctx.ReplyOK()
 }
+
+// preferenceDomain returns the protected domain a preference applies to, it is empty when the preference applies to
+// every domain.
+func preferenceDomain(ctx *middlewares.AutheliaCtx, domain string) (string, error) {
+	domain = strings.ToLower(domain)
+
+	if domain == "" {
+		return "", nil
+	}
+
+	if _, ok := session.MatchDomain(ctx.Configuration.Session, domain); !ok || len(domain) > 253 {
+		return "", fmt.Errorf("The domain %s is not protected by any of the session domains", domain)
+	}
+
+	return domain, nil
+}
+
+// isMethodEnabled returns whether the method is enabled, some of them are only available when they are configured.
+func isMethodEnabled(configuration schema.Configuration, method string) bool {
+	switch method {
+	case authentication.Push:
+		return configuration.DuoAPI != nil
+	case authentication.Email:
+		return configuration.EmailOneTimeCode != nil
+	case authentication.PushApproval:
+		return configuration.PushApproval != nil
+	case authentication.PrivacyIDEA:
+		return configuration.PrivacyIDEA != nil
+	case authentication.HOTP:
+		return configuration.HOTP != nil
+	case authentication.YubiKeyOTP:
+		return configuration.YubiKeyOTP != nil
+	default:
+		return true
+	}
+}
+
+// firstAllowedMethod returns the first enabled method allowed by the second factor policy of the groups, the fallback
+// is returned when there is none.
+func firstAllowedMethod(configuration schema.Configuration, groups []string, fallback string) string {
+	for _, method := range authentication.PossibleMethods {
+		if isMethodEnabled(configuration, method) &&
+			isSecondFactorMethodAllowed(configuration.SecondFactorPolicies, groups, preferredMethodAuthnMethod(method)) {
+			return method
+		}
+	}
+
+	return fallback
+}
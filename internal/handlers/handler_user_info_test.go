@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/sirupsen/logrus"
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
 	"github.com/authelia/authelia/internal/storage"
 )
 
@@ -53,16 +55,16 @@ func setPreferencesExpectations(preferences UserInfo, provider *storage.MockProv
 	}
 
 	if preferences.HasTOTP {
-		totpSecret := "secret"
+		totpConfiguration := &models.TOTPConfiguration{Username: "john", Secret: "secret"}
 		provider.
 			EXPECT().
-			LoadTOTPSecret(gomock.Eq("john")).
-			Return(totpSecret, nil)
+			LoadTOTPConfiguration(gomock.Eq("john")).
+			Return(totpConfiguration, nil)
 	} else {
 		provider.
 			EXPECT().
-			LoadTOTPSecret(gomock.Eq("john")).
-			Return("", storage.ErrNoTOTPSecret)
+			LoadTOTPConfiguration(gomock.Eq("john")).
+			Return(nil, storage.ErrNoTOTPSecret)
 	}
 }
 
@@ -131,13 +133,47 @@ func (s *FetchSuite) TestShouldGetDefaultPreferenceIfNotInDB() {
 
 	s.mock.StorageProviderMock.
 		EXPECT().
-		LoadTOTPSecret(gomock.Eq("john")).
-		Return("", storage.ErrNoTOTPSecret)
+		LoadTOTPConfiguration(gomock.Eq("john")).
+		Return(nil, storage.ErrNoTOTPSecret)
 
 	UserInfoGet(s.mock.Ctx)
 	s.mock.Assert200OK(s.T(), UserInfo{Method: "totp"})
 }
 
+func (s *FetchSuite) TestShouldGetTheLastSignInWithTheTOTPDevice() {
+	lastUsed := time.Unix(1620000000, 0).UTC()
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		LoadPreferred2FAMethod(gomock.Eq("john")).
+		Return("totp", nil)
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		LoadU2FDeviceHandle(gomock.Eq("john")).
+		Return(nil, nil, storage.ErrNoU2FDeviceHandle)
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		LoadTOTPConfiguration(gomock.Eq("john")).
+		Return(&models.TOTPConfiguration{
+			Username:        "john",
+			Secret:          "secret",
+			LastUsed:        &lastUsed,
+			LastUsedIP:      "1.0.0.1",
+			LastUsedCountry: "AU",
+		}, nil)
+
+	UserInfoGet(s.mock.Ctx)
+	s.mock.Assert200OK(s.T(), UserInfo{
+		Method:              "totp",
+		HasTOTP:             true,
+		TOTPLastUsedAt:      &lastUsed,
+		TOTPLastUsedIP:      "1.0.0.1",
+		TOTPLastUsedCountry: "AU",
+	})
+}
+
 func (s *FetchSuite) TestShouldReturnError500WhenStorageFailsToLoad() {
 	s.mock.StorageProviderMock.EXPECT().
 		LoadPreferred2FAMethod(gomock.Eq("john")).
@@ -149,7 +185,7 @@ func (s *FetchSuite) TestShouldReturnError500WhenStorageFailsToLoad() {
 
 	s.mock.StorageProviderMock.
 		EXPECT().
-		LoadTOTPSecret(gomock.Eq("john"))
+		LoadTOTPConfiguration(gomock.Eq("john"))
 
 	UserInfoGet(s.mock.Ctx)
 
@@ -158,6 +194,48 @@ func (s *FetchSuite) TestShouldReturnError500WhenStorageFailsToLoad() {
 	assert.Equal(s.T(), logrus.ErrorLevel, s.mock.Hook.LastEntry().Level)
 }
 
+func (s *FetchSuite) TestShouldGetPreferenceOfDomain() {
+	s.mock.Ctx.Configuration.Session.Domain = "example.com"
+	s.mock.Ctx.Request.SetRequestURI("/api/user/info?domain=Admin.example.com")
+
+	setPreferencesExpectations(UserInfo{Method: "totp"}, s.mock.StorageProviderMock)
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		LoadDomainPreferred2FAMethod(gomock.Eq("john"), gomock.Eq("admin.example.com")).
+		Return("u2f", nil)
+
+	UserInfoGet(s.mock.Ctx)
+	s.mock.Assert200OK(s.T(), UserInfo{Method: "u2f"})
+}
+
+func (s *FetchSuite) TestShouldGetDefaultPreferenceIfDomainHasNone() {
+	s.mock.Ctx.Configuration.Session.Domain = "example.com"
+	s.mock.Ctx.Request.SetRequestURI("/api/user/info?domain=app.example.com")
+
+	setPreferencesExpectations(UserInfo{Method: "mobile_push"}, s.mock.StorageProviderMock)
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		LoadDomainPreferred2FAMethod(gomock.Eq("john"), gomock.Eq("app.example.com")).
+		Return("", nil)
+
+	UserInfoGet(s.mock.Ctx)
+	s.mock.Assert200OK(s.T(), UserInfo{Method: "mobile_push"})
+}
+
+func (s *FetchSuite) TestShouldReturnError500WhenDomainIsNotProtected() {
+	s.mock.Ctx.Configuration.Session.Domain = "example.com"
+	s.mock.Ctx.Request.SetRequestURI("/api/user/info?domain=example.org")
+
+	setPreferencesExpectations(UserInfo{Method: "totp"}, s.mock.StorageProviderMock)
+
+	UserInfoGet(s.mock.Ctx)
+
+	s.mock.Assert200KO(s.T(), "Operation failed.")
+	assert.Equal(s.T(), "The domain example.org is not protected by any of the session domains", s.mock.Hook.LastEntry().Message)
+}
+
 func TestFetchSuite(t *testing.T) {
 	suite.Run(t, &FetchSuite{})
 }
@@ -213,7 +291,16 @@ func (s *SaveSuite) TestShouldReturnError500WhenBadMethodProvided() {
 	MethodPreferencePost(s.mock.Ctx)
 
 	s.mock.Assert200KO(s.T(), "Operation failed.")
-	assert.Equal(s.T(), "Unknown method 'abc', it should be one of totp, u2f, mobile_push", s.mock.Hook.LastEntry().Message)
+	assert.Equal(s.T(), "Unknown method 'abc', it should be one of totp, u2f, mobile_push, email, push_approval, privacyidea, hotp, yubikey_otp", s.mock.Hook.LastEntry().Message)
+	assert.Equal(s.T(), logrus.ErrorLevel, s.mock.Hook.LastEntry().Level)
+}
+
+func (s *SaveSuite) TestShouldReturnError500WhenEmailMethodIsNotEnabled() {
+	s.mock.Ctx.Request.SetBody([]byte("{\"method\":\"email\"}"))
+	MethodPreferencePost(s.mock.Ctx)
+
+	s.mock.Assert200KO(s.T(), "Operation failed.")
+	assert.Equal(s.T(), "Method 'email' is not enabled in the configuration", s.mock.Hook.LastEntry().Message)
 	assert.Equal(s.T(), logrus.ErrorLevel, s.mock.Hook.LastEntry().Level)
 }
 
@@ -241,6 +328,28 @@ func (s *SaveSuite) TestShouldReturn200WhenMethodIsSuccessfullySaved() {
 	assert.Equal(s.T(), 200, s.mock.Ctx.Response.StatusCode())
 }
 
+func (s *SaveSuite) TestShouldReturn200WhenMethodOfDomainIsSuccessfullySaved() {
+	s.mock.Ctx.Configuration.Session.Domain = "example.com"
+	s.mock.Ctx.Request.SetBody([]byte("{\"method\":\"u2f\",\"domain\":\"admin.example.com\"}"))
+	s.mock.StorageProviderMock.EXPECT().
+		SaveDomainPreferred2FAMethod(gomock.Eq("john"), gomock.Eq("admin.example.com"), gomock.Eq("u2f")).
+		Return(nil)
+
+	MethodPreferencePost(s.mock.Ctx)
+
+	assert.Equal(s.T(), 200, s.mock.Ctx.Response.StatusCode())
+}
+
+func (s *SaveSuite) TestShouldReturnError500WhenMethodOfUnprotectedDomainIsSaved() {
+	s.mock.Ctx.Configuration.Session.Domain = "example.com"
+	s.mock.Ctx.Request.SetBody([]byte("{\"method\":\"u2f\",\"domain\":\"example.org\"}"))
+
+	MethodPreferencePost(s.mock.Ctx)
+
+	s.mock.Assert200KO(s.T(), "Operation failed.")
+	assert.Equal(s.T(), "The domain example.org is not protected by any of the session domains", s.mock.Hook.LastEntry().Message)
+}
+
 func TestSaveSuite(t *testing.T) {
 	suite.Run(t, &SaveSuite{})
 }
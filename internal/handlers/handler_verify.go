@@ -6,15 +6,19 @@ import (
 	"fmt"
 	"net"
 	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/valyala/fasthttp"
 
 	"github.com/authelia/authelia/internal/authentication"
 	"github.com/authelia/authelia/internal/authorization"
 	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
 	"github.com/authelia/authelia/internal/session"
 	"github.com/authelia/authelia/internal/utils"
 )
@@ -31,6 +35,13 @@ func isSchemeWSS(url *url.URL) bool {
 	return url.Scheme == "wss"
 }
 
+// isWebSocketUpgrade returns true if the request checked by the proxy is a WebSocket upgrade request, either because
+// the proxy forwarded its Upgrade header or because the target URL has the wss scheme.
+func isWebSocketUpgrade(ctx *middlewares.AutheliaCtx, targetURL *url.URL) bool {
+	return isSchemeWSS(targetURL) ||
+		bytes.EqualFold(ctx.Request.Header.Peek(fasthttp.HeaderUpgrade), []byte(webSocketUpgrade))
+}
+
 // parseBasicAuth parses an HTTP Basic Authentication string.
 // "Basic QWxhZGRpbjpvcGVuIHNlc2FtZQ==" returns ("Aladdin", "open sesame", true).
 func parseBasicAuth(header, auth string) (username, password string, err error) {
@@ -53,10 +64,11 @@ func parseBasicAuth(header, auth string) (username, password string, err error)
 	return cs[:s], cs[s+1:], nil
 }
 
-// isTargetURLAuthorized check whether the given user is authorized to access the resource.
+// isTargetURLAuthorized check whether the given user is authorized to access the resource. The required level and the
+// position of the matching rule are returned as well to explain the decision.
 func isTargetURLAuthorized(authorizer *authorization.Authorizer, targetURL url.URL,
-	username string, userGroups []string, clientIP net.IP, method []byte, authLevel authentication.Level) authorizationMatching {
-	level := authorizer.GetRequiredLevel(
+	username string, userGroups []string, clientIP net.IP, method []byte, authLevel authentication.Level) (matching authorizationMatching, level authorization.Level, rule int) {
+	level, rule = authorizer.GetRequiredLevelAndRule(
 		authorization.Subject{
 			Username: username,
 			Groups:   userGroups,
@@ -66,7 +78,7 @@ func isTargetURLAuthorized(authorizer *authorization.Authorizer, targetURL url.U
 
 	switch {
 	case level == authorization.Bypass:
-		return Authorized
+		return Authorized, level, rule
 	case level == authorization.Denied && username != "":
 		// If the user is not anonymous, it means that we went through
 		// all the rules related to that user and knowing who he is we can
@@ -74,13 +86,13 @@ func isTargetURLAuthorized(authorizer *authorization.Authorizer, targetURL url.U
 		// For anonymous users though, we cannot be sure that she
 		// could not be granted the rights to access the resource. Consequently
 		// for anonymous users we send Unauthorized instead of Forbidden
-		return Forbidden
+		return Forbidden, level, rule
 	case level == authorization.OneFactor && authLevel >= authentication.OneFactor,
 		level == authorization.TwoFactor && authLevel >= authentication.TwoFactor:
-		return Authorized
+		return Authorized, level, rule
 	}
 
-	return NotAuthorized
+	return NotAuthorized, level, rule
 }
 
 // verifyBasicAuth verify that the provided username and password are correct and
@@ -113,21 +125,139 @@ func verifyBasicAuth(header string, auth []byte, targetURL url.URL, ctx *middlew
 	return username, details.DisplayName, details.Groups, details.Emails, authentication.OneFactor, nil
 }
 
-// setForwardedHeaders set the forwarded User, Groups, Name and Email headers.
-func setForwardedHeaders(headers *fasthttp.ResponseHeader, username, name string, groups, emails []string) {
-	if username != "" {
-		headers.Set(remoteUserHeader, username)
-		headers.Set(remoteGroupsHeader, strings.Join(groups, ","))
-		headers.Set(remoteNameHeader, name)
+// setForwardedHeaders set the forwarded User, Groups, Name and Email headers, with the names configured for the verify
+// endpoint, and the static headers.
+func setForwardedHeaders(headers *fasthttp.ResponseHeader, cfg schema.ServerVerifyHeadersConfiguration, username, name string, groups, emails []string) {
+	if username == "" {
+		return
+	}
 
-		if emails != nil {
-			headers.Set(remoteEmailHeader, emails[0])
-		} else {
-			headers.Set(remoteEmailHeader, "")
+	email := ""
+	if emails != nil {
+		email = emails[0]
+	}
+
+	for _, header := range []struct {
+		kind, name, fallback, value string
+	}{
+		{"user", cfg.User, remoteUserHeader, username},
+		{"groups", cfg.Groups, remoteGroupsHeader, strings.Join(groups, ",")},
+		{"name", cfg.Name, remoteNameHeader, name},
+		{"email", cfg.Email, remoteEmailHeader, email},
+	} {
+		if utils.IsStringInSlice(header.kind, cfg.Exclude) {
+			continue
+		}
+
+		if header.name == "" {
+			header.name = header.fallback
+		}
+
+		headers.Set(header.name, header.value)
+	}
+
+	for _, header := range cfg.Static {
+		headers.Set(header.Name, header.Value)
+	}
+}
+
+// setDecisionHeaders set the optional headers describing the authorization decision: the position of the matched rule,
+// 0 being the default policy, the authentication level of the user and the methods they authenticated with.
+func setDecisionHeaders(headers *fasthttp.ResponseHeader, cfg schema.ServerVerifyHeadersConfiguration, username string, rule int, authLevel authentication.Level, methods []string) {
+	if username == "" {
+		return
+	}
+
+	if cfg.Rule != "" {
+		headers.Set(cfg.Rule, strconv.Itoa(rule))
+	}
+
+	if cfg.Level != "" {
+		level := "none"
+
+		switch authLevel {
+		case authentication.OneFactor:
+			level = "one_factor"
+		case authentication.TwoFactor:
+			level = "two_factor"
+		}
+
+		headers.Set(cfg.Level, level)
+	}
+
+	if cfg.Methods != "" && len(methods) != 0 {
+		headers.Set(cfg.Methods, strings.Join(methods, ","))
+	}
+}
+
+// getAuthenticationMethods returns the methods the user of the request authenticated with, given the strategy of
+// verifyAuth which authenticated the request.
+func getAuthenticationMethods(ctx *middlewares.AutheliaCtx, strategy string) []string {
+	switch strategy {
+	case verifyStrategyTrustedHeader:
+		return []string{authnMethodTrustedHeader}
+	case verifyStrategyClientCertificate:
+		return []string{authnMethodCertificate}
+	case verifyStrategyAPIKey:
+		return []string{authnMethodAPIKey}
+	case verifyStrategyBearerToken:
+		return []string{authnMethodToken}
+	case verifyStrategyBasicAuth:
+		return []string{authnMethodPassword}
+	case verifyStrategySessionCookie:
+		return ctx.GetSession().AuthenticationMethods
+	}
+
+	return nil
+}
+
+// setAttributeHeaders set the headers mapped to the attributes of the user, the values of the multi-valued attributes
+// being comma separated. The headers of the attributes the user doesn't have are not set.
+func setAttributeHeaders(headers *fasthttp.ResponseHeader, cfg schema.ServerVerifyHeadersConfiguration, attributes map[string][]string) {
+	for _, header := range cfg.Attributes {
+		if values := attributes[header.Attribute]; len(values) != 0 {
+			headers.Set(header.Header, strings.Join(values, ","))
 		}
 	}
 }
 
+// getUserAttributes returns the attributes of the user, from the session when it's the one of the user or from the
+// authentication backend otherwise.
+func getUserAttributes(ctx *middlewares.AutheliaCtx, username string) map[string][]string {
+	if userSession := ctx.GetSession(); userSession.Username == username {
+		return userSession.Attributes
+	}
+
+	details, err := ctx.Providers.UserProvider.GetDetails(username)
+	if err != nil {
+		ctx.Logger.Debugf("Unable to retrieve the attributes of user %s: %s", username, err)
+		return nil
+	}
+
+	return details.Attributes
+}
+
+// setBasicAuthChallenge set the Basic challenge of the responses to the requests with missing or wrong credentials,
+// in the WWW-Authenticate header or the Proxy-Authenticate header for the clients expecting it.
+func setBasicAuthChallenge(headers *fasthttp.ResponseHeader, cfg schema.ServerVerifyBasicAuthConfiguration) {
+	header := wwwAuthenticateHeader
+	if cfg.ProxyAuthenticate {
+		header = proxyAuthenticateHeader
+	}
+
+	realm := cfg.Realm
+	if realm == "" {
+		realm = schema.DefaultServerConfiguration.VerifyBasicAuth.Realm
+	}
+
+	challenge := fmt.Sprintf("Basic realm=\"%s\"", realm)
+	if cfg.Charset != "" {
+		challenge += fmt.Sprintf(", charset=\"%s\"", cfg.Charset)
+	}
+
+	headers.Add(header, challenge)
+}
+
 // hasUserBeenInactiveTooLong checks whether the user has been inactive for too long.
 func hasUserBeenInactiveTooLong(ctx *middlewares.AutheliaCtx) (bool, error) { //nolint:unparam
 	maxInactivityPeriod := int64(ctx.Providers.SessionProvider.Inactivity.Seconds())
@@ -150,7 +280,7 @@ func hasUserBeenInactiveTooLong(ctx *middlewares.AutheliaCtx) (bool, error) { //
 
 // verifySessionCookie verifies if a user is identified by a cookie.
 func verifySessionCookie(ctx *middlewares.AutheliaCtx, targetURL *url.URL, userSession *session.UserSession, refreshProfile bool,
-	refreshProfileInterval time.Duration) (username, name string, groups, emails []string, authLevel authentication.Level, err error) {
+	refreshProfileInterval time.Duration, refresher *asyncProfileRefresher) (username, name string, groups, emails []string, authLevel authentication.Level, err error) {
 	// No username in the session means the user is anonymous.
 	isUserAnonymous := userSession.Username == ""
 
@@ -175,7 +305,7 @@ func verifySessionCookie(ctx *middlewares.AutheliaCtx, targetURL *url.URL, userS
 		}
 	}
 
-	err = verifySessionHasUpToDateProfile(ctx, targetURL, userSession, refreshProfile, refreshProfileInterval)
+	err = verifySessionHasUpToDateProfile(ctx, targetURL, userSession, refreshProfile, refreshProfileInterval, refresher)
 	if err != nil {
 		if err == authentication.ErrUserNotFound {
 			err = ctx.Providers.SessionProvider.DestroySession(ctx.RequestCtx)
@@ -192,16 +322,156 @@ func verifySessionCookie(ctx *middlewares.AutheliaCtx, targetURL *url.URL, userS
 	return userSession.Username, userSession.DisplayName, userSession.Groups, userSession.Emails, userSession.AuthenticationLevel, nil
 }
 
-func handleUnauthorized(ctx *middlewares.AutheliaCtx, targetURL fmt.Stringer, isBasicAuth bool, username string, method []byte) {
+// isAuthenticationTooOld checks whether the factor required by the policy was performed by the user of the session
+// longer than the maximum age ago. The authentication level of the session is then lowered so the portal prompts the
+// user for this factor again.
+func isAuthenticationTooOld(ctx *middlewares.AutheliaCtx, username string, requiredLevel authorization.Level, maxAge time.Duration) (bool, error) {
+	if maxAge == 0 {
+		return false, nil
+	}
+
+	userSession := ctx.GetSession()
+	if userSession.Username == "" || userSession.Username != username {
+		return false, nil
+	}
+
+	tooOld, level := isSessionAuthenticationTooOld(userSession, requiredLevel, maxAge, ctx.Clock.Now())
+	if !tooOld {
+		return false, nil
+	}
+
+	if userSession.AuthenticationLevel > level {
+		userSession.AuthenticationLevel = level
+
+		if err := ctx.SaveSession(userSession); err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+// isSessionAuthenticationTooOld checks whether the factor required by the policy was performed by the user of the
+// session longer than the maximum age ago, returning the authentication level the session must be lowered to if so.
+func isSessionAuthenticationTooOld(userSession session.UserSession, requiredLevel authorization.Level, maxAge time.Duration, now time.Time) (bool, authentication.Level) {
+	if maxAge == 0 {
+		return false, userSession.AuthenticationLevel
+	}
+
+	var (
+		timestamp int64
+		level     authentication.Level
+	)
+
+	switch requiredLevel {
+	case authorization.OneFactor:
+		timestamp, level = userSession.FirstFactorAuthnTimestamp, authentication.NotAuthenticated
+	case authorization.TwoFactor:
+		timestamp, level = userSession.SecondFactorAuthnTimestamp, authentication.OneFactor
+	default:
+		return false, userSession.AuthenticationLevel
+	}
+
+	if now.Sub(time.Unix(timestamp, 0)) <= maxAge {
+		return false, userSession.AuthenticationLevel
+	}
+
+	return true, level
+}
+
+// isSecondFactorMethodMissing returns true when none of the second factor methods required by the rule was performed by
+// the user. The authentication level of the session is then lowered to the first factor so that the user performs one
+// of them.
+func isSecondFactorMethodMissing(ctx *middlewares.AutheliaCtx, username, strategy string, required []string) (bool, error) {
+	if len(required) == 0 {
+		return false, nil
+	}
+
+	for _, method := range getAuthenticationMethods(ctx, strategy) {
+		if isSecondFactorMethodSatisfied(required, method) {
+			return false, nil
+		}
+	}
+
+	if strategy != verifyStrategySessionCookie {
+		return true, nil
+	}
+
+	userSession := ctx.GetSession()
+	if userSession.Username == username && userSession.AuthenticationLevel > authentication.OneFactor {
+		userSession.AuthenticationLevel = authentication.OneFactor
+
+		if err := ctx.SaveSession(userSession); err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+// checkPolicyEngine returns the decision of the policy engine about a request authorized by the ACLs. Like for the deny
+// policy, the anonymous users who are refused are asked to authenticate since the policy may allow them once known.
+func checkPolicyEngine(ctx *middlewares.AutheliaCtx, targetURL *url.URL, method []byte, username string, groups []string, level authorization.Level, rule int) authorizationMatching {
+	allowed, err := ctx.Providers.Authorizer.IsAllowedByPolicyEngine(
+		authorization.Subject{
+			Username: username,
+			Groups:   groups,
+			IP:       ctx.RemoteIP(),
+		},
+		authorization.NewObjectRaw(targetURL, method), level, rule)
+	if err != nil {
+		ctx.Logger.Error(err)
+	}
+
+	switch {
+	case allowed:
+		return Authorized
+	case username == "":
+		return NotAuthorized
+	default:
+		ctx.Logger.Infof("Access to %s is refused to user %s by the policy engine", targetURL.String(), username)
+		return Forbidden
+	}
+}
+
+// getVerifyMaxAuthenticationAge returns the maximum age of the first factor of the sessions for the verify endpoint.
+func getVerifyMaxAuthenticationAge(ctx *middlewares.AutheliaCtx) time.Duration {
+	// Skip Error Check since validator checks it.
+	maxAge, _ := utils.ParseDurationString(ctx.Configuration.Server.VerifyMaxAuthenticationAge)
+
+	return maxAge
+}
+
+// handleUnauthorized replies to the requests which are not authorized, redirecting them to the portal of the domain of
+// the target URL if it's configured or to the portal given by the rd parameter otherwise.
+func handleUnauthorized(ctx *middlewares.AutheliaCtx, targetURL *url.URL, isBasicAuth bool, username string, method []byte, portalURL string) {
 	friendlyUsername := "<anonymous>"
 	if username != "" {
 		friendlyUsername = username
 	}
 
+	// The WebSocket clients can't follow a redirection nor display a body, the socket is simply refused.
+	if !isBasicAuth && isWebSocketUpgrade(ctx, targetURL) {
+		ctx.Logger.Infof("WebSocket upgrade to %s is not authorized to user %s, sending 401 response", targetURL.String(), friendlyUsername)
+		ctx.ReplyUnauthorized()
+		ctx.Response.ResetBody()
+
+		return
+	}
+
+	// Neither can the gRPC clients, the call simply fails with the UNAUTHENTICATED status.
+	if isGRPCRequest(ctx) {
+		ctx.Logger.Infof("gRPC call to %s is not authorized to user %s, sending 401 response", targetURL.String(), friendlyUsername)
+		ctx.ReplyUnauthorized()
+		ctx.Response.ResetBody()
+
+		return
+	}
+
 	if isBasicAuth {
 		ctx.Logger.Infof("Access to %s is not authorized to user %s, sending 401 response with basic auth header", targetURL.String(), friendlyUsername)
 		ctx.ReplyUnauthorized()
-		ctx.Response.Header.Add("WWW-Authenticate", "Basic realm=\"Authentication required\"")
+		setBasicAuthChallenge(&ctx.Response.Header, ctx.Configuration.Server.VerifyBasicAuth)
 
 		return
 	}
@@ -210,6 +480,10 @@ func handleUnauthorized(ctx *middlewares.AutheliaCtx, targetURL fmt.Stringer, is
 	// endpoint to provide the URL of the login portal. The target URL of the user
 	// is computed from X-Forwarded-* headers or X-Original-URL.
 	rd := string(ctx.QueryArgs().Peek("rd"))
+	if portalURL != "" {
+		rd = portalURL
+	}
+
 	rm := string(method)
 
 	friendlyMethod := "unknown"
@@ -227,9 +501,25 @@ func handleUnauthorized(ctx *middlewares.AutheliaCtx, targetURL fmt.Stringer, is
 			redirectionURL = fmt.Sprintf("%s?rd=%s", rd, url.QueryEscape(targetURL.String()))
 		}
 
+		cfg := ctx.Configuration.Server.VerifyRedirect
+
+		// The XMLHttpRequest clients follow the redirections transparently, they are told where to authenticate instead.
+		if cfg.XHRUnauthorized && bytes.Equal(ctx.Request.Header.Peek(xRequestedWithHeader), []byte(xmlHTTPRequest)) {
+			ctx.Logger.Infof("Access to %s (method %s) is not authorized to user %s, sending 401 response with the location %s", targetURL.String(), friendlyMethod, friendlyUsername, redirectionURL)
+			ctx.ReplyUnauthorized()
+			ctx.Response.Header.Set(fasthttp.HeaderLocation, redirectionURL)
+
+			return
+		}
+
+		statusCode := cfg.StatusCode
+		if statusCode == 0 {
+			statusCode = schema.DefaultServerConfiguration.VerifyRedirect.StatusCode
+		}
+
 		ctx.Logger.Infof("Access to %s (method %s) is not authorized to user %s, redirecting to %s", targetURL.String(), friendlyMethod, friendlyUsername, redirectionURL)
-		ctx.Redirect(redirectionURL, 302)
-		ctx.SetBodyString(fmt.Sprintf("Found. Redirecting to %s", redirectionURL))
+		ctx.Redirect(redirectionURL, statusCode)
+		ctx.SetBodyString(fmt.Sprintf("%s. Redirecting to %s", fasthttp.StatusMessage(statusCode), redirectionURL))
 	} else {
 		ctx.Logger.Infof("Access to %s (method %s) is not authorized to user %s, sending 401 response", targetURL.String(), friendlyMethod, friendlyUsername)
 		ctx.ReplyUnauthorized()
@@ -302,7 +592,7 @@ func generateVerifySessionHasUpToDateProfileTraceLogs(ctx *middlewares.AutheliaC
 }
 
 func verifySessionHasUpToDateProfile(ctx *middlewares.AutheliaCtx, targetURL *url.URL, userSession *session.UserSession,
-	refreshProfile bool, refreshProfileInterval time.Duration) error {
+	refreshProfile bool, refreshProfileInterval time.Duration, refresher *asyncProfileRefresher) error {
 	// TODO: Add a check for LDAP password changes based on a time format attribute.
 	// See https://www.authelia.com/docs/security/threat-model.html#potential-future-guarantees
 	ctx.Logger.Tracef("Checking if we need check the authentication backend for an updated profile for %s.", userSession.Username)
@@ -315,8 +605,22 @@ func verifySessionHasUpToDateProfile(ctx *middlewares.AutheliaCtx, targetURL *ur
 		return nil
 	}
 
-	ctx.Logger.Debugf("Checking the authentication backend for an updated profile for user %s", userSession.Username)
-	details, err := ctx.Providers.UserProvider.GetDetails(userSession.Username)
+	var (
+		details *authentication.UserDetails
+		err     error
+	)
+
+	if refresher != nil {
+		details, err = refresher.details(ctx.Providers.UserProvider, userSession.Username, ctx.Clock.Now())
+		if details == nil && err == nil {
+			ctx.Logger.Tracef("Refreshing the profile of user %s in the background", userSession.Username)
+			return nil
+		}
+	} else {
+		ctx.Logger.Debugf("Checking the authentication backend for an updated profile for user %s", userSession.Username)
+		details, err = ctx.Providers.UserProvider.GetDetails(userSession.Username)
+	}
+
 	// Only update the session if we could get the new details.
 	if err != nil {
 		return err
@@ -325,8 +629,9 @@ func verifySessionHasUpToDateProfile(ctx *middlewares.AutheliaCtx, targetURL *ur
 	emailsDiff := utils.IsStringSlicesDifferent(userSession.Emails, details.Emails)
 	groupsDiff := utils.IsStringSlicesDifferent(userSession.Groups, details.Groups)
 	nameDiff := userSession.DisplayName != details.DisplayName
+	attributesDiff := !reflect.DeepEqual(userSession.Attributes, details.Attributes)
 
-	if !groupsDiff && !emailsDiff && !nameDiff {
+	if !groupsDiff && !emailsDiff && !nameDiff && !attributesDiff {
 		ctx.Logger.Tracef("Updated profile not detected for %s.", userSession.Username)
 		// Only update TTL if the user has a interval set.
 		// We get to this check when there were no changes.
@@ -345,6 +650,7 @@ func verifySessionHasUpToDateProfile(ctx *middlewares.AutheliaCtx, targetURL *ur
 		userSession.Emails = details.Emails
 		userSession.Groups = details.Groups
 		userSession.DisplayName = details.DisplayName
+		userSession.Attributes = details.Attributes
 
 		// Only update TTL if the user has a interval set.
 		if refreshProfileInterval != schema.RefreshIntervalAlways {
@@ -378,7 +684,81 @@ func getProfileRefreshSettings(cfg schema.AuthenticationBackendConfiguration) (r
 	return refresh, refreshInterval
 }
 
-func verifyAuth(ctx *middlewares.AutheliaCtx, targetURL *url.URL, refreshProfile bool, refreshProfileInterval time.Duration) (isBasicAuth bool, username, name string, groups, emails []string, authLevel authentication.Level, err error) {
+// getVerifyObject returns the target URL and the method of the request, read from the configured headers when they are
+// set for the proxies which don't follow the conventions of the X-Original-URL and X-Forwarded-* headers.
+func getVerifyObject(ctx *middlewares.AutheliaCtx, cfg schema.ServerVerifyObjectHeadersConfiguration) (targetURL *url.URL, method []byte, err error) {
+	method = ctx.XForwardedMethod()
+
+	if cfg.Method != "" {
+		if value := ctx.Request.Header.Peek(cfg.Method); len(value) != 0 {
+			method = value
+		}
+	}
+
+	if cfg.URL != "" {
+		if value := ctx.Request.Header.Peek(cfg.URL); len(value) != 0 {
+			targetURL, err = url.ParseRequestURI(string(value))
+			if err != nil {
+				return nil, nil, fmt.Errorf("Unable to parse URL extracted from %s header: %v", cfg.URL, err)
+			}
+
+			return targetURL, method, nil
+		}
+	}
+
+	targetURL, err = ctx.GetOriginalURL()
+
+	return targetURL, method, err
+}
+
+// verifyAuthWithStrategy authenticates the request with the strategy if its credentials are present in the request.
+func verifyAuthWithStrategy(ctx *middlewares.AutheliaCtx, strategy string, targetURL *url.URL, refreshProfile bool, refreshProfileInterval time.Duration, refresher *asyncProfileRefresher, trustedHeader *trustedHeaderAuthentication, clientCertificate *clientCertificateAuthentication) (present, isBasicAuth bool, username, name string, groups, emails []string, authLevel authentication.Level, err error) {
+	switch strategy {
+	case verifyStrategyTrustedHeader:
+		if trustedHeader == nil {
+			return
+		}
+
+		if value := ctx.Request.Header.Peek(trustedHeader.header); len(value) != 0 {
+			userSession := ctx.GetSession()
+			username = string(value)
+			name, groups, emails, authLevel, err = verifyTrustedHeader(ctx, trustedHeader, username, &userSession)
+
+			return true, false, username, name, groups, emails, authLevel, err
+		}
+	case verifyStrategyClientCertificate:
+		if clientCertificate != nil && len(ctx.Request.Header.Peek(clientCertificate.header)) != 0 {
+			userSession := ctx.GetSession()
+			username, name, groups, emails, authLevel, err = verifyClientCertificate(ctx, clientCertificate, &userSession)
+
+			return true, false, username, name, groups, emails, authLevel, err
+		}
+	case verifyStrategyAPIKey:
+		if bearerAuth := ctx.Request.Header.Peek(AuthorizationHeader); isAPIKeyAuthorization(bearerAuth) {
+			username, groups, authLevel, err = verifyAPIKey(AuthorizationHeader, bearerAuth, ctx)
+
+			return true, true, username, "", groups, nil, authLevel, err
+		}
+	case verifyStrategyBearerToken:
+		if bearerAuth := ctx.Request.Header.Peek(AuthorizationHeader); isBearerAuthorization(ctx, bearerAuth) {
+			username, name, groups, emails, authLevel, err = verifyBearerToken(AuthorizationHeader, bearerAuth, targetURL, ctx)
+
+			return true, true, username, name, groups, emails, authLevel, err
+		}
+	case verifyStrategyBasicAuth:
+		return verifyBasicAuthStrategy(ctx, targetURL)
+	case verifyStrategySessionCookie:
+		username, name, groups, emails, authLevel, err = verifySessionCookieStrategy(ctx, targetURL, refreshProfile, refreshProfileInterval, refresher)
+
+		return true, false, username, name, groups, emails, authLevel, err
+	}
+
+	return false, false, "", "", nil, nil, authentication.NotAuthenticated, nil
+}
+
+// verifyBasicAuthStrategy authenticates the request with the credentials of the Proxy-Authorization header, or the
+// Authorization header when the Basic scheme is requested by the auth query parameter.
+func verifyBasicAuthStrategy(ctx *middlewares.AutheliaCtx, targetURL *url.URL) (present, isBasicAuth bool, username, name string, groups, emails []string, authLevel authentication.Level, err error) {
 	authHeader := ProxyAuthorizationHeader
 	if bytes.Equal(ctx.QueryArgs().Peek("auth"), []byte("basic")) {
 		authHeader = AuthorizationHeader
@@ -390,16 +770,23 @@ func verifyAuth(ctx *middlewares.AutheliaCtx, targetURL *url.URL, refreshProfile
 		isBasicAuth = true
 	} else if isBasicAuth {
 		err = fmt.Errorf("Basic auth requested via query arg, but no value provided via %s header", authHeader)
-		return
+		return true, true, "", "", nil, nil, authentication.NotAuthenticated, err
 	}
 
-	if isBasicAuth {
-		username, name, groups, emails, authLevel, err = verifyBasicAuth(authHeader, authValue, *targetURL, ctx)
-		return
+	if !isBasicAuth {
+		return false, false, "", "", nil, nil, authentication.NotAuthenticated, nil
 	}
 
+	username, name, groups, emails, authLevel, err = verifyBasicAuth(authHeader, authValue, *targetURL, ctx)
+
+	return true, true, username, name, groups, emails, authLevel, err
+}
+
+// verifySessionCookieStrategy authenticates the request with the session cookie, the session being destroyed if it
+// doesn't belong to the user of the Session-Username header.
+func verifySessionCookieStrategy(ctx *middlewares.AutheliaCtx, targetURL *url.URL, refreshProfile bool, refreshProfileInterval time.Duration, refresher *asyncProfileRefresher) (username, name string, groups, emails []string, authLevel authentication.Level, err error) {
 	userSession := ctx.GetSession()
-	username, name, groups, emails, authLevel, err = verifySessionCookie(ctx, targetURL, &userSession, refreshProfile, refreshProfileInterval)
+	username, name, groups, emails, authLevel, err = verifySessionCookie(ctx, targetURL, &userSession, refreshProfile, refreshProfileInterval, refresher)
 
 	sessionUsername := ctx.Request.Header.Peek(SessionUsernameHeader)
 	if sessionUsername != nil && !strings.EqualFold(string(sessionUsername), username) {
@@ -416,16 +803,75 @@ func verifyAuth(ctx *middlewares.AutheliaCtx, targetURL *url.URL, refreshProfile
 		err = fmt.Errorf("Could not match user %s to their %s header with a value of %s when visiting %s", username, SessionUsernameHeader, sessionUsername, targetURL.String())
 	}
 
-	return
+	return username, name, groups, emails, authLevel, err
 }
 
-// VerifyGet returns the handler verifying if a request is allowed to go through.
-func VerifyGet(cfg schema.AuthenticationBackendConfiguration) middlewares.RequestHandler {
+// getVerifyStrategies returns the authentication strategies of the verify endpoint in order of priority, the default
+// order being used when none is configured.
+func getVerifyStrategies(ctx *middlewares.AutheliaCtx) []schema.ServerVerifyStrategyConfiguration {
+	if strategies := ctx.Configuration.Server.VerifyStrategies; len(strategies) != 0 {
+		return strategies
+	}
+
+	strategies := make([]schema.ServerVerifyStrategyConfiguration, len(defaultVerifyStrategies))
+	for i, name := range defaultVerifyStrategies {
+		strategies[i] = schema.ServerVerifyStrategyConfiguration{Name: name}
+	}
+
+	return strategies
+}
+
+// verifyAuth authenticates the request with the first strategy whose credentials are present in the request. The
+// failure of a strategy ends the evaluation unless the strategy is configured to fall through to the next ones, in
+// which case the error is only returned if none of them authenticates the request.
+func verifyAuth(ctx *middlewares.AutheliaCtx, targetURL *url.URL, refreshProfile bool, refreshProfileInterval time.Duration, refresher *asyncProfileRefresher, trustedHeader *trustedHeaderAuthentication, clientCertificate *clientCertificateAuthentication) (strategy string, isBasicAuth bool, username, name string, groups, emails []string, authLevel authentication.Level, err error) {
+	var failure error
+
+	for _, s := range getVerifyStrategies(ctx) {
+		present, isBasicAuth, username, name, groups, emails, authLevel, err := verifyAuthWithStrategy(ctx, s.Name, targetURL,
+			refreshProfile, refreshProfileInterval, refresher, trustedHeader, clientCertificate)
+
+		if !present {
+			continue
+		}
+
+		if err == nil || !s.Fallthrough {
+			return s.Name, isBasicAuth, username, name, groups, emails, authLevel, err
+		}
+
+		ctx.Logger.Debugf("Trying the next authentication strategy after the failure of the %s strategy: %s", s.Name, err)
+
+		failure = err
+	}
+
+	return "", false, "", "", nil, nil, authentication.NotAuthenticated, failure
+}
+
+// VerifyGet returns the handler verifying if a request is allowed to go through. The decisions made for the requests
+// authenticated with a session cookie are cached for the given duration, unless it's zero, and the decisions made for
+// the WebSocket upgrade requests for their own duration if it's not zero.
+func VerifyGet(cfg schema.AuthenticationBackendConfiguration, cacheDuration, webSocketCacheDuration time.Duration) middlewares.RequestHandler {
 	refreshProfile, refreshProfileInterval := getProfileRefreshSettings(cfg)
+	refresher := newAsyncProfileRefresher(refreshProfile && cfg.RefreshAsync, refreshProfileInterval)
+	trustedHeader := newTrustedHeaderAuthentication(cfg.TrustedHeader)
+	clientCertificate := newClientCertificateAuthentication(cfg.ClientCertificate)
+	decisions := newVerifyDecisionCache(cacheDuration, webSocketCacheDuration)
+	templates := &lazyVerifyTemplates{}
 
 	return func(ctx *middlewares.AutheliaCtx) {
 		ctx.Logger.Tracef("Headers=%s", ctx.Request.Header.String())
-		targetURL, err := ctx.GetOriginalURL()
+
+		headers := ctx.Configuration.Server.VerifyHeaders
+
+		// The gRPC metadata must be lowercase and the gRPC clients don't read the bodies of the responses.
+		if isGRPCRequest(ctx) {
+			headers = grpcVerifyHeaders(headers)
+			ctx.Response.Header.DisableNormalizing()
+
+			defer ctx.Response.ResetBody()
+		}
+
+		targetURL, method, err := getVerifyObject(ctx, ctx.Configuration.Server.VerifyObjectHeaders)
 
 		if err != nil {
 			ctx.Logger.Error(fmt.Errorf("Unable to parse target URL: %s", err))
@@ -442,7 +888,8 @@ func VerifyGet(cfg schema.AuthenticationBackendConfiguration) middlewares.Reques
 			return
 		}
 
-		if !isURLUnderProtectedDomain(targetURL, ctx.Configuration.Session.Domain) {
+		domain, ok := session.MatchDomain(ctx.Configuration.Session, targetURL.Hostname())
+		if !ok {
 			ctx.Logger.Error(fmt.Errorf("The target URL %s is not under the protected domain %s",
 				targetURL.String(), ctx.Configuration.Session.Domain))
 			ctx.ReplyUnauthorized()
@@ -450,9 +897,44 @@ func VerifyGet(cfg schema.AuthenticationBackendConfiguration) middlewares.Reques
 			return
 		}
 
-		isBasicAuth, username, name, groups, emails, authLevel, err := verifyAuth(ctx, targetURL, refreshProfile, refreshProfileInterval)
+		webSocket := isWebSocketUpgrade(ctx, targetURL)
+
+		cacheKey, cacheable := decisions.key(ctx, targetURL, method, webSocket, trustedHeader, clientCertificate)
+		if cacheable {
+			decision, ok := decisions.get(cacheKey, ctx.Clock.Now())
 
-		method := ctx.XForwardedMethod()
+			// The decision is made again when the session no longer matches it, for example when the user logged out or
+			// has been inactive for too long.
+			if ok && isVerifyDecisionStale(ctx, targetURL, method, decision) {
+				ctx.Logger.Debugf("Discarding the cached authorization decision for the access to %s", targetURL.String())
+				decisions.remove(cacheKey)
+
+				ok = false
+			}
+
+			if ok {
+				ctx.Logger.Debugf("Using the cached authorization decision for the access to %s", targetURL.String())
+
+				if decision.authorized == Forbidden {
+					ctx.Logger.Infof("Access to %s is forbidden to user %s", targetURL.String(), decision.username)
+					ctx.ReplyForbidden()
+					templates.get(ctx).render(ctx, targetURL, method, decision.username, authorization.LevelToPolicy(decision.level))
+				} else {
+					setForwardedHeaders(&ctx.Response.Header, headers, decision.username, decision.name, decision.groups, decision.emails)
+					setDecisionHeaders(&ctx.Response.Header, headers, decision.username, decision.rule, decision.authLevel, decision.methods)
+					setAttributeHeaders(&ctx.Response.Header, headers, decision.attributes)
+					setSignatureHeader(&ctx.Response.Header, headers, decision.username, decision.groups, ctx.Clock.Now())
+				}
+
+				if err := updateActivityTimestamp(ctx, false, decision.username); err != nil {
+					ctx.Error(fmt.Errorf("Unable to update last activity: %s", err), operationFailedMessage)
+				}
+
+				return
+			}
+		}
+
+		strategy, isBasicAuth, username, name, groups, emails, authLevel, err := verifyAuth(ctx, targetURL, refreshProfile, refreshProfileInterval, refresher, trustedHeader, clientCertificate)
 
 		if err != nil {
 			ctx.Logger.Error(fmt.Sprintf("Error caught when verifying user authorization: %s", err))
@@ -462,25 +944,148 @@ func VerifyGet(cfg schema.AuthenticationBackendConfiguration) middlewares.Reques
 				return
 			}
 
-			handleUnauthorized(ctx, targetURL, isBasicAuth, username, method)
+			handleUnauthorized(ctx, targetURL, isBasicAuth, username, method, domain.AutheliaURL)
+			templates.get(ctx).render(ctx, targetURL, method, username, "")
 
 			return
 		}
 
-		authorized := isTargetURLAuthorized(ctx.Providers.Authorizer, *targetURL, username,
+		// The administrators impersonating a user are authorized as the user, with their own authentication level.
+		impersonator := ""
+
+		if impersonated := getImpersonatedUsername(ctx); impersonated != "" && username != "" {
+			details, err := impersonateUser(ctx, strategy, username, groups, impersonated)
+			if err != nil {
+				ctx.Logger.Errorf("Access to %s is forbidden: %s", targetURL.String(), err)
+				ctx.ReplyForbidden()
+				templates.get(ctx).render(ctx, targetURL, method, username, "")
+
+				return
+			}
+
+			impersonator = username
+			username, name, groups, emails = impersonated, details.DisplayName, details.Groups, details.Emails
+		}
+
+		authenticated := username
+		if impersonator != "" {
+			authenticated = impersonator
+		}
+
+		authorized, requiredLevel, rule := isTargetURLAuthorized(ctx.Providers.Authorizer, *targetURL, username,
 			groups, ctx.RemoteIP(), method, authLevel)
 
+		// The users required to enroll a second factor access the resources requiring one without it until the end of
+		// their grace period.
+		if authorized == NotAuthorized && requiredLevel == authorization.TwoFactor && impersonator == "" &&
+			isWithinSecondFactorEnrollmentGracePeriod(ctx, username, strategy) {
+			ctx.Logger.Infof("Access to %s is granted to user %s without a second factor during the grace period of the second factor enrollment", targetURL.String(), username)
+
+			authorized = Authorized
+		}
+
+		if authorized == Authorized && !isBasicAuth {
+			tooOld, err := isAuthenticationTooOld(ctx, authenticated, requiredLevel, ctx.Providers.Authorizer.GetMaxAuthenticationAge(rule))
+
+			// The sessions whose first factor is older than the maximum age of the endpoint are unauthenticated for
+			// every resource requiring an authentication.
+			if err == nil && !tooOld && requiredLevel != authorization.Bypass {
+				tooOld, err = isAuthenticationTooOld(ctx, authenticated, authorization.OneFactor, getVerifyMaxAuthenticationAge(ctx))
+			}
+
+			if err != nil {
+				ctx.Error(fmt.Errorf("Unable to lower the authentication level of user %s: %s", username, err), operationFailedMessage)
+				return
+			}
+
+			if tooOld {
+				ctx.Logger.Infof("Authentication of user %s is too old to access %s, asking to authenticate again", username, targetURL.String())
+
+				authorized = NotAuthorized
+			}
+		}
+
+		if authorized == Authorized && requiredLevel == authorization.TwoFactor {
+			missing, err := isSecondFactorMethodMissing(ctx, authenticated, strategy, ctx.Providers.Authorizer.GetSecondFactorMethods(rule))
+			if err != nil {
+				ctx.Error(fmt.Errorf("Unable to lower the authentication level of user %s: %s", username, err), operationFailedMessage)
+				return
+			}
+
+			if missing {
+				ctx.Logger.Infof("User %s did not perform any of the second factor methods required to access %s, asking to authenticate again", username, targetURL.String())
+
+				authorized = NotAuthorized
+			}
+		}
+
+		if authorized == Authorized {
+			authorized = checkPolicyEngine(ctx, targetURL, method, username, groups, requiredLevel, rule)
+		}
+
+		ctx.Logger.WithFields(logrus.Fields{
+			"rule":           rule,
+			"username":       username,
+			"impersonator":   impersonator,
+			"groups":         strings.Join(groups, ","),
+			"remote_ip":      ctx.RemoteIP().String(),
+			"url":            targetURL.String(),
+			"method":         string(method),
+			"required_level": authorization.LevelToPolicy(requiredLevel),
+			"decision":       authorized.String(),
+		}).Debug("Authorization decision made")
+
+		var (
+			methods    []string
+			attributes map[string][]string
+		)
+
 		switch authorized {
 		case Forbidden:
 			ctx.Logger.Infof("Access to %s is forbidden to user %s", targetURL.String(), username)
 			ctx.ReplyForbidden()
+			templates.get(ctx).render(ctx, targetURL, method, username, authorization.LevelToPolicy(requiredLevel))
 		case NotAuthorized:
-			handleUnauthorized(ctx, targetURL, isBasicAuth, username, method)
+			handleUnauthorized(ctx, targetURL, isBasicAuth, username, method, domain.AutheliaURL)
+			templates.get(ctx).render(ctx, targetURL, method, username, authorization.LevelToPolicy(requiredLevel))
 		case Authorized:
-			setForwardedHeaders(&ctx.Response.Header, username, name, groups, emails)
+			setForwardedHeaders(&ctx.Response.Header, headers, username, name, groups, emails)
+			setSignatureHeader(&ctx.Response.Header, headers, username, groups, ctx.Clock.Now())
+
+			if headers.Rule != "" || headers.Level != "" || headers.Methods != "" {
+				methods = getAuthenticationMethods(ctx, strategy)
+				setDecisionHeaders(&ctx.Response.Header, headers, username, rule, authLevel, methods)
+			}
+
+			if len(headers.Attributes) != 0 && username != "" {
+				attributes = getUserAttributes(ctx, username)
+				setAttributeHeaders(&ctx.Response.Header, headers, attributes)
+			}
+
+			if impersonator != "" {
+				ctx.Logger.Infof("Access to %s is granted to user %s impersonating user %s", targetURL.String(), impersonator, username)
+				appendAuditEvent(ctx, impersonator, models.AuditEventImpersonation, fmt.Sprintf("username=%s url=%s", username, targetURL.String()))
+			}
+		}
+
+		// The requests which are not authorized are never cached since the user is expected to authenticate, nor the
+		// decisions made without the session of a user since the cookie can then hold any value.
+		if cacheable && authorized != NotAuthorized && strategy == verifyStrategySessionCookie && username != "" {
+			decisions.set(cacheKey, verifyDecision{
+				authorized: authorized,
+				username:   username,
+				name:       name,
+				groups:     groups,
+				emails:     emails,
+				rule:       rule,
+				level:      requiredLevel,
+				authLevel:  authLevel,
+				methods:    methods,
+				attributes: attributes,
+			}, webSocket, ctx.Clock.Now())
 		}
 
-		if err := updateActivityTimestamp(ctx, isBasicAuth, username); err != nil {
+		if err := updateActivityTimestamp(ctx, isBasicAuth, authenticated); err != nil {
 			ctx.Error(fmt.Errorf("Unable to update last activity: %s", err), operationFailedMessage)
 		}
 	}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/webauthn"
+)
+
+// WebAuthnCredentialsGet is the handler listing the passkeys registered by the user, the oldest first.
+func WebAuthnCredentialsGet(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	credentials, err := ctx.Providers.StorageProvider.LoadWebAuthnCredentials(userSession.Username)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to load the passkeys of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	response := make([]webAuthnCredentialResponse, 0, len(credentials))
+
+	for _, credential := range credentials {
+		response = append(response, webAuthnCredentialResponse{
+			ID:              credential.ID,
+			Description:     credential.Description,
+			CreatedAt:       credential.Time,
+			LastUsedAt:      credential.LastUsed,
+			LastUsedIP:      credential.LastUsedIP,
+			LastUsedCountry: credential.LastUsedCountry,
+			SignCount:       credential.SignCount,
+			CloneWarning:    credential.CloneWarning,
+			BackupEligible:  credential.BackupEligible,
+			BackupState:     credential.BackupState,
+			AAGUID:          credential.AAGUID,
+			Model:           webauthn.AuthenticatorModel(credential.AAGUID),
+		})
+	}
+
+	if err = ctx.SetJSONBody(response); err != nil {
+		ctx.Logger.Errorf("Unable to set the passkeys in body: %s", err)
+	}
+}
+
+// WebAuthnCredentialRenamePost is the handler updating the description of a passkey registered by the user.
+func WebAuthnCredentialRenamePost(ctx *middlewares.AutheliaCtx) {
+	var bodyJSON webAuthnCredentialRenameRequestBody
+
+	if err := ctx.ParseBody(&bodyJSON); err != nil {
+		ctx.Error(err, operationFailedMessage)
+		return
+	}
+
+	if len(bodyJSON.ID) == 0 {
+		ctx.Error(fmt.Errorf("The ID of the passkey is required"), operationFailedMessage)
+		return
+	}
+
+	if utf8.RuneCountInString(bodyJSON.Description) > webAuthnCredentialDescriptionMaxLength {
+		ctx.Error(fmt.Errorf("The description of the passkey is longer than %d characters", webAuthnCredentialDescriptionMaxLength), operationFailedMessage)
+		return
+	}
+
+	userSession := ctx.GetSession()
+
+	count, err := ctx.Providers.StorageProvider.UpdateWebAuthnCredentialDescription(userSession.Username, bodyJSON.ID, bodyJSON.Description)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to rename the passkey of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	if count == 0 {
+		ctx.Error(fmt.Errorf("The passkey to rename is not registered by user %s", userSession.Username), operationFailedMessage)
+		return
+	}
+
+	ctx.ReplyOK()
+}
+
+// WebAuthnCredentialDeletePost is the handler deleting a passkey registered by the user, the other passkeys of the
+// user are left untouched.
+func WebAuthnCredentialDeletePost(ctx *middlewares.AutheliaCtx) {
+	var bodyJSON webAuthnCredentialDeleteRequestBody
+
+	if err := ctx.ParseBody(&bodyJSON); err != nil {
+		ctx.Error(err, operationFailedMessage)
+		return
+	}
+
+	if len(bodyJSON.ID) == 0 {
+		ctx.Error(fmt.Errorf("The ID of the passkey is required"), operationFailedMessage)
+		return
+	}
+
+	userSession := ctx.GetSession()
+
+	// The description is only loaded for the notification, the passkey is deleted without it.
+	description := ""
+	if credential, err := ctx.Providers.StorageProvider.LoadWebAuthnCredential(bodyJSON.ID); err == nil {
+		description = credential.Description
+	}
+
+	count, err := ctx.Providers.StorageProvider.DeleteWebAuthnCredential(userSession.Username, bodyJSON.ID)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to delete the passkey of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	if count == 0 {
+		ctx.Error(fmt.Errorf("The passkey to delete is not registered by user %s", userSession.Username), operationFailedMessage)
+		return
+	}
+
+	appendAuditEvent(ctx, userSession.Username, models.AuditEventPasskeyDeleted, "")
+	notifyPasskeyChange(ctx, userSession, "A passkey was removed", "A passkey was removed from your account", description)
+
+	ctx.ReplyOK()
+}
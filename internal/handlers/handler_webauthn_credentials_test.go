@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+)
+
+type WebAuthnCredentialsSuite struct {
+	suite.Suite
+
+	mock *mocks.MockAutheliaCtx
+}
+
+func (s *WebAuthnCredentialsSuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.mock.Ctx.Clock = &s.mock.Clock
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+}
+
+func (s *WebAuthnCredentialsSuite) TearDownTest() {
+	s.mock.Close()
+}
+
+func (s *WebAuthnCredentialsSuite) TestShouldListCredentials() {
+	created := time.Unix(1577880000, 0)
+	lastUsed := created.Add(time.Hour)
+
+	s.mock.StorageProviderMock.EXPECT().
+		LoadWebAuthnCredentials(gomock.Eq(testUsername)).
+		Return([]models.WebAuthnCredential{
+			{Username: testUsername, ID: []byte("abc"), Description: "Phone", Time: created, LastUsed: &lastUsed, LastUsedIP: "192.168.1.10", SignCount: 12, CloneWarning: true, BackupEligible: true, BackupState: true, AAGUID: "cb69481e-8ff7-4039-93ec-0a2729a154a8"},
+			{Username: testUsername, ID: []byte("def"), Time: created},
+			{Username: testUsername, ID: []byte("ghi"), Time: created, AAGUID: "00000000-0000-0000-0000-000000000001"},
+		}, nil)
+
+	WebAuthnCredentialsGet(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), []webAuthnCredentialResponse{
+		{ID: []byte("abc"), Description: "Phone", CreatedAt: created, LastUsedAt: &lastUsed, LastUsedIP: "192.168.1.10", SignCount: 12, CloneWarning: true, BackupEligible: true, BackupState: true, AAGUID: "cb69481e-8ff7-4039-93ec-0a2729a154a8", Model: "YubiKey 5 Series"},
+		{ID: []byte("def"), CreatedAt: created},
+		{ID: []byte("ghi"), CreatedAt: created, AAGUID: "00000000-0000-0000-0000-000000000001"},
+	})
+}
+
+func (s *WebAuthnCredentialsSuite) TestShouldRenameCredential() {
+	s.mock.StorageProviderMock.EXPECT().
+		UpdateWebAuthnCredentialDescription(gomock.Eq(testUsername), gomock.Eq([]byte("abc")), gomock.Eq("Laptop")).
+		Return(int64(1), nil)
+
+	s.mock.Ctx.Request.SetBodyString(`{"id":"YWJj","description":"Laptop"}`)
+
+	WebAuthnCredentialRenamePost(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), nil)
+}
+
+func (s *WebAuthnCredentialsSuite) TestShouldNotRenameCredentialWithLongDescription() {
+	s.mock.Ctx.Request.SetBodyString(fmt.Sprintf(`{"id":"YWJj","description":"%065d"}`, 0))
+
+	WebAuthnCredentialRenamePost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "The description of the passkey is longer than 64 characters", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), "Operation failed.")
+}
+
+func (s *WebAuthnCredentialsSuite) TestShouldNotRenameCredentialOfAnotherUser() {
+	s.mock.StorageProviderMock.EXPECT().
+		UpdateWebAuthnCredentialDescription(gomock.Eq(testUsername), gomock.Eq([]byte("abc")), gomock.Eq("Laptop")).
+		Return(int64(0), nil)
+
+	s.mock.Ctx.Request.SetBodyString(`{"id":"YWJj","description":"Laptop"}`)
+
+	WebAuthnCredentialRenamePost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "The passkey to rename is not registered by user john", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), "Operation failed.")
+}
+
+func (s *WebAuthnCredentialsSuite) TestShouldDeleteCredential() {
+	s.mock.StorageProviderMock.EXPECT().
+		LoadWebAuthnCredential(gomock.Eq([]byte("abc"))).
+		Return(&models.WebAuthnCredential{Username: testUsername, ID: []byte("abc")}, nil)
+
+	s.mock.StorageProviderMock.EXPECT().
+		DeleteWebAuthnCredential(gomock.Eq(testUsername), gomock.Eq([]byte("abc"))).
+		Return(int64(1), nil)
+
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuditEvent(gomock.Eq(models.AuditEvent{
+			Username: testUsername,
+			Type:     models.AuditEventPasskeyDeleted,
+			RemoteIP: "0.0.0.0",
+			Time:     s.mock.Clock.Now(),
+		}))
+
+	s.mock.Ctx.Request.SetBodyString(`{"id":"YWJj"}`)
+
+	WebAuthnCredentialDeletePost(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), nil)
+}
+
+func (s *WebAuthnCredentialsSuite) TestShouldNotifyUserOfDeletedCredential() {
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Emails = []string{"john@example.com"}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+
+	s.mock.StorageProviderMock.EXPECT().
+		LoadWebAuthnCredential(gomock.Eq([]byte("abc"))).
+		Return(&models.WebAuthnCredential{Username: testUsername, ID: []byte("abc"), Description: "Phone"}, nil)
+
+	s.mock.StorageProviderMock.EXPECT().
+		DeleteWebAuthnCredential(gomock.Eq(testUsername), gomock.Eq([]byte("abc"))).
+		Return(int64(1), nil)
+
+	s.mock.StorageProviderMock.EXPECT().
+		AppendAuditEvent(gomock.Any())
+
+	s.mock.NotifierMock.EXPECT().
+		Send(gomock.Eq("john@example.com"), gomock.Eq("A passkey was removed"), gomock.Any(), gomock.Eq("")).
+		DoAndReturn(func(recipient, subject, body, htmlBody string) error {
+			assert.Contains(s.T(), body, "A passkey was removed from your account.")
+			assert.Contains(s.T(), body, "Passkey: Phone")
+			assert.Contains(s.T(), body, "IP address: 0.0.0.0")
+			assert.Contains(s.T(), body, fmt.Sprintf("Date: %s", s.mock.Clock.Now().UTC().Format(time.RFC1123)))
+
+			return fmt.Errorf("connection refused")
+		})
+
+	s.mock.Ctx.Request.SetBodyString(`{"id":"YWJj"}`)
+
+	WebAuthnCredentialDeletePost(s.mock.Ctx)
+
+	// The passkey is deleted even if the user couldn't be notified.
+	s.mock.Assert200OK(s.T(), nil)
+	assert.Equal(s.T(), "Unable to notify user john of the change of their passkeys: connection refused", s.mock.Hook.LastEntry().Message)
+}
+
+func (s *WebAuthnCredentialsSuite) TestShouldNotDeleteCredentialWithoutID() {
+	s.mock.Ctx.Request.SetBodyString(`{}`)
+
+	WebAuthnCredentialDeletePost(s.mock.Ctx)
+
+	assert.Equal(s.T(), "The ID of the passkey is required", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), "Operation failed.")
+}
+
+func TestRunWebAuthnCredentialsSuite(t *testing.T) {
+	suite.Run(t, new(WebAuthnCredentialsSuite))
+}
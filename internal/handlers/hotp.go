@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+// HOTPVerifier is the interface for verifying the counter-based one-time passwords of the HOTP devices.
+type HOTPVerifier interface {
+	Verify(token string, device models.HOTPDevice) (uint64, bool, error)
+	Resync(token, nextToken string, device models.HOTPDevice) (uint64, bool, error)
+}
+
+// HOTPVerifierImpl the production implementation for HOTP verification.
+type HOTPVerifierImpl struct {
+	// LookAhead is the number of one-time passwords after the expected one which are valid.
+	LookAhead uint64
+	// ResyncWindow is the number of one-time passwords after the expected one within which the device is resynchronized.
+	ResyncWindow uint64
+}
+
+// Verify verifies the one-time password against the expected one and the ones within the look-ahead after it. It
+// returns the counter the device is expected to use next when the one-time password is valid.
+func (hv *HOTPVerifierImpl) Verify(token string, device models.HOTPDevice) (uint64, bool, error) {
+	counter, valid, err := hv.find(token, device, hv.LookAhead)
+	if err != nil || !valid {
+		return 0, false, err
+	}
+
+	return counter + 1, true, nil
+}
+
+// Resync verifies two consecutive one-time passwords within the resync window, for the devices which generated more
+// one-time passwords than the look-ahead without them being used. It returns the counter the device is expected to use
+// next when both one-time passwords are valid.
+func (hv *HOTPVerifierImpl) Resync(token, nextToken string, device models.HOTPDevice) (uint64, bool, error) {
+	counter, valid, err := hv.find(token, device, hv.ResyncWindow)
+	if err != nil || !valid {
+		return 0, false, err
+	}
+
+	valid, err = hotp.ValidateCustom(nextToken, counter+1, device.Secret, hotpValidateOpts(device))
+	if err != nil || !valid {
+		return 0, false, err
+	}
+
+	return counter + 2, true, nil
+}
+
+// find returns the counter of the one-time password among the expected one and the window after it.
+func (hv *HOTPVerifierImpl) find(token string, device models.HOTPDevice, window uint64) (uint64, bool, error) {
+	opts := hotpValidateOpts(device)
+
+	for i := device.Counter; i <= device.Counter+window; i++ {
+		valid, err := hotp.ValidateCustom(token, i, device.Secret, opts)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if valid {
+			return i, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+func hotpValidateOpts(device models.HOTPDevice) hotp.ValidateOpts {
+	return hotp.ValidateOpts{
+		Digits:    totpDigits(device.Digits),
+		Algorithm: otp.AlgorithmSHA1,
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+const testHOTPSecret = "GXZMPAG2QCP3D7PR2JUA7ULXJMOT5QZR"
+
+func hotpCode(t *testing.T, counter uint64, digits otp.Digits) string {
+	token, err := hotp.GenerateCodeCustom(testHOTPSecret, counter, hotp.ValidateOpts{
+		Digits:    digits,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestShouldVerifyHOTPWithinLookAhead(t *testing.T) {
+	verifier := &HOTPVerifierImpl{LookAhead: 2, ResyncWindow: 10}
+	device := models.HOTPDevice{Secret: testHOTPSecret, Counter: 5}
+
+	for counter, expected := range map[uint64]bool{
+		4: false,
+		5: true,
+		6: true,
+		7: true,
+		8: false,
+	} {
+		next, valid, err := verifier.Verify(hotpCode(t, counter, otp.DigitsSix), device)
+		require.NoError(t, err)
+		assert.Equal(t, expected, valid, "counter %d", counter)
+
+		if expected {
+			assert.Equal(t, counter+1, next)
+		}
+	}
+}
+
+func TestShouldVerifyHOTPWithTheDigitsOfTheDevice(t *testing.T) {
+	verifier := &HOTPVerifierImpl{LookAhead: 0, ResyncWindow: 0}
+	device := models.HOTPDevice{Secret: testHOTPSecret, Counter: 5, Digits: 8}
+
+	_, valid, err := verifier.Verify(hotpCode(t, 5, otp.DigitsEight), device)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	_, valid, err = verifier.Verify(hotpCode(t, 5, otp.DigitsSix), device)
+	assert.EqualError(t, err, "Input length unexpected")
+	assert.False(t, valid)
+}
+
+func TestShouldResyncHOTPWithConsecutiveTokens(t *testing.T) {
+	verifier := &HOTPVerifierImpl{LookAhead: 2, ResyncWindow: 50}
+	device := models.HOTPDevice{Secret: testHOTPSecret, Counter: 5}
+
+	next, valid, err := verifier.Resync(hotpCode(t, 40, otp.DigitsSix), hotpCode(t, 41, otp.DigitsSix), device)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, uint64(42), next)
+
+	_, valid, err = verifier.Resync(hotpCode(t, 40, otp.DigitsSix), hotpCode(t, 42, otp.DigitsSix), device)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	_, valid, err = verifier.Resync(hotpCode(t, 56, otp.DigitsSix), hotpCode(t, 57, otp.DigitsSix), device)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/templates"
+)
+
+// notifyPasskeyChange sends an email to the user when a passkey is registered or removed from their account so an
+// account takeover doesn't go unnoticed. The failures are only logged as the change is already done.
+func notifyPasskeyChange(ctx *middlewares.AutheliaCtx, userSession session.UserSession, subject, event, description string) {
+	if len(userSession.Emails) == 0 {
+		ctx.Logger.Debugf("User %s has no email address to notify of the change of their passkeys", userSession.Username)
+		return
+	}
+
+	if description == "" {
+		description = unnamedPasskeyDescription
+	}
+
+	body := new(bytes.Buffer)
+
+	err := templates.PasskeyNotificationEmailTemplate.Execute(body, map[string]interface{}{
+		"event":       event,
+		"description": description,
+		"ip":          ctx.RemoteIP().String(),
+		"time":        ctx.Clock.Now().UTC().Format(time.RFC1123),
+	})
+	if err != nil {
+		ctx.Logger.Errorf("Unable to render the notification of the change of the passkeys of user %s: %s", userSession.Username, err)
+		return
+	}
+
+	if err = ctx.Providers.Notifier.Send(userSession.Emails[0], subject, body.String(), ""); err != nil {
+		ctx.Logger.Errorf("Unable to notify user %s of the change of their passkeys: %s", userSession.Username, err)
+	}
+}
+
+// notifyRecoveryCodesChange sends an email to the user when recovery codes are generated for their account or when one
+// of them is used to sign in. The failures are only logged as the change is already done.
+func notifyRecoveryCodesChange(ctx *middlewares.AutheliaCtx, userSession session.UserSession, subject, event string, remaining int) {
+	if len(userSession.Emails) == 0 {
+		ctx.Logger.Debugf("User %s has no email address to notify of the change of their recovery codes", userSession.Username)
+		return
+	}
+
+	body := new(bytes.Buffer)
+
+	err := templates.RecoveryCodeNotificationEmailTemplate.Execute(body, map[string]interface{}{
+		"event":     event,
+		"remaining": remaining,
+		"ip":        ctx.RemoteIP().String(),
+		"time":      ctx.Clock.Now().UTC().Format(time.RFC1123),
+	})
+	if err != nil {
+		ctx.Logger.Errorf("Unable to render the notification of the change of the recovery codes of user %s: %s", userSession.Username, err)
+		return
+	}
+
+	if err = ctx.Providers.Notifier.Send(userSession.Emails[0], subject, body.String(), ""); err != nil {
+		ctx.Logger.Errorf("Unable to notify user %s of the change of their recovery codes: %s", userSession.Username, err)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/authelia/authelia/internal/authentication"
+)
+
+// refreshedProfile is the outcome of the background refresh of the profile of a user, along with the time it started.
+type refreshedProfile struct {
+	details   *authentication.UserDetails
+	err       error
+	refreshed time.Time
+}
+
+// asyncProfileRefresherDefaultTTL is the time the outcomes of the refreshes are kept when the profiles are refreshed on
+// every request, after which the outcomes of the users who didn't come back are evicted.
+const asyncProfileRefresherDefaultTTL = 5 * time.Minute
+
+// asyncProfileRefresher refreshes the profiles of the users from the authentication backend in the background so the
+// latency of the backend doesn't delay the verify endpoint. The requests are served with the profile of the session
+// while the refresh is in progress and its outcome is applied by the next request of the user.
+type asyncProfileRefresher struct {
+	maxAge time.Duration
+	ttl    time.Duration
+
+	mutex    sync.Mutex
+	inflight map[string]bool
+	profiles map[string]refreshedProfile
+	purged   time.Time
+}
+
+// newAsyncProfileRefresher returns a refresher whose outcomes are discarded once older than the given duration, unless
+// it's zero, or nil if the background refresh is disabled.
+func newAsyncProfileRefresher(enabled bool, maxAge time.Duration) *asyncProfileRefresher {
+	if !enabled {
+		return nil
+	}
+
+	ttl := maxAge
+	if ttl == 0 {
+		ttl = asyncProfileRefresherDefaultTTL
+	}
+
+	return &asyncProfileRefresher{
+		maxAge:   maxAge,
+		ttl:      ttl,
+		inflight: make(map[string]bool),
+		profiles: make(map[string]refreshedProfile),
+	}
+}
+
+// details returns the outcome of the last background refresh of the profile of the user. If there is none yet, a
+// refresh is started unless one is already in progress and both the details and the error are nil. The outcome of a
+// user who was removed from the backend is kept until it's evicted, so every session of the user is denied at once
+// instead of being served with its profile while a new refresh is in progress.
+func (r *asyncProfileRefresher) details(provider authentication.UserProvider, username string, now time.Time) (*authentication.UserDetails, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.evict(now)
+
+	if profile, ok := r.profiles[username]; ok {
+		if profile.err == authentication.ErrUserNotFound {
+			return nil, profile.err
+		}
+
+		delete(r.profiles, username)
+
+		if r.maxAge == 0 || now.Sub(profile.refreshed) <= r.maxAge {
+			return profile.details, profile.err
+		}
+	}
+
+	if r.inflight[username] {
+		return nil, nil
+	}
+
+	r.inflight[username] = true
+
+	go func() {
+		details, err := provider.GetDetails(username)
+
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+
+		delete(r.inflight, username)
+		r.profiles[username] = refreshedProfile{details: details, err: err, refreshed: now}
+	}()
+
+	return nil, nil
+}
+
+// evict deletes the outcomes older than the TTL of the refresher, which belong to the users who didn't come back since
+// their refresh. The outcomes are checked at most once per minute.
+func (r *asyncProfileRefresher) evict(now time.Time) {
+	if now.Sub(r.purged) < time.Minute {
+		return
+	}
+
+	for username, profile := range r.profiles {
+		if now.Sub(profile.refreshed) > r.ttl {
+			delete(r.profiles, username)
+		}
+	}
+
+	r.purged = now
+}
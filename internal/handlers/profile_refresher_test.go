@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/mocks"
+)
+
+func waitForRefreshedProfile(t *testing.T, refresher *asyncProfileRefresher, username string) {
+	require.Eventually(t, func() bool {
+		refresher.mutex.Lock()
+		defer refresher.mutex.Unlock()
+
+		_, ok := refresher.profiles[username]
+
+		return ok
+	}, time.Second, time.Millisecond)
+}
+
+func TestShouldNotCreateAsyncProfileRefresherWhenDisabled(t *testing.T) {
+	assert.Nil(t, newAsyncProfileRefresher(false, time.Minute))
+	assert.NotNil(t, newAsyncProfileRefresher(true, time.Minute))
+}
+
+func TestShouldRefreshProfileInBackground(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockUserProvider(ctrl)
+	provider.EXPECT().
+		GetDetails(gomock.Eq(testUsername)).
+		Return(&authentication.UserDetails{Username: testUsername, Groups: []string{"admin"}}, nil).
+		Times(1)
+
+	refresher := newAsyncProfileRefresher(true, time.Minute)
+	now := time.Now()
+
+	details, err := refresher.details(provider, testUsername, now)
+	assert.NoError(t, err)
+	assert.Nil(t, details)
+
+	waitForRefreshedProfile(t, refresher, testUsername)
+
+	details, err = refresher.details(provider, testUsername, now.Add(time.Second))
+	require.NoError(t, err)
+	require.NotNil(t, details)
+	assert.Equal(t, []string{"admin"}, details.Groups)
+}
+
+func TestShouldReturnErrorOfBackgroundRefresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockUserProvider(ctrl)
+	provider.EXPECT().
+		GetDetails(gomock.Eq(testUsername)).
+		Return(nil, authentication.ErrUserNotFound).
+		Times(1)
+
+	refresher := newAsyncProfileRefresher(true, time.Minute)
+	now := time.Now()
+
+	_, _ = refresher.details(provider, testUsername, now)
+
+	waitForRefreshedProfile(t, refresher, testUsername)
+
+	details, err := refresher.details(provider, testUsername, now)
+	assert.True(t, errors.Is(err, authentication.ErrUserNotFound))
+	assert.Nil(t, details)
+}
+
+func TestShouldDiscardOutdatedBackgroundRefresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockUserProvider(ctrl)
+	provider.EXPECT().
+		GetDetails(gomock.Eq(testUsername)).
+		Return(&authentication.UserDetails{Username: testUsername}, nil).
+		Times(2)
+
+	refresher := newAsyncProfileRefresher(true, time.Minute)
+	now := time.Now()
+
+	_, _ = refresher.details(provider, testUsername, now)
+
+	waitForRefreshedProfile(t, refresher, testUsername)
+
+	details, err := refresher.details(provider, testUsername, now.Add(2*time.Minute))
+	assert.NoError(t, err)
+	assert.Nil(t, details)
+
+	waitForRefreshedProfile(t, refresher, testUsername)
+}
+
+func TestShouldDenyEveryRequestOfRemovedUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockUserProvider(ctrl)
+	provider.EXPECT().
+		GetDetails(gomock.Eq(testUsername)).
+		Return(nil, authentication.ErrUserNotFound).
+		Times(1)
+
+	refresher := newAsyncProfileRefresher(true, time.Minute)
+	now := time.Now()
+
+	_, _ = refresher.details(provider, testUsername, now)
+
+	waitForRefreshedProfile(t, refresher, testUsername)
+
+	// The other sessions of the user are denied without waiting for another refresh, even after the refresh interval.
+	for _, at := range []time.Time{now, now.Add(time.Second), now.Add(time.Minute)} {
+		details, err := refresher.details(provider, testUsername, at)
+		assert.Equal(t, authentication.ErrUserNotFound, err)
+		assert.Nil(t, details)
+	}
+}
+
+func TestShouldEvictBackgroundRefreshOfUsersNotComingBack(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockUserProvider(ctrl)
+	provider.EXPECT().
+		GetDetails(gomock.Eq(testUsername)).
+		Return(nil, authentication.ErrUserNotFound).
+		Times(1)
+	provider.EXPECT().
+		GetDetails(gomock.Eq("harry")).
+		Return(&authentication.UserDetails{Username: "harry"}, nil).
+		Times(2)
+
+	refresher := newAsyncProfileRefresher(true, time.Minute)
+	now := time.Now()
+
+	_, _ = refresher.details(provider, testUsername, now)
+	_, _ = refresher.details(provider, "harry", now)
+
+	waitForRefreshedProfile(t, refresher, testUsername)
+	waitForRefreshedProfile(t, refresher, "harry")
+
+	_, _ = refresher.details(provider, "harry", now.Add(2*time.Minute))
+
+	waitForRefreshedProfile(t, refresher, "harry")
+
+	refresher.mutex.Lock()
+	defer refresher.mutex.Unlock()
+
+	assert.NotContains(t, refresher.profiles, testUsername)
+	assert.Len(t, refresher.profiles, 1)
+}
+
+func TestShouldUseDefaultTTLWhenRefreshingOnEveryRequest(t *testing.T) {
+	assert.Equal(t, asyncProfileRefresherDefaultTTL, newAsyncProfileRefresher(true, 0).ttl)
+	assert.Equal(t, time.Minute, newAsyncProfileRefresher(true, time.Minute).ttl)
+}
@@ -8,9 +8,17 @@ import (
 
 	"github.com/authelia/authelia/internal/authorization"
 	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
 	"github.com/authelia/authelia/internal/utils"
 )
 
+// isRedirectionSafe returns true if the URL is a https URL under one of the cookie domains.
+func isRedirectionSafe(ctx *middlewares.AutheliaCtx, targetURL url.URL) bool {
+	domain, ok := session.MatchDomain(ctx.Configuration.Session, targetURL.Hostname())
+
+	return ok && utils.IsRedirectionSafe(targetURL, domain.Domain)
+}
+
 // HandleOIDCWorkflowResponse handle the redirection upon authentication in the OIDC workflow.
 func HandleOIDCWorkflowResponse(ctx *middlewares.AutheliaCtx) {
 	userSession := ctx.GetSession()
@@ -85,7 +93,7 @@ func Handle1FAResponse(ctx *middlewares.AutheliaCtx, targetURI, requestMethod st
 		return
 	}
 
-	safeRedirection := utils.IsRedirectionSafe(*targetURL, ctx.Configuration.Session.Domain)
+	safeRedirection := isRedirectionSafe(ctx, *targetURL)
 
 	if !safeRedirection {
 		if !ctx.Providers.Authorizer.IsSecondFactorEnabled() && ctx.Configuration.DefaultRedirectionURL != "" {
@@ -132,7 +140,7 @@ func Handle2FAResponse(ctx *middlewares.AutheliaCtx, targetURI string) {
 		return
 	}
 
-	if targetURL != nil && utils.IsRedirectionSafe(*targetURL, ctx.Configuration.Session.Domain) {
+	if targetURL != nil && isRedirectionSafe(ctx, *targetURL) {
 		err := ctx.SetJSONBody(redirectResponse{Redirect: targetURI})
 		if err != nil {
 			ctx.Logger.Errorf("Unable to set redirection URL in body: %s", err)
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/storage"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// requireSecondFactorEnrollment sets the deadline of the grace period in the session of the users who signed in with
+// a first factor and are required to enroll a second factor but did not. The grace period starts the first time the
+// user signs in without a second factor, it is over immediately when it can't be determined.
+func requireSecondFactorEnrollment(ctx *middlewares.AutheliaCtx, userSession *session.UserSession) {
+	configuration := ctx.Configuration.SecondFactorEnrollment
+
+	if userSession.AuthenticationLevel != authentication.OneFactor ||
+		!isSecondFactorEnrollmentRequired(configuration, userSession.Groups) ||
+		!ctx.Providers.Authorizer.IsSecondFactorEnabled() {
+		return
+	}
+
+	enrolled, err := isSecondFactorEnrolled(ctx.Providers.StorageProvider, userSession.Username)
+	if err != nil {
+		ctx.Logger.Errorf("Unable to check whether user %s enrolled a second factor: %s", userSession.Username, err)
+
+		userSession.SecondFactorEnrollmentDeadline = ctx.Clock.Now().Unix()
+
+		return
+	}
+
+	if enrolled {
+		return
+	}
+
+	start, err := ctx.Providers.StorageProvider.StartSecondFactorEnrollment(userSession.Username, ctx.Clock.Now())
+	if err != nil {
+		ctx.Logger.Errorf("Unable to start the second factor enrollment grace period of user %s: %s", userSession.Username, err)
+
+		userSession.SecondFactorEnrollmentDeadline = ctx.Clock.Now().Unix()
+
+		return
+	}
+
+	// The grace period is validated when the configuration is loaded.
+	gracePeriod, _ := utils.ParseDurationString(configuration.GracePeriod)
+
+	userSession.SecondFactorEnrollmentDeadline = start.Add(gracePeriod).Unix()
+
+	ctx.Logger.Debugf("User %s is required to enroll a second factor before %s", userSession.Username, start.Add(gracePeriod))
+}
+
+// isSecondFactorEnrollmentRequired returns true when the policy requires the users of one of the groups to enroll a
+// second factor, the policy applies to every user when it has no groups.
+func isSecondFactorEnrollmentRequired(configuration *schema.SecondFactorEnrollmentConfiguration, groups []string) bool {
+	if configuration == nil {
+		return false
+	}
+
+	if len(configuration.Groups) == 0 {
+		return true
+	}
+
+	for _, group := range groups {
+		if utils.IsStringInSlice(group, configuration.Groups) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSecondFactorEnrolled returns true when the user registered any device used as a second factor.
+func isSecondFactorEnrolled(storageProvider storage.Provider, username string) (bool, error) {
+	_, err := storageProvider.LoadTOTPConfiguration(username)
+
+	switch {
+	case err == nil:
+		return true, nil
+	case err != storage.ErrNoTOTPSecret:
+		return false, err
+	}
+
+	_, _, err = storageProvider.LoadU2FDeviceHandle(username)
+
+	switch {
+	case err == nil:
+		return true, nil
+	case err != storage.ErrNoU2FDeviceHandle:
+		return false, err
+	}
+
+	credentials, err := storageProvider.LoadWebAuthnCredentials(username)
+
+	switch {
+	case err != nil:
+		return false, err
+	case len(credentials) != 0:
+		return true, nil
+	}
+
+	_, err = storageProvider.LoadHOTPDevice(username)
+
+	switch {
+	case err == nil:
+		return true, nil
+	case err != storage.ErrNoHOTPDevice:
+		return false, err
+	}
+
+	devices, err := storageProvider.LoadYubiKeyOTPDevices(username)
+	if err != nil {
+		return false, err
+	}
+
+	return len(devices) != 0, nil
+}
+
+// isWithinSecondFactorEnrollmentGracePeriod returns true when the user of the session signed in with a first factor
+// and is still within the grace period given to enroll a second factor.
+func isWithinSecondFactorEnrollmentGracePeriod(ctx *middlewares.AutheliaCtx, username, strategy string) bool {
+	if strategy != verifyStrategySessionCookie {
+		return false
+	}
+
+	userSession := ctx.GetSession()
+
+	return userSession.Username == username &&
+		userSession.AuthenticationLevel == authentication.OneFactor &&
+		userSession.SecondFactorEnrollmentDeadline != 0 &&
+		ctx.Clock.Now().Before(time.Unix(userSession.SecondFactorEnrollmentDeadline, 0))
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+)
+
+func expectNoSecondFactorEnrolled(mock *mocks.MockAutheliaCtx) {
+	mock.StorageProviderMock.EXPECT().LoadTOTPConfiguration(gomock.Eq(testUsername)).Return(nil, storage.ErrNoTOTPSecret)
+	mock.StorageProviderMock.EXPECT().LoadU2FDeviceHandle(gomock.Eq(testUsername)).Return(nil, nil, storage.ErrNoU2FDeviceHandle)
+	mock.StorageProviderMock.EXPECT().LoadWebAuthnCredentials(gomock.Eq(testUsername)).Return([]models.WebAuthnCredential{}, nil)
+	mock.StorageProviderMock.EXPECT().LoadHOTPDevice(gomock.Eq(testUsername)).Return(nil, storage.ErrNoHOTPDevice)
+	mock.StorageProviderMock.EXPECT().LoadYubiKeyOTPDevices(gomock.Eq(testUsername)).Return([]models.YubiKeyOTPDevice{}, nil)
+}
+
+func TestShouldRequireSecondFactorEnrollmentOfGroups(t *testing.T) {
+	assert.False(t, isSecondFactorEnrollmentRequired(nil, []string{"admins"}))
+	assert.True(t, isSecondFactorEnrollmentRequired(&schema.SecondFactorEnrollmentConfiguration{}, nil))
+	assert.True(t, isSecondFactorEnrollmentRequired(&schema.SecondFactorEnrollmentConfiguration{Groups: []string{"admins"}}, []string{"dev", "admins"}))
+	assert.False(t, isSecondFactorEnrollmentRequired(&schema.SecondFactorEnrollmentConfiguration{Groups: []string{"admins"}}, []string{"dev"}))
+}
+
+func TestShouldSetSecondFactorEnrollmentDeadlineOfUsersNotEnrolled(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Clock = &mock.Clock
+	mock.Ctx.Configuration.SecondFactorEnrollment = &schema.SecondFactorEnrollmentConfiguration{GracePeriod: "7d"}
+
+	expectNoSecondFactorEnrolled(mock)
+
+	start := mock.Clock.Now().Add(-24 * time.Hour)
+	mock.StorageProviderMock.EXPECT().
+		StartSecondFactorEnrollment(gomock.Eq(testUsername), gomock.Eq(mock.Clock.Now())).
+		Return(start, nil)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+
+	requireSecondFactorEnrollment(mock.Ctx, &userSession)
+
+	assert.Equal(t, start.Add(7*24*time.Hour).Unix(), userSession.SecondFactorEnrollmentDeadline)
+}
+
+func TestShouldNotSetSecondFactorEnrollmentDeadlineOfUsersEnrolled(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Configuration.SecondFactorEnrollment = &schema.SecondFactorEnrollmentConfiguration{GracePeriod: "7d"}
+
+	mock.StorageProviderMock.EXPECT().LoadTOTPConfiguration(gomock.Eq(testUsername)).Return(nil, storage.ErrNoTOTPSecret)
+	mock.StorageProviderMock.EXPECT().LoadU2FDeviceHandle(gomock.Eq(testUsername)).Return(nil, nil, storage.ErrNoU2FDeviceHandle)
+	mock.StorageProviderMock.EXPECT().
+		LoadWebAuthnCredentials(gomock.Eq(testUsername)).
+		Return([]models.WebAuthnCredential{{Username: testUsername}}, nil)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+
+	requireSecondFactorEnrollment(mock.Ctx, &userSession)
+
+	assert.Equal(t, int64(0), userSession.SecondFactorEnrollmentDeadline)
+}
+
+func TestShouldEndSecondFactorEnrollmentGracePeriodWhenStorageFails(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Clock = &mock.Clock
+	mock.Ctx.Configuration.SecondFactorEnrollment = &schema.SecondFactorEnrollmentConfiguration{GracePeriod: "7d"}
+
+	mock.StorageProviderMock.EXPECT().LoadTOTPConfiguration(gomock.Eq(testUsername)).Return(nil, errors.New("connection refused"))
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+
+	requireSecondFactorEnrollment(mock.Ctx, &userSession)
+
+	assert.Equal(t, mock.Clock.Now().Unix(), userSession.SecondFactorEnrollmentDeadline)
+	assert.Equal(t, "Unable to check whether user john enrolled a second factor: connection refused", mock.Hook.LastEntry().Message)
+}
+
+func TestShouldReturnSecondFactorEnrollmentDeadlineInUserInfo(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().LoadPreferred2FAMethod(gomock.Eq(testUsername)).Return("totp", nil)
+	mock.StorageProviderMock.EXPECT().LoadU2FDeviceHandle(gomock.Eq(testUsername)).Return(nil, nil, storage.ErrNoU2FDeviceHandle)
+	mock.StorageProviderMock.EXPECT().LoadTOTPConfiguration(gomock.Eq(testUsername)).Return(nil, storage.ErrNoTOTPSecret)
+	expectNoSecondFactorEnrolled(mock)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.SecondFactorEnrollmentDeadline = 1600000000
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+
+	UserInfoGet(mock.Ctx)
+
+	mock.Assert200OK(t, UserInfo{Method: "totp", EnrollmentRequired: true, EnrollmentDeadline: 1600000000})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/storage"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// secondFactorFallbackMethods returns the methods the user can sign in with in the order the portal offers them, the
+// preferred method first followed by the other methods in the configured order. Only the methods which are enabled,
+// allowed by the second factor policy of the user and for which the user registered a device are returned.
+func secondFactorFallbackMethods(ctx *middlewares.AutheliaCtx, userSession session.UserSession, userInfo UserInfo) ([]string, error) {
+	if ctx.Configuration.SecondFactorFallback == nil {
+		return nil, nil
+	}
+
+	methods := []string{}
+
+	for _, method := range append([]string{userInfo.Method}, ctx.Configuration.SecondFactorFallback.Methods...) {
+		if utils.IsStringInSlice(method, methods) ||
+			!isMethodEnabled(ctx.Configuration, method) ||
+			!isSecondFactorMethodAllowed(ctx.Configuration.SecondFactorPolicies, userSession.Groups, preferredMethodAuthnMethod(method)) {
+			continue
+		}
+
+		registered, err := isMethodRegistered(ctx.Providers.StorageProvider, userSession.Username, userInfo, method)
+		if err != nil {
+			return nil, err
+		}
+
+		if registered {
+			methods = append(methods, method)
+		}
+	}
+
+	return methods, nil
+}
+
+// isMethodRegistered returns true when the user registered the device required by the method, the methods which
+// don't require one are always registered.
+func isMethodRegistered(storageProvider storage.Provider, username string, userInfo UserInfo, method string) (bool, error) {
+	switch method {
+	case authentication.U2F:
+		return userInfo.HasU2F, nil
+	case authentication.TOTP:
+		return userInfo.HasTOTP, nil
+	case authentication.HOTP:
+		_, err := storageProvider.LoadHOTPDevice(username)
+
+		switch {
+		case err == nil:
+			return true, nil
+		case err == storage.ErrNoHOTPDevice:
+			return false, nil
+		default:
+			return false, err
+		}
+	case authentication.YubiKeyOTP:
+		devices, err := storageProvider.LoadYubiKeyOTPDevices(username)
+
+		return len(devices) != 0, err
+	default:
+		return true, nil
+	}
+}
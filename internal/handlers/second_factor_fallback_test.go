@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+)
+
+func TestShouldNotReturnFallbackMethodsWhenNotConfigured(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	methods, err := secondFactorFallbackMethods(mock.Ctx, mock.Ctx.GetSession(), UserInfo{Method: "totp", HasTOTP: true})
+
+	require.NoError(t, err)
+	assert.Nil(t, methods)
+}
+
+func TestShouldReturnRegisteredFallbackMethodsInConfiguredOrder(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Configuration.SecondFactorFallback = &schema.SecondFactorFallbackConfiguration{
+		Methods: []string{"u2f", "hotp", "yubikey_otp", "totp", "email"},
+	}
+	mock.Ctx.Configuration.YubiKeyOTP = &schema.YubiKeyOTPConfiguration{}
+	mock.Ctx.Configuration.HOTP = &schema.HOTPConfiguration{}
+
+	mock.StorageProviderMock.EXPECT().LoadHOTPDevice(gomock.Eq(testUsername)).Return(nil, storage.ErrNoHOTPDevice)
+	mock.StorageProviderMock.EXPECT().
+		LoadYubiKeyOTPDevices(gomock.Eq(testUsername)).
+		Return([]models.YubiKeyOTPDevice{{Username: testUsername}}, nil)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+
+	methods, err := secondFactorFallbackMethods(mock.Ctx, userSession, UserInfo{Method: "totp", HasTOTP: true, HasU2F: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"totp", "u2f", "yubikey_otp"}, methods)
+}
+
+func TestShouldSkipFallbackMethodsNotAllowedBySecondFactorPolicy(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Configuration.SecondFactorFallback = &schema.SecondFactorFallbackConfiguration{Methods: []string{"u2f", "totp"}}
+	mock.Ctx.Configuration.SecondFactorPolicies = testSecondFactorPolicies
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.Groups = []string{"dev"}
+
+	methods, err := secondFactorFallbackMethods(mock.Ctx, userSession, UserInfo{Method: "u2f", HasTOTP: true, HasU2F: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"totp"}, methods)
+}
+
+func TestShouldReturnFallbackMethodsInUserInfo(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Configuration.SecondFactorFallback = &schema.SecondFactorFallbackConfiguration{Methods: []string{"u2f", "totp"}}
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+
+	// The preferred method is not registered so the first registered method of the configured order is tried first.
+	setPreferencesExpectations(UserInfo{Method: "hotp", HasTOTP: true, HasU2F: true}, mock.StorageProviderMock)
+
+	UserInfoGet(mock.Ctx)
+
+	mock.Assert200OK(t, UserInfo{Method: "u2f", HasTOTP: true, HasU2F: true, FallbackMethods: []string{"u2f", "totp"}})
+}
+
+func TestShouldReturnErrorWhenFallbackMethodsFailToLoad(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Configuration.SecondFactorFallback = &schema.SecondFactorFallbackConfiguration{Methods: []string{"hotp", "totp"}}
+	mock.Ctx.Configuration.HOTP = &schema.HOTPConfiguration{}
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+
+	setPreferencesExpectations(UserInfo{Method: "totp", HasTOTP: true}, mock.StorageProviderMock)
+	mock.StorageProviderMock.EXPECT().LoadHOTPDevice(gomock.Eq(testUsername)).Return(nil, errors.New("connection refused"))
+
+	UserInfoGet(mock.Ctx)
+
+	mock.Assert200KO(t, "Operation failed.")
+	assert.Equal(t, "Unable to load the second factor methods of user john: connection refused", mock.Hook.LastEntry().Message)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// secondFactorPolicyMethods returns the second factor methods the users of the groups are allowed to use. The first
+// policy matching one of the groups applies, nil is returned when none does and the users can use any method.
+func secondFactorPolicyMethods(policies []schema.SecondFactorPolicyConfiguration, groups []string) []string {
+	for _, policy := range policies {
+		for _, group := range groups {
+			if utils.IsStringInSlice(group, policy.Groups) {
+				return policy.Methods
+			}
+		}
+	}
+
+	return nil
+}
+
+// isSecondFactorMethodSatisfied returns true when the authentication method performed by the user is one of the
+// second factor methods of the access control rules or of the second factor policies.
+func isSecondFactorMethodSatisfied(methods []string, authnMethod string) bool {
+	for _, method := range methods {
+		if authnMethod == method || (method == secondFactorMethodWebAuthn && (authnMethod == authnMethodU2F || authnMethod == authnMethodPasskey)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSecondFactorMethodAllowed returns true when the second factor policy of the groups allows the authentication method.
+func isSecondFactorMethodAllowed(policies []schema.SecondFactorPolicyConfiguration, groups []string, authnMethod string) bool {
+	methods := secondFactorPolicyMethods(policies, groups)
+
+	return methods == nil || isSecondFactorMethodSatisfied(methods, authnMethod)
+}
+
+// preferredMethodAuthnMethod returns the authentication method performed by the users signing in with one of the
+// methods they can select in the portal.
+func preferredMethodAuthnMethod(method string) string {
+	switch method {
+	case authentication.Push:
+		return authnMethodDuo
+	default:
+		return method
+	}
+}
+
+// isSecondFactorMethodDenied replies with an unauthorized error and returns true when the second factor policy of the
+// user does not allow the authentication method.
+func isSecondFactorMethodDenied(ctx *middlewares.AutheliaCtx, userSession session.UserSession, authnMethod string) bool {
+	if isSecondFactorMethodAllowed(ctx.Configuration.SecondFactorPolicies, userSession.Groups, authnMethod) {
+		return false
+	}
+
+	handleAuthenticationUnauthorized(ctx, fmt.Errorf("The second factor policy of user %s does not allow the %s method", userSession.Username, authnMethod), mfaValidationFailedMessage)
+
+	return true
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/authorization"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+)
+
+var testSecondFactorPolicies = []schema.SecondFactorPolicyConfiguration{
+	{Groups: []string{"admins"}, Methods: []string{"webauthn"}},
+	{Groups: []string{"dev"}, Methods: []string{"totp", "duo"}},
+}
+
+func setSecondFactorPolicyGroups(t *testing.T, mock *mocks.MockAutheliaCtx, groups ...string) {
+	mock.Ctx.Configuration.SecondFactorPolicies = testSecondFactorPolicies
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.Groups = groups
+	userSession.AuthenticationLevel = authentication.OneFactor
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+}
+
+func TestShouldApplyFirstMatchingSecondFactorPolicy(t *testing.T) {
+	assert.Nil(t, secondFactorPolicyMethods(testSecondFactorPolicies, []string{"users"}))
+	assert.Equal(t, []string{"webauthn"}, secondFactorPolicyMethods(testSecondFactorPolicies, []string{"dev", "admins"}))
+	assert.Equal(t, []string{"totp", "duo"}, secondFactorPolicyMethods(testSecondFactorPolicies, []string{"dev"}))
+}
+
+func TestShouldAllowSecondFactorMethodsOfPolicy(t *testing.T) {
+	assert.True(t, isSecondFactorMethodAllowed(nil, []string{"admins"}, authnMethodTOTP))
+	assert.True(t, isSecondFactorMethodAllowed(testSecondFactorPolicies, []string{"users"}, authnMethodEmail))
+	assert.True(t, isSecondFactorMethodAllowed(testSecondFactorPolicies, []string{"admins"}, authnMethodU2F))
+	assert.True(t, isSecondFactorMethodAllowed(testSecondFactorPolicies, []string{"admins"}, authnMethodPasskey))
+	assert.False(t, isSecondFactorMethodAllowed(testSecondFactorPolicies, []string{"admins"}, authnMethodTOTP))
+	assert.True(t, isSecondFactorMethodAllowed(testSecondFactorPolicies, []string{"dev"}, preferredMethodAuthnMethod(authentication.Push)))
+	assert.False(t, isSecondFactorMethodAllowed(testSecondFactorPolicies, []string{"dev"}, authnMethodU2F))
+}
+
+func TestShouldOnlyServeMethodsAllowedBySecondFactorPolicy(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{DefaultPolicy: "deny"})
+	mock.Ctx.Configuration.TOTP = &schema.TOTPConfiguration{Period: schema.DefaultTOTPConfiguration.Period}
+	mock.Ctx.Configuration.DuoAPI = &schema.DuoAPIConfiguration{}
+
+	setSecondFactorPolicyGroups(t, mock, "dev")
+
+	ConfigurationGet(mock.Ctx)
+
+	mock.Assert200OK(t, ConfigurationBody{
+		AvailableMethods: []string{"totp", "mobile_push"},
+		TOTPPeriod:       schema.DefaultTOTPConfiguration.Period,
+	})
+}
+
+func TestShouldNotSavePreferredMethodNotAllowedBySecondFactorPolicy(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	setSecondFactorPolicyGroups(t, mock, "admins")
+
+	mock.Ctx.Request.SetBody([]byte("{\"method\":\"totp\"}"))
+	MethodPreferencePost(mock.Ctx)
+
+	mock.Assert200KO(t, "Operation failed.")
+	assert.Equal(t, "Method 'totp' is not allowed by the second factor policy of user john", mock.Hook.LastEntry().Message)
+}
+
+func TestShouldReturnFirstMethodAllowedBySecondFactorPolicyInUserInfo(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	setSecondFactorPolicyGroups(t, mock, "admins")
+
+	setPreferencesExpectations(UserInfo{Method: "totp", HasTOTP: true}, mock.StorageProviderMock)
+
+	UserInfoGet(mock.Ctx)
+
+	mock.Assert200OK(t, UserInfo{Method: "u2f", HasTOTP: true})
+}
+
+func TestShouldDenySecondFactorMethodNotAllowedBySecondFactorPolicy(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	setSecondFactorPolicyGroups(t, mock, "admins")
+
+	verifier := NewMockTOTPVerifier(mock.Ctrl)
+	verifier.EXPECT().Verify(gomock.Any(), gomock.Any()).Times(0)
+
+	bodyBytes, err := json.Marshal(signTOTPRequestBody{Token: "abc"})
+	require.NoError(t, err)
+	mock.Ctx.Request.SetBody(bodyBytes)
+
+	SecondFactorTOTPPost(verifier)(mock.Ctx)
+
+	mock.Assert401KO(t, "Authentication failed, please retry later.")
+	assert.Equal(t, "The second factor policy of user john does not allow the totp method", mock.Hook.LastEntry().Message)
+	assert.Equal(t, authentication.OneFactor, mock.Ctx.GetSession().AuthenticationLevel)
+}
@@ -4,28 +4,85 @@ import (
 	"time"
 
 	"github.com/pquerna/otp"
-	"github.com/pquerna/otp/totp"
+	"github.com/pquerna/otp/hotp"
+
+	"github.com/authelia/authelia/internal/models"
 )
 
 // TOTPVerifier is the interface for verifying TOTPs.
 type TOTPVerifier interface {
-	Verify(token, secret string) (bool, error)
+	Verify(token string, configuration models.TOTPConfiguration) (bool, error)
 }
 
 // TOTPVerifierImpl the production implementation for TOTP verification.
 type TOTPVerifierImpl struct {
+	// Period is the period of the configurations registered before their period was stored.
 	Period uint
-	Skew   uint
+	// SkewBefore is the number of one-time passwords before the current one which are valid.
+	SkewBefore uint
+	// SkewAfter is the number of one-time passwords after the current one which are valid.
+	SkewAfter uint
+}
+
+// Verify verifies TOTPs with the algorithm, digits and period of the configuration of the user.
+func (tv *TOTPVerifierImpl) Verify(token string, configuration models.TOTPConfiguration) (bool, error) {
+	return tv.verifyAt(token, configuration, time.Now().UTC())
+}
+
+// verifyAt verifies the TOTP at the given time, against the current one-time password and the ones within the skew on
+// either side of it. A token which doesn't have the digits of the configuration is invalid rather than an error.
+func (tv *TOTPVerifierImpl) verifyAt(token string, configuration models.TOTPConfiguration, t time.Time) (bool, error) {
+	opts := hotp.ValidateOpts{
+		Digits:    totpDigits(configuration.Digits),
+		Algorithm: totpAlgorithm(configuration.Algorithm),
+	}
+
+	period := tv.Period
+	if configuration.Period > 0 {
+		period = uint(configuration.Period)
+	}
+
+	counter := int64(t.Unix()) / int64(period)
+
+	for i := counter - int64(tv.SkewBefore); i <= counter+int64(tv.SkewAfter); i++ {
+		if i < 0 {
+			continue
+		}
+
+		valid, err := hotp.ValidateCustom(token, uint64(i), configuration.Secret, opts)
+		if err == otp.ErrValidateInputInvalidLength {
+			return false, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if valid {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// totpAlgorithm returns the algorithm of the one-time passwords, SHA1 when it is not set.
+func totpAlgorithm(algorithm string) otp.Algorithm {
+	switch algorithm {
+	case models.TOTPAlgorithmSHA256:
+		return otp.AlgorithmSHA256
+	case models.TOTPAlgorithmSHA512:
+		return otp.AlgorithmSHA512
+	default:
+		return otp.AlgorithmSHA1
+	}
 }
 
-// Verify verifies TOTPs.
-func (tv *TOTPVerifierImpl) Verify(token, secret string) (bool, error) {
-	opts := totp.ValidateOpts{
-		Period:    tv.Period,
-		Skew:      tv.Skew,
-		Digits:    otp.DigitsSix,
-		Algorithm: otp.AlgorithmSHA1,
+// totpDigits returns the number of digits of the one-time passwords, 6 when it is not set.
+func totpDigits(digits int) otp.Digits {
+	if digits == 8 {
+		return otp.DigitsEight
 	}
 
-	return totp.ValidateCustom(token, secret, time.Now().UTC(), opts)
+	return otp.DigitsSix
 }
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+func TestShouldVerifyTOTPWithinSkewBeforeAndAfter(t *testing.T) {
+	secret := "GXZMPAG2QCP3D7PR2JUA7ULXJMOT5QZR"
+	now := time.Unix(1577880000, 0)
+	verifier := &TOTPVerifierImpl{Period: 30, SkewBefore: 2, SkewAfter: 0}
+
+	code := func(offset time.Duration) string {
+		token, err := totp.GenerateCodeCustom(secret, now.Add(offset), totp.ValidateOpts{
+			Period:    30,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		require.NoError(t, err)
+
+		return token
+	}
+
+	for offset, expected := range map[time.Duration]bool{
+		0:                 true,
+		-30 * time.Second: true,
+		-60 * time.Second: true,
+		-90 * time.Second: false,
+		30 * time.Second:  false,
+	} {
+		valid, err := verifier.verifyAt(code(offset), models.TOTPConfiguration{Secret: secret}, now)
+		require.NoError(t, err)
+		assert.Equal(t, expected, valid, "offset %s", offset)
+	}
+}
+
+func TestShouldVerifyTOTPWithTheParametersOfTheConfiguration(t *testing.T) {
+	configuration := models.TOTPConfiguration{
+		Secret:    "GXZMPAG2QCP3D7PR2JUA7ULXJMOT5QZR",
+		Algorithm: models.TOTPAlgorithmSHA256,
+		Digits:    8,
+		Period:    60,
+	}
+	now := time.Unix(1577880000, 0)
+	verifier := &TOTPVerifierImpl{Period: 30, SkewBefore: 0, SkewAfter: 0}
+
+	token, err := totp.GenerateCodeCustom(configuration.Secret, now, totp.ValidateOpts{
+		Period:    60,
+		Digits:    otp.DigitsEight,
+		Algorithm: otp.AlgorithmSHA256,
+	})
+	require.NoError(t, err)
+
+	valid, err := verifier.verifyAt(token, configuration, now)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	// The token of the algorithm and the period of the configuration isn't valid with the defaults.
+	token, err = totp.GenerateCodeCustom(configuration.Secret, now, totp.ValidateOpts{
+		Period:    60,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA256,
+	})
+	require.NoError(t, err)
+
+	configuration.Algorithm, configuration.Digits, configuration.Period = "", 0, 0
+
+	valid, err = verifier.verifyAt(token, configuration, now)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestShouldNotVerifyTOTPWithOtherDigits(t *testing.T) {
+	configuration := models.TOTPConfiguration{Secret: "GXZMPAG2QCP3D7PR2JUA7ULXJMOT5QZR"}
+	now := time.Unix(1577880000, 0)
+	verifier := &TOTPVerifierImpl{Period: 30, SkewBefore: 1, SkewAfter: 1}
+
+	token, err := totp.GenerateCodeCustom(configuration.Secret, now, totp.ValidateOpts{
+		Period:    30,
+		Digits:    otp.DigitsEight,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+	require.NoError(t, err)
+
+	for _, token := range []string{token, token[:5], ""} {
+		valid, err := verifier.verifyAt(token, configuration, now)
+		assert.NoError(t, err)
+		assert.False(t, valid, token)
+	}
+}
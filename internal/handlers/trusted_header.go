@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
+)
+
+// trustedHeaderAuthentication authenticates the requests with the username set in a header by a trusted reverse proxy,
+// for instance a proxy performing its own single sign-on in front of Authelia.
+type trustedHeaderAuthentication struct {
+	header       string
+	networks     []*net.IPNet
+	secretHeader string
+	secret       []byte
+}
+
+// newTrustedHeaderAuthentication returns the trusted header authentication of the configuration, or nil if it's not
+// configured.
+func newTrustedHeaderAuthentication(configuration *schema.TrustedHeaderAuthenticationConfiguration) *trustedHeaderAuthentication {
+	if configuration == nil {
+		return nil
+	}
+
+	return &trustedHeaderAuthentication{
+		header:       configuration.Header,
+		networks:     parseTrustedNetworks(configuration.TrustedNetworks),
+		secretHeader: configuration.SecretHeader,
+		secret:       []byte(configuration.Secret),
+	}
+}
+
+// parseTrustedNetworks parses the IP addresses and CIDR networks of the reverse proxies.
+func parseTrustedNetworks(trustedNetworks []string) (networks []*net.IPNet) {
+	for _, network := range trustedNetworks {
+		if !strings.Contains(network, "/") {
+			if ip := net.ParseIP(network); ip.To4() != nil {
+				network += "/32"
+			} else {
+				network += "/128"
+			}
+		}
+
+		// The networks are checked by the configuration validator.
+		if _, cidr, err := net.ParseCIDR(network); err == nil {
+			networks = append(networks, cidr)
+		}
+	}
+
+	return networks
+}
+
+// isIPTrusted returns true if the IP belongs to one of the networks.
+func isIPTrusted(ip net.IP, networks []*net.IPNet) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isTrusted returns true if the request comes from one of the trusted networks and carries the shared secret, when
+// they're configured. The network is checked against the address of the TCP peer as the X-Forwarded-For header can be
+// set by anyone, and the header is never trusted when neither the networks nor the secret are configured.
+func (a *trustedHeaderAuthentication) isTrusted(ctx *middlewares.AutheliaCtx) bool {
+	if len(a.networks) == 0 && len(a.secret) == 0 {
+		return false
+	}
+
+	if len(a.networks) != 0 && !isIPTrusted(ctx.RequestCtx.RemoteIP(), a.networks) {
+		return false
+	}
+
+	if len(a.secret) != 0 {
+		return subtle.ConstantTimeCompare(ctx.Request.Header.Peek(a.secretHeader), a.secret) == 1
+	}
+
+	return true
+}
+
+// verifyTrustedHeader verifies the username set in the trusted header and retrieves the details of the user.
+func verifyTrustedHeader(ctx *middlewares.AutheliaCtx, trustedHeader *trustedHeaderAuthentication, username string, userSession *session.UserSession) (name string, groups, emails []string, authLevel authentication.Level, err error) {
+	if !trustedHeader.isTrusted(ctx) {
+		return "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("The %s header of user %s was not set by a trusted reverse proxy", trustedHeader.header, username)
+	}
+
+	return getUpstreamUserDetails(ctx, username, userSession)
+}
+
+// getUpstreamUserDetails retrieves the details of a user authenticated upstream of Authelia. The user is authenticated
+// with one factor, unless the session of the same user is authenticated with two factors.
+func getUpstreamUserDetails(ctx *middlewares.AutheliaCtx, username string, userSession *session.UserSession) (name string, groups, emails []string, authLevel authentication.Level, err error) {
+	details, err := ctx.Providers.UserProvider.GetDetails(username)
+	if err != nil {
+		return "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("Unable to retrieve details of user %s: %s", username, err)
+	}
+
+	authLevel = authentication.OneFactor
+
+	if strings.EqualFold(userSession.Username, username) && userSession.AuthenticationLevel > authLevel {
+		authLevel = userSession.AuthenticationLevel
+	}
+
+	return details.DisplayName, details.Groups, details.Emails, authLevel, nil
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"time"
+
 	"github.com/tstranex/u2f"
 
 	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/webauthn"
 )
 
 // MethodList is the list of available methods.
@@ -11,6 +14,17 @@ type MethodList = []string
 
 type authorizationMatching int
 
+func (m authorizationMatching) String() string {
+	switch m {
+	case Forbidden:
+		return "forbidden"
+	case NotAuthorized:
+		return "not_authorized"
+	default:
+		return "authorized"
+	}
+}
+
 // UserInfo is the model of user info and second factor preferences.
 type UserInfo struct {
 	// The users display name.
@@ -24,6 +38,28 @@ type UserInfo struct {
 
 	// True if a TOTP device has been registered.
 	HasTOTP bool `json:"has_totp" valid:"required"`
+
+	// The time, the IP address and the country of the client of the last sign in with the TOTP device, so the user can
+	// spot a sign in they didn't make.
+	TOTPLastUsedAt      *time.Time `json:"totp_last_used_at,omitempty"`
+	TOTPLastUsedIP      string     `json:"totp_last_used_ip,omitempty"`
+	TOTPLastUsedCountry string     `json:"totp_last_used_country,omitempty"`
+
+	// The methods offered in order when the second factor fallback is configured, the first one being tried first.
+	FallbackMethods []string `json:"fallback_methods,omitempty"`
+
+	// True if the user is required to enroll a second factor.
+	EnrollmentRequired bool `json:"enrollment_required"`
+
+	// The time until which the user can access the resources requiring a second factor without enrolling one.
+	EnrollmentDeadline int64 `json:"enrollment_deadline,omitempty"`
+}
+
+// signPasswordRequestBody model of the request body received by the password endpoint completing the sign in with a
+// passkey.
+type signPasswordRequestBody struct {
+	Password  string `json:"password" valid:"required"`
+	TargetURL string `json:"targetURL"`
 }
 
 // signTOTPRequestBody model of the request body received by TOTP authentication endpoint.
@@ -32,6 +68,23 @@ type signTOTPRequestBody struct {
 	TargetURL string `json:"targetURL"`
 }
 
+// registerTOTPRequestBody model of the request body received by the endpoint replacing the TOTP secret.
+type registerTOTPRequestBody struct {
+	Token string `json:"token" valid:"required"`
+}
+
+// signRecoveryCodeRequestBody model of the request body received by the recovery code endpoint.
+type signRecoveryCodeRequestBody struct {
+	Code      string `json:"code" valid:"required"`
+	TargetURL string `json:"targetURL"`
+}
+
+// signEmailRequestBody model of the request body received by the email one-time code endpoint.
+type signEmailRequestBody struct {
+	Code      string `json:"code" valid:"required"`
+	TargetURL string `json:"targetURL"`
+}
+
 // signU2FRequestBody model of the request body of U2F authentication endpoint.
 type signU2FRequestBody struct {
 	SignResponse u2f.SignResponse `json:"signResponse"`
@@ -42,6 +95,89 @@ type signDuoRequestBody struct {
 	TargetURL string `json:"targetURL"`
 }
 
+// duoUniversalResponse model of the response of the endpoint starting the Duo Universal Prompt flow.
+type duoUniversalResponse struct {
+	URL string `json:"url"`
+}
+
+// signPushApprovalRequestBody model of the request body received by the push approval endpoints.
+type signPushApprovalRequestBody struct {
+	TargetURL string `json:"targetURL"`
+}
+
+// pushApprovalStatusResponse model of the response of the push approval endpoint while the request is pending.
+type pushApprovalStatusResponse struct {
+	Status string `json:"status"`
+}
+
+// signPrivacyIDEARequestBody model of the request body received by the privacyIDEA endpoints, the token is only set
+// when the user signs in with a one-time password.
+type signPrivacyIDEARequestBody struct {
+	Token     string `json:"token"`
+	TargetURL string `json:"targetURL"`
+}
+
+// privacyIDEATokensResponse model of the response of the endpoint returning the types of the tokens of the user.
+type privacyIDEATokensResponse struct {
+	Enrolled bool     `json:"enrolled"`
+	Types    []string `json:"types"`
+}
+
+// signHOTPRequestBody model of the request body received by the HOTP endpoint, the next token is only set when the
+// user resynchronizes their device with two consecutive one-time passwords.
+type signHOTPRequestBody struct {
+	Token     string `json:"token" valid:"required"`
+	NextToken string `json:"nextToken"`
+	TargetURL string `json:"targetURL"`
+}
+
+// signYubiKeyOTPRequestBody model of the request body received by the YubiKey OTP endpoint.
+type signYubiKeyOTPRequestBody struct {
+	Token     string `json:"token" valid:"required"`
+	TargetURL string `json:"targetURL"`
+}
+
+// firstFactorPasskeyRequestBody represents the JSON body received by the passkey endpoint.
+type firstFactorPasskeyRequestBody struct {
+	Credential    webauthn.CredentialAssertionResponse `json:"credential"`
+	TargetURL     string                               `json:"targetURL"`
+	RequestMethod string                               `json:"requestMethod"`
+	Conditional   bool                                 `json:"conditional"`
+}
+
+// webAuthnRegistrationRequestBody represents the JSON body received by the passkey registration endpoint.
+type webAuthnRegistrationRequestBody struct {
+	Credential  webauthn.CredentialCreationResponse `json:"credential"`
+	Description string                              `json:"description"`
+}
+
+// webAuthnCredentialRenameRequestBody represents the JSON body received by the passkey rename endpoint.
+type webAuthnCredentialRenameRequestBody struct {
+	ID          webauthn.URLEncodedBase64 `json:"id"`
+	Description string                    `json:"description"`
+}
+
+// webAuthnCredentialDeleteRequestBody represents the JSON body received by the passkey delete endpoint.
+type webAuthnCredentialDeleteRequestBody struct {
+	ID webauthn.URLEncodedBase64 `json:"id"`
+}
+
+// webAuthnCredentialResponse represents a passkey of the user in the response of the passkeys endpoint.
+type webAuthnCredentialResponse struct {
+	ID              webauthn.URLEncodedBase64 `json:"id"`
+	Description     string                    `json:"description"`
+	CreatedAt       time.Time                 `json:"createdAt"`
+	LastUsedAt      *time.Time                `json:"lastUsedAt,omitempty"`
+	LastUsedIP      string                    `json:"lastUsedIP,omitempty"`
+	LastUsedCountry string                    `json:"lastUsedCountry,omitempty"`
+	SignCount       uint32                    `json:"signCount"`
+	CloneWarning    bool                      `json:"cloneWarning"`
+	BackupEligible  bool                      `json:"backupEligible"`
+	BackupState     bool                      `json:"backupState"`
+	AAGUID          string                    `json:"aaguid,omitempty"`
+	Model           string                    `json:"model,omitempty"`
+}
+
 // firstFactorRequestBody represents the JSON body received by the endpoint.
 type firstFactorRequestBody struct {
 	Username       string `json:"username" valid:"required"`
@@ -53,6 +189,38 @@ type firstFactorRequestBody struct {
 	// TODO(c.michaud): add required validation once the above PR is merged.
 }
 
+// firstFactorCertificateRequestBody represents the JSON body received by the client certificate endpoint.
+type firstFactorCertificateRequestBody struct {
+	TargetURL     string `json:"targetURL"`
+	RequestMethod string `json:"requestMethod"`
+}
+
+// recoveryCodesResponse is the response of the endpoint generating the recovery codes, the only time the codes are
+// displayed to the user.
+type recoveryCodesResponse struct {
+	Codes []string `json:"codes"`
+}
+
+// recoveryCodesCountResponse is the response of the endpoint counting the recovery codes the user has not used yet.
+type recoveryCodesCountResponse struct {
+	Remaining int `json:"remaining"`
+}
+
+// trustedDeviceRevokeRequestBody represents the JSON body received by the trusted device revoke endpoint.
+type trustedDeviceRevokeRequestBody struct {
+	ID string `json:"id"`
+}
+
+// trustedDeviceResponse represents a device trusted by the user in the response of the trusted devices endpoint.
+type trustedDeviceResponse struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	RemoteIP    string    `json:"remoteIP,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	Current     bool      `json:"current"`
+}
+
 // redirectResponse represent the response sent by the first factor endpoint
 // when a redirection URL has been provided.
 type redirectResponse struct {
@@ -70,6 +238,7 @@ type StateResponse struct {
 	Username              string               `json:"username"`
 	AuthenticationLevel   authentication.Level `json:"authentication_level"`
 	DefaultRedirectionURL string               `json:"default_redirection_url"`
+	PasswordRequired      bool                 `json:"password_required"`
 }
 
 // resetPasswordStep1RequestBody model of the reset password (step1) request body.
@@ -81,3 +250,14 @@ type resetPasswordStep1RequestBody struct {
 type resetPasswordStep2RequestBody struct {
 	Password string `json:"password"`
 }
+
+// healthResponseBody model of the response body of the health check endpoint.
+type healthResponseBody struct {
+	Storage storageHealth `json:"storage"`
+}
+
+// storageHealth model of the health of the storage backend in the response of the health check endpoint.
+type storageHealth struct {
+	SchemaVersion int    `json:"schema_version"`
+	Latency       string `json:"latency"`
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/middlewares"
+)
+
+// verifyBypassMatcher matches the requests the verify endpoint allows without looking up the session, such as the
+// liveness probes and the uptime monitors which would otherwise create sessions and fill the logs.
+type verifyBypassMatcher struct {
+	domains    []string
+	paths      []string
+	userAgents []string
+	networks   []*net.IPNet
+}
+
+// matches returns true if the request targets one of the domains of the matcher and matches every other criterion of
+// the matcher which is set. The networks are matched against the TCP peer since the forwarded headers are controlled by
+// the clients.
+func (m verifyBypassMatcher) matches(ctx *middlewares.AutheliaCtx, targetURL *url.URL) bool {
+	if !isDomainOfAny(targetURL.Hostname(), m.domains) {
+		return false
+	}
+
+	if len(m.paths) != 0 && !hasAnyPathPrefix(targetURL.Path, m.paths) {
+		return false
+	}
+
+	if len(m.userAgents) != 0 && !hasAnyPrefix(string(ctx.Request.Header.UserAgent()), m.userAgents) {
+		return false
+	}
+
+	if len(m.networks) != 0 && !isIPTrusted(ctx.RequestCtx.RemoteIP(), m.networks) {
+		return false
+	}
+
+	return true
+}
+
+// isDomainOfAny returns true if the host is one of the domains, the domains starting with *. matching their
+// subdomains.
+func isDomainOfAny(host string, domains []string) bool {
+	host = strings.ToLower(host)
+
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+
+		if host == domain || (strings.HasPrefix(domain, "*.") && strings.HasSuffix(host, domain[1:])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasAnyPathPrefix returns true if the cleaned path is one of the prefixes or is below one of them, the prefixes
+// matching whole segments so /health doesn't match /healthz and /healthz/../admin doesn't match /healthz.
+func hasAnyPathPrefix(value string, prefixes []string) bool {
+	value = path.Clean("/" + value)
+
+	for _, prefix := range prefixes {
+		prefix = path.Clean(prefix)
+
+		if value == prefix || prefix == "/" || strings.HasPrefix(value, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasAnyPrefix(value string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// VerifyBypass returns the handler allowing the requests matched by one of the bypass matchers of the configuration
+// before the session is looked up, the other requests being verified by next.
+func VerifyBypass(configuration []schema.ServerVerifyBypassConfiguration, next middlewares.RequestHandler) middlewares.RequestHandler {
+	matchers := make([]verifyBypassMatcher, 0, len(configuration))
+
+	for _, bypass := range configuration {
+		matchers = append(matchers, verifyBypassMatcher{
+			domains:    bypass.Domains,
+			paths:      bypass.Paths,
+			userAgents: bypass.UserAgents,
+			networks:   parseTrustedNetworks(bypass.Networks),
+		})
+	}
+
+	return func(ctx *middlewares.AutheliaCtx) {
+		targetURL, _, err := getVerifyObject(ctx, ctx.Configuration.Server.VerifyObjectHeaders)
+		if err != nil {
+			// The error is handled by the verify handler.
+			next(ctx)
+			return
+		}
+
+		for _, matcher := range matchers {
+			if matcher.matches(ctx, targetURL) {
+				ctx.Logger.Tracef("Access to %s by %s is bypassed", targetURL.String(), ctx.RemoteIP().String())
+				return
+			}
+		}
+
+		next(ctx)
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+)
+
+func TestShouldBypassHealthChecks(t *testing.T) {
+	verify := VerifyBypass([]schema.ServerVerifyBypassConfiguration{
+		{Domains: []string{"two-factor.example.com"}, Paths: []string{"/healthz/"}, UserAgents: []string{"kube-probe/"}},
+		{Domains: []string{"*.example.com"}, Paths: []string{"/status"}, Networks: []string{"10.0.0.0/8"}},
+	}, VerifyGet(verifyGetCfg, 0, 0))
+
+	testCases := []struct {
+		name          string
+		url           string
+		userAgent     string
+		remoteIP      string
+		forwardedFor  string
+		expStatusCode int
+	}{
+		{"LivenessProbe", "https://two-factor.example.com/healthz", "kube-probe/1.21", "192.168.1.1", "", 200},
+		{"LivenessProbeBelowPath", "https://two-factor.example.com/healthz/ready", "kube-probe/1.21", "192.168.1.1", "", 200},
+		{"LivenessProbeOtherPath", "https://two-factor.example.com/admin", "kube-probe/1.21", "192.168.1.1", "", 401},
+		{"LivenessProbePathSharingPrefix", "https://two-factor.example.com/healthzadmin", "kube-probe/1.21", "192.168.1.1", "", 401},
+		{"LivenessProbePathTraversal", "https://two-factor.example.com/healthz/../admin", "kube-probe/1.21", "192.168.1.1", "", 401},
+		{"LivenessProbeOtherDomain", "https://admin.example.com/healthz", "kube-probe/1.21", "192.168.1.1", "", 401},
+		{"BrowserHealthPath", "https://two-factor.example.com/healthz", "Mozilla/5.0", "192.168.1.1", "", 401},
+		{"Monitor", "https://two-factor.example.com/status", "Uptime-Kuma/1.0", "10.1.1.1", "", 200},
+		{"MonitorSubdomain", "https://admin.example.com/status", "Uptime-Kuma/1.0", "10.1.1.1", "", 200},
+		{"MonitorOtherDomain", "https://example.org/status", "Uptime-Kuma/1.0", "10.1.1.1", "", 401},
+		{"MonitorSpoofedForwardedFor", "https://two-factor.example.com/status", "Uptime-Kuma/1.0", "192.168.1.1", "10.1.1.1", 401},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mock := mocks.NewMockAutheliaCtx(t)
+			defer mock.Close()
+
+			mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP(tc.remoteIP)})
+			mock.Ctx.Request.Header.Set("X-Original-URL", tc.url)
+			mock.Ctx.Request.Header.SetUserAgent(tc.userAgent)
+
+			if tc.forwardedFor != "" {
+				mock.Ctx.Request.Header.Set("X-Forwarded-For", tc.forwardedFor)
+			}
+
+			verify(mock.Ctx)
+
+			assert.Equal(t, tc.expStatusCode, mock.Ctx.Response.StatusCode())
+			assert.Nil(t, mock.Ctx.Response.Header.Peek("Remote-User"))
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/authorization"
+	"github.com/authelia/authelia/internal/middlewares"
+)
+
+// verifyDecisionCacheMaxSize is the number of decisions the cache keeps at most, the decisions expiring first being
+// evicted once it's full.
+const verifyDecisionCacheMaxSize = 10000
+
+// verifyDecision is an authorization decision of the verify endpoint along with the details of the user it was made
+// for, which are required to set the forwarded headers.
+type verifyDecision struct {
+	authorized authorizationMatching
+	username   string
+	name       string
+	groups     []string
+	emails     []string
+	rule       int
+	level      authorization.Level
+	authLevel  authentication.Level
+	methods    []string
+	attributes map[string][]string
+	expires    time.Time
+}
+
+// verifyDecisionCache caches the decisions of the verify endpoint for the requests authenticated with the session of a
+// user so the subrequests issued by the proxies for every asset of a page aren't all authorized again. The decisions
+// of the WebSocket upgrade requests can be kept longer so the sockets re-checked by the proxies mid-connection are not
+// dropped.
+type verifyDecisionCache struct {
+	duration          time.Duration
+	webSocketDuration time.Duration
+
+	mutex     sync.RWMutex
+	decisions map[string]verifyDecision
+	maxSize   int
+	purged    time.Time
+}
+
+// newVerifyDecisionCache returns a cache keeping the decisions for the given durations, or nil if it's disabled. The
+// decisions of the WebSocket upgrade requests are kept for the regular duration when their own duration is zero.
+func newVerifyDecisionCache(duration, webSocketDuration time.Duration) *verifyDecisionCache {
+	if duration < 0 {
+		duration = 0
+	}
+
+	if webSocketDuration <= 0 {
+		webSocketDuration = duration
+	}
+
+	if duration == 0 && webSocketDuration == 0 {
+		return nil
+	}
+
+	return &verifyDecisionCache{
+		duration:          duration,
+		webSocketDuration: webSocketDuration,
+		decisions:         make(map[string]verifyDecision),
+		maxSize:           verifyDecisionCacheMaxSize,
+	}
+}
+
+// durationOf returns the duration the decisions of the regular or WebSocket upgrade requests are kept for.
+func (c *verifyDecisionCache) durationOf(webSocket bool) time.Duration {
+	if webSocket {
+		return c.webSocketDuration
+	}
+
+	return c.duration
+}
+
+// key returns the key of the decision of the request. Only the requests solely authenticated by the session cookie
+// can be cached, the key being made of the session cookie and every input of the authorization rules.
+func (c *verifyDecisionCache) key(ctx *middlewares.AutheliaCtx, targetURL *url.URL, method []byte, webSocket bool,
+	trustedHeader *trustedHeaderAuthentication, clientCertificate *clientCertificateAuthentication) (key string, ok bool) {
+	if c == nil || c.durationOf(webSocket) == 0 {
+		return "", false
+	}
+
+	if len(ctx.Request.Header.Peek(AuthorizationHeader)) != 0 ||
+		len(ctx.Request.Header.Peek(ProxyAuthorizationHeader)) != 0 ||
+		len(ctx.QueryArgs().Peek("auth")) != 0 ||
+		getImpersonatedUsername(ctx) != "" ||
+		(trustedHeader != nil && len(ctx.Request.Header.Peek(trustedHeader.header)) != 0) ||
+		(clientCertificate != nil && len(ctx.Request.Header.Peek(clientCertificate.header)) != 0) {
+		return "", false
+	}
+
+	cookie := ctx.Request.Header.Cookie(ctx.Configuration.Session.Name)
+	if len(cookie) == 0 {
+		return "", false
+	}
+
+	kind := "http"
+	if webSocket {
+		kind = "websocket"
+	}
+
+	return strings.Join([]string{
+		kind,
+		string(cookie),
+		string(ctx.Request.Header.Peek(SessionUsernameHeader)),
+		ctx.RemoteIP().String(),
+		string(method),
+		targetURL.String(),
+	}, "\x00"), true
+}
+
+// get returns the decision of the key if it has not expired yet.
+func (c *verifyDecisionCache) get(key string, now time.Time) (decision verifyDecision, ok bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	decision, ok = c.decisions[key]
+	if !ok || !now.Before(decision.expires) {
+		return verifyDecision{}, false
+	}
+
+	return decision, true
+}
+
+// set caches the decision of the key. The expired decisions are purged at most once per shortest cache duration.
+func (c *verifyDecisionCache) set(key string, decision verifyDecision, webSocket bool, now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	interval := c.duration
+	if interval == 0 || (c.webSocketDuration != 0 && c.webSocketDuration < interval) {
+		interval = c.webSocketDuration
+	}
+
+	if now.Sub(c.purged) >= interval {
+		for k, d := range c.decisions {
+			if !now.Before(d.expires) {
+				delete(c.decisions, k)
+			}
+		}
+
+		c.purged = now
+	}
+
+	if _, ok := c.decisions[key]; !ok && len(c.decisions) >= c.maxSize {
+		c.evict(now)
+	}
+
+	decision.expires = now.Add(c.durationOf(webSocket))
+	c.decisions[key] = decision
+}
+
+// evict deletes the expired decisions, or the decision expiring first if none has expired.
+func (c *verifyDecisionCache) evict(now time.Time) {
+	var (
+		first   string
+		expires time.Time
+	)
+
+	for k, d := range c.decisions {
+		if !now.Before(d.expires) {
+			delete(c.decisions, k)
+			continue
+		}
+
+		if first == "" || d.expires.Before(expires) {
+			first, expires = k, d.expires
+		}
+	}
+
+	if len(c.decisions) >= c.maxSize {
+		delete(c.decisions, first)
+	}
+
+	c.purged = now
+}
+
+// remove deletes the decision of the key.
+func (c *verifyDecisionCache) remove(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.decisions, key)
+}
+
+// isVerifyDecisionStale returns true if the cached decision doesn't hold for the session of the request anymore: the
+// session is not the one of the user of the decision, its authentication level was lowered, the user has been inactive
+// for too long or, for the authorized requests, the authentication is older than the maximum age or the policy engine
+// refuses the request.
+func isVerifyDecisionStale(ctx *middlewares.AutheliaCtx, targetURL *url.URL, method []byte, decision verifyDecision) bool {
+	userSession := ctx.GetSession()
+
+	if userSession.Username == "" || userSession.Username != decision.username ||
+		userSession.AuthenticationLevel < decision.authLevel {
+		return true
+	}
+
+	if !userSession.KeepMeLoggedIn {
+		if inactive, err := hasUserBeenInactiveTooLong(ctx); err != nil || inactive {
+			return true
+		}
+	}
+
+	if decision.authorized != Authorized {
+		return false
+	}
+
+	now := ctx.Clock.Now()
+
+	if tooOld, _ := isSessionAuthenticationTooOld(userSession, decision.level, ctx.Providers.Authorizer.GetMaxAuthenticationAge(decision.rule), now); tooOld {
+		return true
+	}
+
+	if decision.level != authorization.Bypass {
+		if tooOld, _ := isSessionAuthenticationTooOld(userSession, authorization.OneFactor, getVerifyMaxAuthenticationAge(ctx), now); tooOld {
+			return true
+		}
+	}
+
+	return checkPolicyEngine(ctx, targetURL, method, decision.username, decision.groups, decision.level, decision.rule) != Authorized
+}
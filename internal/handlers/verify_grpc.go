@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/middlewares"
+)
+
+// isGRPCRequest returns true if the gRPC mode of the verify endpoint is enabled and the request checked by the proxy
+// is a gRPC call, whatever its protobuf, JSON or web flavour.
+func isGRPCRequest(ctx *middlewares.AutheliaCtx) bool {
+	return ctx.Configuration.Server.VerifyGRPC &&
+		bytes.HasPrefix(ctx.Request.Header.ContentType(), []byte(grpcContentType))
+}
+
+// grpcVerifyHeaders returns the headers of the verify endpoint with lowercase names, as required by the metadata of
+// gRPC. The validator ensures none of them uses a prefix reserved by gRPC.
+func grpcVerifyHeaders(cfg schema.ServerVerifyHeadersConfiguration) schema.ServerVerifyHeadersConfiguration {
+	for _, header := range []struct {
+		value    *string
+		fallback string
+	}{
+		{&cfg.User, remoteUserHeader},
+		{&cfg.Groups, remoteGroupsHeader},
+		{&cfg.Name, remoteNameHeader},
+		{&cfg.Email, remoteEmailHeader},
+	} {
+		if *header.value == "" {
+			*header.value = header.fallback
+		}
+	}
+
+	cfg.User = strings.ToLower(cfg.User)
+	cfg.Groups = strings.ToLower(cfg.Groups)
+	cfg.Name = strings.ToLower(cfg.Name)
+	cfg.Email = strings.ToLower(cfg.Email)
+	cfg.Rule = strings.ToLower(cfg.Rule)
+	cfg.Level = strings.ToLower(cfg.Level)
+	cfg.Methods = strings.ToLower(cfg.Methods)
+	cfg.Signature.Header = strings.ToLower(cfg.Signature.Header)
+
+	static := make([]schema.ServerStaticHeaderConfiguration, len(cfg.Static))
+	for i, header := range cfg.Static {
+		static[i] = schema.ServerStaticHeaderConfiguration{Name: strings.ToLower(header.Name), Value: header.Value}
+	}
+
+	attributes := make([]schema.ServerVerifyAttributeHeaderConfiguration, len(cfg.Attributes))
+	for i, header := range cfg.Attributes {
+		attributes[i] = schema.ServerVerifyAttributeHeaderConfiguration{Attribute: header.Attribute, Header: strings.ToLower(header.Header)}
+	}
+
+	cfg.Static, cfg.Attributes = static, attributes
+
+	return cfg
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// getImpersonatedUsername returns the username in the impersonation header of the request, if the impersonation is
+// enabled.
+func getImpersonatedUsername(ctx *middlewares.AutheliaCtx) string {
+	header := ctx.Configuration.Server.VerifyImpersonation.Header
+	if header == "" {
+		return ""
+	}
+
+	return string(ctx.Request.Header.Peek(header))
+}
+
+// impersonateUser returns the details of the user impersonated by the authenticated user, who must be a member of one
+// of the groups allowed to impersonate and be authenticated with the session cookie, so the API keys, the credentials
+// and the headers of the other strategies can't be used to act as someone else. The impersonated user must be a member
+// of one of the target groups when they are configured and can never be a member of the groups allowed to impersonate,
+// so an administrator can't act as another administrator.
+func impersonateUser(ctx *middlewares.AutheliaCtx, strategy, username string, groups []string, impersonated string) (*authentication.UserDetails, error) {
+	config := ctx.Configuration.Server.VerifyImpersonation
+
+	if strategy != verifyStrategySessionCookie {
+		return nil, fmt.Errorf("User %s authenticated with the %s strategy is not allowed to impersonate user %s", username, strategy, impersonated)
+	}
+
+	if !isAnyStringInSlice(groups, config.Groups) {
+		return nil, fmt.Errorf("User %s is not allowed to impersonate user %s", username, impersonated)
+	}
+
+	details, err := ctx.Providers.UserProvider.GetDetails(impersonated)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve details of user %s impersonated by user %s: %s", impersonated, username, err)
+	}
+
+	if isAnyStringInSlice(details.Groups, config.Groups) {
+		return nil, fmt.Errorf("User %s is not allowed to impersonate user %s who is allowed to impersonate", username, impersonated)
+	}
+
+	if len(config.TargetGroups) != 0 && !isAnyStringInSlice(details.Groups, config.TargetGroups) {
+		return nil, fmt.Errorf("User %s is not allowed to impersonate user %s who is not a member of the target groups", username, impersonated)
+	}
+
+	return details, nil
+}
+
+func isAnyStringInSlice(values, slice []string) bool {
+	for _, value := range values {
+		if utils.IsStringInSlice(value, slice) {
+			return true
+		}
+	}
+
+	return false
+}
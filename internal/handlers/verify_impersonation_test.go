@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+)
+
+func TestShouldGetImpersonatedUsernameWhenEnabled(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Request.Header.Set("Remote-Impersonate", "bob")
+
+	assert.Equal(t, "", getImpersonatedUsername(mock.Ctx))
+
+	mock.Ctx.Configuration.Server.VerifyImpersonation.Header = "Remote-Impersonate"
+
+	assert.Equal(t, "bob", getImpersonatedUsername(mock.Ctx))
+}
+
+func TestShouldRestrictImpersonatedUsers(t *testing.T) {
+	testCases := []struct {
+		name         string
+		strategy     string
+		groups       []string
+		targetGroups []string
+		details      *authentication.UserDetails
+		err          error
+		expErr       string
+	}{
+		{
+			name:    "Allowed",
+			groups:  []string{"admin"},
+			details: &authentication.UserDetails{Username: "bob", Groups: []string{"dev"}},
+		},
+		{
+			name:   "NotAdministrator",
+			groups: []string{"dev"},
+			expErr: "User john is not allowed to impersonate user bob",
+		},
+		{
+			name:    "ImpersonatedAdministrator",
+			groups:  []string{"admin"},
+			details: &authentication.UserDetails{Username: "bob", Groups: []string{"dev", "admin"}},
+			expErr:  "User john is not allowed to impersonate user bob who is allowed to impersonate",
+		},
+		{
+			name:         "TargetGroup",
+			groups:       []string{"admin"},
+			targetGroups: []string{"users"},
+			details:      &authentication.UserDetails{Username: "bob", Groups: []string{"dev", "users"}},
+		},
+		{
+			name:         "NotTargetGroup",
+			groups:       []string{"admin"},
+			targetGroups: []string{"users"},
+			details:      &authentication.UserDetails{Username: "bob", Groups: []string{"dev"}},
+			expErr:       "User john is not allowed to impersonate user bob who is not a member of the target groups",
+		},
+		{
+			name:     "APIKey",
+			strategy: verifyStrategyAPIKey,
+			groups:   []string{"admin"},
+			expErr:   "User john authenticated with the api_key strategy is not allowed to impersonate user bob",
+		},
+		{
+			name:     "BasicAuth",
+			strategy: verifyStrategyBasicAuth,
+			groups:   []string{"admin"},
+			expErr:   "User john authenticated with the basic_auth strategy is not allowed to impersonate user bob",
+		},
+		{
+			name:     "TrustedHeader",
+			strategy: verifyStrategyTrustedHeader,
+			groups:   []string{"admin"},
+			expErr:   "User john authenticated with the trusted_header strategy is not allowed to impersonate user bob",
+		},
+		{
+			name:   "UnknownUser",
+			groups: []string{"admin"},
+			err:    errors.New("user not found"),
+			expErr: "Unable to retrieve details of user bob impersonated by user john: user not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mock := mocks.NewMockAutheliaCtx(t)
+			defer mock.Close()
+
+			mock.Ctx.Configuration.Server.VerifyImpersonation = schema.ServerVerifyImpersonationConfiguration{
+				Header:       "Remote-Impersonate",
+				Groups:       []string{"admin"},
+				TargetGroups: tc.targetGroups,
+			}
+
+			if tc.details != nil || tc.err != nil {
+				mock.UserProviderMock.EXPECT().
+					GetDetails(gomock.Eq("bob")).
+					Return(tc.details, tc.err)
+			}
+
+			strategy := tc.strategy
+			if strategy == "" {
+				strategy = verifyStrategySessionCookie
+			}
+
+			details, err := impersonateUser(mock.Ctx, strategy, testUsername, tc.groups, "bob")
+
+			if tc.expErr != "" {
+				assert.EqualError(t, err, tc.expErr)
+				assert.Nil(t, details)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.details, details)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// signIdentity returns the signature of the forwarded user and groups at the given time, which is the hex encoded
+// HMAC-SHA256 with the shared secret of the timestamp, the username and the comma separated groups, each on its own
+// line.
+func signIdentity(secret string, username string, groups []string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join([]string{strconv.FormatInt(timestamp, 10), username, strings.Join(groups, ",")}, "\n")))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// setSignatureHeader set the header signing the forwarded user and groups along with the current time, in the form
+// t=<unix timestamp>,v1=<signature>, so the backends can check the identity headers were set by Authelia recently and
+// not injected on the way.
+func setSignatureHeader(headers *fasthttp.ResponseHeader, cfg schema.ServerVerifyHeadersConfiguration, username string, groups []string, now time.Time) {
+	if username == "" || cfg.Signature.Secret == "" {
+		return
+	}
+
+	header := cfg.Signature.Header
+	if header == "" {
+		header = remoteSignatureHeader
+	}
+
+	timestamp := now.Unix()
+
+	headers.Set(header, fmt.Sprintf("t=%d,v1=%s", timestamp, signIdentity(cfg.Signature.Secret, username, groups, timestamp)))
+}
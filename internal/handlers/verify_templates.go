@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/middlewares"
+)
+
+// verifyTemplate renders the body of a response.
+type verifyTemplate interface {
+	Execute(wr io.Writer, data interface{}) error
+}
+
+// verifyTemplates are the templates of the 401 and 403 responses of the verify endpoint, the ones which are not
+// configured being nil.
+type verifyTemplates struct {
+	unauthorizedHTML verifyTemplate
+	unauthorizedJSON verifyTemplate
+	forbiddenHTML    verifyTemplate
+	forbiddenJSON    verifyTemplate
+}
+
+// verifyTemplateData is the data the templates of the verify endpoint are rendered with.
+type verifyTemplateData struct {
+	StatusCode    int
+	Status        string
+	TargetURL     string
+	Method        string
+	Username      string
+	RequiredLevel string
+}
+
+// verifyTemplateFuncs are the functions available to the JSON templates, the json function quoting a value.
+var verifyTemplateFuncs = texttemplate.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+}
+
+// loadVerifyTemplates parses the templates of the configuration, the HTML ones escaping the values automatically.
+func loadVerifyTemplates(configuration schema.ServerVerifyTemplatesConfiguration) (templates *verifyTemplates, err error) {
+	templates = &verifyTemplates{}
+
+	for _, t := range []struct {
+		path     string
+		html     bool
+		template *verifyTemplate
+	}{
+		{configuration.UnauthorizedHTML, true, &templates.unauthorizedHTML},
+		{configuration.UnauthorizedJSON, false, &templates.unauthorizedJSON},
+		{configuration.ForbiddenHTML, true, &templates.forbiddenHTML},
+		{configuration.ForbiddenJSON, false, &templates.forbiddenJSON},
+	} {
+		if t.path == "" {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(t.path)
+		if err != nil {
+			return nil, err
+		}
+
+		if t.html {
+			*t.template, err = htmltemplate.New(t.path).Parse(string(content))
+		} else {
+			*t.template, err = texttemplate.New(t.path).Funcs(verifyTemplateFuncs).Parse(string(content))
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return templates, nil
+}
+
+// lazyVerifyTemplates loads the templates of the configuration on first use, the configuration being the same for the
+// whole life of the process.
+type lazyVerifyTemplates struct {
+	once      sync.Once
+	templates *verifyTemplates
+}
+
+func (l *lazyVerifyTemplates) get(ctx *middlewares.AutheliaCtx) *verifyTemplates {
+	l.once.Do(func() {
+		templates, err := loadVerifyTemplates(ctx.Configuration.Server.VerifyTemplates)
+		if err != nil {
+			ctx.Logger.Errorf("Unable to load the templates of the verify endpoint, the default bodies are used: %s", err)
+			return
+		}
+
+		l.templates = templates
+	})
+
+	return l.templates
+}
+
+// render replaces the body of the 401 and 403 responses with the template matching the status code and the Accept
+// header of the request, if it's configured. The WebSocket upgrade requests and gRPC calls keep their empty body.
+func (t *verifyTemplates) render(ctx *middlewares.AutheliaCtx, targetURL *url.URL, method []byte, username, requiredLevel string) {
+	if t == nil || isWebSocketUpgrade(ctx, targetURL) || isGRPCRequest(ctx) {
+		return
+	}
+
+	statusCode := ctx.Response.StatusCode()
+	wantsJSON := strings.Contains(string(ctx.Request.Header.Peek(fasthttp.HeaderAccept)), "application/json")
+
+	var (
+		template    verifyTemplate
+		contentType = "text/html; charset=utf-8"
+	)
+
+	switch statusCode {
+	case fasthttp.StatusUnauthorized:
+		template = t.unauthorizedHTML
+		if wantsJSON {
+			template = t.unauthorizedJSON
+		}
+	case fasthttp.StatusForbidden:
+		template = t.forbiddenHTML
+		if wantsJSON {
+			template = t.forbiddenJSON
+		}
+	}
+
+	if template == nil {
+		return
+	}
+
+	if wantsJSON {
+		contentType = "application/json; charset=utf-8"
+	}
+
+	var body bytes.Buffer
+
+	err := template.Execute(&body, verifyTemplateData{
+		StatusCode:    statusCode,
+		Status:        fasthttp.StatusMessage(statusCode),
+		TargetURL:     targetURL.String(),
+		Method:        string(method),
+		Username:      username,
+		RequiredLevel: requiredLevel,
+	})
+	if err != nil {
+		ctx.Logger.Errorf("Unable to render the template of the %d response: %s", statusCode, err)
+		return
+	}
+
+	ctx.SetContentType(contentType)
+	ctx.SetBody(body.Bytes())
+}
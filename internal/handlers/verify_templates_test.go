@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/mocks"
+)
+
+func TestShouldRenderVerifyTemplates(t *testing.T) {
+	dir := t.TempDir()
+
+	unauthorizedHTML := filepath.Join(dir, "unauthorized.html")
+	require.NoError(t, ioutil.WriteFile(unauthorizedHTML, []byte(`<p>Log in to access {{ .TargetURL }}</p>`), 0600))
+
+	forbiddenJSON := filepath.Join(dir, "forbidden.json")
+	require.NoError(t, ioutil.WriteFile(forbiddenJSON, []byte(`{"status": {{ .StatusCode }}, "user": {{ json .Username }}, "level": {{ json .RequiredLevel }}}`), 0600))
+
+	testCases := []struct {
+		name           string
+		url            string
+		accept         string
+		authenticated  bool
+		expStatusCode  int
+		expContentType string
+		expBody        string
+	}{
+		{"UnauthorizedHTML", "https://one-factor.example.com/?a=<b>", "text/html", false, 401,
+			"text/html; charset=utf-8", "<p>Log in to access https://one-factor.example.com/?a=&lt;b&gt;</p>"},
+		{"ForbiddenJSON", "https://deny.example.com", "application/json", true, 403,
+			"application/json; charset=utf-8", `{"status": 403, "user": "john", "level": "deny"}`},
+		{"UnauthorizedJSONNotConfigured", "https://one-factor.example.com", "application/json", false, 401,
+			"text/plain; charset=utf-8", "Unauthorized"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mock := mocks.NewMockAutheliaCtx(t)
+			defer mock.Close()
+
+			mock.Clock.Set(time.Now())
+
+			mock.Ctx.Configuration.Server.VerifyTemplates.UnauthorizedHTML = unauthorizedHTML
+			mock.Ctx.Configuration.Server.VerifyTemplates.ForbiddenJSON = forbiddenJSON
+
+			if tc.authenticated {
+				userSession := mock.Ctx.GetSession()
+				userSession.Username = testUsername
+				userSession.AuthenticationLevel = authentication.TwoFactor
+				userSession.RefreshTTL = mock.Clock.Now().Add(5 * time.Minute)
+				require.NoError(t, mock.Ctx.SaveSession(userSession))
+			}
+
+			mock.Ctx.Request.Header.Set("X-Original-URL", tc.url)
+			mock.Ctx.Request.Header.Set("Accept", tc.accept)
+
+			VerifyGet(verifyGetCfg, 0, 0)(mock.Ctx)
+
+			assert.Equal(t, tc.expStatusCode, mock.Ctx.Response.StatusCode())
+			assert.Equal(t, tc.expContentType, string(mock.Ctx.Response.Header.ContentType()))
+			assert.Equal(t, tc.expBody, string(mock.Ctx.Response.Body()))
+		})
+	}
+}
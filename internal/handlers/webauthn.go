@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/utils"
+	"github.com/authelia/authelia/internal/webauthn"
+)
+
+// newWebAuthnAttestationPolicy returns the attestation policy of the configuration, or nil if the authenticators are
+// not restricted.
+func newWebAuthnAttestationPolicy(configuration *schema.WebAuthnConfiguration) *webauthn.AttestationPolicy {
+	if configuration == nil || (configuration.AttestationCertificateAuthority == "" &&
+		len(configuration.AllowedAAGUIDs) == 0 && len(configuration.DeniedAAGUIDs) == 0 &&
+		len(configuration.EnterpriseAttestationRPIDs) == 0) {
+		return nil
+	}
+
+	policy := &webauthn.AttestationPolicy{
+		AAGUIDs:           expandWebAuthnAAGUIDs(configuration.AllowedAAGUIDs),
+		DeniedAAGUIDs:     expandWebAuthnAAGUIDs(configuration.DeniedAAGUIDs),
+		EnterpriseRPIDs:   configuration.EnterpriseAttestationRPIDs,
+		EnterpriseAAGUIDs: expandWebAuthnAAGUIDs(configuration.EnterpriseAttestationAAGUIDs),
+	}
+
+	// The certificate authority is checked by the configuration validator.
+	if configuration.AttestationCertificateAuthority != "" {
+		policy.Roots = x509.NewCertPool()
+
+		if content, err := ioutil.ReadFile(configuration.AttestationCertificateAuthority); err == nil {
+			policy.Roots.AppendCertsFromPEM(content)
+		}
+	}
+
+	return policy
+}
+
+// expandWebAuthnAAGUIDs replaces the presets of the AAGUIDs of the configuration with the AAGUIDs they stand for.
+func expandWebAuthnAAGUIDs(entries []string) (aaguids []string) {
+	for _, entry := range entries {
+		if preset, ok := schema.WebAuthnAAGUIDPresets[entry]; ok {
+			aaguids = append(aaguids, preset...)
+		} else {
+			aaguids = append(aaguids, entry)
+		}
+	}
+
+	return aaguids
+}
+
+// webAuthnMaxPasskeys returns the maximum number of passkeys a user can register.
+func webAuthnMaxPasskeys(configuration *schema.WebAuthnConfiguration) int {
+	if configuration == nil || configuration.MaxPasskeys <= 0 {
+		return schema.DefaultWebAuthnConfiguration.MaxPasskeys
+	}
+
+	return configuration.MaxPasskeys
+}
+
+// isWebAuthnPasskeyFirstFactor returns true if the passkeys are only a first factor the users complete with their
+// password.
+func isWebAuthnPasskeyFirstFactor(configuration *schema.WebAuthnConfiguration) bool {
+	return configuration != nil && configuration.PasskeyMode == passkeyModeFirstFactor
+}
+
+// webAuthnTimeout returns the timeout of a ceremony, which defaults to the timeout shared by the ceremonies.
+func webAuthnTimeout(timeout, fallback string) string {
+	if timeout == "" {
+		return fallback
+	}
+
+	return timeout
+}
+
+// verifySyncedPasskeyPolicy applies the synced passkeys policy of the configuration to a passkey of the user. The
+// passkeys eligible to a backup are the ones the authenticators sync between the devices of the user.
+func verifySyncedPasskeyPolicy(ctx *middlewares.AutheliaCtx, configuration *schema.WebAuthnConfiguration, username string, credential *webauthn.Credential) error {
+	if configuration == nil || !credential.BackupEligible {
+		return nil
+	}
+
+	switch configuration.SyncedPasskeys {
+	case syncedPasskeysReject:
+		return errSyncedPasskeyRejected
+	case syncedPasskeysWarn:
+		ctx.Logger.Warnf("The passkey of user %s can be synced between devices", username)
+	}
+
+	return nil
+}
+
+// newWebAuthnRelyingParty returns the relying party of the portal serving the request with the attestation policy.
+// The passkeys are scoped to the rp_id of the configuration, or by default to the cookie domain protecting the portal,
+// so they can be used with any portal sharing the domain.
+func newWebAuthnRelyingParty(ctx *middlewares.AutheliaCtx, attestation *webauthn.AttestationPolicy) (*webauthn.RelyingParty, error) {
+	if ctx.XForwardedProto() == nil {
+		return nil, errMissingXForwardedProto
+	}
+
+	if ctx.XForwardedHost() == nil {
+		return nil, errMissingXForwardedHost
+	}
+
+	host := string(ctx.XForwardedHost())
+
+	domain, ok := session.MatchDomain(ctx.Configuration.Session, host)
+	if !ok {
+		return nil, fmt.Errorf("The host %s is not protected by any of the session domains", host)
+	}
+
+	configuration := schema.DefaultWebAuthnConfiguration
+	if ctx.Configuration.WebAuthn != nil {
+		configuration = *ctx.Configuration.WebAuthn
+	}
+
+	registrationTimeout, err := utils.ParseDurationString(webAuthnTimeout(configuration.RegistrationTimeout, configuration.Timeout))
+	if err != nil {
+		return nil, err
+	}
+
+	assertionTimeout, err := utils.ParseDurationString(webAuthnTimeout(configuration.AuthenticationTimeout, configuration.Timeout))
+	if err != nil {
+		return nil, err
+	}
+
+	id := strings.TrimPrefix(domain.Domain, ".")
+
+	if configuration.RPID != "" {
+		// The browsers only allow a portal to use the relying party IDs matching its host or one of its parents.
+		if host != configuration.RPID && !strings.HasSuffix(host, "."+configuration.RPID) {
+			return nil, fmt.Errorf("The host %s is not within the WebAuthn relying party ID %s", host, configuration.RPID)
+		}
+
+		id = configuration.RPID
+	}
+
+	// The passkeys used as a first factor don't need to be a second factor on their own, so any security key can be used.
+	userVerification := webauthn.UserVerificationRequired
+	if isWebAuthnPasskeyFirstFactor(&configuration) {
+		userVerification = webauthn.UserVerificationPreferred
+	}
+
+	return &webauthn.RelyingParty{
+		ID:                  id,
+		Name:                configuration.DisplayName,
+		Origin:              fmt.Sprintf("%s://%s", ctx.XForwardedProto(), host),
+		AllowedOrigins:      configuration.Origins,
+		RegistrationTimeout: registrationTimeout,
+		AssertionTimeout:    assertionTimeout,
+		ResidentKey:         configuration.ResidentKey,
+		Attachment:          configuration.Attachment,
+		UserVerification:    userVerification,
+		Attestation:         attestation,
+	}, nil
+}
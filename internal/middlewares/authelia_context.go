@@ -88,6 +88,11 @@ func (c *AutheliaCtx) ReplyForbidden() {
 	c.RequestCtx.Error(fasthttp.StatusMessage(fasthttp.StatusForbidden), fasthttp.StatusForbidden)
 }
 
+// ReplyTooManyRequests response sent when the rate limit of the client is exceeded.
+func (c *AutheliaCtx) ReplyTooManyRequests() {
+	c.RequestCtx.Error(fasthttp.StatusMessage(fasthttp.StatusTooManyRequests), fasthttp.StatusTooManyRequests)
+}
+
 // ReplyBadRequest response sent when bad request has been sent.
 func (c *AutheliaCtx) ReplyBadRequest() {
 	c.RequestCtx.Error(fasthttp.StatusMessage(fasthttp.StatusBadRequest), fasthttp.StatusBadRequest)
@@ -0,0 +1,123 @@
+package middlewares
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// rateLimitBucket is the token bucket of a remote IP.
+type rateLimitBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimit limits the requests of each remote IP to the configured number of requests per second, allowing bursts of
+// the configured size. The remote IP is the IP of the TCP peer, unless the peer is one of the trusted proxies in which
+// case it's the IP forwarded by the proxy. The requests exceeding the limit are rejected with a 429 status code before
+// reaching the next handler.
+func RateLimit(configuration schema.ServerVerifyRateLimitConfiguration, next RequestHandler) RequestHandler {
+	var (
+		mutex          sync.Mutex
+		buckets        = make(map[string]*rateLimitBucket)
+		purged         time.Time
+		requests       = float64(configuration.Requests)
+		burst          = float64(configuration.Burst)
+		trustedProxies = parseRateLimitTrustedProxies(configuration.TrustedProxies)
+	)
+
+	// refill returns the tokens of the bucket at the given time.
+	refill := func(bucket *rateLimitBucket, now time.Time) float64 {
+		tokens := bucket.tokens + now.Sub(bucket.updated).Seconds()*requests
+		if tokens > burst {
+			return burst
+		}
+
+		return tokens
+	}
+
+	// allow takes a token from the bucket of the key if it's not empty.
+	allow := func(key string, now time.Time) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		// The full buckets are purged since they are identical to the bucket of a key never seen.
+		if now.Sub(purged) >= time.Minute {
+			for key, bucket := range buckets {
+				if refill(bucket, now) >= burst {
+					delete(buckets, key)
+				}
+			}
+
+			purged = now
+		}
+
+		bucket, ok := buckets[key]
+		if !ok {
+			bucket = &rateLimitBucket{tokens: burst, updated: now}
+			buckets[key] = bucket
+		}
+
+		bucket.tokens, bucket.updated = refill(bucket, now), now
+
+		if bucket.tokens < 1 {
+			return false
+		}
+
+		bucket.tokens--
+
+		return true
+	}
+
+	return func(ctx *AutheliaCtx) {
+		ip := ctx.RequestCtx.RemoteIP()
+		if isRateLimitTrustedProxy(ip, trustedProxies) {
+			if forwarded := ctx.RemoteIP(); forwarded != nil {
+				ip = forwarded
+			}
+		}
+
+		if !allow(ip.String(), ctx.Clock.Now()) {
+			ctx.Logger.Warnf("Too many requests from %s, sending 429 response", ip)
+			ctx.ReplyTooManyRequests()
+
+			return
+		}
+
+		next(ctx)
+	}
+}
+
+// parseRateLimitTrustedProxies parses the IP addresses and CIDR networks of the trusted proxies.
+func parseRateLimitTrustedProxies(trustedProxies []string) (networks []*net.IPNet) {
+	for _, network := range trustedProxies {
+		if !strings.Contains(network, "/") {
+			if ip := net.ParseIP(network); ip.To4() != nil {
+				network += "/32"
+			} else {
+				network += "/128"
+			}
+		}
+
+		// The networks are checked by the configuration validator.
+		if _, cidr, err := net.ParseCIDR(network); err == nil {
+			networks = append(networks, cidr)
+		}
+	}
+
+	return networks
+}
+
+// isRateLimitTrustedProxy returns true if the IP belongs to one of the networks of the trusted proxies.
+func isRateLimitTrustedProxy(ip net.IP, networks []*net.IPNet) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
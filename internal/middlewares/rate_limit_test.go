@@ -0,0 +1,113 @@
+package middlewares_test
+
+import (
+	"encoding/base64"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/mocks"
+)
+
+func TestShouldRateLimitRequestsOfRemoteIP(t *testing.T) {
+	now := time.Now()
+	calls := 0
+
+	handler := middlewares.RateLimit(schema.ServerVerifyRateLimitConfiguration{Requests: 1, Burst: 2}, func(ctx *middlewares.AutheliaCtx) {
+		calls++
+	})
+
+	request := func(ip, forwardedFor string, at time.Time) int {
+		mock := mocks.NewMockAutheliaCtx(t)
+		defer mock.Close()
+
+		mock.Ctx.Clock = &mock.Clock
+		mock.Clock.Set(at)
+		mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP(ip)})
+		mock.Ctx.Request.Header.Set("X-Forwarded-For", forwardedFor)
+
+		handler(mock.Ctx)
+
+		return mock.Ctx.Response.StatusCode()
+	}
+
+	// The burst is allowed, then one request per second. Rotating the forwarded IP doesn't reset the limit.
+	assert.Equal(t, 200, request("10.0.0.1", "192.168.0.1", now))
+	assert.Equal(t, 200, request("10.0.0.1", "192.168.0.2", now))
+	assert.Equal(t, 429, request("10.0.0.1", "192.168.0.3", now))
+	assert.Equal(t, 200, request("10.0.0.2", "192.168.0.1", now))
+	assert.Equal(t, 429, request("10.0.0.1", "192.168.0.4", now.Add(500*time.Millisecond)))
+	assert.Equal(t, 200, request("10.0.0.1", "192.168.0.5", now.Add(time.Second)))
+
+	assert.Equal(t, 4, calls)
+}
+
+func TestShouldRateLimitRequestsOfForwardedIPOfTrustedProxies(t *testing.T) {
+	now := time.Now()
+	calls := 0
+
+	handler := middlewares.RateLimit(schema.ServerVerifyRateLimitConfiguration{Requests: 1, Burst: 1, TrustedProxies: []string{"10.0.0.0/24"}}, func(ctx *middlewares.AutheliaCtx) {
+		calls++
+	})
+
+	request := func(ip, forwardedFor string) int {
+		mock := mocks.NewMockAutheliaCtx(t)
+		defer mock.Close()
+
+		mock.Ctx.Clock = &mock.Clock
+		mock.Clock.Set(now)
+		mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP(ip)})
+		mock.Ctx.Request.Header.Set("X-Forwarded-For", forwardedFor)
+
+		handler(mock.Ctx)
+
+		return mock.Ctx.Response.StatusCode()
+	}
+
+	// The clients of a trusted proxy each have their own bucket, whatever the proxy they come through.
+	assert.Equal(t, 200, request("10.0.0.1", "192.168.0.1"))
+	assert.Equal(t, 200, request("10.0.0.1", "192.168.0.2"))
+	assert.Equal(t, 429, request("10.0.0.2", "192.168.0.1"))
+
+	// The forwarded IP of the other peers is ignored.
+	assert.Equal(t, 200, request("10.0.1.1", "192.168.0.3"))
+	assert.Equal(t, 429, request("10.0.1.1", "192.168.0.4"))
+
+	assert.Equal(t, 3, calls)
+}
+
+func TestShouldNotRateLimitRequestsOfUsernameFromOtherRemoteIPs(t *testing.T) {
+	now := time.Now()
+	calls := 0
+
+	handler := middlewares.RateLimit(schema.ServerVerifyRateLimitConfiguration{Requests: 1, Burst: 1}, func(ctx *middlewares.AutheliaCtx) {
+		calls++
+
+		ctx.ReplyUnauthorized()
+	})
+
+	request := func(ip string) int {
+		mock := mocks.NewMockAutheliaCtx(t)
+		defer mock.Close()
+
+		mock.Ctx.Clock = &mock.Clock
+		mock.Clock.Set(now)
+		mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP(ip)})
+		mock.Ctx.Request.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("john:password")))
+
+		handler(mock.Ctx)
+
+		return mock.Ctx.Response.StatusCode()
+	}
+
+	// The failed attempts of a remote IP don't lock the user out of the other remote IPs.
+	assert.Equal(t, 401, request("10.0.0.1"))
+	assert.Equal(t, 429, request("10.0.0.1"))
+	assert.Equal(t, 401, request("10.0.0.2"))
+
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,17 @@
+package middlewares
+
+import (
+	"github.com/authelia/authelia/internal/authentication"
+)
+
+// RequireTwoFactor check if user has authenticated with two factors before executing the next handler.
+func RequireTwoFactor(next RequestHandler) RequestHandler {
+	return func(ctx *AutheliaCtx) {
+		if ctx.GetSession().AuthenticationLevel < authentication.TwoFactor {
+			ctx.ReplyForbidden()
+			return
+		}
+
+		next(ctx)
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+// Types of the authentication attempts.
+const (
+	AuthenticationTypeFirstFactor  = "1FA"
+	AuthenticationTypePasskey      = "passkey"
+	AuthenticationTypeRecoveryCode = "recovery_code"
+	AuthenticationTypeEmail        = "email"
+	AuthenticationTypePushApproval = "push_approval"
+	AuthenticationTypePrivacyIDEA  = "privacyidea"
+	AuthenticationTypeTOTP         = "totp"
+	AuthenticationTypeHOTP         = "hotp"
+	AuthenticationTypeYubiKeyOTP   = "yubikey_otp"
+)
+
+// Types of the audit events.
+const (
+	AuditEventTOTPRegistered = "totp_registered"
+	AuditEventU2FRegistered  = "u2f_registered"
+	AuditEventPasswordReset  = "password_reset"
+	AuditEventConsentGranted = "consent_granted"
+	AuditEventImpersonation  = "impersonation"
+
+	AuditEventPasskeyRegistered = "passkey_registered"
+	AuditEventPasskeyDeleted    = "passkey_deleted"
+	AuditEventPasskeyRestored   = "passkey_restored"
+
+	AuditEventRecoveryCodesGenerated = "recovery_codes_generated"
+	AuditEventRecoveryCodeUsed       = "recovery_code_used"
+
+	AuditEventDeviceTrusted        = "device_trusted"
+	AuditEventTrustedDeviceRevoked = "trusted_device_revoked"
+
+	AuditEventSecondFactorReset = "second_factor_reset"
+
+	AuditEventHOTPResynchronized = "hotp_resynchronized"
+)
+
+// Hash algorithms of the one-time passwords.
+const (
+	TOTPAlgorithmSHA1   = "SHA1"
+	TOTPAlgorithmSHA256 = "SHA256"
+	TOTPAlgorithmSHA512 = "SHA512"
+)
+
+// Changes recorded in the TOTP history.
+const (
+	TOTPHistoryEventCreated  = "created"
+	TOTPHistoryEventReplaced = "replaced"
+	TOTPHistoryEventDeleted  = "deleted"
+)
+
+// APIKeyPrefix is the prefix of the API keys, which tells them apart from the OAuth2 access tokens.
+const APIKeyPrefix = "authelia_ak_"
@@ -5,9 +5,202 @@ import "time"
 // AuthenticationAttempt represent an authentication attempt.
 type AuthenticationAttempt struct {
 	// The user who tried to authenticate.
-	Username string
+	Username string `json:"username"`
 	// Successful true if the attempt was successful.
-	Successful bool
+	Successful bool `json:"successful"`
 	// The time of the attempt.
-	Time time.Time
+	Time time.Time `json:"time"`
+	// The IP address of the client which made the attempt.
+	RemoteIP string `json:"remote_ip,omitempty"`
+	// The type of the authentication, one of the AuthenticationType constants.
+	Type string `json:"type,omitempty"`
+}
+
+// AuditEvent represents an administrative or security event related to a user.
+type AuditEvent struct {
+	// The user the event relates to.
+	Username string `json:"username"`
+	// The type of the event, one of the AuditEvent constants.
+	Type string `json:"type"`
+	// The IP address of the client which caused the event.
+	RemoteIP string `json:"remote_ip"`
+	// Additional details about the event.
+	Details string `json:"details,omitempty"`
+	// The time of the event.
+	Time time.Time `json:"time"`
+	// The position of the event in the hash chain of the audit log, zero for the events recorded before the chain.
+	Index int64 `json:"index,omitempty"`
+	// The hash of the event chained to the hash of the previous event.
+	Hash string `json:"hash,omitempty"`
+}
+
+// TOTPConfiguration represents the TOTP secret of a user and the parameters of the one-time passwords generated with it.
+type TOTPConfiguration struct {
+	// The user the secret belongs to.
+	Username string `json:"username"`
+	// The secret encoded in base32.
+	Secret string `json:"secret"`
+	// The hash algorithm of the one-time passwords, one of the TOTPAlgorithm constants. Empty stands for SHA1.
+	Algorithm string `json:"algorithm,omitempty"`
+	// The number of digits of the one-time passwords, 6 or 8. Zero stands for 6.
+	Digits int `json:"digits,omitempty"`
+	// The period in seconds of the one-time passwords. Zero stands for the configured period.
+	Period int `json:"period,omitempty"`
+	// The time of the last sign in with the secret, nil if it was never used.
+	LastUsed *time.Time `json:"last_used,omitempty"`
+	// The IP address of the client which last signed in with the secret.
+	LastUsedIP string `json:"last_used_ip,omitempty"`
+	// The ISO 3166-1 alpha-2 code of the country the client which last signed in with the secret was located in, empty
+	// if it's unknown.
+	LastUsedCountry string `json:"last_used_country,omitempty"`
+}
+
+// TOTPHistoryEntry represents a change of the TOTP secret of a user.
+type TOTPHistoryEntry struct {
+	// The user whose TOTP secret changed.
+	Username string `json:"username"`
+	// The change, one of the TOTPHistoryEvent constants.
+	Event string `json:"event"`
+	// The IP address of the client which made the change.
+	RemoteIP string `json:"remote_ip"`
+	// The time of the change.
+	Time time.Time `json:"time"`
+}
+
+// Ban represents a ban of a user persisted in the storage.
+type Ban struct {
+	// The banned user.
+	Username string `json:"username"`
+	// The reason of the ban.
+	Reason string `json:"reason,omitempty"`
+	// The time the user was banned.
+	Time time.Time `json:"time"`
+	// The time the ban expires, nil if the ban is permanent.
+	Expires *time.Time `json:"expires,omitempty"`
+	// Revoked true if the ban was lifted before it expired.
+	Revoked bool `json:"revoked"`
+}
+
+// APIKey represents an API key persisted in the storage.
+type APIKey struct {
+	// The name of the key, used as the username of the requests authenticated with the key.
+	Name string `json:"name"`
+	// The SHA256 hash of the key.
+	Hash string `json:"hash"`
+	// The groups of the requests authenticated with the key.
+	Groups []string `json:"groups,omitempty"`
+	// The time the key was created.
+	Time time.Time `json:"time"`
+	// Revoked true if the key was revoked.
+	Revoked bool `json:"revoked"`
+}
+
+// WebAuthnCredential represents a discoverable WebAuthn credential, also known as a passkey, persisted in the storage.
+type WebAuthnCredential struct {
+	// The user the credential was registered by.
+	Username string `json:"username"`
+	// The ID of the credential chosen by the authenticator.
+	ID []byte `json:"id"`
+	// The user handle stored by the authenticator along with the credential, which identifies the user when signing in
+	// without a username.
+	UserHandle []byte `json:"user_handle"`
+	// The COSE encoded public key of the credential.
+	PublicKey []byte `json:"public_key"`
+	// The signature counter of the authenticator at the last use of the credential.
+	SignCount uint32 `json:"sign_count"`
+	// The time the credential was registered.
+	Time time.Time `json:"time"`
+	// The description of the credential given by the user.
+	Description string `json:"description,omitempty"`
+	// The time the credential was last used to sign in, nil if it was never used.
+	LastUsed *time.Time `json:"last_used,omitempty"`
+	// The IP address of the client which last used the credential to sign in.
+	LastUsedIP string `json:"last_used_ip,omitempty"`
+	// The ISO 3166-1 alpha-2 code of the country the client which last used the credential was located in, empty if
+	// it's unknown.
+	LastUsedCountry string `json:"last_used_country,omitempty"`
+	// Whether the signature counter of the authenticator did not increase on a sign in, which means the authenticator
+	// may have been cloned.
+	CloneWarning bool `json:"clone_warning,omitempty"`
+	// Whether the authenticator allows the credential to be backed up, i.e. synced between the devices of the user.
+	BackupEligible bool `json:"backup_eligible,omitempty"`
+	// Whether the credential was backed up at its last use.
+	BackupState bool `json:"backup_state,omitempty"`
+	// The AAGUID of the authenticator model, empty if the authenticator didn't disclose it.
+	AAGUID string `json:"aaguid,omitempty"`
+	// The time the credential was deleted, nil unless it was deleted and awaits its purge at the end of the grace period.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// RecoveryCode represents a single use recovery code persisted in the storage, which the user can sign in with when
+// they lost access to their other second factors.
+type RecoveryCode struct {
+	// The user the code was generated for.
+	Username string `json:"username"`
+	// The SHA256 hash of the code.
+	Hash string `json:"hash"`
+	// The time the code was generated.
+	Time time.Time `json:"time"`
+	// Used true if the code was used to sign in.
+	Used bool `json:"used"`
+}
+
+// TrustedDevice represents a device, in practice a browser, a user trusted after a second factor and on which the
+// second factor is skipped until the trust expires.
+type TrustedDevice struct {
+	// The user who trusted the device.
+	Username string `json:"username"`
+	// The random ID of the device the user revokes it with.
+	ID string `json:"id"`
+	// The SHA256 hash of the token stored in the cookie of the device.
+	TokenHash string `json:"token_hash"`
+	// The description of the device, i.e. the user agent of the browser.
+	Description string `json:"description,omitempty"`
+	// The IP address of the client which trusted the device.
+	RemoteIP string `json:"remote_ip,omitempty"`
+	// The time the device was trusted.
+	Time time.Time `json:"time"`
+	// The time the trust expires.
+	Expires time.Time `json:"expires"`
+}
+
+// HOTPDevice represents the counter-based one-time password token of a user, usually a legacy hardware token.
+type HOTPDevice struct {
+	// The user the token belongs to.
+	Username string `json:"username"`
+	// The secret encoded in base32.
+	Secret string `json:"secret"`
+	// The counter of the next one-time password the token is expected to generate.
+	Counter uint64 `json:"counter"`
+	// The number of digits of the one-time passwords, 6 or 8. Zero stands for 6.
+	Digits int `json:"digits,omitempty"`
+}
+
+// YubiKeyOTPDevice represents a YubiKey of a user generating Yubico OTPs, identified by the public ID prefixing the
+// one-time passwords.
+type YubiKeyOTPDevice struct {
+	// The user the YubiKey belongs to.
+	Username string `json:"username"`
+	// The modhex encoded public ID of the YubiKey, the first 12 characters of its one-time passwords.
+	PublicID string `json:"public_id"`
+	// The time the YubiKey was added.
+	Time time.Time `json:"time"`
+}
+
+// SecondFactorEnrollment represents the start of the grace period given to a user required to enroll a second factor.
+type SecondFactorEnrollment struct {
+	// The user required to enroll a second factor.
+	Username string `json:"username"`
+	// The time the user was first required to enroll a second factor.
+	Time time.Time `json:"time"`
+}
+
+// DomainPreference represents the second factor method a user prefers to use to access a protected domain.
+type DomainPreference struct {
+	// The user the preference belongs to.
+	Username string `json:"username"`
+	// The protected domain the preference applies to.
+	Domain string `json:"domain"`
+	// The preferred second factor method.
+	SecondFactorMethod string `json:"second_factor_method"`
 }
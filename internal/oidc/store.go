@@ -32,6 +32,7 @@ func NewOpenIDConnectStore(configuration *schema.OpenIDConnectConfiguration) (st
 			GrantTypes:    clientConf.GrantTypes,
 			ResponseTypes: clientConf.ResponseTypes,
 			Scopes:        clientConf.Scopes,
+			Audience:      clientConf.Audience,
 		}
 
 		store.clients[client.ID] = client
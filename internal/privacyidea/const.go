@@ -0,0 +1,10 @@
+package privacyidea
+
+import (
+	"time"
+)
+
+// TokenTypePush is the type of the tokens approving the sign in with a notification on the phone of the user.
+const TokenTypePush = "push"
+
+const requestTimeout = 10 * time.Second
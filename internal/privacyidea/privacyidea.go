@@ -0,0 +1,122 @@
+package privacyidea
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// NewPrivacyIDEAAPI create privacyIDEA API instance.
+func NewPrivacyIDEAAPI(configuration schema.PrivacyIDEAConfiguration) *APIImpl {
+	return &APIImpl{
+		url:        strings.TrimSuffix(configuration.URL, "/"),
+		realm:      configuration.Realm,
+		adminToken: configuration.AdminToken,
+		client:     &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Check validates the one-time password of the user, or the answer to the challenge of the transaction when it is
+// set. An empty pass triggers the challenges of the tokens of the user such as the push notifications.
+func (a *APIImpl) Check(username, pass, transactionID string) (*CheckResponse, error) {
+	values := url.Values{}
+	values.Set("user", username)
+	values.Set("pass", pass)
+
+	if a.realm != "" {
+		values.Set("realm", a.realm)
+	}
+
+	if transactionID != "" {
+		values.Set("transaction_id", transactionID)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, a.url+"/validate/check", strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var response CheckResponse
+
+	if err = a.do(req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Result.Error != nil {
+		return nil, fmt.Errorf("privacyIDEA error %d: %s", response.Result.Error.Code, response.Result.Error.Message)
+	}
+
+	return &response, nil
+}
+
+// PollTransaction returns whether the challenge of the transaction was answered, for example a push notification
+// accepted by the user.
+func (a *APIImpl) PollTransaction(transactionID string) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, a.url+"/validate/polltransaction?"+url.Values{"transaction_id": {transactionID}}.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+
+	var response pollTransactionResponse
+
+	if err = a.do(req, &response); err != nil {
+		return false, err
+	}
+
+	if response.Result.Error != nil {
+		return false, fmt.Errorf("privacyIDEA error %d: %s", response.Result.Error.Code, response.Result.Error.Message)
+	}
+
+	return response.Result.Value, nil
+}
+
+// Tokens returns the tokens enrolled by the user, it requires the authorization of an administrator.
+func (a *APIImpl) Tokens(username string) ([]Token, error) {
+	values := url.Values{}
+	values.Set("user", username)
+
+	if a.realm != "" {
+		values.Set("realm", a.realm)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, a.url+"/token/?"+values.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", a.adminToken)
+
+	var response tokensResponse
+
+	if err = a.do(req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Result.Error != nil {
+		return nil, fmt.Errorf("privacyIDEA error %d: %s", response.Result.Error.Code, response.Result.Error.Message)
+	}
+
+	return response.Result.Value.Tokens, nil
+}
+
+func (a *APIImpl) do(req *http.Request, response interface{}) error {
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	// The errors of the server are described in the body with a 4xx or 5xx status code.
+	if err = json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("unable to parse the response with the status code %d: %w", resp.StatusCode, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package privacyidea
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func newTestAPI(t *testing.T, handler http.HandlerFunc) *APIImpl {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewPrivacyIDEAAPI(schema.PrivacyIDEAConfiguration{
+		URL:        server.URL + "/",
+		Realm:      "example",
+		AdminToken: "admin-token",
+	})
+}
+
+func TestShouldCheckOneTimePassword(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "/validate/check", r.URL.Path)
+		assert.Equal(t, "john", r.PostForm.Get("user"))
+		assert.Equal(t, "123456", r.PostForm.Get("pass"))
+		assert.Equal(t, "example", r.PostForm.Get("realm"))
+		assert.Equal(t, "", r.PostForm.Get("transaction_id"))
+
+		_, _ = w.Write([]byte(`{"result":{"status":true,"value":true},"detail":{"message":"matching 1 tokens"}}`))
+	})
+
+	response, err := api.Check("john", "123456", "")
+	require.NoError(t, err)
+	assert.True(t, response.Result.Value)
+}
+
+func TestShouldReturnErrorOfServer(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"result":{"status":false,"error":{"code":904,"message":"The user can not be found in any resolver in this realm!"}}}`))
+	})
+
+	_, err := api.Check("harry", "123456", "")
+	assert.EqualError(t, err, "privacyIDEA error 904: The user can not be found in any resolver in this realm!")
+}
+
+func TestShouldPollTransaction(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/validate/polltransaction", r.URL.Path)
+		assert.Equal(t, "12345", r.URL.Query().Get("transaction_id"))
+
+		_, _ = w.Write([]byte(`{"result":{"status":true,"value":true}}`))
+	})
+
+	accepted, err := api.PollTransaction("12345")
+	require.NoError(t, err)
+	assert.True(t, accepted)
+}
+
+func TestShouldListTokensWithAdminToken(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/token/", r.URL.Path)
+		assert.Equal(t, "john", r.URL.Query().Get("user"))
+		assert.Equal(t, "example", r.URL.Query().Get("realm"))
+		assert.Equal(t, "admin-token", r.Header.Get("Authorization"))
+
+		_, _ = w.Write([]byte(`{"result":{"status":true,"value":{"count":2,"tokens":[` +
+			`{"serial":"TOTP0001","tokentype":"totp","active":true},` +
+			`{"serial":"PIPU0001","tokentype":"push","active":true,"revoked":true}]}}}`))
+	})
+
+	tokens, err := api.Tokens("john")
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, Token{Serial: "TOTP0001", TokenType: "totp", Active: true}, tokens[0])
+	assert.True(t, tokens[0].Usable())
+	assert.False(t, tokens[1].Usable())
+}
@@ -0,0 +1,73 @@
+package privacyidea
+
+import (
+	"net/http"
+)
+
+// API interface wrapping the privacyIDEA REST API for testing purpose.
+type API interface {
+	Check(username, pass, transactionID string) (*CheckResponse, error)
+	PollTransaction(transactionID string) (bool, error)
+	Tokens(username string) ([]Token, error)
+}
+
+// APIImpl implementation of the API interface calling the privacyIDEA server.
+type APIImpl struct {
+	url        string
+	realm      string
+	adminToken string
+	client     *http.Client
+}
+
+// Error is the error returned by the privacyIDEA server.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// CheckResponse is the response of the /validate/check endpoint. The value is false with a transaction identifier
+// when a challenge was triggered, for example a push notification.
+type CheckResponse struct {
+	Result struct {
+		Status bool   `json:"status"`
+		Value  bool   `json:"value"`
+		Error  *Error `json:"error,omitempty"`
+	} `json:"result"`
+	Detail struct {
+		Message       string `json:"message"`
+		TransactionID string `json:"transaction_id"`
+	} `json:"detail"`
+}
+
+// Token is one of the tokens of a user returned by the /token/ endpoint.
+type Token struct {
+	Serial    string `json:"serial"`
+	TokenType string `json:"tokentype"`
+	Active    bool   `json:"active"`
+	Revoked   bool   `json:"revoked"`
+	Locked    bool   `json:"locked"`
+}
+
+// Usable returns whether the token can be used to sign in.
+func (t Token) Usable() bool {
+	return t.Active && !t.Revoked && !t.Locked
+}
+
+type pollTransactionResponse struct {
+	Result struct {
+		Status bool   `json:"status"`
+		Value  bool   `json:"value"`
+		Error  *Error `json:"error,omitempty"`
+	} `json:"result"`
+}
+
+type tokensResponse struct {
+	Result struct {
+		Status bool `json:"status"`
+		Value  struct {
+			Count  int     `json:"count"`
+			Tokens []Token `json:"tokens"`
+		} `json:"value"`
+		Error *Error `json:"error,omitempty"`
+	} `json:"result"`
+}
@@ -0,0 +1,27 @@
+package pushapproval
+
+import (
+	"errors"
+	"time"
+)
+
+// The statuses of the approval requests.
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusDenied   = "denied"
+	StatusExpired  = "expired"
+)
+
+// SignatureHeader is the header carrying the signature of the approval requests and of the callbacks.
+const SignatureHeader = "X-Authelia-Signature"
+
+const signaturePrefix = "sha256="
+
+const requestIDLength = 32
+
+const webhookTimeout = 10 * time.Second
+
+// ErrRequestNotFound is returned when a callback answers an approval request which doesn't exist, has expired or has
+// already been answered.
+var ErrRequestNotFound = errors.New("the approval request does not exist or has already been answered")
@@ -0,0 +1,145 @@
+package pushapproval
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// NewWebhookProvider creates a provider posting the approval requests to the configured webhook. The pending requests
+// are kept in memory so the callbacks must reach the instance which sent the request.
+func NewWebhookProvider(configuration schema.PushApprovalConfiguration, clock utils.Clock) *WebhookProvider {
+	timeout, _ := utils.ParseDurationString(configuration.Timeout)
+
+	return &WebhookProvider{
+		url:      configuration.URL,
+		secret:   []byte(configuration.Secret),
+		timeout:  timeout,
+		client:   &http.Client{Timeout: webhookTimeout},
+		clock:    clock,
+		requests: map[string]*approvalRequest{},
+	}
+}
+
+// Send posts a signed approval request for the user to the webhook and returns its identifier.
+func (p *WebhookProvider) Send(username, remoteIP, targetURL string) (id string, err error) {
+	random := make([]byte, requestIDLength/2)
+	if _, err = rand.Read(random); err != nil {
+		return "", err
+	}
+
+	id = hex.EncodeToString(random)
+	expires := p.clock.Now().Add(p.timeout)
+
+	body, err := json.Marshal(Payload{
+		ID:        id,
+		Username:  username,
+		RemoteIP:  remoteIP,
+		TargetURL: targetURL,
+		ExpiresAt: expires.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, p.sign(body))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("the webhook responded with the status code %d", resp.StatusCode)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.purge()
+	p.requests[id] = &approvalRequest{username: username, expires: expires, status: StatusPending}
+
+	return id, nil
+}
+
+// Status returns the status of the approval request of the user. The requests which have been answered are forgotten
+// once their status is returned so an approval can't be used twice.
+func (p *WebhookProvider) Status(id, username string) string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	request, ok := p.requests[id]
+	if !ok || request.username != username {
+		return StatusExpired
+	}
+
+	if request.status == StatusPending && p.clock.Now().After(request.expires) {
+		delete(p.requests, id)
+		return StatusExpired
+	}
+
+	if request.status != StatusPending {
+		delete(p.requests, id)
+	}
+
+	return request.status
+}
+
+// Respond records the answer of the user to a pending approval request.
+func (p *WebhookProvider) Respond(id string, approved bool) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	request, ok := p.requests[id]
+	if !ok || request.status != StatusPending || p.clock.Now().After(request.expires) {
+		return ErrRequestNotFound
+	}
+
+	if approved {
+		request.status = StatusApproved
+	} else {
+		request.status = StatusDenied
+	}
+
+	return nil
+}
+
+// VerifySignature checks the signature of the body of a callback was made with the shared secret.
+func (p *WebhookProvider) VerifySignature(body []byte, signature string) bool {
+	return hmac.Equal([]byte(p.sign(body)), []byte(signature))
+}
+
+// sign returns the HMAC-SHA256 signature of the body with the shared secret.
+func (p *WebhookProvider) sign(body []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// purge forgets the requests which expired without being checked by the portal.
+func (p *WebhookProvider) purge() {
+	now := p.clock.Now()
+
+	for id, request := range p.requests {
+		if now.After(request.expires) {
+			delete(p.requests, id)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package pushapproval
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+)
+
+func newTestProvider(t *testing.T, handler http.HandlerFunc) (*WebhookProvider, *mocks.TestingClock) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	clock := &mocks.TestingClock{}
+	clock.Set(time.Unix(1600000000, 0))
+
+	return NewWebhookProvider(schema.PushApprovalConfiguration{
+		URL:     server.URL,
+		Secret:  "secret",
+		Timeout: "60s",
+	}, clock), clock
+}
+
+func TestShouldSendSignedApprovalRequest(t *testing.T) {
+	var (
+		payload   Payload
+		signature string
+		body      []byte
+	)
+
+	provider, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	id, err := provider.Send("john", "1.2.3.4", "https://app.example.com")
+	require.NoError(t, err)
+	assert.Len(t, id, requestIDLength)
+
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, Payload{
+		ID:        id,
+		Username:  "john",
+		RemoteIP:  "1.2.3.4",
+		TargetURL: "https://app.example.com",
+		ExpiresAt: 1600000060,
+	}, payload)
+	assert.True(t, provider.VerifySignature(body, signature))
+	assert.False(t, provider.VerifySignature(body, "sha256=abc"))
+
+	assert.Equal(t, StatusPending, provider.Status(id, "john"))
+}
+
+func TestShouldFailWhenWebhookRejectsApprovalRequest(t *testing.T) {
+	provider, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	_, err := provider.Send("john", "1.2.3.4", "")
+	assert.EqualError(t, err, "the webhook responded with the status code 500")
+}
+
+func TestShouldApproveRequestOnlyOnce(t *testing.T) {
+	provider, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	id, err := provider.Send("john", "1.2.3.4", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, StatusExpired, provider.Status(id, "harry"))
+
+	require.NoError(t, provider.Respond(id, true))
+	assert.Equal(t, ErrRequestNotFound, provider.Respond(id, false))
+
+	assert.Equal(t, StatusApproved, provider.Status(id, "john"))
+	assert.Equal(t, StatusExpired, provider.Status(id, "john"))
+}
+
+func TestShouldDenyRequest(t *testing.T) {
+	provider, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	id, err := provider.Send("john", "1.2.3.4", "")
+	require.NoError(t, err)
+
+	require.NoError(t, provider.Respond(id, false))
+	assert.Equal(t, StatusDenied, provider.Status(id, "john"))
+}
+
+func TestShouldExpireRequest(t *testing.T) {
+	provider, clock := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	id, err := provider.Send("john", "1.2.3.4", "")
+	require.NoError(t, err)
+
+	clock.Set(clock.Now().Add(2 * time.Minute))
+
+	assert.Equal(t, ErrRequestNotFound, provider.Respond(id, true))
+	assert.Equal(t, StatusExpired, provider.Status(id, "john"))
+}
@@ -0,0 +1,50 @@
+package pushapproval
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// Provider is the interface pushing approval requests to the device of the user and collecting their answers.
+type Provider interface {
+	Send(username, remoteIP, targetURL string) (id string, err error)
+	Status(id, username string) string
+	Respond(id string, approved bool) error
+	VerifySignature(body []byte, signature string) bool
+}
+
+// WebhookProvider is the provider posting the approval requests to a webhook which answers them with a callback.
+type WebhookProvider struct {
+	url     string
+	secret  []byte
+	timeout time.Duration
+	client  *http.Client
+	clock   utils.Clock
+
+	mutex    sync.Mutex
+	requests map[string]*approvalRequest
+}
+
+type approvalRequest struct {
+	username string
+	expires  time.Time
+	status   string
+}
+
+// Payload is the body of the approval requests posted to the webhook.
+type Payload struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	RemoteIP  string `json:"remote_ip"`
+	TargetURL string `json:"target_url,omitempty"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// Callback is the body of the callbacks answering the approval requests.
+type Callback struct {
+	ID       string `json:"id"`
+	Approved bool   `json:"approved"`
+}
@@ -2,6 +2,7 @@ package regulation
 
 import (
 	"fmt"
+	"net"
 	"time"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
@@ -40,25 +41,32 @@ func NewRegulator(configuration *schema.RegulationConfiguration, provider storag
 	return regulator
 }
 
-// Mark mark an authentication attempt.
+// Mark mark an authentication attempt of the given type made from the given IP address.
 // We split Mark and Regulate in order to avoid timing attacks.
-func (r *Regulator) Mark(username string, successful bool) error {
+func (r *Regulator) Mark(username string, successful bool, remoteIP net.IP, authType string) error {
 	return r.storageProvider.AppendAuthenticationLog(models.AuthenticationAttempt{
 		Username:   username,
 		Successful: successful,
 		Time:       r.clock.Now(),
+		RemoteIP:   remoteIP.String(),
+		Type:       authType,
 	})
 }
 
 // Regulate regulate the authentication attempts for a given user.
 // This method returns ErrUserIsBanned if the user is banned along with the time until when
-// the user is banned.
+// the user is banned, which is zero if the user is banned permanently.
 func (r *Regulator) Regulate(username string) (time.Time, error) {
 	// If there is regulation configuration, no regulation applies.
 	if !r.enabled {
 		return time.Time{}, nil
 	}
 
+	bans, err := r.storageProvider.LoadActiveBans(username)
+	if err == nil && len(bans) != 0 {
+		return latestBanExpiry(bans), ErrUserIsBanned
+	}
+
 	now := r.clock.Now()
 
 	// TODO(c.michaud): make sure FindTime < BanTime.
@@ -98,3 +106,18 @@ func (r *Regulator) Regulate(username string) (time.Time, error) {
 
 	return time.Time{}, nil
 }
+
+// latestBanExpiry returns the time the latest of the bans expires, or zero if one of them is permanent.
+func latestBanExpiry(bans []models.Ban) (expires time.Time) {
+	for _, ban := range bans {
+		if ban.Expires == nil {
+			return time.Time{}
+		}
+
+		if ban.Expires.After(expires) {
+			expires = *ban.Expires
+		}
+	}
+
+	return expires
+}
@@ -49,6 +49,10 @@ func (s *RegulatorSuite) TestShouldNotThrowWhenUserIsLegitimate() {
 		},
 	}
 
+	s.storageMock.EXPECT().
+		LoadActiveBans(gomock.Eq("john")).
+		Return(nil, nil)
+
 	s.storageMock.EXPECT().
 		LoadLatestAuthenticationLogs(gomock.Eq("john"), gomock.Any()).
 		Return(attemptsInDB, nil)
@@ -80,6 +84,10 @@ func (s *RegulatorSuite) TestShouldNotThrowWhenFailedAuthenticationNotInFindTime
 		},
 	}
 
+	s.storageMock.EXPECT().
+		LoadActiveBans(gomock.Eq("john")).
+		Return(nil, nil)
+
 	s.storageMock.EXPECT().
 		LoadLatestAuthenticationLogs(gomock.Eq("john"), gomock.Any()).
 		Return(attemptsInDB, nil)
@@ -116,6 +124,10 @@ func (s *RegulatorSuite) TestShouldBanUserIfLatestAttemptsAreWithinFinTime() {
 		},
 	}
 
+	s.storageMock.EXPECT().
+		LoadActiveBans(gomock.Eq("john")).
+		Return(nil, nil)
+
 	s.storageMock.EXPECT().
 		LoadLatestAuthenticationLogs(gomock.Eq("john"), gomock.Any()).
 		Return(attemptsInDB, nil)
@@ -149,6 +161,10 @@ func (s *RegulatorSuite) TestShouldCheckUserIsStillBanned() {
 		},
 	}
 
+	s.storageMock.EXPECT().
+		LoadActiveBans(gomock.Eq("john")).
+		Return(nil, nil)
+
 	s.storageMock.EXPECT().
 		LoadLatestAuthenticationLogs(gomock.Eq("john"), gomock.Any()).
 		Return(attemptsInDB, nil)
@@ -173,6 +189,10 @@ func (s *RegulatorSuite) TestShouldCheckUserIsNotYetBanned() {
 		},
 	}
 
+	s.storageMock.EXPECT().
+		LoadActiveBans(gomock.Eq("john")).
+		Return(nil, nil)
+
 	s.storageMock.EXPECT().
 		LoadLatestAuthenticationLogs(gomock.Eq("john"), gomock.Any()).
 		Return(attemptsInDB, nil)
@@ -205,6 +225,10 @@ func (s *RegulatorSuite) TestShouldCheckUserWasAboutToBeBanned() {
 		},
 	}
 
+	s.storageMock.EXPECT().
+		LoadActiveBans(gomock.Eq("john")).
+		Return(nil, nil)
+
 	s.storageMock.EXPECT().
 		LoadLatestAuthenticationLogs(gomock.Eq("john"), gomock.Any()).
 		Return(attemptsInDB, nil)
@@ -241,6 +265,10 @@ func (s *RegulatorSuite) TestShouldCheckRegulationHasBeenResetOnSuccessfulAttemp
 		},
 	}
 
+	s.storageMock.EXPECT().
+		LoadActiveBans(gomock.Eq("john")).
+		Return(nil, nil)
+
 	s.storageMock.EXPECT().
 		LoadLatestAuthenticationLogs(gomock.Eq("john"), gomock.Any()).
 		Return(attemptsInDB, nil)
@@ -251,6 +279,39 @@ func (s *RegulatorSuite) TestShouldCheckRegulationHasBeenResetOnSuccessfulAttemp
 	assert.NoError(s.T(), err)
 }
 
+func (s *RegulatorSuite) TestShouldBanUserWithActiveBan() {
+	expires := s.clock.Now().Add(time.Hour)
+
+	s.storageMock.EXPECT().
+		LoadActiveBans(gomock.Eq("john")).
+		Return([]models.Ban{
+			{Username: "john", Time: s.clock.Now().Add(-time.Hour), Expires: &expires},
+		}, nil)
+
+	regulator := regulation.NewRegulator(&s.configuration, s.storageMock, &s.clock)
+
+	bannedUntil, err := regulator.Regulate("john")
+	assert.Equal(s.T(), regulation.ErrUserIsBanned, err)
+	assert.Equal(s.T(), expires, bannedUntil)
+}
+
+func (s *RegulatorSuite) TestShouldBanUserWithPermanentBan() {
+	expires := s.clock.Now().Add(time.Hour)
+
+	s.storageMock.EXPECT().
+		LoadActiveBans(gomock.Eq("john")).
+		Return([]models.Ban{
+			{Username: "john", Time: s.clock.Now().Add(-time.Minute), Expires: &expires},
+			{Username: "john", Reason: "compromised", Time: s.clock.Now().Add(-time.Hour)},
+		}, nil)
+
+	regulator := regulation.NewRegulator(&s.configuration, s.storageMock, &s.clock)
+
+	bannedUntil, err := regulator.Regulate("john")
+	assert.Equal(s.T(), regulation.ErrUserIsBanned, err)
+	assert.True(s.T(), bannedUntil.IsZero())
+}
+
 func TestRunRegulatorSuite(t *testing.T) {
 	s := new(RegulatorSuite)
 	suite.Run(t, s)
@@ -276,6 +337,10 @@ func (s *RegulatorSuite) TestShouldHaveRegulatorDisabled() {
 		},
 	}
 
+	s.storageMock.EXPECT().
+		LoadActiveBans(gomock.Eq("john")).
+		Return(nil, nil)
+
 	s.storageMock.EXPECT().
 		LoadLatestAuthenticationLogs(gomock.Eq("john"), gomock.Any()).
 		Return(attemptsInDB, nil)
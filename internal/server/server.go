@@ -11,7 +11,6 @@ import (
 	"strconv"
 	"strings"
 
-	duoapi "github.com/duosecurity/duo_api_golang"
 	"github.com/fasthttp/router"
 	"github.com/valyala/fasthttp"
 	"github.com/valyala/fasthttp/expvarhandler"
@@ -23,6 +22,10 @@ import (
 	"github.com/authelia/authelia/internal/handlers"
 	"github.com/authelia/authelia/internal/logging"
 	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/privacyidea"
+	"github.com/authelia/authelia/internal/pushapproval"
+	"github.com/authelia/authelia/internal/utils"
+	"github.com/authelia/authelia/internal/yubico"
 )
 
 //go:embed public_html
@@ -32,14 +35,15 @@ func registerRoutes(configuration schema.Configuration, providers middlewares.Pr
 	autheliaMiddleware := middlewares.AutheliaMiddleware(configuration, providers)
 	rememberMe := strconv.FormatBool(configuration.Session.RememberMeDuration != "0")
 	resetPassword := strconv.FormatBool(!configuration.AuthenticationBackend.DisableResetPassword)
+	passkeys := strconv.FormatBool(configuration.WebAuthn != nil && configuration.WebAuthn.EnablePasskeys)
 
 	embeddedPath, _ := fs.Sub(assets, "public_html")
 	embeddedFS := fasthttpadaptor.NewFastHTTPHandler(http.FileServer(http.FS(embeddedPath)))
 	rootFiles := []string{"favicon.ico", "manifest.json", "robots.txt"}
 
-	serveIndexHandler := ServeTemplatedFile(embeddedAssets, indexFile, configuration.Server.Path, rememberMe, resetPassword, configuration.Session.Name, configuration.Theme)
-	serveSwaggerHandler := ServeTemplatedFile(swaggerAssets, indexFile, configuration.Server.Path, rememberMe, resetPassword, configuration.Session.Name, configuration.Theme)
-	serveSwaggerAPIHandler := ServeTemplatedFile(swaggerAssets, apiFile, configuration.Server.Path, rememberMe, resetPassword, configuration.Session.Name, configuration.Theme)
+	serveIndexHandler := ServeTemplatedFile(embeddedAssets, indexFile, configuration.Server.Path, rememberMe, resetPassword, passkeys, configuration.Session.Name, configuration.Theme)
+	serveSwaggerHandler := ServeTemplatedFile(swaggerAssets, indexFile, configuration.Server.Path, rememberMe, resetPassword, passkeys, configuration.Session.Name, configuration.Theme)
+	serveSwaggerAPIHandler := ServeTemplatedFile(swaggerAssets, apiFile, configuration.Server.Path, rememberMe, resetPassword, passkeys, configuration.Session.Name, configuration.Theme)
 
 	r := router.New()
 	r.GET("/", serveIndexHandler)
@@ -59,10 +63,53 @@ func registerRoutes(configuration schema.Configuration, providers middlewares.Pr
 	r.GET("/api/configuration", autheliaMiddleware(
 		middlewares.RequireFirstFactor(handlers.ConfigurationGet)))
 
-	r.GET("/api/verify", autheliaMiddleware(handlers.VerifyGet(configuration.AuthenticationBackend)))
-	r.HEAD("/api/verify", autheliaMiddleware(handlers.VerifyGet(configuration.AuthenticationBackend)))
+	// Skip Error Check since validator checks it.
+	verifyCacheDuration, _ := utils.ParseDurationString(configuration.Server.VerifyCacheDuration)
+	verifyWebSocketCacheDuration, _ := utils.ParseDurationString(configuration.Server.VerifyWebSocketCacheDuration)
+	verifyHandler := handlers.VerifyGet(configuration.AuthenticationBackend, verifyCacheDuration, verifyWebSocketCacheDuration)
+
+	if configuration.Server.VerifyRateLimit.Requests > 0 {
+		verifyHandler = middlewares.RateLimit(configuration.Server.VerifyRateLimit, verifyHandler)
+	}
+
+	// The health checks are bypassed before being rate limited.
+	if len(configuration.Server.VerifyBypass) != 0 {
+		verifyHandler = handlers.VerifyBypass(configuration.Server.VerifyBypass, verifyHandler)
+	}
+
+	r.GET("/api/verify", autheliaMiddleware(verifyHandler))
+	r.HEAD("/api/verify", autheliaMiddleware(verifyHandler))
 
 	r.POST("/api/firstfactor", autheliaMiddleware(handlers.FirstFactorPost(1000, true)))
+
+	if configuration.AuthenticationBackend.ClientCertificate != nil && configuration.AuthenticationBackend.ClientCertificate.FirstFactor {
+		r.POST("/api/firstfactor/certificate", autheliaMiddleware(
+			handlers.FirstFactorCertificatePost(configuration.AuthenticationBackend.ClientCertificate)))
+	}
+
+	if configuration.WebAuthn != nil && configuration.WebAuthn.EnablePasskeys {
+		r.POST("/api/firstfactor/passkey/options", autheliaMiddleware(handlers.FirstFactorPasskeyOptionsPost))
+		r.POST("/api/firstfactor/passkey/conditional/options", autheliaMiddleware(handlers.FirstFactorPasskeyConditionalOptionsPost))
+		r.POST("/api/firstfactor/passkey", autheliaMiddleware(handlers.FirstFactorPasskeyPost))
+
+		if configuration.WebAuthn.PasskeyMode == "first_factor" {
+			r.POST("/api/secondfactor/password", autheliaMiddleware(
+				middlewares.RequireFirstFactor(handlers.SecondFactorPasswordPost)))
+		}
+
+		r.POST("/api/webauthn/register/options", autheliaMiddleware(
+			middlewares.RequireTwoFactor(handlers.WebAuthnRegistrationOptionsPost(configuration.WebAuthn))))
+		r.POST("/api/webauthn/register", autheliaMiddleware(
+			middlewares.RequireTwoFactor(handlers.WebAuthnRegistrationPost(configuration.WebAuthn))))
+
+		r.GET("/api/webauthn/credentials", autheliaMiddleware(
+			middlewares.RequireTwoFactor(handlers.WebAuthnCredentialsGet)))
+		r.POST("/api/webauthn/credentials/rename", autheliaMiddleware(
+			middlewares.RequireTwoFactor(handlers.WebAuthnCredentialRenamePost)))
+		r.POST("/api/webauthn/credentials/delete", autheliaMiddleware(
+			middlewares.RequireTwoFactor(handlers.WebAuthnCredentialDeletePost)))
+	}
+
 	r.POST("/api/logout", autheliaMiddleware(handlers.LogoutPost))
 
 	// Only register endpoints if forgot password is not disabled.
@@ -83,15 +130,20 @@ func registerRoutes(configuration schema.Configuration, providers middlewares.Pr
 		middlewares.RequireFirstFactor(handlers.MethodPreferencePost)))
 
 	// TOTP related endpoints.
+	totpVerifier := &handlers.TOTPVerifierImpl{
+		Period:     uint(configuration.TOTP.Period),
+		SkewBefore: uint(*configuration.TOTP.SkewBefore),
+		SkewAfter:  uint(*configuration.TOTP.SkewAfter),
+	}
+
 	r.POST("/api/secondfactor/totp/identity/start", autheliaMiddleware(
 		middlewares.RequireFirstFactor(handlers.SecondFactorTOTPIdentityStart)))
 	r.POST("/api/secondfactor/totp/identity/finish", autheliaMiddleware(
 		middlewares.RequireFirstFactor(handlers.SecondFactorTOTPIdentityFinish)))
+	r.POST("/api/secondfactor/totp/register", autheliaMiddleware(
+		middlewares.RequireFirstFactor(handlers.SecondFactorTOTPRegisterPost(totpVerifier))))
 	r.POST("/api/secondfactor/totp", autheliaMiddleware(
-		middlewares.RequireFirstFactor(handlers.SecondFactorTOTPPost(&handlers.TOTPVerifierImpl{
-			Period: uint(configuration.TOTP.Period),
-			Skew:   uint(*configuration.TOTP.Skew),
-		}))))
+		middlewares.RequireFirstFactor(handlers.SecondFactorTOTPPost(totpVerifier))))
 
 	// U2F related endpoints.
 	r.POST("/api/secondfactor/u2f/identity/start", autheliaMiddleware(
@@ -108,23 +160,90 @@ func registerRoutes(configuration schema.Configuration, providers middlewares.Pr
 	r.POST("/api/secondfactor/u2f/sign", autheliaMiddleware(
 		middlewares.RequireFirstFactor(handlers.SecondFactorU2FSignPost(&handlers.U2FVerifierImpl{}))))
 
-	// Configure DUO api endpoint only if configuration exists.
+	// Recovery codes related endpoints.
+	r.GET("/api/secondfactor/recovery_codes", autheliaMiddleware(
+		middlewares.RequireTwoFactor(handlers.RecoveryCodesGet)))
+	r.POST("/api/secondfactor/recovery_codes", autheliaMiddleware(
+		middlewares.RequireTwoFactor(handlers.RecoveryCodesPost)))
+	r.POST("/api/secondfactor/recovery_code", autheliaMiddleware(
+		middlewares.RequireFirstFactor(handlers.SecondFactorRecoveryCodePost)))
+
+	// Configure the trusted devices endpoints only if configuration exists.
+	if configuration.TrustedDevices != nil {
+		r.GET("/api/trusted_devices", autheliaMiddleware(
+			middlewares.RequireTwoFactor(handlers.TrustedDevicesGet)))
+		r.POST("/api/trusted_devices", autheliaMiddleware(
+			middlewares.RequireTwoFactor(handlers.TrustedDevicePost)))
+		r.POST("/api/trusted_devices/revoke", autheliaMiddleware(
+			middlewares.RequireTwoFactor(handlers.TrustedDeviceRevokePost)))
+	}
+
+	// Configure the email one-time code endpoints only if configuration exists.
+	if configuration.EmailOneTimeCode != nil {
+		r.POST("/api/secondfactor/email/start", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.SecondFactorEmailStartPost)))
+		r.POST("/api/secondfactor/email", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.SecondFactorEmailPost)))
+	}
+
+	// Configure the push approval endpoints only if configuration exists.
+	if configuration.PushApproval != nil {
+		pushApprovalProvider := pushapproval.NewWebhookProvider(*configuration.PushApproval, utils.RealClock{})
+
+		r.POST("/api/secondfactor/push_approval/start", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.SecondFactorPushApprovalStartPost(pushApprovalProvider))))
+		r.POST("/api/secondfactor/push_approval", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.SecondFactorPushApprovalPost(pushApprovalProvider))))
+		// The callback is authenticated by the signature of its body.
+		r.POST("/api/secondfactor/push_approval/callback", autheliaMiddleware(
+			handlers.PushApprovalCallbackPost(pushApprovalProvider)))
+	}
+
+	// Configure the privacyIDEA endpoints only if configuration exists.
+	if configuration.PrivacyIDEA != nil {
+		privacyIDEAAPI := privacyidea.NewPrivacyIDEAAPI(*configuration.PrivacyIDEA)
+
+		r.GET("/api/secondfactor/privacyidea/tokens", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.PrivacyIDEATokensGet(privacyIDEAAPI))))
+		r.POST("/api/secondfactor/privacyidea", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.SecondFactorPrivacyIDEAPost(privacyIDEAAPI))))
+		r.POST("/api/secondfactor/privacyidea/push", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.SecondFactorPrivacyIDEAPushPost(privacyIDEAAPI))))
+	}
+
+	// Configure the HOTP endpoint only if configuration exists.
+	if configuration.HOTP != nil {
+		hotpVerifier := &handlers.HOTPVerifierImpl{
+			LookAhead:    uint64(configuration.HOTP.LookAhead),
+			ResyncWindow: uint64(configuration.HOTP.ResyncWindow),
+		}
+
+		r.POST("/api/secondfactor/hotp", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.SecondFactorHOTPPost(hotpVerifier))))
+	}
+
+	// Configure the YubiKey OTP endpoint only if configuration exists.
+	if configuration.YubiKeyOTP != nil {
+		yubicoAPI := yubico.NewYubicoAPI(*configuration.YubiKeyOTP)
+
+		r.POST("/api/secondfactor/yubikey_otp", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.SecondFactorYubiKeyOTPPost(yubicoAPI))))
+	}
+
+	// Configure DUO api endpoint only if configuration exists, the users are redirected to the Universal Prompt when
+	// the Web SDK credentials are configured.
 	if configuration.DuoAPI != nil {
-		var duoAPI duo.API
-		if os.Getenv("ENVIRONMENT") == dev {
-			duoAPI = duo.NewDuoAPI(duoapi.NewDuoApi(
-				configuration.DuoAPI.IntegrationKey,
-				configuration.DuoAPI.SecretKey,
-				configuration.DuoAPI.Hostname, "", duoapi.SetInsecure()))
+		integrations := duo.NewIntegrations(*configuration.DuoAPI, os.Getenv("ENVIRONMENT") == dev)
+
+		if configuration.DuoAPI.IsUniversalPrompt() {
+			r.POST("/api/secondfactor/duo/universal", autheliaMiddleware(
+				middlewares.RequireFirstFactor(handlers.SecondFactorDuoUniversalPost(integrations))))
+			r.GET("/api/secondfactor/duo/callback", autheliaMiddleware(
+				middlewares.RequireFirstFactor(handlers.SecondFactorDuoUniversalCallbackGet(integrations))))
 		} else {
-			duoAPI = duo.NewDuoAPI(duoapi.NewDuoApi(
-				configuration.DuoAPI.IntegrationKey,
-				configuration.DuoAPI.SecretKey,
-				configuration.DuoAPI.Hostname, ""))
+			r.POST("/api/secondfactor/duo", autheliaMiddleware(
+				middlewares.RequireFirstFactor(handlers.SecondFactorDuoPost(integrations))))
 		}
-
-		r.POST("/api/secondfactor/duo", autheliaMiddleware(
-			middlewares.RequireFirstFactor(handlers.SecondFactorDuoPost(duoAPI))))
 	}
 
 	// If trace is set, enable pprofhandler and expvarhandler.
@@ -0,0 +1,43 @@
+package session
+
+import (
+	"net"
+	"strings"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// Domains returns the cookie domains of the configuration, the domain of the session being the first one.
+func Domains(configuration schema.SessionConfiguration) []schema.SessionDomainConfiguration {
+	domains := []schema.SessionDomainConfiguration{{Domain: configuration.Domain}}
+
+	for _, domain := range configuration.Domains {
+		if domain.Domain == configuration.Domain {
+			domains[0] = domain
+			continue
+		}
+
+		domains = append(domains, domain)
+	}
+
+	return domains
+}
+
+// MatchDomain returns the cookie domain protecting the host, the longest one if several match.
+func MatchDomain(configuration schema.SessionConfiguration, host string) (match schema.SessionDomainConfiguration, ok bool) {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	for _, domain := range Domains(configuration) {
+		if !strings.HasSuffix(host, domain.Domain) {
+			continue
+		}
+
+		if !ok || len(domain.Domain) > len(match.Domain) {
+			match, ok = domain, true
+		}
+	}
+
+	return match, ok
+}
@@ -0,0 +1,38 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldMatchDomainOfHost(t *testing.T) {
+	configuration := schema.SessionConfiguration{
+		Domain: testDomain,
+		Domains: []schema.SessionDomainConfiguration{
+			{Domain: testDomain, AutheliaURL: "https://auth.example.com"},
+			{Domain: "example.org", AutheliaURL: "https://auth.example.org"},
+			{Domain: "sub.example.org"},
+		},
+	}
+
+	assert.Len(t, Domains(configuration), 3)
+	assert.Equal(t, "https://auth.example.com", Domains(configuration)[0].AutheliaURL)
+
+	domain, ok := MatchDomain(configuration, "app.example.com:8080")
+	assert.True(t, ok)
+	assert.Equal(t, testDomain, domain.Domain)
+
+	domain, ok = MatchDomain(configuration, "app.example.org")
+	assert.True(t, ok)
+	assert.Equal(t, "https://auth.example.org", domain.AutheliaURL)
+
+	domain, ok = MatchDomain(configuration, "app.sub.example.org")
+	assert.True(t, ok)
+	assert.Equal(t, "sub.example.org", domain.Domain)
+
+	_, ok = MatchDomain(configuration, "app.example.net")
+	assert.False(t, ok)
+}
@@ -17,9 +17,11 @@ import (
 
 // Provider a session provider.
 type Provider struct {
-	sessionHolder *fasthttpsession.Session
-	RememberMe    time.Duration
-	Inactivity    time.Duration
+	sessionHolder  *fasthttpsession.Session
+	sessionHolders map[string]*fasthttpsession.Session
+	configuration  schema.SessionConfiguration
+	RememberMe     time.Duration
+	Inactivity     time.Duration
 }
 
 // NewProvider instantiate a session provider given a configuration.
@@ -28,6 +30,17 @@ func NewProvider(configuration schema.SessionConfiguration, certPool *x509.CertP
 
 	provider := new(Provider)
 	provider.sessionHolder = fasthttpsession.New(providerConfig.config)
+	provider.configuration = configuration
+	provider.sessionHolders = map[string]*fasthttpsession.Session{
+		configuration.Domain: provider.sessionHolder,
+	}
+
+	// The sessions of every cookie domain are kept in the same store, only the domain of the cookie differs.
+	for _, domain := range Domains(configuration)[1:] {
+		config := providerConfig.config
+		config.Domain = domain.Domain
+		provider.sessionHolders[domain.Domain] = fasthttpsession.New(config)
+	}
 
 	logger := logging.Logger()
 
@@ -65,17 +78,33 @@ func NewProvider(configuration schema.SessionConfiguration, certPool *x509.CertP
 		}
 	}
 
-	err = provider.sessionHolder.SetProvider(providerImpl)
-	if err != nil {
-		logger.Fatal(err)
+	for _, sessionHolder := range provider.sessionHolders {
+		if err = sessionHolder.SetProvider(providerImpl); err != nil {
+			logger.Fatal(err)
+		}
 	}
 
 	return provider
 }
 
+// holder returns the session holder of the cookie domain protecting the host of the request, or the one of the domain
+// of the session if none does.
+func (p *Provider) holder(ctx *fasthttp.RequestCtx) *fasthttpsession.Session {
+	host := ctx.Request.Header.Peek("X-Forwarded-Host")
+	if len(host) == 0 {
+		host = ctx.Host()
+	}
+
+	if domain, ok := MatchDomain(p.configuration, string(host)); ok {
+		return p.sessionHolders[domain.Domain]
+	}
+
+	return p.sessionHolder
+}
+
 // GetSession return the user session from a request.
 func (p *Provider) GetSession(ctx *fasthttp.RequestCtx) (UserSession, error) {
-	store, err := p.sessionHolder.Get(ctx)
+	store, err := p.holder(ctx).Get(ctx)
 
 	if err != nil {
 		return NewDefaultUserSession(), err
@@ -105,7 +134,7 @@ func (p *Provider) GetSession(ctx *fasthttp.RequestCtx) (UserSession, error) {
 
 // SaveSession save the user session.
 func (p *Provider) SaveSession(ctx *fasthttp.RequestCtx, userSession UserSession) error {
-	store, err := p.sessionHolder.Get(ctx)
+	store, err := p.holder(ctx).Get(ctx)
 
 	if err != nil {
 		return err
@@ -119,7 +148,7 @@ func (p *Provider) SaveSession(ctx *fasthttp.RequestCtx, userSession UserSession
 
 	store.Set(userSessionStorerKey, userSessionJSON)
 
-	err = p.sessionHolder.Save(ctx, store)
+	err = p.holder(ctx).Save(ctx, store)
 
 	if err != nil {
 		return err
@@ -130,19 +159,19 @@ func (p *Provider) SaveSession(ctx *fasthttp.RequestCtx, userSession UserSession
 
 // RegenerateSession regenerate a session ID.
 func (p *Provider) RegenerateSession(ctx *fasthttp.RequestCtx) error {
-	err := p.sessionHolder.Regenerate(ctx)
+	err := p.holder(ctx).Regenerate(ctx)
 
 	return err
 }
 
 // DestroySession destroy a session ID and delete the cookie.
 func (p *Provider) DestroySession(ctx *fasthttp.RequestCtx) error {
-	return p.sessionHolder.Destroy(ctx)
+	return p.holder(ctx).Destroy(ctx)
 }
 
 // UpdateExpiration update the expiration of the cookie and session.
 func (p *Provider) UpdateExpiration(ctx *fasthttp.RequestCtx, expiration time.Duration) error {
-	store, err := p.sessionHolder.Get(ctx)
+	store, err := p.holder(ctx).Get(ctx)
 
 	if err != nil {
 		return err
@@ -154,12 +183,12 @@ func (p *Provider) UpdateExpiration(ctx *fasthttp.RequestCtx, expiration time.Du
 		return err
 	}
 
-	return p.sessionHolder.Save(ctx, store)
+	return p.holder(ctx).Save(ctx, store)
 }
 
 // GetExpiration get the expiration of the current session.
 func (p *Provider) GetExpiration(ctx *fasthttp.RequestCtx) (time.Duration, error) {
-	store, err := p.sessionHolder.Get(ctx)
+	store, err := p.holder(ctx).Get(ctx)
 
 	if err != nil {
 		return time.Duration(0), err
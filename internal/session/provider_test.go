@@ -80,3 +80,30 @@ func TestShouldDestroySessionAndWipeSessionData(t *testing.T) {
 	assert.Equal(t, "", newUserSession.Username)
 	assert.Equal(t, authentication.NotAuthenticated, newUserSession.AuthenticationLevel)
 }
+
+func TestShouldSetCookieOfDomainOfHost(t *testing.T) {
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Domains = []schema.SessionDomainConfiguration{{Domain: "example.org"}}
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+
+	provider := NewProvider(configuration, nil)
+
+	for _, host := range []string{"auth.example.com", "auth.example.org"} {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.Set("X-Forwarded-Host", host)
+
+		session, err := provider.GetSession(ctx)
+		require.NoError(t, err)
+
+		session.Username = testUsername
+		require.NoError(t, provider.SaveSession(ctx, session))
+
+		cookie := fasthttp.AcquireCookie()
+		cookie.SetKey(testName)
+		require.True(t, ctx.Response.Header.Cookie(cookie))
+		assert.Equal(t, []byte(host[len("auth."):]), cookie.Domain())
+		fasthttp.ReleaseCookie(cookie)
+	}
+}
@@ -32,11 +32,25 @@ type UserSession struct {
 	// TODO(c.michaud): move groups out of the session.
 	Groups []string
 	Emails []string
+	// The additional attributes of the user forwarded by the verify endpoint.
+	Attributes map[string][]string
 
 	KeepMeLoggedIn      bool
 	AuthenticationLevel authentication.Level
 	LastActivity        int64
 
+	// The times the first and second factors were last performed, checked by the rules requiring a recent
+	// authentication.
+	FirstFactorAuthnTimestamp  int64
+	SecondFactorAuthnTimestamp int64
+
+	// The methods the user authenticated with, in the order they were performed.
+	AuthenticationMethods []string
+
+	// The time until which the user required to enroll a second factor can access the resources requiring one without
+	// it, zero when the user is not required to enroll a second factor.
+	SecondFactorEnrollmentDeadline int64
+
 	// The challenge generated in first step of U2F registration (after identity verification) or authentication.
 	// This is used reused in the second phase to check that the challenge has been completed.
 	U2FChallenge *u2f.Challenge
@@ -44,6 +58,28 @@ type UserSession struct {
 	// This is used in second phase of a U2F authentication.
 	U2FRegistration *U2FRegistration
 
+	// The challenge of the WebAuthn registration or assertion in progress, checked when the authenticator responds.
+	WebAuthnChallenge []byte
+	// The user handle of the passkey being registered, shared by all of the passkeys of the user.
+	WebAuthnUserHandle []byte
+	// The challenge of the conditional assertion offering the passkeys in the username field, kept apart from the
+	// other ceremonies as it is pending for as long as the sign in form is displayed.
+	WebAuthnConditionalChallenge []byte
+
+	// The hash of the one-time code sent by email and the time after which it can't be used anymore.
+	EmailOneTimeCodeHash       string
+	EmailOneTimeCodeExpiration int64
+
+	// The identifier of the approval request pushed to the device of the user, checked until it is answered.
+	PushApprovalRequestID string
+
+	// The state and nonce of the redirection to the Duo Universal Prompt, checked when Duo redirects the user back, the
+	// integration the user was redirected to and the URL the user is redirected to afterwards.
+	DuoState       string
+	DuoNonce       string
+	DuoIntegration string
+	DuoTargetURL   string
+
 	// Represent an OIDC workflow session initiated by the client if not null.
 	OIDCWorkflowSession *OIDCWorkflowSession
 
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+// SaveAPIKey persists an API key. Only the hash of the key is persisted.
+func (p *SQLProvider) SaveAPIKey(key models.APIKey) error {
+	_, err := p.exec(p.sqlInsertAPIKey, key.Name, key.Hash, strings.Join(key.Groups, ","), key.Time.Unix(), key.Revoked)
+	return err
+}
+
+// LoadAPIKey loads the API key which is not revoked given the hash of the key.
+func (p *SQLProvider) LoadAPIKey(hash string) (*models.APIKey, error) {
+	var (
+		key    models.APIKey
+		groups sql.NullString
+		t      int64
+	)
+
+	if err := p.queryRow(p.db, p.sqlSelectActiveAPIKey, []interface{}{hash}, &key.Name, &key.Hash, &groups, &t, &key.Revoked); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNoAPIKey
+		}
+
+		return nil, err
+	}
+
+	key.Groups = splitAPIKeyGroups(groups)
+	key.Time = time.Unix(t, 0)
+
+	return &key, nil
+}
+
+// LoadAPIKeys loads the API keys which are not revoked, the oldest first.
+func (p *SQLProvider) LoadAPIKeys() ([]models.APIKey, error) {
+	var keys []models.APIKey
+
+	err := p.retry(func() error {
+		rows, err := p.db.Query(p.sqlSelectActiveAPIKeys)
+		if err != nil {
+			return err
+		}
+
+		defer rows.Close()
+
+		keys = make([]models.APIKey, 0, 1)
+
+		for rows.Next() {
+			key, err := scanAPIKey(rows)
+			if err != nil {
+				return err
+			}
+
+			keys = append(keys, key)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes the API keys with the given name. It returns the number of revoked keys.
+func (p *SQLProvider) RevokeAPIKey(name string) (int64, error) {
+	result, err := p.exec(p.sqlRevokeAPIKeys, name)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func splitAPIKeyGroups(groups sql.NullString) []string {
+	if groups.String == "" {
+		return nil
+	}
+
+	return strings.Split(groups.String, ",")
+}
+
+func scanAPIKey(rows *sql.Rows) (key models.APIKey, err error) {
+	var (
+		groups sql.NullString
+		t      int64
+	)
+
+	if err = rows.Scan(&key.Name, &key.Hash, &groups, &t, &key.Revoked); err != nil {
+		return key, err
+	}
+
+	key.Groups = splitAPIKeyGroups(groups)
+	key.Time = time.Unix(t, 0)
+
+	return key, nil
+}
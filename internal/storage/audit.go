@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+// auditEventAppendMaxAttempts is the number of times an audit event is appended when another instance appended an
+// event to the chain at the same time.
+const auditEventAppendMaxAttempts = 5
+
+// AppendAuditEvent records an audit event. Audit events are never updated nor deleted by Authelia. Each event is
+// chained to the previous one by including the hash of the previous event in its own hash, so an event which is
+// modified or deleted afterwards breaks the chain verified by VerifyAuditEvents.
+func (p *SQLProvider) AppendAuditEvent(event models.AuditEvent) (err error) {
+	for attempt := 1; ; attempt++ {
+		var index int64
+
+		if index, err = p.appendAuditEvent(event); err == nil || attempt >= auditEventAppendMaxAttempts {
+			return err
+		}
+
+		// The unique index on the position in the chain rejects the event if another instance appended one at the
+		// same position, in which case the event is chained again to the new head.
+		head, _, headErr := p.loadAuditChainHead()
+		if headErr != nil || head < index {
+			return err
+		}
+	}
+}
+
+func (p *SQLProvider) appendAuditEvent(event models.AuditEvent) (index int64, err error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	var previous sql.NullString
+
+	var head sql.NullInt64
+
+	if err = tx.QueryRow(p.sqlSelectAuditChainHead).Scan(&head, &previous); err != nil && err != sql.ErrNoRows {
+		_ = tx.Rollback()
+
+		return 0, err
+	}
+
+	index = head.Int64 + 1
+
+	_, err = tx.Exec(p.sqlInsertAuditEvent, event.Username, event.Type, event.RemoteIP, event.Details, event.Time.Unix(),
+		index, auditEventHash(previous.String, index, event))
+	if err != nil {
+		_ = tx.Rollback()
+
+		return index, err
+	}
+
+	return index, tx.Commit()
+}
+
+// loadAuditChainHead returns the position and the hash of the last audit event of the chain.
+func (p *SQLProvider) loadAuditChainHead() (index int64, hash string, err error) {
+	var (
+		head     sql.NullInt64
+		headHash sql.NullString
+	)
+
+	err = p.db.QueryRow(p.sqlSelectAuditChainHead).Scan(&head, &headHash)
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	}
+
+	return head.Int64, headHash.String, err
+}
+
+// VerifyAuditEvents recomputes the hash chain of the audit events and returns ErrAuditChainBroken if an event was
+// modified, deleted or inserted outside of the chain after it was recorded. The events recorded before the chain was
+// introduced can't be verified and are only counted.
+func (p *SQLProvider) VerifyAuditEvents() (verification *AuditEventsVerification, err error) {
+	verification = &AuditEventsVerification{}
+
+	var first time.Time
+
+	err = p.exportRows(p.sqlSelectAuditChain, func(rows *sql.Rows) error {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case event.Index != int64(verification.Events)+1:
+			return fmt.Errorf("%w: expected the audit event at position %d but found position %d", ErrAuditChainBroken, verification.Events+1, event.Index)
+		case event.Hash != auditEventHash(verification.Head, event.Index, event):
+			return fmt.Errorf("%w: the audit event at position %d doesn't match its hash", ErrAuditChainBroken, event.Index)
+		}
+
+		if event.Index == 1 {
+			first = event.Time
+		}
+
+		verification.Events++
+		verification.Head = event.Hash
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.db.QueryRow(p.sqlCountUnchainedAuditEvents, 0).Scan(&verification.Unchained); err != nil {
+		return nil, err
+	}
+
+	if verification.Events == 0 {
+		return verification, nil
+	}
+
+	var inserted int
+
+	if err = p.db.QueryRow(p.sqlCountUnchainedAuditEvents, first.Unix()+1).Scan(&inserted); err != nil {
+		return nil, err
+	}
+
+	if inserted != 0 {
+		return nil, fmt.Errorf("%w: %d audit events outside of the chain were recorded after it started", ErrAuditChainBroken, inserted)
+	}
+
+	return verification, nil
+}
+
+// auditEventHash returns the hash of an audit event chained to the hash of the previous event. Each field is prefixed
+// with its length so the boundaries between the fields can't be moved without changing the hash.
+func auditEventHash(previous string, index int64, event models.AuditEvent) string {
+	hash := sha256.New()
+
+	for _, field := range []string{previous, strconv.FormatInt(index, 10), event.Username, event.Type, event.RemoteIP,
+		event.Details, strconv.FormatInt(event.Time.Unix(), 10)} {
+		length := make([]byte, 4)
+		binary.BigEndian.PutUint32(length, uint32(len(field)))
+
+		hash.Write(length)
+		hash.Write([]byte(field))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// LoadAuditEvents loads the audit events matching the filter, the most recent first, along with the cursor of the next
+// page which is empty on the last page.
+func (p *SQLProvider) LoadAuditEvents(filter AuditEventsFilter) (events []models.AuditEvent, next string, err error) {
+	var (
+		from, to int64 = 0, math.MaxInt32
+		limit          = math.MaxInt32
+	)
+
+	if !filter.From.IsZero() {
+		from = filter.From.Unix()
+	}
+
+	if !filter.To.IsZero() {
+		to = filter.To.Unix()
+	}
+
+	if filter.Limit > 0 {
+		limit = filter.Limit + 1
+	}
+
+	if from, to, err = pageBounds(from, to, filter.Cursor); err != nil {
+		return nil, "", err
+	}
+
+	if events, err = p.loadAuditEvents(filter, from, to, limit); err != nil {
+		return nil, "", err
+	}
+
+	keep, next, whole := pageByTime(len(events), filter.Limit, func(i int) int64 { return events[i].Time.Unix() })
+	if whole {
+		last := events[len(events)-1].Time.Unix()
+
+		if events, err = p.loadAuditEvents(filter, last, last, math.MaxInt32); err != nil {
+			return nil, "", err
+		}
+
+		return events, next, nil
+	}
+
+	return events[:keep], next, nil
+}
+
+func (p *SQLProvider) loadAuditEvents(filter AuditEventsFilter, from, to int64, limit int) (events []models.AuditEvent, err error) {
+	err = p.retry(func() error {
+		rows, err := p.dbRead().Query(p.sqlSelectAuditEvents,
+			filter.Username, filter.Username, filter.Type, filter.Type, from, to, limit)
+		if err != nil {
+			return err
+		}
+
+		defer rows.Close()
+
+		events = make([]models.AuditEvent, 0, 10)
+
+		for rows.Next() {
+			event, err := scanAuditEvent(rows)
+			if err != nil {
+				return err
+			}
+
+			events = append(events, event)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func scanAuditEvent(rows *sql.Rows) (event models.AuditEvent, err error) {
+	var (
+		details sql.NullString
+		t       int64
+		index   sql.NullInt64
+		hash    sql.NullString
+	)
+
+	if err = rows.Scan(&event.Username, &event.Type, &event.RemoteIP, &details, &t, &index, &hash); err != nil {
+		return event, err
+	}
+
+	event.Details = details.String
+	event.Time = time.Unix(t, 0)
+	event.Index = index.Int64
+	event.Hash = hash.String
+
+	return event, nil
+}
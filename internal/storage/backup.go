@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// ErrBackupDecryption is returned when a backup can't be decrypted, either because the passphrase is wrong or because
+// the backup was altered.
+var ErrBackupDecryption = errors.New("unable to decrypt the backup, the passphrase is wrong or the backup is corrupted")
+
+// EncryptBackup encrypts an export with a key derived from the passphrase and returns the resulting backup encoded as
+// JSON.
+func EncryptBackup(export *Export, passphrase string) (data []byte, err error) {
+	plaintext, err := json.Marshal(export)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode the export: %w", err)
+	}
+
+	backup := Backup{
+		Version:       backupVersion,
+		SchemaVersion: export.SchemaVersion,
+		Created:       time.Now().UTC(),
+		Salt:          make([]byte, backupSaltLength),
+	}
+
+	if _, err = rand.Read(backup.Salt); err != nil {
+		return nil, fmt.Errorf("unable to generate the salt of the backup: %w", err)
+	}
+
+	aead, err := newBackupAEAD(passphrase, backup.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	backup.Nonce = make([]byte, aead.NonceSize())
+
+	if _, err = rand.Read(backup.Nonce); err != nil {
+		return nil, fmt.Errorf("unable to generate the nonce of the backup: %w", err)
+	}
+
+	backup.Data = aead.Seal(nil, backup.Nonce, plaintext, backup.additionalData())
+
+	return json.MarshalIndent(backup, "", "  ")
+}
+
+// DecryptBackup decrypts a backup created by EncryptBackup and returns the export it contains.
+func DecryptBackup(data []byte, passphrase string) (export *Export, err error) {
+	backup := Backup{}
+
+	if err = json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("unable to decode the backup: %w", err)
+	}
+
+	if backup.Version != backupVersion {
+		return nil, fmt.Errorf("unable to restore a backup of version %d, only version %d is supported", backup.Version, backupVersion)
+	}
+
+	aead, err := newBackupAEAD(passphrase, backup.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(backup.Nonce) != aead.NonceSize() {
+		return nil, ErrBackupDecryption
+	}
+
+	plaintext, err := aead.Open(nil, backup.Nonce, backup.Data, backup.additionalData())
+	if err != nil {
+		return nil, ErrBackupDecryption
+	}
+
+	export = &Export{}
+
+	if err = json.Unmarshal(plaintext, export); err != nil {
+		return nil, fmt.Errorf("unable to decode the export of the backup: %w", err)
+	}
+
+	return export, nil
+}
+
+// newBackupAEAD returns the AES-256-GCM cipher keyed with the Argon2id hash of the passphrase.
+func newBackupAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, errors.New("the passphrase of the backup must not be empty")
+	}
+
+	key := argon2.IDKey([]byte(passphrase), salt, backupArgon2Iterations, backupArgon2Memory, backupArgon2Parallelism, 32)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// additionalData returns the authenticated but unencrypted header of the backup, so it can't be altered without the
+// decryption failing.
+func (b Backup) additionalData() []byte {
+	return []byte(fmt.Sprintf("%d:%d:%d", b.Version, b.SchemaVersion, b.Created.Unix()))
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/models"
+)
+
+func TestShouldEncryptAndDecryptBackup(t *testing.T) {
+	export := &Export{
+		SchemaVersion:   storageSchemaCurrentVersion,
+		UserPreferences: []ExportUserPreferences{{Username: unitTestUser, SecondFactorMethod: authentication.TOTP}},
+		TOTPSecrets:     []models.TOTPConfiguration{{Username: unitTestUser, Secret: "secret"}},
+	}
+
+	data, err := EncryptBackup(export, "passphrase")
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(data), "secret")
+
+	decrypted, err := DecryptBackup(data, "passphrase")
+	require.NoError(t, err)
+
+	assert.Equal(t, export, decrypted)
+
+	_, err = DecryptBackup(data, "wrong")
+	assert.Equal(t, ErrBackupDecryption, err)
+}
+
+func TestShouldNotDecryptAlteredBackup(t *testing.T) {
+	data, err := EncryptBackup(&Export{SchemaVersion: storageSchemaCurrentVersion}, "passphrase")
+	require.NoError(t, err)
+
+	backup := Backup{}
+	require.NoError(t, json.Unmarshal(data, &backup))
+
+	backup.SchemaVersion = 1
+
+	altered, err := json.Marshal(backup)
+	require.NoError(t, err)
+
+	_, err = DecryptBackup(altered, "passphrase")
+	assert.Equal(t, ErrBackupDecryption, err)
+
+	backup.Version = 2
+
+	altered, err = json.Marshal(backup)
+	require.NoError(t, err)
+
+	_, err = DecryptBackup(altered, "passphrase")
+	assert.EqualError(t, err, "unable to restore a backup of version 2, only version 1 is supported")
+
+	_, err = EncryptBackup(&Export{}, "")
+	assert.EqualError(t, err, "the passphrase of the backup must not be empty")
+}
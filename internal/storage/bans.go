@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+// SaveBan persists a ban of a user. A ban without an expiry time is permanent until it's revoked.
+func (p *SQLProvider) SaveBan(ban models.Ban) error {
+	_, err := p.exec(p.sqlInsertBan, ban.Username, ban.Reason, ban.Time.Unix(), banExpires(ban), ban.Revoked)
+	return err
+}
+
+// LoadActiveBans loads the bans of the user which are neither expired nor revoked, the most recent first. The bans of
+// every user are loaded when the username is empty.
+func (p *SQLProvider) LoadActiveBans(username string) ([]models.Ban, error) {
+	var bans []models.Ban
+
+	err := p.retry(func() error {
+		rows, err := p.db.Query(p.sqlSelectActiveBans, username, username, time.Now().Unix())
+		if err != nil {
+			return err
+		}
+
+		defer rows.Close()
+
+		bans = make([]models.Ban, 0, 1)
+
+		for rows.Next() {
+			ban, err := scanBan(rows)
+			if err != nil {
+				return err
+			}
+
+			bans = append(bans, ban)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bans, nil
+}
+
+// RevokeBan lifts the active bans of the user. It returns the number of revoked bans.
+func (p *SQLProvider) RevokeBan(username string) (int64, error) {
+	result, err := p.exec(p.sqlRevokeBans, username, time.Now().Unix())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func banExpires(ban models.Ban) sql.NullInt64 {
+	if ban.Expires == nil {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: ban.Expires.Unix(), Valid: true}
+}
+
+func scanBan(rows *sql.Rows) (ban models.Ban, err error) {
+	var (
+		reason  sql.NullString
+		t       int64
+		expires sql.NullInt64
+	)
+
+	if err = rows.Scan(&ban.Username, &reason, &t, &expires, &ban.Revoked); err != nil {
+		return ban, err
+	}
+
+	ban.Reason = reason.String
+	ban.Time = time.Unix(t, 0)
+
+	if expires.Valid {
+		expiresTime := time.Unix(expires.Int64, 0)
+		ban.Expires = &expiresTime
+	}
+
+	return ban, nil
+}
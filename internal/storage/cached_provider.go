@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// CachedProvider is a storage provider which caches the reads of the preferences, TOTP secrets and U2F devices of the
+// users made on every authentication. The cached values are invalidated when they are written through the provider, so
+// the cache is only coherent within a single instance of Authelia.
+type CachedProvider struct {
+	Provider
+
+	ttl   time.Duration
+	clock utils.Clock
+
+	mutex     sync.Mutex
+	entries   map[cacheKey]cacheEntry
+	lastPurge time.Time
+
+	// generation is incremented on every invalidation so a value loaded concurrently with a write isn't cached.
+	generation uint64
+}
+
+type cacheKey struct {
+	kind     string
+	username string
+}
+
+type cacheEntry struct {
+	values  []interface{}
+	err     error
+	expires time.Time
+}
+
+// Kinds of the cached values.
+const (
+	cacheKindPreferred2FAMethod = "preferred_2fa_method"
+	cacheKindTOTPSecret         = "totp_secret"
+	cacheKindU2FDeviceHandle    = "u2f_device_handle"
+)
+
+// NewCachedProvider constructs a provider caching the reads of provider for the given duration.
+func NewCachedProvider(provider Provider, ttl time.Duration, clock utils.Clock) *CachedProvider {
+	return &CachedProvider{
+		Provider: provider,
+		ttl:      ttl,
+		clock:    clock,
+		entries:  map[cacheKey]cacheEntry{},
+	}
+}
+
+// LoadPreferred2FAMethod load the preferred method for 2FA from the cache or the underlying provider.
+func (p *CachedProvider) LoadPreferred2FAMethod(username string) (string, error) {
+	values, err := p.load(cacheKey{cacheKindPreferred2FAMethod, username}, nil, func() ([]interface{}, error) {
+		method, err := p.Provider.LoadPreferred2FAMethod(username)
+		return []interface{}{method}, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return values[0].(string), nil
+}
+
+// SavePreferred2FAMethod save the preferred method for 2FA and invalidates the cached one.
+func (p *CachedProvider) SavePreferred2FAMethod(username string, method string) error {
+	defer p.invalidate(cacheKey{cacheKindPreferred2FAMethod, username})
+
+	return p.Provider.SavePreferred2FAMethod(username, method)
+}
+
+// LoadTOTPConfiguration load a TOTP configuration given a username from the cache or the underlying provider.
+func (p *CachedProvider) LoadTOTPConfiguration(username string) (*models.TOTPConfiguration, error) {
+	values, err := p.load(cacheKey{cacheKindTOTPSecret, username}, ErrNoTOTPSecret, func() ([]interface{}, error) {
+		configuration, err := p.Provider.LoadTOTPConfiguration(username)
+		return []interface{}{configuration}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The cached configuration is copied so the callers can't alter it.
+	configuration := *values[0].(*models.TOTPConfiguration)
+
+	return &configuration, nil
+}
+
+// SaveTOTPConfiguration save the TOTP configuration of a given user and invalidates the cached one.
+func (p *CachedProvider) SaveTOTPConfiguration(configuration models.TOTPConfiguration) error {
+	defer p.invalidate(cacheKey{cacheKindTOTPSecret, configuration.Username})
+
+	return p.Provider.SaveTOTPConfiguration(configuration)
+}
+
+// UpdateTOTPConfigurationSignIn updates the last sign in with the TOTP secret of a given user and invalidates the cached
+// one.
+func (p *CachedProvider) UpdateTOTPConfigurationSignIn(configuration models.TOTPConfiguration) error {
+	defer p.invalidate(cacheKey{cacheKindTOTPSecret, configuration.Username})
+
+	return p.Provider.UpdateTOTPConfigurationSignIn(configuration)
+}
+
+// DeleteTOTPSecret delete a TOTP secret given a username and invalidates the cached one.
+func (p *CachedProvider) DeleteTOTPSecret(username string) error {
+	defer p.invalidate(cacheKey{cacheKindTOTPSecret, username})
+
+	return p.Provider.DeleteTOTPSecret(username)
+}
+
+// LoadU2FDeviceHandle load a U2F device handle given a username from the cache or the underlying provider.
+func (p *CachedProvider) LoadU2FDeviceHandle(username string) ([]byte, []byte, error) {
+	values, err := p.load(cacheKey{cacheKindU2FDeviceHandle, username}, ErrNoU2FDeviceHandle, func() ([]interface{}, error) {
+		keyHandle, publicKey, err := p.Provider.LoadU2FDeviceHandle(username)
+		return []interface{}{keyHandle, publicKey}, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return values[0].([]byte), values[1].([]byte), nil
+}
+
+// SaveU2FDeviceHandle save a registered U2F device registration blob and invalidates the cached one.
+func (p *CachedProvider) SaveU2FDeviceHandle(username string, keyHandle []byte, publicKey []byte) error {
+	defer p.invalidate(cacheKey{cacheKindU2FDeviceHandle, username})
+
+	return p.Provider.SaveU2FDeviceHandle(username, keyHandle, publicKey)
+}
+
+// DeleteU2FDeviceHandle delete the U2F device registration blob of a given username and invalidates the cached one.
+func (p *CachedProvider) DeleteU2FDeviceHandle(username string) error {
+	defer p.invalidate(cacheKey{cacheKindU2FDeviceHandle, username})
+
+	return p.Provider.DeleteU2FDeviceHandle(username)
+}
+
+// load returns the cached values of key if they haven't expired, otherwise it calls fn and caches its values. The
+// error returned by fn is only cached if it's the cacheableErr, which denotes the absence of a value.
+func (p *CachedProvider) load(key cacheKey, cacheableErr error, fn func() ([]interface{}, error)) ([]interface{}, error) {
+	now := p.clock.Now()
+
+	p.mutex.Lock()
+	entry, ok := p.entries[key]
+	generation := p.generation
+	p.mutex.Unlock()
+
+	if ok && now.Before(entry.expires) {
+		return entry.values, entry.err
+	}
+
+	values, err := fn()
+	if err != nil && (cacheableErr == nil || err != cacheableErr) {
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if generation != p.generation {
+		return values, err
+	}
+
+	if now.Sub(p.lastPurge) >= p.ttl {
+		for k, e := range p.entries {
+			if !now.Before(e.expires) {
+				delete(p.entries, k)
+			}
+		}
+
+		p.lastPurge = now
+	}
+
+	p.entries[key] = cacheEntry{values: values, err: err, expires: now.Add(p.ttl)}
+
+	return values, err
+}
+
+func (p *CachedProvider) invalidate(key cacheKey) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.entries, key)
+	p.generation++
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func TestCachedProviderShouldCacheReadsUntilExpiry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMockProvider(ctrl)
+	clock := &testClock{now: time.Unix(1577880000, 0)}
+	provider := NewCachedProvider(mock, time.Minute, clock)
+
+	mock.EXPECT().LoadPreferred2FAMethod(unitTestUser).Return("totp", nil).Times(2)
+
+	for i := 0; i < 3; i++ {
+		method, err := provider.LoadPreferred2FAMethod(unitTestUser)
+		require.NoError(t, err)
+		assert.Equal(t, "totp", method)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+
+	method, err := provider.LoadPreferred2FAMethod(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, "totp", method)
+}
+
+func TestCachedProviderShouldInvalidateOnWrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMockProvider(ctrl)
+	provider := NewCachedProvider(mock, time.Minute, &testClock{now: time.Unix(1577880000, 0)})
+
+	configuration := models.TOTPConfiguration{Username: unitTestUser, Secret: "secret"}
+
+	gomock.InOrder(
+		mock.EXPECT().LoadTOTPConfiguration(unitTestUser).Return(nil, ErrNoTOTPSecret),
+		mock.EXPECT().SaveTOTPConfiguration(configuration).Return(nil),
+		mock.EXPECT().LoadTOTPConfiguration(unitTestUser).Return(&configuration, nil),
+	)
+
+	for i := 0; i < 2; i++ {
+		_, err := provider.LoadTOTPConfiguration(unitTestUser)
+		assert.Equal(t, ErrNoTOTPSecret, err)
+	}
+
+	require.NoError(t, provider.SaveTOTPConfiguration(configuration))
+
+	for i := 0; i < 2; i++ {
+		loaded, err := provider.LoadTOTPConfiguration(unitTestUser)
+		require.NoError(t, err)
+		assert.Equal(t, &configuration, loaded)
+	}
+
+	gomock.InOrder(
+		mock.EXPECT().LoadU2FDeviceHandle(unitTestUser).Return([]byte("handle"), []byte("key"), nil),
+		mock.EXPECT().SaveU2FDeviceHandle(unitTestUser, []byte("new"), []byte("key")).Return(nil),
+		mock.EXPECT().LoadU2FDeviceHandle(unitTestUser).Return([]byte("new"), []byte("key"), nil),
+	)
+
+	keyHandle, _, err := provider.LoadU2FDeviceHandle(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("handle"), keyHandle)
+
+	require.NoError(t, provider.SaveU2FDeviceHandle(unitTestUser, []byte("new"), []byte("key")))
+
+	keyHandle, publicKey, err := provider.LoadU2FDeviceHandle(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new"), keyHandle)
+	assert.Equal(t, []byte("key"), publicKey)
+}
+
+func TestCachedProviderShouldNotCacheErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMockProvider(ctrl)
+	provider := NewCachedProvider(mock, time.Minute, &testClock{now: time.Unix(1577880000, 0)})
+
+	gomock.InOrder(
+		mock.EXPECT().LoadTOTPConfiguration(unitTestUser).Return(nil, errors.New("connection refused")),
+		mock.EXPECT().LoadTOTPConfiguration(unitTestUser).Return(&models.TOTPConfiguration{Username: unitTestUser, Secret: "secret"}, nil),
+	)
+
+	_, err := provider.LoadTOTPConfiguration(unitTestUser)
+	assert.EqualError(t, err, "connection refused")
+
+	configuration, err := provider.LoadTOTPConfiguration(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", configuration.Secret)
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/jackc/pgx/v4/stdlib" // Load the PostgreSQL Driver used in the connection string.
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// CockroachDBProvider is a CockroachDB provider.
+type CockroachDBProvider struct {
+	SQLProvider
+}
+
+// NewCockroachDBProvider a CockroachDB provider.
+func NewCockroachDBProvider(configuration schema.StorageConfiguration) *CockroachDBProvider {
+	tablePrefix := configuration.TablePrefix
+
+	provider := CockroachDBProvider{
+		SQLProvider{
+			name:        "cockroachdb",
+			tablePrefix: tablePrefix,
+
+			sqlUpgradesCreateTableStatements:        sqlCockroachDBUpgradeCreateTableStatements,
+			sqlUpgradesAlterTableStatements:         sqlUpgradesAlterTableStatements,
+			sqlUpgradesCreateTableIndexesStatements: sqlUpgradesCreateTableIndexesStatements,
+
+			sqlGetPreferencesByUsername:     fmt.Sprintf("SELECT second_factor_method FROM %s WHERE username=$1", tablePrefix+userPreferencesTableName),
+			sqlUpsertSecondFactorPreference: fmt.Sprintf("UPSERT INTO %s (username, second_factor_method) VALUES ($1, $2)", tablePrefix+userPreferencesTableName),
+
+			sqlTestIdentityVerificationTokenExistence: fmt.Sprintf("SELECT EXISTS (SELECT * FROM %s WHERE token=$1)", tablePrefix+identityVerificationTokensTableName),
+			sqlInsertIdentityVerificationToken:        fmt.Sprintf("INSERT INTO %s (token) VALUES ($1)", tablePrefix+identityVerificationTokensTableName),
+			sqlDeleteIdentityVerificationToken:        fmt.Sprintf("DELETE FROM %s WHERE token=$1", tablePrefix+identityVerificationTokensTableName),
+
+			sqlGetTOTPConfigurationByUsername: fmt.Sprintf("SELECT username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country FROM %s WHERE username=$1", tablePrefix+totpSecretsTableName),
+			sqlUpsertTOTPConfiguration:        fmt.Sprintf("UPSERT INTO %s (username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)", tablePrefix+totpSecretsTableName),
+			sqlUpdateTOTPConfigurationSignIn:  fmt.Sprintf("UPDATE %s SET last_used=$1, last_used_ip=$2, last_used_country=$3 WHERE username=$4", tablePrefix+totpSecretsTableName),
+			sqlDeleteTOTPSecret:               fmt.Sprintf("DELETE FROM %s WHERE username=$1", tablePrefix+totpSecretsTableName),
+
+			sqlInsertTOTPHistory:        fmt.Sprintf("INSERT INTO %s (username, event, remote_ip, time) VALUES ($1, $2, $3, $4)", tablePrefix+totpHistoryTableName),
+			sqlGetTOTPHistoryByUsername: fmt.Sprintf("SELECT username, event, remote_ip, time FROM %s WHERE username=$1 ORDER BY time DESC", tablePrefix+totpHistoryTableName),
+
+			sqlGetU2FDeviceHandleByUsername: fmt.Sprintf("SELECT keyHandle, publicKey FROM %s WHERE username=$1", tablePrefix+u2fDeviceHandlesTableName),
+			sqlUpsertU2FDeviceHandle:        fmt.Sprintf("UPSERT INTO %s (username, keyHandle, publicKey) VALUES ($1, $2, $3)", tablePrefix+u2fDeviceHandlesTableName),
+			sqlDeleteU2FDeviceHandle:        fmt.Sprintf("DELETE FROM %s WHERE username=$1", tablePrefix+u2fDeviceHandlesTableName),
+
+			sqlInsertAuthenticationLog:                fmt.Sprintf("INSERT INTO %s (username, successful, time, remote_ip, auth_type) VALUES ($1, $2, $3, $4, $5)", tablePrefix+authenticationLogsTableName),
+			sqlGetLatestAuthenticationLogs:            fmt.Sprintf("SELECT successful, time FROM %s WHERE time>$1 AND username=$2 ORDER BY time DESC", tablePrefix+authenticationLogsTableName),
+			sqlGetAuthenticationLogs:                  fmt.Sprintf("SELECT username, successful, time, remote_ip, auth_type FROM %s WHERE (username=$1 OR $2='') AND (remote_ip=$3 OR $4='') AND (auth_type=$5 OR $6='') AND (successful=$7 OR $8) AND time>=$9 AND time<=$10 ORDER BY time DESC LIMIT $11", tablePrefix+authenticationLogsTableName),
+			sqlDeleteAuthenticationLogsOlderThan:      fmt.Sprintf("DELETE FROM %s WHERE time<$1", tablePrefix+authenticationLogsTableName),
+			sqlDeleteAuthenticationLogsExceedingCount: fmt.Sprintf("DELETE FROM %[1]s WHERE time<(SELECT time FROM %[1]s ORDER BY time DESC LIMIT 1 OFFSET $1)", tablePrefix+authenticationLogsTableName),
+
+			sqlInsertAuditEvent:          fmt.Sprintf("INSERT INTO %s (username, event, remote_ip, details, time, chain_index, chain_hash) VALUES ($1, $2, $3, $4, $5, $6, $7)", tablePrefix+auditEventsTableName),
+			sqlSelectAuditEvents:         fmt.Sprintf("SELECT username, event, remote_ip, details, time, chain_index, chain_hash FROM %s WHERE (username=$1 OR $2='') AND (event=$3 OR $4='') AND time>=$5 AND time<=$6 ORDER BY time DESC, chain_index DESC LIMIT $7", tablePrefix+auditEventsTableName),
+			sqlSelectAuditChainHead:      fmt.Sprintf("SELECT chain_index, chain_hash FROM %s WHERE chain_index IS NOT NULL ORDER BY chain_index DESC LIMIT 1", tablePrefix+auditEventsTableName),
+			sqlSelectAuditChain:          fmt.Sprintf("SELECT username, event, remote_ip, details, time, chain_index, chain_hash FROM %s WHERE chain_index IS NOT NULL ORDER BY chain_index", tablePrefix+auditEventsTableName),
+			sqlCountUnchainedAuditEvents: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE chain_index IS NULL AND time>=$1", tablePrefix+auditEventsTableName),
+
+			sqlInsertBan:        fmt.Sprintf("INSERT INTO %s (username, reason, time, expires, revoked) VALUES ($1, $2, $3, $4, $5)", tablePrefix+bannedUsersTableName),
+			sqlSelectActiveBans: fmt.Sprintf("SELECT username, reason, time, expires, revoked FROM %s WHERE (username=$1 OR $2='') AND revoked=FALSE AND (expires IS NULL OR expires>$3) ORDER BY time DESC", tablePrefix+bannedUsersTableName),
+			sqlRevokeBans:       fmt.Sprintf("UPDATE %s SET revoked=TRUE WHERE username=$1 AND revoked=FALSE AND (expires IS NULL OR expires>$2)", tablePrefix+bannedUsersTableName),
+
+			sqlInsertAPIKey:        fmt.Sprintf("INSERT INTO %s (name, key_hash, key_groups, time, revoked) VALUES ($1, $2, $3, $4, $5)", tablePrefix+apiKeysTableName),
+			sqlSelectActiveAPIKey:  fmt.Sprintf("SELECT name, key_hash, key_groups, time, revoked FROM %s WHERE key_hash=$1 AND revoked=FALSE", tablePrefix+apiKeysTableName),
+			sqlSelectActiveAPIKeys: fmt.Sprintf("SELECT name, key_hash, key_groups, time, revoked FROM %s WHERE revoked=FALSE ORDER BY time", tablePrefix+apiKeysTableName),
+			sqlRevokeAPIKeys:       fmt.Sprintf("UPDATE %s SET revoked=TRUE WHERE name=$1 AND revoked=FALSE", tablePrefix+apiKeysTableName),
+
+			sqlInsertWebAuthnCredential:               fmt.Sprintf("INSERT INTO %s (username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)", tablePrefix+webAuthnCredentialsTableName),
+			sqlSelectWebAuthnCredentialByID:           fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s WHERE credential_id=$1 AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlSelectWebAuthnCredentialsByUser:        fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s WHERE username=$1 AND deleted_at IS NULL ORDER BY time", tablePrefix+webAuthnCredentialsTableName),
+			sqlSelectDeletedWebAuthnCredentialsByUser: fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s WHERE username=$1 AND deleted_at IS NOT NULL ORDER BY deleted_at", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialUsage:          fmt.Sprintf("UPDATE %s SET sign_count=$1, last_used=$2, last_used_ip=$3, last_used_country=$4, backup_eligible=$5, backup_state=$6 WHERE credential_id=$7 AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialDescription:    fmt.Sprintf("UPDATE %s SET description=$1 WHERE username=$2 AND credential_id=$3 AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialCloneWarning:   fmt.Sprintf("UPDATE %s SET clone_warning=$1 WHERE credential_id=$2 AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialDeleted:        fmt.Sprintf("UPDATE %s SET deleted_at=$1 WHERE username=$2 AND credential_id=$3 AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialsDeletedByUser: fmt.Sprintf("UPDATE %s SET deleted_at=$1 WHERE username=$2 AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialRestored:       fmt.Sprintf("UPDATE %s SET deleted_at=NULL WHERE username=$1 AND credential_id=$2 AND deleted_at IS NOT NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlDeleteWebAuthnCredentialsDeletedBefore: fmt.Sprintf("DELETE FROM %s WHERE deleted_at IS NOT NULL AND deleted_at<$1", tablePrefix+webAuthnCredentialsTableName),
+			sqlDeleteDeletedWebAuthnCredentialByID:    fmt.Sprintf("DELETE FROM %s WHERE credential_id=$1 AND deleted_at IS NOT NULL", tablePrefix+webAuthnCredentialsTableName),
+
+			sqlInsertRecoveryCode:  fmt.Sprintf("INSERT INTO %s (username, code_hash, time, used) VALUES ($1, $2, $3, $4)", tablePrefix+recoveryCodesTableName),
+			sqlCountRecoveryCodes:  fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE username=$1 AND used=FALSE", tablePrefix+recoveryCodesTableName),
+			sqlConsumeRecoveryCode: fmt.Sprintf("UPDATE %s SET used=TRUE WHERE username=$1 AND code_hash=$2 AND used=FALSE", tablePrefix+recoveryCodesTableName),
+			sqlDeleteRecoveryCodes: fmt.Sprintf("DELETE FROM %s WHERE username=$1", tablePrefix+recoveryCodesTableName),
+
+			sqlInsertTrustedDevice:        fmt.Sprintf("INSERT INTO %s (username, device_id, token_hash, description, remote_ip, time, expires) VALUES ($1, $2, $3, $4, $5, $6, $7)", tablePrefix+trustedDevicesTableName),
+			sqlSelectTrustedDeviceByHash:  fmt.Sprintf("SELECT username, device_id, token_hash, description, remote_ip, time, expires FROM %s WHERE token_hash=$1 AND expires>$2", tablePrefix+trustedDevicesTableName),
+			sqlSelectTrustedDevicesByUser: fmt.Sprintf("SELECT username, device_id, token_hash, description, remote_ip, time, expires FROM %s WHERE username=$1 AND expires>$2 ORDER BY time", tablePrefix+trustedDevicesTableName),
+			sqlDeleteTrustedDevice:        fmt.Sprintf("DELETE FROM %s WHERE username=$1 AND device_id=$2", tablePrefix+trustedDevicesTableName),
+
+			sqlUpsertHOTPDevice:       fmt.Sprintf("UPSERT INTO %s (username, secret, counter, digits) VALUES ($1, $2, $3, $4)", tablePrefix+hotpDevicesTableName),
+			sqlSelectHOTPDeviceByUser: fmt.Sprintf("SELECT username, secret, counter, digits FROM %s WHERE username=$1", tablePrefix+hotpDevicesTableName),
+			sqlUpdateHOTPCounter:      fmt.Sprintf("UPDATE %s SET counter=$1 WHERE username=$2 AND counter=$3", tablePrefix+hotpDevicesTableName),
+			sqlDeleteHOTPDevice:       fmt.Sprintf("DELETE FROM %s WHERE username=$1", tablePrefix+hotpDevicesTableName),
+
+			sqlInsertYubiKeyOTPDevice:        fmt.Sprintf("INSERT INTO %s (username, public_id, time) VALUES ($1, $2, $3)", tablePrefix+yubiKeyOTPDevicesTableName),
+			sqlSelectYubiKeyOTPDevicesByUser: fmt.Sprintf("SELECT username, public_id, time FROM %s WHERE username=$1 ORDER BY time", tablePrefix+yubiKeyOTPDevicesTableName),
+			sqlDeleteYubiKeyOTPDevice:        fmt.Sprintf("DELETE FROM %s WHERE username=$1 AND public_id=$2", tablePrefix+yubiKeyOTPDevicesTableName),
+			sqlDeleteYubiKeyOTPDevicesByUser: fmt.Sprintf("DELETE FROM %s WHERE username=$1", tablePrefix+yubiKeyOTPDevicesTableName),
+
+			sqlSelectSecondFactorEnrollmentStart: fmt.Sprintf("SELECT time FROM %s WHERE username=$1", tablePrefix+secondFactorEnrollmentsTableName),
+			sqlInsertSecondFactorEnrollmentStart: fmt.Sprintf("INSERT INTO %s (username, time) VALUES ($1, $2) ON CONFLICT (username) DO NOTHING", tablePrefix+secondFactorEnrollmentsTableName),
+
+			sqlSelectDomainPreferenceByUser:  fmt.Sprintf("SELECT second_factor_method FROM %s WHERE username=$1 AND domain=$2", tablePrefix+userDomainPreferencesTableName),
+			sqlSelectDomainPreferencesByUser: fmt.Sprintf("SELECT username, domain, second_factor_method FROM %s WHERE username=$1 ORDER BY domain", tablePrefix+userDomainPreferencesTableName),
+			sqlUpsertDomainPreference:        fmt.Sprintf("UPSERT INTO %s (username, domain, second_factor_method) VALUES ($1, $2, $3)", tablePrefix+userDomainPreferencesTableName),
+
+			sqlSelectUserPreferences:            fmt.Sprintf("SELECT username, second_factor_method FROM %s", tablePrefix+userPreferencesTableName),
+			sqlSelectIdentityVerificationTokens: fmt.Sprintf("SELECT token FROM %s", tablePrefix+identityVerificationTokensTableName),
+			sqlSelectTOTPSecrets:                fmt.Sprintf("SELECT username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country FROM %s", tablePrefix+totpSecretsTableName),
+			sqlSelectU2FDeviceHandles:           fmt.Sprintf("SELECT username, keyHandle, publicKey FROM %s", tablePrefix+u2fDeviceHandlesTableName),
+			sqlSelectAuthenticationLogs:         fmt.Sprintf("SELECT username, successful, time, remote_ip, auth_type FROM %s ORDER BY time", tablePrefix+authenticationLogsTableName),
+			sqlSelectAllAuditEvents:             fmt.Sprintf("SELECT username, event, remote_ip, details, time, chain_index, chain_hash FROM %s ORDER BY time, chain_index", tablePrefix+auditEventsTableName),
+			sqlSelectTOTPHistory:                fmt.Sprintf("SELECT username, event, remote_ip, time FROM %s ORDER BY time", tablePrefix+totpHistoryTableName),
+			sqlSelectBans:                       fmt.Sprintf("SELECT username, reason, time, expires, revoked FROM %s ORDER BY time", tablePrefix+bannedUsersTableName),
+			sqlSelectAPIKeys:                    fmt.Sprintf("SELECT name, key_hash, key_groups, time, revoked FROM %s ORDER BY time", tablePrefix+apiKeysTableName),
+			sqlSelectWebAuthnCredentials:        fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s ORDER BY time", tablePrefix+webAuthnCredentialsTableName),
+			sqlSelectRecoveryCodes:              fmt.Sprintf("SELECT username, code_hash, time, used FROM %s ORDER BY time", tablePrefix+recoveryCodesTableName),
+			sqlSelectTrustedDevices:             fmt.Sprintf("SELECT username, device_id, token_hash, description, remote_ip, time, expires FROM %s ORDER BY time", tablePrefix+trustedDevicesTableName),
+			sqlSelectHOTPDevices:                fmt.Sprintf("SELECT username, secret, counter, digits FROM %s ORDER BY username", tablePrefix+hotpDevicesTableName),
+			sqlSelectYubiKeyOTPDevices:          fmt.Sprintf("SELECT username, public_id, time FROM %s ORDER BY time", tablePrefix+yubiKeyOTPDevicesTableName),
+			sqlSelectSecondFactorEnrollments:    fmt.Sprintf("SELECT username, time FROM %s ORDER BY username", tablePrefix+secondFactorEnrollmentsTableName),
+			sqlSelectDomainPreferences:          fmt.Sprintf("SELECT username, domain, second_factor_method FROM %s ORDER BY username, domain", tablePrefix+userDomainPreferencesTableName),
+
+			sqlGetExistingTables: "SELECT table_name FROM information_schema.tables WHERE table_type='BASE TABLE' AND table_schema='public'",
+
+			sqlConfigSetValue: fmt.Sprintf("UPSERT INTO %s (category, key_name, value) VALUES ($1, $2, $3)", tablePrefix+configTableName),
+			sqlConfigGetValue: fmt.Sprintf("SELECT value FROM %s WHERE category=$1 AND key_name=$2", tablePrefix+configTableName),
+
+			isRetryableError: isPostgreSQLRetryableError,
+			retryConfig:      configuration.Retry,
+
+			authenticationLogsConfig:  configuration.AuthenticationLogs,
+			webAuthnCredentialsConfig: configuration.WebAuthnCredentials,
+			maintenanceConfig:         configuration.Maintenance,
+
+			upgradeDisabled: configuration.DisableSchemaUpgrade,
+		},
+	}
+
+	cockroachdbConfiguration := configuration.CockroachDB
+
+	connectionString := postgreSQLConnectionString(cockroachdbConfiguration.SQLStorageConfiguration, cockroachdbConfiguration.Host, cockroachdbConfiguration.Port, cockroachdbConfiguration.SSLMode)
+
+	db, err := sql.Open("pgx", connectionString)
+	if err != nil {
+		provider.log.Fatalf("Unable to connect to SQL database: %v", err)
+	}
+
+	configureConnectionPool(db, cockroachdbConfiguration.SQLStorageConfiguration)
+
+	provider.openReadReplicas("pgx", cockroachdbConfiguration.SQLStorageConfiguration, func(host string, port int) string {
+		return postgreSQLConnectionString(cockroachdbConfiguration.SQLStorageConfiguration, host, port, cockroachdbConfiguration.SSLMode)
+	})
+
+	if err := provider.initialize(db); err != nil {
+		provider.log.Fatalf("Unable to initialize SQL database: %v", err)
+	}
+
+	return &provider
+}
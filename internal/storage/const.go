@@ -1,12 +1,43 @@
 package storage
 
-import (
-	"fmt"
-)
-
-const storageSchemaCurrentVersion = SchemaVersion(1)
+const storageSchemaCurrentVersion = SchemaVersion(21)
 const storageSchemaUpgradeMessage = "Storage schema upgraded to v"
 const storageSchemaUpgradeErrorText = "storage schema upgrade failed at v"
+const storageSchemaDowngradeMessage = "Storage schema downgraded to v"
+const storageSchemaDowngradeErrorText = "storage schema downgrade failed at v"
+
+// storageSchemaUpgradeMaxAttempts is the number of times an upgrade transaction is attempted when the provider reports
+// the failure as retryable.
+const storageSchemaUpgradeMaxAttempts = 5
+
+// sqliteInMemoryPath is the path of the local storage which makes the SQLite provider keep the database in memory.
+const sqliteInMemoryPath = ":memory:"
+
+// backupVersion is the version of the format of the backups, which is increased whenever it changes in a way older
+// versions of Authelia can't restore.
+const backupVersion = 1
+
+// Parameters of the Argon2id hash deriving the key of a backup from its passphrase.
+const (
+	backupSaltLength        = 16
+	backupArgon2Iterations  = 3
+	backupArgon2Memory      = 64 * 1024
+	backupArgon2Parallelism = 4
+)
+
+// PostgreSQL (and CockroachDB) SQLSTATE codes of the errors which are transient. CockroachDB returns a serialization
+// failure when a transaction must be retried by the client.
+const (
+	postgreSQLErrorCodeSerializationFailure = "40001"
+	postgreSQLErrorCodeDeadlockDetected     = "40P01"
+	postgreSQLErrorClassConnectionException = "08"
+)
+
+// MySQL error numbers of the errors which are transient.
+const (
+	mySQLErrorNumberLockWaitTimeout = 1205
+	mySQLErrorNumberDeadlock        = 1213
+)
 
 // Keep table names in lower case because some DB does not support upper case.
 const userPreferencesTableName = "user_preferences"
@@ -14,6 +45,17 @@ const identityVerificationTokensTableName = "identity_verification_tokens"
 const totpSecretsTableName = "totp_secrets"
 const u2fDeviceHandlesTableName = "u2f_devices"
 const authenticationLogsTableName = "authentication_logs"
+const auditEventsTableName = "audit_events"
+const totpHistoryTableName = "totp_history"
+const bannedUsersTableName = "banned_users"
+const apiKeysTableName = "api_keys"
+const webAuthnCredentialsTableName = "webauthn_credentials"
+const recoveryCodesTableName = "recovery_codes"
+const trustedDevicesTableName = "trusted_devices"
+const hotpDevicesTableName = "hotp_devices"
+const yubiKeyOTPDevicesTableName = "yubikey_otp_devices"
+const secondFactorEnrollmentsTableName = "second_factor_enrollments"
+const userDomainPreferencesTableName = "user_domain_preferences"
 const configTableName = "config"
 
 // sqlUpgradeCreateTableStatements is a map of the schema version number, plus a map of the table name and the statement used to create it.
@@ -27,13 +69,214 @@ var sqlUpgradeCreateTableStatements = map[SchemaVersion]map[string]string{
 		authenticationLogsTableName:         "CREATE TABLE %s (username VARCHAR(100), successful BOOL, time INTEGER)",
 		configTableName:                     "CREATE TABLE %s (category VARCHAR(32) NOT NULL, key_name VARCHAR(32) NOT NULL, value TEXT, PRIMARY KEY (category, key_name))",
 	},
+	SchemaVersion(2): {
+		auditEventsTableName: "CREATE TABLE %s (username VARCHAR(100), event VARCHAR(64), remote_ip VARCHAR(47), details TEXT, time INTEGER)",
+	},
+	SchemaVersion(4): {
+		totpHistoryTableName: "CREATE TABLE %s (username VARCHAR(100), event VARCHAR(16), remote_ip VARCHAR(47), time INTEGER)",
+	},
+	SchemaVersion(5): {
+		bannedUsersTableName: "CREATE TABLE %s (username VARCHAR(100), reason TEXT, time INTEGER, expires INTEGER, revoked BOOL NOT NULL)",
+	},
+	SchemaVersion(6): {
+		apiKeysTableName: "CREATE TABLE %s (name VARCHAR(100), key_hash VARCHAR(64), key_groups TEXT, time INTEGER, revoked BOOL NOT NULL)",
+	},
+	SchemaVersion(7): {
+		webAuthnCredentialsTableName: "CREATE TABLE %s (username VARCHAR(100), credential_id VARCHAR(512), user_handle VARCHAR(128), public_key TEXT, sign_count INTEGER, time INTEGER)",
+	},
+	SchemaVersion(13): {
+		recoveryCodesTableName: "CREATE TABLE %s (username VARCHAR(100), code_hash VARCHAR(64), time INTEGER, used BOOL NOT NULL)",
+	},
+	SchemaVersion(14): {
+		trustedDevicesTableName: "CREATE TABLE %s (username VARCHAR(100), device_id VARCHAR(32), token_hash VARCHAR(64), description VARCHAR(255), remote_ip VARCHAR(47), time INTEGER, expires INTEGER)",
+	},
+	SchemaVersion(15): {
+		hotpDevicesTableName:       "CREATE TABLE %s (username VARCHAR(100) PRIMARY KEY, secret VARCHAR(64), counter BIGINT, digits INTEGER)",
+		yubiKeyOTPDevicesTableName: "CREATE TABLE %s (username VARCHAR(100), public_id VARCHAR(16), time INTEGER)",
+	},
+	SchemaVersion(16): {
+		secondFactorEnrollmentsTableName: "CREATE TABLE %s (username VARCHAR(100) PRIMARY KEY, time INTEGER)",
+	},
+	SchemaVersion(17): {
+		userDomainPreferencesTableName: "CREATE TABLE %s (username VARCHAR(100), domain VARCHAR(255), second_factor_method VARCHAR(11), PRIMARY KEY (username, domain))",
+	},
+}
+
+// sqlCockroachDBUpgradeCreateTableStatements is the same as sqlUpgradeCreateTableStatements except every table has a
+// primary key, as CockroachDB otherwise adds a hidden rowid column which serializes inserts on a single range.
+var sqlCockroachDBUpgradeCreateTableStatements = map[SchemaVersion]map[string]string{
+	SchemaVersion(1): {
+		userPreferencesTableName:            "CREATE TABLE %s (username VARCHAR(100) PRIMARY KEY, second_factor_method VARCHAR(11))",
+		identityVerificationTokensTableName: "CREATE TABLE %s (token VARCHAR(512) PRIMARY KEY)",
+		totpSecretsTableName:                "CREATE TABLE %s (username VARCHAR(100) PRIMARY KEY, secret VARCHAR(64))",
+		u2fDeviceHandlesTableName:           "CREATE TABLE %s (username VARCHAR(100) PRIMARY KEY, keyHandle TEXT, publicKey TEXT)",
+		authenticationLogsTableName:         "CREATE TABLE %s (id UUID PRIMARY KEY DEFAULT gen_random_uuid(), username VARCHAR(100), successful BOOL, time INTEGER)",
+		configTableName:                     "CREATE TABLE %s (category VARCHAR(32) NOT NULL, key_name VARCHAR(32) NOT NULL, value TEXT, PRIMARY KEY (category, key_name))",
+	},
+	SchemaVersion(2): {
+		auditEventsTableName: "CREATE TABLE %s (id UUID PRIMARY KEY DEFAULT gen_random_uuid(), username VARCHAR(100), event VARCHAR(64), remote_ip VARCHAR(47), details TEXT, time INTEGER)",
+	},
+	SchemaVersion(4): {
+		totpHistoryTableName: "CREATE TABLE %s (id UUID PRIMARY KEY DEFAULT gen_random_uuid(), username VARCHAR(100), event VARCHAR(16), remote_ip VARCHAR(47), time INTEGER)",
+	},
+	SchemaVersion(5): {
+		bannedUsersTableName: "CREATE TABLE %s (id UUID PRIMARY KEY DEFAULT gen_random_uuid(), username VARCHAR(100), reason TEXT, time INTEGER, expires INTEGER, revoked BOOL NOT NULL)",
+	},
+	SchemaVersion(6): {
+		apiKeysTableName: "CREATE TABLE %s (id UUID PRIMARY KEY DEFAULT gen_random_uuid(), name VARCHAR(100), key_hash VARCHAR(64), key_groups TEXT, time INTEGER, revoked BOOL NOT NULL)",
+	},
+	SchemaVersion(7): {
+		webAuthnCredentialsTableName: "CREATE TABLE %s (credential_id VARCHAR(512) PRIMARY KEY, username VARCHAR(100), user_handle VARCHAR(128), public_key TEXT, sign_count INTEGER, time INTEGER)",
+	},
+	SchemaVersion(13): {
+		recoveryCodesTableName: "CREATE TABLE %s (id UUID PRIMARY KEY DEFAULT gen_random_uuid(), username VARCHAR(100), code_hash VARCHAR(64), time INTEGER, used BOOL NOT NULL)",
+	},
+	SchemaVersion(14): {
+		trustedDevicesTableName: "CREATE TABLE %s (device_id VARCHAR(32) PRIMARY KEY, username VARCHAR(100), token_hash VARCHAR(64), description VARCHAR(255), remote_ip VARCHAR(47), time INTEGER, expires INTEGER)",
+	},
+	SchemaVersion(15): {
+		hotpDevicesTableName:       "CREATE TABLE %s (username VARCHAR(100) PRIMARY KEY, secret VARCHAR(64), counter BIGINT, digits INTEGER)",
+		yubiKeyOTPDevicesTableName: "CREATE TABLE %s (id UUID PRIMARY KEY DEFAULT gen_random_uuid(), username VARCHAR(100), public_id VARCHAR(16), time INTEGER)",
+	},
+	SchemaVersion(16): {
+		secondFactorEnrollmentsTableName: "CREATE TABLE %s (username VARCHAR(100) PRIMARY KEY, time INTEGER)",
+	},
+	SchemaVersion(17): {
+		userDomainPreferencesTableName: "CREATE TABLE %s (username VARCHAR(100), domain VARCHAR(255), second_factor_method VARCHAR(11), PRIMARY KEY (username, domain))",
+	},
 }
 
 // sqlUpgradesCreateTableIndexesStatements is a map of t he schema version number, plus a slice of statements to create all of the indexes.
+// The statements are fmt.Sprintf'd with the table prefix as the first argument.
 var sqlUpgradesCreateTableIndexesStatements = map[SchemaVersion][]string{
 	SchemaVersion(1): {
-		fmt.Sprintf("CREATE INDEX IF NOT EXISTS usr_time_idx ON %s (username, time)", authenticationLogsTableName),
+		"CREATE INDEX IF NOT EXISTS %[1]susr_time_idx ON %[1]s" + authenticationLogsTableName + " (username, time)",
+	},
+	SchemaVersion(2): {
+		"CREATE INDEX IF NOT EXISTS %[1]saudit_usr_time_idx ON %[1]s" + auditEventsTableName + " (username, time)",
+		"CREATE INDEX IF NOT EXISTS %[1]saudit_time_idx ON %[1]s" + auditEventsTableName + " (time)",
+	},
+	SchemaVersion(3): {
+		"CREATE INDEX IF NOT EXISTS %[1]sremote_ip_time_idx ON %[1]s" + authenticationLogsTableName + " (remote_ip, time)",
+	},
+	SchemaVersion(4): {
+		"CREATE INDEX IF NOT EXISTS %[1]stotp_history_usr_time_idx ON %[1]s" + totpHistoryTableName + " (username, time)",
+	},
+	SchemaVersion(5): {
+		"CREATE INDEX IF NOT EXISTS %[1]sbanned_users_usr_time_idx ON %[1]s" + bannedUsersTableName + " (username, time)",
+	},
+	SchemaVersion(6): {
+		"CREATE INDEX IF NOT EXISTS %[1]sapi_keys_hash_idx ON %[1]s" + apiKeysTableName + " (key_hash)",
+	},
+	SchemaVersion(7): {
+		"CREATE INDEX IF NOT EXISTS %[1]swebauthn_credentials_id_idx ON %[1]s" + webAuthnCredentialsTableName + " (credential_id)",
+		"CREATE INDEX IF NOT EXISTS %[1]swebauthn_credentials_usr_idx ON %[1]s" + webAuthnCredentialsTableName + " (username)",
+	},
+	SchemaVersion(13): {
+		"CREATE INDEX IF NOT EXISTS %[1]srecovery_codes_usr_idx ON %[1]s" + recoveryCodesTableName + " (username)",
+	},
+	SchemaVersion(14): {
+		"CREATE INDEX IF NOT EXISTS %[1]strusted_devices_hash_idx ON %[1]s" + trustedDevicesTableName + " (token_hash)",
+		"CREATE INDEX IF NOT EXISTS %[1]strusted_devices_usr_idx ON %[1]s" + trustedDevicesTableName + " (username)",
+	},
+	SchemaVersion(15): {
+		"CREATE INDEX IF NOT EXISTS %[1]syubikey_otp_devices_usr_idx ON %[1]s" + yubiKeyOTPDevicesTableName + " (username)",
+	},
+	SchemaVersion(18): {
+		"CREATE UNIQUE INDEX IF NOT EXISTS %[1]saudit_chain_idx ON %[1]s" + auditEventsTableName + " (chain_index)",
+	},
+	SchemaVersion(21): {
+		"CREATE UNIQUE INDEX IF NOT EXISTS %[1]swebauthn_credentials_id_uniq_idx ON %[1]s" + webAuthnCredentialsTableName + " (credential_id)",
 	},
 }
 
+// sqlUpgradesAlterTableStatements is a map of the schema version number, plus a slice of statements to alter the
+// existing tables. The statements are fmt.Sprintf'd with the table prefix as the first argument.
+var sqlUpgradesAlterTableStatements = map[SchemaVersion][]string{
+	SchemaVersion(3): {
+		"ALTER TABLE %[1]s" + authenticationLogsTableName + " ADD COLUMN remote_ip VARCHAR(47)",
+		"ALTER TABLE %[1]s" + authenticationLogsTableName + " ADD COLUMN auth_type VARCHAR(16)",
+	},
+	SchemaVersion(8): {
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN description VARCHAR(64)",
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN last_used INTEGER",
+	},
+	SchemaVersion(9): {
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN last_used_ip VARCHAR(47)",
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN clone_warning BOOL",
+	},
+	SchemaVersion(10): {
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN backup_eligible BOOL",
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN backup_state BOOL",
+	},
+	SchemaVersion(11): {
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN aaguid CHAR(36)",
+	},
+	SchemaVersion(12): {
+		"ALTER TABLE %[1]s" + totpSecretsTableName + " ADD COLUMN algorithm VARCHAR(6) NOT NULL DEFAULT 'SHA1'",
+		"ALTER TABLE %[1]s" + totpSecretsTableName + " ADD COLUMN digits INTEGER NOT NULL DEFAULT 6",
+		"ALTER TABLE %[1]s" + totpSecretsTableName + " ADD COLUMN period INTEGER NOT NULL DEFAULT 0",
+	},
+	SchemaVersion(18): {
+		"ALTER TABLE %[1]s" + auditEventsTableName + " ADD COLUMN chain_index INTEGER",
+		"ALTER TABLE %[1]s" + auditEventsTableName + " ADD COLUMN chain_hash CHAR(64)",
+	},
+	SchemaVersion(19): {
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN deleted_at INTEGER",
+	},
+	SchemaVersion(20): {
+		"ALTER TABLE %[1]s" + totpSecretsTableName + " ADD COLUMN last_used INTEGER",
+		"ALTER TABLE %[1]s" + totpSecretsTableName + " ADD COLUMN last_used_ip VARCHAR(47)",
+		"ALTER TABLE %[1]s" + totpSecretsTableName + " ADD COLUMN last_used_country CHAR(2)",
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN last_used_country CHAR(2)",
+	},
+	SchemaVersion(21): {
+		sqlDeleteDeletedDuplicateWebAuthnCredentials,
+	},
+}
+
+// sqlMySQLUpgradesAlterTableStatements is the same as sqlUpgradesAlterTableStatements except the indexes are added
+// along with the columns, as MySQL doesn't support CREATE INDEX IF NOT EXISTS.
+var sqlMySQLUpgradesAlterTableStatements = map[SchemaVersion][]string{
+	SchemaVersion(3): {
+		"ALTER TABLE %[1]s" + authenticationLogsTableName + " ADD COLUMN remote_ip VARCHAR(47), ADD COLUMN auth_type VARCHAR(16), ADD INDEX remote_ip_time_idx (remote_ip, time)",
+	},
+	SchemaVersion(8): {
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN description VARCHAR(64), ADD COLUMN last_used INTEGER",
+	},
+	SchemaVersion(9): {
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN last_used_ip VARCHAR(47), ADD COLUMN clone_warning BOOL",
+	},
+	SchemaVersion(10): {
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN backup_eligible BOOL, ADD COLUMN backup_state BOOL",
+	},
+	SchemaVersion(11): {
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN aaguid CHAR(36)",
+	},
+	SchemaVersion(12): {
+		"ALTER TABLE %[1]s" + totpSecretsTableName + " ADD COLUMN algorithm VARCHAR(6) NOT NULL DEFAULT 'SHA1', ADD COLUMN digits INTEGER NOT NULL DEFAULT 6, ADD COLUMN period INTEGER NOT NULL DEFAULT 0",
+	},
+	SchemaVersion(18): {
+		"ALTER TABLE %[1]s" + auditEventsTableName + " ADD COLUMN chain_index INTEGER, ADD COLUMN chain_hash CHAR(64), ADD UNIQUE INDEX audit_chain_idx (chain_index)",
+	},
+	SchemaVersion(19): {
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN deleted_at INTEGER",
+	},
+	SchemaVersion(20): {
+		"ALTER TABLE %[1]s" + totpSecretsTableName + " ADD COLUMN last_used INTEGER, ADD COLUMN last_used_ip VARCHAR(47), ADD COLUMN last_used_country CHAR(2)",
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD COLUMN last_used_country CHAR(2)",
+	},
+	SchemaVersion(21): {
+		sqlDeleteDeletedDuplicateWebAuthnCredentials,
+		"ALTER TABLE %[1]s" + webAuthnCredentialsTableName + " ADD UNIQUE INDEX webauthn_credentials_id_uniq_idx (credential_id)",
+	},
+}
+
+// sqlDeleteDeletedDuplicateWebAuthnCredentials purges the deleted WebAuthn credentials which were registered again
+// since they were deleted, so the credential IDs can be made unique. The duplicates are selected through a derived table
+// as MySQL doesn't allow selecting from the table a statement deletes from.
+const sqlDeleteDeletedDuplicateWebAuthnCredentials = "DELETE FROM %[1]s" + webAuthnCredentialsTableName +
+	" WHERE deleted_at IS NOT NULL AND credential_id IN (SELECT credential_id FROM (SELECT credential_id FROM %[1]s" +
+	webAuthnCredentialsTableName + " GROUP BY credential_id HAVING COUNT(*) > 1) AS duplicates)"
+
 const unitTestUser = "john"
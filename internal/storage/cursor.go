@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"encoding/base64"
+	"strconv"
+)
+
+// encodeTimeCursor encodes the unix time up to which the next page of a listing is loaded.
+func encodeTimeCursor(t int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(t, 10)))
+}
+
+// decodeTimeCursor decodes a cursor returned by encodeTimeCursor.
+func decodeTimeCursor(cursor string) (t int64, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	if t, err = strconv.ParseInt(string(raw), 10, 64); err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	return t, nil
+}
+
+// pageByTime splits the n rows sorted by descending time loaded for a page with one row more than the limit, so that
+// the rows sharing a time are never split across two pages. It returns the number of rows to keep and the cursor of
+// the next page, which is empty when there is no next page. When all the rows kept would share the same time, whole
+// is true and the page must be loaded again with every row of that second.
+func pageByTime(n, limit int, timeAt func(i int) int64) (keep int, next string, whole bool) {
+	if limit <= 0 || n <= limit {
+		return n, "", false
+	}
+
+	last := timeAt(limit - 1)
+
+	if timeAt(limit) != last {
+		return limit, encodeTimeCursor(last), false
+	}
+
+	for keep = limit; keep > 0 && timeAt(keep-1) == last; keep-- {
+	}
+
+	if keep == 0 {
+		return 0, encodeTimeCursor(last), true
+	}
+
+	return keep, encodeTimeCursor(last + 1), false
+}
+
+// pageBounds returns the bounds of the time range of a page, narrowed by the cursor of the page if any. The cursor is
+// the exclusive upper bound of the page.
+func pageBounds(from, to int64, cursor string) (int64, int64, error) {
+	if cursor == "" {
+		return from, to, nil
+	}
+
+	t, err := decodeTimeCursor(cursor)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if t-1 < to {
+		to = t - 1
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+// LoadDomainPreferred2FAMethod loads the preferred method for 2FA of a user to access a protected domain, it is empty
+// when the user has no preference for the domain.
+func (p *SQLProvider) LoadDomainPreferred2FAMethod(username, domain string) (string, error) {
+	var method string
+
+	err := p.queryRow(p.db, p.sqlSelectDomainPreferenceByUser, []interface{}{username, domain}, &method)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+
+	return method, err
+}
+
+// SaveDomainPreferred2FAMethod saves the preferred method for 2FA of a user to access a protected domain.
+func (p *SQLProvider) SaveDomainPreferred2FAMethod(username, domain, method string) error {
+	_, err := p.exec(p.sqlUpsertDomainPreference, username, domain, method)
+
+	return err
+}
+
+// LoadDomainPreferences loads the preferred methods for 2FA of a user for each protected domain.
+func (p *SQLProvider) LoadDomainPreferences(username string) ([]models.DomainPreference, error) {
+	var preferences []models.DomainPreference
+
+	err := p.retry(func() error {
+		rows, err := p.db.Query(p.sqlSelectDomainPreferencesByUser, username)
+		if err != nil {
+			return err
+		}
+
+		defer rows.Close()
+
+		preferences = make([]models.DomainPreference, 0, 1)
+
+		for rows.Next() {
+			preference, err := scanDomainPreference(rows)
+			if err != nil {
+				return err
+			}
+
+			preferences = append(preferences, preference)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+func scanDomainPreference(rows *sql.Rows) (preference models.DomainPreference, err error) {
+	err = rows.Scan(&preference.Username, &preference.Domain, &preference.SecondFactorMethod)
+
+	return preference, err
+}
@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+var (
+	downgradeAlterTableRegexp  = regexp.MustCompile(`^ALTER TABLE %\[1]s(\w+) `)
+	downgradeAddColumnRegexp   = regexp.MustCompile(`ADD COLUMN (\w+)`)
+	downgradeCreateIndexRegexp = regexp.MustCompile(`INDEX IF NOT EXISTS %\[1]s(\w+) ON`)
+	downgradeAddIndexRegexp    = regexp.MustCompile(`ADD (?:UNIQUE )?INDEX (\w+)`)
+)
+
+// PlanSchemaDowngrade returns the tables and the columns dropped by each migration reverted to downgrade the schema to
+// the target version, along with the number of rows holding data in each of them, without changing the schema.
+func (p *SQLProvider) PlanSchemaDowngrade(target SchemaVersion) ([]SchemaDowngrade, error) {
+	version, err := p.downgradeCheckTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	downgrades := make([]SchemaDowngrade, 0, version-target)
+
+	for ; version > target; version-- {
+		downgrade, err := p.downgradeDataLoss(tx, version)
+		if err != nil {
+			return nil, fmt.Errorf("%s%d: %w", storageSchemaDowngradeErrorText, version, err)
+		}
+
+		downgrades = append(downgrades, downgrade)
+	}
+
+	return downgrades, nil
+}
+
+// DowngradeSchema reverts the migrations of the schema down to the target version in a single transaction, dropping
+// the tables and the columns they added along with their data. It returns what was dropped by each migration.
+func (p *SQLProvider) DowngradeSchema(target SchemaVersion) ([]SchemaDowngrade, error) {
+	version, err := p.downgradeCheckTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	downgrades := make([]SchemaDowngrade, 0, version-target)
+
+	for ; version > target; version-- {
+		downgrade, err := p.downgradeSchemaFromVersion(tx, version)
+		if err != nil {
+			return nil, p.handleDowngradeFailure(tx, version, err)
+		}
+
+		downgrades = append(downgrades, downgrade)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	p.log.Infof("Storage schema downgrade to v%d completed", target)
+
+	return downgrades, nil
+}
+
+// downgradeCheckTarget returns the current version of the schema if it can be downgraded to the target version.
+func (p *SQLProvider) downgradeCheckTarget(target SchemaVersion) (SchemaVersion, error) {
+	version, _, err := p.getSchemaBasicDetails()
+	if err != nil {
+		return 0, err
+	}
+
+	if target < 1 || target >= version {
+		return 0, fmt.Errorf("the storage schema can only be downgraded from v%d to a version between v1 and v%d", version, version-1)
+	}
+
+	return version, nil
+}
+
+func (p *SQLProvider) handleDowngradeFailure(tx *sql.Tx, version SchemaVersion, err error) error {
+	rollbackErr := tx.Rollback()
+	formattedErr := fmt.Errorf("%s%d: %w", storageSchemaDowngradeErrorText, version, err)
+
+	if rollbackErr != nil {
+		return fmt.Errorf("rollback error occurred: %v (inner error %v)", rollbackErr, formattedErr)
+	}
+
+	return formattedErr
+}
+
+// downgradeSchemaFromVersion reverts the migration to the provided version. The indexes of the version are dropped
+// first, as some databases refuse to drop an indexed column, then its columns and finally its tables.
+func (p *SQLProvider) downgradeSchemaFromVersion(tx *sql.Tx, version SchemaVersion) (downgrade SchemaDowngrade, err error) {
+	if downgrade, err = p.downgradeDataLoss(tx, version); err != nil {
+		return downgrade, err
+	}
+
+	// MySQL indexes are part of its create and alter table statements and are dropped along with their columns, unless
+	// they were added to existing columns.
+	if p.name == "mysql" {
+		for _, index := range p.downgradeMySQLIndexes(version) {
+			if _, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", index.table, index.name)); err != nil {
+				return downgrade, fmt.Errorf("Unable to drop index %s of table %s: %w", index.name, index.table, err)
+			}
+		}
+	} else {
+		for _, index := range p.downgradeIndexes(version) {
+			if _, err = tx.Exec(fmt.Sprintf("DROP INDEX IF EXISTS %s", index)); err != nil {
+				return downgrade, fmt.Errorf("Unable to drop index %s: %w", index, err)
+			}
+		}
+	}
+
+	for _, column := range downgrade.Columns {
+		if _, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", column.Table, column.Column)); err != nil {
+			return downgrade, fmt.Errorf("Unable to drop column %s of table %s: %w", column.Column, column.Table, err)
+		}
+	}
+
+	for _, table := range downgrade.Tables {
+		if _, err = tx.Exec(fmt.Sprintf("DROP TABLE %s", table.Table)); err != nil {
+			return downgrade, fmt.Errorf("Unable to drop table %s: %w", table.Table, err)
+		}
+	}
+
+	if _, err = tx.Exec(p.sqlConfigSetValue, "schema", "version", (version - 1).ToString()); err != nil {
+		return downgrade, err
+	}
+
+	p.log.Debugf("%s%d", storageSchemaDowngradeMessage, version-1)
+
+	return downgrade, nil
+}
+
+// downgradeDataLoss returns the tables and the columns added by the migration to the provided version, along with the
+// number of rows holding data in each of them.
+func (p *SQLProvider) downgradeDataLoss(tx *sql.Tx, version SchemaVersion) (downgrade SchemaDowngrade, err error) {
+	downgrade.Version = version
+
+	tables := make([]string, 0, len(p.sqlUpgradesCreateTableStatements[version]))
+	for table := range p.sqlUpgradesCreateTableStatements[version] {
+		tables = append(tables, p.tablePrefix+table)
+	}
+
+	sort.Strings(tables)
+
+	for _, table := range tables {
+		loss := SchemaDataLoss{Table: table}
+
+		if err = tx.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", table)).Scan(&loss.Rows); err != nil {
+			return downgrade, fmt.Errorf("Unable to count the rows of table %s: %w", table, err)
+		}
+
+		downgrade.Tables = append(downgrade.Tables, loss)
+	}
+
+	for _, statement := range p.sqlUpgradesAlterTableStatements[version] {
+		match := downgradeAlterTableRegexp.FindStringSubmatch(statement)
+		if match == nil {
+			continue
+		}
+
+		for _, column := range downgradeAddColumnRegexp.FindAllStringSubmatch(statement, -1) {
+			loss := SchemaDataLoss{Table: p.tablePrefix + match[1], Column: column[1]}
+
+			err = tx.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s IS NOT NULL", loss.Table, loss.Column)).Scan(&loss.Rows)
+			if err != nil {
+				return downgrade, fmt.Errorf("Unable to count the rows of column %s of table %s: %w", loss.Column, loss.Table, err)
+			}
+
+			downgrade.Columns = append(downgrade.Columns, loss)
+		}
+	}
+
+	return downgrade, nil
+}
+
+// downgradeIndexes returns the names of the indexes created by the migration to the provided version.
+func (p *SQLProvider) downgradeIndexes(version SchemaVersion) (indexes []string) {
+	for _, statement := range p.sqlUpgradesCreateTableIndexesStatements[version] {
+		if match := downgradeCreateIndexRegexp.FindStringSubmatch(statement); match != nil {
+			indexes = append(indexes, p.tablePrefix+match[1])
+		}
+	}
+
+	return indexes
+}
+
+// downgradeMySQLIndexes returns the tables and the names of the indexes added to existing columns by the MySQL alter
+// table statements of the migration to the provided version.
+func (p *SQLProvider) downgradeMySQLIndexes(version SchemaVersion) (indexes []mysqlIndex) {
+	for _, statement := range p.sqlUpgradesAlterTableStatements[version] {
+		match := downgradeAlterTableRegexp.FindStringSubmatch(statement)
+		if match == nil || downgradeAddColumnRegexp.MatchString(statement) {
+			continue
+		}
+
+		for _, index := range downgradeAddIndexRegexp.FindAllStringSubmatch(statement, -1) {
+			indexes = append(indexes, mysqlIndex{table: p.tablePrefix + match[1], name: index[1]})
+		}
+	}
+
+	return indexes
+}
+
+// mysqlIndex is an index added to an existing MySQL table.
+type mysqlIndex struct {
+	table, name string
+}
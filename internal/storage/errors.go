@@ -8,4 +8,26 @@ var (
 
 	// ErrNoTOTPSecret error thrown when no TOTP secret has been found in DB.
 	ErrNoTOTPSecret = errors.New("No TOTP secret registered")
+
+	// ErrNoAPIKey error thrown when no API key has been found in DB.
+	ErrNoAPIKey = errors.New("No API key found")
+
+	// ErrNoWebAuthnCredential error thrown when no WebAuthn credential has been found in DB.
+	ErrNoWebAuthnCredential = errors.New("No WebAuthn credential found")
+
+	// ErrWebAuthnCredentialRegistered error thrown when a WebAuthn credential is saved, imported or restored while a
+	// credential with the same ID is registered.
+	ErrWebAuthnCredentialRegistered = errors.New("WebAuthn credential already registered")
+
+	// ErrNoTrustedDevice error thrown when no trusted device which has not expired has been found in DB.
+	ErrNoTrustedDevice = errors.New("No trusted device found")
+
+	// ErrNoHOTPDevice error thrown when no HOTP device has been found in DB.
+	ErrNoHOTPDevice = errors.New("No HOTP device registered")
+
+	// ErrAuditChainBroken error thrown when the hash chain of the audit events doesn't match their content.
+	ErrAuditChainBroken = errors.New("Audit events hash chain is broken")
+
+	// ErrInvalidCursor error thrown when the cursor of a page of a listing is malformed.
+	ErrInvalidCursor = errors.New("Invalid cursor")
 )
@@ -0,0 +1,497 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+// Export reads all of the data persisted in the database so it can be imported into another database, including one
+// using a different SQL provider.
+func (p *SQLProvider) Export() (export *Export, err error) {
+	export = &Export{}
+
+	if export.SchemaVersion, _, err = p.getSchemaBasicDetails(); err != nil {
+		return nil, err
+	}
+
+	err = p.exportRows(p.sqlSelectUserPreferences, func(rows *sql.Rows) error {
+		var preferences ExportUserPreferences
+		if err := rows.Scan(&preferences.Username, &preferences.SecondFactorMethod); err != nil {
+			return err
+		}
+
+		export.UserPreferences = append(export.UserPreferences, preferences)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export user preferences: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectIdentityVerificationTokens, func(rows *sql.Rows) error {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			return err
+		}
+
+		export.IdentityVerificationTokens = append(export.IdentityVerificationTokens, token)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export identity verification tokens: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectTOTPSecrets, func(rows *sql.Rows) error {
+		var (
+			secret  models.TOTPConfiguration
+			columns totpConfigurationColumns
+		)
+
+		if err := rows.Scan(columns.dest(&secret)...); err != nil {
+			return err
+		}
+
+		columns.decode(&secret)
+
+		export.TOTPSecrets = append(export.TOTPSecrets, secret)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export TOTP secrets: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectTOTPHistory, func(rows *sql.Rows) error {
+		entry, err := scanTOTPHistoryEntry(rows)
+		if err != nil {
+			return err
+		}
+
+		export.TOTPHistory = append(export.TOTPHistory, entry)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export TOTP history: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectU2FDeviceHandles, func(rows *sql.Rows) error {
+		var (
+			device                           ExportU2FDevice
+			keyHandleBase64, publicKeyBase64 string
+			err                              error
+		)
+
+		if err = rows.Scan(&device.Username, &keyHandleBase64, &publicKeyBase64); err != nil {
+			return err
+		}
+
+		if device.KeyHandle, err = base64.StdEncoding.DecodeString(keyHandleBase64); err != nil {
+			return err
+		}
+
+		if device.PublicKey, err = base64.StdEncoding.DecodeString(publicKeyBase64); err != nil {
+			return err
+		}
+
+		export.U2FDevices = append(export.U2FDevices, device)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export U2F devices: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectAuthenticationLogs, func(rows *sql.Rows) error {
+		attempt, err := scanAuthenticationAttempt(rows)
+		if err != nil {
+			return err
+		}
+
+		export.AuthenticationLogs = append(export.AuthenticationLogs, attempt)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export authentication logs: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectAllAuditEvents, func(rows *sql.Rows) error {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+
+		export.AuditEvents = append(export.AuditEvents, event)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export audit events: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectBans, func(rows *sql.Rows) error {
+		ban, err := scanBan(rows)
+		if err != nil {
+			return err
+		}
+
+		export.Bans = append(export.Bans, ban)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export bans: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectAPIKeys, func(rows *sql.Rows) error {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return err
+		}
+
+		export.APIKeys = append(export.APIKeys, key)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export API keys: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectWebAuthnCredentials, func(rows *sql.Rows) error {
+		credential, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return err
+		}
+
+		export.WebAuthnCredentials = append(export.WebAuthnCredentials, credential)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export WebAuthn credentials: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectRecoveryCodes, func(rows *sql.Rows) error {
+		code, err := scanRecoveryCode(rows)
+		if err != nil {
+			return err
+		}
+
+		export.RecoveryCodes = append(export.RecoveryCodes, code)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export recovery codes: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectTrustedDevices, func(rows *sql.Rows) error {
+		device, err := scanTrustedDevice(rows)
+		if err != nil {
+			return err
+		}
+
+		export.TrustedDevices = append(export.TrustedDevices, device)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export trusted devices: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectHOTPDevices, func(rows *sql.Rows) error {
+		device, err := scanHOTPDevice(rows)
+		if err != nil {
+			return err
+		}
+
+		export.HOTPDevices = append(export.HOTPDevices, device)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export HOTP devices: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectYubiKeyOTPDevices, func(rows *sql.Rows) error {
+		device, err := scanYubiKeyOTPDevice(rows)
+		if err != nil {
+			return err
+		}
+
+		export.YubiKeyOTPDevices = append(export.YubiKeyOTPDevices, device)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export YubiKey OTP devices: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectSecondFactorEnrollments, func(rows *sql.Rows) error {
+		enrollment, err := scanSecondFactorEnrollment(rows)
+		if err != nil {
+			return err
+		}
+
+		export.SecondFactorEnrollments = append(export.SecondFactorEnrollments, enrollment)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export second factor enrollments: %w", err)
+	}
+
+	err = p.exportRows(p.sqlSelectDomainPreferences, func(rows *sql.Rows) error {
+		preference, err := scanDomainPreference(rows)
+		if err != nil {
+			return err
+		}
+
+		export.DomainPreferences = append(export.DomainPreferences, preference)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export user domain preferences: %w", err)
+	}
+
+	return export, nil
+}
+
+// ExportUser reads all of the data persisted in the database about a single user.
+func (p *SQLProvider) ExportUser(username string) (export *UserExport, err error) {
+	export = &UserExport{Username: username}
+
+	if export.SecondFactorMethod, err = p.LoadPreferred2FAMethod(username); err != nil {
+		return nil, fmt.Errorf("unable to export the preferences of user %s: %w", username, err)
+	}
+
+	if export.DomainPreferences, err = p.LoadDomainPreferences(username); err != nil {
+		return nil, fmt.Errorf("unable to export the domain preferences of user %s: %w", username, err)
+	}
+
+	if export.TOTPConfiguration, err = p.LoadTOTPConfiguration(username); err != nil && err != ErrNoTOTPSecret {
+		return nil, fmt.Errorf("unable to export the TOTP secret of user %s: %w", username, err)
+	}
+
+	if export.TOTPHistory, err = p.LoadTOTPHistory(username); err != nil {
+		return nil, fmt.Errorf("unable to export the TOTP history of user %s: %w", username, err)
+	}
+
+	keyHandle, publicKey, err := p.LoadU2FDeviceHandle(username)
+
+	switch err {
+	case nil:
+		export.U2FDevice = &ExportU2FDevice{Username: username, KeyHandle: keyHandle, PublicKey: publicKey}
+	case ErrNoU2FDeviceHandle:
+	default:
+		return nil, fmt.Errorf("unable to export the U2F device of user %s: %w", username, err)
+	}
+
+	if export.WebAuthnCredentials, err = p.LoadWebAuthnCredentials(username); err != nil {
+		return nil, fmt.Errorf("unable to export the WebAuthn credentials of user %s: %w", username, err)
+	}
+
+	if export.DeletedWebAuthnCredentials, err = p.LoadDeletedWebAuthnCredentials(username); err != nil {
+		return nil, fmt.Errorf("unable to export the deleted WebAuthn credentials of user %s: %w", username, err)
+	}
+
+	if export.TrustedDevices, err = p.LoadTrustedDevices(username); err != nil {
+		return nil, fmt.Errorf("unable to export the trusted devices of user %s: %w", username, err)
+	}
+
+	if export.HOTPDevice, err = p.LoadHOTPDevice(username); err != nil && err != ErrNoHOTPDevice {
+		return nil, fmt.Errorf("unable to export the HOTP device of user %s: %w", username, err)
+	}
+
+	if export.YubiKeyOTPDevices, err = p.LoadYubiKeyOTPDevices(username); err != nil {
+		return nil, fmt.Errorf("unable to export the YubiKey OTP devices of user %s: %w", username, err)
+	}
+
+	if export.AuthenticationLogs, _, err = p.LoadAuthenticationLogs(AuthenticationLogsFilter{Username: username}); err != nil {
+		return nil, fmt.Errorf("unable to export the authentication logs of user %s: %w", username, err)
+	}
+
+	if export.AuditEvents, _, err = p.LoadAuditEvents(AuditEventsFilter{Username: username}); err != nil {
+		return nil, fmt.Errorf("unable to export the audit events of user %s: %w", username, err)
+	}
+
+	return export, nil
+}
+
+// Import persists all of the data of an export in a single transaction. It's intended to be used with an empty
+// database, the authentication logs and identity verification tokens are appended to the existing ones. The audit
+// events of the export are never imported as the audit log can only be appended to by Authelia itself, otherwise an
+// import could forge the history of the security events.
+func (p *SQLProvider) Import(export *Export) (err error) {
+	if export.SchemaVersion != storageSchemaCurrentVersion {
+		return fmt.Errorf("unable to import data from schema version %d into schema version %d", export.SchemaVersion, storageSchemaCurrentVersion)
+	}
+
+	if err = p.importCheckWebAuthnCredentials(export); err != nil {
+		return err
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = p.importData(tx, export); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("rollback error occurred: %v (inner error %v)", rollbackErr, err)
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// importCheckWebAuthnCredentials returns ErrWebAuthnCredentialRegistered if the ID of a WebAuthn credential of the
+// export is used by another credential of the export or by a registered credential.
+func (p *SQLProvider) importCheckWebAuthnCredentials(export *Export) (err error) {
+	ids := make(map[string]bool, len(export.WebAuthnCredentials))
+
+	for _, credential := range export.WebAuthnCredentials {
+		if ids[string(credential.ID)] {
+			return fmt.Errorf("unable to import the WebAuthn credentials of user %s: %w", credential.Username, ErrWebAuthnCredentialRegistered)
+		}
+
+		ids[string(credential.ID)] = true
+
+		if _, err = p.LoadWebAuthnCredential(credential.ID); err == nil {
+			return fmt.Errorf("unable to import the WebAuthn credentials of user %s: %w", credential.Username, ErrWebAuthnCredentialRegistered)
+		} else if err != ErrNoWebAuthnCredential {
+			return fmt.Errorf("unable to import the WebAuthn credentials of user %s: %w", credential.Username, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *SQLProvider) importData(tx transaction, export *Export) (err error) {
+	for _, preferences := range export.UserPreferences {
+		if _, err = tx.Exec(p.sqlUpsertSecondFactorPreference, preferences.Username, preferences.SecondFactorMethod); err != nil {
+			return fmt.Errorf("unable to import the preferences of user %s: %w", preferences.Username, err)
+		}
+	}
+
+	for _, token := range export.IdentityVerificationTokens {
+		if _, err = tx.Exec(p.sqlInsertIdentityVerificationToken, token); err != nil {
+			return fmt.Errorf("unable to import identity verification token: %w", err)
+		}
+	}
+
+	for _, secret := range export.TOTPSecrets {
+		_, err = tx.Exec(p.sqlUpsertTOTPConfiguration, secret.Username, secret.Secret,
+			totpConfigurationAlgorithm(secret), totpConfigurationDigits(secret), secret.Period,
+			totpConfigurationLastUsed(secret), nullString(secret.LastUsedIP), nullString(secret.LastUsedCountry))
+		if err != nil {
+			return fmt.Errorf("unable to import the TOTP secret of user %s: %w", secret.Username, err)
+		}
+	}
+
+	for _, entry := range export.TOTPHistory {
+		if _, err = tx.Exec(p.sqlInsertTOTPHistory, entry.Username, entry.Event, entry.RemoteIP, entry.Time.Unix()); err != nil {
+			return fmt.Errorf("unable to import the TOTP history of user %s: %w", entry.Username, err)
+		}
+	}
+
+	for _, device := range export.U2FDevices {
+		_, err = tx.Exec(p.sqlUpsertU2FDeviceHandle, device.Username,
+			base64.StdEncoding.EncodeToString(device.KeyHandle),
+			base64.StdEncoding.EncodeToString(device.PublicKey))
+		if err != nil {
+			return fmt.Errorf("unable to import the U2F device of user %s: %w", device.Username, err)
+		}
+	}
+
+	for _, attempt := range export.AuthenticationLogs {
+		if _, err = tx.Exec(p.sqlInsertAuthenticationLog, attempt.Username, attempt.Successful, attempt.Time.Unix(), attempt.RemoteIP, attempt.Type); err != nil {
+			return fmt.Errorf("unable to import the authentication logs of user %s: %w", attempt.Username, err)
+		}
+	}
+
+	for _, ban := range export.Bans {
+		if _, err = tx.Exec(p.sqlInsertBan, ban.Username, ban.Reason, ban.Time.Unix(), banExpires(ban), ban.Revoked); err != nil {
+			return fmt.Errorf("unable to import the bans of user %s: %w", ban.Username, err)
+		}
+	}
+
+	for _, key := range export.APIKeys {
+		if _, err = tx.Exec(p.sqlInsertAPIKey, key.Name, key.Hash, strings.Join(key.Groups, ","), key.Time.Unix(), key.Revoked); err != nil {
+			return fmt.Errorf("unable to import the API key %s: %w", key.Name, err)
+		}
+	}
+
+	for _, credential := range export.WebAuthnCredentials {
+		if err = p.saveWebAuthnCredential(tx, credential); err != nil {
+			return fmt.Errorf("unable to import the WebAuthn credentials of user %s: %w", credential.Username, err)
+		}
+	}
+
+	for _, code := range export.RecoveryCodes {
+		if _, err = tx.Exec(p.sqlInsertRecoveryCode, code.Username, code.Hash, code.Time.Unix(), code.Used); err != nil {
+			return fmt.Errorf("unable to import the recovery codes of user %s: %w", code.Username, err)
+		}
+	}
+
+	for _, device := range export.TrustedDevices {
+		_, err = tx.Exec(p.sqlInsertTrustedDevice, device.Username, device.ID, device.TokenHash, device.Description,
+			device.RemoteIP, device.Time.Unix(), device.Expires.Unix())
+		if err != nil {
+			return fmt.Errorf("unable to import the trusted devices of user %s: %w", device.Username, err)
+		}
+	}
+
+	for _, device := range export.HOTPDevices {
+		if _, err = tx.Exec(p.sqlUpsertHOTPDevice, device.Username, device.Secret, int64(device.Counter), device.Digits); err != nil {
+			return fmt.Errorf("unable to import the HOTP device of user %s: %w", device.Username, err)
+		}
+	}
+
+	for _, device := range export.YubiKeyOTPDevices {
+		if _, err = tx.Exec(p.sqlInsertYubiKeyOTPDevice, device.Username, device.PublicID, device.Time.Unix()); err != nil {
+			return fmt.Errorf("unable to import the YubiKey OTP devices of user %s: %w", device.Username, err)
+		}
+	}
+
+	for _, enrollment := range export.SecondFactorEnrollments {
+		if _, err = tx.Exec(p.sqlInsertSecondFactorEnrollmentStart, enrollment.Username, enrollment.Time.Unix()); err != nil {
+			return fmt.Errorf("unable to import the second factor enrollment of user %s: %w", enrollment.Username, err)
+		}
+	}
+
+	for _, preference := range export.DomainPreferences {
+		if _, err = tx.Exec(p.sqlUpsertDomainPreference, preference.Username, preference.Domain, preference.SecondFactorMethod); err != nil {
+			return fmt.Errorf("unable to import the preference of user %s for domain %s: %w", preference.Username, preference.Domain, err)
+		}
+	}
+
+	return nil
+}
+
+// exportRows runs a query on the primary database and calls scan for every row returned.
+func (p *SQLProvider) exportRows(query string, scan func(rows *sql.Rows) error) error {
+	rows, err := p.db.Query(query)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = scan(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// HealthCheck checks the storage backend can be queried and its schema is the one expected by this version of
+// Authelia. It returns the schema version and the round trip time of the query.
+func (p *SQLProvider) HealthCheck() (status HealthStatus, err error) {
+	start := time.Now()
+
+	if err = p.db.QueryRow(p.sqlConfigGetValue, "schema", "version").Scan(&status.SchemaVersion); err != nil {
+		return status, fmt.Errorf("unable to query the schema version: %w", err)
+	}
+
+	status.Latency = time.Since(start)
+
+	if status.SchemaVersion != storageSchemaCurrentVersion {
+		return status, fmt.Errorf("the schema version is %d but version %d is expected", status.SchemaVersion, storageSchemaCurrentVersion)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+// SaveHOTPDevice persists the HOTP device of a user, replacing the one registered before if any.
+func (p *SQLProvider) SaveHOTPDevice(device models.HOTPDevice) error {
+	_, err := p.exec(p.sqlUpsertHOTPDevice, device.Username, device.Secret, int64(device.Counter), device.Digits)
+
+	return err
+}
+
+// LoadHOTPDevice loads the HOTP device of a user. It is read from the primary database as the counter of a replica
+// might be behind, which would allow the one-time passwords already used to be replayed.
+func (p *SQLProvider) LoadHOTPDevice(username string) (*models.HOTPDevice, error) {
+	var (
+		device  models.HOTPDevice
+		counter int64
+	)
+
+	err := p.queryRow(p.db, p.sqlSelectHOTPDeviceByUser, []interface{}{username},
+		&device.Username, &device.Secret, &counter, &device.Digits)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNoHOTPDevice
+		}
+
+		return nil, err
+	}
+
+	device.Counter = uint64(counter)
+
+	return &device, nil
+}
+
+// UpdateHOTPCounter moves the counter of the HOTP device of a user forward if it still is the current one. It returns
+// false when the counter was moved by a concurrent authentication in the meantime.
+func (p *SQLProvider) UpdateHOTPCounter(username string, current, next uint64) (bool, error) {
+	result, err := p.exec(p.sqlUpdateHOTPCounter, int64(next), username, int64(current))
+	if err != nil {
+		return false, err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return count == 1, nil
+}
+
+// DeleteHOTPDevice deletes the HOTP device of a user.
+func (p *SQLProvider) DeleteHOTPDevice(username string) error {
+	_, err := p.exec(p.sqlDeleteHOTPDevice, username)
+
+	return err
+}
+
+func scanHOTPDevice(rows *sql.Rows) (device models.HOTPDevice, err error) {
+	var counter int64
+
+	if err = rows.Scan(&device.Username, &device.Secret, &counter, &device.Digits); err != nil {
+		return device, err
+	}
+
+	device.Counter = uint64(counter)
+
+	return device, nil
+}
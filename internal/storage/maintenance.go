@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ErrMaintenanceNotSupported is returned when maintenance is run on a storage backend which performs it automatically.
+var ErrMaintenanceNotSupported = errors.New("the storage backend performs its maintenance automatically")
+
+// Maintenance runs the maintenance statements of the storage backend, reclaiming the space left by deleted rows and
+// refreshing the statistics used by the query planner.
+func (p *SQLProvider) Maintenance() error {
+	if len(p.sqlMaintenanceStatements) == 0 && p.sqlMaintenanceTableStatement == "" {
+		return ErrMaintenanceNotSupported
+	}
+
+	for _, statement := range p.sqlMaintenanceStatements {
+		if _, err := p.db.Exec(statement); err != nil {
+			return fmt.Errorf("unable to run the maintenance statement %s: %w", statement, err)
+		}
+	}
+
+	if p.sqlMaintenanceTableStatement == "" {
+		return nil
+	}
+
+	for _, table := range p.tableNames() {
+		if _, err := p.db.Exec(fmt.Sprintf(p.sqlMaintenanceTableStatement, table)); err != nil {
+			return fmt.Errorf("unable to run the maintenance of table %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// StartMaintenance runs the maintenance of the storage backend in the background every maintenance interval when one
+// is configured.
+func (p *SQLProvider) StartMaintenance() {
+	if p.maintenanceConfig.Interval == "" {
+		return
+	}
+
+	interval, err := utils.ParseDurationString(p.maintenanceConfig.Interval)
+	if err != nil || interval <= 0 {
+		p.log.Errorf("Unable to start the storage maintenance with the interval %s", p.maintenanceConfig.Interval)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := p.Maintenance(); err != nil {
+				p.log.Errorf("Unable to run the storage maintenance: %v", err)
+			} else {
+				p.log.Debug("Storage maintenance completed")
+			}
+		}
+	}()
+}
+
+// tableNames returns the prefixed names of the tables created by the schema upgrades.
+func (p *SQLProvider) tableNames() (tables []string) {
+	for _, statements := range p.sqlUpgradesCreateTableStatements {
+		for table := range statements {
+			tables = append(tables, p.tablePrefix+table)
+		}
+	}
+
+	sort.Strings(tables)
+
+	return tables
+}
@@ -2,9 +2,10 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 
-	_ "github.com/go-sql-driver/mysql" // Load the MySQL Driver used in the connection string.
+	"github.com/go-sql-driver/mysql"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
 )
@@ -15,39 +16,166 @@ type MySQLProvider struct {
 }
 
 // NewMySQLProvider a MySQL provider.
-func NewMySQLProvider(configuration schema.MySQLStorageConfiguration) *MySQLProvider {
+func NewMySQLProvider(configuration schema.StorageConfiguration) *MySQLProvider {
+	tablePrefix := configuration.TablePrefix
+
 	provider := MySQLProvider{
 		SQLProvider{
-			name: "mysql",
+			name:        "mysql",
+			tablePrefix: tablePrefix,
 
 			sqlUpgradesCreateTableStatements: sqlUpgradeCreateTableStatements,
+			sqlUpgradesAlterTableStatements:  sqlMySQLUpgradesAlterTableStatements,
+
+			sqlGetPreferencesByUsername:     fmt.Sprintf("SELECT second_factor_method FROM %s WHERE username=?", tablePrefix+userPreferencesTableName),
+			sqlUpsertSecondFactorPreference: fmt.Sprintf("REPLACE INTO %s (username, second_factor_method) VALUES (?, ?)", tablePrefix+userPreferencesTableName),
+
+			sqlTestIdentityVerificationTokenExistence: fmt.Sprintf("SELECT EXISTS (SELECT * FROM %s WHERE token=?)", tablePrefix+identityVerificationTokensTableName),
+			sqlInsertIdentityVerificationToken:        fmt.Sprintf("INSERT INTO %s (token) VALUES (?)", tablePrefix+identityVerificationTokensTableName),
+			sqlDeleteIdentityVerificationToken:        fmt.Sprintf("DELETE FROM %s WHERE token=?", tablePrefix+identityVerificationTokensTableName),
+
+			sqlGetTOTPConfigurationByUsername: fmt.Sprintf("SELECT username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country FROM %s WHERE username=?", tablePrefix+totpSecretsTableName),
+			sqlUpsertTOTPConfiguration:        fmt.Sprintf("REPLACE INTO %s (username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", tablePrefix+totpSecretsTableName),
+			sqlUpdateTOTPConfigurationSignIn:  fmt.Sprintf("UPDATE %s SET last_used=?, last_used_ip=?, last_used_country=? WHERE username=?", tablePrefix+totpSecretsTableName),
+			sqlDeleteTOTPSecret:               fmt.Sprintf("DELETE FROM %s WHERE username=?", tablePrefix+totpSecretsTableName),
+
+			sqlInsertTOTPHistory:        fmt.Sprintf("INSERT INTO %s (username, event, remote_ip, time) VALUES (?, ?, ?, ?)", tablePrefix+totpHistoryTableName),
+			sqlGetTOTPHistoryByUsername: fmt.Sprintf("SELECT username, event, remote_ip, time FROM %s WHERE username=? ORDER BY time DESC", tablePrefix+totpHistoryTableName),
+
+			sqlGetU2FDeviceHandleByUsername: fmt.Sprintf("SELECT keyHandle, publicKey FROM %s WHERE username=?", tablePrefix+u2fDeviceHandlesTableName),
+			sqlUpsertU2FDeviceHandle:        fmt.Sprintf("REPLACE INTO %s (username, keyHandle, publicKey) VALUES (?, ?, ?)", tablePrefix+u2fDeviceHandlesTableName),
+			sqlDeleteU2FDeviceHandle:        fmt.Sprintf("DELETE FROM %s WHERE username=?", tablePrefix+u2fDeviceHandlesTableName),
+
+			sqlInsertAuthenticationLog:                fmt.Sprintf("INSERT INTO %s (username, successful, time, remote_ip, auth_type) VALUES (?, ?, ?, ?, ?)", tablePrefix+authenticationLogsTableName),
+			sqlGetLatestAuthenticationLogs:            fmt.Sprintf("SELECT successful, time FROM %s WHERE time>? AND username=? ORDER BY time DESC", tablePrefix+authenticationLogsTableName),
+			sqlGetAuthenticationLogs:                  fmt.Sprintf("SELECT username, successful, time, remote_ip, auth_type FROM %s WHERE (username=? OR ?='') AND (remote_ip=? OR ?='') AND (auth_type=? OR ?='') AND (successful=? OR ?) AND time>=? AND time<=? ORDER BY time DESC LIMIT ?", tablePrefix+authenticationLogsTableName),
+			sqlDeleteAuthenticationLogsOlderThan:      fmt.Sprintf("DELETE FROM %s WHERE time<?", tablePrefix+authenticationLogsTableName),
+			sqlDeleteAuthenticationLogsExceedingCount: fmt.Sprintf("DELETE FROM %[1]s WHERE time<(SELECT time FROM (SELECT time FROM %[1]s ORDER BY time DESC LIMIT 1 OFFSET ?) AS latest)", tablePrefix+authenticationLogsTableName),
+
+			sqlInsertAuditEvent:          fmt.Sprintf("INSERT INTO %s (username, event, remote_ip, details, time, chain_index, chain_hash) VALUES (?, ?, ?, ?, ?, ?, ?)", tablePrefix+auditEventsTableName),
+			sqlSelectAuditEvents:         fmt.Sprintf("SELECT username, event, remote_ip, details, time, chain_index, chain_hash FROM %s WHERE (username=? OR ?='') AND (event=? OR ?='') AND time>=? AND time<=? ORDER BY time DESC, chain_index DESC LIMIT ?", tablePrefix+auditEventsTableName),
+			sqlSelectAuditChainHead:      fmt.Sprintf("SELECT chain_index, chain_hash FROM %s WHERE chain_index IS NOT NULL ORDER BY chain_index DESC LIMIT 1", tablePrefix+auditEventsTableName),
+			sqlSelectAuditChain:          fmt.Sprintf("SELECT username, event, remote_ip, details, time, chain_index, chain_hash FROM %s WHERE chain_index IS NOT NULL ORDER BY chain_index", tablePrefix+auditEventsTableName),
+			sqlCountUnchainedAuditEvents: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE chain_index IS NULL AND time>=?", tablePrefix+auditEventsTableName),
+
+			sqlInsertBan:        fmt.Sprintf("INSERT INTO %s (username, reason, time, expires, revoked) VALUES (?, ?, ?, ?, ?)", tablePrefix+bannedUsersTableName),
+			sqlSelectActiveBans: fmt.Sprintf("SELECT username, reason, time, expires, revoked FROM %s WHERE (username=? OR ?='') AND revoked=FALSE AND (expires IS NULL OR expires>?) ORDER BY time DESC", tablePrefix+bannedUsersTableName),
+			sqlRevokeBans:       fmt.Sprintf("UPDATE %s SET revoked=TRUE WHERE username=? AND revoked=FALSE AND (expires IS NULL OR expires>?)", tablePrefix+bannedUsersTableName),
+
+			sqlInsertAPIKey:        fmt.Sprintf("INSERT INTO %s (name, key_hash, key_groups, time, revoked) VALUES (?, ?, ?, ?, ?)", tablePrefix+apiKeysTableName),
+			sqlSelectActiveAPIKey:  fmt.Sprintf("SELECT name, key_hash, key_groups, time, revoked FROM %s WHERE key_hash=? AND revoked=FALSE", tablePrefix+apiKeysTableName),
+			sqlSelectActiveAPIKeys: fmt.Sprintf("SELECT name, key_hash, key_groups, time, revoked FROM %s WHERE revoked=FALSE ORDER BY time", tablePrefix+apiKeysTableName),
+			sqlRevokeAPIKeys:       fmt.Sprintf("UPDATE %s SET revoked=TRUE WHERE name=? AND revoked=FALSE", tablePrefix+apiKeysTableName),
+
+			sqlInsertWebAuthnCredential:               fmt.Sprintf("INSERT INTO %s (username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", tablePrefix+webAuthnCredentialsTableName),
+			sqlSelectWebAuthnCredentialByID:           fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s WHERE credential_id=? AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlSelectWebAuthnCredentialsByUser:        fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s WHERE username=? AND deleted_at IS NULL ORDER BY time", tablePrefix+webAuthnCredentialsTableName),
+			sqlSelectDeletedWebAuthnCredentialsByUser: fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s WHERE username=? AND deleted_at IS NOT NULL ORDER BY deleted_at", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialUsage:          fmt.Sprintf("UPDATE %s SET sign_count=?, last_used=?, last_used_ip=?, last_used_country=?, backup_eligible=?, backup_state=? WHERE credential_id=? AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialDescription:    fmt.Sprintf("UPDATE %s SET description=? WHERE username=? AND credential_id=? AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialCloneWarning:   fmt.Sprintf("UPDATE %s SET clone_warning=? WHERE credential_id=? AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialDeleted:        fmt.Sprintf("UPDATE %s SET deleted_at=? WHERE username=? AND credential_id=? AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialsDeletedByUser: fmt.Sprintf("UPDATE %s SET deleted_at=? WHERE username=? AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialRestored:       fmt.Sprintf("UPDATE %s SET deleted_at=NULL WHERE username=? AND credential_id=? AND deleted_at IS NOT NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlDeleteWebAuthnCredentialsDeletedBefore: fmt.Sprintf("DELETE FROM %s WHERE deleted_at IS NOT NULL AND deleted_at<?", tablePrefix+webAuthnCredentialsTableName),
+			sqlDeleteDeletedWebAuthnCredentialByID:    fmt.Sprintf("DELETE FROM %s WHERE credential_id=? AND deleted_at IS NOT NULL", tablePrefix+webAuthnCredentialsTableName),
+
+			sqlInsertRecoveryCode:  fmt.Sprintf("INSERT INTO %s (username, code_hash, time, used) VALUES (?, ?, ?, ?)", tablePrefix+recoveryCodesTableName),
+			sqlCountRecoveryCodes:  fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE username=? AND used=FALSE", tablePrefix+recoveryCodesTableName),
+			sqlConsumeRecoveryCode: fmt.Sprintf("UPDATE %s SET used=TRUE WHERE username=? AND code_hash=? AND used=FALSE", tablePrefix+recoveryCodesTableName),
+			sqlDeleteRecoveryCodes: fmt.Sprintf("DELETE FROM %s WHERE username=?", tablePrefix+recoveryCodesTableName),
+
+			sqlInsertTrustedDevice:        fmt.Sprintf("INSERT INTO %s (username, device_id, token_hash, description, remote_ip, time, expires) VALUES (?, ?, ?, ?, ?, ?, ?)", tablePrefix+trustedDevicesTableName),
+			sqlSelectTrustedDeviceByHash:  fmt.Sprintf("SELECT username, device_id, token_hash, description, remote_ip, time, expires FROM %s WHERE token_hash=? AND expires>?", tablePrefix+trustedDevicesTableName),
+			sqlSelectTrustedDevicesByUser: fmt.Sprintf("SELECT username, device_id, token_hash, description, remote_ip, time, expires FROM %s WHERE username=? AND expires>? ORDER BY time", tablePrefix+trustedDevicesTableName),
+			sqlDeleteTrustedDevice:        fmt.Sprintf("DELETE FROM %s WHERE username=? AND device_id=?", tablePrefix+trustedDevicesTableName),
+
+			sqlUpsertHOTPDevice:       fmt.Sprintf("REPLACE INTO %s (username, secret, counter, digits) VALUES (?, ?, ?, ?)", tablePrefix+hotpDevicesTableName),
+			sqlSelectHOTPDeviceByUser: fmt.Sprintf("SELECT username, secret, counter, digits FROM %s WHERE username=?", tablePrefix+hotpDevicesTableName),
+			sqlUpdateHOTPCounter:      fmt.Sprintf("UPDATE %s SET counter=? WHERE username=? AND counter=?", tablePrefix+hotpDevicesTableName),
+			sqlDeleteHOTPDevice:       fmt.Sprintf("DELETE FROM %s WHERE username=?", tablePrefix+hotpDevicesTableName),
+
+			sqlInsertYubiKeyOTPDevice:        fmt.Sprintf("INSERT INTO %s (username, public_id, time) VALUES (?, ?, ?)", tablePrefix+yubiKeyOTPDevicesTableName),
+			sqlSelectYubiKeyOTPDevicesByUser: fmt.Sprintf("SELECT username, public_id, time FROM %s WHERE username=? ORDER BY time", tablePrefix+yubiKeyOTPDevicesTableName),
+			sqlDeleteYubiKeyOTPDevice:        fmt.Sprintf("DELETE FROM %s WHERE username=? AND public_id=?", tablePrefix+yubiKeyOTPDevicesTableName),
+			sqlDeleteYubiKeyOTPDevicesByUser: fmt.Sprintf("DELETE FROM %s WHERE username=?", tablePrefix+yubiKeyOTPDevicesTableName),
+
+			sqlSelectSecondFactorEnrollmentStart: fmt.Sprintf("SELECT time FROM %s WHERE username=?", tablePrefix+secondFactorEnrollmentsTableName),
+			sqlInsertSecondFactorEnrollmentStart: fmt.Sprintf("INSERT IGNORE INTO %s (username, time) VALUES (?, ?)", tablePrefix+secondFactorEnrollmentsTableName),
+
+			sqlSelectDomainPreferenceByUser:  fmt.Sprintf("SELECT second_factor_method FROM %s WHERE username=? AND domain=?", tablePrefix+userDomainPreferencesTableName),
+			sqlSelectDomainPreferencesByUser: fmt.Sprintf("SELECT username, domain, second_factor_method FROM %s WHERE username=? ORDER BY domain", tablePrefix+userDomainPreferencesTableName),
+			sqlUpsertDomainPreference:        fmt.Sprintf("REPLACE INTO %s (username, domain, second_factor_method) VALUES (?, ?, ?)", tablePrefix+userDomainPreferencesTableName),
+
+			sqlSelectUserPreferences:            fmt.Sprintf("SELECT username, second_factor_method FROM %s", tablePrefix+userPreferencesTableName),
+			sqlSelectIdentityVerificationTokens: fmt.Sprintf("SELECT token FROM %s", tablePrefix+identityVerificationTokensTableName),
+			sqlSelectTOTPSecrets:                fmt.Sprintf("SELECT username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country FROM %s", tablePrefix+totpSecretsTableName),
+			sqlSelectU2FDeviceHandles:           fmt.Sprintf("SELECT username, keyHandle, publicKey FROM %s", tablePrefix+u2fDeviceHandlesTableName),
+			sqlSelectAuthenticationLogs:         fmt.Sprintf("SELECT username, successful, time, remote_ip, auth_type FROM %s ORDER BY time", tablePrefix+authenticationLogsTableName),
+			sqlSelectAllAuditEvents:             fmt.Sprintf("SELECT username, event, remote_ip, details, time, chain_index, chain_hash FROM %s ORDER BY time, chain_index", tablePrefix+auditEventsTableName),
+			sqlSelectTOTPHistory:                fmt.Sprintf("SELECT username, event, remote_ip, time FROM %s ORDER BY time", tablePrefix+totpHistoryTableName),
+			sqlSelectBans:                       fmt.Sprintf("SELECT username, reason, time, expires, revoked FROM %s ORDER BY time", tablePrefix+bannedUsersTableName),
+			sqlSelectAPIKeys:                    fmt.Sprintf("SELECT name, key_hash, key_groups, time, revoked FROM %s ORDER BY time", tablePrefix+apiKeysTableName),
+			sqlSelectWebAuthnCredentials:        fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s ORDER BY time", tablePrefix+webAuthnCredentialsTableName),
+			sqlSelectRecoveryCodes:              fmt.Sprintf("SELECT username, code_hash, time, used FROM %s ORDER BY time", tablePrefix+recoveryCodesTableName),
+			sqlSelectTrustedDevices:             fmt.Sprintf("SELECT username, device_id, token_hash, description, remote_ip, time, expires FROM %s ORDER BY time", tablePrefix+trustedDevicesTableName),
+			sqlSelectHOTPDevices:                fmt.Sprintf("SELECT username, secret, counter, digits FROM %s ORDER BY username", tablePrefix+hotpDevicesTableName),
+			sqlSelectYubiKeyOTPDevices:          fmt.Sprintf("SELECT username, public_id, time FROM %s ORDER BY time", tablePrefix+yubiKeyOTPDevicesTableName),
+			sqlSelectSecondFactorEnrollments:    fmt.Sprintf("SELECT username, time FROM %s ORDER BY username", tablePrefix+secondFactorEnrollmentsTableName),
+			sqlSelectDomainPreferences:          fmt.Sprintf("SELECT username, domain, second_factor_method FROM %s ORDER BY username, domain", tablePrefix+userDomainPreferencesTableName),
 
-			sqlGetPreferencesByUsername:     fmt.Sprintf("SELECT second_factor_method FROM %s WHERE username=?", userPreferencesTableName),
-			sqlUpsertSecondFactorPreference: fmt.Sprintf("REPLACE INTO %s (username, second_factor_method) VALUES (?, ?)", userPreferencesTableName),
-
-			sqlTestIdentityVerificationTokenExistence: fmt.Sprintf("SELECT EXISTS (SELECT * FROM %s WHERE token=?)", identityVerificationTokensTableName),
-			sqlInsertIdentityVerificationToken:        fmt.Sprintf("INSERT INTO %s (token) VALUES (?)", identityVerificationTokensTableName),
-			sqlDeleteIdentityVerificationToken:        fmt.Sprintf("DELETE FROM %s WHERE token=?", identityVerificationTokensTableName),
+			sqlGetExistingTables: "SELECT table_name FROM information_schema.tables WHERE table_type='BASE TABLE' AND table_schema=database()",
 
-			sqlGetTOTPSecretByUsername: fmt.Sprintf("SELECT secret FROM %s WHERE username=?", totpSecretsTableName),
-			sqlUpsertTOTPSecret:        fmt.Sprintf("REPLACE INTO %s (username, secret) VALUES (?, ?)", totpSecretsTableName),
-			sqlDeleteTOTPSecret:        fmt.Sprintf("DELETE FROM %s WHERE username=?", totpSecretsTableName),
+			sqlMaintenanceTableStatement: "OPTIMIZE TABLE %s",
 
-			sqlGetU2FDeviceHandleByUsername: fmt.Sprintf("SELECT keyHandle, publicKey FROM %s WHERE username=?", u2fDeviceHandlesTableName),
-			sqlUpsertU2FDeviceHandle:        fmt.Sprintf("REPLACE INTO %s (username, keyHandle, publicKey) VALUES (?, ?, ?)", u2fDeviceHandlesTableName),
+			sqlConfigSetValue: fmt.Sprintf("REPLACE INTO %s (category, key_name, value) VALUES (?, ?, ?)", tablePrefix+configTableName),
+			sqlConfigGetValue: fmt.Sprintf("SELECT value FROM %s WHERE category=? AND key_name=?", tablePrefix+configTableName),
 
-			sqlInsertAuthenticationLog:     fmt.Sprintf("INSERT INTO %s (username, successful, time) VALUES (?, ?, ?)", authenticationLogsTableName),
-			sqlGetLatestAuthenticationLogs: fmt.Sprintf("SELECT successful, time FROM %s WHERE time>? AND username=? ORDER BY time DESC", authenticationLogsTableName),
+			isRetryableError: isMySQLRetryableError,
+			retryConfig:      configuration.Retry,
 
-			sqlGetExistingTables: "SELECT table_name FROM information_schema.tables WHERE table_type='BASE TABLE' AND table_schema=database()",
+			authenticationLogsConfig:  configuration.AuthenticationLogs,
+			webAuthnCredentialsConfig: configuration.WebAuthnCredentials,
+			maintenanceConfig:         configuration.Maintenance,
 
-			sqlConfigSetValue: fmt.Sprintf("REPLACE INTO %s (category, key_name, value) VALUES (?, ?, ?)", configTableName),
-			sqlConfigGetValue: fmt.Sprintf("SELECT value FROM %s WHERE category=? AND key_name=?", configTableName),
+			upgradeDisabled: configuration.DisableSchemaUpgrade,
 		},
 	}
 
 	provider.sqlUpgradesCreateTableStatements[SchemaVersion(1)][authenticationLogsTableName] = "CREATE TABLE %s (username VARCHAR(100), successful BOOL, time INTEGER, INDEX usr_time_idx (username, time))"
+	provider.sqlUpgradesCreateTableStatements[SchemaVersion(2)][auditEventsTableName] = "CREATE TABLE %s (username VARCHAR(100), event VARCHAR(64), remote_ip VARCHAR(47), details TEXT, time INTEGER, INDEX audit_usr_time_idx (username, time), INDEX audit_time_idx (time))"
+	provider.sqlUpgradesCreateTableStatements[SchemaVersion(4)][totpHistoryTableName] = "CREATE TABLE %s (username VARCHAR(100), event VARCHAR(16), remote_ip VARCHAR(47), time INTEGER, INDEX totp_history_usr_time_idx (username, time))"
+	provider.sqlUpgradesCreateTableStatements[SchemaVersion(5)][bannedUsersTableName] = "CREATE TABLE %s (username VARCHAR(100), reason TEXT, time INTEGER, expires INTEGER, revoked BOOL NOT NULL, INDEX banned_users_usr_time_idx (username, time))"
+	provider.sqlUpgradesCreateTableStatements[SchemaVersion(6)][apiKeysTableName] = "CREATE TABLE %s (name VARCHAR(100), key_hash VARCHAR(64), key_groups TEXT, time INTEGER, revoked BOOL NOT NULL, INDEX api_keys_hash_idx (key_hash))"
+	provider.sqlUpgradesCreateTableStatements[SchemaVersion(7)][webAuthnCredentialsTableName] = "CREATE TABLE %s (username VARCHAR(100), credential_id VARCHAR(512), user_handle VARCHAR(128), public_key TEXT, sign_count INTEGER, time INTEGER, INDEX webauthn_credentials_id_idx (credential_id), INDEX webauthn_credentials_usr_idx (username))"
+	provider.sqlUpgradesCreateTableStatements[SchemaVersion(13)][recoveryCodesTableName] = "CREATE TABLE %s (username VARCHAR(100), code_hash VARCHAR(64), time INTEGER, used BOOL NOT NULL, INDEX recovery_codes_usr_idx (username))"
+	provider.sqlUpgradesCreateTableStatements[SchemaVersion(14)][trustedDevicesTableName] = "CREATE TABLE %s (username VARCHAR(100), device_id VARCHAR(32), token_hash VARCHAR(64), description VARCHAR(255), remote_ip VARCHAR(47), time INTEGER, expires INTEGER, INDEX trusted_devices_hash_idx (token_hash), INDEX trusted_devices_usr_idx (username))"
+	provider.sqlUpgradesCreateTableStatements[SchemaVersion(15)][yubiKeyOTPDevicesTableName] = "CREATE TABLE %s (username VARCHAR(100), public_id VARCHAR(16), time INTEGER, INDEX yubikey_otp_devices_usr_idx (username))"
 
+	mysqlConfiguration := configuration.MySQL
+
+	connectionString := mySQLConnectionString(mysqlConfiguration.SQLStorageConfiguration, mysqlConfiguration.Host, mysqlConfiguration.Port)
+
+	db, err := sql.Open("mysql", connectionString)
+	if err != nil {
+		provider.log.Fatalf("Unable to connect to SQL database: %v", err)
+	}
+
+	configureConnectionPool(db, mysqlConfiguration.SQLStorageConfiguration)
+
+	provider.openReadReplicas("mysql", mysqlConfiguration.SQLStorageConfiguration, func(host string, port int) string {
+		return mySQLConnectionString(mysqlConfiguration.SQLStorageConfiguration, host, port)
+	})
+
+	if err := provider.initialize(db); err != nil {
+		provider.log.Fatalf("Unable to initialize SQL database: %v", err)
+	}
+
+	return &provider
+}
+
+// mySQLConnectionString builds a MySQL connection string for the provided host and port.
+func mySQLConnectionString(configuration schema.SQLStorageConfiguration, host string, port int) string {
 	connectionString := configuration.Username
 
 	if configuration.Password != "" {
@@ -58,9 +186,9 @@ func NewMySQLProvider(configuration schema.MySQLStorageConfiguration) *MySQLProv
 		connectionString += "@"
 	}
 
-	address := configuration.Host
-	if configuration.Port > 0 {
-		address += fmt.Sprintf(":%d", configuration.Port)
+	address := host
+	if port > 0 {
+		address += fmt.Sprintf(":%d", port)
 	}
 
 	connectionString += fmt.Sprintf("tcp(%s)", address)
@@ -68,14 +196,16 @@ func NewMySQLProvider(configuration schema.MySQLStorageConfiguration) *MySQLProv
 		connectionString += fmt.Sprintf("/%s", configuration.Database)
 	}
 
-	db, err := sql.Open("mysql", connectionString)
-	if err != nil {
-		provider.log.Fatalf("Unable to connect to SQL database: %v", err)
-	}
+	return connectionString
+}
 
-	if err := provider.initialize(db); err != nil {
-		provider.log.Fatalf("Unable to initialize SQL database: %v", err)
+// isMySQLRetryableError returns true if the error is a deadlock, a lock wait timeout, or a connection error.
+func isMySQLRetryableError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mySQLErrorNumberDeadlock || mysqlErr.Number == mySQLErrorNumberLockWaitTimeout
 	}
 
-	return &provider
+	return isConnectionError(err)
 }
@@ -3,6 +3,7 @@ package storage
 import (
 	"time"
 
+	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/models"
 )
 
@@ -12,17 +13,90 @@ type Provider interface {
 	LoadPreferred2FAMethod(username string) (string, error)
 	SavePreferred2FAMethod(username string, method string) error
 
+	LoadDomainPreferred2FAMethod(username, domain string) (string, error)
+	SaveDomainPreferred2FAMethod(username, domain, method string) error
+	LoadDomainPreferences(username string) ([]models.DomainPreference, error)
+
 	FindIdentityVerificationToken(token string) (bool, error)
 	SaveIdentityVerificationToken(token string) error
 	RemoveIdentityVerificationToken(token string) error
 
-	SaveTOTPSecret(username string, secret string) error
-	LoadTOTPSecret(username string) (string, error)
+	SaveTOTPConfiguration(configuration models.TOTPConfiguration) error
+	LoadTOTPConfiguration(username string) (*models.TOTPConfiguration, error)
+	UpdateTOTPConfigurationSignIn(configuration models.TOTPConfiguration) error
 	DeleteTOTPSecret(username string) error
+	AppendTOTPHistory(entry models.TOTPHistoryEntry) error
+	LoadTOTPHistory(username string) ([]models.TOTPHistoryEntry, error)
 
 	SaveU2FDeviceHandle(username string, keyHandle []byte, publicKey []byte) error
 	LoadU2FDeviceHandle(username string) (keyHandle []byte, publicKey []byte, err error)
+	DeleteU2FDeviceHandle(username string) error
 
 	AppendAuthenticationLog(attempt models.AuthenticationAttempt) error
 	LoadLatestAuthenticationLogs(username string, fromDate time.Time) ([]models.AuthenticationAttempt, error)
+	LoadAuthenticationLogs(filter AuthenticationLogsFilter) (attempts []models.AuthenticationAttempt, next string, err error)
+
+	AppendAuditEvent(event models.AuditEvent) error
+	LoadAuditEvents(filter AuditEventsFilter) (events []models.AuditEvent, next string, err error)
+	VerifyAuditEvents() (*AuditEventsVerification, error)
+
+	SaveBan(ban models.Ban) error
+	LoadActiveBans(username string) ([]models.Ban, error)
+	RevokeBan(username string) (int64, error)
+
+	SaveAPIKey(key models.APIKey) error
+	LoadAPIKey(hash string) (*models.APIKey, error)
+	LoadAPIKeys() ([]models.APIKey, error)
+	RevokeAPIKey(name string) (int64, error)
+
+	SaveWebAuthnCredential(credential models.WebAuthnCredential) error
+	LoadWebAuthnCredential(id []byte) (*models.WebAuthnCredential, error)
+	LoadWebAuthnCredentials(username string) ([]models.WebAuthnCredential, error)
+	LoadDeletedWebAuthnCredentials(username string) ([]models.WebAuthnCredential, error)
+	UpdateWebAuthnCredentialUsage(credential models.WebAuthnCredential) error
+	UpdateWebAuthnCredentialDescription(username string, id []byte, description string) (int64, error)
+	SetWebAuthnCredentialCloneWarning(id []byte) error
+	DeleteWebAuthnCredential(username string, id []byte) (int64, error)
+	DeleteWebAuthnCredentials(username string) (int64, error)
+	RestoreWebAuthnCredential(username string, id []byte) (int64, error)
+
+	SaveRecoveryCodes(username string, codes []models.RecoveryCode) error
+	CountRecoveryCodes(username string) (int, error)
+	ConsumeRecoveryCode(username, hash string) (bool, error)
+
+	SaveTrustedDevice(device models.TrustedDevice) error
+	LoadTrustedDevice(hash string) (*models.TrustedDevice, error)
+	LoadTrustedDevices(username string) ([]models.TrustedDevice, error)
+	DeleteTrustedDevice(username, id string) (int64, error)
+
+	SaveHOTPDevice(device models.HOTPDevice) error
+	LoadHOTPDevice(username string) (*models.HOTPDevice, error)
+	UpdateHOTPCounter(username string, current, next uint64) (bool, error)
+	DeleteHOTPDevice(username string) error
+
+	SaveYubiKeyOTPDevice(device models.YubiKeyOTPDevice) error
+	LoadYubiKeyOTPDevices(username string) ([]models.YubiKeyOTPDevice, error)
+	DeleteYubiKeyOTPDevice(username, publicID string) (int64, error)
+	DeleteYubiKeyOTPDevices(username string) (int64, error)
+
+	StartSecondFactorEnrollment(username string, now time.Time) (time.Time, error)
+
+	HealthCheck() (HealthStatus, error)
+}
+
+// NewSQLProvider constructs the SQL provider of the storage backend selected in the configuration, or returns nil if no
+// storage backend is configured.
+func NewSQLProvider(configuration schema.StorageConfiguration) *SQLProvider {
+	switch {
+	case configuration.PostgreSQL != nil:
+		return &NewPostgreSQLProvider(configuration).SQLProvider
+	case configuration.CockroachDB != nil:
+		return &NewCockroachDBProvider(configuration).SQLProvider
+	case configuration.MySQL != nil:
+		return &NewMySQLProvider(configuration).SQLProvider
+	case configuration.Local != nil:
+		return &NewSQLiteProvider(configuration).SQLProvider
+	default:
+		return nil
+	}
 }
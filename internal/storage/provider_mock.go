@@ -65,6 +65,50 @@ func (mr *MockProviderMockRecorder) SavePreferred2FAMethod(username, method inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePreferred2FAMethod", reflect.TypeOf((*MockProvider)(nil).SavePreferred2FAMethod), username, method)
 }
 
+// LoadDomainPreferred2FAMethod mocks base method
+func (m *MockProvider) LoadDomainPreferred2FAMethod(username, domain string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadDomainPreferred2FAMethod", username, domain)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadDomainPreferred2FAMethod indicates an expected call of LoadDomainPreferred2FAMethod
+func (mr *MockProviderMockRecorder) LoadDomainPreferred2FAMethod(username, domain interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadDomainPreferred2FAMethod", reflect.TypeOf((*MockProvider)(nil).LoadDomainPreferred2FAMethod), username, domain)
+}
+
+// SaveDomainPreferred2FAMethod mocks base method
+func (m *MockProvider) SaveDomainPreferred2FAMethod(username, domain, method string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDomainPreferred2FAMethod", username, domain, method)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveDomainPreferred2FAMethod indicates an expected call of SaveDomainPreferred2FAMethod
+func (mr *MockProviderMockRecorder) SaveDomainPreferred2FAMethod(username, domain, method interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDomainPreferred2FAMethod", reflect.TypeOf((*MockProvider)(nil).SaveDomainPreferred2FAMethod), username, domain, method)
+}
+
+// LoadDomainPreferences mocks base method
+func (m *MockProvider) LoadDomainPreferences(username string) ([]models.DomainPreference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadDomainPreferences", username)
+	ret0, _ := ret[0].([]models.DomainPreference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadDomainPreferences indicates an expected call of LoadDomainPreferences
+func (mr *MockProviderMockRecorder) LoadDomainPreferences(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadDomainPreferences", reflect.TypeOf((*MockProvider)(nil).LoadDomainPreferences), username)
+}
+
 // FindIdentityVerificationToken mocks base method
 func (m *MockProvider) FindIdentityVerificationToken(token string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -108,33 +152,47 @@ func (mr *MockProviderMockRecorder) RemoveIdentityVerificationToken(token interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveIdentityVerificationToken", reflect.TypeOf((*MockProvider)(nil).RemoveIdentityVerificationToken), token)
 }
 
-// SaveTOTPSecret mocks base method
-func (m *MockProvider) SaveTOTPSecret(username, secret string) error {
+// SaveTOTPConfiguration mocks base method
+func (m *MockProvider) SaveTOTPConfiguration(configuration models.TOTPConfiguration) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveTOTPSecret", username, secret)
+	ret := m.ctrl.Call(m, "SaveTOTPConfiguration", configuration)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SaveTOTPSecret indicates an expected call of SaveTOTPSecret
-func (mr *MockProviderMockRecorder) SaveTOTPSecret(username, secret interface{}) *gomock.Call {
+// SaveTOTPConfiguration indicates an expected call of SaveTOTPConfiguration
+func (mr *MockProviderMockRecorder) SaveTOTPConfiguration(configuration interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTOTPSecret", reflect.TypeOf((*MockProvider)(nil).SaveTOTPSecret), username, secret)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTOTPConfiguration", reflect.TypeOf((*MockProvider)(nil).SaveTOTPConfiguration), configuration)
 }
 
-// LoadTOTPSecret mocks base method
-func (m *MockProvider) LoadTOTPSecret(username string) (string, error) {
+// LoadTOTPConfiguration mocks base method
+func (m *MockProvider) LoadTOTPConfiguration(username string) (*models.TOTPConfiguration, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LoadTOTPSecret", username)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "LoadTOTPConfiguration", username)
+	ret0, _ := ret[0].(*models.TOTPConfiguration)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// LoadTOTPSecret indicates an expected call of LoadTOTPSecret
-func (mr *MockProviderMockRecorder) LoadTOTPSecret(username interface{}) *gomock.Call {
+// LoadTOTPConfiguration indicates an expected call of LoadTOTPConfiguration
+func (mr *MockProviderMockRecorder) LoadTOTPConfiguration(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadTOTPConfiguration", reflect.TypeOf((*MockProvider)(nil).LoadTOTPConfiguration), username)
+}
+
+// UpdateTOTPConfigurationSignIn mocks base method
+func (m *MockProvider) UpdateTOTPConfigurationSignIn(configuration models.TOTPConfiguration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTOTPConfigurationSignIn", configuration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTOTPConfigurationSignIn indicates an expected call of UpdateTOTPConfigurationSignIn
+func (mr *MockProviderMockRecorder) UpdateTOTPConfigurationSignIn(configuration interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadTOTPSecret", reflect.TypeOf((*MockProvider)(nil).LoadTOTPSecret), username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTOTPConfigurationSignIn", reflect.TypeOf((*MockProvider)(nil).UpdateTOTPConfigurationSignIn), configuration)
 }
 
 // DeleteTOTPSecret mocks base method
@@ -151,6 +209,35 @@ func (mr *MockProviderMockRecorder) DeleteTOTPSecret(username interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTOTPSecret", reflect.TypeOf((*MockProvider)(nil).DeleteTOTPSecret), username)
 }
 
+// AppendTOTPHistory mocks base method
+func (m *MockProvider) AppendTOTPHistory(entry models.TOTPHistoryEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendTOTPHistory", entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendTOTPHistory indicates an expected call of AppendTOTPHistory
+func (mr *MockProviderMockRecorder) AppendTOTPHistory(entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendTOTPHistory", reflect.TypeOf((*MockProvider)(nil).AppendTOTPHistory), entry)
+}
+
+// LoadTOTPHistory mocks base method
+func (m *MockProvider) LoadTOTPHistory(username string) ([]models.TOTPHistoryEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadTOTPHistory", username)
+	ret0, _ := ret[0].([]models.TOTPHistoryEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadTOTPHistory indicates an expected call of LoadTOTPHistory
+func (mr *MockProviderMockRecorder) LoadTOTPHistory(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadTOTPHistory", reflect.TypeOf((*MockProvider)(nil).LoadTOTPHistory), username)
+}
+
 // SaveU2FDeviceHandle mocks base method
 func (m *MockProvider) SaveU2FDeviceHandle(username string, keyHandle, publicKey []byte) error {
 	m.ctrl.T.Helper()
@@ -181,6 +268,20 @@ func (mr *MockProviderMockRecorder) LoadU2FDeviceHandle(username interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadU2FDeviceHandle", reflect.TypeOf((*MockProvider)(nil).LoadU2FDeviceHandle), username)
 }
 
+// DeleteU2FDeviceHandle mocks base method
+func (m *MockProvider) DeleteU2FDeviceHandle(username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteU2FDeviceHandle", username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteU2FDeviceHandle indicates an expected call of DeleteU2FDeviceHandle
+func (mr *MockProviderMockRecorder) DeleteU2FDeviceHandle(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteU2FDeviceHandle", reflect.TypeOf((*MockProvider)(nil).DeleteU2FDeviceHandle), username)
+}
+
 // AppendAuthenticationLog mocks base method
 func (m *MockProvider) AppendAuthenticationLog(attempt models.AuthenticationAttempt) error {
 	m.ctrl.T.Helper()
@@ -209,3 +310,564 @@ func (mr *MockProviderMockRecorder) LoadLatestAuthenticationLogs(username, fromD
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadLatestAuthenticationLogs", reflect.TypeOf((*MockProvider)(nil).LoadLatestAuthenticationLogs), username, fromDate)
 }
+
+// LoadAuthenticationLogs mocks base method
+func (m *MockProvider) LoadAuthenticationLogs(filter AuthenticationLogsFilter) ([]models.AuthenticationAttempt, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadAuthenticationLogs", filter)
+	ret0, _ := ret[0].([]models.AuthenticationAttempt)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LoadAuthenticationLogs indicates an expected call of LoadAuthenticationLogs
+func (mr *MockProviderMockRecorder) LoadAuthenticationLogs(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAuthenticationLogs", reflect.TypeOf((*MockProvider)(nil).LoadAuthenticationLogs), filter)
+}
+
+// AppendAuditEvent mocks base method
+func (m *MockProvider) AppendAuditEvent(event models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendAuditEvent", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendAuditEvent indicates an expected call of AppendAuditEvent
+func (mr *MockProviderMockRecorder) AppendAuditEvent(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendAuditEvent", reflect.TypeOf((*MockProvider)(nil).AppendAuditEvent), event)
+}
+
+// LoadAuditEvents mocks base method
+func (m *MockProvider) LoadAuditEvents(filter AuditEventsFilter) ([]models.AuditEvent, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadAuditEvents", filter)
+	ret0, _ := ret[0].([]models.AuditEvent)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LoadAuditEvents indicates an expected call of LoadAuditEvents
+func (mr *MockProviderMockRecorder) LoadAuditEvents(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAuditEvents", reflect.TypeOf((*MockProvider)(nil).LoadAuditEvents), filter)
+}
+
+// VerifyAuditEvents mocks base method
+func (m *MockProvider) VerifyAuditEvents() (*AuditEventsVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAuditEvents")
+	ret0, _ := ret[0].(*AuditEventsVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyAuditEvents indicates an expected call of VerifyAuditEvents
+func (mr *MockProviderMockRecorder) VerifyAuditEvents() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAuditEvents", reflect.TypeOf((*MockProvider)(nil).VerifyAuditEvents))
+}
+
+// SaveBan mocks base method
+func (m *MockProvider) SaveBan(ban models.Ban) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveBan", ban)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveBan indicates an expected call of SaveBan
+func (mr *MockProviderMockRecorder) SaveBan(ban interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBan", reflect.TypeOf((*MockProvider)(nil).SaveBan), ban)
+}
+
+// LoadActiveBans mocks base method
+func (m *MockProvider) LoadActiveBans(username string) ([]models.Ban, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadActiveBans", username)
+	ret0, _ := ret[0].([]models.Ban)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadActiveBans indicates an expected call of LoadActiveBans
+func (mr *MockProviderMockRecorder) LoadActiveBans(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadActiveBans", reflect.TypeOf((*MockProvider)(nil).LoadActiveBans), username)
+}
+
+// RevokeBan mocks base method
+func (m *MockProvider) RevokeBan(username string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeBan", username)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeBan indicates an expected call of RevokeBan
+func (mr *MockProviderMockRecorder) RevokeBan(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeBan", reflect.TypeOf((*MockProvider)(nil).RevokeBan), username)
+}
+
+// SaveRecoveryCodes mocks base method
+func (m *MockProvider) SaveRecoveryCodes(username string, codes []models.RecoveryCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRecoveryCodes", username, codes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRecoveryCodes indicates an expected call of SaveRecoveryCodes
+func (mr *MockProviderMockRecorder) SaveRecoveryCodes(username, codes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRecoveryCodes", reflect.TypeOf((*MockProvider)(nil).SaveRecoveryCodes), username, codes)
+}
+
+// CountRecoveryCodes mocks base method
+func (m *MockProvider) CountRecoveryCodes(username string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRecoveryCodes", username)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRecoveryCodes indicates an expected call of CountRecoveryCodes
+func (mr *MockProviderMockRecorder) CountRecoveryCodes(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRecoveryCodes", reflect.TypeOf((*MockProvider)(nil).CountRecoveryCodes), username)
+}
+
+// ConsumeRecoveryCode mocks base method
+func (m *MockProvider) ConsumeRecoveryCode(username, hash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeRecoveryCode", username, hash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeRecoveryCode indicates an expected call of ConsumeRecoveryCode
+func (mr *MockProviderMockRecorder) ConsumeRecoveryCode(username, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeRecoveryCode", reflect.TypeOf((*MockProvider)(nil).ConsumeRecoveryCode), username, hash)
+}
+
+// SaveTrustedDevice mocks base method
+func (m *MockProvider) SaveTrustedDevice(device models.TrustedDevice) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTrustedDevice", device)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveTrustedDevice indicates an expected call of SaveTrustedDevice
+func (mr *MockProviderMockRecorder) SaveTrustedDevice(device interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTrustedDevice", reflect.TypeOf((*MockProvider)(nil).SaveTrustedDevice), device)
+}
+
+// LoadTrustedDevice mocks base method
+func (m *MockProvider) LoadTrustedDevice(hash string) (*models.TrustedDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadTrustedDevice", hash)
+	ret0, _ := ret[0].(*models.TrustedDevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadTrustedDevice indicates an expected call of LoadTrustedDevice
+func (mr *MockProviderMockRecorder) LoadTrustedDevice(hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadTrustedDevice", reflect.TypeOf((*MockProvider)(nil).LoadTrustedDevice), hash)
+}
+
+// LoadTrustedDevices mocks base method
+func (m *MockProvider) LoadTrustedDevices(username string) ([]models.TrustedDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadTrustedDevices", username)
+	ret0, _ := ret[0].([]models.TrustedDevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadTrustedDevices indicates an expected call of LoadTrustedDevices
+func (mr *MockProviderMockRecorder) LoadTrustedDevices(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadTrustedDevices", reflect.TypeOf((*MockProvider)(nil).LoadTrustedDevices), username)
+}
+
+// DeleteTrustedDevice mocks base method
+func (m *MockProvider) DeleteTrustedDevice(username, id string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTrustedDevice", username, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTrustedDevice indicates an expected call of DeleteTrustedDevice
+func (mr *MockProviderMockRecorder) DeleteTrustedDevice(username, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTrustedDevice", reflect.TypeOf((*MockProvider)(nil).DeleteTrustedDevice), username, id)
+}
+
+// SaveHOTPDevice mocks base method
+func (m *MockProvider) SaveHOTPDevice(device models.HOTPDevice) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveHOTPDevice", device)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveHOTPDevice indicates an expected call of SaveHOTPDevice
+func (mr *MockProviderMockRecorder) SaveHOTPDevice(device interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveHOTPDevice", reflect.TypeOf((*MockProvider)(nil).SaveHOTPDevice), device)
+}
+
+// LoadHOTPDevice mocks base method
+func (m *MockProvider) LoadHOTPDevice(username string) (*models.HOTPDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadHOTPDevice", username)
+	ret0, _ := ret[0].(*models.HOTPDevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadHOTPDevice indicates an expected call of LoadHOTPDevice
+func (mr *MockProviderMockRecorder) LoadHOTPDevice(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadHOTPDevice", reflect.TypeOf((*MockProvider)(nil).LoadHOTPDevice), username)
+}
+
+// UpdateHOTPCounter mocks base method
+func (m *MockProvider) UpdateHOTPCounter(username string, current, next uint64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateHOTPCounter", username, current, next)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateHOTPCounter indicates an expected call of UpdateHOTPCounter
+func (mr *MockProviderMockRecorder) UpdateHOTPCounter(username, current, next interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHOTPCounter", reflect.TypeOf((*MockProvider)(nil).UpdateHOTPCounter), username, current, next)
+}
+
+// DeleteHOTPDevice mocks base method
+func (m *MockProvider) DeleteHOTPDevice(username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteHOTPDevice", username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteHOTPDevice indicates an expected call of DeleteHOTPDevice
+func (mr *MockProviderMockRecorder) DeleteHOTPDevice(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHOTPDevice", reflect.TypeOf((*MockProvider)(nil).DeleteHOTPDevice), username)
+}
+
+// SaveYubiKeyOTPDevice mocks base method
+func (m *MockProvider) SaveYubiKeyOTPDevice(device models.YubiKeyOTPDevice) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveYubiKeyOTPDevice", device)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveYubiKeyOTPDevice indicates an expected call of SaveYubiKeyOTPDevice
+func (mr *MockProviderMockRecorder) SaveYubiKeyOTPDevice(device interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveYubiKeyOTPDevice", reflect.TypeOf((*MockProvider)(nil).SaveYubiKeyOTPDevice), device)
+}
+
+// LoadYubiKeyOTPDevices mocks base method
+func (m *MockProvider) LoadYubiKeyOTPDevices(username string) ([]models.YubiKeyOTPDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadYubiKeyOTPDevices", username)
+	ret0, _ := ret[0].([]models.YubiKeyOTPDevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadYubiKeyOTPDevices indicates an expected call of LoadYubiKeyOTPDevices
+func (mr *MockProviderMockRecorder) LoadYubiKeyOTPDevices(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadYubiKeyOTPDevices", reflect.TypeOf((*MockProvider)(nil).LoadYubiKeyOTPDevices), username)
+}
+
+// DeleteYubiKeyOTPDevice mocks base method
+func (m *MockProvider) DeleteYubiKeyOTPDevice(username, publicID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteYubiKeyOTPDevice", username, publicID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteYubiKeyOTPDevice indicates an expected call of DeleteYubiKeyOTPDevice
+func (mr *MockProviderMockRecorder) DeleteYubiKeyOTPDevice(username, publicID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteYubiKeyOTPDevice", reflect.TypeOf((*MockProvider)(nil).DeleteYubiKeyOTPDevice), username, publicID)
+}
+
+// DeleteYubiKeyOTPDevices mocks base method
+func (m *MockProvider) DeleteYubiKeyOTPDevices(username string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteYubiKeyOTPDevices", username)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteYubiKeyOTPDevices indicates an expected call of DeleteYubiKeyOTPDevices
+func (mr *MockProviderMockRecorder) DeleteYubiKeyOTPDevices(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteYubiKeyOTPDevices", reflect.TypeOf((*MockProvider)(nil).DeleteYubiKeyOTPDevices), username)
+}
+
+// StartSecondFactorEnrollment mocks base method
+func (m *MockProvider) StartSecondFactorEnrollment(username string, now time.Time) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartSecondFactorEnrollment", username, now)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartSecondFactorEnrollment indicates an expected call of StartSecondFactorEnrollment
+func (mr *MockProviderMockRecorder) StartSecondFactorEnrollment(username, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartSecondFactorEnrollment", reflect.TypeOf((*MockProvider)(nil).StartSecondFactorEnrollment), username, now)
+}
+
+// HealthCheck mocks base method
+func (m *MockProvider) HealthCheck() (HealthStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HealthCheck")
+	ret0, _ := ret[0].(HealthStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HealthCheck indicates an expected call of HealthCheck
+func (mr *MockProviderMockRecorder) HealthCheck() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthCheck", reflect.TypeOf((*MockProvider)(nil).HealthCheck))
+}
+
+// SaveAPIKey mocks base method
+func (m *MockProvider) SaveAPIKey(key models.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAPIKey", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAPIKey indicates an expected call of SaveAPIKey
+func (mr *MockProviderMockRecorder) SaveAPIKey(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAPIKey", reflect.TypeOf((*MockProvider)(nil).SaveAPIKey), key)
+}
+
+// LoadAPIKey mocks base method
+func (m *MockProvider) LoadAPIKey(hash string) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadAPIKey", hash)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadAPIKey indicates an expected call of LoadAPIKey
+func (mr *MockProviderMockRecorder) LoadAPIKey(hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAPIKey", reflect.TypeOf((*MockProvider)(nil).LoadAPIKey), hash)
+}
+
+// LoadAPIKeys mocks base method
+func (m *MockProvider) LoadAPIKeys() ([]models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadAPIKeys")
+	ret0, _ := ret[0].([]models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadAPIKeys indicates an expected call of LoadAPIKeys
+func (mr *MockProviderMockRecorder) LoadAPIKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAPIKeys", reflect.TypeOf((*MockProvider)(nil).LoadAPIKeys))
+}
+
+// RevokeAPIKey mocks base method
+func (m *MockProvider) RevokeAPIKey(name string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", name)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey
+func (mr *MockProviderMockRecorder) RevokeAPIKey(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockProvider)(nil).RevokeAPIKey), name)
+}
+
+// SaveWebAuthnCredential mocks base method
+func (m *MockProvider) SaveWebAuthnCredential(credential models.WebAuthnCredential) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWebAuthnCredential", credential)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveWebAuthnCredential indicates an expected call of SaveWebAuthnCredential
+func (mr *MockProviderMockRecorder) SaveWebAuthnCredential(credential interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWebAuthnCredential", reflect.TypeOf((*MockProvider)(nil).SaveWebAuthnCredential), credential)
+}
+
+// LoadWebAuthnCredential mocks base method
+func (m *MockProvider) LoadWebAuthnCredential(id []byte) (*models.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadWebAuthnCredential", id)
+	ret0, _ := ret[0].(*models.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadWebAuthnCredential indicates an expected call of LoadWebAuthnCredential
+func (mr *MockProviderMockRecorder) LoadWebAuthnCredential(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadWebAuthnCredential", reflect.TypeOf((*MockProvider)(nil).LoadWebAuthnCredential), id)
+}
+
+// LoadWebAuthnCredentials mocks base method
+func (m *MockProvider) LoadWebAuthnCredentials(username string) ([]models.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadWebAuthnCredentials", username)
+	ret0, _ := ret[0].([]models.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadWebAuthnCredentials indicates an expected call of LoadWebAuthnCredentials
+func (mr *MockProviderMockRecorder) LoadWebAuthnCredentials(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadWebAuthnCredentials", reflect.TypeOf((*MockProvider)(nil).LoadWebAuthnCredentials), username)
+}
+
+// LoadDeletedWebAuthnCredentials mocks base method
+func (m *MockProvider) LoadDeletedWebAuthnCredentials(username string) ([]models.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadDeletedWebAuthnCredentials", username)
+	ret0, _ := ret[0].([]models.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadDeletedWebAuthnCredentials indicates an expected call of LoadDeletedWebAuthnCredentials
+func (mr *MockProviderMockRecorder) LoadDeletedWebAuthnCredentials(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadDeletedWebAuthnCredentials", reflect.TypeOf((*MockProvider)(nil).LoadDeletedWebAuthnCredentials), username)
+}
+
+// UpdateWebAuthnCredentialUsage mocks base method
+func (m *MockProvider) UpdateWebAuthnCredentialUsage(credential models.WebAuthnCredential) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebAuthnCredentialUsage", credential)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebAuthnCredentialUsage indicates an expected call of UpdateWebAuthnCredentialUsage
+func (mr *MockProviderMockRecorder) UpdateWebAuthnCredentialUsage(credential interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebAuthnCredentialUsage", reflect.TypeOf((*MockProvider)(nil).UpdateWebAuthnCredentialUsage), credential)
+}
+
+// SetWebAuthnCredentialCloneWarning mocks base method
+func (m *MockProvider) SetWebAuthnCredentialCloneWarning(id []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWebAuthnCredentialCloneWarning", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWebAuthnCredentialCloneWarning indicates an expected call of SetWebAuthnCredentialCloneWarning
+func (mr *MockProviderMockRecorder) SetWebAuthnCredentialCloneWarning(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWebAuthnCredentialCloneWarning", reflect.TypeOf((*MockProvider)(nil).SetWebAuthnCredentialCloneWarning), id)
+}
+
+// UpdateWebAuthnCredentialDescription mocks base method
+func (m *MockProvider) UpdateWebAuthnCredentialDescription(username string, id []byte, description string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebAuthnCredentialDescription", username, id, description)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebAuthnCredentialDescription indicates an expected call of UpdateWebAuthnCredentialDescription
+func (mr *MockProviderMockRecorder) UpdateWebAuthnCredentialDescription(username, id, description interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebAuthnCredentialDescription", reflect.TypeOf((*MockProvider)(nil).UpdateWebAuthnCredentialDescription), username, id, description)
+}
+
+// DeleteWebAuthnCredential mocks base method
+func (m *MockProvider) DeleteWebAuthnCredential(username string, id []byte) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebAuthnCredential", username, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteWebAuthnCredential indicates an expected call of DeleteWebAuthnCredential
+func (mr *MockProviderMockRecorder) DeleteWebAuthnCredential(username, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebAuthnCredential", reflect.TypeOf((*MockProvider)(nil).DeleteWebAuthnCredential), username, id)
+}
+
+// DeleteWebAuthnCredentials mocks base method
+func (m *MockProvider) DeleteWebAuthnCredentials(username string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebAuthnCredentials", username)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteWebAuthnCredentials indicates an expected call of DeleteWebAuthnCredentials
+func (mr *MockProviderMockRecorder) DeleteWebAuthnCredentials(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebAuthnCredentials", reflect.TypeOf((*MockProvider)(nil).DeleteWebAuthnCredentials), username)
+}
+
+// RestoreWebAuthnCredential mocks base method
+func (m *MockProvider) RestoreWebAuthnCredential(username string, id []byte) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreWebAuthnCredential", username, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreWebAuthnCredential indicates an expected call of RestoreWebAuthnCredential
+func (mr *MockProviderMockRecorder) RestoreWebAuthnCredential(username, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreWebAuthnCredential", reflect.TypeOf((*MockProvider)(nil).RestoreWebAuthnCredential), username, id)
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ErrNoAuthenticationLogsRetention is returned when the authentication logs are pruned but neither a max age nor a max
+// count is configured.
+var ErrNoAuthenticationLogsRetention = errors.New("no retention is configured for the authentication logs")
+
+// PruneAuthenticationLogs deletes the authentication logs which are older than the configured max age and the oldest
+// ones in excess of the configured max count. It returns the number of deleted authentication logs.
+func (p *SQLProvider) PruneAuthenticationLogs() (deleted int64, err error) {
+	config := p.authenticationLogsConfig
+
+	if config.MaxAge == "" && config.MaxCount == 0 {
+		return 0, ErrNoAuthenticationLogsRetention
+	}
+
+	if config.MaxAge != "" {
+		maxAge, err := utils.ParseDurationString(config.MaxAge)
+		if err != nil {
+			return deleted, err
+		}
+
+		result, err := p.exec(p.sqlDeleteAuthenticationLogsOlderThan, time.Now().Add(-maxAge).Unix())
+		if err != nil {
+			return deleted, fmt.Errorf("unable to delete the authentication logs older than %s: %w", config.MaxAge, err)
+		}
+
+		if affected, err := result.RowsAffected(); err == nil {
+			deleted += affected
+		}
+	}
+
+	if config.MaxCount > 0 {
+		result, err := p.exec(p.sqlDeleteAuthenticationLogsExceedingCount, config.MaxCount-1)
+		if err != nil {
+			return deleted, fmt.Errorf("unable to delete the authentication logs in excess of %d: %w", config.MaxCount, err)
+		}
+
+		if affected, err := result.RowsAffected(); err == nil {
+			deleted += affected
+		}
+	}
+
+	return deleted, nil
+}
+
+// StartAuthenticationLogsPruning prunes the authentication logs in the background every prune interval when a retention
+// is configured for them.
+func (p *SQLProvider) StartAuthenticationLogsPruning() {
+	config := p.authenticationLogsConfig
+
+	if config.MaxAge == "" && config.MaxCount == 0 {
+		return
+	}
+
+	interval, err := utils.ParseDurationString(config.PruneInterval)
+	if err != nil || interval <= 0 {
+		p.log.Errorf("Unable to start pruning the authentication logs with the prune interval %s", config.PruneInterval)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			deleted, err := p.PruneAuthenticationLogs()
+			if err != nil {
+				p.log.Errorf("Unable to prune the authentication logs: %v", err)
+			} else {
+				p.log.Debugf("Pruned %d authentication logs", deleted)
+			}
+
+			<-ticker.C
+		}
+	}()
+}
+
+// PurgeWebAuthnCredentials deletes permanently the WebAuthn credentials which were deleted before the configured grace
+// period. It returns the number of purged WebAuthn credentials.
+func (p *SQLProvider) PurgeWebAuthnCredentials() (purged int64, err error) {
+	gracePeriod, err := utils.ParseDurationString(p.webAuthnCredentialsConfig.GracePeriod)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := p.exec(p.sqlDeleteWebAuthnCredentialsDeletedBefore, time.Now().Add(-gracePeriod).Unix())
+	if err != nil {
+		return 0, fmt.Errorf("unable to purge the WebAuthn credentials deleted more than %s ago: %w", p.webAuthnCredentialsConfig.GracePeriod, err)
+	}
+
+	return result.RowsAffected()
+}
+
+// StartWebAuthnCredentialsPurge purges the deleted WebAuthn credentials in the background every purge interval.
+func (p *SQLProvider) StartWebAuthnCredentialsPurge() {
+	config := p.webAuthnCredentialsConfig
+
+	interval, err := utils.ParseDurationString(config.PurgeInterval)
+	if err != nil || interval <= 0 {
+		p.log.Errorf("Unable to start purging the deleted WebAuthn credentials with the purge interval %s", config.PurgeInterval)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			purged, err := p.PurgeWebAuthnCredentials()
+			if err != nil {
+				p.log.Errorf("Unable to purge the deleted WebAuthn credentials: %v", err)
+			} else {
+				p.log.Debugf("Purged %d deleted WebAuthn credentials", purged)
+			}
+
+			<-ticker.C
+		}
+	}()
+}
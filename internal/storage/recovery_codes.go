@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+// SaveRecoveryCodes replaces the recovery codes of a user in a single transaction so the codes generated previously
+// can't be used anymore. Only the hashes of the codes are persisted.
+func (p *SQLProvider) SaveRecoveryCodes(username string, codes []models.RecoveryCode) (err error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = p.saveRecoveryCodes(tx, username, codes); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("rollback error occurred: %v (inner error %v)", rollbackErr, err)
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (p *SQLProvider) saveRecoveryCodes(tx transaction, username string, codes []models.RecoveryCode) (err error) {
+	if _, err = tx.Exec(p.sqlDeleteRecoveryCodes, username); err != nil {
+		return fmt.Errorf("unable to delete the recovery codes of user %s: %w", username, err)
+	}
+
+	for _, code := range codes {
+		if _, err = tx.Exec(p.sqlInsertRecoveryCode, username, code.Hash, code.Time.Unix(), code.Used); err != nil {
+			return fmt.Errorf("unable to save the recovery codes of user %s: %w", username, err)
+		}
+	}
+
+	return nil
+}
+
+// CountRecoveryCodes returns the number of recovery codes of a user which have not been used yet.
+func (p *SQLProvider) CountRecoveryCodes(username string) (count int, err error) {
+	if err = p.queryRow(p.db, p.sqlCountRecoveryCodes, []interface{}{username}, &count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ConsumeRecoveryCode marks the recovery code of a user with the given hash as used. It returns false if the user has
+// no such code or if it was already used, the update making sure a code can't be used twice concurrently.
+func (p *SQLProvider) ConsumeRecoveryCode(username, hash string) (bool, error) {
+	result, err := p.exec(p.sqlConsumeRecoveryCode, username, hash)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func scanRecoveryCode(rows *sql.Rows) (code models.RecoveryCode, err error) {
+	var t int64
+
+	if err = rows.Scan(&code.Username, &code.Hash, &t, &code.Used); err != nil {
+		return code, err
+	}
+
+	code.Time = time.Unix(t, 0)
+
+	return code, nil
+}
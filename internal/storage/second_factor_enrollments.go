@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+// StartSecondFactorEnrollment records that the user is required to enroll a second factor from now on, unless it was
+// already recorded. It returns the time the user was first required to enroll a second factor. It is read from the
+// primary database as a replica might not have the time which was just recorded yet.
+func (p *SQLProvider) StartSecondFactorEnrollment(username string, now time.Time) (time.Time, error) {
+	if _, err := p.exec(p.sqlInsertSecondFactorEnrollmentStart, username, now.Unix()); err != nil {
+		return time.Time{}, err
+	}
+
+	var t int64
+
+	if err := p.queryRow(p.db, p.sqlSelectSecondFactorEnrollmentStart, []interface{}{username}, &t); err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(t, 0), nil
+}
+
+func scanSecondFactorEnrollment(rows *sql.Rows) (enrollment models.SecondFactorEnrollment, err error) {
+	var t int64
+
+	if err = rows.Scan(&enrollment.Username, &t); err != nil {
+		return enrollment, err
+	}
+
+	enrollment.Time = time.Unix(t, 0)
+
+	return enrollment, nil
+}
@@ -2,12 +2,18 @@ package storage
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/logging"
 	"github.com/authelia/authelia/internal/models"
 	"github.com/authelia/authelia/internal/utils"
@@ -19,8 +25,16 @@ type SQLProvider struct {
 	log  *logrus.Logger
 	name string
 
+	// tablePrefix is prepended to the name of every table and index.
+	tablePrefix string
+
+	// dbReplicas are read replicas of db which are used round robin for read only queries.
+	dbReplicas     []*sql.DB
+	dbReplicaIndex uint32
+
 	sqlUpgradesCreateTableStatements        map[SchemaVersion]map[string]string
 	sqlUpgradesCreateTableIndexesStatements map[SchemaVersion][]string
+	sqlUpgradesAlterTableStatements         map[SchemaVersion][]string
 
 	sqlGetPreferencesByUsername     string
 	sqlUpsertSecondFactorPreference string
@@ -29,29 +43,179 @@ type SQLProvider struct {
 	sqlInsertIdentityVerificationToken        string
 	sqlDeleteIdentityVerificationToken        string
 
-	sqlGetTOTPSecretByUsername string
-	sqlUpsertTOTPSecret        string
-	sqlDeleteTOTPSecret        string
+	sqlGetTOTPConfigurationByUsername string
+	sqlUpsertTOTPConfiguration        string
+	sqlUpdateTOTPConfigurationSignIn  string
+	sqlDeleteTOTPSecret               string
+
+	sqlInsertTOTPHistory        string
+	sqlGetTOTPHistoryByUsername string
 
 	sqlGetU2FDeviceHandleByUsername string
 	sqlUpsertU2FDeviceHandle        string
+	sqlDeleteU2FDeviceHandle        string
 
 	sqlInsertAuthenticationLog     string
 	sqlGetLatestAuthenticationLogs string
+	sqlGetAuthenticationLogs       string
+
+	sqlDeleteAuthenticationLogsOlderThan      string
+	sqlDeleteAuthenticationLogsExceedingCount string
+
+	sqlInsertAuditEvent          string
+	sqlSelectAuditEvents         string
+	sqlSelectAuditChainHead      string
+	sqlSelectAuditChain          string
+	sqlCountUnchainedAuditEvents string
+
+	sqlInsertBan        string
+	sqlSelectActiveBans string
+	sqlRevokeBans       string
+
+	sqlInsertAPIKey        string
+	sqlSelectActiveAPIKey  string
+	sqlSelectActiveAPIKeys string
+	sqlRevokeAPIKeys       string
+
+	sqlInsertWebAuthnCredential               string
+	sqlSelectWebAuthnCredentialByID           string
+	sqlSelectWebAuthnCredentialsByUser        string
+	sqlSelectDeletedWebAuthnCredentialsByUser string
+	sqlUpdateWebAuthnCredentialUsage          string
+	sqlUpdateWebAuthnCredentialDescription    string
+	sqlUpdateWebAuthnCredentialCloneWarning   string
+	sqlUpdateWebAuthnCredentialDeleted        string
+	sqlUpdateWebAuthnCredentialsDeletedByUser string
+	sqlUpdateWebAuthnCredentialRestored       string
+	sqlDeleteWebAuthnCredentialsDeletedBefore string
+	sqlDeleteDeletedWebAuthnCredentialByID    string
+
+	sqlInsertRecoveryCode  string
+	sqlCountRecoveryCodes  string
+	sqlConsumeRecoveryCode string
+	sqlDeleteRecoveryCodes string
+
+	sqlInsertTrustedDevice        string
+	sqlSelectTrustedDeviceByHash  string
+	sqlSelectTrustedDevicesByUser string
+	sqlDeleteTrustedDevice        string
+
+	sqlUpsertHOTPDevice       string
+	sqlSelectHOTPDeviceByUser string
+	sqlUpdateHOTPCounter      string
+	sqlDeleteHOTPDevice       string
+
+	sqlInsertYubiKeyOTPDevice        string
+	sqlSelectYubiKeyOTPDevicesByUser string
+	sqlDeleteYubiKeyOTPDevice        string
+	sqlDeleteYubiKeyOTPDevicesByUser string
+
+	sqlSelectSecondFactorEnrollmentStart string
+	sqlInsertSecondFactorEnrollmentStart string
+
+	sqlSelectDomainPreferenceByUser  string
+	sqlSelectDomainPreferencesByUser string
+	sqlUpsertDomainPreference        string
+
+	sqlSelectUserPreferences            string
+	sqlSelectIdentityVerificationTokens string
+	sqlSelectTOTPSecrets                string
+	sqlSelectU2FDeviceHandles           string
+	sqlSelectAuthenticationLogs         string
+	sqlSelectAllAuditEvents             string
+	sqlSelectTOTPHistory                string
+	sqlSelectBans                       string
+	sqlSelectAPIKeys                    string
+	sqlSelectWebAuthnCredentials        string
+	sqlSelectRecoveryCodes              string
+	sqlSelectTrustedDevices             string
+	sqlSelectHOTPDevices                string
+	sqlSelectYubiKeyOTPDevices          string
+	sqlSelectSecondFactorEnrollments    string
+	sqlSelectDomainPreferences          string
 
 	sqlGetExistingTables string
 
+	// sqlMaintenanceStatements are run once by the maintenance, and sqlMaintenanceTableStatement is run for every table
+	// with the table name as the first argument.
+	sqlMaintenanceStatements     []string
+	sqlMaintenanceTableStatement string
+
 	sqlConfigSetValue string
 	sqlConfigGetValue string
+
+	// isRetryableError reports if an error is transient and the failed operation can be attempted again, nil if it
+	// never can.
+	isRetryableError func(err error) bool
+	retryConfig      schema.StorageRetryConfiguration
+
+	authenticationLogsConfig  schema.StorageAuthenticationLogsConfiguration
+	webAuthnCredentialsConfig schema.StorageWebAuthnCredentialsConfiguration
+	maintenanceConfig         schema.StorageMaintenanceConfiguration
+
+	// upgradeDisabled prevents the schema from being upgraded when the provider is initialized.
+	upgradeDisabled bool
 }
 
 func (p *SQLProvider) initialize(db *sql.DB) error {
 	p.db = db
 	p.log = logging.Logger()
 
+	if p.upgradeDisabled {
+		return nil
+	}
+
 	return p.upgrade()
 }
 
+// openReadReplicas opens a database handle for each of the configured read replicas.
+func (p *SQLProvider) openReadReplicas(driverName string, configuration schema.SQLStorageConfiguration, connectionString func(host string, port int) string) {
+	for _, replica := range configuration.ReadReplicas {
+		db, err := sql.Open(driverName, connectionString(replica.Host, replica.Port))
+		if err != nil {
+			logging.Logger().Fatalf("Unable to connect to SQL read replica %s: %v", replica.Host, err)
+		}
+
+		configureConnectionPool(db, configuration)
+
+		p.dbReplicas = append(p.dbReplicas, db)
+	}
+}
+
+// configureConnectionPool applies the connection pool options which have been configured, leaving the database/sql
+// defaults in place for the others.
+func configureConnectionPool(db *sql.DB, configuration schema.SQLStorageConfiguration) {
+	if configuration.MaxOpenConns != 0 {
+		db.SetMaxOpenConns(configuration.MaxOpenConns)
+	}
+
+	if configuration.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(configuration.MaxIdleConns)
+	}
+
+	if configuration.ConnMaxLifetime != "" {
+		lifetime, _ := utils.ParseDurationString(configuration.ConnMaxLifetime)
+		db.SetConnMaxLifetime(lifetime)
+	}
+
+	if configuration.ConnMaxIdleTime != "" {
+		idleTime, _ := utils.ParseDurationString(configuration.ConnMaxIdleTime)
+		db.SetConnMaxIdleTime(idleTime)
+	}
+}
+
+// dbRead returns the database handle to use for a reporting query. Read replicas may lag behind the primary so the
+// queries taking part in the authentication of the users, such as the regulation, must use db directly.
+func (p *SQLProvider) dbRead() *sql.DB {
+	if len(p.dbReplicas) == 0 {
+		return p.db
+	}
+
+	index := atomic.AddUint32(&p.dbReplicaIndex, 1)
+
+	return p.dbReplicas[index%uint32(len(p.dbReplicas))]
+}
+
 func (p *SQLProvider) getSchemaBasicDetails() (version SchemaVersion, tables []string, err error) {
 	rows, err := p.db.Query(p.sqlGetExistingTables)
 	if err != nil {
@@ -71,7 +235,12 @@ func (p *SQLProvider) getSchemaBasicDetails() (version SchemaVersion, tables []s
 		tables = append(tables, table)
 	}
 
-	if utils.IsStringInSlice(configTableName, tables) {
+	// Close the rows before running the next query so this works with a single connection.
+	if err = rows.Close(); err != nil {
+		return version, tables, err
+	}
+
+	if utils.IsStringInSlice(p.tablePrefix+configTableName, tables) {
 		rows, err := p.db.Query(p.sqlConfigGetValue, "schema", "version")
 		if err != nil {
 			return version, tables, err
@@ -99,27 +268,20 @@ func (p *SQLProvider) upgrade() error {
 	if version < storageSchemaCurrentVersion {
 		p.log.Debugf("Storage schema is v%d, latest is v%d", version, storageSchemaCurrentVersion)
 
-		tx, err := p.db.Begin()
-		if err != nil {
-			return err
-		}
-
-		switch version {
-		case 0:
-			err := p.upgradeSchemaToVersion001(tx, tables)
-			if err != nil {
-				return p.handleUpgradeFailure(tx, 1, err)
+		for attempt := 1; ; attempt++ {
+			err = p.upgradeSchema(version, tables)
+			if err == nil {
+				break
 			}
 
-			fallthrough
-		default:
-			err := tx.Commit()
-			if err != nil {
+			if p.isRetryableError == nil || !p.isRetryableError(err) || attempt >= storageSchemaUpgradeMaxAttempts {
 				return err
 			}
 
-			p.log.Infof("Storage schema upgrade to v%d completed", storageSchemaCurrentVersion)
+			p.log.Warnf("Storage schema upgrade attempt %d of %d failed with a retryable error: %v", attempt, storageSchemaUpgradeMaxAttempts, err)
 		}
+
+		p.log.Infof("Storage schema upgrade to v%d completed", storageSchemaCurrentVersion)
 	} else {
 		p.log.Debug("Storage schema is up to date")
 	}
@@ -127,9 +289,25 @@ func (p *SQLProvider) upgrade() error {
 	return nil
 }
 
+// upgradeSchema upgrades the schema from the provided version to the current version in a single transaction.
+func (p *SQLProvider) upgradeSchema(version SchemaVersion, tables []string) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for next := version + 1; next <= storageSchemaCurrentVersion; next++ {
+		if err = p.upgradeSchemaToVersion(tx, next, tables); err != nil {
+			return p.handleUpgradeFailure(tx, next, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
 func (p *SQLProvider) handleUpgradeFailure(tx *sql.Tx, version SchemaVersion, err error) error {
 	rollbackErr := tx.Rollback()
-	formattedErr := fmt.Errorf("%s%d: %v", storageSchemaUpgradeErrorText, version, err)
+	formattedErr := fmt.Errorf("%s%d: %w", storageSchemaUpgradeErrorText, version, err)
 
 	if rollbackErr != nil {
 		return fmt.Errorf("rollback error occurred: %v (inner error %v)", rollbackErr, formattedErr)
@@ -138,28 +316,84 @@ func (p *SQLProvider) handleUpgradeFailure(tx *sql.Tx, version SchemaVersion, er
 	return formattedErr
 }
 
+// exec executes a query on the primary database, retrying it if it fails with a transient error.
+func (p *SQLProvider) exec(query string, args ...interface{}) (result sql.Result, err error) {
+	err = p.retry(func() error {
+		result, err = p.db.Exec(query, args...)
+		return err
+	})
+
+	return result, err
+}
+
+// queryRow executes a query expected to return at most one row on the provided database and scans the row into dest,
+// retrying it if it fails with a transient error.
+func (p *SQLProvider) queryRow(db *sql.DB, query string, args []interface{}, dest ...interface{}) error {
+	return p.retry(func() error {
+		return db.QueryRow(query, args...).Scan(dest...)
+	})
+}
+
+// retry calls the function until it succeeds, fails with an error which is not transient, or the maximum number of
+// attempts is reached. The delay before each retry is chosen at random up to an exponentially increasing limit.
+func (p *SQLProvider) retry(fn func() error) (err error) {
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || attempt >= p.retryConfig.MaxAttempts || p.isRetryableError == nil || !p.isRetryableError(err) {
+			return err
+		}
+
+		backoff := p.retryBackoff(attempt)
+
+		p.log.Warnf("Storage operation failed on attempt %d of %d with a transient error, retrying in %s: %v", attempt, p.retryConfig.MaxAttempts, backoff, err)
+
+		time.Sleep(backoff)
+	}
+}
+
+// retryBackoff returns a random delay between zero and the backoff doubled for every previous attempt, without
+// exceeding the maximum backoff.
+func (p *SQLProvider) retryBackoff(attempt int) time.Duration {
+	limit := p.retryConfig.Backoff
+
+	for i := 1; i < attempt && limit < p.retryConfig.MaxBackoff; i++ {
+		limit *= 2
+	}
+
+	if limit > p.retryConfig.MaxBackoff {
+		limit = p.retryConfig.MaxBackoff
+	}
+
+	if limit <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(limit)+1)) * time.Millisecond //nolint:gosec // Jitter does not need to be cryptographically secure.
+}
+
+// isConnectionError returns true if the error is caused by a broken connection to the database which the driver
+// detected before sending the statement. The connection errors raised while the statement is in flight, such as an
+// unexpected EOF or a connection reset, are not included as the statement may have been executed by the database and
+// retrying a statement which is not idempotent, such as appending an authentication log, would apply it twice.
+func isConnectionError(err error) bool {
+	return errors.Is(err, driver.ErrBadConn)
+}
+
 // LoadPreferred2FAMethod load the preferred method for 2FA from the database.
 func (p *SQLProvider) LoadPreferred2FAMethod(username string) (string, error) {
 	var method string
 
-	rows, err := p.db.Query(p.sqlGetPreferencesByUsername, username)
-	if err != nil {
-		return "", err
-	}
-	defer rows.Close()
-
-	if !rows.Next() {
+	err := p.queryRow(p.db, p.sqlGetPreferencesByUsername, []interface{}{username}, &method)
+	if err == sql.ErrNoRows {
 		return "", nil
 	}
 
-	err = rows.Scan(&method)
-
 	return method, err
 }
 
 // SavePreferred2FAMethod save the preferred method for 2FA to the database.
 func (p *SQLProvider) SavePreferred2FAMethod(username string, method string) error {
-	_, err := p.db.Exec(p.sqlUpsertSecondFactorPreference, username, method)
+	_, err := p.exec(p.sqlUpsertSecondFactorPreference, username, method)
 	return err
 }
 
@@ -167,7 +401,7 @@ func (p *SQLProvider) SavePreferred2FAMethod(username string, method string) err
 func (p *SQLProvider) FindIdentityVerificationToken(token string) (bool, error) {
 	var found bool
 
-	err := p.db.QueryRow(p.sqlTestIdentityVerificationTokenExistence, token).Scan(&found)
+	err := p.queryRow(p.db, p.sqlTestIdentityVerificationTokenExistence, []interface{}{token}, &found)
 	if err != nil {
 		return false, err
 	}
@@ -177,45 +411,159 @@ func (p *SQLProvider) FindIdentityVerificationToken(token string) (bool, error)
 
 // SaveIdentityVerificationToken save an identity verification token in the database.
 func (p *SQLProvider) SaveIdentityVerificationToken(token string) error {
-	_, err := p.db.Exec(p.sqlInsertIdentityVerificationToken, token)
+	_, err := p.exec(p.sqlInsertIdentityVerificationToken, token)
 	return err
 }
 
 // RemoveIdentityVerificationToken remove an identity verification token from the database.
 func (p *SQLProvider) RemoveIdentityVerificationToken(token string) error {
-	_, err := p.db.Exec(p.sqlDeleteIdentityVerificationToken, token)
+	_, err := p.exec(p.sqlDeleteIdentityVerificationToken, token)
 	return err
 }
 
-// SaveTOTPSecret save a TOTP secret of a given user in the database.
-func (p *SQLProvider) SaveTOTPSecret(username string, secret string) error {
-	_, err := p.db.Exec(p.sqlUpsertTOTPSecret, username, secret)
+// SaveTOTPConfiguration save the TOTP secret of a given user and the parameters of its one-time passwords in the
+// database.
+func (p *SQLProvider) SaveTOTPConfiguration(configuration models.TOTPConfiguration) error {
+	_, err := p.exec(p.sqlUpsertTOTPConfiguration, configuration.Username, configuration.Secret,
+		totpConfigurationAlgorithm(configuration), totpConfigurationDigits(configuration), configuration.Period,
+		totpConfigurationLastUsed(configuration), nullString(configuration.LastUsedIP), nullString(configuration.LastUsedCountry))
+
 	return err
 }
 
-// LoadTOTPSecret load a TOTP secret given a username from the database.
-func (p *SQLProvider) LoadTOTPSecret(username string) (string, error) {
-	var secret string
-	if err := p.db.QueryRow(p.sqlGetTOTPSecretByUsername, username).Scan(&secret); err != nil {
+// LoadTOTPConfiguration load the TOTP secret and the parameters of its one-time passwords given a username from the
+// database.
+func (p *SQLProvider) LoadTOTPConfiguration(username string) (*models.TOTPConfiguration, error) {
+	var (
+		configuration models.TOTPConfiguration
+		columns       totpConfigurationColumns
+	)
+
+	err := p.queryRow(p.db, p.sqlGetTOTPConfigurationByUsername, []interface{}{username}, columns.dest(&configuration)...)
+	if err != nil {
 		if err == sql.ErrNoRows {
-			return "", ErrNoTOTPSecret
+			return nil, ErrNoTOTPSecret
 		}
 
-		return "", err
+		return nil, err
+	}
+
+	columns.decode(&configuration)
+
+	return &configuration, nil
+}
+
+// UpdateTOTPConfigurationSignIn updates the time, the IP address and the country of the last sign in with the TOTP
+// secret of the user.
+func (p *SQLProvider) UpdateTOTPConfigurationSignIn(configuration models.TOTPConfiguration) error {
+	_, err := p.exec(p.sqlUpdateTOTPConfigurationSignIn, totpConfigurationLastUsed(configuration),
+		nullString(configuration.LastUsedIP), nullString(configuration.LastUsedCountry), configuration.Username)
+
+	return err
+}
+
+// totpConfigurationAlgorithm returns the algorithm of the TOTP configuration to persist, SHA1 if it's empty.
+func totpConfigurationAlgorithm(configuration models.TOTPConfiguration) string {
+	if configuration.Algorithm == "" {
+		return models.TOTPAlgorithmSHA1
+	}
+
+	return configuration.Algorithm
+}
+
+// totpConfigurationDigits returns the number of digits of the TOTP configuration to persist, 6 if it's zero.
+func totpConfigurationDigits(configuration models.TOTPConfiguration) int {
+	if configuration.Digits == 0 {
+		return 6
+	}
+
+	return configuration.Digits
+}
+
+// totpConfigurationLastUsed returns the time of the last sign in with the TOTP secret, NULL if it was never used.
+func totpConfigurationLastUsed(configuration models.TOTPConfiguration) sql.NullInt64 {
+	if configuration.LastUsed == nil {
+		return sql.NullInt64{}
 	}
 
-	return secret, nil
+	return sql.NullInt64{Int64: configuration.LastUsed.Unix(), Valid: true}
+}
+
+// nullString returns the value to persist in a nullable column, NULL if it's empty.
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// totpConfigurationColumns are the nullable columns of a TOTP configuration.
+type totpConfigurationColumns struct {
+	lastUsed                    sql.NullInt64
+	lastUsedIP, lastUsedCountry sql.NullString
+}
+
+// dest returns the destinations of the columns selected by the TOTP configuration queries.
+func (c *totpConfigurationColumns) dest(configuration *models.TOTPConfiguration) []interface{} {
+	return []interface{}{&configuration.Username, &configuration.Secret, &configuration.Algorithm, &configuration.Digits,
+		&configuration.Period, &c.lastUsed, &c.lastUsedIP, &c.lastUsedCountry}
+}
+
+// decode decodes the nullable columns of a TOTP configuration.
+func (c *totpConfigurationColumns) decode(configuration *models.TOTPConfiguration) {
+	configuration.LastUsedIP = c.lastUsedIP.String
+	configuration.LastUsedCountry = c.lastUsedCountry.String
+
+	if c.lastUsed.Valid {
+		lastUsed := time.Unix(c.lastUsed.Int64, 0)
+		configuration.LastUsed = &lastUsed
+	}
 }
 
 // DeleteTOTPSecret delete a TOTP secret from the database given a username.
 func (p *SQLProvider) DeleteTOTPSecret(username string) error {
-	_, err := p.db.Exec(p.sqlDeleteTOTPSecret, username)
+	_, err := p.exec(p.sqlDeleteTOTPSecret, username)
+	return err
+}
+
+// AppendTOTPHistory records a change of the TOTP secret of a user.
+func (p *SQLProvider) AppendTOTPHistory(entry models.TOTPHistoryEntry) error {
+	_, err := p.exec(p.sqlInsertTOTPHistory, entry.Username, entry.Event, entry.RemoteIP, entry.Time.Unix())
 	return err
 }
 
+// LoadTOTPHistory retrieve the changes of the TOTP secret of a user, the most recent first.
+func (p *SQLProvider) LoadTOTPHistory(username string) ([]models.TOTPHistoryEntry, error) {
+	var entries []models.TOTPHistoryEntry
+
+	err := p.retry(func() error {
+		rows, err := p.db.Query(p.sqlGetTOTPHistoryByUsername, username)
+		if err != nil {
+			return err
+		}
+
+		defer rows.Close()
+
+		entries = make([]models.TOTPHistoryEntry, 0, 2)
+
+		for rows.Next() {
+			entry, err := scanTOTPHistoryEntry(rows)
+			if err != nil {
+				return err
+			}
+
+			entries = append(entries, entry)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
 // SaveU2FDeviceHandle save a registered U2F device registration blob.
 func (p *SQLProvider) SaveU2FDeviceHandle(username string, keyHandle []byte, publicKey []byte) error {
-	_, err := p.db.Exec(p.sqlUpsertU2FDeviceHandle,
+	_, err := p.exec(p.sqlUpsertU2FDeviceHandle,
 		username,
 		base64.StdEncoding.EncodeToString(keyHandle),
 		base64.StdEncoding.EncodeToString(publicKey))
@@ -223,10 +571,16 @@ func (p *SQLProvider) SaveU2FDeviceHandle(username string, keyHandle []byte, pub
 	return err
 }
 
+// DeleteU2FDeviceHandle delete the U2F device registration blob of a given username.
+func (p *SQLProvider) DeleteU2FDeviceHandle(username string) error {
+	_, err := p.exec(p.sqlDeleteU2FDeviceHandle, username)
+	return err
+}
+
 // LoadU2FDeviceHandle load a U2F device registration blob for a given username.
 func (p *SQLProvider) LoadU2FDeviceHandle(username string) ([]byte, []byte, error) {
 	var keyHandleBase64, publicKeyBase64 string
-	if err := p.db.QueryRow(p.sqlGetU2FDeviceHandleByUsername, username).Scan(&keyHandleBase64, &publicKeyBase64); err != nil {
+	if err := p.queryRow(p.db, p.sqlGetU2FDeviceHandleByUsername, []interface{}{username}, &keyHandleBase64, &publicKeyBase64); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil, ErrNoU2FDeviceHandle
 		}
@@ -251,35 +605,153 @@ func (p *SQLProvider) LoadU2FDeviceHandle(username string) ([]byte, []byte, erro
 
 // AppendAuthenticationLog append a mark to the authentication log.
 func (p *SQLProvider) AppendAuthenticationLog(attempt models.AuthenticationAttempt) error {
-	_, err := p.db.Exec(p.sqlInsertAuthenticationLog, attempt.Username, attempt.Successful, attempt.Time.Unix())
+	_, err := p.exec(p.sqlInsertAuthenticationLog, attempt.Username, attempt.Successful, attempt.Time.Unix(), attempt.RemoteIP, attempt.Type)
 	return err
 }
 
 // LoadLatestAuthenticationLogs retrieve the latest marks from the authentication log.
 func (p *SQLProvider) LoadLatestAuthenticationLogs(username string, fromDate time.Time) ([]models.AuthenticationAttempt, error) {
-	var t int64
+	var attempts []models.AuthenticationAttempt
+
+	err := p.retry(func() error {
+		var t int64
 
-	rows, err := p.db.Query(p.sqlGetLatestAuthenticationLogs, fromDate.Unix(), username)
+		rows, err := p.db.Query(p.sqlGetLatestAuthenticationLogs, fromDate.Unix(), username)
+		if err != nil {
+			return err
+		}
+
+		defer rows.Close()
+
+		attempts = make([]models.AuthenticationAttempt, 0, 10)
+
+		for rows.Next() {
+			attempt := models.AuthenticationAttempt{
+				Username: username,
+			}
+			err = rows.Scan(&attempt.Successful, &t)
+			attempt.Time = time.Unix(t, 0)
+
+			if err != nil {
+				return err
+			}
 
+			attempts = append(attempts, attempt)
+		}
+
+		return rows.Err()
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	attempts := make([]models.AuthenticationAttempt, 0, 10)
+	return attempts, nil
+}
 
-	for rows.Next() {
-		attempt := models.AuthenticationAttempt{
-			Username: username,
+// LoadAuthenticationLogs retrieve the marks from the authentication log matching the filter, the most recent first,
+// along with the cursor of the next page which is empty on the last page.
+func (p *SQLProvider) LoadAuthenticationLogs(filter AuthenticationLogsFilter) (attempts []models.AuthenticationAttempt, next string, err error) {
+	var (
+		from, to int64 = 0, math.MaxInt32
+		limit          = math.MaxInt32
+	)
+
+	if !filter.From.IsZero() {
+		from = filter.From.Unix()
+	}
+
+	if !filter.To.IsZero() {
+		to = filter.To.Unix()
+	}
+
+	if filter.Limit > 0 {
+		limit = filter.Limit + 1
+	}
+
+	if from, to, err = pageBounds(from, to, filter.Cursor); err != nil {
+		return nil, "", err
+	}
+
+	if attempts, err = p.loadAuthenticationLogs(filter, from, to, limit); err != nil {
+		return nil, "", err
+	}
+
+	keep, next, whole := pageByTime(len(attempts), filter.Limit, func(i int) int64 { return attempts[i].Time.Unix() })
+	if whole {
+		last := attempts[len(attempts)-1].Time.Unix()
+
+		if attempts, err = p.loadAuthenticationLogs(filter, last, last, math.MaxInt32); err != nil {
+			return nil, "", err
 		}
-		err = rows.Scan(&attempt.Successful, &t)
-		attempt.Time = time.Unix(t, 0)
 
+		return attempts, next, nil
+	}
+
+	return attempts[:keep], next, nil
+}
+
+func (p *SQLProvider) loadAuthenticationLogs(filter AuthenticationLogsFilter, from, to int64, limit int) (attempts []models.AuthenticationAttempt, err error) {
+	var successful bool
+
+	if filter.Successful != nil {
+		successful = *filter.Successful
+	}
+
+	err = p.retry(func() error {
+		rows, err := p.dbRead().Query(p.sqlGetAuthenticationLogs,
+			filter.Username, filter.Username, filter.RemoteIP, filter.RemoteIP, filter.Type, filter.Type,
+			successful, filter.Successful == nil, from, to, limit)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		attempts = append(attempts, attempt)
+		defer rows.Close()
+
+		attempts = make([]models.AuthenticationAttempt, 0, 10)
+
+		for rows.Next() {
+			attempt, err := scanAuthenticationAttempt(rows)
+			if err != nil {
+				return err
+			}
+
+			attempts = append(attempts, attempt)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return attempts, nil
 }
+
+func scanAuthenticationAttempt(rows *sql.Rows) (attempt models.AuthenticationAttempt, err error) {
+	var (
+		remoteIP, authType sql.NullString
+		t                  int64
+	)
+
+	if err = rows.Scan(&attempt.Username, &attempt.Successful, &t, &remoteIP, &authType); err != nil {
+		return attempt, err
+	}
+
+	attempt.Time = time.Unix(t, 0)
+	attempt.RemoteIP = remoteIP.String
+	attempt.Type = authType.String
+
+	return attempt, nil
+}
+
+func scanTOTPHistoryEntry(rows *sql.Rows) (entry models.TOTPHistoryEntry, err error) {
+	var t int64
+
+	if err = rows.Scan(&entry.Username, &entry.Event, &entry.RemoteIP, &t); err != nil {
+		return entry, err
+	}
+
+	entry.Time = time.Unix(t, 0)
+
+	return entry, nil
+}
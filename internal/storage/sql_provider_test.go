@@ -1,22 +1,31 @@
 package storage
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"encoding/base64"
 	"fmt"
+	"io"
+	"net"
 	"sort"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+	"github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/logging"
 	"github.com/authelia/authelia/internal/models"
 )
 
-const currentSchemaMockSchemaVersion = "1"
+const currentSchemaMockSchemaVersion = "21"
 
 func TestSQLInitializeDatabase(t *testing.T) {
 	provider, mock := NewSQLMockProvider()
@@ -50,6 +59,27 @@ func TestSQLInitializeDatabase(t *testing.T) {
 		WithArgs("schema", "version", "1").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	expectSchemaUpgradeToVersion002(mock)
+	expectSchemaUpgradeToVersion003(mock)
+	expectSchemaUpgradeToVersion004(mock)
+	expectSchemaUpgradeToVersion005(mock)
+	expectSchemaUpgradeToVersion006(mock)
+	expectSchemaUpgradeToVersion007(mock)
+	expectSchemaUpgradeToVersion008(mock)
+	expectSchemaUpgradeToVersion009(mock)
+	expectSchemaUpgradeToVersion010(mock)
+	expectSchemaUpgradeToVersion011(mock)
+	expectSchemaUpgradeToVersion012(mock)
+	expectSchemaUpgradeToVersion013(mock)
+	expectSchemaUpgradeToVersion014(mock)
+	expectSchemaUpgradeToVersion015(mock)
+	expectSchemaUpgradeToVersion016(mock)
+	expectSchemaUpgradeToVersion017(mock)
+	expectSchemaUpgradeToVersion018(mock)
+	expectSchemaUpgradeToVersion019(mock)
+	expectSchemaUpgradeToVersion020(mock)
+	expectSchemaUpgradeToVersion021(mock)
+
 	mock.ExpectCommit()
 
 	err := provider.initialize(provider.db)
@@ -83,12 +113,480 @@ func TestSQLUpgradeDatabase(t *testing.T) {
 		WithArgs("schema", "version", "1").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	expectSchemaUpgradeToVersion002(mock)
+	expectSchemaUpgradeToVersion003(mock)
+	expectSchemaUpgradeToVersion004(mock)
+	expectSchemaUpgradeToVersion005(mock)
+	expectSchemaUpgradeToVersion006(mock)
+	expectSchemaUpgradeToVersion007(mock)
+	expectSchemaUpgradeToVersion008(mock)
+	expectSchemaUpgradeToVersion009(mock)
+	expectSchemaUpgradeToVersion010(mock)
+	expectSchemaUpgradeToVersion011(mock)
+	expectSchemaUpgradeToVersion012(mock)
+	expectSchemaUpgradeToVersion013(mock)
+	expectSchemaUpgradeToVersion014(mock)
+	expectSchemaUpgradeToVersion015(mock)
+	expectSchemaUpgradeToVersion016(mock)
+	expectSchemaUpgradeToVersion017(mock)
+	expectSchemaUpgradeToVersion018(mock)
+	expectSchemaUpgradeToVersion019(mock)
+	expectSchemaUpgradeToVersion020(mock)
+	expectSchemaUpgradeToVersion021(mock)
+
 	mock.ExpectCommit()
 
 	err := provider.initialize(provider.db)
 	assert.NoError(t, err)
 }
 
+func TestSQLUpgradeDatabaseFromVersion001(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+
+	mock.ExpectQuery(
+		"SELECT name FROM sqlite_master WHERE type='table'").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).
+			AddRow(userPreferencesTableName).
+			AddRow(identityVerificationTokensTableName).
+			AddRow(totpSecretsTableName).
+			AddRow(u2fDeviceHandlesTableName).
+			AddRow(authenticationLogsTableName).
+			AddRow(configTableName))
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT value FROM %s WHERE category=\\? AND key_name=\\?", configTableName)).
+		WithArgs("schema", "version").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).
+			AddRow("1"))
+
+	mock.ExpectBegin()
+
+	expectSchemaUpgradeToVersion002(mock)
+	expectSchemaUpgradeToVersion003(mock)
+	expectSchemaUpgradeToVersion004(mock)
+	expectSchemaUpgradeToVersion005(mock)
+	expectSchemaUpgradeToVersion006(mock)
+	expectSchemaUpgradeToVersion007(mock)
+	expectSchemaUpgradeToVersion008(mock)
+	expectSchemaUpgradeToVersion009(mock)
+	expectSchemaUpgradeToVersion010(mock)
+	expectSchemaUpgradeToVersion011(mock)
+	expectSchemaUpgradeToVersion012(mock)
+	expectSchemaUpgradeToVersion013(mock)
+	expectSchemaUpgradeToVersion014(mock)
+	expectSchemaUpgradeToVersion015(mock)
+	expectSchemaUpgradeToVersion016(mock)
+	expectSchemaUpgradeToVersion017(mock)
+	expectSchemaUpgradeToVersion018(mock)
+	expectSchemaUpgradeToVersion019(mock)
+	expectSchemaUpgradeToVersion020(mock)
+	expectSchemaUpgradeToVersion021(mock)
+
+	mock.ExpectCommit()
+
+	err := provider.initialize(provider.db)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func expectSchemaUpgradeToVersion002(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", auditEventsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS audit_usr_time_idx ON %s .*", auditEventsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS audit_time_idx ON %s .*", auditEventsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion003(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN remote_ip .*", authenticationLogsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN auth_type .*", authenticationLogsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS remote_ip_time_idx ON %s .*", authenticationLogsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "3").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion004(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", totpHistoryTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS totp_history_usr_time_idx ON %s .*", totpHistoryTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "4").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion005(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", bannedUsersTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS banned_users_usr_time_idx ON %s .*", bannedUsersTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "5").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion006(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", apiKeysTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS api_keys_hash_idx ON %s .*", apiKeysTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "6").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion007(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", webAuthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS webauthn_credentials_id_idx ON %s .*", webAuthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS webauthn_credentials_usr_idx ON %s .*", webAuthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "7").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion008(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN description .*", webAuthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN last_used .*", webAuthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "8").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion009(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN last_used_ip .*", webAuthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN clone_warning .*", webAuthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "9").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion010(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN backup_eligible .*", webAuthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN backup_state .*", webAuthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "10").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion011(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN aaguid .*", webAuthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "11").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion012(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN algorithm .*", totpSecretsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN digits .*", totpSecretsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN period .*", totpSecretsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "12").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion013(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", recoveryCodesTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS recovery_codes_usr_idx ON %s .*", recoveryCodesTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "13").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion014(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", trustedDevicesTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS trusted_devices_hash_idx ON %s .*", trustedDevicesTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS trusted_devices_usr_idx ON %s .*", trustedDevicesTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "14").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion015(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", hotpDevicesTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", yubiKeyOTPDevicesTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS yubikey_otp_devices_usr_idx ON %s .*", yubiKeyOTPDevicesTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "15").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion016(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", secondFactorEnrollmentsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "16").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion017(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", userDomainPreferencesTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "17").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion018(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN chain_index .*", auditEventsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN chain_hash .*", auditEventsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS audit_chain_idx ON %s .*", auditEventsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "18").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion019(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN deleted_at .*", webAuthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "19").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion020(mock sqlmock.Sqlmock) {
+	for _, column := range []string{"last_used", "last_used_ip", "last_used_country"} {
+		mock.ExpectExec(
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s .*", totpSecretsTableName, column)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	mock.ExpectExec(
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN last_used_country .*", webAuthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "20").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectSchemaUpgradeToVersion021(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(
+		fmt.Sprintf("DELETE FROM %s WHERE deleted_at IS NOT NULL AND credential_id IN .*", webAuthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS webauthn_credentials_id_uniq_idx ON %s .*", webAuthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "21").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func TestSQLUpgradeDatabaseShouldRetryRetryableErrors(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+	provider.isRetryableError = isPostgreSQLRetryableError
+
+	mock.ExpectQuery(
+		"SELECT name FROM sqlite_master WHERE type='table'").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).
+			AddRow(userPreferencesTableName).
+			AddRow(identityVerificationTokensTableName).
+			AddRow(totpSecretsTableName).
+			AddRow(u2fDeviceHandlesTableName).
+			AddRow(authenticationLogsTableName))
+
+	for _, commitErr := range []error{&pgconn.PgError{Code: postgreSQLErrorCodeSerializationFailure}, nil} {
+		mock.ExpectBegin()
+
+		mock.ExpectExec(
+			fmt.Sprintf("CREATE TABLE %s .*", configTableName)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		mock.ExpectExec(
+			fmt.Sprintf("CREATE INDEX IF NOT EXISTS usr_time_idx ON %s .*", authenticationLogsTableName)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		mock.ExpectExec(
+			fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+			WithArgs("schema", "version", "1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		expectSchemaUpgradeToVersion002(mock)
+		expectSchemaUpgradeToVersion003(mock)
+		expectSchemaUpgradeToVersion004(mock)
+		expectSchemaUpgradeToVersion005(mock)
+		expectSchemaUpgradeToVersion006(mock)
+		expectSchemaUpgradeToVersion007(mock)
+		expectSchemaUpgradeToVersion008(mock)
+		expectSchemaUpgradeToVersion009(mock)
+		expectSchemaUpgradeToVersion010(mock)
+		expectSchemaUpgradeToVersion011(mock)
+		expectSchemaUpgradeToVersion012(mock)
+		expectSchemaUpgradeToVersion013(mock)
+		expectSchemaUpgradeToVersion014(mock)
+		expectSchemaUpgradeToVersion015(mock)
+		expectSchemaUpgradeToVersion016(mock)
+		expectSchemaUpgradeToVersion017(mock)
+		expectSchemaUpgradeToVersion018(mock)
+		expectSchemaUpgradeToVersion019(mock)
+		expectSchemaUpgradeToVersion020(mock)
+		expectSchemaUpgradeToVersion021(mock)
+
+		mock.ExpectCommit().WillReturnError(commitErr)
+	}
+
+	err := provider.initialize(provider.db)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLUpgradeDatabaseShouldNotRetryOtherErrors(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+	provider.isRetryableError = isPostgreSQLRetryableError
+
+	mock.ExpectQuery(
+		"SELECT name FROM sqlite_master WHERE type='table'").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).
+			AddRow(userPreferencesTableName).
+			AddRow(identityVerificationTokensTableName).
+			AddRow(totpSecretsTableName).
+			AddRow(u2fDeviceHandlesTableName).
+			AddRow(authenticationLogsTableName))
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", configTableName)).
+		WillReturnError(&pgconn.PgError{Severity: "ERROR", Code: "42P07", Message: "relation already exists"})
+
+	mock.ExpectRollback()
+
+	err := provider.initialize(provider.db)
+	assert.EqualError(t, err, "storage schema upgrade failed at v1: Unable to create table config: ERROR: relation already exists (SQLSTATE 42P07)")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestSQLProviderMethodsAuthenticationLogs(t *testing.T) {
 	provider, mock := NewSQLMockProvider()
 
@@ -107,7 +605,7 @@ func TestSQLProviderMethodsAuthenticationLogs(t *testing.T) {
 		fmt.Sprintf("SELECT value FROM %s WHERE category=\\? AND key_name=\\?", configTableName)).
 		WithArgs(args...).
 		WillReturnRows(sqlmock.NewRows([]string{"value"}).
-			AddRow("1"))
+			AddRow(currentSchemaMockSchemaVersion))
 
 	err := provider.initialize(provider.db)
 	assert.NoError(t, err)
@@ -121,9 +619,9 @@ func TestSQLProviderMethodsAuthenticationLogs(t *testing.T) {
 	rows := sqlmock.NewRows([]string{"successful", "time"})
 
 	for id, attempt := range attempts {
-		args = []driver.Value{attempt.Username, attempt.Successful, attempt.Time.Unix()}
+		args = []driver.Value{attempt.Username, attempt.Successful, attempt.Time.Unix(), attempt.RemoteIP, attempt.Type}
 		mock.ExpectExec(
-			fmt.Sprintf("INSERT INTO %s \\(username, successful, time\\) VALUES \\(\\?, \\?, \\?\\)", authenticationLogsTableName)).
+			fmt.Sprintf("INSERT INTO %s \\(username, successful, time, remote_ip, auth_type\\) VALUES \\(\\?, \\?, \\?, \\?, \\?\\)", authenticationLogsTableName)).
 			WithArgs(args...).
 			WillReturnResult(sqlmock.NewResult(int64(id), 1))
 
@@ -238,24 +736,36 @@ func TestSQLProviderMethodsTOTP(t *testing.T) {
 	assert.NoError(t, err)
 
 	pretendSecret := "abc123"
-	args = []driver.Value{unitTestUser, pretendSecret}
+	args = []driver.Value{unitTestUser, pretendSecret, "SHA1", 6, 0, nil, nil, nil}
 	mock.ExpectExec(
-		fmt.Sprintf("REPLACE INTO %s \\(username, secret\\) VALUES \\(\\?, \\?\\)", totpSecretsTableName)).
+		fmt.Sprintf("REPLACE INTO %s \\(username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?\\)", totpSecretsTableName)).
 		WithArgs(args...).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	err = provider.SaveTOTPSecret(unitTestUser, pretendSecret)
+	err = provider.SaveTOTPConfiguration(models.TOTPConfiguration{Username: unitTestUser, Secret: pretendSecret})
 	assert.NoError(t, err)
 
 	args = []driver.Value{unitTestUser}
 	mock.ExpectQuery(
-		fmt.Sprintf("SELECT secret FROM %s WHERE username=\\?", totpSecretsTableName)).
+		fmt.Sprintf("SELECT username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country FROM %s WHERE username=\\?", totpSecretsTableName)).
 		WithArgs(args...).
-		WillReturnRows(sqlmock.NewRows([]string{"secret"}).AddRow(pretendSecret))
+		WillReturnRows(sqlmock.NewRows([]string{"username", "secret", "algorithm", "digits", "period", "last_used", "last_used_ip", "last_used_country"}).
+			AddRow(unitTestUser, pretendSecret, "SHA256", 8, 60, 1620000000, "1.0.0.1", "AU"))
+
+	configuration, err := provider.LoadTOTPConfiguration(unitTestUser)
+	assert.NoError(t, err)
+
+	lastUsed := time.Unix(1620000000, 0)
+	assert.Equal(t, &models.TOTPConfiguration{Username: unitTestUser, Secret: pretendSecret, Algorithm: "SHA256", Digits: 8, Period: 60,
+		LastUsed: &lastUsed, LastUsedIP: "1.0.0.1", LastUsedCountry: "AU"}, configuration)
+
+	mock.ExpectExec(
+		fmt.Sprintf("UPDATE %s SET last_used=\\?, last_used_ip=\\?, last_used_country=\\? WHERE username=\\?", totpSecretsTableName)).
+		WithArgs(lastUsed.Unix(), "1.0.0.1", "AU", unitTestUser).
+		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	secret, err := provider.LoadTOTPSecret(unitTestUser)
+	err = provider.UpdateTOTPConfigurationSignIn(*configuration)
 	assert.NoError(t, err)
-	assert.Equal(t, pretendSecret, secret)
 
 	mock.ExpectExec(
 		fmt.Sprintf("DELETE FROM %s WHERE username=\\?", totpSecretsTableName)).
@@ -266,14 +776,14 @@ func TestSQLProviderMethodsTOTP(t *testing.T) {
 	assert.NoError(t, err)
 
 	mock.ExpectQuery(
-		fmt.Sprintf("SELECT secret FROM %s WHERE username=\\?", totpSecretsTableName)).
+		fmt.Sprintf("SELECT username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country FROM %s WHERE username=\\?", totpSecretsTableName)).
 		WithArgs(args...).
-		WillReturnRows(sqlmock.NewRows([]string{"secret"}))
+		WillReturnRows(sqlmock.NewRows([]string{"username", "secret", "algorithm", "digits", "period", "last_used", "last_used_ip", "last_used_country"}))
 
 	// Test Blank Rows
-	secret, err = provider.LoadTOTPSecret(unitTestUser)
+	configuration, err = provider.LoadTOTPConfiguration(unitTestUser)
 	assert.EqualError(t, err, "No TOTP secret registered")
-	assert.Equal(t, "", secret)
+	assert.Nil(t, configuration)
 }
 
 func TestSQLProviderMethodsU2F(t *testing.T) {
@@ -398,3 +908,112 @@ func TestSQLProviderMethodsIdentityVerificationTokens(t *testing.T) {
 	assert.NoError(t, err)
 	assert.False(t, valid)
 }
+
+func TestSQLProviderShouldUseReadReplicasForReportingQueries(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+
+	replicaDB, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	provider.dbReplicas = append(provider.dbReplicas, replicaDB)
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT second_factor_method FROM %s WHERE username=\\?", userPreferencesTableName)).
+		WithArgs(unitTestUser).
+		WillReturnRows(sqlmock.NewRows([]string{"second_factor_method"}).AddRow("totp"))
+
+	method, err := provider.LoadPreferred2FAMethod(unitTestUser)
+	assert.NoError(t, err)
+	assert.Equal(t, authentication.TOTP, method)
+
+	from := time.Unix(1577880000, 0)
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT successful, time FROM %s WHERE time>\\?.*", authenticationLogsTableName)).
+		WithArgs(from.Unix(), unitTestUser).
+		WillReturnRows(sqlmock.NewRows([]string{"successful", "time"}))
+
+	_, err = provider.LoadLatestAuthenticationLogs(unitTestUser, from)
+	assert.NoError(t, err)
+
+	replicaMock.ExpectQuery(
+		fmt.Sprintf("SELECT username, successful, time, remote_ip, auth_type FROM %s .*", authenticationLogsTableName)).
+		WillReturnRows(sqlmock.NewRows([]string{"username", "successful", "time", "remote_ip", "auth_type"}))
+
+	_, _, err = provider.LoadAuthenticationLogs(AuthenticationLogsFilter{Username: unitTestUser})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestSQLProviderShouldRetryTransientErrors(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+	provider.log = logging.Logger()
+	provider.isRetryableError = isSQLiteRetryableError
+	provider.retryConfig = schema.StorageRetryConfiguration{MaxAttempts: 3}
+
+	query := fmt.Sprintf("REPLACE INTO %s \\(username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?\\)", totpSecretsTableName)
+	configuration := models.TOTPConfiguration{Username: unitTestUser, Secret: "abc"}
+
+	mock.ExpectExec(query).
+		WithArgs(unitTestUser, "abc", "SHA1", 6, 0, nil, nil, nil).
+		WillReturnError(sqlite3.Error{Code: sqlite3.ErrBusy})
+
+	mock.ExpectExec(query).
+		WithArgs(unitTestUser, "abc", "SHA1", 6, 0, nil, nil, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := provider.SaveTOTPConfiguration(configuration)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		mock.ExpectExec(query).
+			WithArgs(unitTestUser, "abc", "SHA1", 6, 0, nil, nil, nil).
+			WillReturnError(sqlite3.Error{Code: sqlite3.ErrLocked})
+	}
+
+	err = provider.SaveTOTPConfiguration(configuration)
+	assert.Equal(t, sqlite3.Error{Code: sqlite3.ErrLocked}, err)
+
+	mock.ExpectExec(query).
+		WithArgs(unitTestUser, "abc", "SHA1", 6, 0, nil, nil, nil).
+		WillReturnError(sqlite3.Error{Code: sqlite3.ErrReadonly})
+
+	err = provider.SaveTOTPConfiguration(configuration)
+	assert.Equal(t, sqlite3.Error{Code: sqlite3.ErrReadonly}, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLProviderRetryBackoffShouldNotExceedLimit(t *testing.T) {
+	provider, _ := NewSQLMockProvider()
+	provider.retryConfig = schema.StorageRetryConfiguration{MaxAttempts: 5, Backoff: 100, MaxBackoff: 300}
+
+	for i := 0; i < 100; i++ {
+		assert.LessOrEqual(t, int64(provider.retryBackoff(1)), int64(100*time.Millisecond))
+		assert.LessOrEqual(t, int64(provider.retryBackoff(2)), int64(200*time.Millisecond))
+		assert.LessOrEqual(t, int64(provider.retryBackoff(5)), int64(300*time.Millisecond))
+	}
+}
+
+func TestShouldDetectRetryableErrors(t *testing.T) {
+	assert.True(t, isPostgreSQLRetryableError(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, isPostgreSQLRetryableError(&pgconn.PgError{Code: "40P01"}))
+	assert.True(t, isPostgreSQLRetryableError(&pgconn.PgError{Code: "08006"}))
+	assert.True(t, isPostgreSQLRetryableError(fmt.Errorf("wrapped: %w", driver.ErrBadConn)))
+	assert.False(t, isPostgreSQLRetryableError(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, isPostgreSQLRetryableError(io.ErrUnexpectedEOF))
+	assert.False(t, isPostgreSQLRetryableError(syscall.ECONNRESET))
+	assert.False(t, isPostgreSQLRetryableError(&net.OpError{Op: "read", Err: syscall.ECONNRESET}))
+
+	assert.True(t, isMySQLRetryableError(&mysql.MySQLError{Number: 1213}))
+	assert.True(t, isMySQLRetryableError(&mysql.MySQLError{Number: 1205}))
+	assert.True(t, isMySQLRetryableError(driver.ErrBadConn))
+	assert.False(t, isMySQLRetryableError(mysql.ErrInvalidConn))
+	assert.False(t, isMySQLRetryableError(&mysql.MySQLError{Number: 1062}))
+
+	assert.True(t, isSQLiteRetryableError(sqlite3.Error{Code: sqlite3.ErrBusy}))
+	assert.False(t, isSQLiteRetryableError(sqlite3.Error{Code: sqlite3.ErrConstraint}))
+	assert.False(t, isSQLiteRetryableError(sql.ErrNoRows))
+}
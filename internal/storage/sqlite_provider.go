@@ -2,9 +2,15 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
 
-	_ "github.com/mattn/go-sqlite3" // Load the SQLite Driver used in the connection string.
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
 )
 
 // SQLiteProvider is a SQLite3 provider.
@@ -13,46 +19,189 @@ type SQLiteProvider struct {
 }
 
 // NewSQLiteProvider constructs a SQLite provider.
-func NewSQLiteProvider(path string) *SQLiteProvider {
+func NewSQLiteProvider(configuration schema.StorageConfiguration) *SQLiteProvider {
+	tablePrefix := configuration.TablePrefix
+
 	provider := SQLiteProvider{
 		SQLProvider{
-			name: "sqlite",
+			name:        "sqlite",
+			tablePrefix: tablePrefix,
 
 			sqlUpgradesCreateTableStatements:        sqlUpgradeCreateTableStatements,
+			sqlUpgradesAlterTableStatements:         sqlUpgradesAlterTableStatements,
 			sqlUpgradesCreateTableIndexesStatements: sqlUpgradesCreateTableIndexesStatements,
 
-			sqlGetPreferencesByUsername:     fmt.Sprintf("SELECT second_factor_method FROM %s WHERE username=?", userPreferencesTableName),
-			sqlUpsertSecondFactorPreference: fmt.Sprintf("REPLACE INTO %s (username, second_factor_method) VALUES (?, ?)", userPreferencesTableName),
+			sqlGetPreferencesByUsername:     fmt.Sprintf("SELECT second_factor_method FROM %s WHERE username=?", tablePrefix+userPreferencesTableName),
+			sqlUpsertSecondFactorPreference: fmt.Sprintf("REPLACE INTO %s (username, second_factor_method) VALUES (?, ?)", tablePrefix+userPreferencesTableName),
+
+			sqlTestIdentityVerificationTokenExistence: fmt.Sprintf("SELECT EXISTS (SELECT * FROM %s WHERE token=?)", tablePrefix+identityVerificationTokensTableName),
+			sqlInsertIdentityVerificationToken:        fmt.Sprintf("INSERT INTO %s (token) VALUES (?)", tablePrefix+identityVerificationTokensTableName),
+			sqlDeleteIdentityVerificationToken:        fmt.Sprintf("DELETE FROM %s WHERE token=?", tablePrefix+identityVerificationTokensTableName),
+
+			sqlGetTOTPConfigurationByUsername: fmt.Sprintf("SELECT username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country FROM %s WHERE username=?", tablePrefix+totpSecretsTableName),
+			sqlUpsertTOTPConfiguration:        fmt.Sprintf("REPLACE INTO %s (username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", tablePrefix+totpSecretsTableName),
+			sqlUpdateTOTPConfigurationSignIn:  fmt.Sprintf("UPDATE %s SET last_used=?, last_used_ip=?, last_used_country=? WHERE username=?", tablePrefix+totpSecretsTableName),
+			sqlDeleteTOTPSecret:               fmt.Sprintf("DELETE FROM %s WHERE username=?", tablePrefix+totpSecretsTableName),
+
+			sqlInsertTOTPHistory:        fmt.Sprintf("INSERT INTO %s (username, event, remote_ip, time) VALUES (?, ?, ?, ?)", tablePrefix+totpHistoryTableName),
+			sqlGetTOTPHistoryByUsername: fmt.Sprintf("SELECT username, event, remote_ip, time FROM %s WHERE username=? ORDER BY time DESC", tablePrefix+totpHistoryTableName),
+
+			sqlGetU2FDeviceHandleByUsername: fmt.Sprintf("SELECT keyHandle, publicKey FROM %s WHERE username=?", tablePrefix+u2fDeviceHandlesTableName),
+			sqlUpsertU2FDeviceHandle:        fmt.Sprintf("REPLACE INTO %s (username, keyHandle, publicKey) VALUES (?, ?, ?)", tablePrefix+u2fDeviceHandlesTableName),
+			sqlDeleteU2FDeviceHandle:        fmt.Sprintf("DELETE FROM %s WHERE username=?", tablePrefix+u2fDeviceHandlesTableName),
+
+			sqlInsertAuthenticationLog:                fmt.Sprintf("INSERT INTO %s (username, successful, time, remote_ip, auth_type) VALUES (?, ?, ?, ?, ?)", tablePrefix+authenticationLogsTableName),
+			sqlGetLatestAuthenticationLogs:            fmt.Sprintf("SELECT successful, time FROM %s WHERE time>? AND username=? ORDER BY time DESC", tablePrefix+authenticationLogsTableName),
+			sqlGetAuthenticationLogs:                  fmt.Sprintf("SELECT username, successful, time, remote_ip, auth_type FROM %s WHERE (username=? OR ?='') AND (remote_ip=? OR ?='') AND (auth_type=? OR ?='') AND (successful=? OR ?) AND time>=? AND time<=? ORDER BY time DESC LIMIT ?", tablePrefix+authenticationLogsTableName),
+			sqlDeleteAuthenticationLogsOlderThan:      fmt.Sprintf("DELETE FROM %s WHERE time<?", tablePrefix+authenticationLogsTableName),
+			sqlDeleteAuthenticationLogsExceedingCount: fmt.Sprintf("DELETE FROM %[1]s WHERE time<(SELECT time FROM %[1]s ORDER BY time DESC LIMIT 1 OFFSET ?)", tablePrefix+authenticationLogsTableName),
+
+			sqlInsertAuditEvent:          fmt.Sprintf("INSERT INTO %s (username, event, remote_ip, details, time, chain_index, chain_hash) VALUES (?, ?, ?, ?, ?, ?, ?)", tablePrefix+auditEventsTableName),
+			sqlSelectAuditEvents:         fmt.Sprintf("SELECT username, event, remote_ip, details, time, chain_index, chain_hash FROM %s WHERE (username=? OR ?='') AND (event=? OR ?='') AND time>=? AND time<=? ORDER BY time DESC, chain_index DESC LIMIT ?", tablePrefix+auditEventsTableName),
+			sqlSelectAuditChainHead:      fmt.Sprintf("SELECT chain_index, chain_hash FROM %s WHERE chain_index IS NOT NULL ORDER BY chain_index DESC LIMIT 1", tablePrefix+auditEventsTableName),
+			sqlSelectAuditChain:          fmt.Sprintf("SELECT username, event, remote_ip, details, time, chain_index, chain_hash FROM %s WHERE chain_index IS NOT NULL ORDER BY chain_index", tablePrefix+auditEventsTableName),
+			sqlCountUnchainedAuditEvents: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE chain_index IS NULL AND time>=?", tablePrefix+auditEventsTableName),
+
+			sqlInsertBan:        fmt.Sprintf("INSERT INTO %s (username, reason, time, expires, revoked) VALUES (?, ?, ?, ?, ?)", tablePrefix+bannedUsersTableName),
+			sqlSelectActiveBans: fmt.Sprintf("SELECT username, reason, time, expires, revoked FROM %s WHERE (username=? OR ?='') AND revoked=FALSE AND (expires IS NULL OR expires>?) ORDER BY time DESC", tablePrefix+bannedUsersTableName),
+			sqlRevokeBans:       fmt.Sprintf("UPDATE %s SET revoked=TRUE WHERE username=? AND revoked=FALSE AND (expires IS NULL OR expires>?)", tablePrefix+bannedUsersTableName),
+
+			sqlInsertAPIKey:        fmt.Sprintf("INSERT INTO %s (name, key_hash, key_groups, time, revoked) VALUES (?, ?, ?, ?, ?)", tablePrefix+apiKeysTableName),
+			sqlSelectActiveAPIKey:  fmt.Sprintf("SELECT name, key_hash, key_groups, time, revoked FROM %s WHERE key_hash=? AND revoked=FALSE", tablePrefix+apiKeysTableName),
+			sqlSelectActiveAPIKeys: fmt.Sprintf("SELECT name, key_hash, key_groups, time, revoked FROM %s WHERE revoked=FALSE ORDER BY time", tablePrefix+apiKeysTableName),
+			sqlRevokeAPIKeys:       fmt.Sprintf("UPDATE %s SET revoked=TRUE WHERE name=? AND revoked=FALSE", tablePrefix+apiKeysTableName),
 
-			sqlTestIdentityVerificationTokenExistence: fmt.Sprintf("SELECT EXISTS (SELECT * FROM %s WHERE token=?)", identityVerificationTokensTableName),
-			sqlInsertIdentityVerificationToken:        fmt.Sprintf("INSERT INTO %s (token) VALUES (?)", identityVerificationTokensTableName),
-			sqlDeleteIdentityVerificationToken:        fmt.Sprintf("DELETE FROM %s WHERE token=?", identityVerificationTokensTableName),
+			sqlInsertWebAuthnCredential:               fmt.Sprintf("INSERT INTO %s (username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", tablePrefix+webAuthnCredentialsTableName),
+			sqlSelectWebAuthnCredentialByID:           fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s WHERE credential_id=? AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlSelectWebAuthnCredentialsByUser:        fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s WHERE username=? AND deleted_at IS NULL ORDER BY time", tablePrefix+webAuthnCredentialsTableName),
+			sqlSelectDeletedWebAuthnCredentialsByUser: fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s WHERE username=? AND deleted_at IS NOT NULL ORDER BY deleted_at", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialUsage:          fmt.Sprintf("UPDATE %s SET sign_count=?, last_used=?, last_used_ip=?, last_used_country=?, backup_eligible=?, backup_state=? WHERE credential_id=? AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialDescription:    fmt.Sprintf("UPDATE %s SET description=? WHERE username=? AND credential_id=? AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialCloneWarning:   fmt.Sprintf("UPDATE %s SET clone_warning=? WHERE credential_id=? AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialDeleted:        fmt.Sprintf("UPDATE %s SET deleted_at=? WHERE username=? AND credential_id=? AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialsDeletedByUser: fmt.Sprintf("UPDATE %s SET deleted_at=? WHERE username=? AND deleted_at IS NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialRestored:       fmt.Sprintf("UPDATE %s SET deleted_at=NULL WHERE username=? AND credential_id=? AND deleted_at IS NOT NULL", tablePrefix+webAuthnCredentialsTableName),
+			sqlDeleteWebAuthnCredentialsDeletedBefore: fmt.Sprintf("DELETE FROM %s WHERE deleted_at IS NOT NULL AND deleted_at<?", tablePrefix+webAuthnCredentialsTableName),
+			sqlDeleteDeletedWebAuthnCredentialByID:    fmt.Sprintf("DELETE FROM %s WHERE credential_id=? AND deleted_at IS NOT NULL", tablePrefix+webAuthnCredentialsTableName),
 
-			sqlGetTOTPSecretByUsername: fmt.Sprintf("SELECT secret FROM %s WHERE username=?", totpSecretsTableName),
-			sqlUpsertTOTPSecret:        fmt.Sprintf("REPLACE INTO %s (username, secret) VALUES (?, ?)", totpSecretsTableName),
-			sqlDeleteTOTPSecret:        fmt.Sprintf("DELETE FROM %s WHERE username=?", totpSecretsTableName),
+			sqlInsertRecoveryCode:  fmt.Sprintf("INSERT INTO %s (username, code_hash, time, used) VALUES (?, ?, ?, ?)", tablePrefix+recoveryCodesTableName),
+			sqlCountRecoveryCodes:  fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE username=? AND used=FALSE", tablePrefix+recoveryCodesTableName),
+			sqlConsumeRecoveryCode: fmt.Sprintf("UPDATE %s SET used=TRUE WHERE username=? AND code_hash=? AND used=FALSE", tablePrefix+recoveryCodesTableName),
+			sqlDeleteRecoveryCodes: fmt.Sprintf("DELETE FROM %s WHERE username=?", tablePrefix+recoveryCodesTableName),
 
-			sqlGetU2FDeviceHandleByUsername: fmt.Sprintf("SELECT keyHandle, publicKey FROM %s WHERE username=?", u2fDeviceHandlesTableName),
-			sqlUpsertU2FDeviceHandle:        fmt.Sprintf("REPLACE INTO %s (username, keyHandle, publicKey) VALUES (?, ?, ?)", u2fDeviceHandlesTableName),
+			sqlInsertTrustedDevice:        fmt.Sprintf("INSERT INTO %s (username, device_id, token_hash, description, remote_ip, time, expires) VALUES (?, ?, ?, ?, ?, ?, ?)", tablePrefix+trustedDevicesTableName),
+			sqlSelectTrustedDeviceByHash:  fmt.Sprintf("SELECT username, device_id, token_hash, description, remote_ip, time, expires FROM %s WHERE token_hash=? AND expires>?", tablePrefix+trustedDevicesTableName),
+			sqlSelectTrustedDevicesByUser: fmt.Sprintf("SELECT username, device_id, token_hash, description, remote_ip, time, expires FROM %s WHERE username=? AND expires>? ORDER BY time", tablePrefix+trustedDevicesTableName),
+			sqlDeleteTrustedDevice:        fmt.Sprintf("DELETE FROM %s WHERE username=? AND device_id=?", tablePrefix+trustedDevicesTableName),
 
-			sqlInsertAuthenticationLog:     fmt.Sprintf("INSERT INTO %s (username, successful, time) VALUES (?, ?, ?)", authenticationLogsTableName),
-			sqlGetLatestAuthenticationLogs: fmt.Sprintf("SELECT successful, time FROM %s WHERE time>? AND username=? ORDER BY time DESC", authenticationLogsTableName),
+			sqlUpsertHOTPDevice:       fmt.Sprintf("REPLACE INTO %s (username, secret, counter, digits) VALUES (?, ?, ?, ?)", tablePrefix+hotpDevicesTableName),
+			sqlSelectHOTPDeviceByUser: fmt.Sprintf("SELECT username, secret, counter, digits FROM %s WHERE username=?", tablePrefix+hotpDevicesTableName),
+			sqlUpdateHOTPCounter:      fmt.Sprintf("UPDATE %s SET counter=? WHERE username=? AND counter=?", tablePrefix+hotpDevicesTableName),
+			sqlDeleteHOTPDevice:       fmt.Sprintf("DELETE FROM %s WHERE username=?", tablePrefix+hotpDevicesTableName),
+
+			sqlInsertYubiKeyOTPDevice:        fmt.Sprintf("INSERT INTO %s (username, public_id, time) VALUES (?, ?, ?)", tablePrefix+yubiKeyOTPDevicesTableName),
+			sqlSelectYubiKeyOTPDevicesByUser: fmt.Sprintf("SELECT username, public_id, time FROM %s WHERE username=? ORDER BY time", tablePrefix+yubiKeyOTPDevicesTableName),
+			sqlDeleteYubiKeyOTPDevice:        fmt.Sprintf("DELETE FROM %s WHERE username=? AND public_id=?", tablePrefix+yubiKeyOTPDevicesTableName),
+			sqlDeleteYubiKeyOTPDevicesByUser: fmt.Sprintf("DELETE FROM %s WHERE username=?", tablePrefix+yubiKeyOTPDevicesTableName),
+
+			sqlSelectSecondFactorEnrollmentStart: fmt.Sprintf("SELECT time FROM %s WHERE username=?", tablePrefix+secondFactorEnrollmentsTableName),
+			sqlInsertSecondFactorEnrollmentStart: fmt.Sprintf("INSERT OR IGNORE INTO %s (username, time) VALUES (?, ?)", tablePrefix+secondFactorEnrollmentsTableName),
+
+			sqlSelectDomainPreferenceByUser:  fmt.Sprintf("SELECT second_factor_method FROM %s WHERE username=? AND domain=?", tablePrefix+userDomainPreferencesTableName),
+			sqlSelectDomainPreferencesByUser: fmt.Sprintf("SELECT username, domain, second_factor_method FROM %s WHERE username=? ORDER BY domain", tablePrefix+userDomainPreferencesTableName),
+			sqlUpsertDomainPreference:        fmt.Sprintf("REPLACE INTO %s (username, domain, second_factor_method) VALUES (?, ?, ?)", tablePrefix+userDomainPreferencesTableName),
+
+			sqlSelectUserPreferences:            fmt.Sprintf("SELECT username, second_factor_method FROM %s", tablePrefix+userPreferencesTableName),
+			sqlSelectIdentityVerificationTokens: fmt.Sprintf("SELECT token FROM %s", tablePrefix+identityVerificationTokensTableName),
+			sqlSelectTOTPSecrets:                fmt.Sprintf("SELECT username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country FROM %s", tablePrefix+totpSecretsTableName),
+			sqlSelectU2FDeviceHandles:           fmt.Sprintf("SELECT username, keyHandle, publicKey FROM %s", tablePrefix+u2fDeviceHandlesTableName),
+			sqlSelectAuthenticationLogs:         fmt.Sprintf("SELECT username, successful, time, remote_ip, auth_type FROM %s ORDER BY time", tablePrefix+authenticationLogsTableName),
+			sqlSelectAllAuditEvents:             fmt.Sprintf("SELECT username, event, remote_ip, details, time, chain_index, chain_hash FROM %s ORDER BY time, chain_index", tablePrefix+auditEventsTableName),
+			sqlSelectTOTPHistory:                fmt.Sprintf("SELECT username, event, remote_ip, time FROM %s ORDER BY time", tablePrefix+totpHistoryTableName),
+			sqlSelectBans:                       fmt.Sprintf("SELECT username, reason, time, expires, revoked FROM %s ORDER BY time", tablePrefix+bannedUsersTableName),
+			sqlSelectAPIKeys:                    fmt.Sprintf("SELECT name, key_hash, key_groups, time, revoked FROM %s ORDER BY time", tablePrefix+apiKeysTableName),
+			sqlSelectWebAuthnCredentials:        fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s ORDER BY time", tablePrefix+webAuthnCredentialsTableName),
+			sqlSelectRecoveryCodes:              fmt.Sprintf("SELECT username, code_hash, time, used FROM %s ORDER BY time", tablePrefix+recoveryCodesTableName),
+			sqlSelectTrustedDevices:             fmt.Sprintf("SELECT username, device_id, token_hash, description, remote_ip, time, expires FROM %s ORDER BY time", tablePrefix+trustedDevicesTableName),
+			sqlSelectHOTPDevices:                fmt.Sprintf("SELECT username, secret, counter, digits FROM %s ORDER BY username", tablePrefix+hotpDevicesTableName),
+			sqlSelectYubiKeyOTPDevices:          fmt.Sprintf("SELECT username, public_id, time FROM %s ORDER BY time", tablePrefix+yubiKeyOTPDevicesTableName),
+			sqlSelectSecondFactorEnrollments:    fmt.Sprintf("SELECT username, time FROM %s ORDER BY username", tablePrefix+secondFactorEnrollmentsTableName),
+			sqlSelectDomainPreferences:          fmt.Sprintf("SELECT username, domain, second_factor_method FROM %s ORDER BY username, domain", tablePrefix+userDomainPreferencesTableName),
 
 			sqlGetExistingTables: "SELECT name FROM sqlite_master WHERE type='table'",
 
-			sqlConfigSetValue: fmt.Sprintf("REPLACE INTO %s (category, key_name, value) VALUES (?, ?, ?)", configTableName),
-			sqlConfigGetValue: fmt.Sprintf("SELECT value FROM %s WHERE category=? AND key_name=?", configTableName),
+			sqlMaintenanceStatements: []string{"VACUUM", "ANALYZE"},
+
+			sqlConfigSetValue: fmt.Sprintf("REPLACE INTO %s (category, key_name, value) VALUES (?, ?, ?)", tablePrefix+configTableName),
+			sqlConfigGetValue: fmt.Sprintf("SELECT value FROM %s WHERE category=? AND key_name=?", tablePrefix+configTableName),
+
+			isRetryableError: isSQLiteRetryableError,
+			retryConfig:      configuration.Retry,
+
+			authenticationLogsConfig:  configuration.AuthenticationLogs,
+			webAuthnCredentialsConfig: configuration.WebAuthnCredentials,
+			maintenanceConfig:         configuration.Maintenance,
+
+			upgradeDisabled: configuration.DisableSchemaUpgrade,
 		},
 	}
 
-	db, err := sql.Open("sqlite3", path)
+	path := configuration.Local.Path
+
+	db, err := sql.Open("sqlite3", sqliteConnectionString(*configuration.Local))
 	if err != nil {
 		provider.log.Fatalf("Unable to create SQL database %s: %s", path, err)
 	}
 
+	if path == sqliteInMemoryPath {
+		// Every connection to an in-memory database has its own database, so a single connection must be used and
+		// kept open for the lifetime of the provider.
+		db.SetMaxOpenConns(1)
+		db.SetConnMaxLifetime(0)
+		db.SetConnMaxIdleTime(0)
+	}
+
 	if err := provider.initialize(db); err != nil {
 		provider.log.Fatalf("Unable to initialize SQL database %s: %s", path, err)
 	}
 
 	return &provider
 }
+
+// sqliteConnectionString returns the connection string of the SQLite database at the configured path, with the
+// configured pragmas as parameters of the driver.
+func sqliteConnectionString(configuration schema.LocalStorageConfiguration) string {
+	params := url.Values{}
+
+	if configuration.JournalMode != "" {
+		params.Set("_journal_mode", configuration.JournalMode)
+	}
+
+	if configuration.BusyTimeout != "" {
+		if busyTimeout, err := utils.ParseDurationString(configuration.BusyTimeout); err == nil {
+			params.Set("_busy_timeout", strconv.FormatInt(busyTimeout.Milliseconds(), 10))
+		}
+	}
+
+	if configuration.Synchronous != "" {
+		params.Set("_synchronous", configuration.Synchronous)
+	}
+
+	if len(params) == 0 {
+		return configuration.Path
+	}
+
+	return configuration.Path + "?" + params.Encode()
+}
+
+// isSQLiteRetryableError returns true if the error is caused by the database or a table being locked by another
+// connection.
+func isSQLiteRetryableError(err error) bool {
+	var sqliteErr sqlite3.Error
+
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+
+	return false
+}
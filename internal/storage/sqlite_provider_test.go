@@ -0,0 +1,1167 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/models"
+)
+
+func TestSQLiteProviderShouldPrefixTables(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local:       &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+		TablePrefix: "authelia_",
+	})
+
+	_, tables, err := provider.getSchemaBasicDetails()
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		"authelia_api_keys",
+		"authelia_audit_events",
+		"authelia_authentication_logs",
+		"authelia_banned_users",
+		"authelia_config",
+		"authelia_hotp_devices",
+		"authelia_recovery_codes",
+		"authelia_second_factor_enrollments",
+		"authelia_user_domain_preferences",
+		"authelia_trusted_devices",
+		"authelia_identity_verification_tokens",
+		"authelia_totp_history",
+		"authelia_totp_secrets",
+		"authelia_u2f_devices",
+		"authelia_user_preferences",
+		"authelia_webauthn_credentials",
+		"authelia_yubikey_otp_devices",
+	}, tables)
+
+	var index string
+
+	err = provider.db.QueryRow("SELECT name FROM sqlite_master WHERE type='index' AND tbl_name='authelia_authentication_logs'").Scan(&index)
+	require.NoError(t, err)
+	assert.Equal(t, "authelia_usr_time_idx", index)
+
+	require.NoError(t, provider.SavePreferred2FAMethod(unitTestUser, authentication.TOTP))
+
+	method, err := provider.LoadPreferred2FAMethod(unitTestUser)
+	assert.NoError(t, err)
+	assert.Equal(t, authentication.TOTP, method)
+
+	version, _, err := provider.getSchemaBasicDetails()
+	assert.NoError(t, err)
+	assert.Equal(t, storageSchemaCurrentVersion, version)
+}
+
+func TestSQLiteProviderShouldExportAndImport(t *testing.T) {
+	dir := t.TempDir()
+
+	source := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(dir, "source.sqlite3")},
+	})
+
+	require.NoError(t, source.SavePreferred2FAMethod(unitTestUser, authentication.TOTP))
+	require.NoError(t, source.SaveDomainPreferred2FAMethod(unitTestUser, "admin.example.com", authentication.U2F))
+	require.NoError(t, source.SaveIdentityVerificationToken("abc"))
+	require.NoError(t, source.SaveTOTPConfiguration(models.TOTPConfiguration{Username: unitTestUser, Secret: "secret", Algorithm: models.TOTPAlgorithmSHA256, Digits: 8, Period: 60}))
+	require.NoError(t, source.AppendTOTPHistory(models.TOTPHistoryEntry{Username: unitTestUser, Event: models.TOTPHistoryEventCreated, RemoteIP: "127.0.0.1", Time: time.Unix(1577880000, 0)}))
+	require.NoError(t, source.SaveU2FDeviceHandle(unitTestUser, []byte("handle"), []byte("key")))
+	require.NoError(t, source.AppendAuthenticationLog(models.AuthenticationAttempt{Username: unitTestUser, Successful: true, Time: time.Unix(1577880001, 0)}))
+	require.NoError(t, source.AppendAuthenticationLog(models.AuthenticationAttempt{Username: unitTestUser, Successful: false, Time: time.Unix(1577880002, 0)}))
+	require.NoError(t, source.AppendAuditEvent(models.AuditEvent{Username: unitTestUser, Type: models.AuditEventTOTPRegistered, RemoteIP: "127.0.0.1", Time: time.Unix(1577880003, 0)}))
+
+	export, err := source.Export()
+	require.NoError(t, err)
+
+	assert.Equal(t, storageSchemaCurrentVersion, export.SchemaVersion)
+	assert.Equal(t, []ExportUserPreferences{{Username: unitTestUser, SecondFactorMethod: authentication.TOTP}}, export.UserPreferences)
+	assert.Equal(t, []models.DomainPreference{{Username: unitTestUser, Domain: "admin.example.com", SecondFactorMethod: authentication.U2F}}, export.DomainPreferences)
+	assert.Equal(t, []string{"abc"}, export.IdentityVerificationTokens)
+	assert.Equal(t, []models.TOTPConfiguration{{Username: unitTestUser, Secret: "secret", Algorithm: models.TOTPAlgorithmSHA256, Digits: 8, Period: 60}}, export.TOTPSecrets)
+	assert.Equal(t, []ExportU2FDevice{{Username: unitTestUser, KeyHandle: []byte("handle"), PublicKey: []byte("key")}}, export.U2FDevices)
+	require.Len(t, export.AuthenticationLogs, 2)
+	require.Len(t, export.AuditEvents, 1)
+	require.Len(t, export.TOTPHistory, 1)
+
+	data, err := json.Marshal(export)
+	require.NoError(t, err)
+
+	decoded := &Export{}
+	require.NoError(t, json.Unmarshal(data, decoded))
+
+	destination := NewSQLiteProvider(schema.StorageConfiguration{
+		Local:       &schema.LocalStorageConfiguration{Path: filepath.Join(dir, "destination.sqlite3")},
+		TablePrefix: "authelia_",
+	})
+
+	require.NoError(t, destination.Import(decoded))
+
+	imported, err := destination.Export()
+	require.NoError(t, err)
+
+	assert.Nil(t, imported.AuditEvents)
+
+	imported.AuditEvents = export.AuditEvents
+	assert.Equal(t, export, imported)
+
+	decoded.SchemaVersion = 0
+	assert.EqualError(t, destination.Import(decoded), fmt.Sprintf("unable to import data from schema version 0 into schema version %d", storageSchemaCurrentVersion))
+}
+
+func TestSQLiteProviderShouldExportUser(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	require.NoError(t, provider.SavePreferred2FAMethod(unitTestUser, authentication.TOTP))
+	require.NoError(t, provider.SaveDomainPreferred2FAMethod(unitTestUser, "admin.example.com", authentication.U2F))
+	require.NoError(t, provider.SaveTOTPConfiguration(models.TOTPConfiguration{Username: unitTestUser, Secret: "secret"}))
+	require.NoError(t, provider.SaveU2FDeviceHandle(unitTestUser, []byte("handle"), []byte("key")))
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: unitTestUser, ID: []byte("id"), UserHandle: []byte("handle"), PublicKey: []byte("key"), Time: time.Unix(1577880000, 0)}))
+	require.NoError(t, provider.AppendAuthenticationLog(models.AuthenticationAttempt{Username: unitTestUser, Successful: true, Time: time.Unix(1577880001, 0)}))
+	require.NoError(t, provider.AppendAuthenticationLog(models.AuthenticationAttempt{Username: "other", Successful: true, Time: time.Unix(1577880002, 0)}))
+
+	export, err := provider.ExportUser(unitTestUser)
+	require.NoError(t, err)
+
+	assert.Equal(t, &UserExport{
+		Username:           unitTestUser,
+		SecondFactorMethod: authentication.TOTP,
+		DomainPreferences:  []models.DomainPreference{{Username: unitTestUser, Domain: "admin.example.com", SecondFactorMethod: authentication.U2F}},
+		TOTPConfiguration:  &models.TOTPConfiguration{Username: unitTestUser, Secret: "secret", Algorithm: models.TOTPAlgorithmSHA1, Digits: 6},
+		TOTPHistory:        []models.TOTPHistoryEntry{},
+		U2FDevice:          &ExportU2FDevice{Username: unitTestUser, KeyHandle: []byte("handle"), PublicKey: []byte("key")},
+		WebAuthnCredentials: []models.WebAuthnCredential{
+			{Username: unitTestUser, ID: []byte("id"), UserHandle: []byte("handle"), PublicKey: []byte("key"), Time: time.Unix(1577880000, 0)},
+		},
+		DeletedWebAuthnCredentials: []models.WebAuthnCredential{},
+		TrustedDevices:             []models.TrustedDevice{},
+		YubiKeyOTPDevices:          []models.YubiKeyOTPDevice{},
+		AuthenticationLogs:         []models.AuthenticationAttempt{{Username: unitTestUser, Successful: true, Time: time.Unix(1577880001, 0)}},
+		AuditEvents:                []models.AuditEvent{},
+	}, export)
+
+	export, err = provider.ExportUser("nobody")
+	require.NoError(t, err)
+
+	assert.Equal(t, &UserExport{Username: "nobody", DomainPreferences: []models.DomainPreference{}, TOTPHistory: []models.TOTPHistoryEntry{}, WebAuthnCredentials: []models.WebAuthnCredential{},
+		DeletedWebAuthnCredentials: []models.WebAuthnCredential{}, TrustedDevices: []models.TrustedDevice{}, YubiKeyOTPDevices: []models.YubiKeyOTPDevice{}, AuthenticationLogs: []models.AuthenticationAttempt{},
+		AuditEvents: []models.AuditEvent{}}, export)
+}
+
+func TestSQLiteProviderShouldPruneAuthenticationLogs(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	_, err := provider.PruneAuthenticationLogs()
+	assert.Equal(t, ErrNoAuthenticationLogsRetention, err)
+
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, provider.AppendAuthenticationLog(models.AuthenticationAttempt{Username: unitTestUser, Successful: true, Time: now.Add(-time.Duration(i) * time.Hour)}))
+	}
+
+	provider.authenticationLogsConfig.MaxAge = "3h"
+
+	deleted, err := provider.PruneAuthenticationLogs()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	provider.authenticationLogsConfig.MaxAge = ""
+	provider.authenticationLogsConfig.MaxCount = 2
+
+	deleted, err = provider.PruneAuthenticationLogs()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	attempts, err := provider.LoadLatestAuthenticationLogs(unitTestUser, time.Unix(0, 0))
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, now.Unix(), attempts[0].Time.Unix())
+	assert.Equal(t, now.Add(-time.Hour).Unix(), attempts[1].Time.Unix())
+}
+
+func TestSQLiteProviderShouldSoftDeleteWebAuthnCredentials(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	now := time.Now()
+
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: unitTestUser, ID: []byte("kept"), Time: now}))
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: unitTestUser, ID: []byte("deleted"), Time: now}))
+
+	deleted, err := provider.DeleteWebAuthnCredential(unitTestUser, []byte("deleted"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	credentials, err := provider.LoadWebAuthnCredentials(unitTestUser)
+	require.NoError(t, err)
+	require.Len(t, credentials, 1)
+	assert.Equal(t, []byte("kept"), credentials[0].ID)
+
+	_, err = provider.LoadWebAuthnCredential([]byte("deleted"))
+	assert.Equal(t, ErrNoWebAuthnCredential, err)
+
+	updated, err := provider.UpdateWebAuthnCredentialDescription(unitTestUser, []byte("deleted"), "Phone")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), updated)
+
+	credentials, err = provider.LoadDeletedWebAuthnCredentials(unitTestUser)
+	require.NoError(t, err)
+	require.Len(t, credentials, 1)
+	assert.Equal(t, []byte("deleted"), credentials[0].ID)
+	require.NotNil(t, credentials[0].DeletedAt)
+	assert.Equal(t, now.Unix(), credentials[0].DeletedAt.Unix())
+
+	restored, err := provider.RestoreWebAuthnCredential(unitTestUser, []byte("deleted"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), restored)
+
+	_, err = provider.LoadWebAuthnCredential([]byte("deleted"))
+	assert.NoError(t, err)
+
+	restored, err = provider.RestoreWebAuthnCredential(unitTestUser, []byte("deleted"))
+	assert.Equal(t, ErrWebAuthnCredentialRegistered, err)
+	assert.Equal(t, int64(0), restored)
+
+	deleted, err = provider.DeleteWebAuthnCredentials(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	credentials, err = provider.LoadWebAuthnCredentials(unitTestUser)
+	require.NoError(t, err)
+	assert.Len(t, credentials, 0)
+
+	restored, err = provider.RestoreWebAuthnCredential("other", []byte("kept"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), restored)
+}
+
+func TestSQLiteProviderShouldRejectDuplicateWebAuthnCredentials(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	now := time.Now()
+
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: unitTestUser, ID: []byte("abc"), Time: now}))
+	assert.Equal(t, ErrWebAuthnCredentialRegistered, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: "other", ID: []byte("abc"), Time: now}))
+
+	_, err := provider.DeleteWebAuthnCredential(unitTestUser, []byte("abc"))
+	require.NoError(t, err)
+
+	// The credential deleted previously is purged when it's registered again, so it can't be restored anymore.
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: "other", ID: []byte("abc"), Time: now}))
+
+	credentials, err := provider.LoadDeletedWebAuthnCredentials(unitTestUser)
+	require.NoError(t, err)
+	assert.Len(t, credentials, 0)
+
+	credential, err := provider.LoadWebAuthnCredential([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, "other", credential.Username)
+
+	export := &Export{
+		SchemaVersion:       storageSchemaCurrentVersion,
+		WebAuthnCredentials: []models.WebAuthnCredential{{Username: unitTestUser, ID: []byte("abc"), Time: now}},
+	}
+
+	assert.EqualError(t, provider.Import(export), "unable to import the WebAuthn credentials of user john: WebAuthn credential already registered")
+
+	destination := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "destination.sqlite3")},
+	})
+
+	export.WebAuthnCredentials = append(export.WebAuthnCredentials, models.WebAuthnCredential{Username: "other", ID: []byte("abc"), Time: now, DeletedAt: &now})
+
+	assert.EqualError(t, destination.Import(export), "unable to import the WebAuthn credentials of user other: WebAuthn credential already registered")
+
+	credentials, err = destination.LoadWebAuthnCredentials(unitTestUser)
+	require.NoError(t, err)
+	assert.Len(t, credentials, 0)
+}
+
+func TestSQLiteProviderShouldPurgeDuplicateDeletedWebAuthnCredentialsOnUpgrade(t *testing.T) {
+	configuration := schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	}
+
+	provider := NewSQLiteProvider(configuration)
+
+	_, err := provider.DowngradeSchema(20)
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	for _, credential := range []models.WebAuthnCredential{
+		{Username: "other", ID: []byte("abc"), Time: now},
+		{Username: unitTestUser, ID: []byte("abc"), Time: now, DeletedAt: &now},
+		{Username: unitTestUser, ID: []byte("def"), Time: now, DeletedAt: &now},
+	} {
+		require.NoError(t, provider.saveWebAuthnCredential(provider.db, credential))
+	}
+
+	provider = NewSQLiteProvider(configuration)
+
+	version, _, err := provider.getSchemaBasicDetails()
+	require.NoError(t, err)
+	assert.Equal(t, storageSchemaCurrentVersion, version)
+
+	credentials, err := provider.LoadDeletedWebAuthnCredentials(unitTestUser)
+	require.NoError(t, err)
+	require.Len(t, credentials, 1)
+	assert.Equal(t, []byte("def"), credentials[0].ID)
+
+	credential, err := provider.LoadWebAuthnCredential([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, "other", credential.Username)
+}
+
+func TestSQLiteProviderShouldPurgeWebAuthnCredentialsDeletedBeforeTheGracePeriod(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	now := time.Now()
+	expired, recent := now.Add(-2*time.Hour), now.Add(-30*time.Minute)
+
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: unitTestUser, ID: []byte("active"), Time: now}))
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: unitTestUser, ID: []byte("expired"), Time: now, DeletedAt: &expired}))
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: unitTestUser, ID: []byte("recent"), Time: now, DeletedAt: &recent}))
+
+	provider.webAuthnCredentialsConfig.GracePeriod = "1h"
+
+	purged, err := provider.PurgeWebAuthnCredentials()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	credentials, err := provider.LoadDeletedWebAuthnCredentials(unitTestUser)
+	require.NoError(t, err)
+	require.Len(t, credentials, 1)
+	assert.Equal(t, []byte("recent"), credentials[0].ID)
+
+	credentials, err = provider.LoadWebAuthnCredentials(unitTestUser)
+	require.NoError(t, err)
+	require.Len(t, credentials, 1)
+	assert.Equal(t, []byte("active"), credentials[0].ID)
+}
+
+func TestSQLiteProviderShouldLoadActiveBans(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	now := time.Now()
+	expired, active := now.Add(-time.Hour), now.Add(time.Hour)
+
+	require.NoError(t, provider.SaveBan(models.Ban{Username: unitTestUser, Time: now.Add(-2 * time.Hour), Expires: &expired}))
+	require.NoError(t, provider.SaveBan(models.Ban{Username: unitTestUser, Time: now, Expires: &active}))
+	require.NoError(t, provider.SaveBan(models.Ban{Username: "harry", Reason: "compromised", Time: now}))
+
+	bans, err := provider.LoadActiveBans(unitTestUser)
+	require.NoError(t, err)
+	require.Len(t, bans, 1)
+	assert.Equal(t, active.Unix(), bans[0].Expires.Unix())
+
+	bans, err = provider.LoadActiveBans("")
+	require.NoError(t, err)
+	assert.Len(t, bans, 2)
+
+	revoked, err := provider.RevokeBan("harry")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), revoked)
+
+	bans, err = provider.LoadActiveBans("harry")
+	require.NoError(t, err)
+	assert.Len(t, bans, 0)
+
+	export, err := provider.Export()
+	require.NoError(t, err)
+	require.Len(t, export.Bans, 3)
+	assert.Equal(t, models.Ban{Username: "harry", Reason: "compromised", Time: time.Unix(now.Unix(), 0), Revoked: true}, export.Bans[2])
+}
+
+func TestSQLiteProviderShouldCheckHealth(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	status, err := provider.HealthCheck()
+	require.NoError(t, err)
+	assert.Equal(t, storageSchemaCurrentVersion, status.SchemaVersion)
+	assert.True(t, status.Latency > 0)
+
+	_, err = provider.exec(provider.sqlConfigSetValue, "schema", "version", "1")
+	require.NoError(t, err)
+
+	_, err = provider.HealthCheck()
+	assert.EqualError(t, err, fmt.Sprintf("the schema version is 1 but version %d is expected", storageSchemaCurrentVersion))
+}
+
+func TestSQLiteProviderShouldLoadAPIKeys(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	now := time.Unix(time.Now().Unix(), 0)
+
+	require.NoError(t, provider.SaveAPIKey(models.APIKey{Name: "webhook", Hash: "abc", Groups: []string{"ci", "deploy"}, Time: now}))
+	require.NoError(t, provider.SaveAPIKey(models.APIKey{Name: "monitoring", Hash: "def", Time: now.Add(time.Second)}))
+
+	key, err := provider.LoadAPIKey("abc")
+	require.NoError(t, err)
+	assert.Equal(t, &models.APIKey{Name: "webhook", Hash: "abc", Groups: []string{"ci", "deploy"}, Time: now}, key)
+
+	_, err = provider.LoadAPIKey("ghi")
+	assert.Equal(t, ErrNoAPIKey, err)
+
+	revoked, err := provider.RevokeAPIKey("webhook")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), revoked)
+
+	_, err = provider.LoadAPIKey("abc")
+	assert.Equal(t, ErrNoAPIKey, err)
+
+	keys, err := provider.LoadAPIKeys()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "monitoring", keys[0].Name)
+	assert.Nil(t, keys[0].Groups)
+
+	export, err := provider.Export()
+	require.NoError(t, err)
+	require.Len(t, export.APIKeys, 2)
+	assert.True(t, export.APIKeys[0].Revoked)
+}
+
+func TestSQLiteProviderShouldConsumeRecoveryCodes(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	now := time.Unix(time.Now().Unix(), 0)
+
+	require.NoError(t, provider.SaveRecoveryCodes(unitTestUser, []models.RecoveryCode{{Hash: "abc", Time: now}, {Hash: "def", Time: now}}))
+
+	count, err := provider.CountRecoveryCodes(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	consumed, err := provider.ConsumeRecoveryCode(unitTestUser, "abc")
+	require.NoError(t, err)
+	assert.True(t, consumed)
+
+	consumed, err = provider.ConsumeRecoveryCode(unitTestUser, "abc")
+	require.NoError(t, err)
+	assert.False(t, consumed)
+
+	consumed, err = provider.ConsumeRecoveryCode("harry", "def")
+	require.NoError(t, err)
+	assert.False(t, consumed)
+
+	count, err = provider.CountRecoveryCodes(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, provider.SaveRecoveryCodes(unitTestUser, []models.RecoveryCode{{Hash: "ghi", Time: now}}))
+
+	consumed, err = provider.ConsumeRecoveryCode(unitTestUser, "def")
+	require.NoError(t, err)
+	assert.False(t, consumed)
+
+	export, err := provider.Export()
+	require.NoError(t, err)
+	assert.Equal(t, []models.RecoveryCode{{Username: unitTestUser, Hash: "ghi", Time: now}}, export.RecoveryCodes)
+}
+
+func TestSQLiteProviderShouldLoadTrustedDevicesWhichHaveNotExpired(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	now := time.Unix(time.Now().Unix(), 0)
+	device := models.TrustedDevice{Username: unitTestUser, ID: "abc", TokenHash: "hash1", Description: "Firefox", RemoteIP: "10.0.0.1", Time: now, Expires: now.Add(time.Hour)}
+
+	require.NoError(t, provider.SaveTrustedDevice(device))
+	require.NoError(t, provider.SaveTrustedDevice(models.TrustedDevice{Username: unitTestUser, ID: "def", TokenHash: "hash2", Time: now.Add(-2 * time.Hour), Expires: now.Add(-time.Hour)}))
+
+	loaded, err := provider.LoadTrustedDevice("hash1")
+	require.NoError(t, err)
+	assert.Equal(t, device, *loaded)
+
+	_, err = provider.LoadTrustedDevice("hash2")
+	assert.Equal(t, ErrNoTrustedDevice, err)
+
+	devices, err := provider.LoadTrustedDevices(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, []models.TrustedDevice{device}, devices)
+
+	count, err := provider.DeleteTrustedDevice("harry", "abc")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	count, err = provider.DeleteTrustedDevice(unitTestUser, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	_, err = provider.LoadTrustedDevice("hash1")
+	assert.Equal(t, ErrNoTrustedDevice, err)
+}
+
+func TestSQLiteProviderShouldUpdateHOTPCounterOnlyFromCurrentValue(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	_, err := provider.LoadHOTPDevice(unitTestUser)
+	assert.Equal(t, ErrNoHOTPDevice, err)
+
+	require.NoError(t, provider.SaveHOTPDevice(models.HOTPDevice{Username: unitTestUser, Secret: "secret", Counter: 5, Digits: 8}))
+
+	updated, err := provider.UpdateHOTPCounter(unitTestUser, 5, 7)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	updated, err = provider.UpdateHOTPCounter(unitTestUser, 5, 6)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	device, err := provider.LoadHOTPDevice(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, models.HOTPDevice{Username: unitTestUser, Secret: "secret", Counter: 7, Digits: 8}, *device)
+
+	require.NoError(t, provider.DeleteHOTPDevice(unitTestUser))
+
+	_, err = provider.LoadHOTPDevice(unitTestUser)
+	assert.Equal(t, ErrNoHOTPDevice, err)
+}
+
+func TestSQLiteProviderShouldLoadYubiKeyOTPDevices(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	now := time.Unix(time.Now().Unix(), 0)
+	first := models.YubiKeyOTPDevice{Username: unitTestUser, PublicID: "ccccccbcgujh", Time: now.Add(-time.Hour)}
+	second := models.YubiKeyOTPDevice{Username: unitTestUser, PublicID: "cccccckdvvul", Time: now}
+
+	require.NoError(t, provider.SaveYubiKeyOTPDevice(second))
+	require.NoError(t, provider.SaveYubiKeyOTPDevice(first))
+
+	devices, err := provider.LoadYubiKeyOTPDevices(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, []models.YubiKeyOTPDevice{first, second}, devices)
+
+	count, err := provider.DeleteYubiKeyOTPDevice(unitTestUser, first.PublicID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = provider.DeleteYubiKeyOTPDevices(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	devices, err = provider.LoadYubiKeyOTPDevices(unitTestUser)
+	require.NoError(t, err)
+	assert.Empty(t, devices)
+}
+
+func TestSQLiteProviderShouldKeepFirstSecondFactorEnrollmentStart(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	now := time.Unix(time.Now().Unix(), 0)
+
+	start, err := provider.StartSecondFactorEnrollment(unitTestUser, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-time.Hour), start)
+
+	start, err = provider.StartSecondFactorEnrollment(unitTestUser, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-time.Hour), start)
+
+	start, err = provider.StartSecondFactorEnrollment("harry", now)
+	require.NoError(t, err)
+	assert.Equal(t, now, start)
+}
+
+func TestSQLiteProviderShouldSavePreferred2FAMethodPerDomain(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	method, err := provider.LoadDomainPreferred2FAMethod(unitTestUser, "admin.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "", method)
+
+	require.NoError(t, provider.SaveDomainPreferred2FAMethod(unitTestUser, "admin.example.com", "totp"))
+	require.NoError(t, provider.SaveDomainPreferred2FAMethod(unitTestUser, "admin.example.com", "u2f"))
+	require.NoError(t, provider.SaveDomainPreferred2FAMethod(unitTestUser, "app.example.com", "mobile_push"))
+
+	method, err = provider.LoadDomainPreferred2FAMethod(unitTestUser, "admin.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "u2f", method)
+
+	preferences, err := provider.LoadDomainPreferences(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, []models.DomainPreference{
+		{Username: unitTestUser, Domain: "admin.example.com", SecondFactorMethod: "u2f"},
+		{Username: unitTestUser, Domain: "app.example.com", SecondFactorMethod: "mobile_push"},
+	}, preferences)
+
+	preferences, err = provider.LoadDomainPreferences("harry")
+	require.NoError(t, err)
+	assert.Empty(t, preferences)
+}
+
+func TestSQLiteProviderShouldLoadWebAuthnCredentials(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	now := time.Unix(time.Now().Unix(), 0)
+
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: unitTestUser, ID: []byte("abc"), UserHandle: []byte("handle"), PublicKey: []byte("key"), Time: now, AAGUID: "cb69481e-8ff7-4039-93ec-0a2729a154a8"}))
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: unitTestUser, ID: []byte("def"), UserHandle: []byte("handle"), PublicKey: []byte("key"), Time: now.Add(time.Second)}))
+
+	lastUsed := now.Add(time.Minute)
+	require.NoError(t, provider.UpdateWebAuthnCredentialUsage(models.WebAuthnCredential{ID: []byte("abc"), SignCount: 10, LastUsed: &lastUsed, LastUsedIP: "192.168.1.10", LastUsedCountry: "AU", BackupEligible: true, BackupState: true}))
+	require.NoError(t, provider.SetWebAuthnCredentialCloneWarning([]byte("abc")))
+
+	credential, err := provider.LoadWebAuthnCredential([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, &models.WebAuthnCredential{Username: unitTestUser, ID: []byte("abc"), UserHandle: []byte("handle"), PublicKey: []byte("key"), SignCount: 10, Time: now, LastUsed: &lastUsed, LastUsedIP: "192.168.1.10", LastUsedCountry: "AU", CloneWarning: true, BackupEligible: true, BackupState: true, AAGUID: "cb69481e-8ff7-4039-93ec-0a2729a154a8"}, credential)
+
+	_, err = provider.LoadWebAuthnCredential([]byte("ghi"))
+	assert.Equal(t, ErrNoWebAuthnCredential, err)
+
+	credentials, err := provider.LoadWebAuthnCredentials(unitTestUser)
+	require.NoError(t, err)
+	require.Len(t, credentials, 2)
+	assert.Equal(t, []byte("def"), credentials[1].ID)
+
+	credentials, err = provider.LoadWebAuthnCredentials("other")
+	require.NoError(t, err)
+	assert.Len(t, credentials, 0)
+}
+
+func TestSQLiteProviderShouldRenameAndDeleteWebAuthnCredentials(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: unitTestUser, ID: []byte("abc"), UserHandle: []byte("handle"), PublicKey: []byte("key"), Time: time.Now()}))
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: unitTestUser, ID: []byte("def"), UserHandle: []byte("handle"), PublicKey: []byte("key"), Time: time.Now()}))
+
+	// The credentials of the other users are left untouched.
+	count, err := provider.UpdateWebAuthnCredentialDescription("other", []byte("abc"), "Phone")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	count, err = provider.UpdateWebAuthnCredentialDescription(unitTestUser, []byte("abc"), "Phone")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	credential, err := provider.LoadWebAuthnCredential([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, "Phone", credential.Description)
+	assert.Nil(t, credential.LastUsed)
+
+	count, err = provider.DeleteWebAuthnCredential("other", []byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	count, err = provider.DeleteWebAuthnCredential(unitTestUser, []byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	credentials, err := provider.LoadWebAuthnCredentials(unitTestUser)
+	require.NoError(t, err)
+	require.Len(t, credentials, 1)
+	assert.Equal(t, []byte("def"), credentials[0].ID)
+}
+
+func TestSQLiteProviderShouldRunMaintenance(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	require.NoError(t, provider.AppendAuthenticationLog(models.AuthenticationAttempt{Username: unitTestUser, Successful: true, Time: time.Now()}))
+	require.NoError(t, provider.Maintenance())
+
+	attempts, err := provider.LoadLatestAuthenticationLogs(unitTestUser, time.Unix(0, 0))
+	require.NoError(t, err)
+	assert.Len(t, attempts, 1)
+}
+
+func TestSQLiteProviderShouldLoadFilteredAuditEvents(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	events := []models.AuditEvent{
+		{Username: unitTestUser, Type: models.AuditEventTOTPRegistered, RemoteIP: "127.0.0.1", Time: time.Unix(1577880001, 0)},
+		{Username: unitTestUser, Type: models.AuditEventPasswordReset, RemoteIP: "127.0.0.1", Time: time.Unix(1577880002, 0)},
+		{Username: "harry", Type: models.AuditEventConsentGranted, RemoteIP: "::1", Details: "client_id=app scopes=openid", Time: time.Unix(1577880003, 0)},
+	}
+
+	previous := ""
+
+	for i := range events {
+		require.NoError(t, provider.AppendAuditEvent(events[i]))
+
+		events[i].Index = int64(i + 1)
+		events[i].Hash = auditEventHash(previous, events[i].Index, events[i])
+		previous = events[i].Hash
+	}
+
+	loaded, _, err := provider.LoadAuditEvents(AuditEventsFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []models.AuditEvent{events[2], events[1], events[0]}, loaded)
+
+	loaded, _, err = provider.LoadAuditEvents(AuditEventsFilter{Username: unitTestUser})
+	require.NoError(t, err)
+	assert.Equal(t, []models.AuditEvent{events[1], events[0]}, loaded)
+
+	loaded, _, err = provider.LoadAuditEvents(AuditEventsFilter{Type: models.AuditEventConsentGranted})
+	require.NoError(t, err)
+	assert.Equal(t, []models.AuditEvent{events[2]}, loaded)
+
+	loaded, _, err = provider.LoadAuditEvents(AuditEventsFilter{From: time.Unix(1577880002, 0), To: time.Unix(1577880002, 0)})
+	require.NoError(t, err)
+	assert.Equal(t, []models.AuditEvent{events[1]}, loaded)
+
+	loaded, _, err = provider.LoadAuditEvents(AuditEventsFilter{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []models.AuditEvent{events[2]}, loaded)
+}
+
+func TestSQLiteProviderShouldVerifyAuditEventsChain(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	verification, err := provider.VerifyAuditEvents()
+	require.NoError(t, err)
+	assert.Equal(t, &AuditEventsVerification{}, verification)
+
+	_, err = provider.db.Exec(fmt.Sprintf("INSERT INTO %s (username, event, remote_ip, time) VALUES (?, ?, ?, ?)", auditEventsTableName),
+		unitTestUser, models.AuditEventTOTPRegistered, "127.0.0.1", 1577880000)
+	require.NoError(t, err)
+
+	for i := int64(1); i <= 3; i++ {
+		require.NoError(t, provider.AppendAuditEvent(models.AuditEvent{Username: unitTestUser, Type: models.AuditEventPasswordReset, RemoteIP: "127.0.0.1", Time: time.Unix(1577880000+i, 0)}))
+	}
+
+	events, _, err := provider.LoadAuditEvents(AuditEventsFilter{Limit: 1})
+	require.NoError(t, err)
+
+	verification, err = provider.VerifyAuditEvents()
+	require.NoError(t, err)
+	assert.Equal(t, &AuditEventsVerification{Events: 3, Unchained: 1, Head: events[0].Hash}, verification)
+
+	_, err = provider.db.Exec(fmt.Sprintf("UPDATE %s SET remote_ip=? WHERE chain_index=?", auditEventsTableName), "10.0.0.1", 2)
+	require.NoError(t, err)
+
+	_, err = provider.VerifyAuditEvents()
+	assert.EqualError(t, err, "Audit events hash chain is broken: the audit event at position 2 doesn't match its hash")
+
+	_, err = provider.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE chain_index=?", auditEventsTableName), 2)
+	require.NoError(t, err)
+
+	_, err = provider.VerifyAuditEvents()
+	assert.EqualError(t, err, "Audit events hash chain is broken: expected the audit event at position 2 but found position 3")
+}
+
+func TestSQLiteProviderShouldDetectAuditEventsInsertedOutsideOfTheChain(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	require.NoError(t, provider.AppendAuditEvent(models.AuditEvent{Username: unitTestUser, Type: models.AuditEventPasswordReset, RemoteIP: "127.0.0.1", Time: time.Unix(1577880000, 0)}))
+
+	_, err := provider.db.Exec(fmt.Sprintf("INSERT INTO %s (username, event, remote_ip, time) VALUES (?, ?, ?, ?)", auditEventsTableName),
+		unitTestUser, models.AuditEventTOTPRegistered, "127.0.0.1", 1577880001)
+	require.NoError(t, err)
+
+	_, err = provider.VerifyAuditEvents()
+	assert.EqualError(t, err, "Audit events hash chain is broken: 1 audit events outside of the chain were recorded after it started")
+}
+
+func TestSQLiteProviderShouldLoadFilteredAuthenticationLogs(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	attempts := []models.AuthenticationAttempt{
+		{Username: unitTestUser, Successful: false, Time: time.Unix(1577880001, 0), RemoteIP: "192.168.0.1", Type: models.AuthenticationTypeFirstFactor},
+		{Username: unitTestUser, Successful: true, Time: time.Unix(1577880002, 0), RemoteIP: "192.168.0.2", Type: models.AuthenticationTypeFirstFactor},
+		{Username: "harry", Successful: false, Time: time.Unix(1577880003, 0), RemoteIP: "192.168.0.1", Type: models.AuthenticationTypeFirstFactor},
+	}
+
+	for _, attempt := range attempts {
+		require.NoError(t, provider.AppendAuthenticationLog(attempt))
+	}
+
+	successful, failed := true, false
+
+	testCases := []struct {
+		name     string
+		filter   AuthenticationLogsFilter
+		expected []models.AuthenticationAttempt
+	}{
+		{"All", AuthenticationLogsFilter{}, []models.AuthenticationAttempt{attempts[2], attempts[1], attempts[0]}},
+		{"Username", AuthenticationLogsFilter{Username: "harry"}, []models.AuthenticationAttempt{attempts[2]}},
+		{"RemoteIP", AuthenticationLogsFilter{RemoteIP: "192.168.0.1"}, []models.AuthenticationAttempt{attempts[2], attempts[0]}},
+		{"Type", AuthenticationLogsFilter{Type: "2FA"}, []models.AuthenticationAttempt{}},
+		{"Successful", AuthenticationLogsFilter{Successful: &successful}, []models.AuthenticationAttempt{attempts[1]}},
+		{"Failed", AuthenticationLogsFilter{Successful: &failed, Username: unitTestUser}, []models.AuthenticationAttempt{attempts[0]}},
+		{"TimeRange", AuthenticationLogsFilter{From: time.Unix(1577880002, 0), To: time.Unix(1577880003, 0)}, []models.AuthenticationAttempt{attempts[2], attempts[1]}},
+		{"Limit", AuthenticationLogsFilter{Limit: 2}, []models.AuthenticationAttempt{attempts[2], attempts[1]}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			loaded, _, err := provider.LoadAuthenticationLogs(tc.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, loaded)
+		})
+	}
+}
+
+func TestSQLiteProviderShouldPageAuthenticationLogs(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	attempts := []models.AuthenticationAttempt{
+		{Username: unitTestUser, Successful: false, Time: time.Unix(1577880001, 0), RemoteIP: "192.168.0.1", Type: models.AuthenticationTypeFirstFactor},
+		{Username: unitTestUser, Successful: false, Time: time.Unix(1577880002, 0), RemoteIP: "192.168.0.1", Type: models.AuthenticationTypeFirstFactor},
+		{Username: unitTestUser, Successful: false, Time: time.Unix(1577880002, 0), RemoteIP: "192.168.0.2", Type: models.AuthenticationTypeFirstFactor},
+		{Username: unitTestUser, Successful: false, Time: time.Unix(1577880002, 0), RemoteIP: "192.168.0.3", Type: models.AuthenticationTypeFirstFactor},
+		{Username: unitTestUser, Successful: true, Time: time.Unix(1577880003, 0), RemoteIP: "192.168.0.1", Type: models.AuthenticationTypeFirstFactor},
+	}
+
+	for _, attempt := range attempts {
+		require.NoError(t, provider.AppendAuthenticationLog(attempt))
+	}
+
+	var (
+		loaded []models.AuthenticationAttempt
+		pages  int
+	)
+
+	filter := AuthenticationLogsFilter{Username: unitTestUser, Limit: 2}
+
+	for {
+		page, next, err := provider.LoadAuthenticationLogs(filter)
+		require.NoError(t, err)
+
+		loaded = append(loaded, page...)
+		pages++
+
+		if next == "" {
+			break
+		}
+
+		filter.Cursor = next
+	}
+
+	assert.Equal(t, 3, pages)
+	require.Len(t, loaded, len(attempts))
+	assert.Equal(t, attempts[4], loaded[0])
+	assert.ElementsMatch(t, attempts[1:4], loaded[1:4])
+	assert.Equal(t, attempts[0], loaded[4])
+
+	_, _, err := provider.LoadAuthenticationLogs(AuthenticationLogsFilter{Cursor: "not a cursor"})
+	assert.EqualError(t, err, "Invalid cursor")
+}
+
+func TestSQLiteProviderShouldPageAuditEvents(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	for i := int64(1); i <= 5; i++ {
+		require.NoError(t, provider.AppendAuditEvent(models.AuditEvent{Username: unitTestUser, Type: models.AuditEventPasswordReset, RemoteIP: "127.0.0.1", Time: time.Unix(1577880000+i, 0)}))
+	}
+
+	events, next, err := provider.LoadAuditEvents(AuditEventsFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, int64(5), events[0].Index)
+	assert.Equal(t, int64(4), events[1].Index)
+
+	events, next, err = provider.LoadAuditEvents(AuditEventsFilter{Limit: 2, Cursor: next})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, int64(3), events[0].Index)
+	assert.Equal(t, int64(2), events[1].Index)
+
+	events, next, err = provider.LoadAuditEvents(AuditEventsFilter{Limit: 2, Cursor: next})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, int64(1), events[0].Index)
+	assert.Equal(t, "", next)
+}
+
+func TestSQLiteProviderShouldLoadTOTPHistory(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	entries := []models.TOTPHistoryEntry{
+		{Username: unitTestUser, Event: models.TOTPHistoryEventCreated, RemoteIP: "192.168.0.1", Time: time.Unix(1577880001, 0)},
+		{Username: "harry", Event: models.TOTPHistoryEventCreated, RemoteIP: "192.168.0.2", Time: time.Unix(1577880002, 0)},
+		{Username: unitTestUser, Event: models.TOTPHistoryEventReplaced, RemoteIP: "192.168.0.3", Time: time.Unix(1577880003, 0)},
+	}
+
+	for _, entry := range entries {
+		require.NoError(t, provider.AppendTOTPHistory(entry))
+	}
+
+	history, err := provider.LoadTOTPHistory(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, []models.TOTPHistoryEntry{entries[2], entries[0]}, history)
+
+	history, err = provider.LoadTOTPHistory("nobody")
+	require.NoError(t, err)
+	assert.Len(t, history, 0)
+}
+
+func TestSQLiteProviderShouldUpdateTOTPConfigurationSignIn(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	require.NoError(t, provider.SaveTOTPConfiguration(models.TOTPConfiguration{Username: unitTestUser, Secret: "secret"}))
+
+	configuration, err := provider.LoadTOTPConfiguration(unitTestUser)
+	require.NoError(t, err)
+	assert.Nil(t, configuration.LastUsed)
+	assert.Equal(t, "", configuration.LastUsedIP)
+
+	lastUsed := time.Unix(time.Now().Unix(), 0)
+	configuration.LastUsed, configuration.LastUsedIP, configuration.LastUsedCountry = &lastUsed, "192.168.1.10", "AU"
+
+	require.NoError(t, provider.UpdateTOTPConfigurationSignIn(*configuration))
+
+	configuration, err = provider.LoadTOTPConfiguration(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, &models.TOTPConfiguration{Username: unitTestUser, Secret: "secret", Algorithm: "SHA1", Digits: 6,
+		LastUsed: &lastUsed, LastUsedIP: "192.168.1.10", LastUsedCountry: "AU"}, configuration)
+}
+
+func TestSQLiteProviderShouldDowngradeSchema(t *testing.T) {
+	configuration := schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	}
+
+	provider := NewSQLiteProvider(configuration)
+
+	lastUsed := time.Unix(time.Now().Unix(), 0)
+
+	require.NoError(t, provider.SaveTOTPConfiguration(models.TOTPConfiguration{Username: unitTestUser, Secret: "secret"}))
+	require.NoError(t, provider.SaveTOTPConfiguration(models.TOTPConfiguration{Username: "other", Secret: "secret"}))
+	require.NoError(t, provider.UpdateTOTPConfigurationSignIn(models.TOTPConfiguration{Username: unitTestUser, LastUsed: &lastUsed, LastUsedIP: "192.168.1.10"}))
+	require.NoError(t, provider.SaveDomainPreferred2FAMethod(unitTestUser, "app.example.com", "totp"))
+
+	expected := []SchemaDowngrade{
+		{
+			Version: 21,
+		},
+		{
+			Version: 20,
+			Columns: []SchemaDataLoss{
+				{Table: totpSecretsTableName, Column: "last_used", Rows: 1},
+				{Table: totpSecretsTableName, Column: "last_used_ip", Rows: 1},
+				{Table: totpSecretsTableName, Column: "last_used_country", Rows: 0},
+				{Table: webAuthnCredentialsTableName, Column: "last_used_country", Rows: 0},
+			},
+		},
+		{
+			Version: 19,
+			Columns: []SchemaDataLoss{{Table: webAuthnCredentialsTableName, Column: "deleted_at", Rows: 0}},
+		},
+		{
+			Version: 18,
+			Columns: []SchemaDataLoss{
+				{Table: auditEventsTableName, Column: "chain_index", Rows: 0},
+				{Table: auditEventsTableName, Column: "chain_hash", Rows: 0},
+			},
+		},
+		{
+			Version: 17,
+			Tables:  []SchemaDataLoss{{Table: userDomainPreferencesTableName, Rows: 1}},
+		},
+	}
+
+	downgrades, err := provider.PlanSchemaDowngrade(16)
+	require.NoError(t, err)
+	assert.Equal(t, expected, downgrades)
+	assert.False(t, downgrades[0].DestroysData())
+	assert.True(t, downgrades[1].DestroysData())
+	assert.False(t, downgrades[2].DestroysData())
+
+	version, _, err := provider.getSchemaBasicDetails()
+	require.NoError(t, err)
+	assert.Equal(t, storageSchemaCurrentVersion, version)
+
+	_, err = provider.DowngradeSchema(storageSchemaCurrentVersion)
+	assert.EqualError(t, err, "the storage schema can only be downgraded from v21 to a version between v1 and v20")
+
+	downgrades, err = provider.DowngradeSchema(16)
+	require.NoError(t, err)
+	assert.Equal(t, expected, downgrades)
+
+	version, tables, err := provider.getSchemaBasicDetails()
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion(16), version)
+	assert.NotContains(t, tables, userDomainPreferencesTableName)
+
+	// The schema is upgraded again by the next start and the data which was kept is still there.
+	provider = NewSQLiteProvider(configuration)
+
+	totpConfiguration, err := provider.LoadTOTPConfiguration(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", totpConfiguration.Secret)
+	assert.Nil(t, totpConfiguration.LastUsed)
+}
+
+func TestSQLiteProviderShouldPlanSchemaUpgrade(t *testing.T) {
+	configuration := schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	}
+
+	provider := NewSQLiteProvider(configuration)
+
+	upgrades, err := provider.PlanSchemaUpgrade()
+	require.NoError(t, err)
+	assert.Len(t, upgrades, 0)
+
+	_, err = provider.DowngradeSchema(18)
+	require.NoError(t, err)
+
+	configuration.DisableSchemaUpgrade = true
+	provider = NewSQLiteProvider(configuration)
+
+	upgrades, err = provider.PlanSchemaUpgrade()
+	require.NoError(t, err)
+	require.Len(t, upgrades, 3)
+	assert.Equal(t, SchemaVersion(19), upgrades[0].Version)
+	assert.Equal(t, []string{"ALTER TABLE webauthn_credentials ADD COLUMN deleted_at INTEGER"}, upgrades[0].Statements)
+	assert.Equal(t, SchemaVersion(20), upgrades[1].Version)
+	assert.Len(t, upgrades[1].Statements, 4)
+	assert.Equal(t, SchemaVersion(21), upgrades[2].Version)
+	assert.Len(t, upgrades[2].Statements, 2)
+
+	// The statements were only planned, the schema is upgraded by the next provider which isn't disabled.
+	version, _, err := provider.getSchemaBasicDetails()
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion(18), version)
+
+	configuration.DisableSchemaUpgrade = false
+	provider = NewSQLiteProvider(configuration)
+
+	version, _, err = provider.getSchemaBasicDetails()
+	require.NoError(t, err)
+	assert.Equal(t, storageSchemaCurrentVersion, version)
+}
+
+func TestSQLiteProviderShouldKeepInMemoryDatabase(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: ":memory:"},
+	})
+
+	require.NoError(t, provider.SaveTOTPConfiguration(models.TOTPConfiguration{Username: unitTestUser, Secret: "secret"}))
+
+	configuration, err := provider.LoadTOTPConfiguration(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, &models.TOTPConfiguration{Username: unitTestUser, Secret: "secret", Algorithm: models.TOTPAlgorithmSHA1, Digits: 6}, configuration)
+
+	version, _, err := provider.getSchemaBasicDetails()
+	require.NoError(t, err)
+	assert.Equal(t, storageSchemaCurrentVersion, version)
+
+	export, err := provider.Export()
+	require.NoError(t, err)
+	assert.Equal(t, []models.TOTPConfiguration{{Username: unitTestUser, Secret: "secret", Algorithm: models.TOTPAlgorithmSHA1, Digits: 6}}, export.TOTPSecrets)
+
+	other := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: ":memory:"},
+	})
+
+	_, err = other.LoadTOTPConfiguration(unitTestUser)
+	assert.Equal(t, ErrNoTOTPSecret, err)
+}
+
+func TestSQLiteProviderShouldApplyPragmas(t *testing.T) {
+	assert.Equal(t, "/config/db.sqlite3", sqliteConnectionString(schema.LocalStorageConfiguration{Path: "/config/db.sqlite3"}))
+	assert.Equal(t, "/config/db.sqlite3?_busy_timeout=5000&_journal_mode=WAL&_synchronous=NORMAL", sqliteConnectionString(schema.LocalStorageConfiguration{
+		Path:        "/config/db.sqlite3",
+		JournalMode: "WAL",
+		BusyTimeout: "5s",
+		Synchronous: "NORMAL",
+	}))
+
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{
+			Path:        filepath.Join(t.TempDir(), "db.sqlite3"),
+			JournalMode: "WAL",
+			BusyTimeout: "3s",
+			Synchronous: "NORMAL",
+		},
+	})
+
+	var (
+		journalMode              string
+		busyTimeout, synchronous int
+	)
+
+	require.NoError(t, provider.db.QueryRow("PRAGMA journal_mode").Scan(&journalMode))
+	require.NoError(t, provider.db.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout))
+	require.NoError(t, provider.db.QueryRow("PRAGMA synchronous").Scan(&synchronous))
+
+	assert.Equal(t, "wal", journalMode)
+	assert.Equal(t, 3000, busyTimeout)
+	assert.Equal(t, 1, synchronous)
+}
+
+func TestSQLiteProviderShouldDeleteAllSecondFactorDevicesOfUser(t *testing.T) {
+	provider := NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: filepath.Join(t.TempDir(), "db.sqlite3")},
+	})
+
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: unitTestUser, ID: []byte("abc"), UserHandle: []byte("handle"), PublicKey: []byte("key"), Time: time.Now()}))
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: unitTestUser, ID: []byte("def"), UserHandle: []byte("handle"), PublicKey: []byte("key"), Time: time.Now()}))
+	require.NoError(t, provider.SaveWebAuthnCredential(models.WebAuthnCredential{Username: "other", ID: []byte("ghi"), UserHandle: []byte("handle"), PublicKey: []byte("key"), Time: time.Now()}))
+	require.NoError(t, provider.SaveU2FDeviceHandle(unitTestUser, []byte("handle"), []byte("key")))
+
+	count, err := provider.DeleteWebAuthnCredentials(unitTestUser)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	require.NoError(t, provider.DeleteU2FDeviceHandle(unitTestUser))
+
+	credentials, err := provider.LoadWebAuthnCredentials(unitTestUser)
+	require.NoError(t, err)
+	assert.Len(t, credentials, 0)
+
+	credentials, err = provider.LoadWebAuthnCredentials("other")
+	require.NoError(t, err)
+	assert.Len(t, credentials, 1)
+
+	_, _, err = provider.LoadU2FDeviceHandle(unitTestUser)
+	assert.Equal(t, ErrNoU2FDeviceHandle, err)
+}
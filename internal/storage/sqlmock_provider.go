@@ -18,6 +18,7 @@ func NewSQLMockProvider() (*SQLMockProvider, sqlmock.Sqlmock) {
 			name: "sqlmock",
 
 			sqlUpgradesCreateTableStatements:        sqlUpgradeCreateTableStatements,
+			sqlUpgradesAlterTableStatements:         sqlUpgradesAlterTableStatements,
 			sqlUpgradesCreateTableIndexesStatements: sqlUpgradesCreateTableIndexesStatements,
 
 			sqlGetPreferencesByUsername:     fmt.Sprintf("SELECT second_factor_method FROM %s WHERE username=?", userPreferencesTableName),
@@ -27,18 +28,100 @@ func NewSQLMockProvider() (*SQLMockProvider, sqlmock.Sqlmock) {
 			sqlInsertIdentityVerificationToken:        fmt.Sprintf("INSERT INTO %s (token) VALUES (?)", identityVerificationTokensTableName),
 			sqlDeleteIdentityVerificationToken:        fmt.Sprintf("DELETE FROM %s WHERE token=?", identityVerificationTokensTableName),
 
-			sqlGetTOTPSecretByUsername: fmt.Sprintf("SELECT secret FROM %s WHERE username=?", totpSecretsTableName),
-			sqlUpsertTOTPSecret:        fmt.Sprintf("REPLACE INTO %s (username, secret) VALUES (?, ?)", totpSecretsTableName),
-			sqlDeleteTOTPSecret:        fmt.Sprintf("DELETE FROM %s WHERE username=?", totpSecretsTableName),
+			sqlGetTOTPConfigurationByUsername: fmt.Sprintf("SELECT username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country FROM %s WHERE username=?", totpSecretsTableName),
+			sqlUpsertTOTPConfiguration:        fmt.Sprintf("REPLACE INTO %s (username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", totpSecretsTableName),
+			sqlUpdateTOTPConfigurationSignIn:  fmt.Sprintf("UPDATE %s SET last_used=?, last_used_ip=?, last_used_country=? WHERE username=?", totpSecretsTableName),
+			sqlDeleteTOTPSecret:               fmt.Sprintf("DELETE FROM %s WHERE username=?", totpSecretsTableName),
+
+			sqlInsertTOTPHistory:        fmt.Sprintf("INSERT INTO %s (username, event, remote_ip, time) VALUES (?, ?, ?, ?)", totpHistoryTableName),
+			sqlGetTOTPHistoryByUsername: fmt.Sprintf("SELECT username, event, remote_ip, time FROM %s WHERE username=? ORDER BY time DESC", totpHistoryTableName),
 
 			sqlGetU2FDeviceHandleByUsername: fmt.Sprintf("SELECT keyHandle, publicKey FROM %s WHERE username=?", u2fDeviceHandlesTableName),
 			sqlUpsertU2FDeviceHandle:        fmt.Sprintf("REPLACE INTO %s (username, keyHandle, publicKey) VALUES (?, ?, ?)", u2fDeviceHandlesTableName),
-
-			sqlInsertAuthenticationLog:     fmt.Sprintf("INSERT INTO %s (username, successful, time) VALUES (?, ?, ?)", authenticationLogsTableName),
-			sqlGetLatestAuthenticationLogs: fmt.Sprintf("SELECT successful, time FROM %s WHERE time>? AND username=? ORDER BY time DESC", authenticationLogsTableName),
+			sqlDeleteU2FDeviceHandle:        fmt.Sprintf("DELETE FROM %s WHERE username=?", u2fDeviceHandlesTableName),
+
+			sqlInsertAuthenticationLog:                fmt.Sprintf("INSERT INTO %s (username, successful, time, remote_ip, auth_type) VALUES (?, ?, ?, ?, ?)", authenticationLogsTableName),
+			sqlGetLatestAuthenticationLogs:            fmt.Sprintf("SELECT successful, time FROM %s WHERE time>? AND username=? ORDER BY time DESC", authenticationLogsTableName),
+			sqlGetAuthenticationLogs:                  fmt.Sprintf("SELECT username, successful, time, remote_ip, auth_type FROM %s WHERE (username=? OR ?='') AND (remote_ip=? OR ?='') AND (auth_type=? OR ?='') AND (successful=? OR ?) AND time>=? AND time<=? ORDER BY time DESC LIMIT ?", authenticationLogsTableName),
+			sqlDeleteAuthenticationLogsOlderThan:      fmt.Sprintf("DELETE FROM %s WHERE time<?", authenticationLogsTableName),
+			sqlDeleteAuthenticationLogsExceedingCount: fmt.Sprintf("DELETE FROM %[1]s WHERE time<(SELECT time FROM %[1]s ORDER BY time DESC LIMIT 1 OFFSET ?)", authenticationLogsTableName),
+
+			sqlInsertAuditEvent:          fmt.Sprintf("INSERT INTO %s (username, event, remote_ip, details, time, chain_index, chain_hash) VALUES (?, ?, ?, ?, ?, ?, ?)", auditEventsTableName),
+			sqlSelectAuditEvents:         fmt.Sprintf("SELECT username, event, remote_ip, details, time, chain_index, chain_hash FROM %s WHERE (username=? OR ?='') AND (event=? OR ?='') AND time>=? AND time<=? ORDER BY time DESC, chain_index DESC LIMIT ?", auditEventsTableName),
+			sqlSelectAuditChainHead:      fmt.Sprintf("SELECT chain_index, chain_hash FROM %s WHERE chain_index IS NOT NULL ORDER BY chain_index DESC LIMIT 1", auditEventsTableName),
+			sqlSelectAuditChain:          fmt.Sprintf("SELECT username, event, remote_ip, details, time, chain_index, chain_hash FROM %s WHERE chain_index IS NOT NULL ORDER BY chain_index", auditEventsTableName),
+			sqlCountUnchainedAuditEvents: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE chain_index IS NULL AND time>=?", auditEventsTableName),
+
+			sqlInsertBan:        fmt.Sprintf("INSERT INTO %s (username, reason, time, expires, revoked) VALUES (?, ?, ?, ?, ?)", bannedUsersTableName),
+			sqlSelectActiveBans: fmt.Sprintf("SELECT username, reason, time, expires, revoked FROM %s WHERE (username=? OR ?='') AND revoked=FALSE AND (expires IS NULL OR expires>?) ORDER BY time DESC", bannedUsersTableName),
+			sqlRevokeBans:       fmt.Sprintf("UPDATE %s SET revoked=TRUE WHERE username=? AND revoked=FALSE AND (expires IS NULL OR expires>?)", bannedUsersTableName),
+
+			sqlInsertAPIKey:        fmt.Sprintf("INSERT INTO %s (name, key_hash, key_groups, time, revoked) VALUES (?, ?, ?, ?, ?)", apiKeysTableName),
+			sqlSelectActiveAPIKey:  fmt.Sprintf("SELECT name, key_hash, key_groups, time, revoked FROM %s WHERE key_hash=? AND revoked=FALSE", apiKeysTableName),
+			sqlSelectActiveAPIKeys: fmt.Sprintf("SELECT name, key_hash, key_groups, time, revoked FROM %s WHERE revoked=FALSE ORDER BY time", apiKeysTableName),
+			sqlRevokeAPIKeys:       fmt.Sprintf("UPDATE %s SET revoked=TRUE WHERE name=? AND revoked=FALSE", apiKeysTableName),
+
+			sqlInsertWebAuthnCredential:               fmt.Sprintf("INSERT INTO %s (username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", webAuthnCredentialsTableName),
+			sqlSelectWebAuthnCredentialByID:           fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s WHERE credential_id=? AND deleted_at IS NULL", webAuthnCredentialsTableName),
+			sqlSelectWebAuthnCredentialsByUser:        fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s WHERE username=? AND deleted_at IS NULL ORDER BY time", webAuthnCredentialsTableName),
+			sqlSelectDeletedWebAuthnCredentialsByUser: fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s WHERE username=? AND deleted_at IS NOT NULL ORDER BY deleted_at", webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialUsage:          fmt.Sprintf("UPDATE %s SET sign_count=?, last_used=?, last_used_ip=?, last_used_country=?, backup_eligible=?, backup_state=? WHERE credential_id=? AND deleted_at IS NULL", webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialDescription:    fmt.Sprintf("UPDATE %s SET description=? WHERE username=? AND credential_id=? AND deleted_at IS NULL", webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialCloneWarning:   fmt.Sprintf("UPDATE %s SET clone_warning=? WHERE credential_id=? AND deleted_at IS NULL", webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialDeleted:        fmt.Sprintf("UPDATE %s SET deleted_at=? WHERE username=? AND credential_id=? AND deleted_at IS NULL", webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialsDeletedByUser: fmt.Sprintf("UPDATE %s SET deleted_at=? WHERE username=? AND deleted_at IS NULL", webAuthnCredentialsTableName),
+			sqlUpdateWebAuthnCredentialRestored:       fmt.Sprintf("UPDATE %s SET deleted_at=NULL WHERE username=? AND credential_id=? AND deleted_at IS NOT NULL", webAuthnCredentialsTableName),
+			sqlDeleteWebAuthnCredentialsDeletedBefore: fmt.Sprintf("DELETE FROM %s WHERE deleted_at IS NOT NULL AND deleted_at<?", webAuthnCredentialsTableName),
+			sqlDeleteDeletedWebAuthnCredentialByID:    fmt.Sprintf("DELETE FROM %s WHERE credential_id=? AND deleted_at IS NOT NULL", webAuthnCredentialsTableName),
+
+			sqlInsertRecoveryCode:  fmt.Sprintf("INSERT INTO %s (username, code_hash, time, used) VALUES (?, ?, ?, ?)", recoveryCodesTableName),
+			sqlCountRecoveryCodes:  fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE username=? AND used=FALSE", recoveryCodesTableName),
+			sqlConsumeRecoveryCode: fmt.Sprintf("UPDATE %s SET used=TRUE WHERE username=? AND code_hash=? AND used=FALSE", recoveryCodesTableName),
+			sqlDeleteRecoveryCodes: fmt.Sprintf("DELETE FROM %s WHERE username=?", recoveryCodesTableName),
+
+			sqlInsertTrustedDevice:        fmt.Sprintf("INSERT INTO %s (username, device_id, token_hash, description, remote_ip, time, expires) VALUES (?, ?, ?, ?, ?, ?, ?)", trustedDevicesTableName),
+			sqlSelectTrustedDeviceByHash:  fmt.Sprintf("SELECT username, device_id, token_hash, description, remote_ip, time, expires FROM %s WHERE token_hash=? AND expires>?", trustedDevicesTableName),
+			sqlSelectTrustedDevicesByUser: fmt.Sprintf("SELECT username, device_id, token_hash, description, remote_ip, time, expires FROM %s WHERE username=? AND expires>? ORDER BY time", trustedDevicesTableName),
+			sqlDeleteTrustedDevice:        fmt.Sprintf("DELETE FROM %s WHERE username=? AND device_id=?", trustedDevicesTableName),
+
+			sqlUpsertHOTPDevice:       fmt.Sprintf("REPLACE INTO %s (username, secret, counter, digits) VALUES (?, ?, ?, ?)", hotpDevicesTableName),
+			sqlSelectHOTPDeviceByUser: fmt.Sprintf("SELECT username, secret, counter, digits FROM %s WHERE username=?", hotpDevicesTableName),
+			sqlUpdateHOTPCounter:      fmt.Sprintf("UPDATE %s SET counter=? WHERE username=? AND counter=?", hotpDevicesTableName),
+			sqlDeleteHOTPDevice:       fmt.Sprintf("DELETE FROM %s WHERE username=?", hotpDevicesTableName),
+
+			sqlInsertYubiKeyOTPDevice:        fmt.Sprintf("INSERT INTO %s (username, public_id, time) VALUES (?, ?, ?)", yubiKeyOTPDevicesTableName),
+			sqlSelectYubiKeyOTPDevicesByUser: fmt.Sprintf("SELECT username, public_id, time FROM %s WHERE username=? ORDER BY time", yubiKeyOTPDevicesTableName),
+			sqlDeleteYubiKeyOTPDevice:        fmt.Sprintf("DELETE FROM %s WHERE username=? AND public_id=?", yubiKeyOTPDevicesTableName),
+			sqlDeleteYubiKeyOTPDevicesByUser: fmt.Sprintf("DELETE FROM %s WHERE username=?", yubiKeyOTPDevicesTableName),
+
+			sqlSelectSecondFactorEnrollmentStart: fmt.Sprintf("SELECT time FROM %s WHERE username=?", secondFactorEnrollmentsTableName),
+			sqlInsertSecondFactorEnrollmentStart: fmt.Sprintf("INSERT OR IGNORE INTO %s (username, time) VALUES (?, ?)", secondFactorEnrollmentsTableName),
+
+			sqlSelectDomainPreferenceByUser:  fmt.Sprintf("SELECT second_factor_method FROM %s WHERE username=? AND domain=?", userDomainPreferencesTableName),
+			sqlSelectDomainPreferencesByUser: fmt.Sprintf("SELECT username, domain, second_factor_method FROM %s WHERE username=? ORDER BY domain", userDomainPreferencesTableName),
+			sqlUpsertDomainPreference:        fmt.Sprintf("REPLACE INTO %s (username, domain, second_factor_method) VALUES (?, ?, ?)", userDomainPreferencesTableName),
+
+			sqlSelectUserPreferences:            fmt.Sprintf("SELECT username, second_factor_method FROM %s", userPreferencesTableName),
+			sqlSelectIdentityVerificationTokens: fmt.Sprintf("SELECT token FROM %s", identityVerificationTokensTableName),
+			sqlSelectTOTPSecrets:                fmt.Sprintf("SELECT username, secret, algorithm, digits, period, last_used, last_used_ip, last_used_country FROM %s", totpSecretsTableName),
+			sqlSelectU2FDeviceHandles:           fmt.Sprintf("SELECT username, keyHandle, publicKey FROM %s", u2fDeviceHandlesTableName),
+			sqlSelectAuthenticationLogs:         fmt.Sprintf("SELECT username, successful, time, remote_ip, auth_type FROM %s ORDER BY time", authenticationLogsTableName),
+			sqlSelectAllAuditEvents:             fmt.Sprintf("SELECT username, event, remote_ip, details, time, chain_index, chain_hash FROM %s ORDER BY time, chain_index", auditEventsTableName),
+			sqlSelectTOTPHistory:                fmt.Sprintf("SELECT username, event, remote_ip, time FROM %s ORDER BY time", totpHistoryTableName),
+			sqlSelectBans:                       fmt.Sprintf("SELECT username, reason, time, expires, revoked FROM %s ORDER BY time", bannedUsersTableName),
+			sqlSelectAPIKeys:                    fmt.Sprintf("SELECT name, key_hash, key_groups, time, revoked FROM %s ORDER BY time", apiKeysTableName),
+			sqlSelectWebAuthnCredentials:        fmt.Sprintf("SELECT username, credential_id, user_handle, public_key, sign_count, time, description, last_used, last_used_ip, last_used_country, clone_warning, backup_eligible, backup_state, aaguid, deleted_at FROM %s ORDER BY time", webAuthnCredentialsTableName),
+			sqlSelectRecoveryCodes:              fmt.Sprintf("SELECT username, code_hash, time, used FROM %s ORDER BY time", recoveryCodesTableName),
+			sqlSelectTrustedDevices:             fmt.Sprintf("SELECT username, device_id, token_hash, description, remote_ip, time, expires FROM %s ORDER BY time", trustedDevicesTableName),
+			sqlSelectHOTPDevices:                fmt.Sprintf("SELECT username, secret, counter, digits FROM %s ORDER BY username", hotpDevicesTableName),
+			sqlSelectYubiKeyOTPDevices:          fmt.Sprintf("SELECT username, public_id, time FROM %s ORDER BY time", yubiKeyOTPDevicesTableName),
+			sqlSelectSecondFactorEnrollments:    fmt.Sprintf("SELECT username, time FROM %s ORDER BY username", secondFactorEnrollmentsTableName),
+			sqlSelectDomainPreferences:          fmt.Sprintf("SELECT username, domain, second_factor_method FROM %s ORDER BY username, domain", userDomainPreferencesTableName),
 
 			sqlGetExistingTables: "SELECT name FROM sqlite_master WHERE type='table'",
 
+			sqlMaintenanceStatements: []string{"VACUUM", "ANALYZE"},
+
 			sqlConfigSetValue: fmt.Sprintf("REPLACE INTO %s (category, key_name, value) VALUES (?, ?, ?)", configTableName),
 			sqlConfigGetValue: fmt.Sprintf("SELECT value FROM %s WHERE category=? AND key_name=?", configTableName),
 		},
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+// SaveTrustedDevice persists a trusted device. Only the hash of the token of the device is persisted.
+func (p *SQLProvider) SaveTrustedDevice(device models.TrustedDevice) error {
+	_, err := p.exec(p.sqlInsertTrustedDevice, device.Username, device.ID, device.TokenHash, device.Description,
+		device.RemoteIP, device.Time.Unix(), device.Expires.Unix())
+
+	return err
+}
+
+// LoadTrustedDevice loads the trusted device which has not expired given the hash of the token of the device.
+func (p *SQLProvider) LoadTrustedDevice(hash string) (*models.TrustedDevice, error) {
+	var (
+		device     models.TrustedDevice
+		t, expires int64
+	)
+
+	err := p.queryRow(p.db, p.sqlSelectTrustedDeviceByHash, []interface{}{hash, time.Now().Unix()},
+		&device.Username, &device.ID, &device.TokenHash, &device.Description, &device.RemoteIP, &t, &expires)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNoTrustedDevice
+		}
+
+		return nil, err
+	}
+
+	device.Time = time.Unix(t, 0)
+	device.Expires = time.Unix(expires, 0)
+
+	return &device, nil
+}
+
+// LoadTrustedDevices loads the devices trusted by a user which have not expired, the oldest first.
+func (p *SQLProvider) LoadTrustedDevices(username string) ([]models.TrustedDevice, error) {
+	var devices []models.TrustedDevice
+
+	err := p.retry(func() error {
+		rows, err := p.db.Query(p.sqlSelectTrustedDevicesByUser, username, time.Now().Unix())
+		if err != nil {
+			return err
+		}
+
+		defer rows.Close()
+
+		devices = make([]models.TrustedDevice, 0, 1)
+
+		for rows.Next() {
+			device, err := scanTrustedDevice(rows)
+			if err != nil {
+				return err
+			}
+
+			devices = append(devices, device)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+// DeleteTrustedDevice deletes the device with the given ID trusted by the user, which revokes the trust. It returns the
+// number of deleted devices.
+func (p *SQLProvider) DeleteTrustedDevice(username, id string) (int64, error) {
+	result, err := p.exec(p.sqlDeleteTrustedDevice, username, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func scanTrustedDevice(rows *sql.Rows) (device models.TrustedDevice, err error) {
+	var t, expires int64
+
+	if err = rows.Scan(&device.Username, &device.ID, &device.TokenHash, &device.Description, &device.RemoteIP, &t, &expires); err != nil {
+		return device, err
+	}
+
+	device.Time = time.Unix(t, 0)
+	device.Expires = time.Unix(expires, 0)
+
+	return device, nil
+}
@@ -3,6 +3,10 @@ package storage
 import (
 	"database/sql"
 	"strconv"
+	"time"
+
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/webauthn"
 )
 
 // SchemaVersion is a simple int representation of the schema version.
@@ -13,6 +17,156 @@ func (s SchemaVersion) ToString() string {
 	return strconv.Itoa(int(s))
 }
 
+// SchemaUpgrade is the statements run by the migration of the schema to a version.
+type SchemaUpgrade struct {
+	Version    SchemaVersion `json:"version"`
+	Statements []string      `json:"statements"`
+}
+
+// SchemaDowngrade is the data destroyed by reverting the migration of the schema to a version.
+type SchemaDowngrade struct {
+	Version SchemaVersion    `json:"version"`
+	Tables  []SchemaDataLoss `json:"tables,omitempty"`
+	Columns []SchemaDataLoss `json:"columns,omitempty"`
+}
+
+// DestroysData returns true if at least one of the tables or the columns dropped by the downgrade holds data.
+func (d SchemaDowngrade) DestroysData() bool {
+	for _, loss := range d.Tables {
+		if loss.Rows > 0 {
+			return true
+		}
+	}
+
+	for _, loss := range d.Columns {
+		if loss.Rows > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SchemaDataLoss is a table or a column dropped by a downgrade of the schema and the number of rows holding data in it.
+type SchemaDataLoss struct {
+	Table  string `json:"table"`
+	Column string `json:"column,omitempty"`
+	Rows   int64  `json:"rows"`
+}
+
 type transaction interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
 }
+
+// Export is a portable representation of all of the data persisted by a SQL provider.
+type Export struct {
+	SchemaVersion              SchemaVersion                   `json:"schema_version"`
+	UserPreferences            []ExportUserPreferences         `json:"user_preferences"`
+	IdentityVerificationTokens []string                        `json:"identity_verification_tokens"`
+	TOTPSecrets                []models.TOTPConfiguration      `json:"totp_secrets"`
+	TOTPHistory                []models.TOTPHistoryEntry       `json:"totp_history"`
+	U2FDevices                 []ExportU2FDevice               `json:"u2f_devices"`
+	AuthenticationLogs         []models.AuthenticationAttempt  `json:"authentication_logs"`
+	AuditEvents                []models.AuditEvent             `json:"audit_events"`
+	Bans                       []models.Ban                    `json:"bans"`
+	APIKeys                    []models.APIKey                 `json:"api_keys"`
+	WebAuthnCredentials        []models.WebAuthnCredential     `json:"webauthn_credentials"`
+	RecoveryCodes              []models.RecoveryCode           `json:"recovery_codes"`
+	TrustedDevices             []models.TrustedDevice          `json:"trusted_devices"`
+	HOTPDevices                []models.HOTPDevice             `json:"hotp_devices"`
+	YubiKeyOTPDevices          []models.YubiKeyOTPDevice       `json:"yubikey_otp_devices"`
+	SecondFactorEnrollments    []models.SecondFactorEnrollment `json:"second_factor_enrollments"`
+	DomainPreferences          []models.DomainPreference       `json:"user_domain_preferences"`
+}
+
+// WebAuthnCredentialImport is a WebAuthn credential registered with another identity provider, as read by the storage
+// user webauthn import command. The binary values are base64url encoded.
+type WebAuthnCredentialImport struct {
+	CredentialID webauthn.URLEncodedBase64 `json:"credential_id"`
+	PublicKey    webauthn.URLEncodedBase64 `json:"public_key"`
+	SignCount    uint32                    `json:"sign_count"`
+	RPID         string                    `json:"rp_id"`
+	UserHandle   webauthn.URLEncodedBase64 `json:"user_handle"`
+	Description  string                    `json:"description"`
+}
+
+// Backup represents an encrypted Export. The export is encrypted with AES-256-GCM using a key derived from a passphrase
+// with Argon2id.
+type Backup struct {
+	Version       int           `json:"version"`
+	SchemaVersion SchemaVersion `json:"schema_version"`
+	Created       time.Time     `json:"created"`
+	Salt          []byte        `json:"salt"`
+	Nonce         []byte        `json:"nonce"`
+	Data          []byte        `json:"data"`
+}
+
+// ExportUserPreferences represents the preferences of a user in an Export.
+type ExportUserPreferences struct {
+	Username           string `json:"username"`
+	SecondFactorMethod string `json:"second_factor_method"`
+}
+
+// ExportU2FDevice represents the U2F device of a user in an Export.
+type ExportU2FDevice struct {
+	Username  string `json:"username"`
+	KeyHandle []byte `json:"key_handle"`
+	PublicKey []byte `json:"public_key"`
+}
+
+// HealthStatus represents the result of a health check of the storage backend.
+type HealthStatus struct {
+	SchemaVersion SchemaVersion
+	Latency       time.Duration
+}
+
+// AuthenticationLogsFilter restricts the authentication logs loaded by LoadAuthenticationLogs. The zero value of each
+// field matches any authentication log. Cursor is the cursor of the next page returned by a previous call.
+type AuthenticationLogsFilter struct {
+	Username   string
+	RemoteIP   string
+	Type       string
+	Successful *bool
+	From       time.Time
+	To         time.Time
+	Limit      int
+	Cursor     string
+}
+
+// AuditEventsFilter restricts the audit events loaded by LoadAuditEvents. The zero value of each field matches any
+// audit event. Cursor is the cursor of the next page returned by a previous call.
+type AuditEventsFilter struct {
+	Username string
+	Type     string
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Cursor   string
+}
+
+// AuditEventsVerification is the result of the verification of the hash chain of the audit events.
+type AuditEventsVerification struct {
+	// Events is the number of audit events in the hash chain.
+	Events int `json:"events"`
+	// Unchained is the number of audit events recorded before the hash chain was introduced.
+	Unchained int `json:"unchained"`
+	// Head is the hash of the last audit event of the chain, empty if there are none.
+	Head string `json:"head"`
+}
+
+// UserExport is a representation of all of the data persisted by a SQL provider about a single user.
+type UserExport struct {
+	Username                   string                         `json:"username"`
+	SecondFactorMethod         string                         `json:"second_factor_method,omitempty"`
+	DomainPreferences          []models.DomainPreference      `json:"domain_preferences"`
+	TOTPConfiguration          *models.TOTPConfiguration      `json:"totp_configuration,omitempty"`
+	TOTPHistory                []models.TOTPHistoryEntry      `json:"totp_history"`
+	U2FDevice                  *ExportU2FDevice               `json:"u2f_device,omitempty"`
+	WebAuthnCredentials        []models.WebAuthnCredential    `json:"webauthn_credentials"`
+	DeletedWebAuthnCredentials []models.WebAuthnCredential    `json:"deleted_webauthn_credentials"`
+	TrustedDevices             []models.TrustedDevice         `json:"trusted_devices"`
+	HOTPDevice                 *models.HOTPDevice             `json:"hotp_device,omitempty"`
+	YubiKeyOTPDevices          []models.YubiKeyOTPDevice      `json:"yubikey_otp_devices"`
+	AuthenticationLogs         []models.AuthenticationAttempt `json:"authentication_logs"`
+	AuditEvents                []models.AuditEvent            `json:"audit_events"`
+}
@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"sort"
 
@@ -15,11 +17,13 @@ func (p *SQLProvider) upgradeCreateTableStatements(tx transaction, statements ma
 
 	sort.Strings(keys)
 
-	for _, table := range keys {
+	for _, key := range keys {
+		table := p.tablePrefix + key
+
 		if !utils.IsStringInSlice(table, existingTables) {
-			_, err := tx.Exec(fmt.Sprintf(statements[table], table))
+			_, err := tx.Exec(fmt.Sprintf(statements[key], table))
 			if err != nil {
-				return fmt.Errorf("Unable to create table %s: %v", table, err)
+				return fmt.Errorf("Unable to create table %s: %w", table, err)
 			}
 		}
 	}
@@ -27,9 +31,10 @@ func (p *SQLProvider) upgradeCreateTableStatements(tx transaction, statements ma
 	return nil
 }
 
+// upgradeRunMultipleStatements runs each statement after it is fmt.Sprintf'd with the table prefix as the first argument.
 func (p *SQLProvider) upgradeRunMultipleStatements(tx transaction, statements []string) error {
 	for _, statement := range statements {
-		_, err := tx.Exec(statement)
+		_, err := tx.Exec(fmt.Sprintf(statement, p.tablePrefix))
 		if err != nil {
 			return err
 		}
@@ -50,27 +55,71 @@ func (p *SQLProvider) upgradeFinalize(tx transaction, version SchemaVersion) err
 	return nil
 }
 
-// upgradeSchemaToVersion001 upgrades the schema to version 1.
-func (p *SQLProvider) upgradeSchemaToVersion001(tx transaction, tables []string) error {
-	version := SchemaVersion(1)
+// upgradeSchemaToVersion upgrades the schema to the provided version and sets the schema version.
+func (p *SQLProvider) upgradeSchemaToVersion(tx transaction, version SchemaVersion, tables []string) error {
+	if err := p.upgradeSchemaStatements(tx, version, tables); err != nil {
+		return err
+	}
+
+	return p.upgradeFinalize(tx, version)
+}
 
+// upgradeSchemaStatements runs the statements of the migration to the provided version. The tables of the version are
+// created first, then the existing tables are altered and finally the indexes of the version are created. The
+// statements of each version are looked up in the sqlUpgrades maps of the provider.
+func (p *SQLProvider) upgradeSchemaStatements(tx transaction, version SchemaVersion, tables []string) error {
 	err := p.upgradeCreateTableStatements(tx, p.sqlUpgradesCreateTableStatements[version], tables)
 	if err != nil {
 		return err
 	}
 
-	// Skip mysql create index statements. It doesn't support CREATE INDEX IF NOT EXIST. May be able to work around this with an Index struct.
+	err = p.upgradeRunMultipleStatements(tx, p.sqlUpgradesAlterTableStatements[version])
+	if err != nil {
+		return fmt.Errorf("Unable to alter table: %w", err)
+	}
+
+	// Skip mysql create index statements. It doesn't support CREATE INDEX IF NOT EXISTS so its indexes are part of its
+	// create and alter table statements.
 	if p.name != "mysql" {
-		err = p.upgradeRunMultipleStatements(tx, p.sqlUpgradesCreateTableIndexesStatements[1])
+		err = p.upgradeRunMultipleStatements(tx, p.sqlUpgradesCreateTableIndexesStatements[version])
 		if err != nil {
-			return fmt.Errorf("Unable to create index: %v", err)
+			return fmt.Errorf("Unable to create index: %w", err)
 		}
 	}
 
-	err = p.upgradeFinalize(tx, version)
+	return nil
+}
+
+// PlanSchemaUpgrade returns the statements of each migration which would be run to upgrade the schema to the current
+// version, without running them. It's empty when the schema is up to date.
+func (p *SQLProvider) PlanSchemaUpgrade() ([]SchemaUpgrade, error) {
+	version, tables, err := p.getSchemaBasicDetails()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	upgrades := make([]SchemaUpgrade, 0)
+
+	for next := version + 1; next <= storageSchemaCurrentVersion; next++ {
+		tx := &recordingTransaction{}
+
+		if err = p.upgradeSchemaStatements(tx, next, tables); err != nil {
+			return nil, err
+		}
+
+		upgrades = append(upgrades, SchemaUpgrade{Version: next, Statements: tx.statements})
+	}
+
+	return upgrades, nil
+}
+
+// recordingTransaction is a transaction recording the statements instead of running them.
+type recordingTransaction struct {
+	statements []string
+}
+
+func (tx *recordingTransaction) Exec(query string, args ...interface{}) (sql.Result, error) {
+	tx.statements = append(tx.statements, query)
+
+	return driver.RowsAffected(0), nil
 }
@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+// SaveWebAuthnCredential persists a WebAuthn credential. It returns ErrWebAuthnCredentialRegistered if a credential
+// with the same ID is registered, the credentials with the same ID deleted previously are purged.
+func (p *SQLProvider) SaveWebAuthnCredential(credential models.WebAuthnCredential) (err error) {
+	if _, err = p.LoadWebAuthnCredential(credential.ID); err == nil {
+		return ErrWebAuthnCredentialRegistered
+	} else if err != ErrNoWebAuthnCredential {
+		return err
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = p.saveWebAuthnCredential(tx, credential); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("rollback error occurred: %v (inner error %v)", rollbackErr, err)
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// saveWebAuthnCredential purges the deleted credentials with the same ID as the WebAuthn credential, as the IDs are
+// unique, and inserts it.
+func (p *SQLProvider) saveWebAuthnCredential(tx transaction, credential models.WebAuthnCredential) (err error) {
+	id := base64.StdEncoding.EncodeToString(credential.ID)
+
+	if _, err = tx.Exec(p.sqlDeleteDeletedWebAuthnCredentialByID, id); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(p.sqlInsertWebAuthnCredential, credential.Username, id,
+		base64.StdEncoding.EncodeToString(credential.UserHandle),
+		base64.StdEncoding.EncodeToString(credential.PublicKey),
+		credential.SignCount, credential.Time.Unix(), credential.Description, webAuthnCredentialLastUsed(credential),
+		credential.LastUsedIP, credential.LastUsedCountry, credential.CloneWarning, credential.BackupEligible, credential.BackupState, webAuthnCredentialAAGUID(credential),
+		webAuthnCredentialDeletedAt(credential))
+
+	return err
+}
+
+// LoadWebAuthnCredential loads the WebAuthn credential given its ID, unless it was deleted.
+func (p *SQLProvider) LoadWebAuthnCredential(id []byte) (*models.WebAuthnCredential, error) {
+	var (
+		credential models.WebAuthnCredential
+		columns    webAuthnCredentialColumns
+	)
+
+	err := p.queryRow(p.db, p.sqlSelectWebAuthnCredentialByID, []interface{}{base64.StdEncoding.EncodeToString(id)},
+		columns.dest(&credential)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNoWebAuthnCredential
+		}
+
+		return nil, err
+	}
+
+	if err = columns.decode(&credential); err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+// LoadWebAuthnCredentials loads the WebAuthn credentials registered by a user which were not deleted, the oldest first.
+func (p *SQLProvider) LoadWebAuthnCredentials(username string) ([]models.WebAuthnCredential, error) {
+	return p.loadWebAuthnCredentials(p.sqlSelectWebAuthnCredentialsByUser, username)
+}
+
+// LoadDeletedWebAuthnCredentials loads the WebAuthn credentials deleted by a user which were not purged yet, the first
+// deleted first.
+func (p *SQLProvider) LoadDeletedWebAuthnCredentials(username string) ([]models.WebAuthnCredential, error) {
+	return p.loadWebAuthnCredentials(p.sqlSelectDeletedWebAuthnCredentialsByUser, username)
+}
+
+func (p *SQLProvider) loadWebAuthnCredentials(query, username string) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+
+	err := p.retry(func() error {
+		rows, err := p.db.Query(query, username)
+		if err != nil {
+			return err
+		}
+
+		defer rows.Close()
+
+		credentials = make([]models.WebAuthnCredential, 0, 1)
+
+		for rows.Next() {
+			credential, err := scanWebAuthnCredential(rows)
+			if err != nil {
+				return err
+			}
+
+			credentials = append(credentials, credential)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// UpdateWebAuthnCredentialUsage updates the signature counter, the backup flags, the time, the IP address and the
+// country of the last use of the WebAuthn credential.
+func (p *SQLProvider) UpdateWebAuthnCredentialUsage(credential models.WebAuthnCredential) error {
+	_, err := p.exec(p.sqlUpdateWebAuthnCredentialUsage, credential.SignCount, webAuthnCredentialLastUsed(credential),
+		credential.LastUsedIP, credential.LastUsedCountry, credential.BackupEligible, credential.BackupState, base64.StdEncoding.EncodeToString(credential.ID))
+
+	return err
+}
+
+// SetWebAuthnCredentialCloneWarning flags the WebAuthn credential with the given ID as possibly cloned.
+func (p *SQLProvider) SetWebAuthnCredentialCloneWarning(id []byte) error {
+	_, err := p.exec(p.sqlUpdateWebAuthnCredentialCloneWarning, true, base64.StdEncoding.EncodeToString(id))
+	return err
+}
+
+// UpdateWebAuthnCredentialDescription updates the description of the WebAuthn credential with the given ID registered
+// by the user. It returns the number of updated credentials.
+func (p *SQLProvider) UpdateWebAuthnCredentialDescription(username string, id []byte, description string) (int64, error) {
+	result, err := p.exec(p.sqlUpdateWebAuthnCredentialDescription, description, username, base64.StdEncoding.EncodeToString(id))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteWebAuthnCredential deletes the WebAuthn credential with the given ID registered by the user. The credential
+// can't be used anymore but is kept until it is purged at the end of the grace period, so it can be restored if it was
+// deleted by mistake. It returns the number of deleted credentials.
+func (p *SQLProvider) DeleteWebAuthnCredential(username string, id []byte) (int64, error) {
+	result, err := p.exec(p.sqlUpdateWebAuthnCredentialDeleted, time.Now().Unix(), username, base64.StdEncoding.EncodeToString(id))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteWebAuthnCredentials deletes all of the WebAuthn credentials registered by the user, which are kept until they
+// are purged like with DeleteWebAuthnCredential. It returns the number of deleted credentials.
+func (p *SQLProvider) DeleteWebAuthnCredentials(username string) (int64, error) {
+	result, err := p.exec(p.sqlUpdateWebAuthnCredentialsDeletedByUser, time.Now().Unix(), username)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// RestoreWebAuthnCredential restores the WebAuthn credential with the given ID deleted by the user and not purged yet.
+// It returns ErrWebAuthnCredentialRegistered if the credential has been registered again since it was deleted, and the
+// number of restored credentials otherwise.
+func (p *SQLProvider) RestoreWebAuthnCredential(username string, id []byte) (int64, error) {
+	if _, err := p.LoadWebAuthnCredential(id); err == nil {
+		return 0, ErrWebAuthnCredentialRegistered
+	} else if err != ErrNoWebAuthnCredential {
+		return 0, err
+	}
+
+	result, err := p.exec(p.sqlUpdateWebAuthnCredentialRestored, username, base64.StdEncoding.EncodeToString(id))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func webAuthnCredentialLastUsed(credential models.WebAuthnCredential) sql.NullInt64 {
+	if credential.LastUsed == nil {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: credential.LastUsed.Unix(), Valid: true}
+}
+
+// webAuthnCredentialAAGUID returns the AAGUID of the WebAuthn credential, NULL if the authenticator model is unknown.
+func webAuthnCredentialAAGUID(credential models.WebAuthnCredential) sql.NullString {
+	return sql.NullString{String: credential.AAGUID, Valid: credential.AAGUID != ""}
+}
+
+// webAuthnCredentialDeletedAt returns the time the WebAuthn credential was deleted, NULL if it wasn't.
+func webAuthnCredentialDeletedAt(credential models.WebAuthnCredential) sql.NullInt64 {
+	if credential.DeletedAt == nil {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: credential.DeletedAt.Unix(), Valid: true}
+}
+
+func scanWebAuthnCredential(rows *sql.Rows) (credential models.WebAuthnCredential, err error) {
+	var columns webAuthnCredentialColumns
+
+	if err = rows.Scan(columns.dest(&credential)...); err != nil {
+		return credential, err
+	}
+
+	err = columns.decode(&credential)
+
+	return credential, err
+}
+
+// webAuthnCredentialColumns are the columns of a WebAuthn credential which are not scanned directly into the model.
+type webAuthnCredentialColumns struct {
+	id, userHandle, publicKey                 string
+	t                                         int64
+	description, lastUsedIP, lastUsedCountry  sql.NullString
+	aaguid                                    sql.NullString
+	lastUsed, deletedAt                       sql.NullInt64
+	cloneWarning, backupEligible, backupState sql.NullBool
+}
+
+// dest returns the destinations of the columns selected by the WebAuthn credential queries.
+func (c *webAuthnCredentialColumns) dest(credential *models.WebAuthnCredential) []interface{} {
+	return []interface{}{&credential.Username, &c.id, &c.userHandle, &c.publicKey, &credential.SignCount, &c.t, &c.description, &c.lastUsed, &c.lastUsedIP, &c.lastUsedCountry, &c.cloneWarning, &c.backupEligible, &c.backupState, &c.aaguid, &c.deletedAt}
+}
+
+// decode decodes the base64 encoded and the nullable columns of a WebAuthn credential.
+func (c *webAuthnCredentialColumns) decode(credential *models.WebAuthnCredential) (err error) {
+	if credential.ID, err = base64.StdEncoding.DecodeString(c.id); err != nil {
+		return err
+	}
+
+	if credential.UserHandle, err = base64.StdEncoding.DecodeString(c.userHandle); err != nil {
+		return err
+	}
+
+	if credential.PublicKey, err = base64.StdEncoding.DecodeString(c.publicKey); err != nil {
+		return err
+	}
+
+	credential.Time = time.Unix(c.t, 0)
+	credential.Description = c.description.String
+	credential.LastUsedIP = c.lastUsedIP.String
+	credential.LastUsedCountry = c.lastUsedCountry.String
+	credential.CloneWarning = c.cloneWarning.Bool
+	credential.BackupEligible = c.backupEligible.Bool
+	credential.BackupState = c.backupState.Bool
+	credential.AAGUID = c.aaguid.String
+
+	if c.lastUsed.Valid {
+		lastUsed := time.Unix(c.lastUsed.Int64, 0)
+		credential.LastUsed = &lastUsed
+	}
+
+	if c.deletedAt.Valid {
+		deletedAt := time.Unix(c.deletedAt.Int64, 0)
+		credential.DeletedAt = &deletedAt
+	}
+
+	return nil
+}
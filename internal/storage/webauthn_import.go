@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/utils"
+	"github.com/authelia/authelia/internal/webauthn"
+)
+
+// ParseWebAuthnCredentialImport decodes the credentials of a user registered with another identity provider and
+// converts them to the credentials persisted by Authelia. The credentials must have been registered for one of the
+// relying party IDs, since the authenticators won't use them with another one.
+func ParseWebAuthnCredentialImport(data []byte, username string, rpIDs []string, now time.Time) ([]models.WebAuthnCredential, error) {
+	var imports []WebAuthnCredentialImport
+
+	if err := json.Unmarshal(data, &imports); err != nil {
+		return nil, fmt.Errorf("unable to decode the credentials: %w", err)
+	}
+
+	credentials := make([]models.WebAuthnCredential, 0, len(imports))
+
+	for i, credential := range imports {
+		if err := validateWebAuthnCredentialImport(credential, rpIDs); err != nil {
+			return nil, fmt.Errorf("credential %d: %w", i, err)
+		}
+
+		credentials = append(credentials, models.WebAuthnCredential{
+			Username:    username,
+			ID:          credential.CredentialID,
+			UserHandle:  credential.UserHandle,
+			PublicKey:   credential.PublicKey,
+			SignCount:   credential.SignCount,
+			Time:        now,
+			Description: credential.Description,
+		})
+	}
+
+	return credentials, nil
+}
+
+func validateWebAuthnCredentialImport(credential WebAuthnCredentialImport, rpIDs []string) error {
+	if len(credential.CredentialID) == 0 {
+		return errors.New("the credential ID is required")
+	}
+
+	if len(credential.UserHandle) == 0 {
+		return errors.New("the user handle is required to sign in with the credential")
+	}
+
+	if !utils.IsStringInSlice(credential.RPID, rpIDs) {
+		return fmt.Errorf("the relying party ID %q is not one of the relying party IDs of the configuration", credential.RPID)
+	}
+
+	if err := webauthn.ValidatePublicKey(credential.PublicKey); err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+
+	return nil
+}
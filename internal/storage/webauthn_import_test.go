@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+const testWebAuthnPublicKey = "pQECAyYgASFYINERUGWx_gyRKSld-DDWyMf9UnYNcjx1IkzJhgR1e1HYIlggVb07m3LKMdDF6gZmg7fYeU7mTfnYHbhzDO6_T3HAk_4"
+
+func TestShouldParseWebAuthnCredentialImport(t *testing.T) {
+	now := time.Unix(1577880000, 0)
+
+	data := fmt.Sprintf(`[{"credential_id":"YWJj","public_key":"%s","sign_count":12,"rp_id":"example.com","user_handle":"aGFuZGxl","description":"YubiKey"}]`, testWebAuthnPublicKey)
+
+	credentials, err := ParseWebAuthnCredentialImport([]byte(data), unitTestUser, []string{"example.com"}, now)
+	require.NoError(t, err)
+
+	publicKey, err := base64.RawURLEncoding.DecodeString(testWebAuthnPublicKey)
+	require.NoError(t, err)
+
+	assert.Equal(t, []models.WebAuthnCredential{{
+		Username:    unitTestUser,
+		ID:          []byte("abc"),
+		UserHandle:  []byte("handle"),
+		PublicKey:   publicKey,
+		SignCount:   12,
+		Time:        now,
+		Description: "YubiKey",
+	}}, credentials)
+}
+
+func TestShouldNotParseInvalidWebAuthnCredentialImport(t *testing.T) {
+	testCases := []struct {
+		name       string
+		credential string
+		err        string
+	}{
+		{"MissingID", fmt.Sprintf(`{"public_key":"%s","rp_id":"example.com","user_handle":"aGFuZGxl"}`, testWebAuthnPublicKey), "credential 0: the credential ID is required"},
+		{"MissingUserHandle", fmt.Sprintf(`{"credential_id":"YWJj","public_key":"%s","rp_id":"example.com"}`, testWebAuthnPublicKey), "credential 0: the user handle is required to sign in with the credential"},
+		{"OtherRPID", fmt.Sprintf(`{"credential_id":"YWJj","public_key":"%s","rp_id":"example.org","user_handle":"aGFuZGxl"}`, testWebAuthnPublicKey), `credential 0: the relying party ID "example.org" is not one of the relying party IDs of the configuration`},
+		{"InvalidPublicKey", `{"credential_id":"YWJj","public_key":"oA","rp_id":"example.com","user_handle":"aGFuZGxl"}`, "credential 0: invalid public key: unsupported public key: key type 0"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseWebAuthnCredentialImport([]byte("["+tc.credential+"]"), unitTestUser, []string{"example.com"}, time.Now())
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/authelia/authelia/internal/models"
+)
+
+// SaveYubiKeyOTPDevice persists a YubiKey of a user generating Yubico OTPs.
+func (p *SQLProvider) SaveYubiKeyOTPDevice(device models.YubiKeyOTPDevice) error {
+	_, err := p.exec(p.sqlInsertYubiKeyOTPDevice, device.Username, device.PublicID, device.Time.Unix())
+
+	return err
+}
+
+// LoadYubiKeyOTPDevices loads the YubiKeys of a user generating Yubico OTPs, the oldest first.
+func (p *SQLProvider) LoadYubiKeyOTPDevices(username string) ([]models.YubiKeyOTPDevice, error) {
+	var devices []models.YubiKeyOTPDevice
+
+	err := p.retry(func() error {
+		rows, err := p.db.Query(p.sqlSelectYubiKeyOTPDevicesByUser, username)
+		if err != nil {
+			return err
+		}
+
+		defer rows.Close()
+
+		devices = make([]models.YubiKeyOTPDevice, 0, 1)
+
+		for rows.Next() {
+			device, err := scanYubiKeyOTPDevice(rows)
+			if err != nil {
+				return err
+			}
+
+			devices = append(devices, device)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+// DeleteYubiKeyOTPDevice deletes the YubiKey with the given public ID of a user. It returns the number of deleted
+// devices.
+func (p *SQLProvider) DeleteYubiKeyOTPDevice(username, publicID string) (int64, error) {
+	result, err := p.exec(p.sqlDeleteYubiKeyOTPDevice, username, publicID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteYubiKeyOTPDevices deletes all of the YubiKeys of a user. It returns the number of deleted devices.
+func (p *SQLProvider) DeleteYubiKeyOTPDevices(username string) (int64, error) {
+	result, err := p.exec(p.sqlDeleteYubiKeyOTPDevicesByUser, username)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func scanYubiKeyOTPDevice(rows *sql.Rows) (device models.YubiKeyOTPDevice, err error) {
+	var t int64
+
+	if err = rows.Scan(&device.Username, &device.PublicID, &t); err != nil {
+		return device, err
+	}
+
+	device.Time = time.Unix(t, 0)
+
+	return device, nil
+}
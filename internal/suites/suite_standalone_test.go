@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/storage"
 )
 
@@ -74,7 +75,9 @@ func (s *StandaloneWebDriverSuite) TestShouldCheckUserIsAskedToRegisterDevice()
 	password := "password"
 
 	// Clean up any TOTP secret already in DB.
-	provider := storage.NewSQLiteProvider("/tmp/db.sqlite3")
+	provider := storage.NewSQLiteProvider(schema.StorageConfiguration{
+		Local: &schema.LocalStorageConfiguration{Path: "/tmp/db.sqlite3"},
+	})
 	require.NoError(s.T(), provider.DeleteTOTPSecret(username))
 
 	// Login one factor.
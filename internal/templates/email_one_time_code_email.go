@@ -0,0 +1,27 @@
+package templates
+
+import (
+	"text/template"
+)
+
+// EmailOneTimeCodeTemplate the template of email that the user will receive with the one-time code used to sign in
+// with the email second factor method.
+var EmailOneTimeCodeTemplate *template.Template
+
+func init() {
+	t, err := template.New("email_one_time_code_template").Parse(emailOneTimeCodeContent)
+	if err != nil {
+		panic(err)
+	}
+
+	EmailOneTimeCodeTemplate = t
+}
+
+const emailOneTimeCodeContent = `
+Your one-time code is {{.code}}, it expires in {{.lifespan}}.
+
+IP address: {{.ip}}
+Date: {{.time}}
+
+If you did not try to sign in your password might have been compromised. You should reset your password and contact an administrator.
+`
@@ -0,0 +1,28 @@
+package templates
+
+import (
+	"text/template"
+)
+
+// PasskeyNotificationEmailTemplate the template of email that the user will receive when a passkey is registered or
+// removed from their account.
+var PasskeyNotificationEmailTemplate *template.Template
+
+func init() {
+	t, err := template.New("passkey_notification_email_template").Parse(emailPasskeyNotificationContent)
+	if err != nil {
+		panic(err)
+	}
+
+	PasskeyNotificationEmailTemplate = t
+}
+
+const emailPasskeyNotificationContent = `
+{{.event}}.
+
+Passkey: {{.description}}
+IP address: {{.ip}}
+Date: {{.time}}
+
+If you did not make this change your credentials might have been compromised. You should reset your password and contact an administrator.
+`
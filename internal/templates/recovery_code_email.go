@@ -0,0 +1,28 @@
+package templates
+
+import (
+	"text/template"
+)
+
+// RecoveryCodeNotificationEmailTemplate the template of email that the user will receive when recovery codes are
+// generated for their account or when one of them is used to sign in.
+var RecoveryCodeNotificationEmailTemplate *template.Template
+
+func init() {
+	t, err := template.New("recovery_code_notification_email_template").Parse(emailRecoveryCodeNotificationContent)
+	if err != nil {
+		panic(err)
+	}
+
+	RecoveryCodeNotificationEmailTemplate = t
+}
+
+const emailRecoveryCodeNotificationContent = `
+{{.event}}.
+
+Remaining recovery codes: {{.remaining}}
+IP address: {{.ip}}
+Date: {{.time}}
+
+If you did not make this change your credentials might have been compromised. You should reset your password and contact an administrator.
+`
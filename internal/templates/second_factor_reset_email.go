@@ -0,0 +1,26 @@
+package templates
+
+import (
+	"text/template"
+)
+
+// SecondFactorResetEmailTemplate the template of email that the user will receive when an administrator resets their
+// second factor methods.
+var SecondFactorResetEmailTemplate *template.Template
+
+func init() {
+	t, err := template.New("second_factor_reset_email_template").Parse(emailSecondFactorResetContent)
+	if err != nil {
+		panic(err)
+	}
+
+	SecondFactorResetEmailTemplate = t
+}
+
+const emailSecondFactorResetContent = `
+An administrator reset the following second factor methods of your account: {{.methods}}.
+
+Date: {{.time}}
+
+You will be asked to register them again the next time you sign in. If you did not request this reset you should contact an administrator.
+`
@@ -0,0 +1,31 @@
+package webauthn
+
+import (
+	_ "embed" // Embeds the names of the authenticator models.
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// authenticatorModelsJSON maps the AAGUIDs to the names of the authenticator models. It combines the descriptions of
+// the FIDO Metadata Service with the passkey providers which are not listed by it, and is refreshed with the
+// gen-aaguids command of authelia-scripts.
+//
+//go:embed aaguids.json
+var authenticatorModelsJSON []byte
+
+var (
+	authenticatorModels     map[string]string
+	authenticatorModelsOnce sync.Once
+)
+
+// AuthenticatorModel returns the name of the authenticator model with the given AAGUID, or an empty string if the
+// model is unknown.
+func AuthenticatorModel(aaguid string) string {
+	authenticatorModelsOnce.Do(func() {
+		// The file is validated by the tests.
+		_ = json.Unmarshal(authenticatorModelsJSON, &authenticatorModels)
+	})
+
+	return authenticatorModels[strings.ToLower(aaguid)]
+}
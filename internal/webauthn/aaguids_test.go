@@ -0,0 +1,26 @@
+package webauthn
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldResolveAuthenticatorModel(t *testing.T) {
+	assert.Equal(t, "YubiKey 5 Series", AuthenticatorModel(testAAGUIDString))
+	assert.Equal(t, "YubiKey 5 Series", AuthenticatorModel("CB69481E-8FF7-4039-93EC-0A2729A154A8"))
+	assert.Equal(t, "", AuthenticatorModel("00000000-0000-0000-0000-000000000000"))
+	assert.Equal(t, "", AuthenticatorModel(""))
+}
+
+func TestShouldHaveValidAuthenticatorModels(t *testing.T) {
+	models := map[string]string{}
+	require.NoError(t, json.Unmarshal(authenticatorModelsJSON, &models))
+
+	for aaguid, name := range models {
+		assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$", aaguid)
+		assert.NotEmpty(t, name, aaguid)
+	}
+}
@@ -0,0 +1,222 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrAttestationRejected is wrapped by the errors returned when the authenticator is not allowed by the attestation
+// policy of the relying party.
+var ErrAttestationRejected = errors.New("the authenticator is not allowed")
+
+// oidFIDOGenCEAAGUID is the extension of the attestation certificates holding the AAGUID of the authenticator model.
+var oidFIDOGenCEAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+// FormatAAGUID formats an AAGUID as an UUID.
+func FormatAAGUID(aaguid []byte) string {
+	s := hex.EncodeToString(aaguid)
+	if len(s) != 32 {
+		return s
+	}
+
+	return strings.Join([]string{s[:8], s[8:12], s[12:16], s[16:20], s[20:]}, "-")
+}
+
+// verify checks the attestation statement of the registration against the policy and returns the verified attestation
+// certificate, nil if the policy doesn't verify the attestation statements.
+func (p *AttestationPolicy) verify(attestation map[interface{}]interface{}, authData, clientDataHash []byte, data *authenticatorData) (*x509.Certificate, error) {
+	aaguid := FormatAAGUID(data.aaguid)
+
+	if containsFold(p.DeniedAAGUIDs, aaguid) {
+		return nil, fmt.Errorf("%w: the authenticator model %s is in the denied AAGUIDs", ErrAttestationRejected, aaguid)
+	}
+
+	if len(p.AAGUIDs) != 0 && !containsFold(p.AAGUIDs, aaguid) {
+		return nil, fmt.Errorf("%w: the authenticator model %s is not in the allowed AAGUIDs", ErrAttestationRejected, aaguid)
+	}
+
+	if p.Roots == nil {
+		return nil, nil
+	}
+
+	format, _ := attestation["fmt"].(string)
+	statement, _ := attestation["attStmt"].(map[interface{}]interface{})
+
+	var (
+		certificates []*x509.Certificate
+		err          error
+	)
+
+	switch format {
+	case "packed":
+		certificates, err = verifyPackedAttestation(statement, authData, clientDataHash, data)
+	case "fido-u2f":
+		certificates, err = verifyFIDOU2FAttestation(statement, clientDataHash, data)
+	default:
+		return nil, fmt.Errorf("%w: the attestation format %q is not supported", ErrAttestationRejected, format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	_, err = certificates[0].Verify(x509.VerifyOptions{
+		Roots:         p.Roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: the attestation certificate is not issued by a trusted certificate authority: %s", ErrAttestationRejected, err)
+	}
+
+	return certificates[0], nil
+}
+
+// verifyEnterprise checks the enterprise attestation of an authenticator given its verified attestation certificate,
+// and returns the serial number of the authenticator.
+func (p *AttestationPolicy) verifyEnterprise(certificate *x509.Certificate, data *authenticatorData) (string, error) {
+	aaguid := FormatAAGUID(data.aaguid)
+
+	if !containsFold(p.EnterpriseAAGUIDs, aaguid) {
+		return "", fmt.Errorf("%w: the authenticator model %s is not allowed to provide an enterprise attestation", ErrAttestationRejected, aaguid)
+	}
+
+	if certificate == nil {
+		return "", fmt.Errorf("%w: the enterprise attestation can't be verified without trusted certificate authorities", ErrAttestationRejected)
+	}
+
+	// The enterprise attestation certificates are unique to each authenticator, which is usually identified by the
+	// serial number of the subject.
+	if certificate.Subject.SerialNumber != "" {
+		return certificate.Subject.SerialNumber, nil
+	}
+
+	return certificate.SerialNumber.Text(16), nil
+}
+
+// verifyPackedAttestation verifies a packed attestation statement with an attestation certificate and returns the
+// certificate chain. The self attestations are rejected as they can't be traced to a certificate authority.
+func verifyPackedAttestation(statement map[interface{}]interface{}, authData, clientDataHash []byte, data *authenticatorData) ([]*x509.Certificate, error) {
+	alg, _ := statement["alg"].(int64)
+	signature, _ := statement["sig"].([]byte)
+
+	if _, ok := statement["x5c"]; !ok {
+		return nil, fmt.Errorf("%w: the authenticator uses self attestation", ErrAttestationRejected)
+	}
+
+	certificates, err := parseAttestationCertificates(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &publicKey{algorithm: alg, key: certificates[0].PublicKey}
+	message := append(append([]byte(nil), authData...), clientDataHash...)
+
+	if err = key.verify(message, signature); err != nil {
+		return nil, fmt.Errorf("unable to verify the attestation signature: %w", err)
+	}
+
+	// The certificate of a model must not be used to attest another model.
+	for _, extension := range certificates[0].Extensions {
+		if !extension.Id.Equal(oidFIDOGenCEAAGUID) {
+			continue
+		}
+
+		var aaguid []byte
+
+		if _, err = asn1.Unmarshal(extension.Value, &aaguid); err != nil || !bytes.Equal(aaguid, data.aaguid) {
+			return nil, errors.New("the AAGUID of the attestation certificate does not match the authenticator data")
+		}
+	}
+
+	return certificates, nil
+}
+
+// verifyFIDOU2FAttestation verifies the attestation statement of an U2F security key and returns its attestation
+// certificate.
+func verifyFIDOU2FAttestation(statement map[interface{}]interface{}, clientDataHash []byte, data *authenticatorData) ([]*x509.Certificate, error) {
+	signature, _ := statement["sig"].([]byte)
+
+	certificates, err := parseAttestationCertificates(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(certificates) != 1 {
+		return nil, errors.New("the U2F attestation must have a single certificate")
+	}
+
+	certificateKey, ok := certificates[0].PublicKey.(*ecdsa.PublicKey)
+	if !ok || certificateKey.Curve != elliptic.P256() {
+		return nil, errors.New("the U2F attestation certificate must have a P-256 public key")
+	}
+
+	key, _, err := parsePublicKey(data.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialKey, ok := key.key.(*ecdsa.PublicKey)
+	if !ok || credentialKey.Curve != elliptic.P256() {
+		return nil, errors.New("the U2F credential must have a P-256 public key")
+	}
+
+	message := []byte{0x00}
+	message = append(message, data.rpIDHash...)
+	message = append(message, clientDataHash...)
+	message = append(message, data.credentialID...)
+	message = append(message, elliptic.Marshal(elliptic.P256(), credentialKey.X, credentialKey.Y)...)
+
+	if err = (&publicKey{algorithm: AlgorithmES256, key: certificateKey}).verify(message, signature); err != nil {
+		return nil, fmt.Errorf("unable to verify the attestation signature: %w", err)
+	}
+
+	return certificates, nil
+}
+
+func parseAttestationCertificates(statement map[interface{}]interface{}) ([]*x509.Certificate, error) {
+	x5c, _ := statement["x5c"].([]interface{})
+	if len(x5c) == 0 {
+		return nil, errors.New("the attestation statement has no certificate")
+	}
+
+	certificates := make([]*x509.Certificate, 0, len(x5c))
+
+	for _, item := range x5c {
+		der, ok := item.([]byte)
+		if !ok {
+			return nil, errors.New("invalid attestation certificate")
+		}
+
+		certificate, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse the attestation certificate: %w", err)
+		}
+
+		certificates = append(certificates, certificate)
+	}
+
+	return certificates, nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,258 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testAAGUID = []byte{0xcb, 0x69, 0x48, 0x1e, 0x8f, 0xf7, 0x40, 0x39, 0x93, 0xec, 0x0a, 0x27, 0x29, 0xa1, 0x54, 0xa8}
+
+const testAAGUIDString = "cb69481e-8ff7-4039-93ec-0a2729a154a8"
+
+type testCertificateAuthority struct {
+	key         *ecdsa.PrivateKey
+	certificate *x509.Certificate
+}
+
+func newTestCertificateAuthority(t *testing.T) *testCertificateAuthority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Attestation Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCertificateAuthority{key: key, certificate: certificate}
+}
+
+func (ca *testCertificateAuthority) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.certificate)
+
+	return pool
+}
+
+// issue returns an attestation certificate of the model with the given AAGUID.
+func (ca *testCertificateAuthority) issue(t *testing.T, key *ecdsa.PrivateKey, aaguid []byte) []byte {
+	extension, err := asn1.Marshal(aaguid)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		Subject:         pkix.Name{CommonName: "Authenticator Attestation", SerialNumber: "YK-0123456"},
+		NotBefore:       time.Now().Add(-time.Hour),
+		NotAfter:        time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{{Id: oidFIDOGenCEAAGUID, Value: extension}},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	return der
+}
+
+// createPacked returns the response of the authenticator to a registration with a packed attestation signed by the
+// attestation key with the given certificate.
+func (a *testAuthenticator) createPacked(challenge []byte, aaguid []byte, attestationKey *ecdsa.PrivateKey, certificate []byte) CredentialCreationResponse {
+	response := a.create(challenge)
+
+	attested := append([]byte(nil), aaguid...)
+	attested = append(attested, byte(len(a.id)>>8), byte(len(a.id)))
+	attested = append(attested, a.id...)
+	attested = append(attested, a.publicKey()...)
+
+	authData := a.authenticatorData(a.flags|flagAttestedCredentialData, attested)
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, attestationKey, digest[:])
+	require.NoError(a.t, err)
+
+	response.Response.AttestationObject = cborEncode(map[interface{}]interface{}{
+		"fmt": "packed",
+		"attStmt": map[interface{}]interface{}{
+			"alg": AlgorithmES256,
+			"sig": signature,
+			"x5c": []interface{}{certificate},
+		},
+		"authData": authData,
+	})
+
+	return response
+}
+
+// withEnterpriseAttestation returns the response with the attestation object flagged as an enterprise attestation.
+func withEnterpriseAttestation(t *testing.T, response CredentialCreationResponse) CredentialCreationResponse {
+	value, _, err := cborDecode(response.Response.AttestationObject)
+	require.NoError(t, err)
+
+	attestation := value.(map[interface{}]interface{})
+	attestation["epAtt"] = true
+	response.Response.AttestationObject = cborEncode(attestation)
+
+	return response
+}
+
+func TestShouldRequestDirectAttestationWithPolicy(t *testing.T) {
+	rp := *testRelyingParty
+	rp.Attestation = &AttestationPolicy{AAGUIDs: []string{testAAGUIDString}}
+
+	assert.Equal(t, "direct", rp.NewRegistrationOptions([]byte("challenge"), User{}, nil).Attestation)
+	assert.Equal(t, "none", testRelyingParty.NewRegistrationOptions([]byte("challenge"), User{}, nil).Attestation)
+}
+
+func TestShouldVerifyPackedAttestation(t *testing.T) {
+	ca := newTestCertificateAuthority(t)
+	attestationKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rp := *testRelyingParty
+	rp.Attestation = &AttestationPolicy{Roots: ca.pool(), AAGUIDs: []string{"CB69481E-8FF7-4039-93EC-0A2729A154A8"}}
+
+	authenticator := newTestAuthenticator(t)
+	challenge := []byte("challenge")
+
+	credential, err := rp.VerifyRegistration(challenge, authenticator.createPacked(challenge, testAAGUID, attestationKey, ca.issue(t, attestationKey, testAAGUID)))
+	require.NoError(t, err)
+	assert.Equal(t, authenticator.id, credential.ID)
+	assert.Equal(t, testAAGUIDString, credential.AAGUID)
+}
+
+func TestShouldRejectUntrustedAttestation(t *testing.T) {
+	ca := newTestCertificateAuthority(t)
+	attestationKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rp := *testRelyingParty
+	rp.Attestation = &AttestationPolicy{Roots: newTestCertificateAuthority(t).pool()}
+
+	authenticator := newTestAuthenticator(t)
+	challenge := []byte("challenge")
+
+	_, err = rp.VerifyRegistration(challenge, authenticator.createPacked(challenge, testAAGUID, attestationKey, ca.issue(t, attestationKey, testAAGUID)))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrAttestationRejected))
+	assert.Contains(t, err.Error(), "the authenticator is not allowed: the attestation certificate is not issued by a trusted certificate authority")
+
+	_, err = rp.VerifyRegistration(challenge, authenticator.create(challenge))
+	assert.EqualError(t, err, `the authenticator is not allowed: the attestation format "none" is not supported`)
+	assert.True(t, errors.Is(err, ErrAttestationRejected))
+}
+
+func TestShouldRejectAttestationOfOtherModel(t *testing.T) {
+	ca := newTestCertificateAuthority(t)
+	attestationKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	authenticator := newTestAuthenticator(t)
+	challenge := []byte("challenge")
+	other := make([]byte, 16)
+
+	rp := *testRelyingParty
+	rp.Attestation = &AttestationPolicy{AAGUIDs: []string{testAAGUIDString}}
+
+	_, err = rp.VerifyRegistration(challenge, authenticator.createPacked(challenge, other, attestationKey, ca.issue(t, attestationKey, other)))
+	assert.EqualError(t, err, "the authenticator is not allowed: the authenticator model 00000000-0000-0000-0000-000000000000 is not in the allowed AAGUIDs")
+
+	rp.Attestation = &AttestationPolicy{DeniedAAGUIDs: []string{"00000000-0000-0000-0000-000000000000"}}
+
+	_, err = rp.VerifyRegistration(challenge, authenticator.createPacked(challenge, other, attestationKey, ca.issue(t, attestationKey, other)))
+	assert.EqualError(t, err, "the authenticator is not allowed: the authenticator model 00000000-0000-0000-0000-000000000000 is in the denied AAGUIDs")
+
+	_, err = rp.VerifyRegistration(challenge, authenticator.createPacked(challenge, testAAGUID, attestationKey, ca.issue(t, attestationKey, testAAGUID)))
+	assert.NoError(t, err)
+
+	// The certificate of a model can't attest another model.
+	rp.Attestation = &AttestationPolicy{Roots: ca.pool()}
+
+	_, err = rp.VerifyRegistration(challenge, authenticator.createPacked(challenge, testAAGUID, attestationKey, ca.issue(t, attestationKey, other)))
+	assert.EqualError(t, err, "the AAGUID of the attestation certificate does not match the authenticator data")
+}
+
+func TestShouldRequestEnterpriseAttestationForConfiguredRelyingParties(t *testing.T) {
+	rp := *testRelyingParty
+	rp.Attestation = &AttestationPolicy{EnterpriseRPIDs: []string{"example.com"}, EnterpriseAAGUIDs: []string{testAAGUIDString}}
+
+	assert.Equal(t, "enterprise", rp.NewRegistrationOptions([]byte("challenge"), User{}, nil).Attestation)
+
+	rp.ID = "other.com"
+
+	assert.Equal(t, "direct", rp.NewRegistrationOptions([]byte("challenge"), User{}, nil).Attestation)
+}
+
+func TestShouldVerifyEnterpriseAttestation(t *testing.T) {
+	ca := newTestCertificateAuthority(t)
+	attestationKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rp := *testRelyingParty
+	rp.Attestation = &AttestationPolicy{Roots: ca.pool(), EnterpriseRPIDs: []string{"example.com"}, EnterpriseAAGUIDs: []string{testAAGUIDString}}
+
+	authenticator := newTestAuthenticator(t)
+	challenge := []byte("challenge")
+	certificate := ca.issue(t, attestationKey, testAAGUID)
+
+	credential, err := rp.VerifyRegistration(challenge, withEnterpriseAttestation(t, authenticator.createPacked(challenge, testAAGUID, attestationKey, certificate)))
+	require.NoError(t, err)
+	assert.Equal(t, "YK-0123456", credential.EnterpriseSerialNumber)
+
+	// The browsers not provisioned for the relying party downgrade the attestation to a direct one.
+	credential, err = rp.VerifyRegistration(challenge, authenticator.createPacked(challenge, testAAGUID, attestationKey, certificate))
+	require.NoError(t, err)
+	assert.Equal(t, "", credential.EnterpriseSerialNumber)
+}
+
+func TestShouldRejectUnexpectedEnterpriseAttestation(t *testing.T) {
+	ca := newTestCertificateAuthority(t)
+	attestationKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	authenticator := newTestAuthenticator(t)
+	challenge := []byte("challenge")
+	other := make([]byte, 16)
+	other[15] = 1
+
+	rp := *testRelyingParty
+	rp.Attestation = &AttestationPolicy{Roots: ca.pool(), EnterpriseRPIDs: []string{"example.com"}, EnterpriseAAGUIDs: []string{testAAGUIDString}}
+
+	_, err = rp.VerifyRegistration(challenge, withEnterpriseAttestation(t, authenticator.createPacked(challenge, other, attestationKey, ca.issue(t, attestationKey, other))))
+	assert.EqualError(t, err, "the authenticator is not allowed: the authenticator model 00000000-0000-0000-0000-000000000001 is not allowed to provide an enterprise attestation")
+	assert.True(t, errors.Is(err, ErrAttestationRejected))
+
+	rp.Attestation = &AttestationPolicy{EnterpriseRPIDs: []string{"example.com"}, EnterpriseAAGUIDs: []string{testAAGUIDString}}
+
+	_, err = rp.VerifyRegistration(challenge, withEnterpriseAttestation(t, authenticator.createPacked(challenge, testAAGUID, attestationKey, ca.issue(t, attestationKey, testAAGUID))))
+	assert.EqualError(t, err, "the authenticator is not allowed: the enterprise attestation can't be verified without trusted certificate authorities")
+
+	rp.Attestation = &AttestationPolicy{Roots: ca.pool(), EnterpriseRPIDs: []string{"other.com"}, EnterpriseAAGUIDs: []string{testAAGUIDString}}
+
+	_, err = rp.VerifyRegistration(challenge, withEnterpriseAttestation(t, authenticator.createPacked(challenge, testAAGUID, attestationKey, ca.issue(t, attestationKey, testAAGUID))))
+	assert.EqualError(t, err, "the authenticator is not allowed: the enterprise attestation was not requested for the relying party example.com")
+
+	_, err = testRelyingParty.VerifyRegistration(challenge, withEnterpriseAttestation(t, authenticator.create(challenge)))
+	assert.EqualError(t, err, "the authenticator is not allowed: the enterprise attestation was not requested for the relying party example.com")
+}
@@ -0,0 +1,207 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// cborMaxDepth is the maximum nesting of the arrays and maps decoded, the structures used by WebAuthn are only a few
+// levels deep.
+const cborMaxDepth = 16
+
+// CBOR major types.
+const (
+	cborUnsignedInt = iota
+	cborNegativeInt
+	cborByteString
+	cborTextString
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+var errCBORTruncated = errors.New("cbor: unexpected end of data")
+
+// cborDecode decodes the first CBOR data item of data and returns it along with the data following it. The integers
+// are decoded as int64, the byte strings as []byte, the text strings as string, the arrays as []interface{} and the
+// maps as map[interface{}]interface{}. The tags are ignored and the indefinite length items are not supported as
+// the authenticators are required to use the canonical encoding.
+func cborDecode(data []byte) (value interface{}, rest []byte, err error) {
+	d := &cborDecoder{data: data}
+
+	if value, err = d.decode(0); err != nil {
+		return nil, nil, err
+	}
+
+	return value, d.data[d.offset:], nil
+}
+
+type cborDecoder struct {
+	data   []byte
+	offset int
+}
+
+func (d *cborDecoder) read(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.offset) {
+		return nil, errCBORTruncated
+	}
+
+	b := d.data[d.offset : d.offset+int(n)]
+	d.offset += int(n)
+
+	return b, nil
+}
+
+// head decodes the initial byte of a data item and its argument.
+func (d *cborDecoder) head() (major byte, info byte, argument uint64, err error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	major, info = b[0]>>5, b[0]&0x1f
+
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info == 24:
+		if b, err = d.read(1); err != nil {
+			return 0, 0, 0, err
+		}
+
+		return major, info, uint64(b[0]), nil
+	case info == 25:
+		if b, err = d.read(2); err != nil {
+			return 0, 0, 0, err
+		}
+
+		return major, info, uint64(binary.BigEndian.Uint16(b)), nil
+	case info == 26:
+		if b, err = d.read(4); err != nil {
+			return 0, 0, 0, err
+		}
+
+		return major, info, uint64(binary.BigEndian.Uint32(b)), nil
+	case info == 27:
+		if b, err = d.read(8); err != nil {
+			return 0, 0, 0, err
+		}
+
+		return major, info, binary.BigEndian.Uint64(b), nil
+	default:
+		return 0, 0, 0, fmt.Errorf("cbor: unsupported additional information %d", info)
+	}
+}
+
+func (d *cborDecoder) decode(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, errors.New("cbor: maximum nesting depth exceeded")
+	}
+
+	major, info, argument, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUnsignedInt:
+		if argument > math.MaxInt64 {
+			return nil, errors.New("cbor: integer overflow")
+		}
+
+		return int64(argument), nil
+	case cborNegativeInt:
+		if argument > math.MaxInt64 {
+			return nil, errors.New("cbor: integer overflow")
+		}
+
+		return -1 - int64(argument), nil
+	case cborByteString:
+		b, err := d.read(argument)
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]byte(nil), b...), nil
+	case cborTextString:
+		b, err := d.read(argument)
+		if err != nil {
+			return nil, err
+		}
+
+		return string(b), nil
+	case cborArray:
+		if argument > uint64(len(d.data)-d.offset) {
+			return nil, errCBORTruncated
+		}
+
+		array := make([]interface{}, 0, argument)
+
+		for i := uint64(0); i < argument; i++ {
+			item, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+
+			array = append(array, item)
+		}
+
+		return array, nil
+	case cborMap:
+		if argument > uint64(len(d.data)-d.offset) {
+			return nil, errCBORTruncated
+		}
+
+		m := make(map[interface{}]interface{}, argument)
+
+		for i := uint64(0); i < argument; i++ {
+			key, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, fmt.Errorf("cbor: unsupported map key of type %T", key)
+			}
+
+			if _, ok := m[key]; ok {
+				return nil, fmt.Errorf("cbor: duplicate map key %v", key)
+			}
+
+			if m[key], err = d.decode(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+
+		return m, nil
+	case cborTag:
+		return d.decode(depth + 1)
+	case cborSimple:
+		return d.simple(info, argument)
+	default:
+		return nil, fmt.Errorf("cbor: unsupported major type %d", major)
+	}
+}
+
+// simple decodes the simple values and the floating point numbers.
+func (d *cborDecoder) simple(info byte, argument uint64) (interface{}, error) {
+	switch {
+	case info == 20:
+		return false, nil
+	case info == 21:
+		return true, nil
+	case info == 22, info == 23:
+		return nil, nil
+	case info == 26:
+		return float64(math.Float32frombits(uint32(argument))), nil
+	case info == 27:
+		return math.Float64frombits(argument), nil
+	default:
+		return nil, fmt.Errorf("cbor: unsupported simple value %d", argument)
+	}
+}
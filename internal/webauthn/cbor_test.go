@@ -0,0 +1,136 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cborEncode encodes the values produced by the authenticators of the tests.
+func cborEncode(value interface{}) []byte {
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return cborHead(cborNegativeInt, uint64(-1-v))
+		}
+
+		return cborHead(cborUnsignedInt, uint64(v))
+	case int64:
+		return cborEncode(int(v))
+	case []byte:
+		return append(cborHead(cborByteString, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(cborTextString, uint64(len(v))), v...)
+	case bool:
+		if v {
+			return []byte{0xf5}
+		}
+
+		return []byte{0xf4}
+	case []interface{}:
+		data := cborHead(cborArray, uint64(len(v)))
+		for _, item := range v {
+			data = append(data, cborEncode(item)...)
+		}
+
+		return data
+	case map[interface{}]interface{}:
+		entries := make([][]byte, 0, len(v))
+		for key, item := range v {
+			entries = append(entries, append(cborEncode(key), cborEncode(item)...))
+		}
+
+		sort.Slice(entries, func(i, j int) bool { return string(entries[i]) < string(entries[j]) })
+
+		data := cborHead(cborMap, uint64(len(v)))
+		for _, entry := range entries {
+			data = append(data, entry...)
+		}
+
+		return data
+	default:
+		panic("unsupported value")
+	}
+}
+
+func cborHead(major byte, argument uint64) []byte {
+	switch {
+	case argument < 24:
+		return []byte{major<<5 | byte(argument)}
+	case argument <= 0xff:
+		return []byte{major<<5 | 24, byte(argument)}
+	case argument <= 0xffff:
+		data := []byte{major<<5 | 25, 0, 0}
+		binary.BigEndian.PutUint16(data[1:], uint16(argument))
+
+		return data
+	default:
+		data := []byte{major<<5 | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(data[1:], uint32(argument))
+
+		return data
+	}
+}
+
+func TestShouldDecodeCBOR(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected interface{}
+	}{
+		{"UnsignedInt", "1903e8", int64(1000)},
+		{"NegativeInt", "3903e7", int64(-1000)},
+		{"ByteString", "4401020304", []byte{1, 2, 3, 4}},
+		{"TextString", "6449455446", "IETF"},
+		{"Array", "83010203", []interface{}{int64(1), int64(2), int64(3)}},
+		{"Map", "a201020304", map[interface{}]interface{}{int64(1): int64(2), int64(3): int64(4)}},
+		{"Tag", "c11a514b67b0", int64(1363896240)},
+		{"True", "f5", true},
+		{"Null", "f6", nil},
+		{"Float", "fb3ff199999999999a", 1.1},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tc.data + "ff")
+			require.NoError(t, err)
+
+			value, rest, err := cborDecode(data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, value)
+			assert.Equal(t, []byte{0xff}, rest)
+		})
+	}
+}
+
+func TestShouldNotDecodeInvalidCBOR(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"Truncated", "440102", "cbor: unexpected end of data"},
+		{"IndefiniteLength", "5f42010243030405ff", "cbor: unsupported additional information 31"},
+		{"DuplicateKey", "a201020103", "cbor: duplicate map key 1"},
+		{"ArrayKey", "a1800102", "cbor: unsupported map key of type []interface {}"},
+		{"Overflow", "1bffffffffffffffff", "cbor: integer overflow"},
+		{"TooLong", "9affffffff", "cbor: unexpected end of data"},
+		{"TooDeep", "8181818181818181818181818181818181818101", "cbor: maximum nesting depth exceeded"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tc.data)
+			require.NoError(t, err)
+
+			_, _, err = cborDecode(data)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
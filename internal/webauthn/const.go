@@ -0,0 +1,48 @@
+package webauthn
+
+// challengeLength is the length of the challenges and of the user handles, the specification recommends at least 16
+// random bytes.
+const challengeLength = 32
+
+// credentialIDMaxLength is the maximum length of the credential IDs allowed by the specification.
+const credentialIDMaxLength = 1023
+
+// authenticatorDataMinLength is the length of the RP ID hash, the flags and the signature counter of the authenticator
+// data.
+const authenticatorDataMinLength = 37
+
+const publicKeyCredentialType = "public-key"
+
+const (
+	clientDataTypeCreate = "webauthn.create"
+	clientDataTypeGet    = "webauthn.get"
+)
+
+// Flags of the authenticator data.
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagBackupEligible         = 0x08
+	flagBackupState            = 0x10
+	flagAttestedCredentialData = 0x40
+)
+
+// Requirements of the relying party on the storage of the credentials by the authenticators.
+const (
+	ResidentKeyDiscouraged = "discouraged"
+	ResidentKeyPreferred   = "preferred"
+	ResidentKeyRequired    = "required"
+)
+
+// Attachments of the authenticators the relying party offers to register credentials with.
+const (
+	AttachmentAny           = "any"
+	AttachmentPlatform      = "platform"
+	AttachmentCrossPlatform = "cross-platform"
+)
+
+// Requirements of the relying party on the verification of the user by the authenticators.
+const (
+	UserVerificationPreferred = "preferred"
+	UserVerificationRequired  = "required"
+)
@@ -0,0 +1,211 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // Registers the hashes used by the signature algorithms.
+	_ "crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE key parameters, see https://www.iana.org/assignments/cose/cose.xhtml.
+const (
+	coseKeyType      = 1
+	coseKeyAlgorithm = 3
+	coseKeyCurve     = -1
+	coseKeyX         = -2
+	coseKeyY         = -3
+	coseKeyN         = -1
+	coseKeyE         = -2
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveP384    = 2
+	coseCurveP521    = 3
+	coseCurveEd25519 = 6
+)
+
+// COSE algorithms supported for the signatures of the authenticators.
+const (
+	AlgorithmES256 = -7
+	AlgorithmES384 = -35
+	AlgorithmES512 = -36
+	AlgorithmEdDSA = -8
+	AlgorithmPS256 = -37
+	AlgorithmRS256 = -257
+)
+
+// SupportedAlgorithms are the COSE algorithms accepted for the public keys of the credentials, the preferred first.
+var SupportedAlgorithms = []int{AlgorithmES256, AlgorithmEdDSA, AlgorithmES384, AlgorithmES512, AlgorithmPS256, AlgorithmRS256}
+
+var errUnsupportedKey = errors.New("unsupported public key")
+
+// publicKey is a COSE encoded public key of a credential.
+type publicKey struct {
+	algorithm int64
+	key       crypto.PublicKey
+}
+
+// parsePublicKey decodes a COSE encoded public key and returns the data following it.
+func parsePublicKey(data []byte) (key *publicKey, rest []byte, err error) {
+	value, rest, err := cborDecode(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, nil, errors.New("public key is not a map")
+	}
+
+	kty, _ := m[int64(coseKeyType)].(int64)
+	alg, _ := m[int64(coseKeyAlgorithm)].(int64)
+
+	key = &publicKey{algorithm: alg}
+
+	switch kty {
+	case coseKeyTypeEC2:
+		key.key, err = parseEC2PublicKey(m, alg)
+	case coseKeyTypeOKP:
+		key.key, err = parseOKPPublicKey(m, alg)
+	case coseKeyTypeRSA:
+		key.key, err = parseRSAPublicKey(m, alg)
+	default:
+		err = fmt.Errorf("%w: key type %d", errUnsupportedKey, kty)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, rest, nil
+}
+
+// ValidatePublicKey checks the data is a single COSE encoded public key of one of the supported algorithms, for example
+// before importing a credential registered with another relying party implementation.
+func ValidatePublicKey(data []byte) error {
+	_, rest, err := parsePublicKey(data)
+	if err != nil {
+		return err
+	}
+
+	if len(rest) != 0 {
+		return errors.New("unexpected data after the public key")
+	}
+
+	return nil
+}
+
+func parseEC2PublicKey(m map[interface{}]interface{}, alg int64) (crypto.PublicKey, error) {
+	crv, _ := m[int64(coseKeyCurve)].(int64)
+	x, _ := m[int64(coseKeyX)].([]byte)
+	y, _ := m[int64(coseKeyY)].([]byte)
+
+	var curve elliptic.Curve
+
+	switch {
+	case crv == coseCurveP256 && alg == AlgorithmES256:
+		curve = elliptic.P256()
+	case crv == coseCurveP384 && alg == AlgorithmES384:
+		curve = elliptic.P384()
+	case crv == coseCurveP521 && alg == AlgorithmES512:
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("%w: curve %d with algorithm %d", errUnsupportedKey, crv, alg)
+	}
+
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, errors.New("public key is not on its curve")
+	}
+
+	return key, nil
+}
+
+func parseOKPPublicKey(m map[interface{}]interface{}, alg int64) (crypto.PublicKey, error) {
+	crv, _ := m[int64(coseKeyCurve)].(int64)
+	x, _ := m[int64(coseKeyX)].([]byte)
+
+	if crv != coseCurveEd25519 || alg != AlgorithmEdDSA {
+		return nil, fmt.Errorf("%w: curve %d with algorithm %d", errUnsupportedKey, crv, alg)
+	}
+
+	if len(x) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid Ed25519 public key size")
+	}
+
+	return ed25519.PublicKey(x), nil
+}
+
+func parseRSAPublicKey(m map[interface{}]interface{}, alg int64) (crypto.PublicKey, error) {
+	n, _ := m[int64(coseKeyN)].([]byte)
+	e, _ := m[int64(coseKeyE)].([]byte)
+
+	if alg != AlgorithmRS256 && alg != AlgorithmPS256 {
+		return nil, fmt.Errorf("%w: RSA with algorithm %d", errUnsupportedKey, alg)
+	}
+
+	if len(e) == 0 || len(e) > 4 || len(n) < 256 {
+		return nil, errors.New("invalid RSA public key")
+	}
+
+	exponent := 0
+	for _, b := range e {
+		exponent = exponent<<8 | int(b)
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}, nil
+}
+
+// verify checks the signature of the message with the public key.
+func (k *publicKey) verify(message, signature []byte) error {
+	var valid bool
+
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest(ecdsaHash(k.algorithm), message), signature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, message, signature)
+	case *rsa.PublicKey:
+		hashed := digest(crypto.SHA256, message)
+
+		if k.algorithm == AlgorithmPS256 {
+			valid = rsa.VerifyPSS(key, crypto.SHA256, hashed, signature, nil) == nil
+		} else {
+			valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed, signature) == nil
+		}
+	}
+
+	if !valid {
+		return errors.New("invalid signature")
+	}
+
+	return nil
+}
+
+func ecdsaHash(alg int64) crypto.Hash {
+	switch alg {
+	case AlgorithmES384:
+		return crypto.SHA384
+	case AlgorithmES512:
+		return crypto.SHA512
+	default:
+		return crypto.SHA256
+	}
+}
+
+func digest(hash crypto.Hash, message []byte) []byte {
+	h := hash.New()
+	h.Write(message)
+
+	return h.Sum(nil)
+}
@@ -0,0 +1,168 @@
+package webauthn
+
+import (
+	"crypto/x509"
+	"time"
+)
+
+// URLEncodedBase64 is a byte slice encoded as a base64url string in JSON, as the binary values of the WebAuthn API are
+// converted by the portal.
+type URLEncodedBase64 []byte
+
+// RelyingParty is the WebAuthn relying party, which registers the credentials and verifies the assertions.
+type RelyingParty struct {
+	// ID is the domain the credentials are scoped to.
+	ID string
+	// Name is the name of the relying party displayed by the authenticators.
+	Name string
+	// Origin is the origin of the portal performing the ceremonies.
+	Origin string
+	// AllowedOrigins are the origins allowed to perform the ceremonies instead of Origin, empty to only allow Origin.
+	AllowedOrigins []string
+	// RegistrationTimeout is the time the user has to complete the registration of a credential.
+	RegistrationTimeout time.Duration
+	// AssertionTimeout is the time the user has to complete the assertion of a credential.
+	AssertionTimeout time.Duration
+	// ResidentKey is the requirement of the relying party on the storage of the credentials by the authenticators, one
+	// of discouraged, preferred or required.
+	ResidentKey string
+	// Attachment restricts the authenticators offered to register credentials to the ones built into the device of the
+	// user, platform, or to the roaming ones, cross-platform. Empty or any offers both.
+	Attachment string
+	// UserVerification is the requirement of the relying party on the verification of the user by the authenticators,
+	// required or preferred. Empty requires it.
+	UserVerification string
+	// Attestation is the policy restricting the authenticators allowed to register credentials, nil to allow any
+	// authenticator.
+	Attestation *AttestationPolicy
+}
+
+// AttestationPolicy restricts the authenticators allowed to register credentials to the ones attesting a certificate
+// issued by a trusted certificate authority or to the models with the given AAGUIDs, and denies the models with the
+// given denied AAGUIDs. It also allows the authenticators provisioned by an organization to identify themselves with an
+// enterprise attestation.
+type AttestationPolicy struct {
+	// Roots are the certificate authorities the attestation certificates must be issued by, nil to not verify the
+	// attestation statements.
+	Roots *x509.CertPool
+	// AAGUIDs are the AAGUIDs of the allowed authenticator models, formatted as UUIDs, empty to allow any model.
+	AAGUIDs []string
+	// DeniedAAGUIDs are the AAGUIDs of the denied authenticator models, formatted as UUIDs.
+	DeniedAAGUIDs []string
+	// EnterpriseRPIDs are the relying party IDs the enterprise attestation is requested for. The organization must have
+	// provisioned the authenticators and the browsers with these relying party IDs.
+	EnterpriseRPIDs []string
+	// EnterpriseAAGUIDs are the AAGUIDs of the authenticator models allowed to provide an enterprise attestation,
+	// formatted as UUIDs.
+	EnterpriseAAGUIDs []string
+}
+
+// Credential is a credential registered by an authenticator.
+type Credential struct {
+	ID        []byte
+	PublicKey []byte
+	SignCount uint32
+	// BackupEligible is whether the authenticator allows the credential to be backed up, i.e. synced between the
+	// devices of the user.
+	BackupEligible bool
+	// BackupState is whether the credential was backed up at its last use.
+	BackupState bool
+	// AAGUID identifies the model of the authenticator, empty if the authenticator didn't disclose it.
+	AAGUID string
+	// EnterpriseSerialNumber is the serial number of the authenticator disclosed by an enterprise attestation, empty if
+	// the authenticator provided another kind of attestation.
+	EnterpriseSerialNumber string
+}
+
+// RelyingPartyEntity describes the relying party in the registration options.
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// User describes the user in the registration options.
+type User struct {
+	ID          URLEncodedBase64 `json:"id"`
+	Name        string           `json:"name"`
+	DisplayName string           `json:"displayName"`
+}
+
+// CredentialParameter is a type of credential accepted by the relying party.
+type CredentialParameter struct {
+	Type      string `json:"type"`
+	Algorithm int    `json:"alg"`
+}
+
+// CredentialDescriptor identifies a credential.
+type CredentialDescriptor struct {
+	Type string           `json:"type"`
+	ID   URLEncodedBase64 `json:"id"`
+}
+
+// AuthenticatorSelection are the requirements of the relying party on the authenticators.
+type AuthenticatorSelection struct {
+	AuthenticatorAttachment string `json:"authenticatorAttachment,omitempty"`
+	ResidentKey             string `json:"residentKey"`
+	RequireResidentKey      bool   `json:"requireResidentKey"`
+	UserVerification        string `json:"userVerification"`
+}
+
+// CredentialCreationOptions are the options of navigator.credentials.create().
+type CredentialCreationOptions struct {
+	Challenge              URLEncodedBase64       `json:"challenge"`
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   User                   `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// CredentialRequestOptions are the options of navigator.credentials.get(). The allowed credentials are left empty so
+// the authenticator offers any of its discoverable credentials.
+type CredentialRequestOptions struct {
+	Challenge        URLEncodedBase64 `json:"challenge"`
+	RPID             string           `json:"rpId"`
+	Timeout          int64            `json:"timeout"`
+	UserVerification string           `json:"userVerification"`
+}
+
+// CredentialCreationResponse is the credential returned by navigator.credentials.create().
+type CredentialCreationResponse struct {
+	ID       URLEncodedBase64 `json:"id"`
+	Type     string           `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON"`
+		AttestationObject URLEncodedBase64 `json:"attestationObject"`
+	} `json:"response"`
+}
+
+// CredentialAssertionResponse is the credential returned by navigator.credentials.get().
+type CredentialAssertionResponse struct {
+	ID       URLEncodedBase64 `json:"id"`
+	Type     string           `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON"`
+		AuthenticatorData URLEncodedBase64 `json:"authenticatorData"`
+		Signature         URLEncodedBase64 `json:"signature"`
+		UserHandle        URLEncodedBase64 `json:"userHandle"`
+	} `json:"response"`
+}
+
+// collectedClientData is the client data signed by the authenticators.
+type collectedClientData struct {
+	Type      string           `json:"type"`
+	Challenge URLEncodedBase64 `json:"challenge"`
+	Origin    string           `json:"origin"`
+}
+
+type authenticatorData struct {
+	rpIDHash       []byte
+	aaguid         []byte
+	signCount      uint32
+	backupEligible bool
+	backupState    bool
+	credentialID   []byte
+	publicKey      []byte
+}
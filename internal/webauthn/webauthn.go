@@ -0,0 +1,352 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrCloneDetected is wrapped by the errors returned when the signature counter of an assertion did not increase, which
+// means the authenticator may have been cloned.
+var ErrCloneDetected = errors.New("the authenticator may have been cloned")
+
+// NewChallenge returns a random challenge for a registration or an assertion.
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, challengeLength)
+
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// NewUserHandle returns a random user handle, which identifies the user in the credentials without revealing their
+// username.
+func NewUserHandle() ([]byte, error) {
+	return NewChallenge()
+}
+
+// MarshalJSON encodes the bytes as an unpadded base64url string.
+func (b URLEncodedBase64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON decodes the bytes from a base64url string, padded or not.
+func (b *URLEncodedBase64) UnmarshalJSON(data []byte) error {
+	var s string
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+
+	*b = decoded
+
+	return nil
+}
+
+// NewRegistrationOptions returns the options of the registration of a credential by the user with the given handle,
+// excluding the credentials already registered by the user. The credential is discoverable unless the resident key
+// requirement of the relying party is discouraged or preferred.
+func (rp *RelyingParty) NewRegistrationOptions(challenge []byte, user User, exclude [][]byte) CredentialCreationOptions {
+	residentKey := rp.ResidentKey
+	if residentKey == "" {
+		residentKey = ResidentKeyRequired
+	}
+
+	options := CredentialCreationOptions{
+		Challenge: challenge,
+		RP:        RelyingPartyEntity{ID: rp.ID, Name: rp.Name},
+		User:      user,
+		Timeout:   rp.RegistrationTimeout.Milliseconds(),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:        residentKey,
+			RequireResidentKey: residentKey == ResidentKeyRequired,
+			UserVerification:   rp.userVerification(),
+		},
+		Attestation: "none",
+	}
+
+	if rp.Attachment != AttachmentAny {
+		options.AuthenticatorSelection.AuthenticatorAttachment = rp.Attachment
+	}
+
+	// The authenticators only provide their attestation statement and AAGUID when asked for a direct attestation, the
+	// browsers downgrade the enterprise attestations to direct ones for the relying parties they are not provisioned for.
+	if rp.enterpriseAttestation() {
+		options.Attestation = "enterprise"
+	} else if rp.Attestation != nil {
+		options.Attestation = "direct"
+	}
+
+	for _, alg := range SupportedAlgorithms {
+		options.PubKeyCredParams = append(options.PubKeyCredParams, CredentialParameter{Type: publicKeyCredentialType, Algorithm: alg})
+	}
+
+	for _, id := range exclude {
+		options.ExcludeCredentials = append(options.ExcludeCredentials, CredentialDescriptor{Type: publicKeyCredentialType, ID: id})
+	}
+
+	return options
+}
+
+// NewAssertionOptions returns the options of an assertion with any of the discoverable credentials of the relying party.
+func (rp *RelyingParty) NewAssertionOptions(challenge []byte) CredentialRequestOptions {
+	return CredentialRequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.ID,
+		Timeout:          rp.AssertionTimeout.Milliseconds(),
+		UserVerification: rp.userVerification(),
+	}
+}
+
+// VerifyRegistration verifies the response of the authenticator to the registration options with the challenge and
+// returns the registered credential. The attestation statement is only verified when the relying party has an
+// attestation policy, in which case the errors related to the policy wrap ErrAttestationRejected. The enterprise
+// attestations are rejected unless the policy requests them for the relying party.
+func (rp *RelyingParty) VerifyRegistration(challenge []byte, response CredentialCreationResponse) (*Credential, error) {
+	if response.Type != publicKeyCredentialType {
+		return nil, fmt.Errorf("invalid credential type %s", response.Type)
+	}
+
+	if err := rp.verifyClientData(response.Response.ClientDataJSON, clientDataTypeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	value, _, err := cborDecode(response.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the attestation object: %w", err)
+	}
+
+	attestation, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("the attestation object is not a map")
+	}
+
+	authData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("the attestation object has no authenticator data")
+	}
+
+	data, err := rp.parseAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.credentialID == nil {
+		return nil, errors.New("the authenticator data has no attested credential data")
+	}
+
+	if !bytes.Equal(data.credentialID, response.ID) {
+		return nil, errors.New("the ID of the credential does not match the attested credential data")
+	}
+
+	credential := &Credential{
+		ID:             data.credentialID,
+		PublicKey:      data.publicKey,
+		SignCount:      data.signCount,
+		BackupEligible: data.backupEligible,
+		BackupState:    data.backupState,
+		AAGUID:         registeredAAGUID(data.aaguid),
+	}
+
+	enterprise, _ := attestation["epAtt"].(bool)
+
+	if enterprise && !rp.enterpriseAttestation() {
+		return nil, fmt.Errorf("%w: the enterprise attestation was not requested for the relying party %s", ErrAttestationRejected, rp.ID)
+	}
+
+	if rp.Attestation == nil {
+		return credential, nil
+	}
+
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+
+	certificate, err := rp.Attestation.verify(attestation, authData, clientDataHash[:], data)
+	if err != nil {
+		return nil, err
+	}
+
+	if enterprise {
+		if credential.EnterpriseSerialNumber, err = rp.Attestation.verifyEnterprise(certificate, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return credential, nil
+}
+
+// registeredAAGUID returns the AAGUID of the authenticator of a registration, or an empty string when the authenticator
+// or the browser replaced it with zeros to not disclose the model of the authenticator.
+func registeredAAGUID(aaguid []byte) string {
+	if len(aaguid) == 0 || bytes.Equal(aaguid, make([]byte, len(aaguid))) {
+		return ""
+	}
+
+	return FormatAAGUID(aaguid)
+}
+
+// VerifyAssertion verifies the response of the authenticator to the assertion options with the challenge, given the
+// public key and the signature counter of the credential. It returns the credential with the signature counter and
+// the backup flags of the assertion.
+func (rp *RelyingParty) VerifyAssertion(challenge []byte, response CredentialAssertionResponse, credential Credential) (*Credential, error) {
+	if response.Type != publicKeyCredentialType {
+		return nil, fmt.Errorf("invalid credential type %s", response.Type)
+	}
+
+	if err := rp.verifyClientData(response.Response.ClientDataJSON, clientDataTypeGet, challenge); err != nil {
+		return nil, err
+	}
+
+	data, err := rp.parseAuthenticatorData(response.Response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	key, _, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the public key of the credential: %w", err)
+	}
+
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+	message := append(append([]byte(nil), response.Response.AuthenticatorData...), clientDataHash[:]...)
+
+	if err = key.verify(message, response.Response.Signature); err != nil {
+		return nil, err
+	}
+
+	// The authenticators which don't implement a signature counter always return zero, any other value must increase
+	// or the credential may have been cloned.
+	if (data.signCount != 0 || credential.SignCount != 0) && data.signCount <= credential.SignCount {
+		return nil, fmt.Errorf("%w: the signature counter %d is not greater than the stored counter %d", ErrCloneDetected, data.signCount, credential.SignCount)
+	}
+
+	credential.SignCount = data.signCount
+	credential.BackupEligible = data.backupEligible
+	credential.BackupState = data.backupState
+
+	return &credential, nil
+}
+
+func (rp *RelyingParty) verifyClientData(clientDataJSON []byte, clientDataType string, challenge []byte) error {
+	var clientData collectedClientData
+
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil {
+		return fmt.Errorf("unable to parse the client data: %w", err)
+	}
+
+	if clientData.Type != clientDataType {
+		return fmt.Errorf("invalid client data type %s", clientData.Type)
+	}
+
+	if len(challenge) == 0 || subtle.ConstantTimeCompare(clientData.Challenge, challenge) != 1 {
+		return errors.New("the challenge does not match")
+	}
+
+	if len(rp.AllowedOrigins) != 0 {
+		for _, origin := range rp.AllowedOrigins {
+			if clientData.Origin == origin {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("the origin %s is not one of the allowed origins of the relying party", clientData.Origin)
+	}
+
+	if clientData.Origin != rp.Origin {
+		return fmt.Errorf("the origin %s does not match the origin %s of the relying party", clientData.Origin, rp.Origin)
+	}
+
+	return nil
+}
+
+// enterpriseAttestation returns true if the relying party requests an enterprise attestation.
+func (rp *RelyingParty) enterpriseAttestation() bool {
+	return rp.Attestation != nil && len(rp.Attestation.EnterpriseAAGUIDs) != 0 && containsFold(rp.Attestation.EnterpriseRPIDs, rp.ID)
+}
+
+// userVerification returns the requirement of the relying party on the verification of the user.
+func (rp *RelyingParty) userVerification() string {
+	if rp.UserVerification == "" {
+		return UserVerificationRequired
+	}
+
+	return rp.UserVerification
+}
+
+// parseAuthenticatorData parses the authenticator data and checks it was issued for the relying party to a present
+// user, who was verified unless the relying party only prefers it.
+func (rp *RelyingParty) parseAuthenticatorData(authData []byte) (*authenticatorData, error) {
+	if len(authData) < authenticatorDataMinLength {
+		return nil, errors.New("the authenticator data is too short")
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+
+	if !bytes.Equal(authData[:32], rpIDHash[:]) {
+		return nil, fmt.Errorf("the authenticator data was not issued for the relying party %s", rp.ID)
+	}
+
+	flags := authData[32]
+
+	if flags&flagUserPresent == 0 {
+		return nil, errors.New("the user was not present")
+	}
+
+	if flags&flagUserVerified == 0 && rp.userVerification() == UserVerificationRequired {
+		return nil, errors.New("the user was not verified")
+	}
+
+	// Only the credentials eligible to a backup can be backed up.
+	if flags&flagBackupState != 0 && flags&flagBackupEligible == 0 {
+		return nil, errors.New("the credential is backed up but not eligible to a backup")
+	}
+
+	data := &authenticatorData{
+		rpIDHash:       authData[:32],
+		signCount:      binary.BigEndian.Uint32(authData[33:37]),
+		backupEligible: flags&flagBackupEligible != 0,
+		backupState:    flags&flagBackupState != 0,
+	}
+
+	if flags&flagAttestedCredentialData == 0 {
+		return data, nil
+	}
+
+	rest := authData[authenticatorDataMinLength:]
+	if len(rest) < 18 {
+		return nil, errors.New("the attested credential data is too short")
+	}
+
+	data.aaguid = append([]byte(nil), rest[:16]...)
+
+	length := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+
+	if length == 0 || length > credentialIDMaxLength || len(rest) < length {
+		return nil, errors.New("invalid credential ID length")
+	}
+
+	data.credentialID = append([]byte(nil), rest[:length]...)
+
+	_, remaining, err := parsePublicKey(rest[length:])
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the public key of the credential: %w", err)
+	}
+
+	data.publicKey = append([]byte(nil), rest[length:len(rest)-len(remaining)]...)
+
+	return data, nil
+}
@@ -0,0 +1,339 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRelyingParty = &RelyingParty{
+	ID:                  "example.com",
+	Name:                "Authelia",
+	Origin:              "https://login.example.com",
+	RegistrationTimeout: 2 * time.Minute,
+	AssertionTimeout:    time.Minute,
+}
+
+// testAuthenticator is a software authenticator holding a single ES256 discoverable credential.
+type testAuthenticator struct {
+	t          *testing.T
+	key        *ecdsa.PrivateKey
+	id         []byte
+	userHandle []byte
+	signCount  uint32
+	flags      byte
+	rpID       string
+	origin     string
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	return &testAuthenticator{
+		t:          t,
+		key:        key,
+		id:         []byte("credential-id"),
+		userHandle: []byte("user-handle"),
+		flags:      flagUserPresent | flagUserVerified,
+		rpID:       testRelyingParty.ID,
+		origin:     testRelyingParty.Origin,
+	}
+}
+
+func (a *testAuthenticator) publicKey() []byte {
+	return cborEncode(map[interface{}]interface{}{
+		coseKeyType:      coseKeyTypeEC2,
+		coseKeyAlgorithm: AlgorithmES256,
+		coseKeyCurve:     coseCurveP256,
+		coseKeyX:         a.key.X.FillBytes(make([]byte, 32)),
+		coseKeyY:         a.key.Y.FillBytes(make([]byte, 32)),
+	})
+}
+
+func (a *testAuthenticator) clientData(clientDataType string, challenge []byte) []byte {
+	data, err := json.Marshal(map[string]interface{}{
+		"type":      clientDataType,
+		"challenge": URLEncodedBase64(challenge),
+		"origin":    a.origin,
+	})
+	require.NoError(a.t, err)
+
+	return data
+}
+
+func (a *testAuthenticator) authenticatorData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+
+	data := append(rpIDHash[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[33:], a.signCount)
+
+	return append(data, attested...)
+}
+
+func (a *testAuthenticator) create(challenge []byte) (response CredentialCreationResponse) {
+	attested := make([]byte, 16, 18)
+	attested = append(attested, byte(len(a.id)>>8), byte(len(a.id)))
+	attested = append(attested, a.id...)
+	attested = append(attested, a.publicKey()...)
+
+	response.ID = a.id
+	response.Type = publicKeyCredentialType
+	response.Response.ClientDataJSON = a.clientData(clientDataTypeCreate, challenge)
+	response.Response.AttestationObject = cborEncode(map[interface{}]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[interface{}]interface{}{},
+		"authData": a.authenticatorData(a.flags|flagAttestedCredentialData, attested),
+	})
+
+	return response
+}
+
+func (a *testAuthenticator) get(challenge []byte) (response CredentialAssertionResponse) {
+	a.signCount++
+
+	response.ID = a.id
+	response.Type = publicKeyCredentialType
+	response.Response.ClientDataJSON = a.clientData(clientDataTypeGet, challenge)
+	response.Response.AuthenticatorData = a.authenticatorData(a.flags, nil)
+	response.Response.UserHandle = a.userHandle
+
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+	digest := sha256.Sum256(append(append([]byte(nil), response.Response.AuthenticatorData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(a.t, err)
+
+	response.Response.Signature = signature
+
+	return response
+}
+
+func TestShouldRegisterAndAssertCredential(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+
+	challenge, err := NewChallenge()
+	require.NoError(t, err)
+
+	credential, err := testRelyingParty.VerifyRegistration(challenge, authenticator.create(challenge))
+	require.NoError(t, err)
+	assert.Equal(t, authenticator.id, credential.ID)
+	assert.Equal(t, authenticator.publicKey(), credential.PublicKey)
+	assert.Equal(t, uint32(0), credential.SignCount)
+	assert.Empty(t, credential.AAGUID)
+
+	challenge, err = NewChallenge()
+	require.NoError(t, err)
+
+	asserted, err := testRelyingParty.VerifyAssertion(challenge, authenticator.get(challenge), *credential)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), asserted.SignCount)
+	assert.False(t, asserted.BackupEligible)
+	assert.False(t, asserted.BackupState)
+}
+
+func TestShouldRecordBackupFlags(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	authenticator.flags |= flagBackupEligible
+
+	challenge := []byte("challenge")
+
+	credential, err := testRelyingParty.VerifyRegistration(challenge, authenticator.create(challenge))
+	require.NoError(t, err)
+	assert.True(t, credential.BackupEligible)
+	assert.False(t, credential.BackupState)
+
+	authenticator.flags |= flagBackupState
+
+	asserted, err := testRelyingParty.VerifyAssertion(challenge, authenticator.get(challenge), *credential)
+	require.NoError(t, err)
+	assert.True(t, asserted.BackupEligible)
+	assert.True(t, asserted.BackupState)
+
+	authenticator.flags &^= flagBackupEligible
+
+	_, err = testRelyingParty.VerifyAssertion(challenge, authenticator.get(challenge), *asserted)
+	assert.EqualError(t, err, "the credential is backed up but not eligible to a backup")
+}
+
+func TestShouldNotRegisterInvalidCredential(t *testing.T) {
+	challenge := []byte("challenge")
+
+	testCases := []struct {
+		name   string
+		modify func(a *testAuthenticator)
+		err    string
+	}{
+		{"WrongOrigin", func(a *testAuthenticator) { a.origin = "https://evil.example.com" }, "the origin https://evil.example.com does not match the origin https://login.example.com of the relying party"},
+		{"WrongRelyingParty", func(a *testAuthenticator) { a.rpID = "evil.com" }, "the authenticator data was not issued for the relying party example.com"},
+		{"UserNotVerified", func(a *testAuthenticator) { a.flags = flagUserPresent }, "the user was not verified"},
+		{"UserNotPresent", func(a *testAuthenticator) { a.flags = flagUserVerified }, "the user was not present"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			authenticator := newTestAuthenticator(t)
+			tc.modify(authenticator)
+
+			_, err := testRelyingParty.VerifyRegistration(challenge, authenticator.create(challenge))
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+
+	_, err := testRelyingParty.VerifyRegistration([]byte("other"), newTestAuthenticator(t).create(challenge))
+	assert.EqualError(t, err, "the challenge does not match")
+}
+
+func TestShouldNotAssertInvalidCredential(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	challenge := []byte("challenge")
+
+	credential, err := testRelyingParty.VerifyRegistration(challenge, authenticator.create(challenge))
+	require.NoError(t, err)
+
+	response := authenticator.get(challenge)
+	response.Response.Signature[len(response.Response.Signature)-1] ^= 0xff
+
+	_, err = testRelyingParty.VerifyAssertion(challenge, response, *credential)
+	assert.EqualError(t, err, "invalid signature")
+
+	credential.SignCount = 10
+
+	_, err = testRelyingParty.VerifyAssertion(challenge, authenticator.get(challenge), *credential)
+	assert.EqualError(t, err, "the authenticator may have been cloned: the signature counter 2 is not greater than the stored counter 10")
+	assert.True(t, errors.Is(err, ErrCloneDetected))
+
+	response = authenticator.get(challenge)
+	response.Response.ClientDataJSON = authenticator.clientData(clientDataTypeCreate, challenge)
+
+	_, err = testRelyingParty.VerifyAssertion(challenge, response, *credential)
+	assert.EqualError(t, err, "invalid client data type webauthn.create")
+}
+
+func TestShouldOnlyPreferUserVerification(t *testing.T) {
+	rp := *testRelyingParty
+	rp.UserVerification = UserVerificationPreferred
+
+	assert.Equal(t, "preferred", rp.NewAssertionOptions([]byte("challenge")).UserVerification)
+	assert.Equal(t, "required", testRelyingParty.NewAssertionOptions([]byte("challenge")).UserVerification)
+
+	authenticator := newTestAuthenticator(t)
+	authenticator.flags = flagUserPresent
+
+	challenge := []byte("challenge")
+
+	credential, err := rp.VerifyRegistration(challenge, authenticator.create(challenge))
+	require.NoError(t, err)
+
+	_, err = rp.VerifyAssertion(challenge, authenticator.get(challenge), *credential)
+	assert.NoError(t, err)
+
+	_, err = testRelyingParty.VerifyAssertion(challenge, authenticator.get(challenge), *credential)
+	assert.EqualError(t, err, "the user was not verified")
+}
+
+func TestShouldVerifyAllowedOrigins(t *testing.T) {
+	rp := *testRelyingParty
+	rp.AllowedOrigins = []string{"https://auth.example.com", "https://login.example.com"}
+
+	authenticator := newTestAuthenticator(t)
+	challenge := []byte("challenge")
+
+	_, err := rp.VerifyRegistration(challenge, authenticator.create(challenge))
+	require.NoError(t, err)
+
+	rp.AllowedOrigins = []string{"https://auth.example.com"}
+
+	_, err = rp.VerifyRegistration(challenge, authenticator.create(challenge))
+	assert.EqualError(t, err, "the origin https://login.example.com is not one of the allowed origins of the relying party")
+}
+
+func TestShouldVerifyEd25519Signature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	key, rest, err := parsePublicKey(cborEncode(map[interface{}]interface{}{
+		coseKeyType:      coseKeyTypeOKP,
+		coseKeyAlgorithm: AlgorithmEdDSA,
+		coseKeyCurve:     coseCurveEd25519,
+		coseKeyX:         []byte(public),
+	}))
+	require.NoError(t, err)
+	assert.Len(t, rest, 0)
+
+	assert.NoError(t, key.verify([]byte("message"), ed25519.Sign(private, []byte("message"))))
+	assert.EqualError(t, key.verify([]byte("other"), ed25519.Sign(private, []byte("message"))), "invalid signature")
+}
+
+func TestShouldNotParseUnsupportedPublicKey(t *testing.T) {
+	_, _, err := parsePublicKey(cborEncode(map[interface{}]interface{}{
+		coseKeyType:      coseKeyTypeEC2,
+		coseKeyAlgorithm: AlgorithmES384,
+		coseKeyCurve:     coseCurveP256,
+	}))
+	assert.EqualError(t, err, "unsupported public key: curve 1 with algorithm -35")
+}
+
+func TestShouldValidatePublicKey(t *testing.T) {
+	key := newTestAuthenticator(t).publicKey()
+
+	assert.NoError(t, ValidatePublicKey(key))
+	assert.EqualError(t, ValidatePublicKey(append(key, 0x00)), "unexpected data after the public key")
+	assert.EqualError(t, ValidatePublicKey(cborEncode(map[interface{}]interface{}{coseKeyType: 4})), "unsupported public key: key type 4")
+}
+
+func TestShouldEncodeURLEncodedBase64(t *testing.T) {
+	data, err := json.Marshal(URLEncodedBase64{0xfb, 0xff})
+	require.NoError(t, err)
+	assert.Equal(t, `"-_8"`, string(data))
+
+	var decoded URLEncodedBase64
+
+	require.NoError(t, json.Unmarshal([]byte(`"-_8="`), &decoded))
+	assert.Equal(t, URLEncodedBase64{0xfb, 0xff}, decoded)
+}
+
+func TestShouldSetResidentKeyRequirementOfRegistrationOptions(t *testing.T) {
+	options := testRelyingParty.NewRegistrationOptions([]byte("challenge"), User{}, nil)
+	assert.Equal(t, ResidentKeyRequired, options.AuthenticatorSelection.ResidentKey)
+	assert.True(t, options.AuthenticatorSelection.RequireResidentKey)
+
+	rp := *testRelyingParty
+	rp.ResidentKey = ResidentKeyPreferred
+
+	options = rp.NewRegistrationOptions([]byte("challenge"), User{}, nil)
+	assert.Equal(t, ResidentKeyPreferred, options.AuthenticatorSelection.ResidentKey)
+	assert.False(t, options.AuthenticatorSelection.RequireResidentKey)
+}
+
+func TestShouldSetTimeoutsOfCeremonies(t *testing.T) {
+	assert.Equal(t, int64(120000), testRelyingParty.NewRegistrationOptions([]byte("challenge"), User{}, nil).Timeout)
+	assert.Equal(t, int64(60000), testRelyingParty.NewAssertionOptions([]byte("challenge")).Timeout)
+}
+
+func TestShouldSetAttachmentOfRegistrationOptions(t *testing.T) {
+	options := testRelyingParty.NewRegistrationOptions([]byte("challenge"), User{}, nil)
+	assert.Equal(t, "", options.AuthenticatorSelection.AuthenticatorAttachment)
+
+	rp := *testRelyingParty
+	rp.Attachment = AttachmentAny
+
+	options = rp.NewRegistrationOptions([]byte("challenge"), User{}, nil)
+	assert.Equal(t, "", options.AuthenticatorSelection.AuthenticatorAttachment)
+
+	rp.Attachment = AttachmentPlatform
+
+	options = rp.NewRegistrationOptions([]byte("challenge"), User{}, nil)
+	assert.Equal(t, AttachmentPlatform, options.AuthenticatorSelection.AuthenticatorAttachment)
+}
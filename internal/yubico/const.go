@@ -0,0 +1,22 @@
+package yubico
+
+// The statuses of the responses of the validation servers.
+const (
+	// StatusOK is the status of a valid one-time password.
+	StatusOK = "OK"
+	// StatusBadOTP is the status of a one-time password which has an invalid format or was not generated by a YubiKey
+	// known by the validation server.
+	StatusBadOTP = "BAD_OTP"
+	// StatusReplayedOTP is the status of a one-time password which was already validated.
+	StatusReplayedOTP = "REPLAYED_OTP"
+)
+
+// modhexAlphabet is the alphabet of the modhex encoding of the Yubico OTPs, chosen to be typed identically by keyboards
+// of any layout.
+const modhexAlphabet = "cbdefghijklnrtuv"
+
+// otpLength is the length of the encrypted part of the Yubico OTPs, prefixed by the public ID of the YubiKey.
+const otpLength = 32
+
+// maxPublicIDLength is the maximum length of the public ID of a YubiKey.
+const maxPublicIDLength = 16
@@ -0,0 +1,18 @@
+package yubico
+
+import (
+	"net/http"
+)
+
+// API interface wrapping the Yubico validation protocol for testing purpose.
+type API interface {
+	Verify(otp string) (string, error)
+}
+
+// APIImpl implementation of the API interface calling YubiCloud or self-hosted validation servers.
+type APIImpl struct {
+	clientID  string
+	secretKey []byte
+	urls      []string
+	client    *http.Client
+}
@@ -0,0 +1,155 @@
+package yubico
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // The validation protocol signs the requests and responses with HMAC-SHA1.
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// NewYubicoAPI create a client of the validation servers. The secret key and the timeout must have been checked by
+// the configuration validator.
+func NewYubicoAPI(configuration schema.YubiKeyOTPConfiguration) *APIImpl {
+	secretKey, _ := base64.StdEncoding.DecodeString(configuration.SecretKey)
+	timeout, _ := utils.ParseDurationString(configuration.Timeout)
+
+	return &APIImpl{
+		clientID:  configuration.ClientID,
+		secretKey: secretKey,
+		urls:      configuration.APIURLs,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+// PublicID returns the public ID of the YubiKey which generated the one-time password, or an error if it is not a
+// Yubico OTP.
+func PublicID(otp string) (string, error) {
+	if len(otp) <= otpLength || len(otp) > otpLength+maxPublicIDLength {
+		return "", fmt.Errorf("the one-time password must be between %d and %d characters long", otpLength+1, otpLength+maxPublicIDLength)
+	}
+
+	for _, c := range otp {
+		if !strings.ContainsRune(modhexAlphabet, c) {
+			return "", fmt.Errorf("the one-time password must be modhex encoded")
+		}
+	}
+
+	return otp[:len(otp)-otpLength], nil
+}
+
+// Verify validates the one-time password with the validation servers and returns the status of the response, StatusOK
+// when the one-time password is valid. The servers are queried in parallel and the first response which is signed with
+// the secret key and matches the request is used.
+func (a *APIImpl) Verify(otp string) (string, error) {
+	nonce := utils.RandomString(32, utils.AlphaNumericCharacters)
+
+	values := url.Values{}
+	values.Set("id", a.clientID)
+	values.Set("otp", otp)
+	values.Set("nonce", nonce)
+	values.Set("h", a.sign(values))
+
+	type result struct {
+		status string
+		err    error
+	}
+
+	results := make(chan result, len(a.urls))
+
+	for _, u := range a.urls {
+		go func(u string) {
+			status, err := a.verify(u+"?"+values.Encode(), otp, nonce)
+			results <- result{status, err}
+		}(u)
+	}
+
+	var err error
+
+	for range a.urls {
+		r := <-results
+		if r.err == nil {
+			return r.status, nil
+		}
+
+		err = r.err
+	}
+
+	return "", err
+}
+
+func (a *APIImpl) verify(requestURL, otp, nonce string) (string, error) {
+	resp, err := a.client.Get(requestURL)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	values := url.Values{}
+	scanner := bufio.NewScanner(resp.Body)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "="); i > 0 {
+			values.Set(line[:i], line[i+1:])
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return "", err
+	}
+
+	signature := values.Get("h")
+	values.Del("h")
+
+	if !hmac.Equal([]byte(signature), []byte(a.sign(values))) {
+		return "", fmt.Errorf("the signature of the response with status %s is invalid", values.Get("status"))
+	}
+
+	status := values.Get("status")
+
+	switch status {
+	case StatusOK, StatusBadOTP, StatusReplayedOTP:
+	default:
+		// The other statuses such as BAD_SIGNATURE or NO_SUCH_CLIENT are errors of the request or the server.
+		return "", fmt.Errorf("the validation server returned the status %s", status)
+	}
+
+	if status == StatusOK && (values.Get("otp") != otp || values.Get("nonce") != nonce) {
+		return "", fmt.Errorf("the response does not match the request")
+	}
+
+	return status, nil
+}
+
+// sign returns the signature of the values, the HMAC-SHA1 of the values sorted by key.
+func (a *APIImpl) sign(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+values.Get(k))
+	}
+
+	mac := hmac.New(sha1.New, a.secretKey)
+	_, _ = mac.Write([]byte(strings.Join(pairs, "&")))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
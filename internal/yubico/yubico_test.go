@@ -0,0 +1,139 @@
+package yubico
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+const testOTP = "ccccccbcgujhingjrdejhgfnuetrgigvejhhgbkugded"
+
+func newTestAPI(t *testing.T, handlers ...http.HandlerFunc) *APIImpl {
+	urls := make([]string, 0, len(handlers))
+
+	for _, handler := range handlers {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+
+		urls = append(urls, server.URL+"/wsapi/2.0/verify")
+	}
+
+	return NewYubicoAPI(schema.YubiKeyOTPConfiguration{
+		ClientID:  "12345",
+		SecretKey: "c2VjcmV0",
+		APIURLs:   urls,
+		Timeout:   "5s",
+	})
+}
+
+// respond writes a response signed by the API to the request.
+func respond(api *APIImpl, w http.ResponseWriter, r *http.Request, status string) {
+	values := url.Values{}
+	values.Set("otp", r.URL.Query().Get("otp"))
+	values.Set("nonce", r.URL.Query().Get("nonce"))
+	values.Set("t", "2021-10-15T12:00:00Z0000")
+	values.Set("status", status)
+
+	_, _ = fmt.Fprintf(w, "h=%s\r\nt=%s\r\notp=%s\r\nnonce=%s\r\nstatus=%s\r\n\r\n", api.sign(values),
+		values.Get("t"), values.Get("otp"), values.Get("nonce"), status)
+}
+
+func TestShouldVerifyOneTimePassword(t *testing.T) {
+	var api *APIImpl
+
+	api = newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		assert.Equal(t, "/wsapi/2.0/verify", r.URL.Path)
+		assert.Equal(t, "12345", query.Get("id"))
+		assert.Equal(t, testOTP, query.Get("otp"))
+		assert.Len(t, query.Get("nonce"), 32)
+
+		signature := query.Get("h")
+		query.Del("h")
+		assert.Equal(t, api.sign(query), signature)
+
+		respond(api, w, r, StatusOK)
+	})
+
+	status, err := api.Verify(testOTP)
+	require.NoError(t, err)
+	assert.Equal(t, StatusOK, status)
+}
+
+func TestShouldReturnReplayedOneTimePassword(t *testing.T) {
+	var api *APIImpl
+
+	api = newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		respond(api, w, r, StatusReplayedOTP)
+	})
+
+	status, err := api.Verify(testOTP)
+	require.NoError(t, err)
+	assert.Equal(t, StatusReplayedOTP, status)
+}
+
+func TestShouldRejectResponseWithInvalidSignature(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "h=abc\r\notp=%s\r\nnonce=%s\r\nstatus=OK\r\n", r.URL.Query().Get("otp"), r.URL.Query().Get("nonce"))
+	})
+
+	_, err := api.Verify(testOTP)
+	assert.EqualError(t, err, "the signature of the response with status OK is invalid")
+}
+
+func TestShouldRejectResponseToAnotherRequest(t *testing.T) {
+	var api *APIImpl
+
+	api = newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		r.URL.RawQuery = url.Values{"otp": {testOTP}, "nonce": {"anothernonce"}}.Encode()
+
+		respond(api, w, r, StatusOK)
+	})
+
+	_, err := api.Verify(testOTP)
+	assert.EqualError(t, err, "the response does not match the request")
+}
+
+func TestShouldUseResponseOfAvailableServer(t *testing.T) {
+	var api *APIImpl
+
+	api = newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, func(w http.ResponseWriter, r *http.Request) {
+		respond(api, w, r, StatusOK)
+	})
+
+	status, err := api.Verify(testOTP)
+	require.NoError(t, err)
+	assert.Equal(t, StatusOK, status)
+}
+
+func TestShouldReturnErrorOfServers(t *testing.T) {
+	var api *APIImpl
+
+	api = newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		respond(api, w, r, "NO_SUCH_CLIENT")
+	})
+
+	_, err := api.Verify(testOTP)
+	assert.EqualError(t, err, "the validation server returned the status NO_SUCH_CLIENT")
+}
+
+func TestShouldExtractPublicID(t *testing.T) {
+	publicID, err := PublicID(testOTP)
+	require.NoError(t, err)
+	assert.Equal(t, "ccccccbcgujh", publicID)
+
+	_, err = PublicID("123456")
+	assert.EqualError(t, err, "the one-time password must be between 33 and 48 characters long")
+
+	_, err = PublicID("ccccccbcgujhingjrdejhgfnuetrgigvejhhgbkugdea")
+	assert.EqualError(t, err, "the one-time password must be modhex encoded")
+}
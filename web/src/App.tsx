@@ -18,7 +18,7 @@ import {
 } from "./Routes";
 import * as themes from "./themes";
 import { getBasePath } from "./utils/BasePath";
-import { getRememberMe, getResetPassword, getTheme } from "./utils/Configuration";
+import { getPasskeys, getRememberMe, getResetPassword, getTheme } from "./utils/Configuration";
 import RegisterOneTimePassword from "./views/DeviceRegistration/RegisterOneTimePassword";
 import RegisterSecurityKey from "./views/DeviceRegistration/RegisterSecurityKey";
 import ConsentView from "./views/LoginPortal/ConsentView/ConsentView";
@@ -71,7 +71,11 @@ const App: React.FC = () => {
                             <ConsentView />
                         </Route>
                         <Route path={FirstFactorRoute}>
-                            <LoginPortal rememberMe={getRememberMe()} resetPassword={getResetPassword()} />
+                            <LoginPortal
+                                rememberMe={getRememberMe()}
+                                resetPassword={getResetPassword()}
+                                passkeys={getPasskeys()}
+                            />
                         </Route>
                         <Route path="/">
                             <Redirect to={FirstFactorRoute} />
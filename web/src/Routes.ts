@@ -6,6 +6,14 @@ export const SecondFactorRoute: string = "/2fa";
 export const SecondFactorU2FRoute: string = "/2fa/security-key";
 export const SecondFactorTOTPRoute: string = "/2fa/one-time-password";
 export const SecondFactorPushRoute: string = "/2fa/push-notification";
+export const SecondFactorPasswordRoute: string = "/2fa/password";
+export const SecondFactorRecoveryCodeRoute: string = "/2fa/recovery-code";
+export const SecondFactorEmailRoute: string = "/2fa/email";
+export const SecondFactorPushApprovalRoute: string = "/2fa/push-approval";
+export const SecondFactorPrivacyIDEARoute: string = "/2fa/privacyidea";
+export const SecondFactorHOTPRoute: string = "/2fa/hotp";
+export const SecondFactorYubiKeyOTPRoute: string = "/2fa/yubikey-otp";
+export const SecondFactorEnrollmentRoute: string = "/2fa/enrollment";
 
 export const ResetPasswordStep1Route: string = "/reset-password/step1";
 export const ResetPasswordStep2Route: string = "/reset-password/step2";
@@ -6,3 +6,17 @@ export function useRedirectionURL() {
     const queryParams = queryString.parse(location.search);
     return queryParams && "rd" in queryParams ? (queryParams["rd"] as string) : undefined;
 }
+
+// The domain of the redirection URL, the preferences of the user for this domain apply.
+export function useRedirectionDomain() {
+    const redirectionURL = useRedirectionURL();
+    if (!redirectionURL) {
+        return undefined;
+    }
+
+    try {
+        return new URL(redirectionURL).hostname;
+    } catch (err) {
+        return undefined;
+    }
+}
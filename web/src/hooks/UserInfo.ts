@@ -1,6 +1,9 @@
+import { useCallback } from "react";
+
 import { getUserPreferences } from "../services/UserPreferences";
 import { useRemoteCall } from "./RemoteCall";
 
-export function useUserPreferences() {
-    return useRemoteCall(getUserPreferences, []);
+export function useUserPreferences(domain?: string) {
+    const fn = useCallback(() => getUserPreferences(domain), [domain]);
+    return useRemoteCall(fn, [domain]);
 }
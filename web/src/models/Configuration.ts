@@ -4,4 +4,6 @@ export interface Configuration {
     available_methods: Set<SecondFactorMethod>;
     second_factor_enabled: boolean;
     totp_period: number;
+    trusted_devices_enabled: boolean;
+    duo_universal_prompt: boolean;
 }
@@ -2,4 +2,9 @@ export enum SecondFactorMethod {
     TOTP = 1,
     U2F = 2,
     MobilePush = 3,
+    Email = 4,
+    PushApproval = 5,
+    PrivacyIDEA = 6,
+    HOTP = 7,
+    YubiKeyOTP = 8,
 }
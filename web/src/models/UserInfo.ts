@@ -5,4 +5,10 @@ export interface UserInfo {
     method: SecondFactorMethod;
     has_u2f: boolean;
     has_totp: boolean;
+    totp_last_used_at?: string;
+    totp_last_used_ip?: string;
+    totp_last_used_country?: string;
+    fallback_methods: SecondFactorMethod[];
+    enrollment_required: boolean;
+    enrollment_deadline?: number;
 }
@@ -8,18 +8,44 @@ const basePath = getBasePath();
 export const ConsentPath = basePath + "/api/oidc/consent";
 
 export const FirstFactorPath = basePath + "/api/firstfactor";
+export const FirstFactorPasskeyOptionsPath = basePath + "/api/firstfactor/passkey/options";
+export const FirstFactorPasskeyConditionalOptionsPath = basePath + "/api/firstfactor/passkey/conditional/options";
+export const FirstFactorPasskeyPath = basePath + "/api/firstfactor/passkey";
 export const InitiateTOTPRegistrationPath = basePath + "/api/secondfactor/totp/identity/start";
 export const CompleteTOTPRegistrationPath = basePath + "/api/secondfactor/totp/identity/finish";
+export const ReplaceTOTPRegistrationPath = basePath + "/api/secondfactor/totp/register";
 
 export const InitiateU2FRegistrationPath = basePath + "/api/secondfactor/u2f/identity/start";
 export const CompleteU2FRegistrationStep1Path = basePath + "/api/secondfactor/u2f/identity/finish";
 export const CompleteU2FRegistrationStep2Path = basePath + "/api/secondfactor/u2f/register";
 
+export const WebAuthnRegistrationOptionsPath = basePath + "/api/webauthn/register/options";
+export const WebAuthnRegistrationPath = basePath + "/api/webauthn/register";
+export const WebAuthnCredentialsPath = basePath + "/api/webauthn/credentials";
+export const WebAuthnCredentialRenamePath = basePath + "/api/webauthn/credentials/rename";
+export const WebAuthnCredentialDeletePath = basePath + "/api/webauthn/credentials/delete";
+
 export const InitiateU2FSignInPath = basePath + "/api/secondfactor/u2f/sign_request";
 export const CompleteU2FSignInPath = basePath + "/api/secondfactor/u2f/sign";
 
 export const CompletePushNotificationSignInPath = basePath + "/api/secondfactor/duo";
+export const InitiateDuoUniversalSignInPath = basePath + "/api/secondfactor/duo/universal";
 export const CompleteTOTPSignInPath = basePath + "/api/secondfactor/totp";
+export const CompletePasswordSignInPath = basePath + "/api/secondfactor/password";
+export const CompleteRecoveryCodeSignInPath = basePath + "/api/secondfactor/recovery_code";
+export const RecoveryCodesPath = basePath + "/api/secondfactor/recovery_codes";
+export const InitiateEmailSignInPath = basePath + "/api/secondfactor/email/start";
+export const CompleteEmailSignInPath = basePath + "/api/secondfactor/email";
+export const InitiatePushApprovalSignInPath = basePath + "/api/secondfactor/push_approval/start";
+export const CompletePushApprovalSignInPath = basePath + "/api/secondfactor/push_approval";
+export const PrivacyIDEATokensPath = basePath + "/api/secondfactor/privacyidea/tokens";
+export const CompletePrivacyIDEASignInPath = basePath + "/api/secondfactor/privacyidea";
+export const CompletePrivacyIDEAPushSignInPath = basePath + "/api/secondfactor/privacyidea/push";
+export const CompleteHOTPSignInPath = basePath + "/api/secondfactor/hotp";
+export const CompleteYubiKeyOTPSignInPath = basePath + "/api/secondfactor/yubikey_otp";
+
+export const TrustedDevicesPath = basePath + "/api/trusted_devices";
+export const TrustedDeviceRevokePath = basePath + "/api/trusted_devices/revoke";
 
 export const InitiateResetPasswordPath = basePath + "/api/reset-password/identity/start";
 export const CompleteResetPasswordPath = basePath + "/api/reset-password/identity/finish";
@@ -7,6 +7,8 @@ interface ConfigurationPayload {
     available_methods: Method2FA[];
     second_factor_enabled: boolean;
     totp_period: number;
+    trusted_devices_enabled: boolean;
+    duo_universal_prompt: boolean;
 }
 
 export async function getConfiguration(): Promise<Configuration> {
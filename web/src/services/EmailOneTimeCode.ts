@@ -0,0 +1,21 @@
+import { CompleteEmailSignInPath, InitiateEmailSignInPath } from "./Api";
+import { PostWithOptionalResponse } from "./Client";
+import { SignInResponse } from "./SignIn";
+
+interface CompleteEmailSignInBody {
+    code: string;
+    targetURL?: string;
+}
+
+// Sending a new code replaces the one sent previously.
+export function initiateEmailSignIn() {
+    return PostWithOptionalResponse(InitiateEmailSignInPath);
+}
+
+export function completeEmailSignIn(code: string, targetURL: string | undefined) {
+    const body: CompleteEmailSignInBody = { code };
+    if (targetURL) {
+        body.targetURL = targetURL;
+    }
+    return PostWithOptionalResponse<SignInResponse>(CompleteEmailSignInPath, body);
+}
@@ -0,0 +1,21 @@
+import { CompleteHOTPSignInPath } from "./Api";
+import { PostWithOptionalResponse } from "./Client";
+import { SignInResponse } from "./SignIn";
+
+interface CompleteHOTPSignInBody {
+    token: string;
+    nextToken?: string;
+    targetURL?: string;
+}
+
+// The next token is only given to resynchronize the token with two consecutive one-time passwords.
+export function completeHOTPSignIn(token: string, nextToken: string | undefined, targetURL: string | undefined) {
+    const body: CompleteHOTPSignInBody = { token };
+    if (nextToken) {
+        body.nextToken = nextToken;
+    }
+    if (targetURL) {
+        body.targetURL = targetURL;
+    }
+    return PostWithOptionalResponse<SignInResponse>(CompleteHOTPSignInPath, body);
+}
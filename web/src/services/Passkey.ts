@@ -0,0 +1,204 @@
+import {
+    FirstFactorPasskeyConditionalOptionsPath,
+    FirstFactorPasskeyOptionsPath,
+    FirstFactorPasskeyPath,
+    WebAuthnCredentialDeletePath,
+    WebAuthnCredentialRenamePath,
+    WebAuthnCredentialsPath,
+    WebAuthnRegistrationOptionsPath,
+    WebAuthnRegistrationPath,
+} from "./Api";
+import { Get, Post, PostWithOptionalResponse } from "./Client";
+import { SignInResponse } from "./SignIn";
+
+// The binary values of the WebAuthn API are exchanged with the backend as base64url strings.
+function decodeBase64URL(value: string): ArrayBuffer {
+    const base64 = value.replace(/-/g, "+").replace(/_/g, "/").padEnd(Math.ceil(value.length / 4) * 4, "=");
+    return Uint8Array.from(atob(base64), (c) => c.charCodeAt(0)).buffer;
+}
+
+function encodeBase64URL(value: ArrayBuffer): string {
+    return btoa(String.fromCharCode(...new Uint8Array(value)))
+        .replace(/\+/g, "-")
+        .replace(/\//g, "_")
+        .replace(/=+$/, "");
+}
+
+interface CredentialDescriptor {
+    type: "public-key";
+    id: string;
+}
+
+interface CredentialCreationOptions {
+    challenge: string;
+    rp: PublicKeyCredentialRpEntity;
+    user: {
+        id: string;
+        name: string;
+        displayName: string;
+    };
+    pubKeyCredParams: PublicKeyCredentialParameters[];
+    timeout: number;
+    excludeCredentials?: CredentialDescriptor[];
+    authenticatorSelection: AuthenticatorSelectionCriteria;
+    attestation: AttestationConveyancePreference;
+}
+
+interface CredentialRequestOptions {
+    challenge: string;
+    rpId: string;
+    timeout: number;
+    userVerification: UserVerificationRequirement;
+}
+
+interface PostFirstFactorPasskeyBody {
+    credential: {
+        id: string;
+        type: string;
+        response: {
+            clientDataJSON: string;
+            authenticatorData: string;
+            signature: string;
+            userHandle: string | null;
+        };
+    };
+    targetURL?: string;
+    requestMethod?: string;
+    conditional?: boolean;
+}
+
+export async function signInWithPasskey(targetURL?: string, requestMethod?: string) {
+    const options = await Post<CredentialRequestOptions>(FirstFactorPasskeyOptionsPath);
+
+    const credential = (await navigator.credentials.get({
+        publicKey: {
+            ...options,
+            challenge: decodeBase64URL(options.challenge),
+        },
+    })) as PublicKeyCredential | null;
+    if (!credential) {
+        throw new Error("No passkey has been selected");
+    }
+
+    return postPasskeyAssertion(credential, false, targetURL, requestMethod);
+}
+
+export async function isConditionalMediationAvailable() {
+    const publicKeyCredential = window.PublicKeyCredential as unknown as
+        | { isConditionalMediationAvailable?: () => Promise<boolean> }
+        | undefined;
+    if (!publicKeyCredential?.isConditionalMediationAvailable) {
+        return false;
+    }
+
+    return publicKeyCredential.isConditionalMediationAvailable();
+}
+
+// signInWithConditionalPasskey offers the passkeys in the autofill of the username field, the returned promise is
+// pending until the user selects one of them or the signal is aborted.
+export async function signInWithConditionalPasskey(signal: AbortSignal, targetURL?: string, requestMethod?: string) {
+    const options = await Post<CredentialRequestOptions>(FirstFactorPasskeyConditionalOptionsPath);
+
+    const credential = (await navigator.credentials.get({
+        mediation: "conditional",
+        signal,
+        publicKey: {
+            ...options,
+            challenge: decodeBase64URL(options.challenge),
+        },
+    } as Parameters<CredentialsContainer["get"]>[0])) as PublicKeyCredential | null;
+    if (!credential) {
+        throw new Error("No passkey has been selected");
+    }
+
+    return postPasskeyAssertion(credential, true, targetURL, requestMethod);
+}
+
+async function postPasskeyAssertion(
+    credential: PublicKeyCredential,
+    conditional: boolean,
+    targetURL?: string,
+    requestMethod?: string,
+) {
+    const response = credential.response as AuthenticatorAssertionResponse;
+    const body: PostFirstFactorPasskeyBody = {
+        credential: {
+            id: encodeBase64URL(credential.rawId),
+            type: credential.type,
+            response: {
+                clientDataJSON: encodeBase64URL(response.clientDataJSON),
+                authenticatorData: encodeBase64URL(response.authenticatorData),
+                signature: encodeBase64URL(response.signature),
+                userHandle: response.userHandle ? encodeBase64URL(response.userHandle) : null,
+            },
+        },
+    };
+    if (targetURL) {
+        body.targetURL = targetURL;
+    }
+    if (requestMethod) {
+        body.requestMethod = requestMethod;
+    }
+    if (conditional) {
+        body.conditional = true;
+    }
+
+    const res = await PostWithOptionalResponse<SignInResponse>(FirstFactorPasskeyPath, body);
+    return res ? res : ({} as SignInResponse);
+}
+
+export async function registerPasskey(description: string) {
+    const options = await Post<CredentialCreationOptions>(WebAuthnRegistrationOptionsPath);
+
+    const credential = (await navigator.credentials.create({
+        publicKey: {
+            ...options,
+            challenge: decodeBase64URL(options.challenge),
+            user: { ...options.user, id: decodeBase64URL(options.user.id) },
+            excludeCredentials: options.excludeCredentials?.map((c) => ({ ...c, id: decodeBase64URL(c.id) })),
+        },
+    })) as PublicKeyCredential | null;
+    if (!credential) {
+        throw new Error("No passkey has been created");
+    }
+
+    const response = credential.response as AuthenticatorAttestationResponse;
+    return PostWithOptionalResponse(WebAuthnRegistrationPath, {
+        credential: {
+            id: encodeBase64URL(credential.rawId),
+            type: credential.type,
+            response: {
+                clientDataJSON: encodeBase64URL(response.clientDataJSON),
+                attestationObject: encodeBase64URL(response.attestationObject),
+            },
+        },
+        description,
+    });
+}
+
+export interface PasskeyInfo {
+    id: string;
+    description: string;
+    createdAt: string;
+    lastUsedAt?: string;
+    lastUsedIP?: string;
+    lastUsedCountry?: string;
+    signCount: number;
+    cloneWarning: boolean;
+    backupEligible: boolean;
+    backupState: boolean;
+    aaguid?: string;
+    model?: string;
+}
+
+export async function getPasskeys() {
+    return Get<PasskeyInfo[]>(WebAuthnCredentialsPath);
+}
+
+export async function renamePasskey(id: string, description: string) {
+    return PostWithOptionalResponse(WebAuthnCredentialRenamePath, { id, description });
+}
+
+export async function deletePasskey(id: string) {
+    return PostWithOptionalResponse(WebAuthnCredentialDeletePath, { id });
+}
@@ -0,0 +1,16 @@
+import { CompletePasswordSignInPath } from "./Api";
+import { PostWithOptionalResponse } from "./Client";
+import { SignInResponse } from "./SignIn";
+
+interface CompletePasswordSignInBody {
+    password: string;
+    targetURL?: string;
+}
+
+export function completePasswordSignIn(password: string, targetURL: string | undefined) {
+    const body: CompletePasswordSignInBody = { password };
+    if (targetURL) {
+        body.targetURL = targetURL;
+    }
+    return PostWithOptionalResponse<SignInResponse>(CompletePasswordSignInPath, body);
+}
@@ -0,0 +1,34 @@
+import { CompletePrivacyIDEAPushSignInPath, CompletePrivacyIDEASignInPath, PrivacyIDEATokensPath } from "./Api";
+import { Get, PostWithOptionalResponse } from "./Client";
+import { SignInResponse } from "./SignIn";
+
+interface CompletePrivacyIDEASignInBody {
+    token?: string;
+    targetURL?: string;
+}
+
+export interface PrivacyIDEATokens {
+    enrolled: boolean;
+    types: string[];
+}
+
+export function getPrivacyIDEATokens() {
+    return Get<PrivacyIDEATokens>(PrivacyIDEATokensPath);
+}
+
+export function completePrivacyIDEASignIn(token: string, targetURL: string | undefined) {
+    const body: CompletePrivacyIDEASignInBody = { token };
+    if (targetURL) {
+        body.targetURL = targetURL;
+    }
+    return PostWithOptionalResponse<SignInResponse>(CompletePrivacyIDEASignInPath, body);
+}
+
+// The request is pending until the user accepts the push notification or it times out.
+export function completePrivacyIDEAPushSignIn(targetURL: string | undefined) {
+    const body: CompletePrivacyIDEASignInBody = {};
+    if (targetURL) {
+        body.targetURL = targetURL;
+    }
+    return PostWithOptionalResponse<SignInResponse>(CompletePrivacyIDEAPushSignInPath, body);
+}
@@ -0,0 +1,28 @@
+import { CompletePushApprovalSignInPath, InitiatePushApprovalSignInPath } from "./Api";
+import { PostWithOptionalResponse } from "./Client";
+
+interface PushApprovalSignInBody {
+    targetURL?: string;
+}
+
+// The response is the status of the request while it is pending and the redirection once it is approved.
+export interface PushApprovalSignInResponse {
+    status?: string;
+    redirect?: string;
+}
+
+function signInBody(targetURL: string | undefined) {
+    const body: PushApprovalSignInBody = {};
+    if (targetURL) {
+        body.targetURL = targetURL;
+    }
+    return body;
+}
+
+export function initiatePushApprovalSignIn(targetURL: string | undefined) {
+    return PostWithOptionalResponse(InitiatePushApprovalSignInPath, signInBody(targetURL));
+}
+
+export function completePushApprovalSignIn(targetURL: string | undefined) {
+    return PostWithOptionalResponse<PushApprovalSignInResponse>(CompletePushApprovalSignInPath, signInBody(targetURL));
+}
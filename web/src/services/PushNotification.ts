@@ -1,5 +1,5 @@
-import { CompletePushNotificationSignInPath } from "./Api";
-import { PostWithOptionalResponse } from "./Client";
+import { CompletePushNotificationSignInPath, InitiateDuoUniversalSignInPath } from "./Api";
+import { Post, PostWithOptionalResponse } from "./Client";
 import { SignInResponse } from "./SignIn";
 
 interface CompleteU2FSigninBody {
@@ -13,3 +13,17 @@ export function completePushNotificationSignIn(targetURL: string | undefined) {
     }
     return PostWithOptionalResponse<SignInResponse>(CompletePushNotificationSignInPath, body);
 }
+
+interface DuoUniversalSignInResponse {
+    url: string;
+}
+
+// initiateDuoUniversalSignIn returns the URL of the Duo Universal Prompt, Duo redirects the user back to the portal.
+export async function initiateDuoUniversalSignIn(targetURL: string | undefined) {
+    const body: CompleteU2FSigninBody = {};
+    if (targetURL) {
+        body.targetURL = targetURL;
+    }
+    const res = await Post<DuoUniversalSignInResponse>(InitiateDuoUniversalSignInPath, body);
+    return res.url;
+}
@@ -0,0 +1,35 @@
+import { CompleteRecoveryCodeSignInPath, RecoveryCodesPath } from "./Api";
+import { Get, Post, PostWithOptionalResponse } from "./Client";
+import { SignInResponse } from "./SignIn";
+
+interface CompleteRecoveryCodeSignInBody {
+    code: string;
+    targetURL?: string;
+}
+
+export interface RecoveryCodes {
+    codes: string[];
+}
+
+export interface RemainingRecoveryCodes {
+    remaining: number;
+}
+
+export function completeRecoveryCodeSignIn(code: string, targetURL: string | undefined) {
+    const body: CompleteRecoveryCodeSignInBody = { code };
+    if (targetURL) {
+        body.targetURL = targetURL;
+    }
+    return PostWithOptionalResponse<SignInResponse>(CompleteRecoveryCodeSignInPath, body);
+}
+
+// The codes are only returned once, the previous ones can't be used anymore.
+export async function generateRecoveryCodes() {
+    const res = await Post<RecoveryCodes>(RecoveryCodesPath);
+    return res.codes;
+}
+
+export async function getRemainingRecoveryCodes() {
+    const res = await Get<RemainingRecoveryCodes>(RecoveryCodesPath);
+    return res.remaining;
+}
@@ -3,6 +3,7 @@ import U2fApi from "u2f-api";
 import {
     InitiateTOTPRegistrationPath,
     CompleteTOTPRegistrationPath,
+    ReplaceTOTPRegistrationPath,
     InitiateU2FRegistrationPath,
     CompleteU2FRegistrationStep1Path,
     CompleteU2FRegistrationStep2Path,
@@ -22,6 +23,10 @@ export async function completeTOTPRegistrationProcess(processToken: string) {
     return Post<CompleteTOTPRegistrationResponse>(CompleteTOTPRegistrationPath, { token: processToken });
 }
 
+export async function replaceTOTPRegistration(passcode: string) {
+    return Post<CompleteTOTPRegistrationResponse>(ReplaceTOTPRegistrationPath, { token: passcode });
+}
+
 export async function initiateU2FRegistrationProcess() {
     return PostWithOptionalResponse(InitiateU2FRegistrationPath);
 }
@@ -10,6 +10,8 @@ export enum AuthenticationLevel {
 export interface AutheliaState {
     username: string;
     authentication_level: AuthenticationLevel;
+    // Whether the user signed in with a passkey used as a first factor and has yet to enter their password.
+    password_required: boolean;
 }
 
 export async function getState(): Promise<AutheliaState> {
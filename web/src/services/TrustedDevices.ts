@@ -0,0 +1,24 @@
+import { TrustedDeviceRevokePath, TrustedDevicesPath } from "./Api";
+import { Get, PostWithOptionalResponse } from "./Client";
+
+export interface TrustedDevice {
+    id: string;
+    description: string;
+    remoteIP?: string;
+    createdAt: string;
+    expiresAt: string;
+    current: boolean;
+}
+
+// The device is trusted with a cookie set by the response, the second factor being skipped on it until the trust expires.
+export async function trustDevice() {
+    return PostWithOptionalResponse(TrustedDevicesPath);
+}
+
+export async function getTrustedDevices() {
+    return Get<TrustedDevice[]>(TrustedDevicesPath);
+}
+
+export async function revokeTrustedDevice(id: string) {
+    return PostWithOptionalResponse(TrustedDeviceRevokePath, { id });
+}
@@ -3,17 +3,24 @@ import { UserInfo } from "../models/UserInfo";
 import { UserInfoPath, UserInfo2FAMethodPath } from "./Api";
 import { Get, PostWithOptionalResponse } from "./Client";
 
-export type Method2FA = "u2f" | "totp" | "mobile_push";
+export type Method2FA = "u2f" | "totp" | "mobile_push" | "email" | "push_approval" | "privacyidea" | "hotp" | "yubikey_otp";
 
 export interface UserInfoPayload {
     display_name: string;
     method: Method2FA;
     has_u2f: boolean;
     has_totp: boolean;
+    totp_last_used_at?: string;
+    totp_last_used_ip?: string;
+    totp_last_used_country?: string;
+    fallback_methods?: Method2FA[];
+    enrollment_required: boolean;
+    enrollment_deadline?: number;
 }
 
 export interface MethodPreferencePayload {
     method: Method2FA;
+    domain?: string;
 }
 
 export function toEnum(method: Method2FA): SecondFactorMethod {
@@ -24,6 +31,16 @@ export function toEnum(method: Method2FA): SecondFactorMethod {
             return SecondFactorMethod.TOTP;
         case "mobile_push":
             return SecondFactorMethod.MobilePush;
+        case "email":
+            return SecondFactorMethod.Email;
+        case "push_approval":
+            return SecondFactorMethod.PushApproval;
+        case "privacyidea":
+            return SecondFactorMethod.PrivacyIDEA;
+        case "hotp":
+            return SecondFactorMethod.HOTP;
+        case "yubikey_otp":
+            return SecondFactorMethod.YubiKeyOTP;
     }
 }
 
@@ -35,14 +52,33 @@ export function toString(method: SecondFactorMethod): Method2FA {
             return "totp";
         case SecondFactorMethod.MobilePush:
             return "mobile_push";
+        case SecondFactorMethod.Email:
+            return "email";
+        case SecondFactorMethod.PushApproval:
+            return "push_approval";
+        case SecondFactorMethod.PrivacyIDEA:
+            return "privacyidea";
+        case SecondFactorMethod.HOTP:
+            return "hotp";
+        case SecondFactorMethod.YubiKeyOTP:
+            return "yubikey_otp";
     }
 }
 
-export async function getUserPreferences(): Promise<UserInfo> {
-    const res = await Get<UserInfoPayload>(UserInfoPath);
-    return { ...res, method: toEnum(res.method) };
+export async function getUserPreferences(domain?: string): Promise<UserInfo> {
+    const path = domain ? `${UserInfoPath}?domain=${encodeURIComponent(domain)}` : UserInfoPath;
+    const res = await Get<UserInfoPayload>(path);
+    return {
+        ...res,
+        method: toEnum(res.method),
+        fallback_methods: res.fallback_methods ? res.fallback_methods.map(toEnum) : [],
+    };
 }
 
-export function setPreferred2FAMethod(method: SecondFactorMethod) {
-    return PostWithOptionalResponse(UserInfo2FAMethodPath, { method: toString(method) } as MethodPreferencePayload);
+// The preference only applies to the given domain when there is one.
+export function setPreferred2FAMethod(method: SecondFactorMethod, domain?: string) {
+    return PostWithOptionalResponse(UserInfo2FAMethodPath, {
+        method: toString(method),
+        domain,
+    } as MethodPreferencePayload);
 }
@@ -0,0 +1,16 @@
+import { CompleteYubiKeyOTPSignInPath } from "./Api";
+import { PostWithOptionalResponse } from "./Client";
+import { SignInResponse } from "./SignIn";
+
+interface CompleteYubiKeyOTPSignInBody {
+    token: string;
+    targetURL?: string;
+}
+
+export function completeYubiKeyOTPSignIn(token: string, targetURL: string | undefined) {
+    const body: CompleteYubiKeyOTPSignInBody = { token };
+    if (targetURL) {
+        body.targetURL = targetURL;
+    }
+    return PostWithOptionalResponse<SignInResponse>(CompleteYubiKeyOTPSignInPath, body);
+}
@@ -15,6 +15,10 @@ export function getResetPassword() {
     return getEmbeddedVariable("resetpassword") === "true";
 }
 
+export function getPasskeys() {
+    return getEmbeddedVariable("passkeys") === "true";
+}
+
 export function getTheme() {
     return getEmbeddedVariable("theme");
 }
@@ -13,7 +13,7 @@ import { GoogleAuthenticator } from "../../constants";
 import { useNotifications } from "../../hooks/NotificationsContext";
 import LoginLayout from "../../layouts/LoginLayout";
 import { FirstFactorRoute } from "../../Routes";
-import { completeTOTPRegistrationProcess } from "../../services/RegisterDevice";
+import { completeTOTPRegistrationProcess, replaceTOTPRegistration } from "../../services/RegisterDevice";
 import { extractIdentityToken } from "../../utils/IdentityToken";
 
 const RegisterOneTimePassword = function () {
@@ -27,6 +27,7 @@ const RegisterOneTimePassword = function () {
     const { createSuccessNotification, createErrorNotification } = useNotifications();
     const [hasErrored, setHasErrored] = useState(false);
     const [isLoading, setIsLoading] = useState(false);
+    const [passcode, setPasscode] = useState("");
 
     // Get the token from the query param to give it back to the API when requesting
     // the secret for OTP.
@@ -57,6 +58,27 @@ const RegisterOneTimePassword = function () {
     useEffect(() => {
         completeRegistrationProcess();
     }, [completeRegistrationProcess]);
+
+    // Without the token of an identity verification, the user proves they hold the current device with a one-time
+    // password before its secret is replaced.
+    const handleReplaceClick = async () => {
+        if (passcode === "") {
+            return;
+        }
+
+        setIsLoading(true);
+        try {
+            const secret = await replaceTOTPRegistration(passcode);
+            setSecretURL(secret.otpauth_url);
+            setSecretBase32(secret.base32_secret);
+        } catch (err) {
+            console.error(err);
+            createErrorNotification("The one-time password might be wrong");
+        }
+        setPasscode("");
+        setIsLoading(false);
+    };
+
     function SecretButton(text: string | undefined, action: string, icon: IconDefinition) {
         return (
             <IconButton
@@ -73,6 +95,43 @@ const RegisterOneTimePassword = function () {
     }
     const qrcodeFuzzyStyle = isLoading || hasErrored ? style.fuzzy : undefined;
 
+    if (!processToken && secretURL === "empty") {
+        return (
+            <LoginLayout title="Replace your device">
+                <div className={style.root}>
+                    <Typography>Enter a one-time password of your current device to register a new one</Typography>
+                    <TextField
+                        id="current-one-time-password"
+                        label="One-time password"
+                        variant="outlined"
+                        className={style.secret}
+                        value={passcode}
+                        disabled={isLoading}
+                        onChange={(v) => setPasscode(v.target.value)}
+                        onKeyPress={(ev) => {
+                            if (ev.key === "Enter") {
+                                handleReplaceClick();
+                                ev.preventDefault();
+                            }
+                        }}
+                    />
+                    <div>
+                        <Button
+                            id="replace-device-button"
+                            variant="contained"
+                            color="primary"
+                            className={style.doneButton}
+                            onClick={handleReplaceClick}
+                            disabled={isLoading}
+                        >
+                            Continue
+                        </Button>
+                    </div>
+                </div>
+            </LoginLayout>
+        );
+    }
+
     return (
         <LoginLayout title="Scan QR Code">
             <div className={style.root}>
@@ -3,22 +3,39 @@ import React from "react";
 import { Grid, makeStyles, Button } from "@material-ui/core";
 import { useHistory } from "react-router";
 
+import { useNotifications } from "../../../hooks/NotificationsContext";
 import LoginLayout from "../../../layouts/LoginLayout";
-import { LogoutRoute as SignOutRoute } from "../../../Routes";
+import { LogoutRoute as SignOutRoute, RegisterOneTimePasswordRoute } from "../../../Routes";
+import { registerPasskey } from "../../../services/Passkey";
 import Authenticated from "../Authenticated";
+import TrustedDevices from "./TrustedDevices";
 
 export interface Props {
     name: string;
+    passkeys: boolean;
+    trustedDevices: boolean;
+    totp: boolean;
 }
 
 const AuthenticatedView = function (props: Props) {
     const style = useStyles();
     const history = useHistory();
+    const { createSuccessNotification, createErrorNotification } = useNotifications();
 
     const handleLogoutClick = () => {
         history.push(SignOutRoute);
     };
 
+    const handleRegisterPasskeyClick = async () => {
+        try {
+            await registerPasskey("");
+            createSuccessNotification("The passkey has been registered.");
+        } catch (err) {
+            console.error(err);
+            createErrorNotification("There was an issue registering the passkey.");
+        }
+    };
+
     return (
         <LoginLayout id="authenticated-stage" title={`Hi ${props.name}`} showBrand>
             <Grid container>
@@ -26,10 +43,29 @@ const AuthenticatedView = function (props: Props) {
                     <Button color="secondary" onClick={handleLogoutClick} id="logout-button">
                         Logout
                     </Button>
+                    {props.passkeys ? (
+                        <Button color="primary" onClick={handleRegisterPasskeyClick} id="register-passkey-button">
+                            Register a passkey
+                        </Button>
+                    ) : null}
+                    {props.totp ? (
+                        <Button
+                            color="primary"
+                            onClick={() => history.push(RegisterOneTimePasswordRoute)}
+                            id="replace-totp-button"
+                        >
+                            Replace the one-time password device
+                        </Button>
+                    ) : null}
                 </Grid>
                 <Grid item xs={12} className={style.mainContainer}>
                     <Authenticated />
                 </Grid>
+                {props.trustedDevices ? (
+                    <Grid item xs={12}>
+                        <TrustedDevices />
+                    </Grid>
+                ) : null}
             </Grid>
         </LoginLayout>
     );
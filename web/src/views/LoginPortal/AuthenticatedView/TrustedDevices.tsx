@@ -0,0 +1,82 @@
+import React, { useCallback, useEffect, useState } from "react";
+
+import {
+    Button,
+    List,
+    ListItem,
+    ListItemSecondaryAction,
+    ListItemText,
+    Typography,
+    makeStyles,
+} from "@material-ui/core";
+
+import { useNotifications } from "../../../hooks/NotificationsContext";
+import { getTrustedDevices, revokeTrustedDevice, TrustedDevice } from "../../../services/TrustedDevices";
+
+const TrustedDevices = function () {
+    const style = useStyles();
+    const [devices, setDevices] = useState<TrustedDevice[]>([]);
+    const { createSuccessNotification, createErrorNotification } = useNotifications();
+
+    const fetchDevices = useCallback(async () => {
+        try {
+            setDevices(await getTrustedDevices());
+        } catch (err) {
+            console.error(err);
+            createErrorNotification("There was an issue retrieving the trusted devices.");
+        }
+    }, [createErrorNotification]);
+
+    useEffect(() => {
+        fetchDevices();
+    }, [fetchDevices]);
+
+    const handleRevokeClick = async (device: TrustedDevice) => {
+        try {
+            await revokeTrustedDevice(device.id);
+            createSuccessNotification("The device is not trusted anymore.");
+        } catch (err) {
+            console.error(err);
+            createErrorNotification("There was an issue revoking the device.");
+        }
+        fetchDevices();
+    };
+
+    if (devices.length === 0) {
+        return null;
+    }
+
+    return (
+        <div id="trusted-devices" className={style.container}>
+            <Typography variant="h6">Trusted devices</Typography>
+            <List dense>
+                {devices.map((device) => (
+                    <ListItem key={device.id}>
+                        <ListItemText
+                            primary={`${device.description || "Unknown device"}${device.current ? " (this device)" : ""}`}
+                            secondary={`Trusted until ${new Date(device.expiresAt).toLocaleString()}`}
+                        />
+                        <ListItemSecondaryAction>
+                            <Button
+                                color="secondary"
+                                id={`revoke-trusted-device-${device.id}`}
+                                onClick={() => handleRevokeClick(device)}
+                            >
+                                Revoke
+                            </Button>
+                        </ListItemSecondaryAction>
+                    </ListItem>
+                ))}
+            </List>
+        </div>
+    );
+};
+
+export default TrustedDevices;
+
+const useStyles = makeStyles((theme) => ({
+    container: {
+        marginTop: theme.spacing(2),
+        textAlign: "left",
+    },
+}));
@@ -0,0 +1,120 @@
+import React, { useState } from "react";
+
+import { Grid, makeStyles, Button, Typography } from "@material-ui/core";
+import { useHistory } from "react-router";
+
+import { useNotifications } from "../../../hooks/NotificationsContext";
+import LoginLayout from "../../../layouts/LoginLayout";
+import { LogoutRoute as SignOutRoute } from "../../../Routes";
+import { initiateTOTPRegistrationProcess, initiateU2FRegistrationProcess } from "../../../services/RegisterDevice";
+
+const EMAIL_SENT_NOTIFICATION = "An email has been sent to your address to complete the process.";
+
+export interface Props {
+    name: string;
+    // The time in seconds until which the user can skip the enrollment.
+    deadline?: number;
+
+    onSkip: () => void;
+}
+
+const EnrollmentView = function (props: Props) {
+    const style = useStyles();
+    const history = useHistory();
+    const { createInfoNotification, createErrorNotification } = useNotifications();
+    const [registrationInProgress, setRegistrationInProgress] = useState(false);
+
+    const deadline = props.deadline ? new Date(props.deadline * 1000) : undefined;
+    const canSkip = deadline !== undefined && deadline.getTime() > Date.now();
+
+    const initiateRegistration = (initiateRegistrationFunc: () => Promise<void>) => {
+        return async () => {
+            if (registrationInProgress) {
+                return;
+            }
+            setRegistrationInProgress(true);
+            try {
+                await initiateRegistrationFunc();
+                createInfoNotification(EMAIL_SENT_NOTIFICATION);
+            } catch (err) {
+                console.error(err);
+                createErrorNotification("There was a problem initiating the registration process");
+            }
+            setRegistrationInProgress(false);
+        };
+    };
+
+    const handleLogoutClick = () => {
+        history.push(SignOutRoute);
+    };
+
+    return (
+        <LoginLayout id="enrollment-stage" title={`Hi ${props.name}`} showBrand>
+            <Grid container>
+                <Grid item xs={12}>
+                    <Button color="secondary" onClick={handleLogoutClick} id="logout-button">
+                        Logout
+                    </Button>
+                </Grid>
+                <Grid item xs={12} className={style.mainContainer}>
+                    <Typography>You are required to register a second factor device.</Typography>
+                    {canSkip && deadline ? (
+                        <Typography id="enrollment-deadline">
+                            You can access your applications without it until {deadline.toLocaleString()}.
+                        </Typography>
+                    ) : (
+                        <Typography id="enrollment-deadline">
+                            The applications requiring a second factor are not accessible until you register one.
+                        </Typography>
+                    )}
+                </Grid>
+                <Grid item xs={12} className={style.buttons}>
+                    <Button
+                        id="register-totp-button"
+                        variant="contained"
+                        color="primary"
+                        fullWidth
+                        disabled={registrationInProgress}
+                        onClick={initiateRegistration(initiateTOTPRegistrationProcess)}
+                    >
+                        Register a one-time password app
+                    </Button>
+                </Grid>
+                <Grid item xs={12} className={style.buttons}>
+                    <Button
+                        id="register-security-key-button"
+                        variant="contained"
+                        color="primary"
+                        fullWidth
+                        disabled={registrationInProgress}
+                        onClick={initiateRegistration(initiateU2FRegistrationProcess)}
+                    >
+                        Register a security key
+                    </Button>
+                </Grid>
+                {canSkip ? (
+                    <Grid item xs={12} className={style.buttons}>
+                        <Button id="skip-enrollment-button" color="primary" fullWidth onClick={props.onSkip}>
+                            Later
+                        </Button>
+                    </Grid>
+                ) : null}
+            </Grid>
+        </LoginLayout>
+    );
+};
+
+export default EnrollmentView;
+
+const useStyles = makeStyles((theme) => ({
+    mainContainer: {
+        border: "1px solid #d6d6d6",
+        borderRadius: "10px",
+        padding: theme.spacing(4),
+        marginTop: theme.spacing(2),
+        marginBottom: theme.spacing(2),
+    },
+    buttons: {
+        marginBottom: theme.spacing(1),
+    },
+}));
@@ -11,11 +11,17 @@ import { useRequestMethod } from "../../../hooks/RequestMethod";
 import LoginLayout from "../../../layouts/LoginLayout";
 import { ResetPasswordStep1Route } from "../../../Routes";
 import { postFirstFactor } from "../../../services/FirstFactor";
+import {
+    isConditionalMediationAvailable,
+    signInWithConditionalPasskey,
+    signInWithPasskey,
+} from "../../../services/Passkey";
 
 export interface Props {
     disabled: boolean;
     rememberMe: boolean;
     resetPassword: boolean;
+    passkeys: boolean;
 
     onAuthenticationStart: () => void;
     onAuthenticationFailure: () => void;
@@ -42,6 +48,40 @@ const FirstFactorForm = function (props: Props) {
         return () => clearTimeout(timeout);
     }, [usernameRef]);
 
+    const { passkeys, onAuthenticationSuccess } = props;
+    const conditionalPasskeyRef = useRef<AbortController | null>(null);
+
+    // The passkeys are offered in the autofill of the username field for as long as the form is displayed.
+    /* eslint-disable react-hooks/exhaustive-deps */
+    useEffect(() => {
+        if (!passkeys) {
+            return;
+        }
+
+        const controller = new AbortController();
+        conditionalPasskeyRef.current = controller;
+
+        (async () => {
+            if (!(await isConditionalMediationAvailable())) {
+                return;
+            }
+
+            try {
+                const res = await signInWithConditionalPasskey(controller.signal, redirectionURL, requestMethod);
+                onAuthenticationSuccess(res ? res.redirect : undefined);
+            } catch (err) {
+                if (controller.signal.aborted) {
+                    return;
+                }
+                console.error(err);
+                createErrorNotification("There was an issue signing in with your passkey.");
+            }
+        })();
+
+        return () => controller.abort();
+    }, [passkeys, redirectionURL, requestMethod]);
+    /* eslint-enable react-hooks/exhaustive-deps */
+
     const disabled = props.disabled;
 
     const handleRememberMeChange = () => {
@@ -73,6 +113,21 @@ const FirstFactorForm = function (props: Props) {
         }
     };
 
+    const handlePasskeySignIn = async () => {
+        // The browsers only allow a single pending WebAuthn request.
+        conditionalPasskeyRef.current?.abort();
+
+        props.onAuthenticationStart();
+        try {
+            const res = await signInWithPasskey(redirectionURL, requestMethod);
+            props.onAuthenticationSuccess(res ? res.redirect : undefined);
+        } catch (err) {
+            console.error(err);
+            createErrorNotification("There was an issue signing in with your passkey.");
+            props.onAuthenticationFailure();
+        }
+    };
+
     const handleResetPasswordClick = () => {
         history.push(ResetPasswordStep1Route);
     };
@@ -95,6 +150,7 @@ const FirstFactorForm = function (props: Props) {
                         onChange={(v) => setUsername(v.target.value)}
                         onFocus={() => setUsernameError(false)}
                         autoCapitalize="none"
+                        autoComplete={props.passkeys ? "username webauthn" : "username"}
                         onKeyPress={(ev) => {
                             if (ev.key === "Enter") {
                                 if (!username.length) {
@@ -197,6 +253,20 @@ const FirstFactorForm = function (props: Props) {
                         Sign in
                     </Button>
                 </Grid>
+                {props.passkeys ? (
+                    <Grid item xs={12}>
+                        <Button
+                            id="passkey-sign-in-button"
+                            variant="outlined"
+                            color="primary"
+                            fullWidth
+                            disabled={disabled}
+                            onClick={handlePasskeySignIn}
+                        >
+                            Sign in with a passkey
+                        </Button>
+                    </Grid>
+                ) : null}
             </Grid>
         </LoginLayout>
     );
@@ -4,7 +4,7 @@ import { Switch, Route, Redirect, useHistory, useLocation } from "react-router";
 
 import { useConfiguration } from "../../hooks/Configuration";
 import { useNotifications } from "../../hooks/NotificationsContext";
-import { useRedirectionURL } from "../../hooks/RedirectionURL";
+import { useRedirectionDomain, useRedirectionURL } from "../../hooks/RedirectionURL";
 import { useRedirector } from "../../hooks/Redirector";
 import { useRequestMethod } from "../../hooks/RequestMethod";
 import { useAutheliaState } from "../../hooks/State";
@@ -16,30 +16,40 @@ import {
     SecondFactorTOTPRoute,
     SecondFactorPushRoute,
     SecondFactorU2FRoute,
+    SecondFactorPasswordRoute,
+    SecondFactorEmailRoute,
+    SecondFactorPushApprovalRoute,
+    SecondFactorPrivacyIDEARoute,
+    SecondFactorHOTPRoute,
+    SecondFactorYubiKeyOTPRoute,
+    SecondFactorEnrollmentRoute,
     AuthenticatedRoute,
 } from "../../Routes";
 import { AuthenticationLevel } from "../../services/State";
 import LoadingPage from "../LoadingPage/LoadingPage";
 import AuthenticatedView from "./AuthenticatedView/AuthenticatedView";
+import EnrollmentView from "./EnrollmentView/EnrollmentView";
 import FirstFactorForm from "./FirstFactor/FirstFactorForm";
 import SecondFactorForm from "./SecondFactor/SecondFactorForm";
 
 export interface Props {
     rememberMe: boolean;
     resetPassword: boolean;
+    passkeys: boolean;
 }
 
 const LoginPortal = function (props: Props) {
     const history = useHistory();
     const location = useLocation();
     const redirectionURL = useRedirectionURL();
+    const redirectionDomain = useRedirectionDomain();
     const requestMethod = useRequestMethod();
     const { createErrorNotification } = useNotifications();
     const [firstFactorDisabled, setFirstFactorDisabled] = useState(true);
     const redirector = useRedirector();
 
     const [state, fetchState, , fetchStateError] = useAutheliaState();
-    const [userInfo, fetchUserInfo, , fetchUserInfoError] = userUserInfo();
+    const [userInfo, fetchUserInfo, , fetchUserInfoError] = userUserInfo(redirectionDomain);
     const [configuration, fetchConfiguration, , fetchConfigurationError] = useConfiguration();
 
     const redirect = useCallback((url: string) => history.push(url), [history]);
@@ -96,13 +106,31 @@ const LoginPortal = function (props: Props) {
                 setFirstFactorDisabled(false);
                 redirect(`${FirstFactorRoute}${redirectionSuffix}`);
             } else if (state.authentication_level >= AuthenticationLevel.OneFactor && userInfo && configuration) {
-                if (!configuration.second_factor_enabled) {
+                if (state.password_required) {
+                    // The user signed in with a passkey used as a first factor.
+                    redirect(`${SecondFactorPasswordRoute}${redirectionSuffix}`);
+                } else if (!configuration.second_factor_enabled) {
                     redirect(AuthenticatedRoute);
+                } else if (
+                    state.authentication_level === AuthenticationLevel.OneFactor &&
+                    userInfo.enrollment_required
+                ) {
+                    redirect(`${SecondFactorEnrollmentRoute}${redirectionSuffix}`);
                 } else {
                     if (userInfo.method === SecondFactorMethod.U2F) {
                         redirect(`${SecondFactorU2FRoute}${redirectionSuffix}`);
                     } else if (userInfo.method === SecondFactorMethod.MobilePush) {
                         redirect(`${SecondFactorPushRoute}${redirectionSuffix}`);
+                    } else if (userInfo.method === SecondFactorMethod.Email) {
+                        redirect(`${SecondFactorEmailRoute}${redirectionSuffix}`);
+                    } else if (userInfo.method === SecondFactorMethod.PushApproval) {
+                        redirect(`${SecondFactorPushApprovalRoute}${redirectionSuffix}`);
+                    } else if (userInfo.method === SecondFactorMethod.PrivacyIDEA) {
+                        redirect(`${SecondFactorPrivacyIDEARoute}${redirectionSuffix}`);
+                    } else if (userInfo.method === SecondFactorMethod.HOTP) {
+                        redirect(`${SecondFactorHOTPRoute}${redirectionSuffix}`);
+                    } else if (userInfo.method === SecondFactorMethod.YubiKeyOTP) {
+                        redirect(`${SecondFactorYubiKeyOTPRoute}${redirectionSuffix}`);
                     } else {
                         redirect(`${SecondFactorTOTPRoute}${redirectionSuffix}`);
                     }
@@ -121,6 +149,15 @@ const LoginPortal = function (props: Props) {
         }
     };
 
+    // The users who postpone the enrollment of a second factor are redirected as if they were authenticated.
+    const handleEnrollmentSkip = () => {
+        if (redirectionURL) {
+            redirector(redirectionURL);
+        } else {
+            redirect(AuthenticatedRoute);
+        }
+    };
+
     const firstFactorReady =
         state !== undefined &&
         state.authentication_level === AuthenticationLevel.Unauthenticated &&
@@ -134,25 +171,43 @@ const LoginPortal = function (props: Props) {
                         disabled={firstFactorDisabled}
                         rememberMe={props.rememberMe}
                         resetPassword={props.resetPassword}
+                        passkeys={props.passkeys}
                         onAuthenticationStart={() => setFirstFactorDisabled(true)}
                         onAuthenticationFailure={() => setFirstFactorDisabled(false)}
                         onAuthenticationSuccess={handleAuthSuccess}
                     />
                 </ComponentOrLoading>
             </Route>
+            <Route path={SecondFactorEnrollmentRoute} exact>
+                {userInfo ? (
+                    <EnrollmentView
+                        name={userInfo.display_name}
+                        deadline={userInfo.enrollment_deadline}
+                        onSkip={handleEnrollmentSkip}
+                    />
+                ) : null}
+            </Route>
             <Route path={SecondFactorRoute}>
                 {state && userInfo && configuration ? (
                     <SecondFactorForm
                         authenticationLevel={state.authentication_level}
                         userInfo={userInfo}
                         configuration={configuration}
+                        domain={redirectionDomain}
                         onMethodChanged={() => fetchUserInfo()}
                         onAuthenticationSuccess={handleAuthSuccess}
                     />
                 ) : null}
             </Route>
             <Route path={AuthenticatedRoute} exact>
-                {userInfo ? <AuthenticatedView name={userInfo.display_name} /> : null}
+                {userInfo ? (
+                    <AuthenticatedView
+                        name={userInfo.display_name}
+                        passkeys={props.passkeys}
+                        trustedDevices={configuration ? configuration.trusted_devices_enabled : false}
+                        totp={userInfo.has_totp}
+                    />
+                ) : null}
             </Route>
             {/* By default we route to first factor page */}
             <Route path="/">
@@ -0,0 +1,129 @@
+import React, { useCallback, useEffect, useState } from "react";
+
+import { Button, Grid } from "@material-ui/core";
+
+import FixedTextField from "../../../components/FixedTextField";
+import { useIsMountedRef } from "../../../hooks/Mounted";
+import { useRedirectionURL } from "../../../hooks/RedirectionURL";
+import { completeEmailSignIn, initiateEmailSignIn } from "../../../services/EmailOneTimeCode";
+import { AuthenticationLevel } from "../../../services/State";
+import MethodContainer, { State as MethodContainerState } from "./MethodContainer";
+
+export interface Props {
+    id: string;
+    authenticationLevel: AuthenticationLevel;
+
+    onSignInError: (err: Error) => void;
+    onSignInSuccess: (redirectURL: string | undefined) => void;
+}
+
+const EmailMethod = function (props: Props) {
+    const [code, setCode] = useState("");
+    const [codeError, setCodeError] = useState(false);
+    const [inProgress, setInProgress] = useState(false);
+    const redirectionURL = useRedirectionURL();
+    const mounted = useIsMountedRef();
+
+    const { onSignInError } = props;
+    /* eslint-disable react-hooks/exhaustive-deps */
+    const onSignInErrorCallback = useCallback(onSignInError, []);
+    /* eslint-enable react-hooks/exhaustive-deps */
+
+    const sendCode = useCallback(async () => {
+        if (props.authenticationLevel === AuthenticationLevel.TwoFactor) {
+            return;
+        }
+
+        try {
+            await initiateEmailSignIn();
+        } catch (err) {
+            // The user might have changed 2FA method in the meantime.
+            if (!mounted.current) return;
+
+            console.error(err);
+            onSignInErrorCallback(new Error("There was an issue sending the one-time code to your email address"));
+        }
+    }, [onSignInErrorCallback, mounted, props.authenticationLevel]);
+
+    useEffect(() => {
+        sendCode();
+    }, [sendCode]);
+
+    const handleSignIn = async () => {
+        if (code === "") {
+            setCodeError(true);
+            return;
+        }
+
+        setInProgress(true);
+        try {
+            const res = await completeEmailSignIn(code, redirectionURL);
+            props.onSignInSuccess(res ? res.redirect : undefined);
+        } catch (err) {
+            console.error(err);
+            props.onSignInError(new Error("The one-time code might be wrong or expired"));
+        }
+        setCode("");
+        setInProgress(false);
+    };
+
+    const methodState =
+        props.authenticationLevel === AuthenticationLevel.TwoFactor
+            ? MethodContainerState.ALREADY_AUTHENTICATED
+            : MethodContainerState.METHOD;
+
+    return (
+        <MethodContainer
+            id={props.id}
+            title="Email"
+            explanation="Enter the one-time code sent to your email address"
+            registered={true}
+            state={methodState}
+        >
+            <Grid container spacing={2}>
+                <Grid item xs={12}>
+                    <FixedTextField
+                        id="email-code-textfield"
+                        label="One-time code"
+                        variant="outlined"
+                        required
+                        fullWidth
+                        autoFocus
+                        disabled={inProgress}
+                        value={code}
+                        error={codeError}
+                        onChange={(v) => setCode(v.target.value)}
+                        onFocus={() => setCodeError(false)}
+                        inputProps={{ inputMode: "numeric" }}
+                        autoComplete="one-time-code"
+                        onKeyPress={(ev) => {
+                            if (ev.key === "Enter") {
+                                handleSignIn();
+                                ev.preventDefault();
+                            }
+                        }}
+                    />
+                </Grid>
+                <Grid item xs={12}>
+                    <Button
+                        id="email-sign-in-button"
+                        variant="contained"
+                        color="primary"
+                        fullWidth
+                        disabled={inProgress}
+                        onClick={handleSignIn}
+                    >
+                        Sign in
+                    </Button>
+                </Grid>
+                <Grid item xs={12}>
+                    <Button id="email-resend-button" color="primary" disabled={inProgress} onClick={sendCode}>
+                        Send a new code
+                    </Button>
+                </Grid>
+            </Grid>
+        </MethodContainer>
+    );
+};
+
+export default EmailMethod;
@@ -0,0 +1,128 @@
+import React, { useState } from "react";
+
+import { Button, Grid, Link } from "@material-ui/core";
+
+import FixedTextField from "../../../components/FixedTextField";
+import { useRedirectionURL } from "../../../hooks/RedirectionURL";
+import { completeHOTPSignIn } from "../../../services/HOTP";
+import { AuthenticationLevel } from "../../../services/State";
+import MethodContainer, { State as MethodContainerState } from "./MethodContainer";
+
+export interface Props {
+    id: string;
+    authenticationLevel: AuthenticationLevel;
+
+    onSignInError: (err: Error) => void;
+    onSignInSuccess: (redirectURL: string | undefined) => void;
+}
+
+const HOTPMethod = function (props: Props) {
+    const [otp, setOTP] = useState("");
+    const [nextOTP, setNextOTP] = useState("");
+    const [otpError, setOTPError] = useState(false);
+    const [resync, setResync] = useState(false);
+    const [inProgress, setInProgress] = useState(false);
+    const redirectionURL = useRedirectionURL();
+
+    const handleSignIn = async () => {
+        if (otp === "" || (resync && nextOTP === "")) {
+            setOTPError(true);
+            return;
+        }
+
+        setInProgress(true);
+        try {
+            const res = await completeHOTPSignIn(otp, resync ? nextOTP : undefined, redirectionURL);
+            props.onSignInSuccess(res ? res.redirect : undefined);
+        } catch (err) {
+            console.error(err);
+            props.onSignInError(new Error("The one-time password might be wrong"));
+        }
+        setOTP("");
+        setNextOTP("");
+        setInProgress(false);
+    };
+
+    const handleKeyPress = (ev: React.KeyboardEvent) => {
+        if (ev.key === "Enter") {
+            handleSignIn();
+            ev.preventDefault();
+        }
+    };
+
+    let methodState = MethodContainerState.METHOD;
+    if (props.authenticationLevel === AuthenticationLevel.TwoFactor) {
+        methodState = MethodContainerState.ALREADY_AUTHENTICATED;
+    }
+
+    return (
+        <MethodContainer
+            id={props.id}
+            title="Hardware Token"
+            explanation={
+                resync
+                    ? "Press the button of your token twice and enter both one-time passwords"
+                    : "Press the button of your token and enter the one-time password"
+            }
+            registered={true}
+            state={methodState}
+        >
+            <Grid container spacing={2}>
+                <Grid item xs={12}>
+                    <FixedTextField
+                        id="hotp-otp-textfield"
+                        label={resync ? "First one-time password" : "One-time password"}
+                        variant="outlined"
+                        required
+                        fullWidth
+                        autoFocus
+                        disabled={inProgress}
+                        value={otp}
+                        error={otpError}
+                        onChange={(v) => setOTP(v.target.value)}
+                        onFocus={() => setOTPError(false)}
+                        autoComplete="one-time-code"
+                        onKeyPress={handleKeyPress}
+                    />
+                </Grid>
+                {resync ? (
+                    <Grid item xs={12}>
+                        <FixedTextField
+                            id="hotp-next-otp-textfield"
+                            label="Second one-time password"
+                            variant="outlined"
+                            required
+                            fullWidth
+                            disabled={inProgress}
+                            value={nextOTP}
+                            error={otpError}
+                            onChange={(v) => setNextOTP(v.target.value)}
+                            onFocus={() => setOTPError(false)}
+                            autoComplete="one-time-code"
+                            onKeyPress={handleKeyPress}
+                        />
+                    </Grid>
+                ) : null}
+                <Grid item xs={12}>
+                    <Button
+                        id="hotp-sign-in-button"
+                        variant="contained"
+                        color="primary"
+                        fullWidth
+                        disabled={inProgress}
+                        onClick={handleSignIn}
+                    >
+                        Sign in
+                    </Button>
+                </Grid>
+                <Grid item xs={12}>
+                    <Link component="button" id="hotp-resync-link" onClick={() => setResync(!resync)}>
+                        {resync ? "Enter a single one-time password" : "Token out of sync?"}
+                    </Link>
+                </Grid>
+            </Grid>
+        </MethodContainer>
+    );
+};
+
+export default HOTPMethod;
@@ -1,12 +1,17 @@
-import React, { ReactNode } from "react";
+import React, { ReactNode, useState } from "react";
 
+import { faEnvelope } from "@fortawesome/free-regular-svg-icons";
+import { faKey } from "@fortawesome/free-solid-svg-icons";
+import { FontAwesomeIcon } from "@fortawesome/react-fontawesome";
 import {
     Dialog,
     Grid,
     makeStyles,
     DialogContent,
     Button,
+    Checkbox,
     DialogActions,
+    FormControlLabel,
     Typography,
     useTheme,
 } from "@material-ui/core";
@@ -20,14 +25,16 @@ export interface Props {
     open: boolean;
     methods: Set<SecondFactorMethod>;
     u2fSupported: boolean;
+    domain?: string;
 
     onClose: () => void;
-    onClick: (method: SecondFactorMethod) => void;
+    onClick: (method: SecondFactorMethod, domainOnly: boolean) => void;
 }
 
 const MethodSelectionDialog = function (props: Props) {
     const style = useStyles();
     const theme = useTheme();
+    const [domainOnly, setDomainOnly] = useState(false);
 
     const pieChartIcon = (
         <TimerIcon width={24} height={24} period={15} color={theme.palette.primary.main} backgroundColor={"white"} />
@@ -42,7 +49,7 @@ const MethodSelectionDialog = function (props: Props) {
                             id="one-time-password-option"
                             method="Time-based One-Time Password"
                             icon={pieChartIcon}
-                            onClick={() => props.onClick(SecondFactorMethod.TOTP)}
+                            onClick={() => props.onClick(SecondFactorMethod.TOTP, domainOnly)}
                         />
                     ) : null}
                     {props.methods.has(SecondFactorMethod.U2F) && props.u2fSupported ? (
@@ -50,7 +57,7 @@ const MethodSelectionDialog = function (props: Props) {
                             id="security-key-option"
                             method="Security Key - U2F"
                             icon={<FingerTouchIcon size={32} />}
-                            onClick={() => props.onClick(SecondFactorMethod.U2F)}
+                            onClick={() => props.onClick(SecondFactorMethod.U2F, domainOnly)}
                         />
                     ) : null}
                     {props.methods.has(SecondFactorMethod.MobilePush) ? (
@@ -58,10 +65,63 @@ const MethodSelectionDialog = function (props: Props) {
                             id="push-notification-option"
                             method="Push Notification"
                             icon={<PushNotificationIcon width={32} height={32} />}
-                            onClick={() => props.onClick(SecondFactorMethod.MobilePush)}
+                            onClick={() => props.onClick(SecondFactorMethod.MobilePush, domainOnly)}
+                        />
+                    ) : null}
+                    {props.methods.has(SecondFactorMethod.Email) ? (
+                        <MethodItem
+                            id="email-option"
+                            method="Email"
+                            icon={<FontAwesomeIcon icon={faEnvelope} size="2x" />}
+                            onClick={() => props.onClick(SecondFactorMethod.Email, domainOnly)}
+                        />
+                    ) : null}
+                    {props.methods.has(SecondFactorMethod.PushApproval) ? (
+                        <MethodItem
+                            id="push-approval-option"
+                            method="Push Approval"
+                            icon={<PushNotificationIcon width={32} height={32} />}
+                            onClick={() => props.onClick(SecondFactorMethod.PushApproval, domainOnly)}
+                        />
+                    ) : null}
+                    {props.methods.has(SecondFactorMethod.PrivacyIDEA) ? (
+                        <MethodItem
+                            id="privacyidea-option"
+                            method="privacyIDEA"
+                            icon={pieChartIcon}
+                            onClick={() => props.onClick(SecondFactorMethod.PrivacyIDEA, domainOnly)}
+                        />
+                    ) : null}
+                    {props.methods.has(SecondFactorMethod.HOTP) ? (
+                        <MethodItem
+                            id="hotp-option"
+                            method="Hardware Token"
+                            icon={<FontAwesomeIcon icon={faKey} size="2x" />}
+                            onClick={() => props.onClick(SecondFactorMethod.HOTP, domainOnly)}
+                        />
+                    ) : null}
+                    {props.methods.has(SecondFactorMethod.YubiKeyOTP) ? (
+                        <MethodItem
+                            id="yubikey-otp-option"
+                            method="YubiKey OTP"
+                            icon={<FingerTouchIcon size={32} />}
+                            onClick={() => props.onClick(SecondFactorMethod.YubiKeyOTP, domainOnly)}
                         />
                     ) : null}
                 </Grid>
+                {props.domain ? (
+                    <FormControlLabel
+                        control={
+                            <Checkbox
+                                id="domain-only-checkbox"
+                                checked={domainOnly}
+                                onChange={() => setDomainOnly(!domainOnly)}
+                                color="primary"
+                            />
+                        }
+                        label={`Only for ${props.domain}`}
+                    />
+                ) : null}
             </DialogContent>
             <DialogActions>
                 <Button color="primary" onClick={props.onClose}>
@@ -0,0 +1,97 @@
+import React, { useState } from "react";
+
+import { Button, Grid } from "@material-ui/core";
+
+import FixedTextField from "../../../components/FixedTextField";
+import { useRedirectionURL } from "../../../hooks/RedirectionURL";
+import { completePasswordSignIn } from "../../../services/Password";
+import { AuthenticationLevel } from "../../../services/State";
+import MethodContainer, { State as MethodContainerState } from "./MethodContainer";
+
+export interface Props {
+    id: string;
+    authenticationLevel: AuthenticationLevel;
+
+    onSignInError: (err: Error) => void;
+    onSignInSuccess: (redirectURL: string | undefined) => void;
+}
+
+const PasswordMethod = function (props: Props) {
+    const [password, setPassword] = useState("");
+    const [passwordError, setPasswordError] = useState(false);
+    const [inProgress, setInProgress] = useState(false);
+    const redirectionURL = useRedirectionURL();
+
+    const handleSignIn = async () => {
+        if (password === "") {
+            setPasswordError(true);
+            return;
+        }
+
+        setInProgress(true);
+        try {
+            const res = await completePasswordSignIn(password, redirectionURL);
+            props.onSignInSuccess(res ? res.redirect : undefined);
+        } catch (err) {
+            console.error(err);
+            props.onSignInError(new Error("The password might be wrong"));
+        }
+        setPassword("");
+        setInProgress(false);
+    };
+
+    const methodState =
+        props.authenticationLevel === AuthenticationLevel.TwoFactor
+            ? MethodContainerState.ALREADY_AUTHENTICATED
+            : MethodContainerState.METHOD;
+
+    return (
+        <MethodContainer
+            id={props.id}
+            title="Password"
+            explanation="Enter your password to complete the sign in with your passkey"
+            registered={true}
+            state={methodState}
+        >
+            <Grid container spacing={2}>
+                <Grid item xs={12}>
+                    <FixedTextField
+                        id="password-textfield"
+                        label="Password"
+                        variant="outlined"
+                        required
+                        fullWidth
+                        autoFocus
+                        disabled={inProgress}
+                        value={password}
+                        error={passwordError}
+                        onChange={(v) => setPassword(v.target.value)}
+                        onFocus={() => setPasswordError(false)}
+                        type="password"
+                        autoComplete="current-password"
+                        onKeyPress={(ev) => {
+                            if (ev.key === "Enter") {
+                                handleSignIn();
+                                ev.preventDefault();
+                            }
+                        }}
+                    />
+                </Grid>
+                <Grid item xs={12}>
+                    <Button
+                        id="password-sign-in-button"
+                        variant="contained"
+                        color="primary"
+                        fullWidth
+                        disabled={inProgress}
+                        onClick={handleSignIn}
+                    >
+                        Sign in
+                    </Button>
+                </Grid>
+            </Grid>
+        </MethodContainer>
+    );
+};
+
+export default PasswordMethod;
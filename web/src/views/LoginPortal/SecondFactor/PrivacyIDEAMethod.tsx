@@ -0,0 +1,160 @@
+import React, { useEffect, useState } from "react";
+
+import { Button, Grid } from "@material-ui/core";
+
+import FixedTextField from "../../../components/FixedTextField";
+import { useIsMountedRef } from "../../../hooks/Mounted";
+import { useRedirectionURL } from "../../../hooks/RedirectionURL";
+import {
+    completePrivacyIDEAPushSignIn,
+    completePrivacyIDEASignIn,
+    getPrivacyIDEATokens,
+    PrivacyIDEATokens,
+} from "../../../services/PrivacyIDEA";
+import { AuthenticationLevel } from "../../../services/State";
+import MethodContainer, { State as MethodContainerState } from "./MethodContainer";
+
+const PUSH_TOKEN_TYPE = "push";
+
+export interface Props {
+    id: string;
+    authenticationLevel: AuthenticationLevel;
+
+    onSignInError: (err: Error) => void;
+    onSignInSuccess: (redirectURL: string | undefined) => void;
+}
+
+const PrivacyIDEAMethod = function (props: Props) {
+    const [tokens, setTokens] = useState<PrivacyIDEATokens | undefined>(undefined);
+    const [otp, setOTP] = useState("");
+    const [otpError, setOTPError] = useState(false);
+    const [inProgress, setInProgress] = useState(false);
+    const redirectionURL = useRedirectionURL();
+    const mounted = useIsMountedRef();
+
+    const { onSignInError } = props;
+
+    useEffect(() => {
+        if (props.authenticationLevel === AuthenticationLevel.TwoFactor) {
+            return;
+        }
+
+        getPrivacyIDEATokens().then(
+            (tokens) => {
+                if (mounted.current) setTokens(tokens);
+            },
+            (err) => {
+                console.error(err);
+                onSignInError(new Error("There was an issue retrieving your privacyIDEA tokens"));
+            },
+        );
+        // eslint-disable-next-line react-hooks/exhaustive-deps
+    }, [props.authenticationLevel, mounted]);
+
+    const handleSignIn = async () => {
+        if (otp === "") {
+            setOTPError(true);
+            return;
+        }
+
+        setInProgress(true);
+        try {
+            const res = await completePrivacyIDEASignIn(otp, redirectionURL);
+            props.onSignInSuccess(res ? res.redirect : undefined);
+        } catch (err) {
+            console.error(err);
+            props.onSignInError(new Error("The one-time password might be wrong"));
+        }
+        setOTP("");
+        setInProgress(false);
+    };
+
+    const handlePushSignIn = async () => {
+        setInProgress(true);
+        try {
+            const res = await completePrivacyIDEAPushSignIn(redirectionURL);
+            props.onSignInSuccess(res ? res.redirect : undefined);
+        } catch (err) {
+            console.error(err);
+            props.onSignInError(new Error("The push notification was not accepted"));
+        }
+        if (mounted.current) setInProgress(false);
+    };
+
+    let methodState = MethodContainerState.METHOD;
+    if (props.authenticationLevel === AuthenticationLevel.TwoFactor) {
+        methodState = MethodContainerState.ALREADY_AUTHENTICATED;
+    } else if (tokens && !tokens.enrolled) {
+        methodState = MethodContainerState.NOT_REGISTERED;
+    }
+
+    const hasPush = tokens ? tokens.types.includes(PUSH_TOKEN_TYPE) : false;
+    const hasOTP = tokens ? tokens.types.some((type) => type !== PUSH_TOKEN_TYPE) : true;
+
+    return (
+        <MethodContainer
+            id={props.id}
+            title="privacyIDEA"
+            explanation="Enter the one-time password of one of your tokens or accept a push notification"
+            registered={tokens ? tokens.enrolled : true}
+            state={methodState}
+        >
+            <Grid container spacing={2}>
+                {hasOTP ? (
+                    <Grid item xs={12}>
+                        <FixedTextField
+                            id="privacyidea-otp-textfield"
+                            label="One-time password"
+                            variant="outlined"
+                            required
+                            fullWidth
+                            autoFocus
+                            disabled={inProgress}
+                            value={otp}
+                            error={otpError}
+                            onChange={(v) => setOTP(v.target.value)}
+                            onFocus={() => setOTPError(false)}
+                            autoComplete="one-time-code"
+                            onKeyPress={(ev) => {
+                                if (ev.key === "Enter") {
+                                    handleSignIn();
+                                    ev.preventDefault();
+                                }
+                            }}
+                        />
+                    </Grid>
+                ) : null}
+                {hasOTP ? (
+                    <Grid item xs={12}>
+                        <Button
+                            id="privacyidea-sign-in-button"
+                            variant="contained"
+                            color="primary"
+                            fullWidth
+                            disabled={inProgress}
+                            onClick={handleSignIn}
+                        >
+                            Sign in
+                        </Button>
+                    </Grid>
+                ) : null}
+                {hasPush ? (
+                    <Grid item xs={12}>
+                        <Button
+                            id="privacyidea-push-button"
+                            color="primary"
+                            variant={hasOTP ? "text" : "contained"}
+                            fullWidth
+                            disabled={inProgress}
+                            onClick={handlePushSignIn}
+                        >
+                            Send a push notification
+                        </Button>
+                    </Grid>
+                ) : null}
+            </Grid>
+        </MethodContainer>
+    );
+};
+
+export default PrivacyIDEAMethod;
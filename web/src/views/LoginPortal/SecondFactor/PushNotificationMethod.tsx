@@ -7,7 +7,7 @@ import PushNotificationIcon from "../../../components/PushNotificationIcon";
 import SuccessIcon from "../../../components/SuccessIcon";
 import { useIsMountedRef } from "../../../hooks/Mounted";
 import { useRedirectionURL } from "../../../hooks/RedirectionURL";
-import { completePushNotificationSignIn } from "../../../services/PushNotification";
+import { completePushNotificationSignIn, initiateDuoUniversalSignIn } from "../../../services/PushNotification";
 import { AuthenticationLevel } from "../../../services/State";
 import MethodContainer, { State as MethodContainerState } from "./MethodContainer";
 
@@ -20,6 +20,8 @@ export enum State {
 export interface Props {
     id: string;
     authenticationLevel: AuthenticationLevel;
+    // Whether the user is redirected to the Duo Universal Prompt instead of receiving a push notification.
+    universalPrompt: boolean;
 
     onSignInError: (err: Error) => void;
     onSignInSuccess: (redirectURL: string | undefined) => void;
@@ -38,7 +40,7 @@ const PushNotificationMethod = function (props: Props) {
     /* eslint-enable react-hooks/exhaustive-deps */
 
     const signInFunc = useCallback(async () => {
-        if (props.authenticationLevel === AuthenticationLevel.TwoFactor) {
+        if (props.authenticationLevel === AuthenticationLevel.TwoFactor || props.universalPrompt) {
             return;
         }
 
@@ -63,7 +65,28 @@ const PushNotificationMethod = function (props: Props) {
             onSignInErrorCallback(new Error("There was an issue completing sign in process"));
             setState(State.Failure);
         }
-    }, [onSignInErrorCallback, onSignInSuccessCallback, setState, redirectionURL, mounted, props.authenticationLevel]);
+    }, [
+        onSignInErrorCallback,
+        onSignInSuccessCallback,
+        setState,
+        redirectionURL,
+        mounted,
+        props.authenticationLevel,
+        props.universalPrompt,
+    ]);
+
+    // The user is only redirected to Duo on click so that a failed attempt sending the user back to the portal does not
+    // redirect the user again.
+    const handleUniversalPrompt = async () => {
+        try {
+            setState(State.SignInInProgress);
+            window.location.href = await initiateDuoUniversalSignIn(redirectionURL);
+        } catch (err) {
+            console.error(err);
+            onSignInErrorCallback(new Error("Duo is unavailable, please try again later"));
+            setState(State.Failure);
+        }
+    };
 
     useEffect(() => {
         signInFunc();
@@ -93,6 +116,27 @@ const PushNotificationMethod = function (props: Props) {
         methodState = MethodContainerState.ALREADY_AUTHENTICATED;
     }
 
+    if (props.universalPrompt) {
+        return (
+            <MethodContainer
+                id={props.id}
+                title="Duo"
+                explanation="You will be redirected to Duo to complete the sign in process"
+                registered={true}
+                state={methodState}
+            >
+                <Button
+                    id="duo-universal-prompt-button"
+                    variant="contained"
+                    color="primary"
+                    onClick={handleUniversalPrompt}
+                >
+                    Sign in with Duo
+                </Button>
+            </MethodContainer>
+        );
+    }
+
     return (
         <MethodContainer
             id={props.id}
@@ -0,0 +1,96 @@
+import React, { useState } from "react";
+
+import { Button, Grid } from "@material-ui/core";
+
+import FixedTextField from "../../../components/FixedTextField";
+import { useRedirectionURL } from "../../../hooks/RedirectionURL";
+import { completeRecoveryCodeSignIn } from "../../../services/RecoveryCodes";
+import { AuthenticationLevel } from "../../../services/State";
+import MethodContainer, { State as MethodContainerState } from "./MethodContainer";
+
+export interface Props {
+    id: string;
+    authenticationLevel: AuthenticationLevel;
+
+    onSignInError: (err: Error) => void;
+    onSignInSuccess: (redirectURL: string | undefined) => void;
+}
+
+const RecoveryCodeMethod = function (props: Props) {
+    const [code, setCode] = useState("");
+    const [codeError, setCodeError] = useState(false);
+    const [inProgress, setInProgress] = useState(false);
+    const redirectionURL = useRedirectionURL();
+
+    const handleSignIn = async () => {
+        if (code === "") {
+            setCodeError(true);
+            return;
+        }
+
+        setInProgress(true);
+        try {
+            const res = await completeRecoveryCodeSignIn(code, redirectionURL);
+            props.onSignInSuccess(res ? res.redirect : undefined);
+        } catch (err) {
+            console.error(err);
+            props.onSignInError(new Error("The recovery code might be wrong or already used"));
+        }
+        setCode("");
+        setInProgress(false);
+    };
+
+    const methodState =
+        props.authenticationLevel === AuthenticationLevel.TwoFactor
+            ? MethodContainerState.ALREADY_AUTHENTICATED
+            : MethodContainerState.METHOD;
+
+    return (
+        <MethodContainer
+            id={props.id}
+            title="Recovery code"
+            explanation="Enter one of your recovery codes, each of them can only be used once"
+            registered={true}
+            state={methodState}
+        >
+            <Grid container spacing={2}>
+                <Grid item xs={12}>
+                    <FixedTextField
+                        id="recovery-code-textfield"
+                        label="Recovery code"
+                        variant="outlined"
+                        required
+                        fullWidth
+                        autoFocus
+                        disabled={inProgress}
+                        value={code}
+                        error={codeError}
+                        onChange={(v) => setCode(v.target.value)}
+                        onFocus={() => setCodeError(false)}
+                        autoComplete="one-time-code"
+                        onKeyPress={(ev) => {
+                            if (ev.key === "Enter") {
+                                handleSignIn();
+                                ev.preventDefault();
+                            }
+                        }}
+                    />
+                </Grid>
+                <Grid item xs={12}>
+                    <Button
+                        id="recovery-code-sign-in-button"
+                        variant="contained"
+                        color="primary"
+                        fullWidth
+                        disabled={inProgress}
+                        onClick={handleSignIn}
+                    >
+                        Sign in
+                    </Button>
+                </Grid>
+            </Grid>
+        </MethodContainer>
+    );
+};
+
+export default RecoveryCodeMethod;
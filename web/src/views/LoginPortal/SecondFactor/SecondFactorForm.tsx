@@ -1,7 +1,7 @@
 import React, { useState, useEffect } from "react";
 
-import { Grid, makeStyles, Button } from "@material-ui/core";
-import { useHistory, Switch, Route, Redirect } from "react-router";
+import { Grid, makeStyles, Button, FormControlLabel, Checkbox } from "@material-ui/core";
+import { useHistory, useLocation, Switch, Route, Redirect } from "react-router";
 import u2fApi from "u2f-api";
 
 import { useNotifications } from "../../../hooks/NotificationsContext";
@@ -14,23 +14,62 @@ import {
     SecondFactorTOTPRoute,
     SecondFactorPushRoute,
     SecondFactorU2FRoute,
+    SecondFactorPasswordRoute,
+    SecondFactorRecoveryCodeRoute,
+    SecondFactorEmailRoute,
+    SecondFactorPushApprovalRoute,
+    SecondFactorPrivacyIDEARoute,
+    SecondFactorHOTPRoute,
+    SecondFactorYubiKeyOTPRoute,
     SecondFactorRoute,
 } from "../../../Routes";
 import { initiateTOTPRegistrationProcess, initiateU2FRegistrationProcess } from "../../../services/RegisterDevice";
 import { AuthenticationLevel } from "../../../services/State";
+import { trustDevice } from "../../../services/TrustedDevices";
 import { setPreferred2FAMethod } from "../../../services/UserPreferences";
+import EmailMethod from "./EmailMethod";
+import HOTPMethod from "./HOTPMethod";
 import MethodSelectionDialog from "./MethodSelectionDialog";
 import OneTimePasswordMethod from "./OneTimePasswordMethod";
+import PasswordMethod from "./PasswordMethod";
+import PrivacyIDEAMethod from "./PrivacyIDEAMethod";
+import PushApprovalMethod from "./PushApprovalMethod";
 import PushNotificationMethod from "./PushNotificationMethod";
+import RecoveryCodeMethod from "./RecoveryCodeMethod";
 import SecurityKeyMethod from "./SecurityKeyMethod";
+import YubiKeyOTPMethod from "./YubiKeyOTPMethod";
 
 const EMAIL_SENT_NOTIFICATION = "An email has been sent to your address to complete the process.";
 
+// The routes and the names of the methods the users can fall back to.
+const methodRoutes = new Map<SecondFactorMethod, string>([
+    [SecondFactorMethod.TOTP, SecondFactorTOTPRoute],
+    [SecondFactorMethod.U2F, SecondFactorU2FRoute],
+    [SecondFactorMethod.MobilePush, SecondFactorPushRoute],
+    [SecondFactorMethod.Email, SecondFactorEmailRoute],
+    [SecondFactorMethod.PushApproval, SecondFactorPushApprovalRoute],
+    [SecondFactorMethod.PrivacyIDEA, SecondFactorPrivacyIDEARoute],
+    [SecondFactorMethod.HOTP, SecondFactorHOTPRoute],
+    [SecondFactorMethod.YubiKeyOTP, SecondFactorYubiKeyOTPRoute],
+]);
+
+const methodNames = new Map<SecondFactorMethod, string>([
+    [SecondFactorMethod.TOTP, "Time-based One-Time Password"],
+    [SecondFactorMethod.U2F, "Security Key - U2F"],
+    [SecondFactorMethod.MobilePush, "Push Notification"],
+    [SecondFactorMethod.Email, "Email"],
+    [SecondFactorMethod.PushApproval, "Push Approval"],
+    [SecondFactorMethod.PrivacyIDEA, "privacyIDEA"],
+    [SecondFactorMethod.HOTP, "Hardware Token"],
+    [SecondFactorMethod.YubiKeyOTP, "YubiKey OTP"],
+]);
+
 export interface Props {
     authenticationLevel: AuthenticationLevel;
 
     userInfo: UserInfo;
     configuration: Configuration;
+    domain?: string;
 
     onMethodChanged: (method: SecondFactorMethod) => void;
     onAuthenticationSuccess: (redirectURL: string | undefined) => void;
@@ -39,10 +78,12 @@ export interface Props {
 const SecondFactorForm = function (props: Props) {
     const style = useStyles();
     const history = useHistory();
+    const location = useLocation();
     const [methodSelectionOpen, setMethodSelectionOpen] = useState(false);
     const { createInfoNotification, createErrorNotification } = useNotifications();
     const [registrationInProgress, setRegistrationInProgress] = useState(false);
     const [u2fSupported, setU2fSupported] = useState(false);
+    const [trustThisDevice, setTrustThisDevice] = useState(false);
 
     // Check that U2F is supported.
     useEffect(() => {
@@ -73,9 +114,9 @@ const SecondFactorForm = function (props: Props) {
         setMethodSelectionOpen(true);
     };
 
-    const handleMethodSelected = async (method: SecondFactorMethod) => {
+    const handleMethodSelected = async (method: SecondFactorMethod, domainOnly: boolean) => {
         try {
-            await setPreferred2FAMethod(method);
+            await setPreferred2FAMethod(method, domainOnly ? props.domain : undefined);
             setMethodSelectionOpen(false);
             props.onMethodChanged(method);
         } catch (err) {
@@ -84,16 +125,49 @@ const SecondFactorForm = function (props: Props) {
         }
     };
 
+    // The device is trusted once the second factor succeeded, a failure doesn't prevent the user from being redirected.
+    const handleAuthenticationSuccess = async (redirectURL: string | undefined) => {
+        if (trustThisDevice) {
+            try {
+                await trustDevice();
+            } catch (err) {
+                console.error(err);
+                createErrorNotification("There was an issue trusting this device");
+            }
+        }
+        props.onAuthenticationSuccess(redirectURL);
+    };
+
     const handleLogoutClick = () => {
         history.push(SignOutRoute);
     };
 
+    // The redirection URL is kept in the query string of the route.
+    const handleRecoveryCodeClick = () => {
+        history.push(`${SecondFactorRecoveryCodeRoute}${location.search}`);
+    };
+
+    // The next method of the configured order is offered when the current one is unavailable.
+    const fallbackMethods = props.userInfo.fallback_methods;
+    const currentMethodIndex = fallbackMethods.findIndex((method) => methodRoutes.get(method) === location.pathname);
+    const nextMethod =
+        currentMethodIndex >= 0 && currentMethodIndex + 1 < fallbackMethods.length
+            ? fallbackMethods[currentMethodIndex + 1]
+            : undefined;
+
+    const handleFallbackClick = () => {
+        if (nextMethod !== undefined) {
+            history.push(`${methodRoutes.get(nextMethod)}${location.search}`);
+        }
+    };
+
     return (
         <LoginLayout id="second-factor-stage" title={`Hi ${props.userInfo.display_name}`} showBrand>
             <MethodSelectionDialog
                 open={methodSelectionOpen}
                 methods={props.configuration.available_methods}
                 u2fSupported={u2fSupported}
+                domain={props.domain}
                 onClose={() => setMethodSelectionOpen(false)}
                 onClick={handleMethodSelected}
             />
@@ -106,6 +180,10 @@ const SecondFactorForm = function (props: Props) {
                     <Button color="secondary" onClick={handleMethodSelectionClick} id="methods-button">
                         Methods
                     </Button>
+                    {" | "}
+                    <Button color="secondary" onClick={handleRecoveryCodeClick} id="recovery-code-button">
+                        Recovery code
+                    </Button>
                 </Grid>
                 <Grid item xs={12} className={style.methodContainer}>
                     <Switch>
@@ -118,7 +196,7 @@ const SecondFactorForm = function (props: Props) {
                                 totp_period={props.configuration.totp_period}
                                 onRegisterClick={initiateRegistration(initiateTOTPRegistrationProcess)}
                                 onSignInError={(err) => createErrorNotification(err.message)}
-                                onSignInSuccess={props.onAuthenticationSuccess}
+                                onSignInSuccess={handleAuthenticationSuccess}
                             />
                         </Route>
                         <Route path={SecondFactorU2FRoute} exact>
@@ -129,22 +207,102 @@ const SecondFactorForm = function (props: Props) {
                                 registered={props.userInfo.has_u2f}
                                 onRegisterClick={initiateRegistration(initiateU2FRegistrationProcess)}
                                 onSignInError={(err) => createErrorNotification(err.message)}
-                                onSignInSuccess={props.onAuthenticationSuccess}
+                                onSignInSuccess={handleAuthenticationSuccess}
                             />
                         </Route>
                         <Route path={SecondFactorPushRoute} exact>
                             <PushNotificationMethod
                                 id="push-notification-method"
                                 authenticationLevel={props.authenticationLevel}
+                                universalPrompt={props.configuration.duo_universal_prompt}
+                                onSignInError={(err) => createErrorNotification(err.message)}
+                                onSignInSuccess={handleAuthenticationSuccess}
+                            />
+                        </Route>
+                        <Route path={SecondFactorPushApprovalRoute} exact>
+                            <PushApprovalMethod
+                                id="push-approval-method"
+                                authenticationLevel={props.authenticationLevel}
+                                onSignInError={(err) => createErrorNotification(err.message)}
+                                onSignInSuccess={handleAuthenticationSuccess}
+                            />
+                        </Route>
+                        <Route path={SecondFactorPrivacyIDEARoute} exact>
+                            <PrivacyIDEAMethod
+                                id="privacyidea-method"
+                                authenticationLevel={props.authenticationLevel}
+                                onSignInError={(err) => createErrorNotification(err.message)}
+                                onSignInSuccess={handleAuthenticationSuccess}
+                            />
+                        </Route>
+                        <Route path={SecondFactorHOTPRoute} exact>
+                            <HOTPMethod
+                                id="hotp-method"
+                                authenticationLevel={props.authenticationLevel}
+                                onSignInError={(err) => createErrorNotification(err.message)}
+                                onSignInSuccess={props.onAuthenticationSuccess}
+                            />
+                        </Route>
+                        <Route path={SecondFactorYubiKeyOTPRoute} exact>
+                            <YubiKeyOTPMethod
+                                id="yubikey-otp-method"
+                                authenticationLevel={props.authenticationLevel}
                                 onSignInError={(err) => createErrorNotification(err.message)}
                                 onSignInSuccess={props.onAuthenticationSuccess}
                             />
                         </Route>
+                        <Route path={SecondFactorPasswordRoute} exact>
+                            <PasswordMethod
+                                id="password-method"
+                                authenticationLevel={props.authenticationLevel}
+                                onSignInError={(err) => createErrorNotification(err.message)}
+                                onSignInSuccess={handleAuthenticationSuccess}
+                            />
+                        </Route>
+                        <Route path={SecondFactorEmailRoute} exact>
+                            <EmailMethod
+                                id="email-method"
+                                authenticationLevel={props.authenticationLevel}
+                                onSignInError={(err) => createErrorNotification(err.message)}
+                                onSignInSuccess={handleAuthenticationSuccess}
+                            />
+                        </Route>
+                        <Route path={SecondFactorRecoveryCodeRoute} exact>
+                            <RecoveryCodeMethod
+                                id="recovery-code-method"
+                                authenticationLevel={props.authenticationLevel}
+                                onSignInError={(err) => createErrorNotification(err.message)}
+                                onSignInSuccess={handleAuthenticationSuccess}
+                            />
+                        </Route>
                         <Route path={SecondFactorRoute}>
                             <Redirect to={SecondFactorTOTPRoute} />
                         </Route>
                     </Switch>
                 </Grid>
+                {nextMethod !== undefined ? (
+                    <Grid item xs={12}>
+                        <Button color="primary" onClick={handleFallbackClick} id="fallback-method-button">
+                            Use {methodNames.get(nextMethod)} instead
+                        </Button>
+                    </Grid>
+                ) : null}
+                {props.configuration.trusted_devices_enabled &&
+                props.authenticationLevel < AuthenticationLevel.TwoFactor ? (
+                    <Grid item xs={12}>
+                        <FormControlLabel
+                            control={
+                                <Checkbox
+                                    id="trust-device-checkbox"
+                                    checked={trustThisDevice}
+                                    onChange={() => setTrustThisDevice(!trustThisDevice)}
+                                    color="primary"
+                                />
+                            }
+                            label="Remember this device"
+                        />
+                    </Grid>
+                ) : null}
             </Grid>
         </LoginLayout>
     );
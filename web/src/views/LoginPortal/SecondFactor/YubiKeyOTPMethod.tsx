@@ -0,0 +1,97 @@
+import React, { useState } from "react";
+
+import { Button, Grid } from "@material-ui/core";
+
+import FixedTextField from "../../../components/FixedTextField";
+import { useRedirectionURL } from "../../../hooks/RedirectionURL";
+import { AuthenticationLevel } from "../../../services/State";
+import { completeYubiKeyOTPSignIn } from "../../../services/YubiKeyOTP";
+import MethodContainer, { State as MethodContainerState } from "./MethodContainer";
+
+export interface Props {
+    id: string;
+    authenticationLevel: AuthenticationLevel;
+
+    onSignInError: (err: Error) => void;
+    onSignInSuccess: (redirectURL: string | undefined) => void;
+}
+
+const YubiKeyOTPMethod = function (props: Props) {
+    const [otp, setOTP] = useState("");
+    const [otpError, setOTPError] = useState(false);
+    const [inProgress, setInProgress] = useState(false);
+    const redirectionURL = useRedirectionURL();
+
+    const handleSignIn = async () => {
+        if (otp === "") {
+            setOTPError(true);
+            return;
+        }
+
+        setInProgress(true);
+        try {
+            const res = await completeYubiKeyOTPSignIn(otp, redirectionURL);
+            props.onSignInSuccess(res ? res.redirect : undefined);
+        } catch (err) {
+            console.error(err);
+            props.onSignInError(new Error("The one-time password might be wrong or already used"));
+        }
+        setOTP("");
+        setInProgress(false);
+    };
+
+    let methodState = MethodContainerState.METHOD;
+    if (props.authenticationLevel === AuthenticationLevel.TwoFactor) {
+        methodState = MethodContainerState.ALREADY_AUTHENTICATED;
+    }
+
+    return (
+        <MethodContainer
+            id={props.id}
+            title="YubiKey OTP"
+            explanation="Select the field below and touch your YubiKey"
+            registered={true}
+            state={methodState}
+        >
+            <Grid container spacing={2}>
+                <Grid item xs={12}>
+                    <FixedTextField
+                        id="yubikey-otp-textfield"
+                        label="One-time password"
+                        variant="outlined"
+                        required
+                        fullWidth
+                        autoFocus
+                        disabled={inProgress}
+                        value={otp}
+                        error={otpError}
+                        onChange={(v) => setOTP(v.target.value)}
+                        onFocus={() => setOTPError(false)}
+                        autoComplete="off"
+                        onKeyPress={(ev) => {
+                            // The YubiKey types Enter after the one-time password.
+                            if (ev.key === "Enter") {
+                                handleSignIn();
+                                ev.preventDefault();
+                            }
+                        }}
+                    />
+                </Grid>
+                <Grid item xs={12}>
+                    <Button
+                        id="yubikey-otp-sign-in-button"
+                        variant="contained"
+                        color="primary"
+                        fullWidth
+                        disabled={inProgress}
+                        onClick={handleSignIn}
+                    >
+                        Sign in
+                    </Button>
+                </Grid>
+            </Grid>
+        </MethodContainer>
+    );
+};
+
+export default YubiKeyOTPMethod;